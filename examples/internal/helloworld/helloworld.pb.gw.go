@@ -63,6 +63,11 @@ func request_Greeter_SayHello_0(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -96,6 +101,11 @@ func local_request_Greeter_SayHello_0(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -133,6 +143,11 @@ func request_Greeter_SayHello_1(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -166,6 +181,11 @@ func local_request_Greeter_SayHello_1(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -203,6 +223,11 @@ func request_Greeter_SayHello_2(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -236,6 +261,11 @@ func local_request_Greeter_SayHello_2(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -273,6 +303,11 @@ func request_Greeter_SayHello_3(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -306,6 +341,11 @@ func local_request_Greeter_SayHello_3(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -343,6 +383,11 @@ func request_Greeter_SayHello_4(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -376,6 +421,11 @@ func local_request_Greeter_SayHello_4(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -413,6 +463,11 @@ func request_Greeter_SayHello_5(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -446,6 +501,11 @@ func local_request_Greeter_SayHello_5(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -483,6 +543,11 @@ func request_Greeter_SayHello_6(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -516,6 +581,11 @@ func local_request_Greeter_SayHello_6(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -553,6 +623,11 @@ func request_Greeter_SayHello_7(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -586,6 +661,11 @@ func local_request_Greeter_SayHello_7(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -623,6 +703,11 @@ func request_Greeter_SayHello_8(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -656,6 +741,11 @@ func local_request_Greeter_SayHello_8(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -693,6 +783,11 @@ func request_Greeter_SayHello_9(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.SayHello(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -726,6 +821,11 @@ func local_request_Greeter_SayHello_9(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.SayHello(ctx, &protoReq)
 	return msg, metadata, err
 
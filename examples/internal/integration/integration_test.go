@@ -1507,7 +1507,7 @@ func TestUnknownPath(t *testing.T) {
 	}
 }
 
-func TestNotImplemented(t *testing.T) {
+func TestMethodNotAllowed(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
 		return
@@ -1526,7 +1526,7 @@ func TestNotImplemented(t *testing.T) {
 		return
 	}
 
-	if got, want := resp.StatusCode, http.StatusNotImplemented; got != want {
+	if got, want := resp.StatusCode, http.StatusMethodNotAllowed; got != want {
 		t.Errorf("resp.StatusCode = %d; want %d", got, want)
 		t.Logf("%s", buf)
 	}
@@ -256,6 +256,11 @@ func request_ABitOfEverythingService_Create_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Create(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -478,6 +483,11 @@ func local_request_ABitOfEverythingService_Create_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Create(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -495,6 +505,11 @@ func request_ABitOfEverythingService_CreateBody_0(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CreateBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -512,6 +527,11 @@ func local_request_ABitOfEverythingService_CreateBody_0(ctx context.Context, mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CreateBody(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -557,6 +577,11 @@ func request_ABitOfEverythingService_CreateBook_0(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CreateBook(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -598,6 +623,11 @@ func local_request_ABitOfEverythingService_CreateBook_0(ctx context.Context, mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CreateBook(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -624,6 +654,11 @@ func request_ABitOfEverythingService_Lookup_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Lookup(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -650,6 +685,11 @@ func local_request_ABitOfEverythingService_Lookup_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Lookup(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -684,6 +724,11 @@ func request_ABitOfEverythingService_Update_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Update(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -718,6 +763,11 @@ func local_request_ABitOfEverythingService_Update_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Update(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -763,6 +813,11 @@ func request_ABitOfEverythingService_UpdateV2_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.UpdateV2(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -804,6 +859,11 @@ func local_request_ABitOfEverythingService_UpdateV2_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.UpdateV2(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -856,6 +916,11 @@ func request_ABitOfEverythingService_UpdateV2_1(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.UpdateV2(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -904,6 +969,11 @@ func local_request_ABitOfEverythingService_UpdateV2_1(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.UpdateV2(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -938,6 +1008,11 @@ func request_ABitOfEverythingService_UpdateV2_2(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "abe.uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.UpdateV2(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -972,6 +1047,11 @@ func local_request_ABitOfEverythingService_UpdateV2_2(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "abe.uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.UpdateV2(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -998,6 +1078,11 @@ func request_ABitOfEverythingService_Delete_0(ctx context.Context, marshaler run
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Delete(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1024,6 +1109,11 @@ func local_request_ABitOfEverythingService_Delete_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "uuid", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Delete(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1061,6 +1151,11 @@ func request_ABitOfEverythingService_GetQuery_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.GetQuery(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1094,6 +1189,11 @@ func local_request_ABitOfEverythingService_GetQuery_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.GetQuery(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1277,6 +1377,11 @@ func request_ABitOfEverythingService_GetRepeatedQuery_0(ctx context.Context, mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "path_repeated_sint64_value", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.GetRepeatedQuery(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1460,6 +1565,11 @@ func local_request_ABitOfEverythingService_GetRepeatedQuery_0(ctx context.Contex
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "path_repeated_sint64_value", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.GetRepeatedQuery(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1486,6 +1596,11 @@ func request_ABitOfEverythingService_Echo_0(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "value", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1512,6 +1627,11 @@ func local_request_ABitOfEverythingService_Echo_0(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "value", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1529,6 +1649,11 @@ func request_ABitOfEverythingService_Echo_1(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1546,6 +1671,11 @@ func local_request_ABitOfEverythingService_Echo_1(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1566,6 +1696,11 @@ func request_ABitOfEverythingService_Echo_2(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1582,6 +1717,11 @@ func local_request_ABitOfEverythingService_Echo_2(ctx context.Context, marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1616,6 +1756,11 @@ func request_ABitOfEverythingService_DeepPathEcho_0(ctx context.Context, marshal
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "single_nested.name", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.DeepPathEcho(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1650,6 +1795,11 @@ func local_request_ABitOfEverythingService_DeepPathEcho_0(ctx context.Context, m
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "single_nested.name", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.DeepPathEcho(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1659,6 +1809,11 @@ func request_ABitOfEverythingService_Timeout_0(ctx context.Context, marshaler ru
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Timeout(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1668,6 +1823,11 @@ func local_request_ABitOfEverythingService_Timeout_0(ctx context.Context, marsha
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Timeout(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1677,6 +1837,11 @@ func request_ABitOfEverythingService_ErrorWithDetails_0(ctx context.Context, mar
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.ErrorWithDetails(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1686,6 +1851,11 @@ func local_request_ABitOfEverythingService_ErrorWithDetails_0(ctx context.Contex
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.ErrorWithDetails(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1720,6 +1890,11 @@ func request_ABitOfEverythingService_GetMessageWithBody_0(ctx context.Context, m
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "id", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.GetMessageWithBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1754,6 +1929,11 @@ func local_request_ABitOfEverythingService_GetMessageWithBody_0(ctx context.Cont
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "id", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.GetMessageWithBody(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1788,6 +1968,11 @@ func request_ABitOfEverythingService_PostWithEmptyBody_0(ctx context.Context, ma
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "name", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.PostWithEmptyBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1822,6 +2007,11 @@ func local_request_ABitOfEverythingService_PostWithEmptyBody_0(ctx context.Conte
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "name", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.PostWithEmptyBody(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1859,6 +2049,11 @@ func request_ABitOfEverythingService_CheckGetQueryParams_0(ctx context.Context,
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CheckGetQueryParams(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1892,6 +2087,11 @@ func local_request_ABitOfEverythingService_CheckGetQueryParams_0(ctx context.Con
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CheckGetQueryParams(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -1937,6 +2137,11 @@ func request_ABitOfEverythingService_CheckNestedEnumGetQueryParams_0(ctx context
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CheckNestedEnumGetQueryParams(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -1978,6 +2183,11 @@ func local_request_ABitOfEverythingService_CheckNestedEnumGetQueryParams_0(ctx c
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CheckNestedEnumGetQueryParams(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -2023,6 +2233,11 @@ func request_ABitOfEverythingService_CheckPostQueryParams_0(ctx context.Context,
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CheckPostQueryParams(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -2064,6 +2279,11 @@ func local_request_ABitOfEverythingService_CheckPostQueryParams_0(ctx context.Co
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CheckPostQueryParams(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -2073,6 +2293,11 @@ func request_ABitOfEverythingService_OverwriteResponseContentType_0(ctx context.
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.OverwriteResponseContentType(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -2082,6 +2307,11 @@ func local_request_ABitOfEverythingService_OverwriteResponseContentType_0(ctx co
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.OverwriteResponseContentType(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -2111,6 +2341,11 @@ func request_ABitOfEverythingService_CheckExternalPathEnum_0(ctx context.Context
 
 	protoReq.Value = pathenum.PathEnum(e)
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CheckExternalPathEnum(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -2140,6 +2375,11 @@ func local_request_ABitOfEverythingService_CheckExternalPathEnum_0(ctx context.C
 
 	protoReq.Value = pathenum.PathEnum(e)
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CheckExternalPathEnum(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -2169,6 +2409,11 @@ func request_ABitOfEverythingService_CheckExternalNestedPathEnum_0(ctx context.C
 
 	protoReq.Value = pathenum.MessagePathEnum_NestedPathEnum(e)
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.CheckExternalNestedPathEnum(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -2198,6 +2443,11 @@ func local_request_ABitOfEverythingService_CheckExternalNestedPathEnum_0(ctx con
 
 	protoReq.Value = pathenum.MessagePathEnum_NestedPathEnum(e)
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.CheckExternalNestedPathEnum(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -2207,6 +2457,11 @@ func request_CamelCaseServiceName_Empty_0(ctx context.Context, marshaler runtime
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Empty(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -2216,6 +2471,11 @@ func local_request_CamelCaseServiceName_Empty_0(ctx context.Context, marshaler r
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Empty(ctx, &protoReq)
 	return msg, metadata, err
 
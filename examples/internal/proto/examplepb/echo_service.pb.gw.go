@@ -63,6 +63,11 @@ func request_EchoService_Echo_0(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -96,6 +101,11 @@ func local_request_EchoService_Echo_0(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -143,6 +153,11 @@ func request_EchoService_Echo_1(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -186,6 +201,11 @@ func local_request_EchoService_Echo_1(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -248,6 +268,11 @@ func request_EchoService_Echo_2(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -306,6 +331,11 @@ func local_request_EchoService_Echo_2(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -368,6 +398,11 @@ func request_EchoService_Echo_3(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -426,6 +461,11 @@ func local_request_EchoService_Echo_3(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -463,6 +503,11 @@ func request_EchoService_Echo_4(ctx context.Context, marshaler runtime.Marshaler
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -496,6 +541,11 @@ func local_request_EchoService_Echo_4(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -513,6 +563,11 @@ func request_EchoService_EchoBody_0(ctx context.Context, marshaler runtime.Marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.EchoBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -530,6 +585,11 @@ func local_request_EchoService_EchoBody_0(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.EchoBody(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -550,6 +610,11 @@ func request_EchoService_EchoDelete_0(ctx context.Context, marshaler runtime.Mar
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.EchoDelete(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -566,6 +631,11 @@ func local_request_EchoService_EchoDelete_0(ctx context.Context, marshaler runti
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.EchoDelete(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -601,6 +671,11 @@ func request_EchoService_EchoPatch_0(ctx context.Context, marshaler runtime.Mars
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.EchoPatch(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -632,6 +707,11 @@ func local_request_EchoService_EchoPatch_0(ctx context.Context, marshaler runtim
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.EchoPatch(ctx, &protoReq)
 	return msg, metadata, err
 
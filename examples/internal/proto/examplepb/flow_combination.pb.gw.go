@@ -35,6 +35,11 @@ func request_FlowCombination_RpcEmptyRpc_0(ctx context.Context, marshaler runtim
 	var protoReq EmptyProto
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcEmptyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -44,6 +49,11 @@ func local_request_FlowCombination_RpcEmptyRpc_0(ctx context.Context, marshaler
 	var protoReq EmptyProto
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcEmptyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -53,6 +63,11 @@ func request_FlowCombination_RpcEmptyStream_0(ctx context.Context, marshaler run
 	var protoReq EmptyProto
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcEmptyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -68,6 +83,7 @@ func request_FlowCombination_RpcEmptyStream_0(ctx context.Context, marshaler run
 
 func request_FlowCombination_StreamEmptyRpc_0(ctx context.Context, marshaler runtime.Marshaler, client FlowCombinationClient, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.StreamEmptyRpc(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -112,6 +128,7 @@ func request_FlowCombination_StreamEmptyRpc_0(ctx context.Context, marshaler run
 
 func request_FlowCombination_StreamEmptyStream_0(ctx context.Context, marshaler runtime.Marshaler, client FlowCombinationClient, req *http.Request, pathParams map[string]string) (FlowCombination_StreamEmptyStreamClient, runtime.ServerMetadata, error) {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.StreamEmptyStream(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -174,6 +191,11 @@ func request_FlowCombination_RpcBodyRpc_0(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -191,6 +213,11 @@ func local_request_FlowCombination_RpcBodyRpc_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -237,6 +264,11 @@ func request_FlowCombination_RpcBodyRpc_1(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "c", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -283,6 +315,11 @@ func local_request_FlowCombination_RpcBodyRpc_1(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "c", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -303,6 +340,11 @@ func request_FlowCombination_RpcBodyRpc_2(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -319,6 +361,11 @@ func local_request_FlowCombination_RpcBodyRpc_2(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -363,6 +410,11 @@ func request_FlowCombination_RpcBodyRpc_3(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -407,6 +459,11 @@ func local_request_FlowCombination_RpcBodyRpc_3(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -435,6 +492,11 @@ func request_FlowCombination_RpcBodyRpc_4(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -459,6 +521,11 @@ func local_request_FlowCombination_RpcBodyRpc_4(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -504,6 +571,11 @@ func request_FlowCombination_RpcBodyRpc_5(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -545,6 +617,11 @@ func local_request_FlowCombination_RpcBodyRpc_5(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -582,6 +659,11 @@ func request_FlowCombination_RpcBodyRpc_6(ctx context.Context, marshaler runtime
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcBodyRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -615,6 +697,11 @@ func local_request_FlowCombination_RpcBodyRpc_6(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcBodyRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -652,6 +739,11 @@ func request_FlowCombination_RpcPathSingleNestedRpc_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcPathSingleNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -685,6 +777,11 @@ func local_request_FlowCombination_RpcPathSingleNestedRpc_0(ctx context.Context,
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcPathSingleNestedRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -740,6 +837,11 @@ func request_FlowCombination_RpcPathNestedRpc_0(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -791,6 +893,11 @@ func local_request_FlowCombination_RpcPathNestedRpc_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcPathNestedRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -828,6 +935,11 @@ func request_FlowCombination_RpcPathNestedRpc_1(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -861,6 +973,11 @@ func local_request_FlowCombination_RpcPathNestedRpc_1(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcPathNestedRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -906,6 +1023,11 @@ func request_FlowCombination_RpcPathNestedRpc_2(ctx context.Context, marshaler r
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.RpcPathNestedRpc(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -947,6 +1069,11 @@ func local_request_FlowCombination_RpcPathNestedRpc_2(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.RpcPathNestedRpc(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -964,6 +1091,11 @@ func request_FlowCombination_RpcBodyStream_0(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1018,6 +1150,11 @@ func request_FlowCombination_RpcBodyStream_1(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "c", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1046,6 +1183,11 @@ func request_FlowCombination_RpcBodyStream_2(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1098,6 +1240,11 @@ func request_FlowCombination_RpcBodyStream_3(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", "b", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1134,6 +1281,11 @@ func request_FlowCombination_RpcBodyStream_4(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1187,6 +1339,11 @@ func request_FlowCombination_RpcBodyStream_5(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1232,6 +1389,11 @@ func request_FlowCombination_RpcBodyStream_6(ctx context.Context, marshaler runt
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcBodyStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1277,6 +1439,11 @@ func request_FlowCombination_RpcPathSingleNestedStream_0(ctx context.Context, ma
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcPathSingleNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1340,6 +1507,11 @@ func request_FlowCombination_RpcPathNestedStream_0(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1385,6 +1557,11 @@ func request_FlowCombination_RpcPathNestedStream_1(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -1438,6 +1615,11 @@ func request_FlowCombination_RpcPathNestedStream_2(ctx context.Context, marshale
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.RpcPathNestedStream(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -43,6 +43,11 @@ func request_GenerateUnboundMethodsEchoService_Echo_0(ctx context.Context, marsh
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.Echo(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -60,6 +65,11 @@ func local_request_GenerateUnboundMethodsEchoService_Echo_0(ctx context.Context,
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.Echo(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -77,6 +87,11 @@ func request_GenerateUnboundMethodsEchoService_EchoBody_0(ctx context.Context, m
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.EchoBody(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -94,6 +109,11 @@ func local_request_GenerateUnboundMethodsEchoService_EchoBody_0(ctx context.Cont
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.EchoBody(ctx, &protoReq)
 	return msg, metadata, err
 
@@ -111,6 +131,11 @@ func request_GenerateUnboundMethodsEchoService_EchoDelete_0(ctx context.Context,
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := client.EchoDelete(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
 	return msg, metadata, err
 
@@ -128,6 +153,11 @@ func local_request_GenerateUnboundMethodsEchoService_EchoDelete_0(ctx context.Co
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.EchoDelete(ctx, &protoReq)
 	return msg, metadata, err
 
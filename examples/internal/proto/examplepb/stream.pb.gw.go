@@ -35,6 +35,7 @@ var _ = metadata.Join
 
 func request_StreamService_BulkCreate_0(ctx context.Context, marshaler runtime.Marshaler, client StreamServiceClient, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.BulkCreate(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -81,6 +82,11 @@ func request_StreamService_List_0(ctx context.Context, marshaler runtime.Marshal
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.List(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -96,6 +102,7 @@ func request_StreamService_List_0(ctx context.Context, marshaler runtime.Marshal
 
 func request_StreamService_BulkEcho_0(ctx context.Context, marshaler runtime.Marshaler, client StreamServiceClient, req *http.Request, pathParams map[string]string) (StreamService_BulkEchoClient, runtime.ServerMetadata, error) {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.BulkEcho(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -150,6 +157,11 @@ func request_StreamService_Download_0(ctx context.Context, marshaler runtime.Mar
 	var protoReq empty.Empty
 	var metadata runtime.ServerMetadata
 
+	if err := runtime.ModifyRequest(ctx, &protoReq); err != nil {
+		return nil, metadata, err
+	}
+
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.Download(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -0,0 +1,58 @@
+package codegenerator
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// The editions fields of the code generator messages are newer than the
+// pluginpb package the generators are built with, so they are set and read in
+// the unknown fields of the messages.
+const (
+	// featureSupportsEditions is the FEATURE_SUPPORTS_EDITIONS feature of
+	// google.protobuf.compiler.CodeGeneratorResponse.
+	featureSupportsEditions = 2
+
+	// minimumEdition and maximumEdition are the numbers of the
+	// minimum_edition and maximum_edition fields of
+	// google.protobuf.compiler.CodeGeneratorResponse.
+	minimumEdition protowire.Number = 3
+	maximumEdition protowire.Number = 4
+
+	// edition2023 is the EDITION_2023 value of google.protobuf.Edition.
+	edition2023 = 1000
+)
+
+// SetSupportedFeatures declares in resp that the generators support proto3
+// optional fields and protobuf editions, up to edition 2023.
+func SetSupportedFeatures(resp *pluginpb.CodeGeneratorResponse) {
+	features := uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) | featureSupportsEditions
+	resp.SupportedFeatures = proto.Uint64(resp.GetSupportedFeatures() | features)
+
+	var b []byte
+	b = protowire.AppendTag(b, minimumEdition, protowire.VarintType)
+	b = protowire.AppendVarint(b, edition2023)
+	b = protowire.AppendTag(b, maximumEdition, protowire.VarintType)
+	b = protowire.AppendVarint(b, edition2023)
+	m := resp.ProtoReflect()
+	m.SetUnknown(append(m.GetUnknown(), b...))
+}
+
+// EditionsAsProto2 returns a copy of req where the files using protobuf
+// editions are declared as proto2 files, which their descriptors are
+// compatible with, for the libraries which do not support editions yet.
+func EditionsAsProto2(req *pluginpb.CodeGeneratorRequest) *pluginpb.CodeGeneratorRequest {
+	var files []*descriptorpb.FileDescriptorProto
+	for _, f := range req.GetProtoFile() {
+		if f.GetSyntax() == "editions" {
+			f = proto.Clone(f).(*descriptorpb.FileDescriptorProto)
+			f.Syntax = proto.String("proto2")
+		}
+		files = append(files, f)
+	}
+	clone := proto.Clone(req).(*pluginpb.CodeGeneratorRequest)
+	clone.ProtoFile = files
+	return clone
+}
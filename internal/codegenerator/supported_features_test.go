@@ -0,0 +1,68 @@
+package codegenerator_test
+
+import (
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/codegenerator"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestSetSupportedFeatures(t *testing.T) {
+	resp := &pluginpb.CodeGeneratorResponse{}
+	codegenerator.SetSupportedFeatures(resp)
+	if got, want := resp.GetSupportedFeatures(), uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL); got&want == 0 {
+		t.Errorf("resp.GetSupportedFeatures() = %d; want the FEATURE_PROTO3_OPTIONAL bit %d", got, want)
+	}
+	if got, want := resp.GetSupportedFeatures(), uint64(2); got&want == 0 {
+		t.Errorf("resp.GetSupportedFeatures() = %d; want the FEATURE_SUPPORTS_EDITIONS bit %d", got, want)
+	}
+
+	editions := map[protowire.Number]uint64{}
+	b := resp.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.VarintType {
+			t.Fatalf("unexpected unknown fields %x", resp.ProtoReflect().GetUnknown())
+		}
+		b = b[n:]
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			t.Fatalf("unexpected unknown fields %x", resp.ProtoReflect().GetUnknown())
+		}
+		b = b[n:]
+		editions[num] = v
+	}
+	// minimum_edition and maximum_edition are EDITION_2023.
+	if got, want := editions[3], uint64(1000); got != want {
+		t.Errorf("minimum_edition = %d; want %d", got, want)
+	}
+	if got, want := editions[4], uint64(1000); got != want {
+		t.Errorf("maximum_edition = %d; want %d", got, want)
+	}
+}
+
+func TestEditionsAsProto2(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"a.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			{Name: proto.String("a.proto"), Syntax: proto.String("editions")},
+			{Name: proto.String("b.proto"), Syntax: proto.String("proto3")},
+		},
+	}
+	got := codegenerator.EditionsAsProto2(req)
+	if syntax := got.GetProtoFile()[0].GetSyntax(); syntax != "proto2" {
+		t.Errorf("got.GetProtoFile()[0].GetSyntax() = %q; want %q", syntax, "proto2")
+	}
+	if syntax := got.GetProtoFile()[1].GetSyntax(); syntax != "proto3" {
+		t.Errorf("got.GetProtoFile()[1].GetSyntax() = %q; want %q", syntax, "proto3")
+	}
+	if syntax := req.GetProtoFile()[0].GetSyntax(); syntax != "editions" {
+		t.Errorf("req.GetProtoFile()[0].GetSyntax() = %q; want %q, the request unchanged", syntax, "editions")
+	}
+	if files := got.GetFileToGenerate(); len(files) != 1 || files[0] != "a.proto" {
+		t.Errorf("got.GetFileToGenerate() = %q; want %q", files, []string{"a.proto"})
+	}
+}
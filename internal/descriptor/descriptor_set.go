@@ -0,0 +1,58 @@
+package descriptor
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadFileDescriptorSet loads the messages, enumerations and fields of the
+// files of "set", e.g. to resolve the types referenced by a gRPC API
+// configuration spanning several modules. Sets must be loaded before Load,
+// whose request files replace the files of the same name.
+//
+// A file may be in several sets, as long as its definitions are the same,
+// ignoring source code info. It is an error otherwise.
+func (r *Registry) LoadFileDescriptorSet(set *descriptorpb.FileDescriptorSet) error {
+	for _, file := range set.GetFile() {
+		loaded, ok := r.files[file.GetName()]
+		if !ok {
+			r.loadFile(file)
+			continue
+		}
+		if !sameFileDefinition(loaded.FileDescriptorProto, file) {
+			return fmt.Errorf("conflicting definitions of file %q in the file descriptor sets", file.GetName())
+		}
+		if loaded.SourceCodeInfo == nil {
+			loaded.SourceCodeInfo = file.GetSourceCodeInfo()
+		}
+	}
+	return nil
+}
+
+// LoadFileDescriptorSetFromFile loads the binary FileDescriptorSet in
+// "path", e.g. built with `protoc -o` or `buf build -o`, as
+// LoadFileDescriptorSet does.
+func (r *Registry) LoadFileDescriptorSetFromFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file descriptor set from '%v': %v", path, err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return fmt.Errorf("failed to parse file descriptor set from '%v': %v", path, err)
+	}
+	return r.LoadFileDescriptorSet(&set)
+}
+
+// sameFileDefinition determines if "a" and "b" define the same file, ignoring
+// their source code info.
+func sameFileDefinition(a, b *descriptorpb.FileDescriptorProto) bool {
+	a = proto.Clone(a).(*descriptorpb.FileDescriptorProto)
+	a.SourceCodeInfo = nil
+	b = proto.Clone(b).(*descriptorpb.FileDescriptorProto)
+	b.SourceCodeInfo = nil
+	return proto.Equal(a, b)
+}
@@ -0,0 +1,141 @@
+package descriptor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func loadFileDescriptorSet(t *testing.T, src string) *descriptorpb.FileDescriptorSet {
+	var set descriptorpb.FileDescriptorSet
+	if err := prototext.Unmarshal([]byte(src), &set); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s, &set) failed with %v; want success", src, err)
+	}
+	return &set
+}
+
+const (
+	firstModuleSet = `
+		file <
+			name: 'common.proto'
+			package: 'common'
+			message_type <
+				name: 'Common'
+			>
+		>
+		file <
+			name: 'a.proto'
+			package: 'a'
+			dependency: 'common.proto'
+			message_type <
+				name: 'A'
+				field <
+					name: 'common'
+					number: 1
+					label: LABEL_OPTIONAL
+					type: TYPE_MESSAGE
+					type_name: '.common.Common'
+				>
+			>
+		>
+	`
+	secondModuleSet = `
+		file <
+			name: 'common.proto'
+			package: 'common'
+			message_type <
+				name: 'Common'
+			>
+			source_code_info <
+				location <
+					path: 4
+					path: 0
+					leading_comments: 'The common message.'
+				>
+			>
+		>
+		file <
+			name: 'b.proto'
+			package: 'b'
+			message_type <
+				name: 'B'
+			>
+		>
+	`
+)
+
+func TestLoadFileDescriptorSets(t *testing.T) {
+	reg := NewRegistry()
+	for _, src := range []string{firstModuleSet, secondModuleSet} {
+		if err := reg.LoadFileDescriptorSet(loadFileDescriptorSet(t, src)); err != nil {
+			t.Fatalf("reg.LoadFileDescriptorSet(%s) failed with %v; want success", src, err)
+		}
+	}
+	for _, name := range []string{".common.Common", ".a.A", ".b.B"} {
+		if _, err := reg.LookupMsg("", name); err != nil {
+			t.Errorf("reg.LookupMsg(%q, %q) failed with %v; want success", "", name, err)
+		}
+	}
+	// The source code info of the duplicate is kept.
+	f, err := reg.LookupFile("common.proto")
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "common.proto", err)
+	}
+	if f.GetSourceCodeInfo() == nil {
+		t.Errorf("f.GetSourceCodeInfo() = nil; want the source code info of the second set")
+	}
+}
+
+func TestLoadFileDescriptorSetConflict(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.LoadFileDescriptorSet(loadFileDescriptorSet(t, firstModuleSet)); err != nil {
+		t.Fatalf("reg.LoadFileDescriptorSet(%s) failed with %v; want success", firstModuleSet, err)
+	}
+	conflicting := loadFileDescriptorSet(t, `
+		file <
+			name: 'common.proto'
+			package: 'common'
+			message_type <
+				name: 'Other'
+			>
+		>
+	`)
+	err := reg.LoadFileDescriptorSet(conflicting)
+	if err == nil || !strings.Contains(err.Error(), `"common.proto"`) {
+		t.Errorf("reg.LoadFileDescriptorSet(%v) = %v; want a conflict on %q", conflicting, err, "common.proto")
+	}
+}
+
+func TestLoadFileDescriptorSetFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "descriptor_set")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() failed with %v; want success", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := proto.Marshal(loadFileDescriptorSet(t, firstModuleSet))
+	if err != nil {
+		t.Fatalf("proto.Marshal() failed with %v; want success", err)
+	}
+	path := filepath.Join(dir, "a.pb")
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) failed with %v; want success", path, err)
+	}
+
+	reg := NewRegistry()
+	if err := reg.LoadFileDescriptorSetFromFile(path); err != nil {
+		t.Fatalf("reg.LoadFileDescriptorSetFromFile(%q) failed with %v; want success", path, err)
+	}
+	if _, err := reg.LookupMsg("", ".a.A"); err != nil {
+		t.Errorf("reg.LookupMsg(%q, %q) failed with %v; want success", "", ".a.A", err)
+	}
+	if err := reg.LoadFileDescriptorSetFromFile(filepath.Join(dir, "missing.pb")); err == nil {
+		t.Errorf("reg.LoadFileDescriptorSetFromFile(%q) succeeded; want an error", "missing.pb")
+	}
+}
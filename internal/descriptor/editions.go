@@ -0,0 +1,155 @@
+package descriptor
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The editions fields of the descriptors are newer than the descriptorpb
+// package the registry is built with, so they are read from the unknown
+// fields of the descriptors.
+const (
+	// syntaxEditions is the syntax of the files using protobuf editions.
+	syntaxEditions = "editions"
+
+	// fileOptionsFeatures, messageOptionsFeatures and fieldOptionsFeatures
+	// are the numbers of the google.protobuf.FeatureSet features fields of the
+	// file, message and field options.
+	fileOptionsFeatures    protowire.Number = 50
+	messageOptionsFeatures protowire.Number = 12
+	fieldOptionsFeatures   protowire.Number = 21
+
+	// featureSetFieldPresence is the number of the field_presence field of
+	// google.protobuf.FeatureSet.
+	featureSetFieldPresence protowire.Number = 1
+)
+
+// fieldPresence is the google.protobuf.FeatureSet.FieldPresence feature of
+// protobuf editions.
+type fieldPresence int32
+
+const (
+	fieldPresenceUnknown fieldPresence = iota
+	fieldPresenceExplicit
+	fieldPresenceImplicit
+	fieldPresenceLegacyRequired
+)
+
+// editions determines if the file uses protobuf editions.
+func (f *File) editions() bool {
+	return f.GetSyntax() == syntaxEditions
+}
+
+// fieldPresence returns the field presence of the fields of the file, which is
+// explicit unless the features of the file override it.
+func (f *File) fieldPresence() fieldPresence {
+	if p := featuresFieldPresence(f.GetOptions(), fileOptionsFeatures); p != fieldPresenceUnknown {
+		return p
+	}
+	return fieldPresenceExplicit
+}
+
+// messageFieldPresence returns the field presence of the fields of md, given
+// the presence of its outer message or file.
+func messageFieldPresence(md *descriptorpb.DescriptorProto, outer fieldPresence) fieldPresence {
+	if p := featuresFieldPresence(md.GetOptions(), messageOptionsFeatures); p != fieldPresenceUnknown {
+		return p
+	}
+	return outer
+}
+
+// explicitPresence determines if the Go field generated for the field tracks
+// its presence, i.e. is a pointer to a scalar value, as in proto2 files, for
+// proto3 optional fields and for the fields of editions files with explicit or
+// legacy required presence. Repeated fields and oneof members of editions files
+// never do.
+func (f *Field) explicitPresence() bool {
+	if f.GetProto3Optional() {
+		return true
+	}
+	file := f.Message.File
+	if !file.editions() {
+		return file.proto2()
+	}
+	if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED || f.inOneof() {
+		return false
+	}
+	presence := featuresFieldPresence(f.GetOptions(), fieldOptionsFeatures)
+	if presence == fieldPresenceUnknown {
+		presence = f.Message.presence
+	}
+	return presence != fieldPresenceImplicit
+}
+
+// featuresFieldPresence returns the field presence set in the features of
+// opts, read from its unknown field with the given number.
+func featuresFieldPresence(opts proto.Message, number protowire.Number) fieldPresence {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return fieldPresenceUnknown
+	}
+	presence := fieldPresenceUnknown
+	// A message field may be split in several records, which are merged.
+	for _, features := range unknownBytes(opts.ProtoReflect().GetUnknown(), number) {
+		for _, v := range unknownVarints(features, featureSetFieldPresence) {
+			presence = fieldPresence(v)
+		}
+	}
+	return presence
+}
+
+// unknownBytes returns the values of the length-delimited unknown fields with
+// the given number in b.
+func unknownBytes(b []byte, number protowire.Number) [][]byte {
+	var values [][]byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+		if num == number && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return values
+			}
+			values = append(values, v)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+	}
+	return values
+}
+
+// unknownVarints returns the values of the varint fields with the given
+// number in b.
+func unknownVarints(b []byte, number protowire.Number) []uint64 {
+	var values []uint64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+		if num == number && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return values
+			}
+			values = append(values, v)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return values
+		}
+		b = b[n:]
+	}
+	return values
+}
@@ -0,0 +1,160 @@
+package descriptor
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// setFieldPresence sets the field_presence feature of opts in the unknown
+// field with the given number, as protoc encodes it for editions files.
+func setFieldPresence(opts proto.Message, number protowire.Number, presence fieldPresence) {
+	var features []byte
+	features = protowire.AppendTag(features, featureSetFieldPresence, protowire.VarintType)
+	features = protowire.AppendVarint(features, uint64(presence))
+	var b []byte
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	b = protowire.AppendBytes(b, features)
+	m := opts.ProtoReflect()
+	m.SetUnknown(append(m.GetUnknown(), b...))
+}
+
+func TestFieldExplicitPresence(t *testing.T) {
+	const src = `
+		name: 'example.proto'
+		package: 'example'
+		syntax: 'editions'
+		message_type <
+			name: 'ExampleMessage'
+			field <
+				name: 'explicit'
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+				number: 1
+			>
+			field <
+				name: 'implicit'
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+				number: 2
+				options <>
+			>
+			field <
+				name: 'repeated'
+				label: LABEL_REPEATED
+				type: TYPE_STRING
+				number: 3
+			>
+			field <
+				name: 'oneof_member'
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+				number: 4
+				oneof_index: 0
+			>
+			nested_type <
+				name: 'NestedMessage'
+				options <>
+				field <
+					name: 'implicit'
+					label: LABEL_OPTIONAL
+					type: TYPE_STRING
+					number: 1
+				>
+				field <
+					name: 'explicit'
+					label: LABEL_OPTIONAL
+					type: TYPE_STRING
+					number: 2
+					options <>
+				>
+			>
+			oneof_decl <
+				name: 'oneof'
+			>
+		>
+	`
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s, &fd) failed with %v; want success", src, err)
+	}
+	msg := fd.GetMessageType()[0]
+	setFieldPresence(msg.GetField()[1].GetOptions(), fieldOptionsFeatures, fieldPresenceImplicit)
+	nested := msg.GetNestedType()[0]
+	setFieldPresence(nested.GetOptions(), messageOptionsFeatures, fieldPresenceImplicit)
+	setFieldPresence(nested.GetField()[1].GetOptions(), fieldOptionsFeatures, fieldPresenceExplicit)
+
+	reg := NewRegistry()
+	reg.loadFile(&fd)
+
+	for _, spec := range []struct {
+		msg   string
+		field string
+		want  bool
+	}{
+		{msg: ".example.ExampleMessage", field: "explicit", want: true},
+		{msg: ".example.ExampleMessage", field: "implicit", want: false},
+		{msg: ".example.ExampleMessage", field: "repeated", want: false},
+		{msg: ".example.ExampleMessage", field: "oneof_member", want: false},
+		{msg: ".example.ExampleMessage.NestedMessage", field: "implicit", want: false},
+		{msg: ".example.ExampleMessage.NestedMessage", field: "explicit", want: true},
+	} {
+		m, err := reg.LookupMsg("", spec.msg)
+		if err != nil {
+			t.Fatalf("reg.LookupMsg(%q, %q) failed with %v; want success", "", spec.msg, err)
+		}
+		var f *Field
+		for _, mf := range m.Fields {
+			if mf.GetName() == spec.field {
+				f = mf
+			}
+		}
+		if f == nil {
+			t.Fatalf("field %q not found in %s", spec.field, spec.msg)
+		}
+		if got := f.explicitPresence(); got != spec.want {
+			t.Errorf("%s.explicitPresence() = %t; want %t", f.FQFN(), got, spec.want)
+		}
+	}
+}
+
+func TestFileFieldPresence(t *testing.T) {
+	const src = `
+		name: 'example.proto'
+		package: 'example'
+		syntax: 'editions'
+		options <>
+		message_type <
+			name: 'ExampleMessage'
+			field <
+				name: 'implicit'
+				label: LABEL_OPTIONAL
+				type: TYPE_INT32
+				number: 1
+			>
+		>
+	`
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s, &fd) failed with %v; want success", src, err)
+	}
+	setFieldPresence(fd.GetOptions(), fileOptionsFeatures, fieldPresenceImplicit)
+
+	reg := NewRegistry()
+	reg.loadFile(&fd)
+	m, err := reg.LookupMsg("", ".example.ExampleMessage")
+	if err != nil {
+		t.Fatalf("reg.LookupMsg(%q, %q) failed with %v; want success", "", ".example.ExampleMessage", err)
+	}
+	f := m.Fields[0]
+	if f.explicitPresence() {
+		t.Errorf("%s.explicitPresence() = true; want false", f.FQFN())
+	}
+	p := Parameter{FieldPath: FieldPath{{Name: "implicit", Target: f}}, Target: f}
+	if got, err := p.ConvertFuncExpr(); err != nil || got != "runtime.Int32" {
+		t.Errorf("p.ConvertFuncExpr() = %q, %v; want %q, nil", got, err, "runtime.Int32")
+	}
+}
@@ -1,13 +1,20 @@
 package descriptor
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor/apiconfig"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	options "google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 func loadGrpcAPIServiceFromYAML(yamlFileContents []byte, yamlSourceLogName string) (*apiconfig.GrpcAPIService, error) {
@@ -16,6 +23,12 @@ func loadGrpcAPIServiceFromYAML(yamlFileContents []byte, yamlSourceLogName strin
 		return nil, fmt.Errorf("failed to convert gRPC API Configuration from YAML in '%v' to JSON: %v", yamlSourceLogName, err)
 	}
 
+	// The http section is fully described by google.api.Http, so reject the
+	// fields it does not know about rather than silently ignoring them.
+	if err := checkUnknownHTTPFields(jsonContents, yamlFileContents, yamlSourceLogName); err != nil {
+		return nil, err
+	}
+
 	// As our GrpcAPIService is incomplete, accept unknown fields.
 	unmarshaler := protojson.UnmarshalOptions{
 		DiscardUnknown: true,
@@ -29,16 +42,147 @@ func loadGrpcAPIServiceFromYAML(yamlFileContents []byte, yamlSourceLogName strin
 	return &serviceConfiguration, nil
 }
 
-func registerHTTPRulesFromGrpcAPIService(registry *Registry, service *apiconfig.GrpcAPIService, sourceLogName string) error {
+// checkUnknownHTTPFields returns an error locating the first field of the http
+// section of the configuration which is not a field of google.api.Http.
+func checkUnknownHTTPFields(jsonContents, yamlFileContents []byte, yamlSourceLogName string) error {
+	var service map[string]interface{}
+	if err := json.Unmarshal(jsonContents, &service); err != nil {
+		// Not an object; reported when unmarshaling the configuration.
+		return nil
+	}
+	httpField := (&apiconfig.GrpcAPIService{}).ProtoReflect().Descriptor().Fields().ByName("http")
+	path, key := unknownField(service["http"], httpField.Message(), "http")
+	if key == "" {
+		return nil
+	}
+	return yamlError(yamlFileContents, yamlSourceLogName, key, "", "unknown field %q in %s", key, path)
+}
+
+// unknownField returns the path and the key of the first field of v, decoded
+// from JSON, which is not a field of md. The key is empty if there is none.
+func unknownField(v interface{}, md protoreflect.MessageDescriptor, path string) (string, string) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fd := md.Fields().ByName(protoreflect.Name(k))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(k)
+		}
+		if fd == nil {
+			return path + "." + k, k
+		}
+		if fd.Message() == nil {
+			continue
+		}
+		if items, ok := obj[k].([]interface{}); ok && fd.IsList() {
+			for i, item := range items {
+				if p, key := unknownField(item, fd.Message(), fmt.Sprintf("%s.%s[%d]", path, k, i)); key != "" {
+					return p, key
+				}
+			}
+			continue
+		}
+		if p, key := unknownField(obj[k], fd.Message(), path+"."+k); key != "" {
+			return p, key
+		}
+	}
+	return "", ""
+}
+
+// yamlError returns an error prefixed with the position of the first
+// occurrence of key in the YAML source, holding value if it is not empty.
+func yamlError(yamlFileContents []byte, yamlSourceLogName, key, value, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if line, col, ok := yamlKeyPosition(yamlFileContents, key, value); ok {
+		return fmt.Errorf("%v:%d:%d: %s", yamlSourceLogName, line, col, msg)
+	}
+	return fmt.Errorf("%v: %s", yamlSourceLogName, msg)
+}
+
+// yamlKeyPosition returns the 1-based line and column of the first block
+// mapping key in src, whose value is value if it is not empty.
+func yamlKeyPosition(src []byte, key, value string) (line, col int, ok bool) {
+	pattern := `(?m)^([ \t]*(?:-[ \t]+)*)` + regexp.QuoteMeta(key) + `[ \t]*:`
+	if value != "" {
+		pattern += `[ \t]*["']?` + regexp.QuoteMeta(value) + `["']?[ \t]*(?:#.*)?$`
+	}
+	loc := regexp.MustCompile(pattern).FindSubmatchIndex(src)
+	if loc == nil {
+		return 0, 0, false
+	}
+	start := loc[3]
+	line = bytes.Count(src[:start], []byte("\n")) + 1
+	col = start - bytes.LastIndexByte(src[:start], '\n')
+	return line, col, true
+}
+
+// validateHTTPRule checks that rule has a valid pattern, and that its
+// additional bindings do not have selectors or additional bindings of their
+// own, as annotations would not allow.
+func validateHTTPRule(rule *options.HttpRule, additional bool) error {
+	var path string
+	switch pattern := rule.GetPattern().(type) {
+	case *options.HttpRule_Get:
+		path = pattern.Get
+	case *options.HttpRule_Put:
+		path = pattern.Put
+	case *options.HttpRule_Post:
+		path = pattern.Post
+	case *options.HttpRule_Delete:
+		path = pattern.Delete
+	case *options.HttpRule_Patch:
+		path = pattern.Patch
+	case *options.HttpRule_Custom:
+		if pattern.Custom.GetKind() == "" {
+			return fmt.Errorf("custom pattern must specify a kind")
+		}
+		path = pattern.Custom.GetPath()
+	default:
+		return fmt.Errorf("no HTTP method and path template specified")
+	}
+	if _, err := httprule.Parse(path); err != nil {
+		return err
+	}
+	if additional {
+		if rule.GetSelector() != "" {
+			return fmt.Errorf("selector must not be set in additional_bindings")
+		}
+		if len(rule.GetAdditionalBindings()) > 0 {
+			return fmt.Errorf("additional_bindings must not be nested")
+		}
+	}
+	for i, binding := range rule.GetAdditionalBindings() {
+		if err := validateHTTPRule(binding, true); err != nil {
+			return fmt.Errorf("additional_bindings[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func registerHTTPRulesFromGrpcAPIService(registry *Registry, service *apiconfig.GrpcAPIService, yamlFileContents []byte, sourceLogName string) error {
 	if service.Http == nil {
 		// Nothing to do
 		return nil
 	}
 
 	for _, rule := range service.Http.GetRules() {
-		selector := "." + strings.Trim(rule.GetSelector(), " ")
+		name := strings.Trim(rule.GetSelector(), " ")
+		if name == "" {
+			return fmt.Errorf("%v: every rule must specify a selector", sourceLogName)
+		}
+		selector := "." + strings.TrimPrefix(name, ".")
 		if strings.ContainsAny(selector, "*, ") {
-			return fmt.Errorf("selector '%v' in %v must specify a single service method without wildcards", rule.GetSelector(), sourceLogName)
+			return yamlError(yamlFileContents, sourceLogName, "selector", rule.GetSelector(), "selector '%v' must specify a single service method without wildcards", rule.GetSelector())
+		}
+		if err := validateHTTPRule(rule, false); err != nil {
+			return yamlError(yamlFileContents, sourceLogName, "selector", rule.GetSelector(), "invalid rule for selector '%v': %v", rule.GetSelector(), err)
 		}
 
 		registry.AddExternalHTTPRule(selector, rule)
@@ -67,5 +211,5 @@ func (r *Registry) LoadGrpcAPIServiceFromYAML(yamlFile string) error {
 		return err
 	}
 
-	return registerHTTPRulesFromGrpcAPIService(r, service, yamlFile)
+	return registerHTTPRulesFromGrpcAPIService(r, service, yamlFileContents, yamlFile)
 }
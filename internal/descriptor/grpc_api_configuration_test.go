@@ -147,3 +147,136 @@ http:
 		t.Errorf("some.other.service has %v additional bindings when it should not have any. Got: %v", len(second.GetAdditionalBindings()), second.GetAdditionalBindings())
 	}
 }
+
+func TestLoadGrpcAPIServiceFromYAMLRejectUnknownHTTPField(t *testing.T) {
+	service, err := loadGrpcAPIServiceFromYAML([]byte(`
+type: google.api.Service
+config_version: 3
+
+http:
+ rules:
+ - selector: grpctest.YourService.Echo
+   post: /v1/myecho
+   respone_body: "*"
+`), "unknownfield")
+	if err == nil {
+		t.Fatalf("loadGrpcAPIServiceFromYAML succeeded with %v; want failure", service)
+	}
+
+	want := `unknownfield:9:4: unknown field "respone_body" in http.rules[0].respone_body`
+	if err.Error() != want {
+		t.Errorf("loadGrpcAPIServiceFromYAML failed with %q; want %q", err, want)
+	}
+}
+
+func TestRegisterHTTPRulesFromGrpcAPIServiceInvalidRules(t *testing.T) {
+	for _, spec := range []struct {
+		name    string
+		rule    string
+		wantErr string
+	}{
+		{
+			name: "nopattern",
+			rule: `
+ - selector: grpctest.YourService.Echo
+   body: "*"
+`,
+			wantErr: "nopattern:7:4: invalid rule for selector 'grpctest.YourService.Echo': no HTTP method and path template specified",
+		},
+		{
+			name: "invalidpath",
+			rule: `
+ - selector: grpctest.YourService.Echo
+   post: v1/myecho
+`,
+			wantErr: "invalidpath:7:4: invalid rule for selector 'grpctest.YourService.Echo': ",
+		},
+		{
+			name: "customkind",
+			rule: `
+ - selector: grpctest.YourService.Echo
+   custom:
+     path: /v1/myecho
+`,
+			wantErr: "customkind:7:4: invalid rule for selector 'grpctest.YourService.Echo': custom pattern must specify a kind",
+		},
+		{
+			name: "nestedbindings",
+			rule: `
+ - selector: grpctest.YourService.Echo
+   post: /v1/myecho
+   additional_bindings:
+   - get: /v1/myecho/{id}
+     additional_bindings:
+     - get: /v2/myecho/{id}
+`,
+			wantErr: "nestedbindings:7:4: invalid rule for selector 'grpctest.YourService.Echo': additional_bindings[0]: additional_bindings must not be nested",
+		},
+		{
+			name: "bindingselector",
+			rule: `
+ - selector: grpctest.YourService.Echo
+   post: /v1/myecho
+   additional_bindings:
+   - selector: grpctest.YourService.Other
+     get: /v1/myecho/{id}
+`,
+			wantErr: "bindingselector:7:4: invalid rule for selector 'grpctest.YourService.Echo': additional_bindings[0]: selector must not be set in additional_bindings",
+		},
+		{
+			name: "wildcard",
+			rule: `
+ - selector: grpctest.YourService.*
+   post: /v1/myecho
+`,
+			wantErr: "wildcard:7:4: selector 'grpctest.YourService.*' must specify a single service method without wildcards",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			src := []byte(`
+type: google.api.Service
+config_version: 3
+
+http:
+ rules:` + spec.rule)
+			service, err := loadGrpcAPIServiceFromYAML(src, spec.name)
+			if err != nil {
+				t.Fatalf("loadGrpcAPIServiceFromYAML failed with %v; want success", err)
+			}
+			err = registerHTTPRulesFromGrpcAPIService(NewRegistry(), service, src, spec.name)
+			if err == nil {
+				t.Fatalf("registerHTTPRulesFromGrpcAPIService succeeded; want failure")
+			}
+			if !strings.HasPrefix(err.Error(), spec.wantErr) {
+				t.Errorf("registerHTTPRulesFromGrpcAPIService failed with %q; want prefix %q", err, spec.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterHTTPRulesFromGrpcAPIServiceLeadingDot(t *testing.T) {
+	src := []byte(`
+http:
+ rules:
+ - selector: .grpctest.YourService.Echo
+   post: /v1/myecho
+   response_body: "value"
+   additional_bindings:
+   - get: /v1/myecho/{id}
+`)
+	service, err := loadGrpcAPIServiceFromYAML(src, "leadingdot")
+	if err != nil {
+		t.Fatalf("loadGrpcAPIServiceFromYAML failed with %v; want success", err)
+	}
+	reg := NewRegistry()
+	if err := registerHTTPRulesFromGrpcAPIService(reg, service, src, "leadingdot"); err != nil {
+		t.Fatalf("registerHTTPRulesFromGrpcAPIService failed with %v; want success", err)
+	}
+	rules := reg.externalHTTPRules[".grpctest.YourService.Echo"]
+	if len(rules) != 1 {
+		t.Fatalf("reg.externalHTTPRules[%q] = %v; want one rule", ".grpctest.YourService.Echo", rules)
+	}
+	if got, want := rules[0].GetResponseBody(), "value"; got != want {
+		t.Errorf("rules[0].GetResponseBody() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+
+	options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/protobuf/proto"
+)
+
+// OpenAPIBindingOptions are the options set on a binding with the
+// binding_options of the openapiv2_operation method option.
+type OpenAPIBindingOptions struct {
+	// Exclude leaves the binding out of the OpenAPI output.
+	Exclude bool
+	// Override replaces the matching fields of the operation of the binding.
+	Override OpenAPIOperationOverride
+	// Headers are the HTTP headers the binding expects.
+	Headers []OpenAPIHeaderParameter
+	// ResponseHeaders are the HTTP headers sent in the responses of the binding.
+	ResponseHeaders []OpenAPIResponseHeader
+}
+
+// OpenAPIHeaderParameter is an HTTP header expected by a binding.
+type OpenAPIHeaderParameter struct {
+	Name        string
+	Description string
+	Type        string
+	Format      string
+	Required    bool
+}
+
+// OpenAPIResponseHeader is an HTTP header sent in the responses of a binding.
+type OpenAPIResponseHeader struct {
+	Name        string
+	Description string
+	Type        string
+	Format      string
+	// Status is the status code of the responses the header is sent with.
+	Status string
+}
+
+// OpenAPIOperationOverride holds the operation fields overridden for a binding.
+// Empty fields are left untouched.
+type OpenAPIOperationOverride struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Deprecated  *bool
+}
+
+// IsSet reports whether any field is overridden.
+func (o OpenAPIOperationOverride) IsSet() bool {
+	return o.Summary != "" || o.Description != "" || o.OperationID != "" || len(o.Tags) > 0 || o.Deprecated != nil
+}
+
+// OpenAPIOptions returns the options set on the binding with the
+// binding_options of the openapiv2_operation method option, or nil if there
+// are none. They are honored by protoc-gen-openapiv2 and recorded next to the
+// route patterns generated by protoc-gen-grpc-gateway.
+func (b *Binding) OpenAPIOptions() (*OpenAPIBindingOptions, error) {
+	if b.Method == nil || b.Method.Options == nil || !proto.HasExtension(b.Method.Options, options.E_Openapiv2Operation) {
+		return nil, nil
+	}
+	op, ok := proto.GetExtension(b.Method.Options, options.E_Openapiv2Operation).(*options.Operation)
+	if !ok {
+		return nil, nil
+	}
+	for _, bo := range op.GetBindingOptions() {
+		if len(bo.GetBindings()) > 0 && !containsIndex(bo.GetBindings(), b.Index) {
+			continue
+		}
+		opts := &OpenAPIBindingOptions{
+			Exclude:  bo.GetExclude(),
+			Override: newOperationOverride(bo.GetOverride()),
+		}
+		for _, h := range bo.GetHeaders() {
+			if h.GetName() == "" {
+				return nil, fmt.Errorf("binding_options of %s: header without a name", b.Method.FQMN())
+			}
+			opts.Headers = append(opts.Headers, OpenAPIHeaderParameter{
+				Name:        h.GetName(),
+				Description: h.GetDescription(),
+				Type:        strings.ToLower(h.GetType().String()),
+				Format:      h.GetFormat(),
+				Required:    h.GetRequired(),
+			})
+		}
+		for _, h := range bo.GetResponseHeaders() {
+			if h.GetName() == "" {
+				return nil, fmt.Errorf("binding_options of %s: response header without a name", b.Method.FQMN())
+			}
+			status := h.GetStatus()
+			if status == "" {
+				status = "200"
+			}
+			opts.ResponseHeaders = append(opts.ResponseHeaders, OpenAPIResponseHeader{
+				Name:        h.GetName(),
+				Description: h.GetDescription(),
+				Type:        strings.ToLower(h.GetType().String()),
+				Format:      h.GetFormat(),
+				Status:      status,
+			})
+		}
+		return opts, nil
+	}
+	return nil, nil
+}
+
+func newOperationOverride(o *options.BindingOverride) OpenAPIOperationOverride {
+	override := OpenAPIOperationOverride{
+		Summary:     o.GetSummary(),
+		Description: o.GetDescription(),
+		OperationID: o.GetOperationId(),
+		Tags:        o.GetTags(),
+	}
+	if o.GetDeprecated() != nil {
+		deprecated := o.GetDeprecated().GetValue()
+		override.Deprecated = &deprecated
+	}
+	return override
+}
+
+func containsIndex(list []int32, index int) bool {
+	for _, i := range list {
+		if int(i) == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package descriptor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func newOpenAPIBindingFixture(op *options.Operation) []*Binding {
+	methodOptions := &descriptorpb.MethodOptions{}
+	proto.SetExtension(methodOptions, options.E_Openapiv2Operation, op)
+	meth := &Method{
+		MethodDescriptorProto: &descriptorpb.MethodDescriptorProto{
+			Name:    proto.String("Echo"),
+			Options: methodOptions,
+		},
+		Service: &Service{
+			ServiceDescriptorProto: &descriptorpb.ServiceDescriptorProto{Name: proto.String("EchoService")},
+			File: &File{
+				FileDescriptorProto: &descriptorpb.FileDescriptorProto{Package: proto.String("example")},
+			},
+		},
+	}
+	return []*Binding{
+		{Method: meth, Index: 0},
+		{Method: meth, Index: 1},
+	}
+}
+
+func TestBindingOpenAPIOptions(t *testing.T) {
+	bindings := newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{
+			{
+				Bindings: []int32{1},
+				Override: &options.BindingOverride{
+					Summary:     "Echo the legacy way",
+					OperationId: "EchoLegacy",
+					Tags:        []string{"Legacy"},
+					Deprecated:  &wrappers.BoolValue{Value: true},
+				},
+			},
+			{
+				Bindings: []int32{1},
+				Exclude:  true,
+			},
+		},
+	})
+
+	if opts, err := bindings[0].OpenAPIOptions(); err != nil || opts != nil {
+		t.Errorf("bindings[0].OpenAPIOptions() = %+v, %v; want nil, nil", opts, err)
+	}
+
+	opts, err := bindings[1].OpenAPIOptions()
+	if err != nil {
+		t.Fatalf("bindings[1].OpenAPIOptions() failed with %v; want success", err)
+	}
+	deprecated := true
+	want := &OpenAPIBindingOptions{
+		Override: OpenAPIOperationOverride{
+			Summary:     "Echo the legacy way",
+			OperationID: "EchoLegacy",
+			Tags:        []string{"Legacy"},
+			Deprecated:  &deprecated,
+		},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("bindings[1].OpenAPIOptions() = %+v; want %+v", opts, want)
+	}
+}
+
+func TestBindingOpenAPIOptionsAllBindings(t *testing.T) {
+	for _, b := range newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{{Exclude: true}},
+	}) {
+		opts, err := b.OpenAPIOptions()
+		if err != nil {
+			t.Fatalf("bindings[%d].OpenAPIOptions() failed with %v; want success", b.Index, err)
+		}
+		if want := (&OpenAPIBindingOptions{Exclude: true}); !reflect.DeepEqual(opts, want) {
+			t.Errorf("bindings[%d].OpenAPIOptions() = %+v; want %+v", b.Index, opts, want)
+		}
+	}
+}
+
+func TestBindingOpenAPIOptionsHeaders(t *testing.T) {
+	b := newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{{
+			Headers: []*options.HeaderParameter{
+				{
+					Name:     "Authorization",
+					Required: true,
+				},
+				{
+					Name:   "X-Request-Count",
+					Type:   options.HeaderParameter_INTEGER,
+					Format: "int32",
+				},
+			},
+		}},
+	})[0]
+
+	opts, err := b.OpenAPIOptions()
+	if err != nil {
+		t.Fatalf("OpenAPIOptions() failed with %v; want success", err)
+	}
+	want := []OpenAPIHeaderParameter{
+		{Name: "Authorization", Type: "string", Required: true},
+		{Name: "X-Request-Count", Type: "integer", Format: "int32"},
+	}
+	if !reflect.DeepEqual(opts.Headers, want) {
+		t.Errorf("OpenAPIOptions().Headers = %+v; want %+v", opts.Headers, want)
+	}
+	if opts.Override.IsSet() {
+		t.Errorf("OpenAPIOptions().Override.IsSet() = true; want false")
+	}
+
+	b = newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{{
+			Headers: []*options.HeaderParameter{{Description: "Number of requests."}},
+		}},
+	})[0]
+	if _, err := b.OpenAPIOptions(); err == nil {
+		t.Errorf("OpenAPIOptions() with a header without a name succeeded; want error")
+	}
+}
+
+func TestBindingOpenAPIOptionsResponseHeaders(t *testing.T) {
+	b := newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{{
+			ResponseHeaders: []*options.ResponseHeader{
+				{
+					Name:        "ETag",
+					Description: "Version of the resource.",
+				},
+				{
+					Name:   "Retry-After",
+					Type:   options.HeaderParameter_INTEGER,
+					Status: "429",
+				},
+			},
+		}},
+	})[0]
+
+	opts, err := b.OpenAPIOptions()
+	if err != nil {
+		t.Fatalf("OpenAPIOptions() failed with %v; want success", err)
+	}
+	want := []OpenAPIResponseHeader{
+		{Name: "ETag", Description: "Version of the resource.", Type: "string", Status: "200"},
+		{Name: "Retry-After", Type: "integer", Status: "429"},
+	}
+	if !reflect.DeepEqual(opts.ResponseHeaders, want) {
+		t.Errorf("OpenAPIOptions().ResponseHeaders = %+v; want %+v", opts.ResponseHeaders, want)
+	}
+
+	b = newOpenAPIBindingFixture(&options.Operation{
+		BindingOptions: []*options.BindingOptions{{
+			ResponseHeaders: []*options.ResponseHeader{{Status: "429"}},
+		}},
+	})[0]
+	if _, err := b.OpenAPIOptions(); err == nil {
+		t.Errorf("OpenAPIOptions() with a response header without a name succeeded; want error")
+	}
+}
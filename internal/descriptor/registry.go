@@ -86,6 +86,10 @@ type Registry struct {
 	simpleOperationIDs bool
 
 	standalone bool
+	// importSubstitutions maps the Go import paths of the message packages
+	// imported by standalone gateways, or their prefixes, to the paths the
+	// packages are actually imported from.
+	importSubstitutions map[string]string
 	// warnOnUnboundMethods causes the registry to emit warning logs if an RPC method
 	// has no HttpRule annotation.
 	warnOnUnboundMethods bool
@@ -110,8 +114,82 @@ type Registry struct {
 	// RPC methods that have no HttpRule annotation.
 	generateUnboundMethods bool
 
+	// unboundMethodsPattern, if set, is the path template of the bindings
+	// generated for unannotated methods, with '{package}', '{service}' and
+	// '{method}' replaced by the lower-cased names of the method.
+	unboundMethodsPattern string
+
 	// omitPackageDoc, if false, causes a package comment to be included in the generated code.
 	omitPackageDoc bool
+
+	// outputFormat selects the kind of documents protoc-gen-openapiv2 emits,
+	// either 'openapiv2' or 'jsonschema'.
+	outputFormat string
+
+	// streamingFormat, if set, documents streaming methods as either
+	// newline-delimited JSON ('ndjson') or server-sent events ('sse').
+	streamingFormat string
+
+	// validationRulesPrecedence controls how protoc-gen-validate and protovalidate
+	// rules are rendered as OpenAPI constraints: 'openapi' lets openapiv2 options win
+	// on conflict, 'validate' lets validation rules win and 'ignore' skips them.
+	validationRulesPrecedence string
+
+	// produces and consumes replace the default MIME types of the generated OpenAPI files.
+	produces []string
+	consumes []string
+
+	// recursiveSchemaMode controls how self-referential messages are rendered:
+	// 'ref' keeps cyclic $refs, 'unroll' expands them up to recursiveSchemaDepth.
+	recursiveSchemaMode  string
+	recursiveSchemaDepth int
+
+	// inlineRefs replaces the $refs of the OpenAPI output with the definitions
+	// they point to, except where needed to break cycles.
+	inlineRefs bool
+
+	// deprecationNote, if set, is appended to the description of deprecated
+	// methods, fields and enum values.
+	deprecationNote string
+
+	// servers are the servers the API is available at, and serverVariables the
+	// default values of the placeholders in their URLs.
+	servers         []Server
+	serverVariables map[string]string
+
+	// wktFormats maps well-known types ('Timestamp', 'Duration', 'FieldMask'
+	// and 'wrappers') to the format their OpenAPI schemas are rendered in.
+	wktFormats map[string]string
+
+	// tagBy controls whether operations are tagged by 'service' or by 'package'.
+	tagBy string
+	// tagOrder lists the tags that come first in the tags of the OpenAPI
+	// output, and tagGroups groups tags in the x-tagGroups extension.
+	tagOrder  []string
+	tagGroups []TagGroup
+}
+
+// TagGroup is a named group of tags, as rendered in the x-tagGroups extension.
+type TagGroup struct {
+	Name string
+	Tags []string
+}
+
+// wktFormatNames lists the formats allowed for each well-known type in
+// SetWKTFormats. The first one is the default.
+var wktFormatNames = map[string][]string{
+	"Timestamp": {"date-time", "unix"},
+	"Duration":  {"string", "pattern", "seconds"},
+	"FieldMask": {"array", "string"},
+	"wrappers":  {"value", "nullable"},
+}
+
+// Server is a server the API is available at.
+type Server struct {
+	// Name identifies the server, e.g. 'prod' or 'staging'.
+	Name string
+	// URL is the URL of the server. It may contain '{variable}' placeholders.
+	URL string
 }
 
 type repeatedFieldSeparator struct {
@@ -137,6 +215,12 @@ func NewRegistry() *Registry {
 		messageOptions: make(map[string]*options.Schema),
 		serviceOptions: make(map[string]*options.Tag),
 		fieldOptions:   make(map[string]*options.JSONSchema),
+		outputFormat:   OutputFormatOpenAPIv2,
+
+		validationRulesPrecedence: "openapi",
+		recursiveSchemaMode:       "ref",
+		recursiveSchemaDepth:      2,
+		tagBy:                     "service",
 	}
 }
 
@@ -177,6 +261,7 @@ func (r *Registry) loadFile(file *descriptorpb.FileDescriptorProto) {
 		Name: r.defaultGoPackageName(file),
 	}
 	if r.standalone {
+		pkg.Path = r.substituteImport(pkg.Path)
 		pkg.Alias = "ext" + strings.Title(pkg.Name)
 	}
 
@@ -195,11 +280,13 @@ func (r *Registry) loadFile(file *descriptorpb.FileDescriptorProto) {
 	}
 
 	r.files[file.GetName()] = f
-	r.registerMsg(f, nil, file.GetMessageType())
+	r.registerMsg(f, nil, f.fieldPresence(), file.GetMessageType())
 	r.registerEnum(f, nil, file.GetEnumType())
 }
 
-func (r *Registry) registerMsg(file *File, outerPath []string, msgs []*descriptorpb.DescriptorProto) {
+// registerMsg registers msgs, whose fields have the presence of their outer
+// message or file unless their features override it.
+func (r *Registry) registerMsg(file *File, outerPath []string, presence fieldPresence, msgs []*descriptorpb.DescriptorProto) {
 	for i, md := range msgs {
 		m := &Message{
 			File:              file,
@@ -207,6 +294,7 @@ func (r *Registry) registerMsg(file *File, outerPath []string, msgs []*descripto
 			DescriptorProto:   md,
 			Index:             i,
 			ForcePrefixedName: r.standalone,
+			presence:          messageFieldPresence(md, presence),
 		}
 		for _, fd := range md.GetField() {
 			m.Fields = append(m.Fields, &Field{
@@ -222,7 +310,7 @@ func (r *Registry) registerMsg(file *File, outerPath []string, msgs []*descripto
 		var outers []string
 		outers = append(outers, outerPath...)
 		outers = append(outers, m.GetName())
-		r.registerMsg(file, outers, m.GetNestedType())
+		r.registerMsg(file, outers, m.presence, m.GetNestedType())
 		r.registerEnum(file, outers, m.GetEnumType())
 	}
 }
@@ -336,6 +424,38 @@ func (r *Registry) UnboundExternalHTTPRules() []string {
 	return missingMethods
 }
 
+// SetImportSubstitutions sets the import paths standalone gateways import
+// message packages from, given as 'from=to'. An import path equal to 'from',
+// or below it, is imported from 'to' instead, e.g. 'example.com/apis=example.com/gen/apis'
+// imports the package 'example.com/apis/v1' from 'example.com/gen/apis/v1'.
+// The longest matching 'from' wins.
+func (r *Registry) SetImportSubstitutions(substitutions []string) error {
+	r.importSubstitutions = make(map[string]string, len(substitutions))
+	for _, substitution := range substitutions {
+		spec := strings.SplitN(substitution, "=", 2)
+		if len(spec) != 2 || spec[0] == "" || spec[1] == "" {
+			return fmt.Errorf("invalid import substitution %q: want from=to", substitution)
+		}
+		r.importSubstitutions[spec[0]] = spec[1]
+	}
+	return nil
+}
+
+// substituteImport returns the import path the package at importPath is
+// imported from, according to importSubstitutions.
+func (r *Registry) substituteImport(importPath string) string {
+	var from string
+	for prefix := range r.importSubstitutions {
+		if (importPath == prefix || strings.HasPrefix(importPath, prefix+"/")) && len(prefix) > len(from) {
+			from = prefix
+		}
+	}
+	if from == "" {
+		return importPath
+	}
+	return r.importSubstitutions[from] + strings.TrimPrefix(importPath, from)
+}
+
 // AddPkgMap adds a mapping from a .proto file to proto package name.
 func (r *Registry) AddPkgMap(file, protoPkg string) {
 	r.pkgMap[file] = protoPkg
@@ -567,6 +687,24 @@ func (r *Registry) SetGenerateUnboundMethods(generate bool) {
 	r.generateUnboundMethods = generate
 }
 
+// SetUnboundMethodsPattern sets the path template of the bindings generated
+// for unannotated methods, e.g. '/api/{service}/{method}'. The placeholders
+// '{package}', '{service}' and '{method}' are replaced by the lower-cased
+// proto package, service name and method name. An empty pattern keeps the
+// gRPC path '/<package>.<Service>/<Method>'.
+func (r *Registry) SetUnboundMethodsPattern(pattern string) error {
+	if pattern != "" && !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("unbound methods pattern must start with '/': %s", pattern)
+	}
+	r.unboundMethodsPattern = pattern
+	return nil
+}
+
+// GetUnboundMethodsPattern returns unboundMethodsPattern
+func (r *Registry) GetUnboundMethodsPattern() string {
+	return r.unboundMethodsPattern
+}
+
 // SetOmitPackageDoc controls whether the generated code contains a package comment (if set to false, it will contain one)
 func (r *Registry) SetOmitPackageDoc(omit bool) {
 	r.omitPackageDoc = omit
@@ -577,6 +715,260 @@ func (r *Registry) GetOmitPackageDoc() bool {
 	return r.omitPackageDoc
 }
 
+const (
+	// OutputFormatOpenAPIv2 emits a single OpenAPI v2 document per proto file.
+	OutputFormatOpenAPIv2 = "openapiv2"
+	// OutputFormatJSONSchema emits a standalone JSON Schema (draft 2020-12)
+	// document for every request and response message.
+	OutputFormatJSONSchema = "jsonschema"
+	// OutputFormatPostman emits a Postman collection per proto file.
+	OutputFormatPostman = "postman"
+)
+
+// SetOutputFormat sets the kind of documents emitted by protoc-gen-openapiv2.
+// Allowed formats are 'openapiv2', 'jsonschema' and 'postman'.
+func (r *Registry) SetOutputFormat(format string) error {
+	switch format {
+	case OutputFormatOpenAPIv2, OutputFormatJSONSchema, OutputFormatPostman:
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+	r.outputFormat = format
+	return nil
+}
+
+// GetOutputFormat returns outputFormat
+func (r *Registry) GetOutputFormat() string {
+	return r.outputFormat
+}
+
+// SetStreamingFormat sets the wire format streaming methods are documented with.
+// Allowed formats are 'ndjson' and 'sse'. An empty format leaves streaming
+// methods documented with the default content types.
+func (r *Registry) SetStreamingFormat(format string) error {
+	switch format {
+	case "", "ndjson", "sse":
+	default:
+		return fmt.Errorf("unknown streaming format: %s", format)
+	}
+	r.streamingFormat = format
+	return nil
+}
+
+// GetStreamingFormat returns streamingFormat
+func (r *Registry) GetStreamingFormat() string {
+	return r.streamingFormat
+}
+
+// SetValidationRulesPrecedence sets which source of constraints wins when both
+// openapiv2 options and validation rules constrain a field.
+// Allowed values are 'openapi', 'validate' and 'ignore'.
+func (r *Registry) SetValidationRulesPrecedence(precedence string) error {
+	switch precedence {
+	case "openapi", "validate", "ignore":
+	default:
+		return fmt.Errorf("unknown validation rules precedence: %s", precedence)
+	}
+	r.validationRulesPrecedence = precedence
+	return nil
+}
+
+// GetValidationRulesPrecedence returns validationRulesPrecedence
+func (r *Registry) GetValidationRulesPrecedence() string {
+	return r.validationRulesPrecedence
+}
+
+// SetProduces sets the MIME types the API produces by default
+func (r *Registry) SetProduces(mimeTypes []string) {
+	r.produces = mimeTypes
+}
+
+// GetProduces returns produces
+func (r *Registry) GetProduces() []string {
+	return r.produces
+}
+
+// SetConsumes sets the MIME types the API consumes by default
+func (r *Registry) SetConsumes(mimeTypes []string) {
+	r.consumes = mimeTypes
+}
+
+// GetConsumes returns consumes
+func (r *Registry) GetConsumes() []string {
+	return r.consumes
+}
+
+// SetRecursiveSchemaMode sets how self-referential messages are rendered.
+// Allowed modes are 'ref' and 'unroll'.
+func (r *Registry) SetRecursiveSchemaMode(mode string) error {
+	switch mode {
+	case "ref", "unroll":
+	default:
+		return fmt.Errorf("unknown recursive schema mode: %s", mode)
+	}
+	r.recursiveSchemaMode = mode
+	return nil
+}
+
+// GetRecursiveSchemaMode returns recursiveSchemaMode
+func (r *Registry) GetRecursiveSchemaMode() string {
+	return r.recursiveSchemaMode
+}
+
+// SetRecursiveSchemaDepth sets how many levels of a self-referential message
+// are expanded when the recursive schema mode is 'unroll'.
+func (r *Registry) SetRecursiveSchemaDepth(depth int) error {
+	if depth < 0 {
+		return fmt.Errorf("recursive schema depth must not be negative: %d", depth)
+	}
+	r.recursiveSchemaDepth = depth
+	return nil
+}
+
+// GetRecursiveSchemaDepth returns recursiveSchemaDepth
+func (r *Registry) GetRecursiveSchemaDepth() int {
+	return r.recursiveSchemaDepth
+}
+
+// SetInlineRefs sets inlineRefs
+func (r *Registry) SetInlineRefs(inline bool) {
+	r.inlineRefs = inline
+}
+
+// GetInlineRefs returns inlineRefs
+func (r *Registry) GetInlineRefs() bool {
+	return r.inlineRefs
+}
+
+// SetDeprecationNote sets the note appended to the description of deprecated elements
+func (r *Registry) SetDeprecationNote(note string) {
+	r.deprecationNote = note
+}
+
+// GetDeprecationNote returns deprecationNote
+func (r *Registry) GetDeprecationNote() string {
+	return r.deprecationNote
+}
+
+// SetWKTFormats sets the formats the schemas of well-known types are rendered
+// in, each given as 'type=format', e.g. 'Timestamp=unix'.
+func (r *Registry) SetWKTFormats(formats []string) error {
+	r.wktFormats = make(map[string]string)
+	for _, format := range formats {
+		spec := strings.SplitN(format, "=", 2)
+		if len(spec) != 2 {
+			return fmt.Errorf("invalid well-known type format %q: want type=format", format)
+		}
+		allowed, ok := wktFormatNames[spec[0]]
+		if !ok {
+			return fmt.Errorf("unknown well-known type %q in %q", spec[0], format)
+		}
+		known := false
+		for _, name := range allowed {
+			known = known || name == spec[1]
+		}
+		if !known {
+			return fmt.Errorf("unknown format %q for %s: allowed values are %s", spec[1], spec[0], strings.Join(allowed, ", "))
+		}
+		r.wktFormats[spec[0]] = spec[1]
+	}
+	return nil
+}
+
+// GetWKTFormat returns the format configured for the well-known type, or its
+// default format.
+func (r *Registry) GetWKTFormat(wkt string) string {
+	if format, ok := r.wktFormats[wkt]; ok {
+		return format
+	}
+	if allowed, ok := wktFormatNames[wkt]; ok {
+		return allowed[0]
+	}
+	return ""
+}
+
+// SetTagBy sets what operations are tagged by.
+// Allowed values are 'service' and 'package'.
+func (r *Registry) SetTagBy(tagBy string) error {
+	switch tagBy {
+	case "service", "package":
+	default:
+		return fmt.Errorf("unknown tag_by value: %s", tagBy)
+	}
+	r.tagBy = tagBy
+	return nil
+}
+
+// GetTagBy returns tagBy
+func (r *Registry) GetTagBy() string {
+	return r.tagBy
+}
+
+// SetTagOrder sets the tags listed first, in order, in the OpenAPI output
+func (r *Registry) SetTagOrder(tags []string) {
+	r.tagOrder = tags
+}
+
+// GetTagOrder returns tagOrder
+func (r *Registry) GetTagOrder() []string {
+	return r.tagOrder
+}
+
+// SetTagGroups sets the groups of tags, each given as 'name=tag1:tag2'.
+func (r *Registry) SetTagGroups(groups []string) error {
+	r.tagGroups = nil
+	for _, group := range groups {
+		spec := strings.SplitN(group, "=", 2)
+		if len(spec) != 2 || spec[0] == "" || spec[1] == "" {
+			return fmt.Errorf("invalid tag group %q: want name=tag1:tag2", group)
+		}
+		r.tagGroups = append(r.tagGroups, TagGroup{Name: spec[0], Tags: strings.Split(spec[1], ":")})
+	}
+	return nil
+}
+
+// GetTagGroups returns tagGroups
+func (r *Registry) GetTagGroups() []TagGroup {
+	return r.tagGroups
+}
+
+// SetServers sets the servers the API is available at, each given as 'name=url'.
+func (r *Registry) SetServers(servers []string) error {
+	r.servers = nil
+	for _, server := range servers {
+		spec := strings.SplitN(server, "=", 2)
+		if len(spec) != 2 || spec[0] == "" || spec[1] == "" {
+			return fmt.Errorf("invalid server %q: want name=url", server)
+		}
+		r.servers = append(r.servers, Server{Name: spec[0], URL: spec[1]})
+	}
+	return nil
+}
+
+// GetServers returns servers
+func (r *Registry) GetServers() []Server {
+	return r.servers
+}
+
+// SetServerVariables sets the default values of the placeholders in server URLs,
+// each given as 'variable=default'.
+func (r *Registry) SetServerVariables(variables []string) error {
+	r.serverVariables = make(map[string]string, len(variables))
+	for _, variable := range variables {
+		spec := strings.SplitN(variable, "=", 2)
+		if len(spec) != 2 || spec[0] == "" {
+			return fmt.Errorf("invalid server variable %q: want variable=default", variable)
+		}
+		r.serverVariables[spec[0]] = spec[1]
+	}
+	return nil
+}
+
+// GetServerVariables returns serverVariables
+func (r *Registry) GetServerVariables() map[string]string {
+	return r.serverVariables
+}
+
 // sanitizePackageName replaces unallowed character in package name
 // with allowed character.
 func sanitizePackageName(pkgName string) string {
@@ -1,6 +1,7 @@
 package descriptor
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor/openapiconfig"
@@ -570,6 +571,41 @@ func TestLoadWithStandalone(t *testing.T) {
 	}
 }
 
+func TestLoadWithImportSubstitutions(t *testing.T) {
+	for _, spec := range []struct {
+		goPackage string
+		want      string
+	}{
+		{goPackage: "example.com/apis/v1;pb", want: "example.com/gen/apis/v1"},
+		{goPackage: "example.com/apis/v1/users;pb", want: "vendor.example.com/users"},
+		{goPackage: "example.com/apis;pb", want: "example.com/gen/apis"},
+		{goPackage: "example.com/apisv2;pb", want: "example.com/apisv2"},
+	} {
+		reg := NewRegistry()
+		reg.SetStandalone(true)
+		if err := reg.SetImportSubstitutions([]string{
+			"example.com/apis=example.com/gen/apis",
+			"example.com/apis/v1/users=vendor.example.com/users",
+		}); err != nil {
+			t.Fatalf("reg.SetImportSubstitutions() failed with %v; want success", err)
+		}
+		loadFile(t, reg, fmt.Sprintf(`
+			name: 'example.proto'
+			package: 'example'
+			options < go_package: '%s' >
+		`, spec.goPackage))
+		wantPkg := GoPackage{Path: spec.want, Name: "pb", Alias: "extPb"}
+		if got := reg.files["example.proto"].GoPkg; got != wantPkg {
+			t.Errorf("file.GoPkg with go_package %q = %#v; want %#v", spec.goPackage, got, wantPkg)
+		}
+	}
+
+	reg := NewRegistry()
+	if err := reg.SetImportSubstitutions([]string{"example.com/apis"}); err == nil {
+		t.Errorf("reg.SetImportSubstitutions(%q) succeeded; want an error", "example.com/apis")
+	}
+}
+
 func TestLoadSetInputPath(t *testing.T) {
 	reg := NewRegistry()
 	reg.SetImportPath("foo/examplepb")
@@ -0,0 +1,124 @@
+package descriptor
+
+import (
+	"fmt"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Field numbers of the google.api.routing extension and of the messages it
+// holds, see google/api/routing.proto. The vendored googleapis annotations
+// predate the extension, so it is decoded from the unknown fields of the
+// method options.
+const (
+	routingExtensionNumber         protowire.Number = 72295729
+	routingRuleParametersNumber    protowire.Number = 2
+	routingParameterFieldNumber    protowire.Number = 1
+	routingParameterTemplateNumber protowire.Number = 2
+)
+
+// routingParameterSpec is a google.api.RoutingParameter.
+type routingParameterSpec struct {
+	field        string
+	pathTemplate string
+}
+
+// extractRoutingParameters returns the routing parameters of the
+// google.api.routing annotation of meth, if any.
+func extractRoutingParameters(meth *descriptorpb.MethodDescriptorProto) ([]routingParameterSpec, error) {
+	if meth.Options == nil {
+		return nil, nil
+	}
+	var specs []routingParameterSpec
+	err := rangeFields(meth.Options.ProtoReflect().GetUnknown(), func(num protowire.Number, rule []byte) error {
+		if num != routingExtensionNumber {
+			return nil
+		}
+		return rangeFields(rule, func(num protowire.Number, param []byte) error {
+			if num != routingRuleParametersNumber {
+				return nil
+			}
+			var spec routingParameterSpec
+			err := rangeFields(param, func(num protowire.Number, value []byte) error {
+				switch num {
+				case routingParameterFieldNumber:
+					spec.field = string(value)
+				case routingParameterTemplateNumber:
+					spec.pathTemplate = string(value)
+				}
+				return nil
+			})
+			specs = append(specs, spec)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid google.api.routing annotation on %s: %v", meth.GetName(), err)
+	}
+	return specs, nil
+}
+
+// rangeFields calls fn with the number and the value of every length-delimited
+// field of the serialized message b, skipping the other fields.
+func rangeFields(b []byte, fn func(num protowire.Number, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRoutingParameter returns the routing parameter of meth extracting the
+// value of spec.field. A parameter without path template uses the field path
+// as key and the whole field value as value.
+func (r *Registry) newRoutingParameter(meth *Method, spec routingParameterSpec) (*RoutingParameter, error) {
+	fields, err := r.resolveFieldPath(meth.RequestType, spec.field, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing parameter field %q of %s: %v", spec.field, meth.GetName(), err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("routing parameter of %s has no field", meth.GetName())
+	}
+	if t := fields[len(fields)-1].Target; t.GetType() != descriptorpb.FieldDescriptorProto_TYPE_STRING || t.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return nil, fmt.Errorf("routing parameter field %q of %s is not a singular string", spec.field, meth.GetName())
+	}
+
+	pathTemplate := spec.pathTemplate
+	if pathTemplate == "" {
+		pathTemplate = fmt.Sprintf("{%s=**}", spec.field)
+	}
+	parsed, err := httprule.Parse("/" + pathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing parameter path template %q of %s: %v", spec.pathTemplate, meth.GetName(), err)
+	}
+	tmpl := parsed.Compile()
+	if len(tmpl.Fields) != 1 {
+		return nil, fmt.Errorf("routing parameter path template %q of %s must have exactly one named segment", spec.pathTemplate, meth.GetName())
+	}
+	return &RoutingParameter{
+		FieldPath: FieldPath(fields),
+		Key:       tmpl.Fields[0],
+		PathTmpl:  tmpl,
+	}, nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	gwoptions "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway/options"
+	openapiv2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
 	options "google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -37,7 +39,7 @@ func (r *Registry) loadServices(file *File) error {
 			}
 			if len(optsList) == 0 {
 				if r.generateUnboundMethods {
-					defaultOpts, err := defaultAPIOptions(svc, md)
+					defaultOpts, err := defaultAPIOptions(svc, md, r.unboundMethodsPattern)
 					if err != nil {
 						glog.Errorf("Failed to generate default HttpRule from %s.%s: %v", svc.GetName(), md.GetName(), err)
 						return err
@@ -194,6 +196,28 @@ func (r *Registry) newMethod(svc *Service, md *descriptorpb.MethodDescriptorProt
 		}
 	}
 
+	specs, err := extractRoutingParameters(md)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		param, err := r.newRoutingParameter(meth, spec)
+		if err != nil {
+			return nil, err
+		}
+		meth.RoutingParameters = append(meth.RoutingParameters, param)
+	}
+
+	if meth.Authorization, err = extractAuthorization(md); err != nil {
+		return nil, err
+	}
+	if meth.StreamEnvelope, err = extractStreamEnvelope(md); err != nil {
+		return nil, err
+	}
+	if meth.CacheControl, err = extractCacheControl(md); err != nil {
+		return nil, err
+	}
+
 	return meth, nil
 }
 
@@ -212,7 +236,61 @@ func extractAPIOptions(meth *descriptorpb.MethodDescriptorProto) (*options.HttpR
 	return opts, nil
 }
 
-func defaultAPIOptions(svc *Service, md *descriptorpb.MethodDescriptorProto) (*options.HttpRule, error) {
+// extractGatewayOptions returns the options of the gateway on meth, set with
+// the gateway field of its openapiv2_operation option, or nil if there are
+// none.
+func extractGatewayOptions(meth *descriptorpb.MethodDescriptorProto) (*gwoptions.MethodOptions, error) {
+	if meth.Options == nil {
+		return nil, nil
+	}
+	if !proto.HasExtension(meth.Options, openapiv2options.E_Openapiv2Operation) {
+		return nil, nil
+	}
+	ext := proto.GetExtension(meth.Options, openapiv2options.E_Openapiv2Operation)
+	opts, ok := ext.(*openapiv2options.Operation)
+	if !ok {
+		return nil, fmt.Errorf("extension is %T; want an Operation", ext)
+	}
+	return opts.GetGateway(), nil
+}
+
+func extractAuthorization(meth *descriptorpb.MethodDescriptorProto) (*gwoptions.Authorization, error) {
+	opts, err := extractGatewayOptions(meth)
+	if err != nil {
+		return nil, err
+	}
+	return opts.GetAuthorization(), nil
+}
+
+func extractStreamEnvelope(meth *descriptorpb.MethodDescriptorProto) (*gwoptions.StreamEnvelope, error) {
+	opts, err := extractGatewayOptions(meth)
+	if err != nil {
+		return nil, err
+	}
+	envelope := opts.GetStreamEnvelope()
+	if envelope != nil && !meth.GetServerStreaming() {
+		return nil, fmt.Errorf("stream_envelope option of %s is only valid for server streaming methods", meth.GetName())
+	}
+	return envelope, nil
+}
+
+func extractCacheControl(meth *descriptorpb.MethodDescriptorProto) (string, error) {
+	opts, err := extractGatewayOptions(meth)
+	if err != nil {
+		return "", err
+	}
+	value := opts.GetCacheControl()
+	if value != "" && (meth.GetClientStreaming() || meth.GetServerStreaming()) {
+		return "", fmt.Errorf("cache_control option of %s is only valid for unary methods", meth.GetName())
+	}
+	return value, nil
+}
+
+func defaultAPIOptions(svc *Service, md *descriptorpb.MethodDescriptorProto, pattern string) (*options.HttpRule, error) {
+	if pattern != "" {
+		return patternAPIOptions(svc, md, pattern)
+	}
+
 	// FQSN prefixes the service's full name with a '.', e.g.: '.example.ExampleService'
 	fqsn := strings.TrimPrefix(svc.FQSN(), ".")
 
@@ -231,6 +309,42 @@ func defaultAPIOptions(svc *Service, md *descriptorpb.MethodDescriptorProto) (*o
 	return rule, nil
 }
 
+// patternAPIOptions generates an HttpRule binding md to the path built from
+// pattern. Methods reading resources, i.e. those named Get* or List* without a
+// streamed request, are bound to GET with their fields as query parameters.
+// Other methods are bound to POST with the request message as body.
+func patternAPIOptions(svc *Service, md *descriptorpb.MethodDescriptorProto, pattern string) (*options.HttpRule, error) {
+	path := strings.NewReplacer(
+		"{package}", strings.ToLower(svc.File.GetPackage()),
+		"{service}", strings.ToLower(svc.GetName()),
+		"{method}", strings.ToLower(md.GetName()),
+	).Replace(pattern)
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("unbound methods pattern %q has unknown placeholders, want {package}, {service} or {method}", pattern)
+	}
+
+	if isReadMethod(md.GetName()) && !md.GetClientStreaming() {
+		return &options.HttpRule{
+			Pattern: &options.HttpRule_Get{Get: path},
+		}, nil
+	}
+	return &options.HttpRule{
+		Pattern: &options.HttpRule_Post{Post: path},
+		Body:    "*",
+	}, nil
+}
+
+// isReadMethod reports whether name is Get or List followed by a new word,
+// e.g. GetUser or ListUsers but not Getaway.
+func isReadMethod(name string) bool {
+	for _, verb := range []string{"Get", "List"} {
+		if rest := strings.TrimPrefix(name, verb); rest != name {
+			return rest == "" || strings.ToUpper(rest[:1]) == rest[:1]
+		}
+	}
+	return false
+}
+
 func (r *Registry) newParam(meth *Method, path string) (Parameter, error) {
 	msg := meth.RequestType
 	fields, err := r.resolveFieldPath(msg, path, true)
@@ -283,6 +397,12 @@ func (r *Registry) newResponse(meth *Method, path string) (*Body, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The parents of a nested response body are read with their getters.
+	for _, c := range fields[:len(fields)-1] {
+		if c.Target.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+			return nil, fmt.Errorf("repeated field not allowed in the parents of response body: %s in %s", c.Name, path)
+		}
+	}
 	return &Body{FieldPath: FieldPath(fields)}, nil
 }
 
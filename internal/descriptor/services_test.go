@@ -2,10 +2,14 @@ package descriptor
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	gwoptions "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway/options"
+	openapiv2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
@@ -353,6 +357,484 @@ func TestExtractServicesGenerateUnboundMethods(t *testing.T) {
 	testExtractServicesWithRegistry(t, reg, []*descriptorpb.FileDescriptorProto{&fd}, "path/to/example.proto", file.Services)
 }
 
+func TestExtractServicesUnboundMethodsPattern(t *testing.T) {
+	src := `
+		name: "path/to/example.proto",
+		package: "example.v1"
+		message_type <
+			name: "StringMessage"
+			field <
+				name: "string"
+				number: 1
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+		>
+		service <
+			name: "UserService"
+			method <
+				name: "GetUser"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "ListUsers"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "CreateUser"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "Getaway"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "ListUsersStream"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+				client_streaming: true
+			>
+		>
+	`
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+		t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+	}
+	reg := NewRegistry()
+	reg.SetGenerateUnboundMethods(true)
+	if err := reg.SetUnboundMethodsPattern("/api/{package}/{service}/{method}"); err != nil {
+		t.Fatalf("reg.SetUnboundMethodsPattern() failed with %v; want success", err)
+	}
+	reg.loadFile(&fd)
+	file := reg.files["path/to/example.proto"]
+	if err := reg.loadServices(file); err != nil {
+		t.Fatalf("loadServices(%q) failed with %v; want success", file.GetName(), err)
+	}
+
+	for i, want := range []struct {
+		httpMethod string
+		path       string
+		body       bool
+	}{
+		{httpMethod: "GET", path: "/api/example.v1/userservice/getuser"},
+		{httpMethod: "GET", path: "/api/example.v1/userservice/listusers"},
+		{httpMethod: "POST", path: "/api/example.v1/userservice/createuser", body: true},
+		{httpMethod: "POST", path: "/api/example.v1/userservice/getaway", body: true},
+		{httpMethod: "POST", path: "/api/example.v1/userservice/listusersstream", body: true},
+	} {
+		meth := file.Services[0].Methods[i]
+		if len(meth.Bindings) != 1 {
+			t.Errorf("%s has %d bindings; want 1", meth.GetName(), len(meth.Bindings))
+			continue
+		}
+		b := meth.Bindings[0]
+		if b.HTTPMethod != want.httpMethod || b.PathTmpl.Template != want.path || (b.Body != nil) != want.body {
+			t.Errorf("%s is bound to %s %s with body %v; want %s %s with body %t", meth.GetName(), b.HTTPMethod, b.PathTmpl.Template, b.Body, want.httpMethod, want.path, want.body)
+		}
+	}
+}
+
+func TestUnboundMethodsPatternErrors(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.SetUnboundMethodsPattern("api/{service}"); err == nil {
+		t.Errorf("reg.SetUnboundMethodsPattern(%q) succeeded; want an error for a relative pattern", "api/{service}")
+	}
+
+	svc := &Service{
+		File:                   &File{FileDescriptorProto: &descriptorpb.FileDescriptorProto{Package: proto.String("example")}},
+		ServiceDescriptorProto: &descriptorpb.ServiceDescriptorProto{Name: proto.String("UserService")},
+	}
+	md := &descriptorpb.MethodDescriptorProto{Name: proto.String("GetUser")}
+	if _, err := defaultAPIOptions(svc, md, "/api/{version}/{method}"); err == nil {
+		t.Errorf("defaultAPIOptions(%q) succeeded; want an error for an unknown placeholder", "/api/{version}/{method}")
+	}
+}
+
+// routingAnnotation returns method options carrying a google.api.routing
+// annotation with the given field and path template pairs.
+func routingAnnotation(params ...string) *descriptorpb.MethodOptions {
+	var rule []byte
+	for i := 0; i < len(params); i += 2 {
+		var param []byte
+		param = protowire.AppendTag(param, routingParameterFieldNumber, protowire.BytesType)
+		param = protowire.AppendString(param, params[i])
+		if params[i+1] != "" {
+			param = protowire.AppendTag(param, routingParameterTemplateNumber, protowire.BytesType)
+			param = protowire.AppendString(param, params[i+1])
+		}
+		rule = protowire.AppendTag(rule, routingRuleParametersNumber, protowire.BytesType)
+		rule = protowire.AppendBytes(rule, param)
+	}
+	var ext []byte
+	ext = protowire.AppendTag(ext, routingExtensionNumber, protowire.BytesType)
+	ext = protowire.AppendBytes(ext, rule)
+	opts := &descriptorpb.MethodOptions{}
+	opts.ProtoReflect().SetUnknown(ext)
+	return opts
+}
+
+func TestExtractServicesRoutingParameters(t *testing.T) {
+	src := `
+		name: "path/to/example.proto",
+		package: "example"
+		message_type <
+			name: "StringMessage"
+			field <
+				name: "name"
+				number: 1
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+			field <
+				name: "app_profile_id"
+				number: 2
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+			field <
+				name: "count"
+				number: 3
+				label: LABEL_OPTIONAL
+				type: TYPE_INT32
+			>
+		>
+		service <
+			name: "ExampleService"
+			method <
+				name: "Echo"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+		>
+	`
+	for _, spec := range []struct {
+		name    string
+		params  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "path templates",
+			params: []string{"name", "{routing_id=projects/*}/**", "app_profile_id", ""},
+			want:   []string{"name routing_id /{routing_id=projects/*}/**", "app_profile_id app_profile_id /{app_profile_id=**}"},
+		},
+		{
+			name:    "unknown field",
+			params:  []string{"parent", ""},
+			wantErr: true,
+		},
+		{
+			name:    "non-string field",
+			params:  []string{"count", ""},
+			wantErr: true,
+		},
+		{
+			name:    "no named segment",
+			params:  []string{"name", "projects/*"},
+			wantErr: true,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			var fd descriptorpb.FileDescriptorProto
+			if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+				t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+			}
+			fd.Service[0].Method[0].Options = routingAnnotation(spec.params...)
+			reg := NewRegistry()
+			reg.loadFile(&fd)
+			file := reg.files["path/to/example.proto"]
+			err := reg.loadServices(file)
+			if spec.wantErr {
+				if err == nil {
+					t.Errorf("loadServices(%q) succeeded; want an error", file.GetName())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadServices(%q) failed with %v; want success", file.GetName(), err)
+			}
+			var got []string
+			for _, p := range file.Services[0].Methods[0].RoutingParameters {
+				got = append(got, strings.Join([]string{p.FieldPath.String(), p.Key, p.PathTmpl.Template}, " "))
+			}
+			if !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("RoutingParameters = %q; want %q", got, spec.want)
+			}
+		})
+	}
+}
+
+func TestExtractServicesAuthorization(t *testing.T) {
+	src := `
+		name: "path/to/example.proto",
+		package: "example"
+		message_type <
+			name: "StringMessage"
+			field <
+				name: "string"
+				number: 1
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+		>
+		service <
+			name: "ExampleService"
+			method <
+				name: "Echo"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "Ping"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+		>
+	`
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+		t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+	}
+	want := &gwoptions.Authorization{Roles: []string{"admin"}, Scopes: []string{"echo.write"}}
+	fd.Service[0].Method[0].Options = &descriptorpb.MethodOptions{}
+	proto.SetExtension(fd.Service[0].Method[0].Options, openapiv2options.E_Openapiv2Operation, &openapiv2options.Operation{
+		Gateway: &gwoptions.MethodOptions{Authorization: want},
+	})
+
+	// Round-trip the options as protoc would encode them.
+	b, err := proto.Marshal(&fd)
+	if err != nil {
+		t.Fatalf("proto.Marshal(&fd) failed with %v; want success", err)
+	}
+	fd.Reset()
+	if err := proto.Unmarshal(b, &fd); err != nil {
+		t.Fatalf("proto.Unmarshal(b, &fd) failed with %v; want success", err)
+	}
+
+	reg := NewRegistry()
+	reg.loadFile(&fd)
+	file := reg.files["path/to/example.proto"]
+	if err := reg.loadServices(file); err != nil {
+		t.Fatalf("loadServices(%q) failed with %v; want success", file.GetName(), err)
+	}
+	if got := file.Services[0].Methods[0].Authorization; !proto.Equal(got, want) {
+		t.Errorf("Methods[0].Authorization = %v; want %v", got, want)
+	}
+	if got := file.Services[0].Methods[1].Authorization; got != nil {
+		t.Errorf("Methods[1].Authorization = %v; want nil", got)
+	}
+}
+
+func TestExtractServicesStreamEnvelope(t *testing.T) {
+	src := `
+		name: "path/to/example.proto",
+		package: "example"
+		message_type <
+			name: "StringMessage"
+			field <
+				name: "string"
+				number: 1
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+		>
+		service <
+			name: "ExampleService"
+			method <
+				name: "Watch"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+				server_streaming: true
+			>
+			method <
+				name: "Echo"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+		>
+	`
+	load := func(t *testing.T, method int, envelope *gwoptions.StreamEnvelope) (*File, error) {
+		var fd descriptorpb.FileDescriptorProto
+		if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+			t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+		}
+		fd.Service[0].Method[method].Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(fd.Service[0].Method[method].Options, openapiv2options.E_Openapiv2Operation, &openapiv2options.Operation{
+			Gateway: &gwoptions.MethodOptions{StreamEnvelope: envelope},
+		})
+		reg := NewRegistry()
+		reg.loadFile(&fd)
+		file := reg.files["path/to/example.proto"]
+		return file, reg.loadServices(file)
+	}
+
+	want := &gwoptions.StreamEnvelope{ResultKey: "data", MetadataKey: "meta"}
+	file, err := load(t, 0, want)
+	if err != nil {
+		t.Fatalf("loadServices(%q) failed with %v; want success", file.GetName(), err)
+	}
+	if got := file.Services[0].Methods[0].StreamEnvelope; !proto.Equal(got, want) {
+		t.Errorf("Methods[0].StreamEnvelope = %v; want %v", got, want)
+	}
+	if got := file.Services[0].Methods[1].StreamEnvelope; got != nil {
+		t.Errorf("Methods[1].StreamEnvelope = %v; want nil", got)
+	}
+
+	if _, err := load(t, 1, &gwoptions.StreamEnvelope{Bare: true}); err == nil {
+		t.Errorf("loadServices(file) succeeded with a stream_envelope option on a unary method; want an error")
+	}
+}
+
+func TestExtractServicesCacheControl(t *testing.T) {
+	src := `
+		name: "path/to/example.proto",
+		package: "example"
+		message_type <
+			name: "StringMessage"
+			field <
+				name: "string"
+				number: 1
+				label: LABEL_OPTIONAL
+				type: TYPE_STRING
+			>
+		>
+		service <
+			name: "ExampleService"
+			method <
+				name: "Echo"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+			>
+			method <
+				name: "Watch"
+				input_type: "StringMessage"
+				output_type: "StringMessage"
+				server_streaming: true
+			>
+		>
+	`
+	load := func(t *testing.T, method int, value string) (*File, error) {
+		var fd descriptorpb.FileDescriptorProto
+		if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+			t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+		}
+		fd.Service[0].Method[method].Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(fd.Service[0].Method[method].Options, openapiv2options.E_Openapiv2Operation, &openapiv2options.Operation{
+			Gateway: &gwoptions.MethodOptions{CacheControl: value},
+		})
+		reg := NewRegistry()
+		reg.loadFile(&fd)
+		file := reg.files["path/to/example.proto"]
+		return file, reg.loadServices(file)
+	}
+
+	const want = "public, max-age=60"
+	file, err := load(t, 0, want)
+	if err != nil {
+		t.Fatalf("loadServices(%q) failed with %v; want success", file.GetName(), err)
+	}
+	if got := file.Services[0].Methods[0].CacheControl; got != want {
+		t.Errorf("Methods[0].CacheControl = %q; want %q", got, want)
+	}
+	if got := file.Services[0].Methods[1].CacheControl; got != "" {
+		t.Errorf("Methods[1].CacheControl = %q; want empty", got)
+	}
+
+	if _, err := load(t, 1, want); err == nil {
+		t.Errorf("loadServices(file) succeeded with a cache_control option on a streaming method; want an error")
+	}
+}
+
+func TestExtractServicesNestedResponseBody(t *testing.T) {
+	for _, spec := range []struct {
+		responseBody string
+		wantErr      bool
+	}{
+		{responseBody: "result.items"},
+		{responseBody: "result.total"},
+		{responseBody: "result.pages.items", wantErr: true},
+		{responseBody: "result.missing", wantErr: true},
+	} {
+		t.Run(spec.responseBody, func(t *testing.T) {
+			src := `
+				name: "path/to/example.proto",
+				package: "example"
+				message_type <
+					name: "Envelope"
+					field <
+						name: "result"
+						number: 1
+						label: LABEL_OPTIONAL
+						type: TYPE_MESSAGE
+						type_name: "Result"
+					>
+				>
+				message_type <
+					name: "Result"
+					field <
+						name: "items"
+						number: 1
+						label: LABEL_REPEATED
+						type: TYPE_STRING
+					>
+					field <
+						name: "total"
+						number: 2
+						label: LABEL_OPTIONAL
+						type: TYPE_INT32
+					>
+					field <
+						name: "pages"
+						number: 3
+						label: LABEL_REPEATED
+						type: TYPE_MESSAGE
+						type_name: "Result"
+					>
+				>
+				service <
+					name: "ExampleService"
+					method <
+						name: "List"
+						input_type: "Envelope"
+						output_type: "Envelope"
+						options <
+							[google.api.http] <
+								get: "/v1/items"
+								response_body: "` + spec.responseBody + `"
+							>
+						>
+					>
+				>
+			`
+			var fd descriptorpb.FileDescriptorProto
+			if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+				t.Fatalf("prototext.Unmarshal (%s, &fd) failed with %v; want success", src, err)
+			}
+			reg := NewRegistry()
+			reg.SetAllowRepeatedFieldsInBody(true)
+			reg.loadFile(&fd)
+			file := reg.files["path/to/example.proto"]
+			err := reg.loadServices(file)
+			if spec.wantErr {
+				if err == nil {
+					t.Errorf("loadServices() with response_body %q succeeded; want an error", spec.responseBody)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadServices() with response_body %q failed with %v; want success", spec.responseBody, err)
+			}
+			if got := file.Services[0].Methods[0].Bindings[0].ResponseBody.FieldPath.String(); got != spec.responseBody {
+				t.Errorf("ResponseBody.FieldPath = %q; want %q", got, spec.responseBody)
+			}
+		})
+	}
+}
+
 func TestExtractServicesCrossPackage(t *testing.T) {
 	srcs := []string{
 		`
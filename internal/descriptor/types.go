@@ -2,10 +2,14 @@ package descriptor
 
 import (
 	"fmt"
+	"go/token"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	gwoptions "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway/options"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/pluginpb"
 )
@@ -87,6 +91,10 @@ type Message struct {
 	Index int
 
 	ForcePrefixedName bool
+
+	// presence is the field presence of the fields of the message in an editions file,
+	// unless their features override it.
+	presence fieldPresence
 }
 
 // FQMN returns a fully qualified message name of this message.
@@ -203,6 +211,19 @@ type Method struct {
 	// ResponseType is the message type of responses from this method.
 	ResponseType *Message
 	Bindings     []*Binding
+	// RoutingParameters are the routing parameters of the google.api.routing
+	// annotation of the method, extracted into the x-goog-request-params header.
+	RoutingParameters []*RoutingParameter
+	// Authorization is the authorization annotation of the method, checked by
+	// the runtime.Authorizer of the mux before the method is called. It is nil
+	// if the method has none.
+	Authorization *gwoptions.Authorization
+	// StreamEnvelope is the envelope of the chunks of the responses of the
+	// server streaming method if it overrides the one of the mux, or nil.
+	StreamEnvelope *gwoptions.StreamEnvelope
+	// CacheControl is the Cache-Control header of the responses of the unary
+	// method, or empty.
+	CacheControl string
 }
 
 // FQMN returns a fully qualified rpc method name of this method.
@@ -213,6 +234,18 @@ func (m *Method) FQMN() string {
 	return strings.Join(components, ".")
 }
 
+// RoutingParameter describes how a key of the x-goog-request-params header is
+// extracted from a field of the request.
+type RoutingParameter struct {
+	// FieldPath is the path to the string field the value is extracted from.
+	FieldPath FieldPath
+	// Key is the key of the value in the header.
+	Key string
+	// PathTmpl is the template the field value is matched against. Its only
+	// variable, named Key, captures the value.
+	PathTmpl httprule.Template
+}
+
 // Binding describes how an HTTP endpoint is bound to a gRPC method.
 type Binding struct {
 	// Method is the method which the endpoint is bound to.
@@ -255,6 +288,12 @@ type Field struct {
 	ForcePrefixedName bool
 }
 
+// inOneof determines if the field is a member of a oneof, other than the
+// synthetic oneof of a proto3 optional field.
+func (f *Field) inOneof() bool {
+	return f.OneofIndex != nil && !f.GetProto3Optional()
+}
+
 // FQFN returns a fully qualified field name of this field.
 func (f *Field) FQFN() string {
 	return strings.Join([]string{f.Message.FQMN(), f.GetName()}, ".")
@@ -274,11 +313,12 @@ type Parameter struct {
 // The converter function converts a string into a value for the parameter.
 func (p Parameter) ConvertFuncExpr() (string, error) {
 	tbl := proto3ConvertFuncs
-	if !p.IsProto2() && p.IsRepeated() {
+	explicit := p.Target.explicitPresence()
+	if !explicit && p.IsRepeated() {
 		tbl = proto3RepeatedConvertFuncs
-	} else if p.IsProto2() && !p.IsRepeated() {
+	} else if explicit && !p.IsRepeated() {
 		tbl = proto2ConvertFuncs
-	} else if p.IsProto2() && p.IsRepeated() {
+	} else if explicit && p.IsRepeated() {
 		tbl = proto2RepeatedConvertFuncs
 	}
 	typ := p.Target.GetType()
@@ -292,6 +332,19 @@ func (p Parameter) ConvertFuncExpr() (string, error) {
 	return conv, nil
 }
 
+// ArgName returns a Go identifier for the parameter, to be used as the name of
+// a function argument: the lower camel case of its field path, with a trailing
+// underscore if it is a Go keyword.
+func (p Parameter) ArgName() string {
+	name := casing.Camel(strings.Replace(p.FieldPath.String(), ".", "_", -1))
+	r, n := utf8.DecodeRuneInString(name)
+	name = string(unicode.ToLower(r)) + name[n:]
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
 // IsEnum returns true if the field is an enum type, otherwise false is returned.
 func (p Parameter) IsEnum() bool {
 	return p.Target.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM
@@ -341,6 +394,20 @@ func (p FieldPath) IsNestedProto3() bool {
 	return false
 }
 
+// ValueExpr is an expression in Go reading the value of the target field, e.g. a nested response_body.
+// It starts with "msgExpr", which is the go expression of the message. The fields of nested paths are read
+// with their getters, so that unset parent messages read as the zero value of the target field.
+func (p FieldPath) ValueExpr(msgExpr string) string {
+	if len(p) == 1 && !p[0].Target.inOneof() {
+		return msgExpr + "." + p[0].AssignableExpr()
+	}
+	expr := msgExpr
+	for _, c := range p {
+		expr += ".Get" + c.AssignableExpr() + "()"
+	}
+	return expr
+}
+
 // AssignableExpr is an assignable expression in Go to be used to assign a value to the target field.
 // It starts with "msgExpr", which is the go expression of the method request object.
 func (p FieldPath) AssignableExpr(msgExpr string) string {
@@ -353,7 +420,7 @@ func (p FieldPath) AssignableExpr(msgExpr string) string {
 	components := msgExpr
 	for i, c := range p {
 		// Check if it is a oneOf field.
-		if c.Target.OneofIndex != nil {
+		if c.Target.inOneof() {
 			index := c.Target.OneofIndex
 			msg := c.Target.Message
 			oneOfName := casing.Camel(msg.GetOneofDecl()[*index].GetName())
@@ -401,7 +468,7 @@ func (c FieldPathComponent) AssignableExpr() string {
 
 // ValueExpr returns an expression in go for this field.
 func (c FieldPathComponent) ValueExpr() string {
-	if c.Target.Message.File.proto2() {
+	if c.Target.explicitPresence() {
 		return fmt.Sprintf("Get%s()", casing.Camel(c.Name))
 	}
 	return casing.Camel(c.Name)
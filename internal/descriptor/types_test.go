@@ -1,6 +1,7 @@
 package descriptor
 
 import (
+	"strings"
 	"testing"
 
 	"google.golang.org/protobuf/encoding/prototext"
@@ -203,6 +204,16 @@ func TestFieldPath(t *testing.T) {
 	if got, want := fpEmpty.AssignableExpr("resp"), "resp"; got != want {
 		t.Errorf("fpEmpty.AssignableExpr(%q) = %q; want %q", "resp", got, want)
 	}
+
+	if got, want := fp.ValueExpr("resp"), "resp.GetNestField().GetNest2Field().GetNestField().GetTerminalField()"; got != want {
+		t.Errorf("fp.ValueExpr(%q) = %q; want %q", "resp", got, want)
+	}
+	if got, want := (FieldPath{c2}).ValueExpr("resp"), "resp.Nest2Field"; got != want {
+		t.Errorf("FieldPath{c2}.ValueExpr(%q) = %q; want %q", "resp", got, want)
+	}
+	if got, want := fpEmpty.ValueExpr("resp"), "resp"; got != want {
+		t.Errorf("fpEmpty.ValueExpr(%q) = %q; want %q", "resp", got, want)
+	}
 }
 
 func TestGoType(t *testing.T) {
@@ -267,3 +278,90 @@ func TestGoType(t *testing.T) {
 	}
 
 }
+
+func TestParameterArgName(t *testing.T) {
+	for _, spec := range []struct {
+		path []string
+		want string
+	}{
+		{path: []string{"id"}, want: "id"},
+		{path: []string{"user_id"}, want: "userId"},
+		{path: []string{"user", "display_name"}, want: "userDisplayName"},
+		{path: []string{"type"}, want: "type_"},
+	} {
+		var fp FieldPath
+		for _, name := range spec.path {
+			fp = append(fp, FieldPathComponent{Name: name})
+		}
+		if got := (Parameter{FieldPath: fp}).ArgName(); got != spec.want {
+			t.Errorf("Parameter{FieldPath: %v}.ArgName() = %q; want %q", fp, got, spec.want)
+		}
+	}
+}
+
+func TestProto3OptionalParameter(t *testing.T) {
+	const src = `
+		name: 'example.proto'
+		package: 'example'
+		syntax: 'proto3'
+		message_type <
+			name: 'ExampleMessage'
+			field <
+				name: 'optional'
+				label: LABEL_OPTIONAL
+				type: TYPE_INT32
+				number: 1
+				oneof_index: 0
+				proto3_optional: true
+			>
+			field <
+				name: 'member'
+				label: LABEL_OPTIONAL
+				type: TYPE_INT32
+				number: 2
+				oneof_index: 1
+			>
+			oneof_decl <
+				name: '_optional'
+			>
+			oneof_decl <
+				name: 'choice'
+			>
+		>
+	`
+	var fd descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(src), &fd); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s, &fd) failed with %v; want success", src, err)
+	}
+	reg := NewRegistry()
+	reg.loadFile(&fd)
+	msg, err := reg.LookupMsg("", ".example.ExampleMessage")
+	if err != nil {
+		t.Fatalf("reg.LookupMsg(%q, %q) failed with %v; want success", "", ".example.ExampleMessage", err)
+	}
+
+	for _, spec := range []struct {
+		field          *Field
+		wantConvert    string
+		wantAssignable string
+	}{
+		{
+			field:          msg.Fields[0],
+			wantConvert:    "runtime.Int32P",
+			wantAssignable: "protoReq.Optional",
+		},
+		{
+			field:          msg.Fields[1],
+			wantConvert:    "runtime.Int32",
+			wantAssignable: "if protoReq.Choice == nil {",
+		},
+	} {
+		p := Parameter{FieldPath: FieldPath{{Name: spec.field.GetName(), Target: spec.field}}, Target: spec.field}
+		if got, err := p.ConvertFuncExpr(); err != nil || got != spec.wantConvert {
+			t.Errorf("p.ConvertFuncExpr() = %q, %v; want %q, nil", got, err, spec.wantConvert)
+		}
+		if got := p.AssignableExpr("protoReq"); !strings.HasPrefix(got, spec.wantAssignable) {
+			t.Errorf("p.AssignableExpr(%q) = %q; want prefix %q", "protoReq", got, spec.wantAssignable)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package gents provides a code generator for TypeScript clients calling the
+// REST endpoints of the gateway with fetch.
+package gents
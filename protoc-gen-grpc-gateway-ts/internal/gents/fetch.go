@@ -0,0 +1,276 @@
+package gents
+
+// fetchFileName is the name of the file of the runtime of the clients, at
+// the root of the output directory.
+const fetchFileName = "fetch.pb.gw.ts"
+
+// fetchRuntime calls the bindings described by the generated files with
+// fetch, building the requests the way the generated handlers of the gateway
+// parse them, like runtime.Client: path parameters are taken from the fields
+// bound by the path template, the body from the body field, and the remaining
+// fields are sent as query parameters.
+const fetchRuntime = `// Code generated by protoc-gen-grpc-gateway-ts. DO NOT EDIT.
+/* eslint-disable */
+
+/** ClientOptions configure the clients calling the gateway. */
+export interface ClientOptions {
+  /** baseURL is the URL of the gateway, e.g. "https://api.example.com", the origin of the page if unset. */
+  baseURL?: string;
+  /** fetch sends the requests, the global fetch if unset. */
+  fetch?: typeof fetch;
+  /** headers are added to every request. */
+  headers?: Record<string, string>;
+}
+
+/** StreamEnvelope describes how the chunks of the server streams wrap their messages and errors. */
+export interface StreamEnvelope {
+  bare?: boolean;
+  resultKey?: string;
+  errorKey?: string;
+}
+
+/** Binding describes the binding of a method to a REST endpoint. */
+export interface Binding<Req> {
+  method: string;
+  /** path builds the path of the request from its path parameters. */
+  path: (req: Req) => string;
+  /** body returns the body of the request, if the binding has one. */
+  body?: (req: Req) => unknown;
+  /**
+   * query, if set, lists the fields not sent as query parameters, as the
+   * paths of their names in JSON, e.g. the path parameters and the body.
+   */
+  query?: string[];
+  /** responseBody is the path of the field of the response written as the body, if not the whole response. */
+  responseBody?: string[];
+  /** envelope is the envelope of the chunks of the server stream, the default one if unset. */
+  envelope?: StreamEnvelope;
+}
+
+/** Status is the error replied by the gateway, a google.rpc.Status. */
+export interface Status {
+  code?: number;
+  message?: string;
+  details?: unknown[];
+}
+
+/** GatewayError is thrown by the clients when the gateway replies with an error. */
+export class GatewayError extends Error {
+  constructor(readonly httpStatus: number, readonly status: Status) {
+    super(status.message || "unexpected HTTP status " + httpStatus);
+    this.name = "GatewayError";
+  }
+
+  /** code is the gRPC status code of the error. */
+  get code(): number {
+    return this.status.code ?? 2;
+  }
+}
+
+/**
+ * pathParam formats the value of a path parameter, joining repeated values
+ * with separator and escaping the slashes of the values of single segments.
+ */
+export function pathParam(value: unknown, single: boolean, separator: string, bytes = false): string {
+  let s = Array.isArray(value) ? value.map(String).join(separator) : value === undefined || value === null ? "" : String(value);
+  if (bytes) {
+    // Unlike queries, paths are parsed from the URL-safe encoding too,
+    // which does not need '/' to be escaped.
+    s = s.replace(/\+/g, "-").replace(/\//g, "_");
+  }
+  if (single) {
+    return encodeURIComponent(s);
+  }
+  return s.split("/").map(encodeURIComponent).join("/");
+}
+
+/** queryString returns the query of the fields of req, named by their path, but those of exclude. */
+export function queryString(req: unknown, exclude: string[]): string {
+  const params = new URLSearchParams();
+  const add = (path: string, value: unknown): void => {
+    if (value === undefined || value === null || exclude.indexOf(path) >= 0) {
+      return;
+    }
+    if (Array.isArray(value)) {
+      for (const v of value) {
+        if (v !== null && typeof v !== "object") {
+          params.append(path, String(v));
+        }
+      }
+    } else if (typeof value === "object") {
+      for (const [k, v] of Object.entries(value as Record<string, unknown>)) {
+        add(path ? path + "." + k : k, v);
+      }
+    } else {
+      params.append(path, String(value));
+    }
+  };
+  add("", req);
+  const query = params.toString();
+  return query ? "?" + query : "";
+}
+
+function requestURL<Req>(options: ClientOptions, binding: Binding<Req>, req: Req): string {
+  let url = (options.baseURL ?? "").replace(/\/$/, "") + binding.path(req);
+  if (binding.query) {
+    url += queryString(req, binding.query);
+  }
+  return url;
+}
+
+async function send(options: ClientOptions, method: string, url: string, body: string | undefined, init?: RequestInit): Promise<Response> {
+  const headers = new Headers(options.headers);
+  new Headers(init?.headers).forEach((v, k) => headers.set(k, v));
+  if (body !== undefined) {
+    headers.set("Content-Type", "application/json");
+  }
+  const resp = await (options.fetch ?? fetch)(url, { ...init, method, headers, body });
+  if (!resp.ok) {
+    throw await gatewayError(resp);
+  }
+  return resp;
+}
+
+async function gatewayError(resp: Response): Promise<GatewayError> {
+  let status: Status | undefined;
+  try {
+    status = (await resp.json()) as Status;
+  } catch {
+    // The error is not a status, e.g. it was replied by a proxy.
+  }
+  if (!status || typeof status.code !== "number" || status.code === 0) {
+    status = { code: codeFromHTTPStatus(resp.status), message: "unexpected HTTP status " + resp.status };
+  }
+  return new GatewayError(resp.status, status);
+}
+
+/** codeFromHTTPStatus is the gRPC status code of the errors without a status body. */
+function codeFromHTTPStatus(httpStatus: number): number {
+  switch (httpStatus) {
+    case 400:
+      return 3;
+    case 401:
+      return 16;
+    case 403:
+      return 7;
+    case 404:
+      return 5;
+    case 409:
+      return 10;
+    case 429:
+      return 8;
+    case 501:
+      return 12;
+    case 503:
+      return 14;
+    case 504:
+      return 4;
+    case 499:
+      return 1;
+  }
+  return 2;
+}
+
+/** wrap returns the response whose field at path is body. */
+function wrap(path: string[] | undefined, body: unknown): unknown {
+  if (!path) {
+    return body;
+  }
+  return path.reduceRight((value: unknown, key: string) => ({ [key]: value }), body);
+}
+
+async function response<Req, Resp>(binding: Binding<Req>, resp: Response): Promise<Resp> {
+  const text = await resp.text();
+  return wrap(binding.responseBody, text ? JSON.parse(text) : {}) as Resp;
+}
+
+/** streamBody returns the requests of a client stream, as a sequence of JSON values. */
+function streamBody<Req>(reqs: Req[]): string {
+  return reqs.map((req) => JSON.stringify(req)).join("\n");
+}
+
+/** messages yields the messages of the chunks of a server stream, throwing its error. */
+async function* messages<Req, Resp>(binding: Binding<Req>, resp: Response): AsyncGenerator<Resp> {
+  const envelope = binding.envelope ?? {};
+  const resultKey = envelope.resultKey || "result";
+  const errorKey = envelope.errorKey || "error";
+  for await (const chunk of chunks(resp)) {
+    if (envelope.bare) {
+      yield wrap(binding.responseBody, chunk) as Resp;
+      continue;
+    }
+    const c = chunk as Record<string, unknown>;
+    if (c[errorKey]) {
+      throw new GatewayError(resp.status, c[errorKey] as Status);
+    }
+    // The chunks without a result are heartbeats.
+    if (resultKey in c) {
+      yield wrap(binding.responseBody, c[resultKey]) as Resp;
+    }
+  }
+}
+
+/** chunks yields the newline delimited JSON values of the body of resp. */
+async function* chunks(resp: Response): AsyncGenerator<unknown> {
+  if (!resp.body) {
+    return;
+  }
+  const reader = resp.body.getReader();
+  const decoder = new TextDecoder();
+  let buf = "";
+  try {
+    for (;;) {
+      const { done, value } = await reader.read();
+      buf += done ? decoder.decode() : decoder.decode(value, { stream: true });
+      let i: number;
+      while ((i = buf.indexOf("\n")) >= 0) {
+        const line = buf.slice(0, i).trim();
+        buf = buf.slice(i + 1);
+        if (line) {
+          yield JSON.parse(line);
+        }
+      }
+      if (done) {
+        break;
+      }
+    }
+    if (buf.trim()) {
+      yield JSON.parse(buf);
+    }
+  } finally {
+    reader.releaseLock();
+  }
+}
+
+/** unary calls the binding of a unary method. */
+export async function unary<Req, Resp>(options: ClientOptions, binding: Binding<Req>, req: Req, init?: RequestInit): Promise<Resp> {
+  const body = binding.body ? JSON.stringify(binding.body(req)) : undefined;
+  const resp = await send(options, binding.method, requestURL(options, binding, req), body, init);
+  return response<Req, Resp>(binding, resp);
+}
+
+/** serverStream calls the binding of a server streaming method, yielding the messages of the stream. */
+export async function* serverStream<Req, Resp>(options: ClientOptions, binding: Binding<Req>, req: Req, init?: RequestInit): AsyncGenerator<Resp> {
+  const body = binding.body ? JSON.stringify(binding.body(req)) : undefined;
+  const resp = await send(options, binding.method, requestURL(options, binding, req), body, init);
+  yield* messages<Req, Resp>(binding, resp);
+}
+
+/** clientStream calls the binding of a client streaming method with the requests. */
+export async function clientStream<Req, Resp>(options: ClientOptions, binding: Binding<Req>, reqs: Req[], init?: RequestInit): Promise<Resp> {
+  const url = (options.baseURL ?? "").replace(/\/$/, "") + binding.path(reqs[0] ?? ({} as Req));
+  const resp = await send(options, binding.method, url, streamBody(reqs), init);
+  return response<Req, Resp>(binding, resp);
+}
+
+/**
+ * bidiStream calls the binding of a bidirectional streaming method with the
+ * requests, yielding the messages of the stream. The requests are all sent
+ * before the responses are read, as fetch does not stream request bodies.
+ */
+export async function* bidiStream<Req, Resp>(options: ClientOptions, binding: Binding<Req>, reqs: Req[], init?: RequestInit): AsyncGenerator<Resp> {
+  const url = (options.baseURL ?? "").replace(/\/$/, "") + binding.path(reqs[0] ?? ({} as Req));
+  const resp = await send(options, binding.method, url, streamBody(reqs), init);
+  yield* messages<Req, Resp>(binding, resp);
+}
+`
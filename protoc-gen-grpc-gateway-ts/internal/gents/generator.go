@@ -0,0 +1,375 @@
+package gents
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/glog"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	gen "github.com/grpc-ecosystem/grpc-gateway/v2/internal/generator"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+type generator struct {
+	reg *descriptor.Registry
+}
+
+// New returns a new generator which generates TypeScript clients calling the
+// REST endpoints of the gateway.
+func New(reg *descriptor.Registry) gen.Generator {
+	return &generator{reg: reg}
+}
+
+func (g *generator) Generate(targets []*descriptor.File) ([]*descriptor.ResponseFile, error) {
+	var files []*descriptor.ResponseFile
+	withClients := false
+	for _, file := range targets {
+		glog.V(1).Infof("Processing %s", file.GetName())
+		data, err := g.buildFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.GetName(), err)
+		}
+		var buf bytes.Buffer
+		if err := fileTemplate.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		withClients = withClients || data.Runtime != ""
+		files = append(files, &descriptor.ResponseFile{
+			GoPkg: file.GoPkg,
+			CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+				Name:    proto.String(tsBase(file.GetName()) + ".ts"),
+				Content: proto.String(buf.String()),
+			},
+		})
+	}
+	if withClients {
+		files = append(files, &descriptor.ResponseFile{
+			CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+				Name:    proto.String(fetchFileName),
+				Content: proto.String(fetchRuntime),
+			},
+		})
+	}
+	return files, nil
+}
+
+// buildFile returns the data of the template of the file generated for file.
+func (g *generator) buildFile(file *descriptor.File) (*tsFile, error) {
+	c := &fileContext{reg: g.reg, file: file, imports: make(map[string]string)}
+	data := &tsFile{Source: file.GetName()}
+	for _, e := range file.Enums {
+		te := tsEnum{Name: tsName(e.Outers, e.GetName()), AsInts: g.reg.GetEnumsAsInts()}
+		for _, v := range e.GetValue() {
+			te.Values = append(te.Values, tsEnumValue{Name: v.GetName(), Number: v.GetNumber()})
+		}
+		data.Enums = append(data.Enums, te)
+	}
+	for _, m := range file.Messages {
+		if m.GetOptions().GetMapEntry() {
+			continue
+		}
+		tm := tsMessage{Name: tsName(m.Outers, m.GetName())}
+		for _, f := range m.Fields {
+			t, err := c.fieldType(f)
+			if err != nil {
+				return nil, err
+			}
+			tm.Fields = append(tm.Fields, tsField{Name: c.property(f), Type: t})
+		}
+		data.Messages = append(data.Messages, tm)
+	}
+	for _, svc := range file.Services {
+		ts := tsService{Name: svc.GetName(), FQSN: strings.TrimPrefix(svc.FQSN(), ".")}
+		for _, meth := range svc.Methods {
+			if len(meth.Bindings) == 0 {
+				continue
+			}
+			m, err := g.buildMethod(c, meth)
+			if err != nil {
+				return nil, err
+			}
+			ts.Methods = append(ts.Methods, m)
+		}
+		if len(ts.Methods) > 0 {
+			data.Services = append(data.Services, ts)
+		}
+	}
+	if len(data.Services) > 0 {
+		data.Runtime = importPath(file.GetName(), strings.TrimSuffix(fetchFileName, ".ts"))
+	}
+	for alias, path := range c.imports {
+		data.Imports = append(data.Imports, tsImport{Alias: alias, Path: path})
+	}
+	sort.Slice(data.Imports, func(i, j int) bool { return data.Imports[i].Alias < data.Imports[j].Alias })
+	return data, nil
+}
+
+// buildMethod returns the method of the client calling the first binding of
+// meth, like the generated HTTP clients of protoc-gen-grpc-gateway.
+func (g *generator) buildMethod(c *fileContext, meth *descriptor.Method) (tsMethod, error) {
+	b := meth.Bindings[0]
+	m := tsMethod{
+		Name:     lowerFirst(meth.GetName()),
+		RPC:      meth.GetName(),
+		Request:  c.messageType(meth.RequestType),
+		Response: c.messageType(meth.ResponseType),
+		Streams:  meth.GetClientStreaming(),
+		Binding:  fmt.Sprintf("%s_%s_%d", meth.Service.GetName(), meth.GetName(), b.Index),
+		Verb:     b.HTTPMethod,
+		Template: strings.Replace(b.PathTmpl.Template, "*/", `*\/`, -1),
+	}
+	switch {
+	case meth.GetClientStreaming() && meth.GetServerStreaming():
+		m.Call, m.Returns = "bidiStream", fmt.Sprintf("AsyncGenerator<%s>", m.Response)
+	case meth.GetClientStreaming():
+		m.Call, m.Returns = "clientStream", fmt.Sprintf("Promise<%s>", m.Response)
+	case meth.GetServerStreaming():
+		m.Call, m.Returns = "serverStream", fmt.Sprintf("AsyncGenerator<%s>", m.Response)
+	default:
+		m.Call, m.Returns = "unary", fmt.Sprintf("Promise<%s>", m.Response)
+	}
+
+	path, err := g.buildPath(c, b)
+	if err != nil {
+		return tsMethod{}, err
+	}
+	m.Path = path
+
+	// The fields of the requests which are not query parameters, as the
+	// paths of their names in JSON.
+	exclude := []string{}
+	for _, p := range b.PathParams {
+		exclude = append(exclude, jsonPath(c, p.FieldPath))
+	}
+	if b.Body != nil {
+		m.Body = "(req) => req"
+		if len(b.Body.FieldPath) > 0 {
+			target := b.Body.FieldPath[len(b.Body.FieldPath)-1].Target
+			zero, err := c.zeroValue(target)
+			if err != nil {
+				return tsMethod{}, err
+			}
+			m.Body = fmt.Sprintf("(req) => %s ?? %s", accessor("req", jsonNames(c, b.Body.FieldPath)), zero)
+			exclude = append(exclude, jsonPath(c, b.Body.FieldPath))
+		}
+	}
+	if !meth.GetClientStreaming() && (b.Body == nil || len(b.Body.FieldPath) > 0) {
+		opaque, err := opaqueFields(c, meth.RequestType, "", nil)
+		if err != nil {
+			return tsMethod{}, err
+		}
+		m.Query = tsStrings(append(exclude, opaque...))
+	}
+	if b.ResponseBody != nil && len(b.ResponseBody.FieldPath) > 0 {
+		m.ResponseBody = tsStrings(jsonNames(c, b.ResponseBody.FieldPath))
+	}
+	if env := meth.StreamEnvelope; env != nil && meth.GetServerStreaming() {
+		var props []string
+		if env.GetBare() {
+			props = append(props, "bare: true")
+		}
+		if env.GetResultKey() != "" {
+			props = append(props, "resultKey: "+strconv.Quote(env.GetResultKey()))
+		}
+		if env.GetErrorKey() != "" {
+			props = append(props, "errorKey: "+strconv.Quote(env.GetErrorKey()))
+		}
+		m.Envelope = "{ " + strings.Join(props, ", ") + " }"
+		if len(props) == 0 {
+			m.Envelope = "{}"
+		}
+	}
+	return m, nil
+}
+
+// pathItem is an item of the stack of the path template being built.
+type pathItem struct {
+	// parts are the literal parts and the TypeScript expressions of the
+	// item.
+	parts []pathPart
+	// single is true for items matching a single path component.
+	single bool
+}
+
+type pathPart struct {
+	literal string
+	expr    string
+}
+
+// buildPath returns the TypeScript function building the path of the binding
+// from the request, like runtime.Pattern.Build.
+func (g *generator) buildPath(c *fileContext, b *descriptor.Binding) (string, error) {
+	params := make(map[string]descriptor.Parameter, len(b.PathParams))
+	for _, p := range b.PathParams {
+		params[p.FieldPath.String()] = p
+	}
+	sep := string(g.reg.GetRepeatedPathParamSeparator())
+	tmpl := b.PathTmpl
+	var stack []pathItem
+	for i := 0; i+1 < len(tmpl.OpCodes); i += 2 {
+		operand := tmpl.OpCodes[i+1]
+		switch utilities.OpCode(tmpl.OpCodes[i]) {
+		case utilities.OpPush:
+			stack = append(stack, pathItem{parts: []pathPart{{literal: "*"}}, single: true})
+		case utilities.OpLitPush:
+			stack = append(stack, pathItem{parts: []pathPart{{literal: tmpl.Pool[operand]}}})
+		case utilities.OpPushM:
+			stack = append(stack, pathItem{parts: []pathPart{{literal: "**"}}})
+		case utilities.OpConcatN:
+			if operand == 1 {
+				continue
+			}
+			l := len(stack) - operand
+			if l < 0 {
+				return "", fmt.Errorf("invalid path template %q", tmpl.Template)
+			}
+			item := pathItem{}
+			for j, it := range stack[l:] {
+				if j > 0 {
+					item.parts = append(item.parts, pathPart{literal: "/"})
+				}
+				item.parts = append(item.parts, it.parts...)
+			}
+			stack = append(stack[:l], item)
+		case utilities.OpCapture:
+			if len(stack) == 0 {
+				return "", fmt.Errorf("invalid path template %q", tmpl.Template)
+			}
+			p, ok := params[tmpl.Pool[operand]]
+			if !ok {
+				return "", fmt.Errorf("no path parameter %q in the binding of %s", tmpl.Pool[operand], b.Method.GetName())
+			}
+			zero, err := c.zeroValue(p.Target)
+			if err != nil {
+				return "", err
+			}
+			n := len(stack) - 1
+			expr := fmt.Sprintf("gw.pathParam(%s ?? %s, %t, %q", accessor("req", jsonNames(c, p.FieldPath)), zero, stack[n].single, sep)
+			if p.Target.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BYTES {
+				expr += ", true"
+			}
+			stack[n] = pathItem{parts: []pathPart{{expr: expr + ")"}}}
+		}
+	}
+
+	parts := []pathPart{{literal: "/"}}
+	for i, it := range stack {
+		if i > 0 {
+			parts = append(parts, pathPart{literal: "/"})
+		}
+		parts = append(parts, it.parts...)
+	}
+	if tmpl.Verb != "" {
+		parts = append(parts, pathPart{literal: ":" + tmpl.Verb})
+	}
+	var exprs []string
+	var literal strings.Builder
+	usesReq := false
+	for _, part := range parts {
+		if part.expr == "" {
+			literal.WriteString(part.literal)
+			continue
+		}
+		if literal.Len() > 0 {
+			exprs = append(exprs, strconv.Quote(literal.String()))
+			literal.Reset()
+		}
+		exprs = append(exprs, part.expr)
+		usesReq = true
+	}
+	if literal.Len() > 0 {
+		exprs = append(exprs, strconv.Quote(literal.String()))
+	}
+	arg := "req"
+	if !usesReq {
+		arg = "_req"
+	}
+	return fmt.Sprintf("(%s) => %s", arg, strings.Join(exprs, " + ")), nil
+}
+
+// opaqueFields returns the paths of the fields of m, and of its nested
+// messages, which are not sent as query parameters: the maps and the
+// well-known types written as objects. The messages of visiting are not
+// visited again, to end with the recursive messages.
+func opaqueFields(c *fileContext, m *descriptor.Message, prefix string, visiting []string) ([]string, error) {
+	for _, fqmn := range visiting {
+		if fqmn == m.FQMN() {
+			return nil, nil
+		}
+	}
+	visiting = append(visiting, m.FQMN())
+	var paths []string
+	for _, f := range m.Fields {
+		if f.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+			continue
+		}
+		path := prefix + c.fieldName(f)
+		if _, ok, err := c.isMap(f); err != nil {
+			return nil, err
+		} else if ok {
+			paths = append(paths, path)
+			continue
+		}
+		if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+			continue
+		}
+		fm, err := c.message(f)
+		if err != nil {
+			return nil, err
+		}
+		if opaqueTypes[fm.FQMN()] {
+			paths = append(paths, path)
+			continue
+		}
+		if _, ok := wellKnownTypes[fm.FQMN()]; ok {
+			continue
+		}
+		nested, err := opaqueFields(c, fm, path+".", visiting)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, nested...)
+	}
+	return paths, nil
+}
+
+// jsonNames returns the names in JSON of the fields of the path.
+func jsonNames(c *fileContext, path descriptor.FieldPath) []string {
+	names := make([]string, 0, len(path))
+	for _, component := range path {
+		name := component.Name
+		if component.Target != nil {
+			name = c.fieldName(component.Target)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func jsonPath(c *fileContext, path descriptor.FieldPath) string {
+	return strings.Join(jsonNames(c, path), ".")
+}
+
+// tsStrings returns the array of strings ss written in TypeScript.
+func tsStrings(ss []string) string {
+	quoted := make([]string, 0, len(ss))
+	for _, s := range ss {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// lowerFirst returns s with its first letter lower-cased, e.g. "getBook" for
+// "GetBook".
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
@@ -0,0 +1,242 @@
+package gents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const libraryRequest = `
+file_to_generate: 'a/common.proto'
+file_to_generate: 'a/b/library.proto'
+proto_file <
+	name: 'google/protobuf/timestamp.proto'
+	package: 'google.protobuf'
+	message_type <
+		name: 'Timestamp'
+		field < name: 'seconds' number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: 'seconds' >
+		field < name: 'nanos' number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: 'nanos' >
+	>
+	syntax: 'proto3'
+>
+proto_file <
+	name: 'a/common.proto'
+	package: 'common'
+	options < go_package: 'example.com/library;library' >
+	enum_type <
+		name: 'Kind'
+		value < name: 'KIND_UNSPECIFIED' number: 0 >
+		value < name: 'KIND_BOOK' number: 1 >
+	>
+	syntax: 'proto3'
+>
+proto_file <
+	name: 'a/b/library.proto'
+	package: 'library'
+	options < go_package: 'example.com/library;library' >
+	dependency: 'a/common.proto'
+	dependency: 'google/protobuf/timestamp.proto'
+	message_type <
+		name: 'Book'
+		field < name: 'name' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'name' >
+		field < name: 'id' number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: 'id' >
+		field < name: 'tags' number: 3 label: LABEL_REPEATED type: TYPE_STRING json_name: 'tags' >
+		field < name: 'labels' number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: '.library.Book.LabelsEntry' json_name: 'labels' >
+		field < name: 'kind' number: 5 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: '.common.Kind' json_name: 'kind' >
+		field < name: 'create_time' number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: '.google.protobuf.Timestamp' json_name: 'createTime' >
+		field < name: 'author' number: 7 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: '.library.Book.Author' json_name: 'author' >
+		nested_type <
+			name: 'LabelsEntry'
+			field < name: 'key' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'key' >
+			field < name: 'value' number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'value' >
+			options < map_entry: true >
+		>
+		nested_type <
+			name: 'Author'
+			field < name: 'display_name' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'displayName' >
+		>
+	>
+	message_type <
+		name: 'GetBookRequest'
+		field < name: 'name' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'name' >
+		field < name: 'digest' number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES json_name: 'digest' >
+	>
+	message_type <
+		name: 'CreateBookRequest'
+		field < name: 'parent' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'parent' >
+		field < name: 'book' number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: '.library.Book' json_name: 'book' >
+	>
+	service <
+		name: 'Library'
+		method < name: 'GetBook' input_type: '.library.GetBookRequest' output_type: '.library.Book' >
+		method < name: 'CreateBook' input_type: '.library.CreateBookRequest' output_type: '.library.Book' >
+		method < name: 'WatchBooks' input_type: '.library.GetBookRequest' output_type: '.library.Book' server_streaming: true >
+		method < name: 'UploadBooks' input_type: '.library.Book' output_type: '.library.Book' client_streaming: true >
+		method < name: 'Unbound' input_type: '.library.Book' output_type: '.library.Book' >
+	>
+	syntax: 'proto3'
+>
+`
+
+func generateLibrary(t *testing.T, configure func(reg *descriptor.Registry)) map[string]string {
+	var req pluginpb.CodeGeneratorRequest
+	if err := prototext.Unmarshal([]byte(libraryRequest), &req); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s) failed with %v; want success", libraryRequest, err)
+	}
+	reg := descriptor.NewRegistry()
+	reg.SetUseJSONNamesForFields(true)
+	reg.AddExternalHTTPRule(".library.Library.GetBook", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=shelves/*/books/*}/{digest}"},
+	})
+	reg.AddExternalHTTPRule(".library.Library.CreateBook", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/{parent=shelves/*}/books"},
+		Body:    "book",
+	})
+	reg.AddExternalHTTPRule(".library.Library.WatchBooks", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/books:watch"},
+	})
+	reg.AddExternalHTTPRule(".library.Library.UploadBooks", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/books:upload"},
+		Body:    "*",
+	})
+	if configure != nil {
+		configure(reg)
+	}
+	if err := reg.Load(&req); err != nil {
+		t.Fatalf("reg.Load(req) failed with %v; want success", err)
+	}
+	var targets []*descriptor.File
+	for _, name := range req.GetFileToGenerate() {
+		f, err := reg.LookupFile(name)
+		if err != nil {
+			t.Fatalf("reg.LookupFile(%q) failed with %v; want success", name, err)
+		}
+		targets = append(targets, f)
+	}
+	files, err := New(reg).Generate(targets)
+	if err != nil {
+		t.Fatalf("Generate(targets) failed with %v; want success", err)
+	}
+	contents := make(map[string]string, len(files))
+	for _, f := range files {
+		contents[f.GetName()] = f.GetContent()
+	}
+	return contents
+}
+
+func TestGenerate(t *testing.T) {
+	files := generateLibrary(t, nil)
+	if got, want := len(files), 3; got != want {
+		t.Errorf("len(Generate(targets)) = %d; want %d", got, want)
+	}
+	if got := files["fetch.pb.gw.ts"]; got != fetchRuntime {
+		t.Errorf("fetch.pb.gw.ts = %s; want the runtime", got)
+	}
+
+	common := files["a/common.pb.gw.ts"]
+	for _, want := range []string{
+		`export type Kind = "KIND_UNSPECIFIED" | "KIND_BOOK";`,
+	} {
+		if !strings.Contains(common, want) {
+			t.Errorf("a/common.pb.gw.ts = %s; want it to contain %s", common, want)
+		}
+	}
+	if strings.Contains(common, "import") {
+		t.Errorf("a/common.pb.gw.ts = %s; want no imports", common)
+	}
+
+	library := files["a/b/library.pb.gw.ts"]
+	for _, want := range []string{
+		`import * as gw from "../../fetch.pb.gw";`,
+		`import * as a_common from "../common.pb.gw";`,
+		`export interface Book {
+  name?: string;
+  id?: string;
+  tags?: string[];
+  labels?: { [key: string]: string };
+  kind?: a_common.Kind;
+  createTime?: string;
+  author?: Book_Author;
+}`,
+		`export interface Book_Author {
+  displayName?: string;
+}`,
+		`const Library_GetBook_0: gw.Binding<GetBookRequest> = {
+  method: "GET",
+  path: (req) => "/v1/" + gw.pathParam(req.name ?? "", false, ",") + "/" + gw.pathParam(req.digest ?? "", true, ",", true),
+  query: ["name", "digest"],
+};`,
+		`const Library_CreateBook_0: gw.Binding<CreateBookRequest> = {
+  method: "POST",
+  path: (req) => "/v1/" + gw.pathParam(req.parent ?? "", false, ",") + "/books",
+  body: (req) => req.book ?? {},
+  query: ["parent", "book", "book.labels"],
+};`,
+		`const Library_UploadBooks_0: gw.Binding<Book> = {
+  method: "POST",
+  path: (_req) => "/v1/books:upload",
+  body: (req) => req,
+};`,
+		`export class LibraryClient {`,
+		`  getBook(req: GetBookRequest, init?: RequestInit): Promise<Book> {
+    return gw.unary<GetBookRequest, Book>(this._options, Library_GetBook_0, req, init);
+  }`,
+		`  watchBooks(req: GetBookRequest, init?: RequestInit): AsyncGenerator<Book> {
+    return gw.serverStream<GetBookRequest, Book>(this._options, Library_WatchBooks_0, req, init);
+  }`,
+		`  uploadBooks(reqs: Book[], init?: RequestInit): Promise<Book> {
+    return gw.clientStream<Book, Book>(this._options, Library_UploadBooks_0, reqs, init);
+  }`,
+	} {
+		if !strings.Contains(library, want) {
+			t.Errorf("a/b/library.pb.gw.ts = %s; want it to contain %s", library, want)
+		}
+	}
+	if strings.Contains(library, "unbound") {
+		t.Errorf("a/b/library.pb.gw.ts = %s; want no client method for the method without bindings", library)
+	}
+}
+
+func TestGenerateWithOptions(t *testing.T) {
+	files := generateLibrary(t, func(reg *descriptor.Registry) {
+		reg.SetUseJSONNamesForFields(false)
+		reg.SetEnumsAsInts(true)
+		if err := reg.SetRepeatedPathParamSeparator("pipes"); err != nil {
+			t.Fatalf("reg.SetRepeatedPathParamSeparator(%q) failed with %v; want success", "pipes", err)
+		}
+	})
+	for name, want := range map[string]string{
+		"a/common.pb.gw.ts": `export enum Kind {
+  KIND_UNSPECIFIED = 0,
+  KIND_BOOK = 1,
+}`,
+		"a/b/library.pb.gw.ts": `  create_time?: string;`,
+	} {
+		if got := files[name]; !strings.Contains(got, want) {
+			t.Errorf("%s = %s; want it to contain %s", name, got, want)
+		}
+	}
+	if got, want := files["a/b/library.pb.gw.ts"], `gw.pathParam(req.parent ?? "", false, "|")`; !strings.Contains(got, want) {
+		t.Errorf("a/b/library.pb.gw.ts = %s; want it to contain %s", got, want)
+	}
+}
+
+func TestImportPath(t *testing.T) {
+	for _, spec := range []struct {
+		from, base, want string
+	}{
+		{from: "example.proto", base: "fetch.pb.gw", want: "./fetch.pb.gw"},
+		{from: "a/b/example.proto", base: "fetch.pb.gw", want: "../../fetch.pb.gw"},
+		{from: "a/b/example.proto", base: "a/b/other.pb.gw", want: "./other.pb.gw"},
+		{from: "a/b/example.proto", base: "a/c/other.pb.gw", want: "../c/other.pb.gw"},
+		{from: "a/example.proto", base: "a/b/other.pb.gw", want: "./b/other.pb.gw"},
+	} {
+		if got := importPath(spec.from, spec.base); got != spec.want {
+			t.Errorf("importPath(%q, %q) = %q; want %q", spec.from, spec.base, got, spec.want)
+		}
+	}
+}
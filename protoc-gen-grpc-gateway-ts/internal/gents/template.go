@@ -0,0 +1,141 @@
+package gents
+
+import (
+	"text/template"
+)
+
+// tsFile is the data of the template of a generated file.
+type tsFile struct {
+	// Source is the name of the proto file.
+	Source string
+	// Runtime is the path the runtime of the clients is imported from, or
+	// empty if the file has no clients.
+	Runtime  string
+	Imports  []tsImport
+	Enums    []tsEnum
+	Messages []tsMessage
+	Services []tsService
+}
+
+type tsImport struct {
+	Alias string
+	Path  string
+}
+
+type tsEnum struct {
+	Name   string
+	AsInts bool
+	Values []tsEnumValue
+}
+
+type tsEnumValue struct {
+	Name   string
+	Number int32
+}
+
+type tsMessage struct {
+	Name   string
+	Fields []tsField
+}
+
+type tsField struct {
+	// Name is the name of the field as a property.
+	Name string
+	Type string
+}
+
+type tsService struct {
+	Name string
+	// FQSN is the fully qualified name of the service, without the leading
+	// dot.
+	FQSN    string
+	Methods []tsMethod
+}
+
+type tsMethod struct {
+	// Name is the name of the method of the client.
+	Name string
+	// RPC is the name of the method in the proto file.
+	RPC string
+	// Call is the function of the runtime calling the binding, depending on
+	// the kind of streaming of the method.
+	Call     string
+	Request  string
+	Response string
+	// Streams reports whether the method takes a stream of requests.
+	Streams bool
+	// Returns is the type returned by the method of the client.
+	Returns  string
+	Binding  string
+	Verb     string
+	Template string
+	// Path, Body, Query, ResponseBody and Envelope are the TypeScript
+	// expressions of the fields of the binding, Body, Query, ResponseBody
+	// and Envelope being omitted if empty.
+	Path         string
+	Body         string
+	Query        string
+	ResponseBody string
+	Envelope     string
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by protoc-gen-grpc-gateway-ts. DO NOT EDIT.
+// source: {{.Source}}
+/* eslint-disable */
+{{if or .Runtime .Imports}}
+{{if .Runtime}}import * as gw from "{{.Runtime}}";
+{{end}}
+{{- range .Imports}}import * as {{.Alias}} from "{{.Path}}";
+{{end}}
+{{- end}}
+{{- range .Enums}}
+{{if .AsInts}}export enum {{.Name}} {
+{{- range .Values}}
+  {{.Name}} = {{.Number}},
+{{- end}}
+}
+{{else}}export type {{.Name}} ={{range $i, $v := .Values}}{{if $i}} |{{end}} "{{$v.Name}}"{{else}} never{{end}};
+{{end}}
+{{- end}}
+{{- range .Messages}}
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}?: {{.Type}};
+{{- end}}
+}
+{{end}}
+{{- range $svc := .Services}}
+{{- range $m := .Methods}}
+const {{$m.Binding}}: gw.Binding<{{$m.Request}}> = {
+  method: "{{$m.Verb}}",
+  path: {{$m.Path}},
+{{- if $m.Body}}
+  body: {{$m.Body}},
+{{- end}}
+{{- if $m.Query}}
+  query: {{$m.Query}},
+{{- end}}
+{{- if $m.ResponseBody}}
+  responseBody: {{$m.ResponseBody}},
+{{- end}}
+{{- if $m.Envelope}}
+  envelope: {{$m.Envelope}},
+{{- end}}
+};
+{{end}}
+/**
+ * {{$svc.Name}}Client calls the methods of service {{$svc.FQSN}} through the
+ * REST endpoints of a gateway, building requests the way its handlers parse them.
+ */
+export class {{$svc.Name}}Client {
+  constructor(private readonly _options: gw.ClientOptions = {}) {}
+{{- range $m := .Methods}}
+
+  /** {{$m.Name}} calls {{$m.RPC}} with {{$m.Verb}} {{$m.Template}}. */
+  {{$m.Name}}({{if $m.Streams}}reqs: {{$m.Request}}[]{{else}}req: {{$m.Request}}{{end}}, init?: RequestInit): {{$m.Returns}} {
+    return gw.{{$m.Call}}<{{$m.Request}}, {{$m.Response}}>(this._options, {{$m.Binding}}, {{if $m.Streams}}reqs{{else}}req{{end}}, init);
+  }
+{{- end}}
+}
+{{end -}}
+`))
@@ -0,0 +1,275 @@
+package gents
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// wellKnownTypes are the TypeScript types of the JSON representation of the
+// well-known types.
+var wellKnownTypes = map[string]string{
+	".google.protobuf.Any":         `{ "@type": string; [key: string]: unknown }`,
+	".google.protobuf.Duration":    "string",
+	".google.protobuf.Timestamp":   "string",
+	".google.protobuf.FieldMask":   "string",
+	".google.protobuf.Empty":       "{}",
+	".google.protobuf.Struct":      "{ [key: string]: unknown }",
+	".google.protobuf.Value":       "unknown",
+	".google.protobuf.ListValue":   "unknown[]",
+	".google.protobuf.DoubleValue": "number | null",
+	".google.protobuf.FloatValue":  "number | null",
+	".google.protobuf.Int64Value":  "string | null",
+	".google.protobuf.UInt64Value": "string | null",
+	".google.protobuf.Int32Value":  "number | null",
+	".google.protobuf.UInt32Value": "number | null",
+	".google.protobuf.BoolValue":   "boolean | null",
+	".google.protobuf.StringValue": "string | null",
+	".google.protobuf.BytesValue":  "string | null",
+}
+
+// opaqueTypes are the well-known types whose JSON representation is an
+// object or an array, which are not sent as query parameters.
+var opaqueTypes = map[string]bool{
+	".google.protobuf.Any":       true,
+	".google.protobuf.Struct":    true,
+	".google.protobuf.Value":     true,
+	".google.protobuf.ListValue": true,
+}
+
+// scalarTypes are the TypeScript types of the JSON representation of the
+// scalar types, the 64-bit integers being strings.
+var scalarTypes = map[descriptorpb.FieldDescriptorProto_Type]string{
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:   "number",
+	descriptorpb.FieldDescriptorProto_TYPE_FLOAT:    "number",
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    "string",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   "string",
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    "number",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  "string",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  "number",
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:     "boolean",
+	descriptorpb.FieldDescriptorProto_TYPE_STRING:   "string",
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:    "string",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   "number",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: "number",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: "string",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   "number",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   "string",
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsName returns the name of the TypeScript type of a message or an enum
+// nested in outers, e.g. "Outer_Inner".
+func tsName(outers []string, name string) string {
+	return strings.Join(append(append([]string(nil), outers...), name), "_")
+}
+
+// tsBase returns the path of the file generated for the proto file name,
+// without its extension.
+func tsBase(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ".pb.gw"
+}
+
+// importAlias returns the alias of the file generated for the proto file
+// name when imported by another one.
+func importAlias(name string) string {
+	return invalidAliasChars.ReplaceAllString(strings.TrimSuffix(name, path.Ext(name)), "_")
+}
+
+var invalidAliasChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// importPath returns the path the file generated at base is imported with
+// from the one generated for the proto file from.
+func importPath(from, base string) string {
+	rel := relPath(path.Dir(from), base)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// relPath returns target relative to the directory dir, both being slash
+// separated and relative to the output directory.
+func relPath(dir, target string) string {
+	var from []string
+	if dir != "." {
+		from = strings.Split(dir, "/")
+	}
+	to := strings.Split(target, "/")
+	for len(from) > 0 && len(to) > 1 && from[0] == to[0] {
+		from, to = from[1:], to[1:]
+	}
+	return strings.Repeat("../", len(from)) + strings.Join(to, "/")
+}
+
+// fileContext resolves the TypeScript types of the fields of the messages of
+// a file, recording the other generated files they are imported from.
+type fileContext struct {
+	reg  *descriptor.Registry
+	file *descriptor.File
+	// imports maps the aliases of the imported files to their paths.
+	imports map[string]string
+}
+
+// fieldName returns the name of the field in JSON.
+func (c *fileContext) fieldName(f *descriptor.Field) string {
+	if c.reg.GetUseJSONNamesForFields() && f.GetJsonName() != "" {
+		return f.GetJsonName()
+	}
+	return f.GetName()
+}
+
+// property returns the name of the field as a property of an interface.
+func (c *fileContext) property(f *descriptor.Field) string {
+	name := c.fieldName(f)
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// accessor returns the expression accessing the field at the path of
+// names in the object expr, with optional chaining past the first field.
+func accessor(expr string, names []string) string {
+	for i, name := range names {
+		op := "?."
+		if i == 0 {
+			op = "."
+		}
+		if identifierPattern.MatchString(name) {
+			expr += op + name
+		} else if i == 0 {
+			expr += fmt.Sprintf("[%q]", name)
+		} else {
+			expr += fmt.Sprintf("?.[%q]", name)
+		}
+	}
+	return expr
+}
+
+// message returns the message type of the field.
+func (c *fileContext) message(f *descriptor.Field) (*descriptor.Message, error) {
+	return c.reg.LookupMsg(f.Message.FQMN(), f.GetTypeName())
+}
+
+// isMap reports whether the field is a map.
+func (c *fileContext) isMap(f *descriptor.Field) (*descriptor.Message, bool, error) {
+	if f.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE || f.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return nil, false, nil
+	}
+	m, err := c.message(f)
+	if err != nil {
+		return nil, false, err
+	}
+	return m, m.GetOptions().GetMapEntry(), nil
+}
+
+// fieldType returns the TypeScript type of the field.
+func (c *fileContext) fieldType(f *descriptor.Field) (string, error) {
+	if entry, ok, err := c.isMap(f); err != nil {
+		return "", err
+	} else if ok {
+		value := &descriptor.Field{Message: entry, FieldDescriptorProto: entry.GetField()[1]}
+		t, err := c.singularType(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("{ [key: string]: %s }", t), nil
+	}
+	t, err := c.singularType(f)
+	if err != nil {
+		return "", err
+	}
+	if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		if strings.ContainsAny(t, " |") {
+			t = "(" + t + ")"
+		}
+		return t + "[]", nil
+	}
+	return t, nil
+}
+
+// singularType returns the TypeScript type of a value of the field.
+func (c *fileContext) singularType(f *descriptor.Field) (string, error) {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		m, err := c.message(f)
+		if err != nil {
+			return "", err
+		}
+		return c.messageType(m), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		e, err := c.reg.LookupEnum(f.Message.FQMN(), f.GetTypeName())
+		if err != nil {
+			return "", err
+		}
+		return c.qualify(e.File, tsName(e.Outers, e.GetName())), nil
+	}
+	t, ok := scalarTypes[f.GetType()]
+	if !ok {
+		return "", fmt.Errorf("unsupported type %s of field %s", f.GetType(), f.FQFN())
+	}
+	return t, nil
+}
+
+// messageType returns the TypeScript type of the message.
+func (c *fileContext) messageType(m *descriptor.Message) string {
+	if t, ok := wellKnownTypes[m.FQMN()]; ok {
+		return t
+	}
+	return c.qualify(m.File, tsName(m.Outers, m.GetName()))
+}
+
+// qualify returns the name of a type of file, imported from the file
+// generated for it if it is not the current one.
+func (c *fileContext) qualify(file *descriptor.File, name string) string {
+	if file.GetName() == c.file.GetName() {
+		return name
+	}
+	alias := importAlias(file.GetName())
+	c.imports[alias] = importPath(c.file.GetName(), tsBase(file.GetName()))
+	return alias + "." + name
+}
+
+// zeroValue returns the JSON value the gateway reads the unset field as,
+// written in TypeScript.
+func (c *fileContext) zeroValue(f *descriptor.Field) (string, error) {
+	if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		if _, ok, err := c.isMap(f); err != nil {
+			return "", err
+		} else if ok {
+			return "{}", nil
+		}
+		return "[]", nil
+	}
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return "{}", nil
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		e, err := c.reg.LookupEnum(f.Message.FQMN(), f.GetTypeName())
+		if err != nil {
+			return "", err
+		}
+		if c.reg.GetEnumsAsInts() || len(e.GetValue()) == 0 {
+			return "0", nil
+		}
+		return fmt.Sprintf("%q", e.GetValue()[0].GetName()), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return "false", nil
+	}
+	switch scalarTypes[f.GetType()] {
+	case "number":
+		return "0", nil
+	case "string":
+		if f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING || f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BYTES {
+			return `""`, nil
+		}
+		return `"0"`, nil
+	}
+	return "", fmt.Errorf("unsupported type %s of field %s", f.GetType(), f.FQFN())
+}
@@ -0,0 +1,148 @@
+// Command protoc-gen-grpc-gateway-ts is a plugin for Google protocol buffer
+// compiler to generate TypeScript clients calling the REST endpoints of the
+// reverse-proxy generated by protoc-gen-grpc-gateway with fetch.
+// You rarely need to run this program directly. Instead, put this program
+// into your $PATH with a name "protoc-gen-grpc-gateway-ts" and run
+//   protoc --grpc-gateway-ts_out=output_directory path/to/input.proto
+//
+// The clients build the requests from the same bindings as the handlers of
+// the gateway, so the options changing the bindings, e.g.
+// grpc_api_configuration or generate_unbound_methods, must be the same.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/codegenerator"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway-ts/internal/gents"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var (
+	allowDeleteBody            = flag.Bool("allow_delete_body", false, "unless set, HTTP DELETE methods may not have a body")
+	grpcAPIConfiguration       = flag.String("grpc_api_configuration", "", "path to gRPC API Configuration in YAML format")
+	allowRepeatedFieldsInBody  = flag.Bool("allow_repeated_fields_in_body", false, "allows to use repeated field in `body` and `response_body` field of `google.api.http` annotation option")
+	repeatedPathParamSeparator = flag.String("repeated_path_param_separator", "csv", "configures how repeated fields should be split. Allowed values are `csv`, `pipes`, `ssv` and `tsv`.")
+	generateUnboundMethods     = flag.Bool("generate_unbound_methods", false, "generate clients even for RPC methods that have no HttpRule annotation")
+	unboundMethodsPattern      = flag.String("unbound_methods_pattern", "", "path template of the methods bound by generate_unbound_methods, e.g. `/api/{service}/{method}`, where {package}, {service} and {method} are replaced by lower-cased names; Get* and List* methods are bound to GET")
+	useJSONNamesForFields      = flag.Bool("json_names_for_fields", true, "if disabled, the fields are named by their original proto name, to match a gateway marshaling with `UseProtoNames`")
+	enumsAsInts                = flag.Bool("enums_as_ints", false, "if set, enums are generated as numeric enums, to match a gateway marshaling with `UseEnumNumbers`")
+	versionFlag                = flag.Bool("version", false, "print the current version")
+)
+
+// Variables set by goreleaser at build time
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+func main() {
+	flag.Parse()
+	defer glog.Flush()
+
+	if *versionFlag {
+		fmt.Printf("Version %v, commit %v, built at %v\n", version, commit, date)
+		os.Exit(0)
+	}
+
+	glog.V(1).Info("Parsing code generator request")
+	req, err := codegenerator.ParseRequest(os.Stdin)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if err := parseReqParam(req.GetParameter(), flag.CommandLine); err != nil {
+		emitError(err)
+		return
+	}
+
+	reg := descriptor.NewRegistry()
+	if err := applyFlags(reg); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.Load(req); err != nil {
+		emitError(err)
+		return
+	}
+
+	var targets []*descriptor.File
+	for _, target := range req.FileToGenerate {
+		f, err := reg.LookupFile(target)
+		if err != nil {
+			emitError(err)
+			return
+		}
+		targets = append(targets, f)
+	}
+
+	out, err := gents.New(reg).Generate(targets)
+	glog.V(1).Info("Processed code generator request")
+	if err != nil {
+		emitError(err)
+		return
+	}
+	files := make([]*pluginpb.CodeGeneratorResponse_File, len(out))
+	for i, f := range out {
+		files[i] = f.CodeGeneratorResponse_File
+	}
+	resp := &pluginpb.CodeGeneratorResponse{File: files}
+	codegenerator.SetSupportedFeatures(resp)
+	emitResp(resp)
+}
+
+func applyFlags(reg *descriptor.Registry) error {
+	if *grpcAPIConfiguration != "" {
+		if err := reg.LoadGrpcAPIServiceFromYAML(*grpcAPIConfiguration); err != nil {
+			return err
+		}
+	}
+	reg.SetAllowDeleteBody(*allowDeleteBody)
+	reg.SetAllowRepeatedFieldsInBody(*allowRepeatedFieldsInBody)
+	reg.SetUseJSONNamesForFields(*useJSONNamesForFields)
+	reg.SetEnumsAsInts(*enumsAsInts)
+	reg.SetGenerateUnboundMethods(*generateUnboundMethods)
+	if err := reg.SetUnboundMethodsPattern(*unboundMethodsPattern); err != nil {
+		return err
+	}
+	return reg.SetRepeatedPathParamSeparator(*repeatedPathParamSeparator)
+}
+
+// parseReqParam parses a CodeGeneratorRequest parameter into the flags of f.
+// The flags given without a value are set to true.
+func parseReqParam(param string, f *flag.FlagSet) error {
+	if param == "" {
+		return nil
+	}
+	for _, p := range strings.Split(param, ",") {
+		spec := strings.SplitN(p, "=", 2)
+		value := "true"
+		if len(spec) == 2 {
+			value = spec[1]
+		}
+		if err := f.Set(spec[0], value); err != nil {
+			return fmt.Errorf("cannot set flag %s: %v", p, err)
+		}
+	}
+	return nil
+}
+
+func emitError(err error) {
+	emitResp(&pluginpb.CodeGeneratorResponse{Error: proto.String(err.Error())})
+}
+
+func emitResp(resp *pluginpb.CodeGeneratorResponse) {
+	buf, err := proto.Marshal(resp)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if _, err := os.Stdout.Write(buf); err != nil {
+		glog.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package gengateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// envoyTranscoderType is the type of the configuration of the
+// grpc_json_transcoder filter of Envoy.
+const envoyTranscoderType = "type.googleapis.com/envoy.extensions.filters.http.grpc_json_transcoder.v3.GrpcJsonTranscoder"
+
+// envoyConfig is the content of the *.envoy.json files, configuring the
+// grpc_json_transcoder filter of Envoy to transcode the services of a proto
+// file as the generated code does with the default options of the runtime.
+type envoyConfig struct {
+	Name        string          `json:"name"`
+	TypedConfig envoyTranscoder `json:"typed_config"`
+}
+
+type envoyTranscoder struct {
+	Type string `json:"@type"`
+	// ProtoDescriptor is the path of the *.envoy.pb descriptor set, relative
+	// to the output directory.
+	ProtoDescriptor   string            `json:"proto_descriptor"`
+	Services          []string          `json:"services"`
+	PrintOptions      envoyPrintOptions `json:"print_options"`
+	ConvertGrpcStatus bool              `json:"convert_grpc_status"`
+}
+
+type envoyPrintOptions struct {
+	AddWhitespace              bool `json:"add_whitespace"`
+	AlwaysPrintPrimitiveFields bool `json:"always_print_primitive_fields"`
+	AlwaysPrintEnumsAsInts     bool `json:"always_print_enums_as_ints"`
+	PreserveProtoFieldNames    bool `json:"preserve_proto_field_names"`
+}
+
+// buildEnvoyConfig returns the configuration transcoding the services of the
+// file with bindings, reading the descriptor set at descriptorPath.
+func buildEnvoyConfig(file *descriptor.File, descriptorPath string) envoyConfig {
+	c := envoyConfig{
+		Name: "envoy.filters.http.grpc_json_transcoder",
+		TypedConfig: envoyTranscoder{
+			Type:            envoyTranscoderType,
+			ProtoDescriptor: descriptorPath,
+			Services:        []string{},
+			// The errors are replied to with their status as JSON, as by
+			// runtime.DefaultHTTPErrorHandler.
+			ConvertGrpcStatus: true,
+		},
+	}
+	for _, svc := range file.Services {
+		for _, meth := range svc.Methods {
+			if len(meth.Bindings) > 0 {
+				c.TypedConfig.Services = append(c.TypedConfig.Services, svc.FQSN()[1:])
+				break
+			}
+		}
+	}
+	return c
+}
+
+func encodeEnvoyConfig(c envoyConfig) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// buildEnvoyDescriptorSet returns the descriptor set of the file and its
+// dependencies, looked up with lookup, read by the grpc_json_transcoder
+// filter. The methods of the file are annotated with the HTTP rules of
+// their bindings, so that those of the gRPC API Configuration and the
+// unbound methods are transcoded too.
+func buildEnvoyDescriptorSet(file *descriptor.File, lookup func(name string) (*descriptor.File, error)) (*descriptorpb.FileDescriptorSet, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{file.GetName(): true}
+	var add func(fd *descriptorpb.FileDescriptorProto) error
+	add = func(fd *descriptorpb.FileDescriptorProto) error {
+		for _, dep := range fd.GetDependency() {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			f, err := lookup(dep)
+			if err != nil {
+				return fmt.Errorf("looking up the dependency %s of %s: %w", dep, fd.GetName(), err)
+			}
+			if err := add(f.FileDescriptorProto); err != nil {
+				return err
+			}
+		}
+		set.File = append(set.File, fd)
+		return nil
+	}
+
+	fd := proto.Clone(file.FileDescriptorProto).(*descriptorpb.FileDescriptorProto)
+	for _, sd := range fd.GetService() {
+		for _, svc := range file.Services {
+			if svc.GetName() != sd.GetName() {
+				continue
+			}
+			for _, md := range sd.GetMethod() {
+				for _, meth := range svc.Methods {
+					if meth.GetName() == md.GetName() {
+						setHTTPRule(md, meth.Bindings)
+					}
+				}
+			}
+		}
+	}
+	if err := add(fd); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// setHTTPRule sets the google.api.http option of md to the rule of
+// bindings, or clears it if there are none.
+func setHTTPRule(md *descriptorpb.MethodDescriptorProto, bindings []*descriptor.Binding) {
+	var rule *annotations.HttpRule
+	for _, b := range bindings {
+		r := &annotations.HttpRule{}
+		path := b.PathTmpl.Template
+		switch b.HTTPMethod {
+		case "GET":
+			r.Pattern = &annotations.HttpRule_Get{Get: path}
+		case "PUT":
+			r.Pattern = &annotations.HttpRule_Put{Put: path}
+		case "POST":
+			r.Pattern = &annotations.HttpRule_Post{Post: path}
+		case "DELETE":
+			r.Pattern = &annotations.HttpRule_Delete{Delete: path}
+		case "PATCH":
+			r.Pattern = &annotations.HttpRule_Patch{Patch: path}
+		default:
+			r.Pattern = &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: b.HTTPMethod, Path: path}}
+		}
+		if b.Body != nil {
+			r.Body = "*"
+			if len(b.Body.FieldPath) > 0 {
+				r.Body = b.Body.FieldPath.String()
+			}
+		}
+		if b.ResponseBody != nil && len(b.ResponseBody.FieldPath) > 0 {
+			r.ResponseBody = b.ResponseBody.FieldPath.String()
+		}
+		if rule == nil {
+			rule = r
+		} else {
+			rule.AdditionalBindings = append(rule.AdditionalBindings, r)
+		}
+	}
+	if rule == nil {
+		if md.Options != nil {
+			proto.ClearExtension(md.Options, annotations.E_Http)
+		}
+		return
+	}
+	if md.Options == nil {
+		md.Options = &descriptorpb.MethodOptions{}
+	}
+	proto.SetExtension(md.Options, annotations.E_Http, rule)
+}
@@ -0,0 +1,87 @@
+package gengateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestBuildEnvoyConfig(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	encoded, err := encodeEnvoyConfig(buildEnvoyConfig(file, "example.pb.gw.envoy.pb"))
+	if err != nil {
+		t.Fatalf("encodeEnvoyConfig() failed with %v; want success", err)
+	}
+	var got envoyConfig
+	if err := json.Unmarshal([]byte(encoded), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed with %v; want success", encoded, err)
+	}
+	want := envoyConfig{
+		Name: "envoy.filters.http.grpc_json_transcoder",
+		TypedConfig: envoyTranscoder{
+			Type:              envoyTranscoderType,
+			ProtoDescriptor:   "example.pb.gw.envoy.pb",
+			Services:          []string{"example.ExampleService"},
+			ConvertGrpcStatus: true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("encodeEnvoyConfig() = %s; want %+v", encoded, want)
+	}
+}
+
+func TestBuildEnvoyDescriptorSet(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = httprule.Template{Template: "/v1/example"}
+	deps := map[string]*descriptorpb.FileDescriptorProto{
+		"a.example/b/c.proto": {Name: proto.String("a.example/b/c.proto")},
+		"a.example/d/e.proto": {
+			Name:       proto.String("a.example/d/e.proto"),
+			Dependency: []string{"a.example/b/c.proto", "google/protobuf/empty.proto"},
+		},
+		"google/protobuf/empty.proto": {Name: proto.String("google/protobuf/empty.proto")},
+	}
+	lookup := func(name string) (*descriptor.File, error) {
+		fd, ok := deps[name]
+		if !ok {
+			return nil, fmt.Errorf("no such file: %s", name)
+		}
+		return &descriptor.File{FileDescriptorProto: fd}, nil
+	}
+
+	set, err := buildEnvoyDescriptorSet(file, lookup)
+	if err != nil {
+		t.Fatalf("buildEnvoyDescriptorSet() failed with %v; want success", err)
+	}
+	var names []string
+	for _, fd := range set.GetFile() {
+		names = append(names, fd.GetName())
+	}
+	if want := []string{"a.example/b/c.proto", "google/protobuf/empty.proto", "a.example/d/e.proto", "example.proto"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("buildEnvoyDescriptorSet() files = %q; want %q", names, want)
+	}
+
+	methods := set.GetFile()[3].GetService()[0].GetMethod()
+	rule, ok := proto.GetExtension(methods[0].GetOptions(), annotations.E_Http).(*annotations.HttpRule)
+	if want := (&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/example"}, Body: "*"}); !ok || !proto.Equal(rule, want) {
+		t.Errorf("http rule of %s = %v; want %v", methods[0].GetName(), rule, want)
+	}
+	if proto.HasExtension(methods[1].GetOptions(), annotations.E_Http) {
+		t.Errorf("http rule of %s is set; want none", methods[1].GetName())
+	}
+	if file.Services[0].Methods[0].GetOptions() != nil {
+		t.Errorf("buildEnvoyDescriptorSet() modified the options of the file; want them unchanged")
+	}
+
+	delete(deps, "a.example/b/c.proto")
+	if _, err := buildEnvoyDescriptorSet(file, lookup); err == nil {
+		t.Errorf("buildEnvoyDescriptorSet() succeeded; want a failure with a missing dependency")
+	}
+}
@@ -0,0 +1,107 @@
+package gengateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
+)
+
+// builtinTemplateFuncs are the helpers available to user-supplied templates.
+var builtinTemplateFuncs = template.FuncMap{
+	"camel":      casing.Camel,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+	"join":       strings.Join,
+	"split":      strings.Split,
+	"comment":    goComment,
+}
+
+// goComment turns text into a Go line comment, one line per line of text.
+func goComment(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// templateFuncConfig is the format of the file given with the template_funcs option.
+//
+//	funcs:
+//	- name: routeComment
+//	  template: '{{ comment (printf "%s is served at %s" .Name .Path) }}'
+//
+// Every function renders its template with its argument as dot (or with the
+// list of its arguments if it is called with more than one) and returns the
+// result. The templates may call the builtin helpers and the functions
+// declared before them.
+type templateFuncConfig struct {
+	Funcs []struct {
+		Name     string `json:"name"`
+		Template string `json:"template"`
+	} `json:"funcs"`
+}
+
+// LoadTemplateFuncsFromYAML loads the template functions declared in the given
+// YAML file. The returned map also contains the builtin helpers.
+func LoadTemplateFuncsFromYAML(yamlFile string) (template.FuncMap, error) {
+	yamlFileContents, err := ioutil.ReadFile(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template functions from %q: %v", yamlFile, err)
+	}
+	return loadTemplateFuncs(yamlFileContents, yamlFile)
+}
+
+func loadTemplateFuncs(yamlFileContents []byte, yamlSourceLogName string) (template.FuncMap, error) {
+	jsonContents, err := yaml.YAMLToJSON(yamlFileContents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert template functions from YAML in '%v' to JSON: %v", yamlSourceLogName, err)
+	}
+	var config templateFuncConfig
+	if err := json.Unmarshal(jsonContents, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse template functions from YAML in '%v': %v", yamlSourceLogName, err)
+	}
+
+	funcs := make(template.FuncMap, len(builtinTemplateFuncs)+len(config.Funcs))
+	for name, fn := range builtinTemplateFuncs {
+		funcs[name] = fn
+	}
+	for _, f := range config.Funcs {
+		if f.Name == "" {
+			return nil, fmt.Errorf("template function without a name in '%v'", yamlSourceLogName)
+		}
+		if _, ok := funcs[f.Name]; ok {
+			return nil, fmt.Errorf("template function %q in '%v' is already defined", f.Name, yamlSourceLogName)
+		}
+		tmpl, err := template.New(f.Name).Funcs(funcs).Parse(f.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template function %q in '%v': %v", f.Name, yamlSourceLogName, err)
+		}
+		funcs[f.Name] = templateFunc(tmpl)
+	}
+	return funcs, nil
+}
+
+// templateFunc returns a template function rendering tmpl.
+func templateFunc(tmpl *template.Template) func(args ...interface{}) (string, error) {
+	return func(args ...interface{}) (string, error) {
+		var data interface{} = args
+		if len(args) == 1 {
+			data = args[0]
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
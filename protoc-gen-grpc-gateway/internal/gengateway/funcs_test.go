@@ -0,0 +1,70 @@
+package gengateway
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestLoadTemplateFuncs(t *testing.T) {
+	config := `
+funcs:
+- name: handlerName
+  template: '{{ camel .Service }}_{{ camel .Method }}Handler'
+- name: routeComment
+  template: '{{ comment (printf "%s is served at\n%s" (handlerName .) .Path) }}'
+`
+	funcs, err := loadTemplateFuncs([]byte(config), "funcs.yaml")
+	if err != nil {
+		t.Fatalf("loadTemplateFuncs() failed with %v; want success", err)
+	}
+
+	tmpl, err := template.New("override").Funcs(funcs).Parse(`{{ routeComment . }}`)
+	if err != nil {
+		t.Fatalf("template.Parse() failed with %v; want success", err)
+	}
+	var buf bytes.Buffer
+	data := map[string]string{
+		"Service": "echo_service",
+		"Method":  "echo",
+		"Path":    "/v1/echo",
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("tmpl.Execute() failed with %v; want success", err)
+	}
+	if got, want := buf.String(), "// EchoService_EchoHandler is served at\n// /v1/echo"; got != want {
+		t.Errorf("tmpl.Execute() = %q; want %q", got, want)
+	}
+}
+
+func TestLoadTemplateFuncsErrors(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "missing name",
+			config: "funcs:\n- template: 'x'\n",
+			want:   "without a name",
+		},
+		{
+			name:   "shadows builtin",
+			config: "funcs:\n- name: camel\n  template: 'x'\n",
+			want:   "already defined",
+		},
+		{
+			name:   "uses later function",
+			config: "funcs:\n- name: a\n  template: '{{ b }}'\n- name: b\n  template: 'x'\n",
+			want:   "failed to parse",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			_, err := loadTemplateFuncs([]byte(spec.config), "funcs.yaml")
+			if err == nil || !strings.Contains(err.Error(), spec.want) {
+				t.Errorf("loadTemplateFuncs() failed with %v; want an error containing %q", err, spec.want)
+			}
+		})
+	}
+}
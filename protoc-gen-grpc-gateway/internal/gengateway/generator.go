@@ -7,8 +7,10 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/golang/glog"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
 	gen "github.com/grpc-ecosystem/grpc-gateway/v2/internal/generator"
 	"google.golang.org/protobuf/proto"
@@ -26,20 +28,77 @@ const (
 	pathTypeSourceRelative
 )
 
+// Options are the options of the generated gateway files, set with the
+// parameters of protoc-gen-grpc-gateway.
+type Options struct {
+	UseRequestContext  bool
+	RegisterFuncSuffix string
+	// Paths is the type of the paths of the generated files, "import" if
+	// empty, or "source_relative".
+	Paths             string
+	ModulePath        string
+	AllowPatchFeature bool
+	Standalone        bool
+	// TemplateFuncs are the functions available to templates overriding the builtin ones.
+	TemplateFuncs template.FuncMap
+	// TemplateDir is the directory of the templates overriding the builtin ones.
+	TemplateDir string
+	// SeparateFiles emits the code of every service in its own file.
+	SeparateFiles bool
+	// PathHelpers emits a function building the path of every binding.
+	PathHelpers bool
+	// HTTPClient emits a client calling the REST endpoints of every service.
+	HTTPClient bool
+	// Hooks emits per-method hook interfaces called by the handlers.
+	Hooks bool
+	// Validate validates decoded requests before forwarding them.
+	Validate bool
+	// RouteManifest emits a *.routes.json file next to every generated file.
+	RouteManifest bool
+	// UnexportedRegisterFuncs generates unexported register functions.
+	UnexportedRegisterFuncs bool
+	// RegisterAll emits a function registering all the services of every file.
+	RegisterAll bool
+	// StdlibPatterns emits functions registering the handlers to a net/http ServeMux with Go 1.22 patterns.
+	StdlibPatterns bool
+	// RouterAdapters are the other routers functions registering the handlers to are emitted for.
+	RouterAdapters []string
+	// GenericForwarders registers the generic handlers of the runtime instead of a handler per binding.
+	GenericForwarders bool
+	// LocalServerStreaming supports server streaming methods in the handlers calling servers in process.
+	LocalServerStreaming bool
+	// FieldViolations reports the field of the requests which could not be decoded in a BadRequest detail.
+	FieldViolations bool
+	// AuditLog passes the requests and responses of unary methods to runtime.Audit.
+	AuditLog bool
+	// Record passes the calls of unary methods to runtime.Record.
+	Record bool
+	// PoolRequests reuses the request messages of the unary methods forwarded to clients.
+	PoolRequests bool
+	// GraphQL generates functions registering the unary methods as the fields of the schema of a runtime.GraphQLHandler.
+	GraphQL bool
+	// BuildTags is the //go:build expression the generated files are built with.
+	BuildTags string
+	// GenerationInfo, if set, is recorded in a generation header of the generated files.
+	GenerationInfo *GenerationInfo
+	// EnvoyConfig emits the *.envoy.json configuration of the Envoy transcoder and its *.envoy.pb descriptor set next to every generated file.
+	EnvoyConfig bool
+	// KubernetesRoutes, if set, configures the *.k8s.yaml manifests routing the bindings emitted next to every generated file.
+	KubernetesRoutes *KubernetesRoutes
+}
+
 type generator struct {
-	reg                *descriptor.Registry
-	baseImports        []descriptor.GoPackage
-	useRequestContext  bool
-	registerFuncSuffix string
-	pathType           pathType
-	modulePath         string
-	allowPatchFeature  bool
-	standalone         bool
+	Options
+
+	reg         *descriptor.Registry
+	baseImports []descriptor.GoPackage
+	pathType    pathType
+	// templates are the templates loaded from TemplateDir.
+	templates *templateSet
 }
 
-// New returns a new generator which generates grpc gateway files.
-func New(reg *descriptor.Registry, useRequestContext bool, registerFuncSuffix, pathTypeString, modulePathString string,
-	allowPatchFeature, standalone bool) gen.Generator {
+// New returns a new generator which generates grpc gateway files with opts.
+func New(reg *descriptor.Registry, opts Options) gen.Generator {
 	var imports []descriptor.GoPackage
 	for _, pkgpath := range []string{
 		"context",
@@ -70,75 +129,206 @@ func New(reg *descriptor.Registry, useRequestContext bool, registerFuncSuffix, p
 		}
 		imports = append(imports, pkg)
 	}
+	for _, adapter := range opts.RouterAdapters {
+		pkg, ok := routerAdapterPackages[adapter]
+		if !ok {
+			glog.Fatalf(`Unknown router adapter %q: want "chi" or "gorilla".`, adapter)
+		}
+		alias := pkg.Alias
+		if alias == "" {
+			alias = pkg.Name
+		}
+		if err := reg.ReserveGoPackageAlias(alias, pkg.Path); err != nil {
+			glog.Fatalf("Cannot import %s for the %s router adapter: %v", pkg.Path, adapter, err)
+		}
+		imports = append(imports, pkg)
+	}
 
 	var pathType pathType
-	switch pathTypeString {
+	switch opts.Paths {
 	case "", "import":
 		// paths=import is default
 	case "source_relative":
 		pathType = pathTypeSourceRelative
 	default:
-		glog.Fatalf(`Unknown path type %q: want "import" or "source_relative".`, pathTypeString)
+		glog.Fatalf(`Unknown path type %q: want "import" or "source_relative".`, opts.Paths)
 	}
 
 	return &generator{
-		reg:                reg,
-		baseImports:        imports,
-		useRequestContext:  useRequestContext,
-		registerFuncSuffix: registerFuncSuffix,
-		pathType:           pathType,
-		modulePath:         modulePathString,
-		allowPatchFeature:  allowPatchFeature,
-		standalone:         standalone,
+		Options:     opts,
+		reg:         reg,
+		baseImports: imports,
+		pathType:    pathType,
 	}
 }
 
 func (g *generator) Generate(targets []*descriptor.File) ([]*descriptor.ResponseFile, error) {
+	if g.TemplateDir != "" && g.templates == nil {
+		templates, err := loadTemplateOverrides(g.TemplateDir, g.TemplateFuncs)
+		if err != nil {
+			return nil, err
+		}
+		g.templates = templates
+	}
 	var files []*descriptor.ResponseFile
 	for _, file := range targets {
 		glog.V(1).Infof("Processing %s", file.GetName())
 
-		code, err := g.generate(file)
-		if err == errNoTargetService {
-			glog.V(1).Infof("%s: %v", file.GetName(), err)
-			continue
+		units := []*descriptor.File{file}
+		if g.SeparateFiles {
+			units = splitByService(file)
 		}
-		if err != nil {
-			return nil, err
-		}
-		formatted, err := format.Source([]byte(code))
-		if err != nil {
-			glog.Errorf("%v: %s", err, code)
-			return nil, err
+		var base string
+		for _, unit := range units {
+			// The services of the file are all registered from its first generated file.
+			var registerAllFrom *descriptor.File
+			if base == "" {
+				registerAllFrom = file
+			}
+			code, err := g.generateCode(unit, base != "", registerAllFrom)
+			if err == errNoTargetService {
+				glog.V(1).Infof("%s: %v", file.GetName(), err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			formatted, err := format.Source([]byte(code))
+			if err != nil {
+				glog.Errorf("%v: %s", err, code)
+				return nil, err
+			}
+
+			name, err := g.getFilePath(file)
+			if err != nil {
+				glog.Errorf("%v: %s", err, code)
+				return nil, err
+			}
+			ext := filepath.Ext(name)
+			base = strings.TrimSuffix(name, ext)
+			filename := fmt.Sprintf("%s.pb.gw.go", base)
+			if g.SeparateFiles {
+				// The service name has been camel cased by the templates.
+				filename = filepath.Join(filepath.Dir(name), fmt.Sprintf("%s.pb.gw.go", strings.ToLower(unit.Services[0].GetName())))
+			}
+			files = append(files, &descriptor.ResponseFile{
+				GoPkg: file.GoPkg,
+				CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String(filename),
+					Content: proto.String(string(formatted)),
+				},
+			})
 		}
 
-		name, err := g.getFilePath(file)
-		if err != nil {
-			glog.Errorf("%v: %s", err, code)
-			return nil, err
+		if g.RouteManifest && base != "" {
+			manifest, err := encodeRouteManifest(buildRouteManifest(file))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, &descriptor.ResponseFile{
+				GoPkg: file.GoPkg,
+				CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String(fmt.Sprintf("%s.routes.json", base)),
+					Content: proto.String(manifest),
+				},
+			})
 		}
-		ext := filepath.Ext(name)
-		base := strings.TrimSuffix(name, ext)
-		filename := fmt.Sprintf("%s.pb.gw.go", base)
-		files = append(files, &descriptor.ResponseFile{
+		if g.EnvoyConfig && base != "" {
+			envoyFiles, err := g.generateEnvoyConfig(file, base)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, envoyFiles...)
+		}
+		if g.KubernetesRoutes != nil && base != "" {
+			manifest, err := buildKubernetesManifest(g.KubernetesRoutes, file, base)
+			if err != nil {
+				return nil, err
+			}
+			if manifest != "" {
+				files = append(files, &descriptor.ResponseFile{
+					GoPkg: file.GoPkg,
+					CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+						Name:    proto.String(fmt.Sprintf("%s.k8s.yaml", base)),
+						Content: proto.String(manifest),
+					},
+				})
+			}
+		}
+	}
+	return files, nil
+}
+
+// generateEnvoyConfig returns the *.envoy.json configuration of the Envoy
+// transcoder of file and its *.envoy.pb descriptor set.
+func (g *generator) generateEnvoyConfig(file *descriptor.File, base string) ([]*descriptor.ResponseFile, error) {
+	descriptorPath := fmt.Sprintf("%s.envoy.pb", base)
+	config, err := encodeEnvoyConfig(buildEnvoyConfig(file, descriptorPath))
+	if err != nil {
+		return nil, err
+	}
+	set, err := buildEnvoyDescriptorSet(file, g.reg.LookupFile)
+	if err != nil {
+		return nil, err
+	}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	return []*descriptor.ResponseFile{
+		{
 			GoPkg: file.GoPkg,
 			CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
-				Name:    proto.String(filename),
-				Content: proto.String(string(formatted)),
+				Name:    proto.String(fmt.Sprintf("%s.envoy.json", base)),
+				Content: proto.String(config),
 			},
-		})
+		},
+		{
+			GoPkg: file.GoPkg,
+			CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+				Name:    proto.String(descriptorPath),
+				Content: proto.String(string(b)),
+			},
+		},
+	}, nil
+}
+
+// newRegisterAll returns the function registering the services of file with bindings.
+func newRegisterAll(file *descriptor.File) *registerAll {
+	name := strings.TrimSuffix(filepath.Base(file.GetName()), filepath.Ext(file.GetName()))
+	ra := &registerAll{Name: casing.Camel(name), Source: file.GetName()}
+	for _, svc := range file.Services {
+		for _, m := range svc.Methods {
+			if len(m.Bindings) > 0 {
+				// The names of the services are camel-cased by the templates.
+				ra.Services = append(ra.Services, casing.Camel(svc.GetName()))
+				break
+			}
+		}
 	}
-	return files, nil
+	return ra
+}
+
+// splitByService returns a copy of the file per service, each with the
+// service as its only one.
+func splitByService(file *descriptor.File) []*descriptor.File {
+	var files []*descriptor.File
+	for _, svc := range file.Services {
+		f := *file
+		f.Services = []*descriptor.Service{svc}
+		files = append(files, &f)
+	}
+	return files
 }
 
 func (g *generator) getFilePath(file *descriptor.File) (string, error) {
 	name := file.GetName()
 	switch {
-	case g.modulePath != "" && g.pathType != pathTypeImport:
+	case g.ModulePath != "" && g.pathType != pathTypeImport:
 		return "", errors.New("cannot use module= with paths=")
 
-	case g.modulePath != "":
-		trimPath, pkgPath := g.modulePath+"/", file.GoPkg.Path+"/"
+	case g.ModulePath != "":
+		trimPath, pkgPath := g.ModulePath+"/", file.GoPkg.Path+"/"
 		if !strings.HasPrefix(pkgPath, trimPath) {
 			return "", fmt.Errorf("%v: file go path does not match module prefix: %v", file.GoPkg.Path, trimPath)
 		}
@@ -153,6 +343,14 @@ func (g *generator) getFilePath(file *descriptor.File) (string, error) {
 }
 
 func (g *generator) generate(file *descriptor.File) (string, error) {
+	return g.generateCode(file, false, file)
+}
+
+// generateCode generates the code of the file. omitPackageDoc leaves out the
+// package comment regardless of the registry, for the files of a package but
+// the first. registerAllFrom, if set, is the file whose services are
+// registered by the register all function, if enabled.
+func (g *generator) generateCode(file *descriptor.File, omitPackageDoc bool, registerAllFrom *descriptor.File) (string, error) {
 	pkgSeen := make(map[string]bool)
 	var imports []descriptor.GoPackage
 	for _, pkg := range g.baseImports {
@@ -160,7 +358,7 @@ func (g *generator) generate(file *descriptor.File) (string, error) {
 		imports = append(imports, pkg)
 	}
 
-	if g.standalone {
+	if g.Standalone {
 		imports = append(imports, file.GoPkg)
 	}
 
@@ -177,15 +375,50 @@ func (g *generator) generate(file *descriptor.File) (string, error) {
 		}
 	}
 	params := param{
-		File:               file,
-		Imports:            imports,
-		UseRequestContext:  g.useRequestContext,
-		RegisterFuncSuffix: g.registerFuncSuffix,
-		AllowPatchFeature:  g.allowPatchFeature,
+		File:                    file,
+		Imports:                 imports,
+		UseRequestContext:       g.UseRequestContext,
+		RegisterFuncSuffix:      g.RegisterFuncSuffix,
+		AllowPatchFeature:       g.AllowPatchFeature,
+		PathHelpers:             g.PathHelpers,
+		HTTPClient:              g.HTTPClient,
+		Hooks:                   g.Hooks,
+		Validate:                g.Validate,
+		UnexportedRegisterFuncs: g.UnexportedRegisterFuncs,
+		StdlibPatterns:          g.StdlibPatterns,
+		RouterAdapters:          g.RouterAdapters,
+		GenericForwarders:       g.GenericForwarders,
+		LocalServerStreaming:    g.LocalServerStreaming,
+		FieldViolations:         g.FieldViolations,
+		AuditLog:                g.AuditLog,
+		Record:                  g.Record,
+		PoolRequests:            g.PoolRequests,
+		GraphQL:                 g.GraphQL,
+		templates:               g.templates,
 	}
 	if g.reg != nil {
 		params.OmitPackageDoc = g.reg.GetOmitPackageDoc()
 	}
+	if omitPackageDoc {
+		params.OmitPackageDoc = true
+	}
+	if g.RegisterAll && registerAllFrom != nil {
+		params.RegisterAll = newRegisterAll(registerAllFrom)
+	}
+	if g.BuildTags != "" {
+		constraint, err := newBuildConstraint(g.BuildTags)
+		if err != nil {
+			return "", err
+		}
+		params.BuildConstraint = constraint
+	}
+	if g.GenerationInfo != nil {
+		header, err := newGenerationHeader(g.GenerationInfo, file)
+		if err != nil {
+			return "", err
+		}
+		params.GenerationHeader = header
+	}
 	return applyTemplate(params, g.reg)
 }
 
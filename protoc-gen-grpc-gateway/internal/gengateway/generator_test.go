@@ -202,8 +202,8 @@ func TestGenerateOutputPath(t *testing.T) {
 
 	for _, c := range cases {
 		g := &generator{
-			pathType:   c.pathType,
-			modulePath: c.modulePath,
+			Options:  Options{ModulePath: c.modulePath},
+			pathType: c.pathType,
 		}
 
 		file := c.file
@@ -242,3 +242,69 @@ func TestGenerateOutputPath(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateSeparateFiles(t *testing.T) {
+	file := newExampleFileDescriptor()
+	example := file.Services[0]
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("other_service"),
+		Method: []*descriptorpb.MethodDescriptorProto{example.Methods[0].MethodDescriptorProto},
+	}
+	file.Services = append(file.Services, &descriptor.Service{
+		ServiceDescriptorProto: svc,
+		Methods: []*descriptor.Method{
+			{
+				MethodDescriptorProto: example.Methods[0].MethodDescriptorProto,
+				RequestType:           example.Methods[0].RequestType,
+				ResponseType:          example.Methods[0].ResponseType,
+				Bindings: []*descriptor.Binding{
+					{
+						HTTPMethod: "POST",
+						Body:       &descriptor.Body{FieldPath: nil},
+					},
+				},
+			},
+		},
+	})
+
+	g := &generator{Options: Options{RegisterFuncSuffix: "Handler", SeparateFiles: true}}
+	files, err := g.Generate([]*descriptor.File{crossLinkFixture(file)})
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Generate() emitted %d files; want 2", len(files))
+	}
+	for i, spec := range []struct {
+		name    string
+		want    string
+		notWant string
+		doc     bool
+	}{
+		{
+			name:    "example.com/path/to/example/example.pb/exampleservice.pb.gw.go",
+			want:    "func RegisterExampleServiceHandlerServer(",
+			notWant: "OtherService",
+			doc:     true,
+		},
+		{
+			name:    "example.com/path/to/example/example.pb/otherservice.pb.gw.go",
+			want:    "func RegisterOtherServiceHandlerServer(",
+			notWant: "ExampleService",
+		},
+	} {
+		if got := files[i].GetName(); got != spec.name {
+			t.Errorf("files[%d].GetName() = %q; want %q", i, got, spec.name)
+		}
+		content := files[i].GetContent()
+		if !strings.Contains(content, spec.want) {
+			t.Errorf("files[%d].GetContent() = %s; want to contain %s", i, content, spec.want)
+		}
+		if strings.Contains(content, spec.notWant) {
+			t.Errorf("files[%d].GetContent() = %s; does not want to contain %s", i, content, spec.notWant)
+		}
+		if doc := strings.Contains(content, "is a reverse proxy."); doc != spec.doc {
+			t.Errorf("files[%d] has a package comment: %t; want %t", i, doc, spec.doc)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package gengateway
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// graphQLService is the part of the GraphQL schema of runtime.GraphQLHandler
+// registered for a service.
+type graphQLService struct {
+	// Fields are the fields of the Query and Mutation types calling the
+	// unary methods with bindings.
+	Fields []*graphQLField
+	// Types are the types of the arguments and of the fields, in the order
+	// they are referenced.
+	Types []*graphQLType
+}
+
+type graphQLField struct {
+	Method *descriptor.Method
+	// Mutation is whether the field belongs to the Mutation type, i.e. the
+	// first binding of the method is not a GET.
+	Mutation   bool
+	Name       string
+	Definition string
+}
+
+type graphQLType struct {
+	Name       string
+	Definition string
+}
+
+// graphQLScalars are the GraphQL types of the scalar fields, the 64-bit
+// integers being strings like in JSON and the unsigned 32-bit ones floats
+// since they may not fit a GraphQL Int.
+var graphQLScalars = map[descriptorpb.FieldDescriptorProto_Type]string{
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:   "Float",
+	descriptorpb.FieldDescriptorProto_TYPE_FLOAT:    "Float",
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    "String",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   "String",
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    "Int",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  "String",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  "Float",
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:     "Boolean",
+	descriptorpb.FieldDescriptorProto_TYPE_STRING:   "String",
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:    "String",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   "Float",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: "Int",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: "String",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   "Int",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   "String",
+}
+
+// graphQLWellKnownTypes are the GraphQL types of the JSON representation of
+// the well-known types, in line with runtime.GraphQLHandler.
+var graphQLWellKnownTypes = map[string]string{
+	".google.protobuf.Any":         "JSON",
+	".google.protobuf.Duration":    "String",
+	".google.protobuf.Empty":       "JSON",
+	".google.protobuf.FieldMask":   "String",
+	".google.protobuf.ListValue":   "JSON",
+	".google.protobuf.Struct":      "JSON",
+	".google.protobuf.Timestamp":   "String",
+	".google.protobuf.Value":       "JSON",
+	".google.protobuf.DoubleValue": "Float",
+	".google.protobuf.FloatValue":  "Float",
+	".google.protobuf.Int64Value":  "String",
+	".google.protobuf.UInt64Value": "String",
+	".google.protobuf.Int32Value":  "Int",
+	".google.protobuf.UInt32Value": "Float",
+	".google.protobuf.BoolValue":   "Boolean",
+	".google.protobuf.StringValue": "String",
+	".google.protobuf.BytesValue":  "String",
+}
+
+// graphQLServices returns the GraphQL schemas of the services, without the
+// services with no unary methods with bindings.
+func graphQLServices(svcs []*descriptor.Service, reg *descriptor.Registry) (map[*descriptor.Service]*graphQLService, error) {
+	services := make(map[*descriptor.Service]*graphQLService)
+	for _, svc := range svcs {
+		s := &graphQLSchema{reg: reg, defined: make(map[string]bool)}
+		var fields []*graphQLField
+		for _, meth := range svc.Methods {
+			if len(meth.Bindings) == 0 || meth.GetClientStreaming() || meth.GetServerStreaming() {
+				continue
+			}
+			f, err := s.field(meth)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", svc.GetName(), meth.GetName(), err)
+			}
+			fields = append(fields, f)
+		}
+		if len(fields) > 0 {
+			services[svc] = &graphQLService{Fields: fields, Types: s.types}
+		}
+	}
+	return services, nil
+}
+
+// graphQLSchema collects the definitions of the types of a schema.
+type graphQLSchema struct {
+	reg     *descriptor.Registry
+	types   []*graphQLType
+	defined map[string]bool
+}
+
+// field returns the root field calling the method, whose arguments are the
+// fields of the request.
+func (s *graphQLSchema) field(meth *descriptor.Method) (*graphQLField, error) {
+	f := &graphQLField{
+		Method:   meth,
+		Mutation: meth.Bindings[0].HTTPMethod != "GET",
+		Name:     strings.ToLower(meth.GetName()[:1]) + meth.GetName()[1:],
+	}
+	args, err := s.fields(meth.RequestType, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.messageType(meth.ResponseType, false)
+	if err != nil {
+		return nil, err
+	}
+	f.Definition = f.Name
+	if len(args) > 0 {
+		f.Definition += "(" + strings.Join(args, ", ") + ")"
+	}
+	f.Definition += ": " + resp
+	return f, nil
+}
+
+// fields returns the definitions of the fields of the message, as an input
+// type if input.
+func (s *graphQLSchema) fields(msg *descriptor.Message, input bool) ([]string, error) {
+	var defs []string
+	for _, f := range msg.Fields {
+		t, err := s.fieldType(f, input)
+		if err != nil {
+			return nil, err
+		}
+		name := f.GetJsonName()
+		if name == "" {
+			name = f.GetName()
+		}
+		defs = append(defs, name+": "+t)
+	}
+	return defs, nil
+}
+
+func (s *graphQLSchema) fieldType(f *descriptor.Field, input bool) (string, error) {
+	var t string
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		m, err := s.reg.LookupMsg(f.Message.FQMN(), f.GetTypeName())
+		if err != nil {
+			return "", err
+		}
+		if m.GetOptions().GetMapEntry() {
+			return "JSON", nil
+		}
+		if t, err = s.messageType(m, input); err != nil {
+			return "", err
+		}
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		e, err := s.reg.LookupEnum(f.Message.FQMN(), f.GetTypeName())
+		if err != nil {
+			return "", err
+		}
+		t = s.enumType(e)
+	default:
+		var ok bool
+		if t, ok = graphQLScalars[f.GetType()]; !ok {
+			return "", fmt.Errorf("unsupported type %s of field %s", f.GetType(), f.FQFN())
+		}
+	}
+	if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return "[" + t + "]", nil
+	}
+	return t, nil
+}
+
+// messageType returns the GraphQL type of the message, defining it if
+// needed, the input types being suffixed with "Input".
+func (s *graphQLSchema) messageType(m *descriptor.Message, input bool) (string, error) {
+	if t, ok := graphQLWellKnownTypes[m.FQMN()]; ok {
+		return t, nil
+	}
+	if len(m.Fields) == 0 {
+		return "JSON", nil
+	}
+	name := graphQLTypeName(m.Outers, m.GetName())
+	kind := "type"
+	if input {
+		name += "Input"
+		kind = "input"
+	}
+	if s.defined[name] {
+		return name, nil
+	}
+	s.defined[name] = true
+	t := &graphQLType{Name: name}
+	s.types = append(s.types, t)
+	fields, err := s.fields(m, input)
+	if err != nil {
+		return "", err
+	}
+	t.Definition = fmt.Sprintf("%s %s {\n  %s\n}", kind, name, strings.Join(fields, "\n  "))
+	return name, nil
+}
+
+// enumType returns the GraphQL type of the enum, defining it if needed. The
+// enums whose values cannot be those of a GraphQL enum are strings.
+func (s *graphQLSchema) enumType(e *descriptor.Enum) string {
+	var values []string
+	for _, v := range e.GetValue() {
+		switch v.GetName() {
+		case "true", "false", "null":
+			return "String"
+		}
+		values = append(values, v.GetName())
+	}
+	if len(values) == 0 {
+		return "String"
+	}
+	name := graphQLTypeName(e.Outers, e.GetName())
+	if !s.defined[name] {
+		s.defined[name] = true
+		s.types = append(s.types, &graphQLType{
+			Name:       name,
+			Definition: fmt.Sprintf("enum %s {\n  %s\n}", name, strings.Join(values, "\n  ")),
+		})
+	}
+	return name
+}
+
+// graphQLTypeName returns the name of the GraphQL type of a message or an
+// enum nested in outers, e.g. "Outer_Inner", as runtime.GraphQLHandler
+// names them.
+func graphQLTypeName(outers []string, name string) string {
+	var names []string
+	for _, n := range append(append([]string(nil), outers...), name) {
+		names = append(names, casing.Camel(n))
+	}
+	return strings.Join(names, "_")
+}
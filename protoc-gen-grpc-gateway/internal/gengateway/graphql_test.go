@@ -0,0 +1,174 @@
+package gengateway
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const graphQLRequest = `
+file_to_generate: 'library.proto'
+proto_file <
+	name: 'google/protobuf/timestamp.proto'
+	package: 'google.protobuf'
+	message_type <
+		name: 'Timestamp'
+		field < name: 'seconds' number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: 'seconds' >
+		field < name: 'nanos' number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: 'nanos' >
+	>
+	syntax: 'proto3'
+>
+proto_file <
+	name: 'library.proto'
+	package: 'library'
+	dependency: 'google/protobuf/timestamp.proto'
+	message_type <
+		name: 'Book'
+		field < name: 'name' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'name' >
+		field < name: 'id' number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: 'id' >
+		field < name: 'tags' number: 3 label: LABEL_REPEATED type: TYPE_STRING json_name: 'tags' >
+		field < name: 'labels' number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: '.library.Book.LabelsEntry' json_name: 'labels' >
+		field < name: 'kind' number: 5 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: '.library.Book.Kind' json_name: 'kind' >
+		field < name: 'create_time' number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: '.google.protobuf.Timestamp' json_name: 'createTime' >
+		field < name: 'sequels' number: 7 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: '.library.Book' json_name: 'sequels' >
+		nested_type <
+			name: 'LabelsEntry'
+			field < name: 'key' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'key' >
+			field < name: 'value' number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'value' >
+			options < map_entry: true >
+		>
+		enum_type <
+			name: 'Kind'
+			value < name: 'KIND_UNSPECIFIED' number: 0 >
+			value < name: 'KIND_NOVEL' number: 1 >
+		>
+	>
+	message_type <
+		name: 'GetBookRequest'
+		field < name: 'name' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'name' >
+	>
+	message_type <
+		name: 'CreateBookRequest'
+		field < name: 'parent' number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: 'parent' >
+		field < name: 'book' number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: '.library.Book' json_name: 'book' >
+	>
+	service <
+		name: 'Library'
+		method < name: 'GetBook' input_type: '.library.GetBookRequest' output_type: '.library.Book' >
+		method < name: 'CreateBook' input_type: '.library.CreateBookRequest' output_type: '.library.Book' >
+		method < name: 'WatchBooks' input_type: '.library.GetBookRequest' output_type: '.library.Book' server_streaming: true >
+		method < name: 'Unbound' input_type: '.library.GetBookRequest' output_type: '.library.Book' >
+	>
+	options < go_package: 'example.com/library;library' >
+	syntax: 'proto3'
+>
+`
+
+func TestGraphQLServices(t *testing.T) {
+	var req pluginpb.CodeGeneratorRequest
+	if err := prototext.Unmarshal([]byte(graphQLRequest), &req); err != nil {
+		t.Fatalf("prototext.Unmarshal(%s) failed with %v; want success", graphQLRequest, err)
+	}
+	reg := descriptor.NewRegistry()
+	reg.AddExternalHTTPRule(".library.Library.GetBook", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=books/*}"},
+	})
+	reg.AddExternalHTTPRule(".library.Library.CreateBook", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/{parent=shelves/*}/books"},
+		Body:    "book",
+	})
+	reg.AddExternalHTTPRule(".library.Library.WatchBooks", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/books:watch"},
+	})
+	if err := reg.Load(&req); err != nil {
+		t.Fatalf("reg.Load(req) failed with %v; want success", err)
+	}
+	file, err := reg.LookupFile("library.proto")
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", "library.proto", err)
+	}
+	services, err := graphQLServices(file.Services, reg)
+	if err != nil {
+		t.Fatalf("graphQLServices(%v) failed with %v; want success", file.Services, err)
+	}
+	svc := services[file.Services[0]]
+	if svc == nil {
+		t.Fatalf("graphQLServices(%v) = %v; want the service Library", file.Services, services)
+	}
+
+	var fields []string
+	for _, f := range svc.Fields {
+		root := "Query"
+		if f.Mutation {
+			root = "Mutation"
+		}
+		fields = append(fields, root+"."+f.Definition)
+	}
+	if got, want := strings.Join(fields, "\n"), "Query.getBook(name: String): Book\nMutation.createBook(parent: String, book: BookInput): Book"; got != want {
+		t.Errorf("fields = %s; want %s", got, want)
+	}
+
+	types := make(map[string]string)
+	var names []string
+	for _, typ := range svc.Types {
+		types[typ.Name] = typ.Definition
+		names = append(names, typ.Name)
+	}
+	if got, want := strings.Join(names, ","), "Book,Book_Kind,BookInput"; got != want {
+		t.Errorf("type names = %s; want %s", got, want)
+	}
+	for name, want := range map[string]string{
+		"Book":      "type Book {\n  name: String\n  id: String\n  tags: [String]\n  labels: JSON\n  kind: Book_Kind\n  createTime: String\n  sequels: [Book]\n}",
+		"Book_Kind": "enum Book_Kind {\n  KIND_UNSPECIFIED\n  KIND_NOVEL\n}",
+		"BookInput": "input BookInput {\n  name: String\n  id: String\n  tags: [String]\n  labels: JSON\n  kind: Book_Kind\n  createTime: String\n  sequels: [BookInput]\n}",
+	} {
+		if got := types[name]; got != want {
+			t.Errorf("definition of %s = %q; want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyTemplateGraphQL(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GraphQL: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"func RegisterExampleServiceHandlerGraphQL(h *runtime.GraphQLHandler, client ExampleServiceClient) error {",
+		"\tif err := h.Handle(runtime.GraphQLField{\n" +
+			"\t\tName:       \"example\",\n" +
+			"\t\tDefinition: \"example: JSON\",\n" +
+			"\t\tRPCMethod:  \"/example.ExampleService/Example\",\n" +
+			"\t\tRequest:    new(ExampleMessage),\n" +
+			"\t\tResponse:   new(ExampleMessage),\n" +
+			"\t\tCall: func(ctx context.Context, req proto.Message) (proto.Message, error) {\n" +
+			"\t\t\treturn client.Example(ctx, req.(*ExampleMessage))\n" +
+			"\t\t},\n" +
+			"\t}); err != nil {\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "DefineType"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+
+	got, err = applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if notWant := "GraphQL"; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
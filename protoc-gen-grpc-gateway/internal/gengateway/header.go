@@ -0,0 +1,73 @@
+package gengateway
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/protobuf/proto"
+)
+
+// GenerationInfo describes the run of protoc-gen-grpc-gateway recorded in the
+// generation header of the generated files.
+type GenerationInfo struct {
+	// Version is the version of protoc-gen-grpc-gateway.
+	Version string
+	// Parameters are the parameters protoc-gen-grpc-gateway was run with.
+	Parameters string
+}
+
+// buildConstraint is a build constraint in both the //go:build and the
+// legacy // +build syntax.
+type buildConstraint struct {
+	GoBuild   string
+	PlusBuild string
+}
+
+// generationHeader is the data of the generation header of a generated file.
+type generationHeader struct {
+	Version      string
+	Parameters   string
+	SourceDigest string
+}
+
+var buildTagPattern = regexp.MustCompile(`^!?[\w.]+$`)
+
+// newBuildConstraint parses expr, a //go:build expression of tags combined
+// with '!', '&&' and '||'. Parentheses are not supported, as they cannot be
+// expressed in a single // +build line.
+func newBuildConstraint(expr string) (*buildConstraint, error) {
+	var goBuild, plusBuild []string
+	for _, or := range strings.Split(expr, "||") {
+		var terms []string
+		for _, term := range strings.Split(or, "&&") {
+			term = strings.TrimSpace(term)
+			if !buildTagPattern.MatchString(term) {
+				return nil, fmt.Errorf("invalid build tags %q: want tags combined with '!', '&&' and '||'", expr)
+			}
+			terms = append(terms, term)
+		}
+		goBuild = append(goBuild, strings.Join(terms, " && "))
+		plusBuild = append(plusBuild, strings.Join(terms, ","))
+	}
+	return &buildConstraint{
+		GoBuild:   strings.Join(goBuild, " || "),
+		PlusBuild: strings.Join(plusBuild, " "),
+	}, nil
+}
+
+// newGenerationHeader returns the generation header of the code generated from
+// file, with the SHA-256 digest of its deterministically serialized descriptor.
+func newGenerationHeader(info *GenerationInfo, file *descriptor.File) (*generationHeader, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(file.FileDescriptorProto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %v", file.GetName(), err)
+	}
+	return &generationHeader{
+		Version:      info.Version,
+		Parameters:   info.Parameters,
+		SourceDigest: fmt.Sprintf("%x", sha256.Sum256(b)),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package gengateway
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+func TestNewBuildConstraint(t *testing.T) {
+	for _, spec := range []struct {
+		expr      string
+		goBuild   string
+		plusBuild string
+	}{
+		{expr: "!no_gateway", goBuild: "!no_gateway", plusBuild: "!no_gateway"},
+		{expr: "linux&&!no_gateway", goBuild: "linux && !no_gateway", plusBuild: "linux,!no_gateway"},
+		{expr: "gateway || linux && amd64", goBuild: "gateway || linux && amd64", plusBuild: "gateway linux,amd64"},
+		{expr: "go1.16", goBuild: "go1.16", plusBuild: "go1.16"},
+	} {
+		got, err := newBuildConstraint(spec.expr)
+		if err != nil {
+			t.Errorf("newBuildConstraint(%q) failed with %v; want success", spec.expr, err)
+			continue
+		}
+		if got.GoBuild != spec.goBuild || got.PlusBuild != spec.plusBuild {
+			t.Errorf("newBuildConstraint(%q) = %#v; want %q and %q", spec.expr, got, spec.goBuild, spec.plusBuild)
+		}
+	}
+
+	for _, expr := range []string{"", "(a || b) && c", "a &&", "a b"} {
+		if got, err := newBuildConstraint(expr); err == nil {
+			t.Errorf("newBuildConstraint(%q) = %#v; want an error", expr, got)
+		}
+	}
+}
+
+func TestGenerateHeader(t *testing.T) {
+	g := &generator{Options: Options{
+		BuildTags:      "!no_gateway",
+		GenerationInfo: &GenerationInfo{Version: "v2.1.0", Parameters: "paths=source_relative,generation_header=true"},
+	}}
+	files, err := g.Generate([]*descriptor.File{crossLinkFixture(newExampleFileDescriptor())})
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+	got := files[0].GetContent()
+	want := "//go:build !no_gateway\n// +build !no_gateway\n\n" +
+		"// Code generated by protoc-gen-grpc-gateway. DO NOT EDIT.\n" +
+		"// source: example.proto\n" +
+		"// protoc-gen-grpc-gateway version: v2.1.0\n" +
+		"// parameters: paths=source_relative,generation_header=true\n" +
+		"// source sha256: "
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("Generate() = %s; want to start with %s", got, want)
+	}
+	digest := strings.SplitN(strings.TrimPrefix(got, want), "\n", 2)[0]
+	if len(digest) != 64 {
+		t.Errorf("Generate() has source digest %q; want a hex SHA-256 digest", digest)
+	}
+
+	again, err := g.Generate([]*descriptor.File{crossLinkFixture(newExampleFileDescriptor())})
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+	if again[0].GetContent() != got {
+		t.Errorf("Generate() = %s; want the same output as %s", again[0].GetContent(), got)
+	}
+}
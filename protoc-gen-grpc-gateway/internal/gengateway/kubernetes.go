@@ -0,0 +1,290 @@
+package gengateway
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+)
+
+// These constants are the valid kinds of KubernetesRoutes.
+const (
+	// KubernetesHTTPRoute routes with a Gateway API HTTPRoute.
+	KubernetesHTTPRoute = "HTTPRoute"
+	// KubernetesIngress routes with an Ingress.
+	KubernetesIngress = "Ingress"
+)
+
+// maxHTTPRouteMatches is the maximum number of matches of a rule of an
+// HTTPRoute.
+const maxHTTPRouteMatches = 64
+
+// KubernetesRoutes configures the *.k8s.yaml manifests routing the bindings
+// of every generated file to the service serving the gateway.
+type KubernetesRoutes struct {
+	// Kind is the kind of the manifests, KubernetesHTTPRoute or
+	// KubernetesIngress.
+	Kind string
+	// Service and Port are the Kubernetes service serving the gateway.
+	Service string
+	Port    int
+	// Gateway, if set, is the name of the Gateway the HTTPRoutes are
+	// attached to.
+	Gateway string
+}
+
+// ParseKubernetesRoutes returns the configuration of the manifests of kind,
+// "httproute" or "ingress", routing to backend, a service and port
+// separated by a colon, and attached to gateway.
+func ParseKubernetesRoutes(kind, backend, gateway string) (*KubernetesRoutes, error) {
+	k := &KubernetesRoutes{Gateway: gateway}
+	switch strings.ToLower(kind) {
+	case "httproute":
+		k.Kind = KubernetesHTTPRoute
+	case "ingress":
+		k.Kind = KubernetesIngress
+		if gateway != "" {
+			return nil, fmt.Errorf("a gateway is only supported by HTTPRoutes, not Ingresses")
+		}
+	default:
+		return nil, fmt.Errorf(`invalid kind of Kubernetes routes %q: want "httproute" or "ingress"`, kind)
+	}
+	i := strings.LastIndex(backend, ":")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid Kubernetes backend %q: want <service>:<port>", backend)
+	}
+	port, err := strconv.Atoi(backend[i+1:])
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port of the Kubernetes backend %q", backend)
+	}
+	k.Service, k.Port = backend[:i], port
+	return k, nil
+}
+
+type kubernetesObjectMeta struct {
+	Name string `json:"name"`
+}
+
+type httpRoute struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Metadata   kubernetesObjectMeta `json:"metadata"`
+	Spec       httpRouteSpec        `json:"spec"`
+}
+
+type httpRouteSpec struct {
+	ParentRefs []httpRouteRef  `json:"parentRefs,omitempty"`
+	Rules      []httpRouteRule `json:"rules"`
+}
+
+type httpRouteRef struct {
+	Name string `json:"name"`
+	Port int    `json:"port,omitempty"`
+}
+
+type httpRouteRule struct {
+	Matches     []httpRouteMatch `json:"matches"`
+	BackendRefs []httpRouteRef   `json:"backendRefs"`
+}
+
+type httpRouteMatch struct {
+	Path   httpRoutePathMatch `json:"path"`
+	Method string             `json:"method,omitempty"`
+}
+
+type httpRoutePathMatch struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type ingress struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Metadata   kubernetesObjectMeta `json:"metadata"`
+	Spec       ingressSpec          `json:"spec"`
+}
+
+type ingressSpec struct {
+	Rules []ingressRule `json:"rules"`
+}
+
+type ingressRule struct {
+	HTTP ingressHTTPRule `json:"http"`
+}
+
+type ingressHTTPRule struct {
+	Paths []ingressPath `json:"paths"`
+}
+
+type ingressPath struct {
+	Path     string         `json:"path"`
+	PathType string         `json:"pathType"`
+	Backend  ingressBackend `json:"backend"`
+}
+
+type ingressBackend struct {
+	Service ingressServiceBackend `json:"service"`
+}
+
+type ingressServiceBackend struct {
+	Name string             `json:"name"`
+	Port ingressServicePort `json:"port"`
+}
+
+type ingressServicePort struct {
+	Number int `json:"number"`
+}
+
+// httpRouteMethods are the methods HTTPRoutes can match, the bindings of
+// the other methods being matched by their path only.
+var httpRouteMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "DELETE": true,
+	"CONNECT": true, "OPTIONS": true, "TRACE": true, "PATCH": true,
+}
+
+var invalidKubernetesNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// kubernetesName returns the name of the manifest of the file generated at
+// base, e.g. "a-b-example" for "a/b/example".
+func kubernetesName(base string) string {
+	name := strings.Trim(invalidKubernetesNameChars.ReplaceAllString(strings.ToLower(base), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// pathMatch returns the path of tmpl matched by an exact match, or the
+// regular expression and the literal prefix of the paths it matches if it
+// has wildcards.
+func pathMatch(tmpl httprule.Template) (exact, re, prefix string) {
+	var literals, segments []string
+	wildcard := false
+	for i := 0; i+1 < len(tmpl.OpCodes); i += 2 {
+		switch utilities.OpCode(tmpl.OpCodes[i]) {
+		case utilities.OpLitPush:
+			lit := tmpl.Pool[tmpl.OpCodes[i+1]]
+			segments = append(segments, regexp.QuoteMeta(lit))
+			if !wildcard {
+				literals = append(literals, lit)
+			}
+		case utilities.OpPush:
+			segments = append(segments, "[^/]+")
+			wildcard = true
+		case utilities.OpPushM:
+			segments = append(segments, ".*")
+			wildcard = true
+		}
+	}
+	prefix = "/" + strings.Join(literals, "/")
+	if !wildcard {
+		exact = prefix
+		if tmpl.Verb != "" {
+			exact += ":" + tmpl.Verb
+		}
+		return exact, "", ""
+	}
+	re = "^/" + strings.Join(segments, "/")
+	if tmpl.Verb != "" {
+		re += ":" + regexp.QuoteMeta(tmpl.Verb)
+	}
+	return "", re + "$", prefix
+}
+
+// buildKubernetesManifest returns the manifest routing the bindings of the
+// file, generated at base, to the gateway, or an empty string if it has
+// none.
+func buildKubernetesManifest(k *KubernetesRoutes, file *descriptor.File, base string) (string, error) {
+	meta := kubernetesObjectMeta{Name: kubernetesName(base)}
+	var manifest interface{}
+	switch k.Kind {
+	case KubernetesHTTPRoute:
+		var matches []httpRouteMatch
+		seen := make(map[httpRouteMatch]bool)
+		for _, svc := range file.Services {
+			for _, meth := range svc.Methods {
+				for _, b := range meth.Bindings {
+					m := httpRouteMatch{Path: httpRoutePathMatch{Type: "Exact"}}
+					exact, re, _ := pathMatch(b.PathTmpl)
+					m.Path.Value = exact
+					if re != "" {
+						m.Path = httpRoutePathMatch{Type: "RegularExpression", Value: re}
+					}
+					if httpRouteMethods[b.HTTPMethod] {
+						m.Method = b.HTTPMethod
+					}
+					if !seen[m] {
+						seen[m] = true
+						matches = append(matches, m)
+					}
+				}
+			}
+		}
+		if len(matches) == 0 {
+			return "", nil
+		}
+		route := httpRoute{
+			APIVersion: "gateway.networking.k8s.io/v1",
+			Kind:       KubernetesHTTPRoute,
+			Metadata:   meta,
+		}
+		if k.Gateway != "" {
+			route.Spec.ParentRefs = []httpRouteRef{{Name: k.Gateway}}
+		}
+		for len(matches) > 0 {
+			n := len(matches)
+			if n > maxHTTPRouteMatches {
+				n = maxHTTPRouteMatches
+			}
+			route.Spec.Rules = append(route.Spec.Rules, httpRouteRule{
+				Matches:     matches[:n],
+				BackendRefs: []httpRouteRef{{Name: k.Service, Port: k.Port}},
+			})
+			matches = matches[n:]
+		}
+		manifest = route
+	case KubernetesIngress:
+		var paths []ingressPath
+		seen := make(map[string]bool)
+		for _, svc := range file.Services {
+			for _, meth := range svc.Methods {
+				for _, b := range meth.Bindings {
+					// Ingresses match neither methods nor wildcards, the
+					// paths with wildcards are matched by their literal prefix.
+					p := ingressPath{PathType: "Exact"}
+					exact, _, prefix := pathMatch(b.PathTmpl)
+					p.Path = exact
+					if exact == "" {
+						p.Path, p.PathType = prefix, "Prefix"
+					}
+					if key := p.PathType + " " + p.Path; !seen[key] {
+						seen[key] = true
+						p.Backend.Service = ingressServiceBackend{Name: k.Service, Port: ingressServicePort{Number: k.Port}}
+						paths = append(paths, p)
+					}
+				}
+			}
+		}
+		if len(paths) == 0 {
+			return "", nil
+		}
+		manifest = ingress{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       KubernetesIngress,
+			Metadata:   meta,
+			Spec:       ingressSpec{Rules: []ingressRule{{HTTP: ingressHTTPRule{Paths: paths}}}},
+		}
+	default:
+		return "", fmt.Errorf("invalid kind of Kubernetes routes %q", k.Kind)
+	}
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
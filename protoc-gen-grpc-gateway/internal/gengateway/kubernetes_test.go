@@ -0,0 +1,152 @@
+package gengateway
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+)
+
+func TestParseKubernetesRoutes(t *testing.T) {
+	for _, spec := range []struct {
+		kind, backend, gateway string
+		want                   *KubernetesRoutes
+	}{
+		{
+			kind:    "httproute",
+			backend: "gateway:8080",
+			gateway: "edge",
+			want:    &KubernetesRoutes{Kind: KubernetesHTTPRoute, Service: "gateway", Port: 8080, Gateway: "edge"},
+		},
+		{
+			kind:    "Ingress",
+			backend: "gateway:80",
+			want:    &KubernetesRoutes{Kind: KubernetesIngress, Service: "gateway", Port: 80},
+		},
+		{kind: "service", backend: "gateway:80"},
+		{kind: "ingress", backend: "gateway:80", gateway: "edge"},
+		{kind: "httproute", backend: "gateway"},
+		{kind: "httproute", backend: ":80"},
+		{kind: "httproute", backend: "gateway:http"},
+	} {
+		got, err := ParseKubernetesRoutes(spec.kind, spec.backend, spec.gateway)
+		if spec.want == nil {
+			if err == nil {
+				t.Errorf("ParseKubernetesRoutes(%q, %q, %q) = %+v; want an error", spec.kind, spec.backend, spec.gateway, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseKubernetesRoutes(%q, %q, %q) failed with %v; want success", spec.kind, spec.backend, spec.gateway, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, spec.want) {
+			t.Errorf("ParseKubernetesRoutes(%q, %q, %q) = %+v; want %+v", spec.kind, spec.backend, spec.gateway, got, spec.want)
+		}
+	}
+}
+
+// newKubernetesFixture returns the example file with bindings of the
+// templates, all bound to GET but the last one bound to POST.
+func newKubernetesFixture(t *testing.T, tmpls ...string) *descriptor.File {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	meth := file.Services[0].Methods[0]
+	meth.Bindings = nil
+	for i, tmpl := range tmpls {
+		compiler, err := httprule.Parse(tmpl)
+		if err != nil {
+			t.Fatalf("httprule.Parse(%q) failed with %v; want success", tmpl, err)
+		}
+		b := &descriptor.Binding{Method: meth, Index: i, HTTPMethod: "GET", PathTmpl: compiler.Compile()}
+		if i == len(tmpls)-1 {
+			b.HTTPMethod = "POST"
+		}
+		meth.Bindings = append(meth.Bindings, b)
+	}
+	return file
+}
+
+func TestBuildKubernetesManifestHTTPRoute(t *testing.T) {
+	file := newKubernetesFixture(t, "/v1/example", "/v1/{name=shelves/*}/books/{id}", "/v1/example/{path=**}:search", "/v1/example")
+	k := &KubernetesRoutes{Kind: KubernetesHTTPRoute, Service: "gateway", Port: 8080, Gateway: "edge"}
+	manifest, err := buildKubernetesManifest(k, file, "a/b/Example_v1")
+	if err != nil {
+		t.Fatalf("buildKubernetesManifest() failed with %v; want success", err)
+	}
+	var got httpRoute
+	if err := yaml.Unmarshal([]byte(manifest), &got); err != nil {
+		t.Fatalf("yaml.Unmarshal(%s) failed with %v; want success", manifest, err)
+	}
+	want := httpRoute{
+		APIVersion: "gateway.networking.k8s.io/v1",
+		Kind:       "HTTPRoute",
+		Metadata:   kubernetesObjectMeta{Name: "a-b-example-v1"},
+		Spec: httpRouteSpec{
+			ParentRefs: []httpRouteRef{{Name: "edge"}},
+			Rules: []httpRouteRule{
+				{
+					Matches: []httpRouteMatch{
+						{Path: httpRoutePathMatch{Type: "Exact", Value: "/v1/example"}, Method: "GET"},
+						{Path: httpRoutePathMatch{Type: "RegularExpression", Value: "^/v1/shelves/[^/]+/books/[^/]+$"}, Method: "GET"},
+						{Path: httpRoutePathMatch{Type: "RegularExpression", Value: "^/v1/example/.*:search$"}, Method: "GET"},
+						{Path: httpRoutePathMatch{Type: "Exact", Value: "/v1/example"}, Method: "POST"},
+					},
+					BackendRefs: []httpRouteRef{{Name: "gateway", Port: 8080}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildKubernetesManifest() = %s; want %+v", manifest, want)
+	}
+}
+
+func TestBuildKubernetesManifestIngress(t *testing.T) {
+	file := newKubernetesFixture(t, "/v1/example:get", "/v1/{name=shelves/*}/books", "/{name}", "/v1/example:get")
+	k := &KubernetesRoutes{Kind: KubernetesIngress, Service: "gateway", Port: 80}
+	manifest, err := buildKubernetesManifest(k, file, "example")
+	if err != nil {
+		t.Fatalf("buildKubernetesManifest() failed with %v; want success", err)
+	}
+	var got ingress
+	if err := yaml.Unmarshal([]byte(manifest), &got); err != nil {
+		t.Fatalf("yaml.Unmarshal(%s) failed with %v; want success", manifest, err)
+	}
+	backend := ingressBackend{Service: ingressServiceBackend{Name: "gateway", Port: ingressServicePort{Number: 80}}}
+	want := ingress{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+		Metadata:   kubernetesObjectMeta{Name: "example"},
+		Spec: ingressSpec{
+			Rules: []ingressRule{
+				{
+					HTTP: ingressHTTPRule{
+						Paths: []ingressPath{
+							{Path: "/v1/example:get", PathType: "Exact", Backend: backend},
+							{Path: "/v1/shelves", PathType: "Prefix", Backend: backend},
+							{Path: "/", PathType: "Prefix", Backend: backend},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildKubernetesManifest() = %s; want %+v", manifest, want)
+	}
+}
+
+func TestBuildKubernetesManifestWithoutBindings(t *testing.T) {
+	file := newKubernetesFixture(t)
+	for _, kind := range []string{KubernetesHTTPRoute, KubernetesIngress} {
+		manifest, err := buildKubernetesManifest(&KubernetesRoutes{Kind: kind, Service: "gateway", Port: 80}, file, "example")
+		if err != nil {
+			t.Errorf("buildKubernetesManifest() with kind %s failed with %v; want success", kind, err)
+		}
+		if manifest != "" {
+			t.Errorf("buildKubernetesManifest() with kind %s = %s; want no manifest", kind, manifest)
+		}
+	}
+}
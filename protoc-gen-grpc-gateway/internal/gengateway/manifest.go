@@ -0,0 +1,103 @@
+package gengateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+// routeManifest is the content of the *.routes.json files, listing the routes
+// registered by the generated code of a proto file.
+type routeManifest struct {
+	// Source is the name of the proto file.
+	Source string  `json:"source"`
+	Routes []route `json:"routes"`
+}
+
+// route is a single binding registered by the generated code.
+type route struct {
+	// Service is the fully qualified name of the service.
+	Service string `json:"service"`
+	// Method is the name of the method as used by the generated code.
+	Method string `json:"method"`
+	// RPC is the full gRPC method name the route forwards to.
+	RPC string `json:"rpc"`
+	// Binding is the index of the binding in the method.
+	Binding         int    `json:"binding"`
+	HTTPMethod      string `json:"httpMethod"`
+	Path            string `json:"path"`
+	Body            string `json:"body,omitempty"`
+	ResponseBody    string `json:"responseBody,omitempty"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+	// Pattern is the compiled path template, as passed to runtime.NewPattern.
+	Pattern routePattern `json:"pattern"`
+}
+
+type routePattern struct {
+	Version int      `json:"version"`
+	OpCodes []int    `json:"opCodes"`
+	Pool    []string `json:"pool"`
+	Verb    string   `json:"verb"`
+}
+
+// buildRouteManifest lists the routes of every binding of the file. It must be
+// called after the code of the file has been generated, so that the names
+// match the ones used by the generated code.
+func buildRouteManifest(file *descriptor.File) routeManifest {
+	m := routeManifest{
+		Source: file.GetName(),
+		Routes: []route{},
+	}
+	for _, svc := range file.Services {
+		for _, meth := range svc.Methods {
+			for _, b := range meth.Bindings {
+				r := route{
+					Service:         svc.FQSN()[1:],
+					Method:          meth.GetName(),
+					RPC:             fmt.Sprintf("/%s.%s/%s", svc.File.GetPackage(), svc.GetName(), meth.GetName()),
+					Binding:         b.Index,
+					HTTPMethod:      b.HTTPMethod,
+					Path:            b.PathTmpl.Template,
+					ClientStreaming: meth.GetClientStreaming(),
+					ServerStreaming: meth.GetServerStreaming(),
+					Pattern: routePattern{
+						Version: b.PathTmpl.Version,
+						OpCodes: b.PathTmpl.OpCodes,
+						Pool:    b.PathTmpl.Pool,
+						Verb:    b.PathTmpl.Verb,
+					},
+				}
+				if r.Pattern.OpCodes == nil {
+					r.Pattern.OpCodes = []int{}
+				}
+				if r.Pattern.Pool == nil {
+					r.Pattern.Pool = []string{}
+				}
+				if b.Body != nil {
+					r.Body = "*"
+					if len(b.Body.FieldPath) > 0 {
+						r.Body = b.Body.FieldPath.String()
+					}
+				}
+				if b.ResponseBody != nil && len(b.ResponseBody.FieldPath) > 0 {
+					r.ResponseBody = b.ResponseBody.FieldPath.String()
+				}
+				m.Routes = append(m.Routes, r)
+			}
+		}
+	}
+	return m
+}
+
+func encodeRouteManifest(m routeManifest) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
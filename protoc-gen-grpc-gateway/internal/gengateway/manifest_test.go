@@ -0,0 +1,75 @@
+package gengateway
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+)
+
+func TestBuildRouteManifest(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = httprule.Template{
+		Version:  1,
+		OpCodes:  []int{2, 0, 2, 1},
+		Pool:     []string{"v1", "example"},
+		Template: "/v1/example",
+	}
+
+	got := buildRouteManifest(file)
+	want := routeManifest{
+		Source: "example.proto",
+		Routes: []route{
+			{
+				Service:    "example.ExampleService",
+				Method:     "Example",
+				RPC:        "/example.ExampleService/Example",
+				HTTPMethod: "GET",
+				Path:       "/v1/example",
+				Body:       "*",
+				Pattern: routePattern{
+					Version: 1,
+					OpCodes: []int{2, 0, 2, 1},
+					Pool:    []string{"v1", "example"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRouteManifest() = %+v; want %+v", got, want)
+	}
+
+	encoded, err := encodeRouteManifest(got)
+	if err != nil {
+		t.Fatalf("encodeRouteManifest() failed with %v; want success", err)
+	}
+	var decoded routeManifest
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed with %v; want success", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("encodeRouteManifest() round trip = %+v; want %+v", decoded, want)
+	}
+}
+
+func TestGenerateRouteManifest(t *testing.T) {
+	file := newExampleFileDescriptor()
+	g := &generator{Options: Options{RouteManifest: true}}
+	files, err := g.Generate([]*descriptor.File{crossLinkFixture(file)})
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.GetName())
+	}
+	if want := []string{"example.com/path/to/example/example.pb/example.pb.gw.go", "example.com/path/to/example/example.pb/example.routes.json"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Generate() emitted %v; want %v", names, want)
+	}
+	if !strings.Contains(files[1].GetContent(), `"rpc": "/example.ExampleService/Example"`) {
+		t.Errorf("Generate() emitted manifest %s; want it to list /example.ExampleService/Example", files[1].GetContent())
+	}
+}
@@ -0,0 +1,161 @@
+package gengateway
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/golang/glog"
+)
+
+// TemplateVersion is the version of the builtin templates. It is incremented
+// whenever the names of the templates or the data they are executed with
+// change incompatibly, so that overriding templates can be checked against it.
+const TemplateVersion = 1
+
+// templateVersionPattern matches the version comment of overriding templates.
+var templateVersionPattern = regexp.MustCompile(`\{\{/\* grpc-gateway template version (\d+) \*/\}\}`)
+
+// templateSet holds the templates rendering the sections of the generated code.
+type templateSet struct {
+	header       *template.Template
+	handler      *template.Template
+	localHandler *template.Template
+	localTrailer *template.Template
+	trailer      *template.Template
+}
+
+var builtinTemplates = templateSet{
+	header:       headerTemplate,
+	handler:      handlerTemplate,
+	localHandler: localHandlerTemplate,
+	localTrailer: localTrailerTemplate,
+	trailer:      trailerTemplate,
+}
+
+// sections returns the templates of the set by file name, without extension.
+func (ts *templateSet) sections() map[string]**template.Template {
+	return map[string]**template.Template{
+		"header":        &ts.header,
+		"handler":       &ts.handler,
+		"local-handler": &ts.localHandler,
+		"local-trailer": &ts.localTrailer,
+		"trailer":       &ts.trailer,
+	}
+}
+
+// loadTemplateOverrides returns the builtin templates with the sections found
+// in dir replaced. Every '<section>.tmpl' file of dir replaces the section of
+// the same name, and may redefine the templates the section calls. The
+// overriding templates can call the builtin helpers and funcs.
+func loadTemplateOverrides(dir string, funcs template.FuncMap) (*templateSet, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates from %q: %v", dir, err)
+	}
+	allFuncs := make(template.FuncMap, len(builtinTemplateFuncs)+len(funcs))
+	for name, fn := range builtinTemplateFuncs {
+		allFuncs[name] = fn
+	}
+	for name, fn := range funcs {
+		allFuncs[name] = fn
+	}
+
+	ts := builtinTemplates
+	sections := ts.sections()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tmpl" {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		section, ok := sections[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown template %q in %q", entry.Name(), dir)
+		}
+		path := filepath.Join(dir, entry.Name())
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %q: %v", path, err)
+		}
+		if err := checkTemplateVersion(path, string(src)); err != nil {
+			return nil, err
+		}
+		tmpl, err := (*section).Clone()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.Funcs(allFuncs).Parse(string(src)); err != nil {
+			return nil, fmt.Errorf("failed to parse template %q: %v", path, err)
+		}
+		*section = tmpl
+	}
+	return &ts, nil
+}
+
+func checkTemplateVersion(path, src string) error {
+	match := templateVersionPattern.FindStringSubmatch(src)
+	if match == nil {
+		glog.Warningf("Template %q does not declare the version of the builtin templates it is based on", path)
+		return nil
+	}
+	if version, _ := strconv.Atoi(match[1]); version != TemplateVersion {
+		return fmt.Errorf("template %q is based on version %d of the builtin templates, want version %d", path, version, TemplateVersion)
+	}
+	return nil
+}
+
+// BuiltinTemplates returns the source of the builtin templates, by the name of
+// the file overriding them in template_dir. Each source starts with the version
+// comment of the builtin templates and defines the templates its section calls,
+// so it is a starting point for an overriding template.
+func BuiltinTemplates() map[string]string {
+	sources := make(map[string]string)
+	for name, section := range builtinTemplates.sections() {
+		tmpl := *section
+		var b strings.Builder
+		fmt.Fprintf(&b, "{{/* grpc-gateway template version %d */}}", TemplateVersion)
+		b.WriteString(sourceOf(tmpl.Tree.Root))
+
+		var associated []string
+		for _, t := range tmpl.Templates() {
+			if t.Name() != tmpl.Name() && t.Tree != nil {
+				associated = append(associated, t.Name())
+			}
+		}
+		sort.Strings(associated)
+		for _, a := range associated {
+			fmt.Fprintf(&b, "\n{{define %q}}%s{{end}}", a, sourceOf(tmpl.Lookup(a).Tree.Root))
+		}
+		b.WriteString("\n")
+		sources[name] = b.String()
+	}
+	return sources
+}
+
+// sourceOf returns the source of the parsed template node. The trim markers
+// are lost once parsed, so that a text ending with "{" is written right before
+// the next action, which is escaped not to be read as a part of it.
+func sourceOf(node parse.Node) string {
+	return strings.Replace(node.String(), "{{{", `{{"{"}}{{`, -1)
+}
+
+// WriteBuiltinTemplates writes the source of the builtin templates into dir,
+// as expected by template_dir.
+func WriteBuiltinTemplates(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, src := range BuiltinTemplates() {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".tmpl"), []byte(src), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
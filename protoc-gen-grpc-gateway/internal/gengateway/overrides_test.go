@@ -0,0 +1,132 @@
+package gengateway
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() failed with %v; want success", err)
+	}
+	for name, src := range templates {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile() failed with %v; want success", err)
+		}
+	}
+	return dir
+}
+
+func generateWithTemplates(t *testing.T, dir string) (string, error) {
+	t.Helper()
+	g := &generator{Options: Options{RegisterFuncSuffix: "Handler", TemplateDir: dir}}
+	files, err := g.Generate([]*descriptor.File{crossLinkFixture(newExampleFileDescriptor())})
+	if err != nil {
+		return "", err
+	}
+	return files[0].GetContent(), nil
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	version := fmt.Sprintf("{{/* grpc-gateway template version %d */}}", TemplateVersion)
+	dir := writeTemplates(t, map[string]string{
+		"header.tmpl": version + `
+// Code generated by house-gateway. DO NOT EDIT.
+// source: {{.GetName}}
+
+package {{.GoPkg.Name}}
+import (
+	{{range $i := .Imports}}{{if $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+
+	{{range $i := .Imports}}{{if not $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+)
+`,
+		// Only redefines a template called by the builtin handler template.
+		"handler.tmpl": version + `{{define "request-func-signature"}}
+// {{camel .Method.GetName}} follows house conventions.
+func request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx context.Context, marshaler runtime.Marshaler, client {{.Method.Service.InstanceName}}Client, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error)
+{{- end}}`,
+		"README.md": "not a template",
+	})
+	defer os.RemoveAll(dir)
+
+	got, err := generateWithTemplates(t, dir)
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+	for _, want := range []string{
+		"// Code generated by house-gateway. DO NOT EDIT.\n",
+		"// Example follows house conventions.\n",
+		"func request_ExampleService_Example_0(",
+		"func RegisterExampleServiceHandlerServer(",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Generate() = %s; want to contain %s", got, want)
+		}
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	want, err := generateWithTemplates(t, "")
+	if err != nil {
+		t.Fatalf("Generate() failed with %v; want success", err)
+	}
+
+	templates := make(map[string]string)
+	for name, src := range BuiltinTemplates() {
+		templates[name+".tmpl"] = src
+	}
+	if len(templates) != 5 {
+		t.Errorf("BuiltinTemplates() = %v; want 5 templates", templates)
+	}
+	dir := writeTemplates(t, templates)
+	defer os.RemoveAll(dir)
+
+	got, err := generateWithTemplates(t, dir)
+	if err != nil {
+		t.Fatalf("Generate() with the builtin templates failed with %v; want success", err)
+	}
+	if got != want {
+		t.Errorf("Generate() with the builtin templates = %s; want %s", got, want)
+	}
+}
+
+func TestTemplateOverridesErrors(t *testing.T) {
+	for _, spec := range []struct {
+		name      string
+		templates map[string]string
+		want      string
+	}{
+		{
+			name:      "unknown template",
+			templates: map[string]string{"footer.tmpl": "x"},
+			want:      "unknown template",
+		},
+		{
+			name:      "version mismatch",
+			templates: map[string]string{"header.tmpl": "{{/* grpc-gateway template version 0 */}}x"},
+			want:      "want version",
+		},
+		{
+			name:      "parse error",
+			templates: map[string]string{"trailer.tmpl": "{{ undefinedFunc }}"},
+			want:      "failed to parse",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			dir := writeTemplates(t, spec.templates)
+			defer os.RemoveAll(dir)
+			if _, err := generateWithTemplates(t, dir); err == nil || !strings.Contains(err.Error(), spec.want) {
+				t.Errorf("Generate() failed with %v; want error containing %q", err, spec.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package gengateway
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+)
+
+// Router adapters generating functions registering the handlers to other
+// routers than runtime.ServeMux.
+const (
+	routerAdapterChi     = "chi"
+	routerAdapterGorilla = "gorilla"
+)
+
+// routerAdapterPackages are the packages the functions of the router adapters
+// refer to.
+var routerAdapterPackages = map[string]descriptor.GoPackage{
+	routerAdapterChi:     {Path: "github.com/go-chi/chi/v5", Name: "chi"},
+	routerAdapterGorilla: {Path: "github.com/gorilla/mux", Name: "mux", Alias: "gorillamux"},
+}
+
+// routerPattern is the pattern of a binding in another router.
+type routerPattern struct {
+	// Pattern is the pattern, e.g. "GET /v1/users/{user_id}" for the net/http
+	// ServeMux of Go 1.22 or "/v1/users/{user_id}" for chi.
+	Pattern string
+	// Wildcards are the wildcards of the pattern bound to fields.
+	Wildcards []routerWildcard
+}
+
+// routerWildcard is a wildcard of a router pattern bound to a field.
+type routerWildcard struct {
+	// Name is the name of the wildcard, e.g. "user_id".
+	Name string
+	// FieldPath is the path of the field, e.g. "user.id".
+	FieldPath string
+}
+
+// routerSegment is a segment of a path template.
+type routerSegment struct {
+	// literal is the value of a literal segment.
+	literal string
+	// wildcard is set for the segments matched by a wildcard.
+	wildcard bool
+	// multi is set for a wildcard matching the rest of the path.
+	multi bool
+	// name is the name of a wildcard bound to a field.
+	name string
+}
+
+// splitTemplate returns the segments of the path template of b. Routers only
+// have wildcards matching a whole segment or, last, the rest of the path, so
+// templates with variables matching several segments are not supported.
+func splitTemplate(b *descriptor.Binding) ([]routerSegment, []routerWildcard, error) {
+	tmpl := b.PathTmpl
+	var (
+		segs      []routerSegment
+		wildcards []routerWildcard
+	)
+	for i := 0; i+1 < len(tmpl.OpCodes); i += 2 {
+		operand := tmpl.OpCodes[i+1]
+		switch utilities.OpCode(tmpl.OpCodes[i]) {
+		case utilities.OpLitPush:
+			segs = append(segs, routerSegment{literal: tmpl.Pool[operand]})
+		case utilities.OpPush:
+			segs = append(segs, routerSegment{wildcard: true})
+		case utilities.OpPushM:
+			segs = append(segs, routerSegment{wildcard: true, multi: true})
+		case utilities.OpConcatN:
+			if operand != 1 || !segs[len(segs)-1].wildcard {
+				return nil, nil, fmt.Errorf("path template %q binds a variable to several segments or to a literal, which router patterns cannot express", tmpl.Template)
+			}
+		case utilities.OpCapture:
+			field := tmpl.Pool[operand]
+			name := strings.Replace(field, ".", "_", -1)
+			for _, w := range wildcards {
+				if w.Name == name {
+					return nil, nil, fmt.Errorf("path template %q binds several fields to the router wildcard %q", tmpl.Template, name)
+				}
+			}
+			segs[len(segs)-1].name = name
+			wildcards = append(wildcards, routerWildcard{Name: name, FieldPath: field})
+		}
+	}
+	for i, seg := range segs {
+		if seg.multi && i != len(segs)-1 {
+			return nil, nil, fmt.Errorf("path template %q has a multi-segment wildcard before its last segment, which router patterns cannot express", tmpl.Template)
+		}
+		if seg.wildcard && seg.name == "" {
+			segs[i].name = fmt.Sprintf("_%d", i)
+		}
+	}
+	return segs, wildcards, nil
+}
+
+// newRouterPattern returns the pattern of b formatting the wildcard segments
+// with wildcard.
+func newRouterPattern(b *descriptor.Binding, wildcard func(seg routerSegment) string) (*routerPattern, []routerSegment, error) {
+	segs, wildcards, err := splitTemplate(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	strs := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		if seg.wildcard {
+			strs = append(strs, wildcard(seg))
+		} else {
+			strs = append(strs, seg.literal)
+		}
+	}
+	path := "/" + strings.Join(strs, "/")
+	if b.PathTmpl.Verb != "" {
+		path += ":" + b.PathTmpl.Verb
+	}
+	return &routerPattern{Pattern: path, Wildcards: wildcards}, segs, nil
+}
+
+// newStdlibPattern returns the pattern of b for the net/http ServeMux of Go
+// 1.22, whose wildcards cannot be followed by a verb.
+func newStdlibPattern(b *descriptor.Binding) (*routerPattern, error) {
+	p, segs, err := newRouterPattern(b, func(seg routerSegment) string {
+		if seg.multi {
+			return "{" + seg.name + "...}"
+		}
+		return "{" + seg.name + "}"
+	})
+	if err != nil {
+		return nil, err
+	}
+	if b.PathTmpl.Verb != "" && (len(segs) == 0 || segs[len(segs)-1].wildcard) {
+		return nil, fmt.Errorf("path template %q has a verb after a wildcard, which net/http patterns cannot express", b.PathTmpl.Template)
+	}
+	p.Pattern = b.HTTPMethod + " " + p.Pattern
+	return p, nil
+}
+
+// newChiPattern returns the pattern of b for chi, whose multi-segment
+// wildcard is the unnamed "*".
+func newChiPattern(b *descriptor.Binding) (*routerPattern, error) {
+	p, segs, err := newRouterPattern(b, func(seg routerSegment) string {
+		if seg.multi {
+			return "*"
+		}
+		return "{" + seg.name + "}"
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n := len(segs); n > 0 && segs[n-1].multi {
+		if b.PathTmpl.Verb != "" {
+			return nil, fmt.Errorf("path template %q has a verb after a multi-segment wildcard, which chi patterns cannot express", b.PathTmpl.Template)
+		}
+		for i, w := range p.Wildcards {
+			if w.Name == segs[n-1].name {
+				p.Wildcards[i].Name = "*"
+			}
+		}
+	}
+	return p, nil
+}
+
+// newGorillaPattern returns the pattern of b for gorilla/mux.
+func newGorillaPattern(b *descriptor.Binding) (*routerPattern, error) {
+	p, _, err := newRouterPattern(b, func(seg routerSegment) string {
+		if seg.multi {
+			return "{" + seg.name + ":.*}"
+		}
+		return "{" + seg.name + "}"
+	})
+	return p, err
+}
@@ -0,0 +1,143 @@
+package gengateway
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+)
+
+func TestNewStdlibPattern(t *testing.T) {
+	for _, spec := range []struct {
+		tmpl      string
+		want      string
+		wildcards []routerWildcard
+	}{
+		{
+			tmpl: "/v1/users",
+			want: "GET /v1/users",
+		},
+		{
+			tmpl:      "/v1/users/{id}",
+			want:      "GET /v1/users/{id}",
+			wildcards: []routerWildcard{{Name: "id", FieldPath: "id"}},
+		},
+		{
+			tmpl:      "/v1/groups/{group.id}/users/{user_id=*}",
+			want:      "GET /v1/groups/{group_id}/users/{user_id}",
+			wildcards: []routerWildcard{{Name: "group_id", FieldPath: "group.id"}, {Name: "user_id", FieldPath: "user_id"}},
+		},
+		{
+			tmpl:      "/v1/*/files/{path=**}",
+			want:      "GET /v1/{_1}/files/{path...}",
+			wildcards: []routerWildcard{{Name: "path", FieldPath: "path"}},
+		},
+		{
+			tmpl: "/v1/users:search",
+			want: "GET /v1/users:search",
+		},
+	} {
+		compiler, err := httprule.Parse(spec.tmpl)
+		if err != nil {
+			t.Fatalf("httprule.Parse(%q) failed with %v; want success", spec.tmpl, err)
+		}
+		b := &descriptor.Binding{HTTPMethod: "GET", PathTmpl: compiler.Compile()}
+		got, err := newStdlibPattern(b)
+		if err != nil {
+			t.Errorf("newStdlibPattern(%q) failed with %v; want success", spec.tmpl, err)
+			continue
+		}
+		if got.Pattern != spec.want {
+			t.Errorf("newStdlibPattern(%q).Pattern = %q; want %q", spec.tmpl, got.Pattern, spec.want)
+		}
+		if !reflect.DeepEqual(got.Wildcards, spec.wildcards) {
+			t.Errorf("newStdlibPattern(%q).Wildcards = %#v; want %#v", spec.tmpl, got.Wildcards, spec.wildcards)
+		}
+	}
+}
+
+func TestNewStdlibPatternUnsupported(t *testing.T) {
+	for _, tmpl := range []string{
+		"/v1/{name=projects/*}",
+		"/v1/{name=*/*}",
+		"/v1/**/files",
+		"/v1/users/{id}:cancel",
+		"/v1/{a.b}/{a_b}",
+	} {
+		compiler, err := httprule.Parse(tmpl)
+		if err != nil {
+			t.Fatalf("httprule.Parse(%q) failed with %v; want success", tmpl, err)
+		}
+		b := &descriptor.Binding{HTTPMethod: "GET", PathTmpl: compiler.Compile()}
+		if got, err := newStdlibPattern(b); err == nil {
+			t.Errorf("newStdlibPattern(%q) = %#v; want an error", tmpl, got)
+		}
+	}
+}
+
+func TestNewChiAndGorillaPatterns(t *testing.T) {
+	for _, spec := range []struct {
+		tmpl      string
+		chi       string
+		gorilla   string
+		wildcards []routerWildcard
+		chiNames  []string
+	}{
+		{
+			tmpl:      "/v1/groups/{group.id}/users/{user_id}",
+			chi:       "/v1/groups/{group_id}/users/{user_id}",
+			gorilla:   "/v1/groups/{group_id}/users/{user_id}",
+			wildcards: []routerWildcard{{Name: "group_id", FieldPath: "group.id"}, {Name: "user_id", FieldPath: "user_id"}},
+			chiNames:  []string{"group_id", "user_id"},
+		},
+		{
+			tmpl:      "/v1/*/files/{path=**}",
+			chi:       "/v1/{_1}/files/*",
+			gorilla:   "/v1/{_1}/files/{path:.*}",
+			wildcards: []routerWildcard{{Name: "path", FieldPath: "path"}},
+			chiNames:  []string{"*"},
+		},
+		{
+			tmpl:      "/v1/users/{id}:cancel",
+			chi:       "/v1/users/{id}:cancel",
+			gorilla:   "/v1/users/{id}:cancel",
+			wildcards: []routerWildcard{{Name: "id", FieldPath: "id"}},
+			chiNames:  []string{"id"},
+		},
+	} {
+		compiler, err := httprule.Parse(spec.tmpl)
+		if err != nil {
+			t.Fatalf("httprule.Parse(%q) failed with %v; want success", spec.tmpl, err)
+		}
+		b := &descriptor.Binding{HTTPMethod: "GET", PathTmpl: compiler.Compile()}
+
+		chi, err := newChiPattern(b)
+		if err != nil {
+			t.Errorf("newChiPattern(%q) failed with %v; want success", spec.tmpl, err)
+			continue
+		}
+		if chi.Pattern != spec.chi {
+			t.Errorf("newChiPattern(%q).Pattern = %q; want %q", spec.tmpl, chi.Pattern, spec.chi)
+		}
+		var chiNames []string
+		for _, w := range chi.Wildcards {
+			chiNames = append(chiNames, w.Name)
+		}
+		if !reflect.DeepEqual(chiNames, spec.chiNames) {
+			t.Errorf("newChiPattern(%q).Wildcards = %#v; want names %q", spec.tmpl, chi.Wildcards, spec.chiNames)
+		}
+
+		gorilla, err := newGorillaPattern(b)
+		if err != nil {
+			t.Errorf("newGorillaPattern(%q) failed with %v; want success", spec.tmpl, err)
+			continue
+		}
+		if gorilla.Pattern != spec.gorilla {
+			t.Errorf("newGorillaPattern(%q).Pattern = %q; want %q", spec.tmpl, gorilla.Pattern, spec.gorilla)
+		}
+		if !reflect.DeepEqual(gorilla.Wildcards, spec.wildcards) {
+			t.Errorf("newGorillaPattern(%q).Wildcards = %#v; want %#v", spec.tmpl, gorilla.Wildcards, spec.wildcards)
+		}
+	}
+}
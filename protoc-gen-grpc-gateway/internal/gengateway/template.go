@@ -20,12 +20,70 @@ type param struct {
 	RegisterFuncSuffix string
 	AllowPatchFeature  bool
 	OmitPackageDoc     bool
+	PathHelpers        bool
+	HTTPClient         bool
+	Hooks              bool
+	Validate           bool
+	// UnexportedRegisterFuncs generates unexported register functions.
+	UnexportedRegisterFuncs bool
+	// RegisterAll, if set, generates a function registering all the services of a file.
+	RegisterAll *registerAll
+	// StdlibPatterns generates functions registering the handlers to a net/http ServeMux.
+	StdlibPatterns bool
+	// RouterAdapters are the other routers functions registering the handlers to are generated for.
+	RouterAdapters []string
+	// GenericForwarders registers the generic handlers of the runtime instead of a handler per binding.
+	GenericForwarders bool
+	// LocalServerStreaming supports server streaming methods in the handlers calling servers in process.
+	LocalServerStreaming bool
+	// FieldViolations reports the field of the requests which could not be decoded in a BadRequest detail.
+	FieldViolations bool
+	// AuditLog passes the requests and responses of unary methods to runtime.Audit.
+	AuditLog bool
+	// Record passes the calls of unary methods to runtime.Record.
+	Record bool
+	// PoolRequests reuses the request messages of the unary methods forwarded to clients.
+	PoolRequests bool
+	// GraphQL generates functions registering the unary methods as the fields of the schema of a runtime.GraphQLHandler.
+	GraphQL bool
+	// BuildConstraint is the build constraint of the generated file, if any.
+	BuildConstraint *buildConstraint
+	// GenerationHeader describes the generation of the file, if requested.
+	GenerationHeader *generationHeader
+	// templates overrides the builtin templates if set.
+	templates *templateSet
 }
 
 type binding struct {
 	*descriptor.Binding
 	Registry          *descriptor.Registry
 	AllowPatchFeature bool
+	Hooks             bool
+	Validate          bool
+	// LocalServerStreaming generates the local request functions of server streaming methods.
+	LocalServerStreaming bool
+	// FieldViolations returns the errors decoding the request with runtime.FieldViolationError.
+	FieldViolations bool
+	// AuditLog calls runtime.Audit with the request and the response of unary methods.
+	AuditLog bool
+	// Record calls runtime.Record with the request, the response and the metadata of unary methods.
+	Record bool
+	// PoolRequests gets the request messages of unary methods from a runtime.MessagePool.
+	PoolRequests bool
+}
+
+// PoolRequest returns whether the request message protoReq is pooled, in which
+// case it is a pointer.
+func (b binding) PoolRequest() bool {
+	return b.PoolRequests && !b.Method.GetClientStreaming() && !b.Method.GetServerStreaming()
+}
+
+// RequestRef returns the expression of the pointer to the request message.
+func (b binding) RequestRef() string {
+	if b.PoolRequest() {
+		return "protoReq"
+	}
+	return "&protoReq"
 }
 
 // GetBodyFieldPath returns the binding body's fieldpath.
@@ -143,14 +201,54 @@ func (f queryParamFilter) String() string {
 }
 
 type trailerParams struct {
-	Services           []*descriptor.Service
-	UseRequestContext  bool
-	RegisterFuncSuffix string
+	Services                []*descriptor.Service
+	UseRequestContext       bool
+	RegisterFuncSuffix      string
+	UnexportedRegisterFuncs bool
+	RegisterAll             *registerAll
+	PathHelpers             bool
+	HTTPClient              bool
+	Hooks                   bool
+	// GenericForwarders registers the generic handlers of the runtime instead of a handler per binding.
+	GenericForwarders bool
+	// LocalServerStreaming supports server streaming methods in the handlers calling servers in process.
+	LocalServerStreaming bool
+	// StdlibPatterns, ChiPatterns and GorillaPatterns are the patterns of the
+	// bindings in the net/http ServeMux, chi and gorilla/mux, if requested.
+	StdlibPatterns  map[*descriptor.Binding]*routerPattern
+	ChiPatterns     map[*descriptor.Binding]*routerPattern
+	GorillaPatterns map[*descriptor.Binding]*routerPattern
+	// GraphQL are the GraphQL schemas of the services, if requested.
+	GraphQL map[*descriptor.Service]*graphQLService
+	// PathParamSeparator separates the values of repeated path parameters.
+	PathParamSeparator string
+}
+
+// RegisterFuncPrefix returns the prefix of the names of the register functions.
+func (p trailerParams) RegisterFuncPrefix() string {
+	if p.UnexportedRegisterFuncs {
+		return "register"
+	}
+	return "Register"
+}
+
+// registerAll describes the function registering all the services of a file.
+type registerAll struct {
+	// Name is the camel-cased base name of the file.
+	Name string
+	// Source is the name of the file.
+	Source string
+	// Services are the camel-cased names of the services with bindings.
+	Services []string
 }
 
 func applyTemplate(p param, reg *descriptor.Registry) (string, error) {
+	ts := p.templates
+	if ts == nil {
+		ts = &builtinTemplates
+	}
 	w := bytes.NewBuffer(nil)
-	if err := headerTemplate.Execute(w, p); err != nil {
+	if err := ts.header.Execute(w, p); err != nil {
 		return "", err
 	}
 	var targetServices []*descriptor.Service
@@ -171,19 +269,31 @@ func applyTemplate(p param, reg *descriptor.Registry) (string, error) {
 			meth.Name = &methName
 			for _, b := range meth.Bindings {
 				methodWithBindingsSeen = true
-				if err := handlerTemplate.Execute(w, binding{
+				if err := ts.handler.Execute(w, binding{
 					Binding:           b,
 					Registry:          reg,
 					AllowPatchFeature: p.AllowPatchFeature,
+					Hooks:             p.Hooks,
+					Validate:          p.Validate,
+					FieldViolations:   p.FieldViolations,
+					AuditLog:          p.AuditLog,
+					Record:            p.Record,
+					PoolRequests:      p.PoolRequests,
 				}); err != nil {
 					return "", err
 				}
 
 				// Local
-				if err := localHandlerTemplate.Execute(w, binding{
-					Binding:           b,
-					Registry:          reg,
-					AllowPatchFeature: p.AllowPatchFeature,
+				if err := ts.localHandler.Execute(w, binding{
+					Binding:              b,
+					Registry:             reg,
+					AllowPatchFeature:    p.AllowPatchFeature,
+					Hooks:                p.Hooks,
+					Validate:             p.Validate,
+					LocalServerStreaming: p.LocalServerStreaming,
+					FieldViolations:      p.FieldViolations,
+					AuditLog:             p.AuditLog,
+					Record:               p.Record,
 				}); err != nil {
 					return "", err
 				}
@@ -198,25 +308,129 @@ func applyTemplate(p param, reg *descriptor.Registry) (string, error) {
 	}
 
 	tp := trailerParams{
-		Services:           targetServices,
-		UseRequestContext:  p.UseRequestContext,
-		RegisterFuncSuffix: p.RegisterFuncSuffix,
+		Services:                targetServices,
+		UseRequestContext:       p.UseRequestContext,
+		RegisterFuncSuffix:      p.RegisterFuncSuffix,
+		UnexportedRegisterFuncs: p.UnexportedRegisterFuncs,
+		RegisterAll:             p.RegisterAll,
+		PathHelpers:             p.PathHelpers,
+		HTTPClient:              p.HTTPClient,
+		Hooks:                   p.Hooks,
+		GenericForwarders:       p.GenericForwarders,
+		LocalServerStreaming:    p.LocalServerStreaming,
+		PathParamSeparator:      ",",
+	}
+	if reg != nil {
+		tp.PathParamSeparator = string(reg.GetRepeatedPathParamSeparator())
+	}
+	if p.StdlibPatterns {
+		var err error
+		if tp.StdlibPatterns, err = routerPatterns(targetServices, newStdlibPattern); err != nil {
+			return "", err
+		}
+	}
+	for _, adapter := range p.RouterAdapters {
+		var err error
+		switch adapter {
+		case routerAdapterChi:
+			tp.ChiPatterns, err = routerPatterns(targetServices, newChiPattern)
+		case routerAdapterGorilla:
+			tp.GorillaPatterns, err = routerPatterns(targetServices, newGorillaPattern)
+		default:
+			err = fmt.Errorf("unknown router adapter %q", adapter)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if p.GraphQL {
+		var err error
+		if tp.GraphQL, err = graphQLServices(targetServices, reg); err != nil {
+			return "", err
+		}
 	}
 	// Local
-	if err := localTrailerTemplate.Execute(w, tp); err != nil {
+	if err := ts.localTrailer.Execute(w, tp); err != nil {
 		return "", err
 	}
 
-	if err := trailerTemplate.Execute(w, tp); err != nil {
+	if err := ts.trailer.Execute(w, tp); err != nil {
 		return "", err
 	}
 	return w.String(), nil
 }
 
+// routerPatterns returns the patterns of the bindings of svcs returned by newPattern.
+func routerPatterns(svcs []*descriptor.Service, newPattern func(*descriptor.Binding) (*routerPattern, error)) (map[*descriptor.Binding]*routerPattern, error) {
+	patterns := make(map[*descriptor.Binding]*routerPattern)
+	for _, svc := range svcs {
+		for _, meth := range svc.Methods {
+			for _, b := range meth.Bindings {
+				pattern, err := newPattern(b)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %v", svc.GetName(), meth.GetName(), err)
+				}
+				patterns[b] = pattern
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// modifyRequestTemplate passes decoded requests to the request modifiers,
+// validateTemplate validates them, beforeHookTemplate and afterHookTemplate
+// call the hooks of unary methods, and auditTemplate audits and
+// recordTemplate records their calls, in both the handlers forwarding to
+// clients and to servers. routingTemplate and localRoutingTemplate send the
+// routing parameters of requests to clients and to servers respectively.
+const (
+	modifyRequestTemplate = `
+	if err := runtime.ModifyRequest(ctx, {{.RequestRef}}); err != nil {
+		return nil, metadata, err
+	}`
+
+	validateTemplate = `
+	if err := runtime.Validate(ctx, {{.RequestRef}}); err != nil {
+		return nil, metadata, err
+	}`
+
+	routingTemplate = `
+	ctx = runtime.AppendRoutingHeader(ctx, {{.RequestRef}}, routing_{{.Method.Service.GetName}}_{{.Method.GetName}})`
+
+	localRoutingTemplate = `
+	ctx = runtime.AppendIncomingRoutingHeader(ctx, &protoReq, routing_{{.Method.Service.GetName}}_{{.Method.GetName}})`
+
+	beforeHookTemplate = `
+	if h, ok := runtime.Hooks(ctx, "{{.Method.Service.File.GetPackage}}.{{.Method.Service.GetName}}").({{.Method.Service.GetName}}_{{.Method.GetName}}BeforeHook); ok {
+		if err := h.Before{{.Method.GetName}}(ctx, {{.RequestRef}}); err != nil {
+			return nil, metadata, err
+		}
+	}`
+
+	afterHookTemplate = `
+	if h, ok := runtime.Hooks(ctx, "{{.Method.Service.File.GetPackage}}.{{.Method.Service.GetName}}").({{.Method.Service.GetName}}_{{.Method.GetName}}AfterHook); ok && err == nil {
+		err = h.After{{.Method.GetName}}(ctx, {{.RequestRef}}, msg)
+	}`
+
+	auditTemplate = `
+	runtime.Audit(ctx, {{.RequestRef}}, msg, err)`
+
+	recordTemplate = `
+	runtime.Record(ctx, {{.RequestRef}}, msg, metadata, err)`
+)
+
 var (
 	headerTemplate = template.Must(template.New("header").Parse(`
-// Code generated by protoc-gen-grpc-gateway. DO NOT EDIT.
+{{if .BuildConstraint}}//go:build {{.BuildConstraint.GoBuild}}
+// +build {{.BuildConstraint.PlusBuild}}
+
+{{end}}// Code generated by protoc-gen-grpc-gateway. DO NOT EDIT.
 // source: {{.GetName}}
+{{- with .GenerationHeader}}
+// protoc-gen-grpc-gateway version: {{.Version}}
+// parameters: {{.Parameters}}
+// source sha256: {{.SourceDigest}}
+{{- end}}
 
 {{if not .OmitPackageDoc}}/*
 Package {{.GoPkg.Name}} is a reverse proxy.
@@ -259,6 +473,7 @@ func request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx cont
 	_ = template.Must(handlerTemplate.New("client-streaming-request-func").Parse(`
 {{template "request-func-signature" .}} {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.{{.Method.GetName}}(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -311,16 +526,26 @@ var (
 	filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}} = {{.QueryParamFilter}}
 )
 {{end}}
+{{if .PoolRequest}}
+var (
+	pool_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}} = runtime.NewMessagePool(func() proto.Message { return new({{.Method.RequestType.GoType .Method.Service.File.GoPkg.Path}}) })
+)
+{{end}}
 {{template "request-func-signature" .}} {
+{{- if .PoolRequest}}
+	protoReq := pool_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}.Get().(*{{.Method.RequestType.GoType .Method.Service.File.GoPkg.Path}})
+	defer pool_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}.Put(protoReq)
+{{- else}}
 	var protoReq {{.Method.RequestType.GoType .Method.Service.File.GoPkg.Path}}
+{{- end}}
 	var metadata runtime.ServerMetadata
 {{if .Body}}
 	newReader, berr := utilities.IOReaderFactory(req.Body)
 	if berr != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", berr)
 	}
-	if err := marshaler.NewDecoder(newReader()).Decode(&{{.Body.AssignableExpr "protoReq"}}); err != nil && err != io.EOF  {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+	if err := marshaler.NewDecoder(newReader()).Decode({{if and .PoolRequest (eq "*" .GetBodyFieldPath)}}protoReq{{else}}&{{.Body.AssignableExpr "protoReq"}}{{end}}); err != nil && err != io.EOF  {
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{if eq "*" $.GetBodyFieldPath}}""{{else}}{{$.GetBodyFieldPath | printf "%q"}}{{end}}, err){{else}}status.Errorf(codes.InvalidArgument, "%v", err){{end}}
 	}
 	{{- if and $AllowPatchFeature (eq (.HTTPMethod) "PATCH") (.FieldMaskField) (not (eq "*" .GetBodyFieldPath)) }}
 	if protoReq.{{.FieldMaskField}} == nil || len(protoReq.{{.FieldMaskField}}.GetPaths()) == 0 {
@@ -353,25 +578,25 @@ var (
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "missing parameter %s", {{$param | printf "%q"}})
 	}
 {{if $param.IsNestedProto3}}
-	err = runtime.PopulateFieldFromPath(&protoReq, {{$param | printf "%q"}}, val)
+	err = runtime.PopulateFieldFromPath({{$binding.RequestRef}}, {{$param | printf "%q"}}, val)
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 	{{if $enum}}
 		e{{if $param.IsRepeated}}s{{end}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}}, {{$enum.GoType $param.Method.Service.File.GoPkg.Path}}_value)
 		if err != nil {
-			return nil, metadata, status.Errorf(codes.InvalidArgument, "could not parse path as enum value, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+			return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "could not parse path as enum value, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 		}
 	{{end}}
 {{else if $enum}}
 	e{{if $param.IsRepeated}}s{{end}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}}, {{$enum.GoType $param.Method.Service.File.GoPkg.Path}}_value)
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 {{else}}
 	{{$param.AssignableExpr "protoReq"}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}})
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 {{end}}
 {{if and $enum $param.IsRepeated}}
@@ -389,11 +614,12 @@ var (
 	if err := req.ParseForm(); err != nil {
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
-	if err := runtime.PopulateQueryParameters(&protoReq, req.Form, filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}); err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+	if err := runtime.PopulateQueryParameters({{.RequestRef}}, req.Form, filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}); err != nil {
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError("", err){{else}}status.Errorf(codes.InvalidArgument, "%v", err){{end}}
 	}
-{{end}}
+{{end}}{{template "modify-request" .}}{{if .Validate}}{{template "validate" .}}{{end}}{{if .Method.RoutingParameters}}{{template "routing" .}}{{end}}
 {{if .Method.GetServerStreaming}}
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.{{.Method.GetName}}(ctx, &protoReq)
 	if err != nil {
 		return nil, metadata, err
@@ -405,14 +631,31 @@ var (
 	metadata.HeaderMD = header
 	return stream, metadata, nil
 {{else}}
-	msg, err := client.{{.Method.GetName}}(ctx, &protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
+{{- if .Hooks}}{{template "before-hook" .}}{{end}}
+	runtime.StartUpstreamTiming(ctx)
+	msg, err := client.{{.Method.GetName}}(ctx, {{.RequestRef}}, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
+{{- if .Hooks}}{{template "after-hook" .}}{{end}}
+{{- if .AuditLog}}{{template "audit" .}}{{end}}
+{{- if .Record}}{{template "record" .}}{{end}}
 	return msg, metadata, err
 {{end}}
 }`))
 
+	_ = template.Must(handlerTemplate.New("modify-request").Parse(modifyRequestTemplate))
+	_ = template.Must(handlerTemplate.New("validate").Parse(validateTemplate))
+	_ = template.Must(handlerTemplate.New("routing").Parse(routingTemplate))
+
+	_ = template.Must(handlerTemplate.New("before-hook").Parse(beforeHookTemplate))
+
+	_ = template.Must(handlerTemplate.New("after-hook").Parse(afterHookTemplate))
+
+	_ = template.Must(handlerTemplate.New("audit").Parse(auditTemplate))
+	_ = template.Must(handlerTemplate.New("record").Parse(recordTemplate))
+
 	_ = template.Must(handlerTemplate.New("bidi-streaming-request-func").Parse(`
 {{template "request-func-signature" .}} {
 	var metadata runtime.ServerMetadata
+	runtime.StartUpstreamTiming(ctx)
 	stream, err := client.{{.Method.GetName}}(ctx)
 	if err != nil {
 		grpclog.Infof("Failed to start streaming: %v", err)
@@ -467,14 +710,26 @@ var (
 	localHandlerTemplate = template.Must(template.New("local-handler").Parse(`
 {{if and .Method.GetClientStreaming .Method.GetServerStreaming}}
 {{else if .Method.GetClientStreaming}}
-{{else if .Method.GetServerStreaming}}
+{{else if .Method.GetServerStreaming}}{{if .LocalServerStreaming}}{{template "local-client-rpc-request-func" .}}{{end}}
 {{else}}
 {{template "local-client-rpc-request-func" .}}
 {{end}}
 `))
 
+	_ = template.Must(localHandlerTemplate.New("modify-request").Parse(modifyRequestTemplate))
+	_ = template.Must(localHandlerTemplate.New("validate").Parse(validateTemplate))
+	_ = template.Must(localHandlerTemplate.New("routing").Parse(localRoutingTemplate))
+
+	_ = template.Must(localHandlerTemplate.New("before-hook").Parse(beforeHookTemplate))
+
+	_ = template.Must(localHandlerTemplate.New("after-hook").Parse(afterHookTemplate))
+
+	_ = template.Must(localHandlerTemplate.New("audit").Parse(auditTemplate))
+	_ = template.Must(localHandlerTemplate.New("record").Parse(recordTemplate))
+
 	_ = template.Must(localHandlerTemplate.New("local-request-func-signature").Parse(strings.Replace(`
 {{if .Method.GetServerStreaming}}
+func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx context.Context, marshaler runtime.Marshaler, server {{.Method.Service.InstanceName}}Server, req *http.Request, pathParams map[string]string) (*runtime.LocalServerStream, runtime.ServerMetadata, error)
 {{else}}
 func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ctx context.Context, marshaler runtime.Marshaler, server {{.Method.Service.InstanceName}}Server, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error)
 {{end}}`, "\n", "", -1)))
@@ -490,7 +745,7 @@ func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ct
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", berr)
 	}
 	if err := marshaler.NewDecoder(newReader()).Decode(&{{.Body.AssignableExpr "protoReq"}}); err != nil && err != io.EOF  {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{if eq "*" $.GetBodyFieldPath}}""{{else}}{{$.GetBodyFieldPath | printf "%q"}}{{end}}, err){{else}}status.Errorf(codes.InvalidArgument, "%v", err){{end}}
 	}
 	{{- if and $AllowPatchFeature (eq (.HTTPMethod) "PATCH") (.FieldMaskField) (not (eq "*" .GetBodyFieldPath)) }}
 	if protoReq.{{.FieldMaskField}} == nil || len(protoReq.{{.FieldMaskField}}.GetPaths()) == 0 {
@@ -525,23 +780,23 @@ func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ct
 {{if $param.IsNestedProto3}}
 	err = runtime.PopulateFieldFromPath(&protoReq, {{$param | printf "%q"}}, val)
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 	{{if $enum}}
 		e{{if $param.IsRepeated}}s{{end}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}}, {{$enum.GoType $param.Method.Service.File.GoPkg.Path}}_value)
 		if err != nil {
-			return nil, metadata, status.Errorf(codes.InvalidArgument, "could not parse path as enum value, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+			return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "could not parse path as enum value, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 		}
 	{{end}}
 {{else if $enum}}
 	e{{if $param.IsRepeated}}s{{end}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}}, {{$enum.GoType $param.Method.Service.File.GoPkg.Path}}_value)
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 {{else}}
 	{{$param.AssignableExpr "protoReq"}}, err = {{$param.ConvertFuncExpr}}(val{{if $param.IsRepeated}}, {{$binding.Registry.GetRepeatedPathParamSeparator | printf "%c" | printf "%q"}}{{end}})
 	if err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError({{$param | printf "%q"}}, err){{else}}status.Errorf(codes.InvalidArgument, "type mismatch, parameter: %s, error: %v", {{$param | printf "%q"}}, err){{end}}
 	}
 {{end}}
 
@@ -561,13 +816,36 @@ func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ct
 		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 	if err := runtime.PopulateQueryParameters(&protoReq, req.Form, filter_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}); err != nil {
-		return nil, metadata, status.Errorf(codes.InvalidArgument, "%v", err)
+		return nil, metadata, {{if $.FieldViolations}}runtime.FieldViolationError("", err){{else}}status.Errorf(codes.InvalidArgument, "%v", err){{end}}
 	}
-{{end}}
+{{end}}{{template "modify-request" .}}{{if .Validate}}{{template "validate" .}}{{end}}{{if .Method.RoutingParameters}}{{template "routing" .}}{{end}}
 {{if .Method.GetServerStreaming}}
-	// TODO
+	runtime.StartUpstreamTiming(ctx)
+	stream := runtime.NewLocalServerStream(ctx)
+	header, err := stream.Start(func() error {
+		return server.{{.Method.GetName}}(&protoReq, local_stream_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}{stream})
+	})
+	metadata.HeaderMD = header
+	if err != nil {
+		return nil, metadata, err
+	}
+	return stream, metadata, nil
+}
+
+// local_stream_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}} is the {{.Method.Service.InstanceName}}_{{.Method.GetName}}Server of the in process calls.
+type local_stream_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}} struct {
+	*runtime.LocalServerStream
+}
+
+func (s local_stream_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}) Send(m *{{.Method.ResponseType.GoType .Method.Service.File.GoPkg.Path}}) error {
+	return s.SendMsg(m)
 {{else}}
+{{- if .Hooks}}{{template "before-hook" .}}{{end}}
+	runtime.StartUpstreamTiming(ctx)
 	msg, err := server.{{.Method.GetName}}(ctx, &protoReq)
+{{- if .Hooks}}{{template "after-hook" .}}{{end}}
+{{- if .AuditLog}}{{template "audit" .}}{{end}}
+{{- if .Record}}{{template "record" .}}{{end}}
 	return msg, metadata, err
 {{end}}
 }`))
@@ -575,21 +853,42 @@ func local_request_{{.Method.Service.GetName}}_{{.Method.GetName}}_{{.Index}}(ct
 	localTrailerTemplate = template.Must(template.New("local-trailer").Parse(`
 {{$UseRequestContext := .UseRequestContext}}
 {{range $svc := .Services}}
-// Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server registers the http handlers for service {{$svc.GetName}} to "mux".
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server registers the http handlers for service {{$svc.GetName}} to "mux".
 // UnaryRPC     :call {{$svc.GetName}}Server directly.
+{{if $.LocalServerStreaming -}}
+// StreamingRPC :call {{$svc.GetName}}Server directly for server streaming, client streaming is currently unsupported pending https://github.com/grpc/grpc-go/issues/906.
+{{- else -}}
 // StreamingRPC :currently unsupported pending https://github.com/grpc/grpc-go/issues/906.
-// Note that using this registration option will cause many gRPC library features to stop working. Consider using Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint instead.
-func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server(ctx context.Context, mux *runtime.ServeMux, server {{$svc.InstanceName}}Server) error {
+{{- end}}
+// Note that using this registration option will cause many gRPC library features to stop working. Consider using {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint instead.
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server(ctx context.Context, mux *runtime.ServeMux, server {{$svc.InstanceName}}Server) error {
 	{{range $m := $svc.Methods}}
 	{{range $b := $m.Bindings}}
-	{{if or $m.GetClientStreaming $m.GetServerStreaming}}
+	{{if or $m.GetClientStreaming (and $m.GetServerStreaming (not $.LocalServerStreaming))}}
 	mux.Handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		err := status.Error(codes.Unimplemented, "streaming calls are not yet supported in the in-process transport")
 		_, outboundMarshaler := runtime.MarshalerForRequest(mux, req)
 		runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
 		return
 	})
-	{{else}}
+	{{else}}{{if and $.GenericForwarders (not $m.GetServerStreaming)}}
+	mux.Handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, runtime.ServerHandler(ctx, mux, {{$UseRequestContext}}, "/{{$svc.File.GetPackage}}.{{$svc.GetName}}/{{$m.GetName}}", func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
+		{{- with $m.Authorization}}
+		if err := runtime.Authorize(ctx, mux, req, runtime.AuthorizationRequirements{ {{- if .Roles}}Roles: {{printf "%#v" .Roles}}, {{end}}{{if .Scopes}}Scopes: {{printf "%#v" .Scopes}}, {{end}}{{if .AllowUnauthenticated}}AllowUnauthenticated: true{{end -}} }); err != nil {
+			return nil, runtime.ServerMetadata{}, err
+		}
+		{{- end}}
+		{{- if $b.ResponseBody}}
+		resp, md, err := local_request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, marshaler, server, req, pathParams)
+		if err != nil {
+			return nil, md, err
+		}
+		return response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}{resp}, md, nil
+		{{- else}}
+		return local_request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, marshaler, server, req, pathParams)
+		{{- end}}
+	}, forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}))
+	{{- else}}
 	mux.Handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 	{{- if $UseRequestContext }}
 		ctx, cancel := context.WithCancel(req.Context())
@@ -605,6 +904,12 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server(ctx context.Context,
 			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
 			return
 		}
+		{{- with $m.Authorization}}
+		if err := runtime.Authorize(rctx, mux, req, runtime.AuthorizationRequirements{ {{- if .Roles}}Roles: {{printf "%#v" .Roles}}, {{end}}{{if .Scopes}}Scopes: {{printf "%#v" .Scopes}}, {{end}}{{if .AllowUnauthenticated}}AllowUnauthenticated: true{{end -}} }); err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		{{- end}}
 		resp, md, err := local_request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(rctx, inboundMarshaler, server, req, pathParams)
 		md.HeaderMD, md.TrailerMD = metadata.Join(md.HeaderMD, stream.Header()), metadata.Join(md.TrailerMD, stream.Trailer())
 		ctx = runtime.NewServerMetadataContext(ctx, md)
@@ -613,12 +918,21 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server(ctx context.Context,
 			return
 		}
 
-		{{ if $b.ResponseBody }}
+		{{if $m.GetServerStreaming}}
+		{{- if $b.ResponseBody}}
+		forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, mux, outboundMarshaler, w, req, func() (proto.Message, error) {
+			res, err := resp.Recv()
+			return response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}{res}, err
+		}, mux.GetForwardResponseOptions()...)
+		{{- else}}
+		forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, mux, outboundMarshaler, w, req, resp.Recv, mux.GetForwardResponseOptions()...)
+		{{- end}}
+		{{else}}{{ if $b.ResponseBody }}
 		forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, mux, outboundMarshaler, w, req, response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}{resp}, mux.GetForwardResponseOptions()...)
 		{{ else }}
 		forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
-		{{end}}
-	})
+		{{end}}{{end}}
+	}){{end}}
 	{{end}}
 	{{end}}
 	{{end}}
@@ -629,9 +943,9 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Server(ctx context.Context,
 	trailerTemplate = template.Must(template.New("trailer").Parse(`
 {{$UseRequestContext := .UseRequestContext}}
 {{range $svc := .Services}}
-// Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint is same as Register{{$svc.GetName}}{{$.RegisterFuncSuffix}} but
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint is same as {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}} but
 // automatically dials to "endpoint" and closes the connection when "ctx" gets done.
-func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) (err error) {
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) (err error) {
 	conn, err := grpc.Dial(endpoint, opts...)
 	if err != nil {
 		return err
@@ -651,23 +965,51 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}FromEndpoint(ctx context.Co
 		}()
 	}()
 
-	return Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}(ctx, mux, conn)
+	return {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}(ctx, mux, conn)
 }
 
-// Register{{$svc.GetName}}{{$.RegisterFuncSuffix}} registers the http handlers for service {{$svc.GetName}} to "mux".
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}} registers the http handlers for service {{$svc.GetName}} to "mux".
 // The handlers forward requests to the grpc endpoint over "conn".
-func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
-	return Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx, mux, {{$svc.ClientConstructorName}}(conn))
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	return {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx, mux, {{$svc.ClientConstructorName}}(conn))
 }
 
-// Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client registers the http handlers for service {{$svc.GetName}}
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client registers the http handlers for service {{$svc.GetName}}
 // to "mux". The handlers forward requests to the grpc endpoint over the given implementation of "{{$svc.InstanceName}}Client".
 // Note: the gRPC framework executes interceptors within the gRPC handler. If the passed in "{{$svc.InstanceName}}Client"
 // doesn't go through the normal gRPC flow (creating a gRPC client etc.) then it will be up to the passed in
 // "{{$svc.InstanceName}}Client" to call the correct interceptors.
-func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx context.Context, mux *runtime.ServeMux, client {{$svc.InstanceName}}Client) error {
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx context.Context, mux *runtime.ServeMux, client {{$svc.InstanceName}}Client) error {
 	{{range $m := $svc.Methods}}
-	{{range $b := $m.Bindings}}
+	{{range $b := $m.Bindings}}{{if $.GenericForwarders}}
+	mux.Handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, runtime.ClientHandler(ctx, mux, {{$UseRequestContext}}, "/{{$svc.File.GetPackage}}.{{$svc.GetName}}/{{$m.GetName}}", func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) ({{if $m.GetServerStreaming}}func() (proto.Message, error){{else}}proto.Message{{end}}, runtime.ServerMetadata, error) {
+		{{- with $m.Authorization}}
+		if err := runtime.Authorize(ctx, mux, req, runtime.AuthorizationRequirements{ {{- if .Roles}}Roles: {{printf "%#v" .Roles}}, {{end}}{{if .Scopes}}Scopes: {{printf "%#v" .Scopes}}, {{end}}{{if .AllowUnauthenticated}}AllowUnauthenticated: true{{end -}} }); err != nil {
+			return nil, runtime.ServerMetadata{}, err
+		}
+		{{- end}}
+		{{- if and (not $m.GetServerStreaming) (not $b.ResponseBody)}}
+		return request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, marshaler, client, req, pathParams)
+		{{- else}}
+		resp, md, err := request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, marshaler, client, req, pathParams)
+		if err != nil {
+			return nil, md, err
+		}
+		{{- if $m.GetServerStreaming}}
+		return func() (proto.Message, error) {
+			{{- if $b.ResponseBody}}
+			res, err := resp.Recv()
+			return response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}{res}, err
+			{{- else}}
+			return resp.Recv()
+			{{- end}}
+		}, md, nil
+		{{- else}}
+		return response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}{resp}, md, nil
+		{{- end}}
+		{{- end}}
+	}, forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}))
+	{{- else}}
 	mux.Handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 	{{- if $UseRequestContext }}
 		ctx, cancel := context.WithCancel(req.Context())
@@ -681,6 +1023,12 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx context.Context,
 			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
 			return
 		}
+		{{- with $m.Authorization}}
+		if err := runtime.Authorize(rctx, mux, req, runtime.AuthorizationRequirements{ {{- if .Roles}}Roles: {{printf "%#v" .Roles}}, {{end}}{{if .Scopes}}Scopes: {{printf "%#v" .Scopes}}, {{end}}{{if .AllowUnauthenticated}}AllowUnauthenticated: true{{end -}} }); err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		{{- end}}
 		resp, md, err := request_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(rctx, inboundMarshaler, client, req, pathParams)
 		ctx = runtime.NewServerMetadataContext(ctx, md)
 		if err != nil {
@@ -703,7 +1051,7 @@ func Register{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx context.Context,
 		forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}(ctx, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
 		{{end}}
 		{{end}}
-	})
+	}){{end}}
 	{{end}}
 	{{end}}
 	return nil
@@ -718,7 +1066,7 @@ type response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} struct {
 
 func (m response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}) XXX_ResponseBody() interface{} {
 	response := m.Message.(*{{$m.ResponseType.GoType $m.Service.File.GoPkg.Path}})
-	return {{$b.ResponseBody.AssignableExpr "response"}}
+	return {{$b.ResponseBody.FieldPath.ValueExpr "response"}}
 }
 {{end}}
 {{end}}
@@ -727,17 +1075,287 @@ func (m response_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}) XXX_ResponseBody(
 var (
 	{{range $m := $svc.Methods}}
 	{{range $b := $m.Bindings}}
+	{{with $b.OpenAPIOptions}}
+	{{if .Exclude}}
+	// pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} is excluded from the OpenAPI documentation.
+	{{else if .Override.IsSet}}
+	// pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} has its OpenAPI documentation overridden.
+	{{end}}
+	{{range .Headers}}
+	// pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} expects the {{if .Required}}required{{else}}optional{{end}} HTTP header {{.Name | printf "%q"}}.
+	{{end}}
+	{{range .ResponseHeaders}}
+	// pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} sends the HTTP header {{.Name | printf "%q"}} with {{.Status}} responses.
+	{{end}}
+	{{end}}
 	pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} = runtime.MustPattern(runtime.NewPattern({{$b.PathTmpl.Version}}, {{$b.PathTmpl.OpCodes | printf "%#v"}}, {{$b.PathTmpl.Pool | printf "%#v"}}, {{$b.PathTmpl.Verb | printf "%q"}}))
 	{{end}}
 	{{end}}
 )
+{{range $m := $svc.Methods}}
+{{if and $m.Bindings $m.RoutingParameters}}
+// routing_{{$svc.GetName}}_{{$m.GetName}} extracts the routing parameters of {{$svc.GetName}}.{{$m.GetName}}
+// requests into the x-goog-request-params header.
+var routing_{{$svc.GetName}}_{{$m.GetName}} = []runtime.RoutingParameter{
+	{{- range $r := $m.RoutingParameters}}
+	{Field: {{$r.FieldPath.String | printf "%q"}}, Key: {{$r.Key | printf "%q"}}, Pattern: runtime.MustPattern(runtime.NewPattern({{$r.PathTmpl.Version}}, {{$r.PathTmpl.OpCodes | printf "%#v"}}, {{$r.PathTmpl.Pool | printf "%#v"}}, {{$r.PathTmpl.Verb | printf "%q"}}))},
+	{{- end}}
+}
+{{end}}
+{{end}}
+{{if $.PathHelpers}}
+{{range $m := $svc.Methods}}
+{{range $b := $m.Bindings}}
+// {{$svc.GetName}}_{{$m.GetName}}{{if $b.Index}}_{{$b.Index}}{{end}}Path returns the path of {{$b.HTTPMethod}} {{$b.PathTmpl.Template}} with the given path parameters.
+func {{$svc.GetName}}_{{$m.GetName}}{{if $b.Index}}_{{$b.Index}}{{end}}Path({{range $i, $p := $b.PathParams}}{{if $i}}, {{end}}{{$p.ArgName}}{{end}}{{if $b.PathParams}} string{{end}}) string {
+	return pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}.Build({{if $b.PathParams}}map[string]string{
+		{{- range $p := $b.PathParams}}
+		{{$p | printf "%q"}}: {{$p.ArgName}},
+		{{- end}}
+	}{{else}}nil{{end}})
+}
+{{end}}
+{{end}}
+{{end}}
 
 var (
 	{{range $m := $svc.Methods}}
 	{{range $b := $m.Bindings}}
+	{{- if $m.StreamEnvelope}}
+	forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} = func(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request, recv func() (proto.Message, error), opts ...func(context.Context, http.ResponseWriter, proto.Message) error) {
+		{{- with $m.StreamEnvelope}}
+		ctx = runtime.NewStreamEnvelopeContext(ctx, runtime.StreamEnvelope{ {{- if .Bare}}Bare: true, {{end}}{{if .ResultKey}}ResultKey: {{printf "%q" .ResultKey}}, {{end}}{{if .ErrorKey}}ErrorKey: {{printf "%q" .ErrorKey}}, {{end}}{{if .MetadataKey}}MetadataKey: {{printf "%q" .MetadataKey}}{{end -}} })
+		{{- end}}
+		runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv, opts...)
+	}
+	{{- else if $m.CacheControl}}
+	forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} = func(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request, resp proto.Message, opts ...func(context.Context, http.ResponseWriter, proto.Message) error) {
+		w.Header().Set("Cache-Control", {{printf "%q" $m.CacheControl}})
+		runtime.ForwardResponseMessage(ctx, mux, marshaler, w, req, resp, opts...)
+	}
+	{{- else}}
 	forward_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}} = {{if $m.GetServerStreaming}}runtime.ForwardResponseStream{{else}}runtime.ForwardResponseMessage{{end}}
+	{{- end}}
 	{{end}}
 	{{end}}
 )
-{{end}}`))
+{{if $.Hooks}}
+{{range $m := $svc.Methods}}
+{{if and $m.Bindings (not $m.GetClientStreaming) (not $m.GetServerStreaming)}}
+// {{$svc.GetName}}_{{$m.GetName}}BeforeHook is implemented by hooks called with the decoded request of
+// {{$svc.GetName}}.{{$m.GetName}} before it is forwarded. The request may be modified; returning an error
+// fails the call with the error.
+type {{$svc.GetName}}_{{$m.GetName}}BeforeHook interface {
+	Before{{$m.GetName}}(ctx context.Context, req *{{$m.RequestType.GoType $m.Service.File.GoPkg.Path}}) error
+}
+
+// {{$svc.GetName}}_{{$m.GetName}}AfterHook is implemented by hooks called with the response of
+// {{$svc.GetName}}.{{$m.GetName}} before it is marshaled. The response may be modified; returning an
+// error fails the call with the error.
+type {{$svc.GetName}}_{{$m.GetName}}AfterHook interface {
+	After{{$m.GetName}}(ctx context.Context, req *{{$m.RequestType.GoType $m.Service.File.GoPkg.Path}}, resp *{{$m.ResponseType.GoType $m.Service.File.GoPkg.Path}}) error
+}
+{{end}}
+{{end}}
+
+// With{{$svc.GetName}}Hooks returns a ServeMuxOption calling "hooks" around the unary methods of service
+// {{$svc.GetName}} whose {{$svc.GetName}}_<Method>BeforeHook or {{$svc.GetName}}_<Method>AfterHook
+// interfaces it implements.
+func With{{$svc.GetName}}Hooks(hooks interface{}) runtime.ServeMuxOption {
+	return runtime.WithHooks("{{$svc.File.GetPackage}}.{{$svc.GetName}}", hooks)
+}
+{{end}}
+{{if $.HTTPClient}}
+// {{$svc.GetName}}HTTPClient calls the methods of service {{$svc.GetName}} through the
+// REST endpoints of a gateway, building requests the way the handlers above parse them.
+// Streaming methods are not supported.
+type {{$svc.GetName}}HTTPClient struct {
+	client *runtime.Client
+}
+
+// New{{$svc.GetName}}HTTPClient returns a {{$svc.GetName}}HTTPClient calling the gateway at "baseURL".
+func New{{$svc.GetName}}HTTPClient(baseURL string, opts ...runtime.ClientOption) *{{$svc.GetName}}HTTPClient {
+	return &{{$svc.GetName}}HTTPClient{client: runtime.NewClient(baseURL, opts...)}
+}
+{{range $m := $svc.Methods}}
+{{if and $m.Bindings (not $m.GetClientStreaming) (not $m.GetServerStreaming)}}
+{{$b := index $m.Bindings 0}}
+// {{$m.GetName}} calls {{$b.HTTPMethod}} {{$b.PathTmpl.Template}}.
+func (c *{{$svc.GetName}}HTTPClient) {{$m.GetName}}(ctx context.Context, in *{{$m.RequestType.GoType $m.Service.File.GoPkg.Path}}) (*{{$m.ResponseType.GoType $m.Service.File.GoPkg.Path}}, error) {
+	out := new({{$m.ResponseType.GoType $m.Service.File.GoPkg.Path}})
+	call := runtime.ClientCall{
+		HTTPMethod: {{$b.HTTPMethod | printf "%q"}},
+		Pattern:    pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}},
+		{{- if $b.Body}}
+		Body: {{if $b.Body.FieldPath}}{{$b.Body.FieldPath.String | printf "%q"}}{{else}}"*"{{end}},
+		{{- end}}
+		{{- if $b.ResponseBody}}
+		ResponseBody: {{$b.ResponseBody.FieldPath.String | printf "%q"}},
+		{{- end}}
+		{{- if ne $.PathParamSeparator ","}}
+		PathParamSeparator: {{$.PathParamSeparator | printf "%q"}},
+		{{- end}}
+	}
+	if err := c.client.Invoke(ctx, call, in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+{{end}}
+{{end}}
+{{end}}
+{{end}}
+{{- if $.StdlibPatterns}}
+{{- range $svc := .Services}}
+
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Stdlib registers the http handlers for service {{$svc.GetName}}
+// to the net/http "mux" with Go 1.22 patterns. The handlers are configured by "rmux", e.g. with its marshalers
+// and error handler, or with the defaults if "rmux" is nil, and forward requests to the grpc endpoint over
+// the given implementation of "{{$svc.InstanceName}}Client".
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Stdlib(ctx context.Context, mux *http.ServeMux, rmux *runtime.ServeMux, client {{$svc.InstanceName}}Client) error {
+	if rmux == nil {
+		rmux = runtime.NewServeMux()
+	}
+	if err := {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx, rmux, client); err != nil {
+		return err
+	}
+	{{- range $m := $svc.Methods}}
+	{{- range $b := $m.Bindings}}
+	{{- with index $.StdlibPatterns $b}}
+	if err := rmux.HandleStdlib(mux, {{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, {{.Pattern | printf "%q"}}, {{template "router-wildcards" .}}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- end}}
+	return nil
+}
+{{- end}}
+{{- end}}
+{{- if $.ChiPatterns}}
+{{- range $svc := .Services}}
+
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Chi registers the http handlers for service {{$svc.GetName}}
+// to the chi "router". The handlers are configured by "rmux", e.g. with its marshalers and error handler,
+// or with the defaults if "rmux" is nil, and forward requests to the grpc endpoint over the given
+// implementation of "{{$svc.InstanceName}}Client".
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Chi(ctx context.Context, router chi.Router, rmux *runtime.ServeMux, client {{$svc.InstanceName}}Client) error {
+	if rmux == nil {
+		rmux = runtime.NewServeMux()
+	}
+	if err := {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx, rmux, client); err != nil {
+		return err
+	}
+	handle := func(meth string, pat runtime.Pattern, wildcards map[string]string, pattern string) error {
+		h, err := rmux.PathValueHandler(meth, pat, wildcards, chi.URLParam)
+		if err != nil {
+			return err
+		}
+		router.Method(meth, pattern, h)
+		return nil
+	}
+	{{- range $m := $svc.Methods}}
+	{{- range $b := $m.Bindings}}
+	{{- with index $.ChiPatterns $b}}
+	if err := handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, {{template "router-wildcards" .}}, {{.Pattern | printf "%q"}}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- end}}
+	return nil
+}
+{{- end}}
+{{- end}}
+{{- if $.GorillaPatterns}}
+{{- range $svc := .Services}}
+
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Gorilla registers the http handlers for service {{$svc.GetName}}
+// to the gorilla/mux "router". The handlers are configured by "rmux", e.g. with its marshalers and error handler,
+// or with the defaults if "rmux" is nil, and forward requests to the grpc endpoint over the given
+// implementation of "{{$svc.InstanceName}}Client".
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Gorilla(ctx context.Context, router *gorillamux.Router, rmux *runtime.ServeMux, client {{$svc.InstanceName}}Client) error {
+	if rmux == nil {
+		rmux = runtime.NewServeMux()
+	}
+	if err := {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}Client(ctx, rmux, client); err != nil {
+		return err
+	}
+	pathValue := func(r *http.Request, name string) string {
+		return gorillamux.Vars(r)[name]
+	}
+	handle := func(meth string, pat runtime.Pattern, wildcards map[string]string, pattern string) error {
+		h, err := rmux.PathValueHandler(meth, pat, wildcards, pathValue)
+		if err != nil {
+			return err
+		}
+		router.Handle(pattern, h).Methods(meth)
+		return nil
+	}
+	{{- range $m := $svc.Methods}}
+	{{- range $b := $m.Bindings}}
+	{{- with index $.GorillaPatterns $b}}
+	if err := handle({{$b.HTTPMethod | printf "%q"}}, pattern_{{$svc.GetName}}_{{$m.GetName}}_{{$b.Index}}, {{template "router-wildcards" .}}, {{.Pattern | printf "%q"}}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- end}}
+	return nil
+}
+{{- end}}
+{{- end}}
+{{- if $.GraphQL}}
+{{- range $svc := .Services}}
+{{- with index $.GraphQL $svc}}
+
+// {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}GraphQL registers the unary methods with bindings of service
+// {{$svc.GetName}} as the fields of the GraphQL schema of "h", the methods bound to GET as queries and the others
+// as mutations. The fields call the methods over the given implementation of "{{$svc.InstanceName}}Client".
+func {{$.RegisterFuncPrefix}}{{$svc.GetName}}{{$.RegisterFuncSuffix}}GraphQL(h *runtime.GraphQLHandler, client {{$svc.InstanceName}}Client) error {
+	{{- range .Types}}
+	if err := h.DefineType({{.Name | printf "%q"}}, {{.Definition | printf "%q"}}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- range $f := .Fields}}
+	if err := h.Handle(runtime.GraphQLField{
+		{{- if $f.Mutation}}
+		Mutation:   true,
+		{{- end}}
+		Name:       {{$f.Name | printf "%q"}},
+		Definition: {{$f.Definition | printf "%q"}},
+		RPCMethod:  "/{{$svc.File.GetPackage}}.{{$svc.GetName}}/{{$f.Method.GetName}}",
+		Request:    new({{$f.Method.RequestType.GoType $svc.File.GoPkg.Path}}),
+		Response:   new({{$f.Method.ResponseType.GoType $svc.File.GoPkg.Path}}),
+		Call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return client.{{$f.Method.GetName}}(ctx, req.(*{{$f.Method.RequestType.GoType $svc.File.GoPkg.Path}}))
+		},
+	}); err != nil {
+		return err
+	}
+	{{- end}}
+	return nil
+}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- with $.RegisterAll}}
+
+// {{$.RegisterFuncPrefix}}All{{.Name}}{{$.RegisterFuncSuffix}}s registers the http handlers of all the services of
+// {{.Source}} to "mux". The handlers forward requests to the grpc endpoint over "conn".
+func {{$.RegisterFuncPrefix}}All{{.Name}}{{$.RegisterFuncSuffix}}s(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	{{- range $svc := .Services}}
+	if err := {{$.RegisterFuncPrefix}}{{$svc}}{{$.RegisterFuncSuffix}}(ctx, mux, conn); err != nil {
+		return err
+	}
+	{{- end}}
+	return nil
+}
+{{- end}}`))
+
+	_ = template.Must(trailerTemplate.New("router-wildcards").Parse(`
+{{- if .Wildcards}}map[string]string{
+	{{- range $i, $w := .Wildcards}}{{if $i}}, {{end}}{{$w.Name | printf "%q"}}: {{$w.FieldPath | printf "%q"}}{{end -}}
+}{{else}}nil{{end}}`))
 )
@@ -1,11 +1,13 @@
 package gengateway
 
 import (
+	"go/format"
 	"strings"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	gwoptions "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway/options"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
@@ -755,3 +757,820 @@ func TestIdentifierCapitalization(t *testing.T) {
 		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
 	}
 }
+
+func TestApplyTemplatePathHelpers(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("GetRequest"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:   proto.String("user_id"),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Number: proto.Int32(1),
+			},
+		},
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Get"),
+		InputType:  proto.String("GetRequest"),
+		OutputType: proto.String("GetRequest"),
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	userIDField := &descriptor.Field{
+		Message:              msg,
+		FieldDescriptorProto: msgdesc.GetField()[0],
+	}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			Name:        proto.String("example.proto"),
+			Package:     proto.String("example"),
+			MessageType: []*descriptorpb.DescriptorProto{msgdesc},
+			Service:     []*descriptorpb.ServiceDescriptorProto{svc},
+			Syntax:      proto.String("proto3"),
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings: []*descriptor.Binding{
+							{
+								HTTPMethod: "GET",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{2, 0, 2, 1, 1, 0, 4, 1, 5, 2},
+									Pool:     []string{"v1", "users", "user_id"},
+									Template: "/v1/users/{user_id}",
+								},
+								PathParams: []descriptor.Parameter{
+									{
+										FieldPath: descriptor.FieldPath([]descriptor.FieldPathComponent{
+											{
+												Name:   "user_id",
+												Target: userIDField,
+											},
+										}),
+										Target: userIDField,
+									},
+								},
+							},
+							{
+								Index:      1,
+								HTTPMethod: "GET",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{2, 0, 2, 1},
+									Pool:     []string{"v1", "users"},
+									Template: "/v1/users",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	got, err := applyTemplate(param{File: crossLinkFixture(&file), RegisterFuncSuffix: "Handler", PathHelpers: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"// ExampleService_GetPath returns the path of GET /v1/users/{user_id} with the given path parameters.\n",
+		"func ExampleService_GetPath(userId string) string {\n\treturn pattern_ExampleService_Get_0.Build(map[string]string{\n\t\t\"user_id\": userId,\n\t})\n}",
+		"func ExampleService_Get_1Path() string {\n\treturn pattern_ExampleService_Get_1.Build(nil)\n}",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+}
+
+func TestApplyTemplateHTTPClient(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = httprule.Template{
+		Version:  1,
+		OpCodes:  []int{2, 0, 2, 1},
+		Pool:     []string{"v1", "example"},
+		Template: "/v1/example",
+	}
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", HTTPClient: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"type ExampleServiceHTTPClient struct {\n\tclient *runtime.Client\n}",
+		"func NewExampleServiceHTTPClient(baseURL string, opts ...runtime.ClientOption) *ExampleServiceHTTPClient {",
+		"// Example calls GET /v1/example.\n",
+		"func (c *ExampleServiceHTTPClient) Example(ctx context.Context, in *ExampleMessage) (*ExampleMessage, error) {",
+		"\tcall := runtime.ClientCall{\n\t\tHTTPMethod: \"GET\",\n\t\tPattern:    pattern_ExampleService_Example_0,\n\t\tBody:       \"*\",\n\t}\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "ExampleWithoutBindings(ctx"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+}
+
+func TestApplyTemplateHooks(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", Hooks: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"type ExampleService_ExampleBeforeHook interface {\n\tBeforeExample(ctx context.Context, req *ExampleMessage) error\n}",
+		"type ExampleService_ExampleAfterHook interface {\n\tAfterExample(ctx context.Context, req *ExampleMessage, resp *ExampleMessage) error\n}",
+		"func WithExampleServiceHooks(hooks interface{}) runtime.ServeMuxOption {\n\treturn runtime.WithHooks(\"example.ExampleService\", hooks)\n}",
+		"\tif h, ok := runtime.Hooks(ctx, \"example.ExampleService\").(ExampleService_ExampleBeforeHook); ok {\n\t\tif err := h.BeforeExample(ctx, &protoReq); err != nil {\n\t\t\treturn nil, metadata, err\n\t\t}\n\t}\n\truntime.StartUpstreamTiming(ctx)\n\tmsg, err := client.Example(",
+		"\tmsg, err := server.Example(ctx, &protoReq)\n\tif h, ok := runtime.Hooks(ctx, \"example.ExampleService\").(ExampleService_ExampleAfterHook); ok && err == nil {\n\t\terr = h.AfterExample(ctx, &protoReq, msg)\n\t}\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "ExampleWithoutBindingsBeforeHook"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+	if i, j := strings.Index(string(formatted), "runtime.ModifyRequest("), strings.Index(string(formatted), "h.BeforeExample("); i < 0 || j < 0 || i > j {
+		t.Errorf("applyTemplate(%#v) = %s; want runtime.ModifyRequest before the before hook", file, formatted)
+	}
+}
+
+func TestApplyTemplateModifyRequest(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	// Both the request func forwarding to the client and the local one
+	// forwarding to the server pass the request to the request modifiers,
+	// whether the hooks are generated or not.
+	want := "\tif err := runtime.ModifyRequest(ctx, &protoReq); err != nil {\n\t\treturn nil, metadata, err\n\t}\n"
+	if n := strings.Count(string(formatted), want); n != 2 {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s twice, got %d", file, formatted, want, n)
+	}
+}
+
+func TestApplyTemplateValidate(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", Validate: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	// Both the request func forwarding to the client and the local one
+	// forwarding to the server validate the request.
+	want := "\tif err := runtime.Validate(ctx, &protoReq); err != nil {\n\t\treturn nil, metadata, err\n\t}\n"
+	if n := strings.Count(string(formatted), want); n != 2 {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s twice, got %d", file, formatted, want, n)
+	}
+	for _, order := range [][2]string{
+		{"runtime.Validate(ctx, &protoReq)", "client.Example(ctx, &protoReq"},
+		{"runtime.Validate(ctx, &protoReq)", "server.Example(ctx, &protoReq)"},
+	} {
+		if i, j := strings.Index(string(formatted), order[0]), strings.Index(string(formatted), order[1]); i < 0 || j < 0 || i > j {
+			t.Errorf("applyTemplate(%#v) = %s; want %s before %s", file, formatted, order[0], order[1])
+		}
+	}
+}
+
+func TestApplyTemplateServerTiming(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", Validate: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	// Both the request func forwarding to the client and the local one
+	// forwarding to the server start the upstream timing once the request is
+	// decoded and validated, right before the call.
+	for _, want := range []string{
+		"\truntime.StartUpstreamTiming(ctx)\n\tmsg, err := client.Example(ctx, &protoReq",
+		"\truntime.StartUpstreamTiming(ctx)\n\tmsg, err := server.Example(ctx, &protoReq)",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if i, j := strings.Index(string(formatted), "runtime.Validate(ctx, &protoReq)"), strings.Index(string(formatted), "runtime.StartUpstreamTiming(ctx)"); i < 0 || j < 0 || i > j {
+		t.Errorf("applyTemplate(%#v) = %s; want runtime.Validate before runtime.StartUpstreamTiming", file, formatted)
+	}
+}
+
+func TestApplyTemplateRoutingParameters(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	parsed, err := httprule.Parse("/{routing_id=projects/*}/**")
+	if err != nil {
+		t.Fatalf("httprule.Parse() failed with %v; want success", err)
+	}
+	file.Services[0].Methods[0].RoutingParameters = []*descriptor.RoutingParameter{
+		{
+			FieldPath: descriptor.FieldPath{{Name: "name"}},
+			Key:       "routing_id",
+			PathTmpl:  parsed.Compile(),
+		},
+	}
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"var routing_ExampleService_Example = []runtime.RoutingParameter{\n\t{Field: \"name\", Key: \"routing_id\", Pattern: runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 1, 0, 4, 2, 5, 1, 3, 0}, []string{\"projects\", \"routing_id\"}, \"\"))},\n}",
+		"\tctx = runtime.AppendRoutingHeader(ctx, &protoReq, routing_ExampleService_Example)\n",
+		"\tctx = runtime.AppendIncomingRoutingHeader(ctx, &protoReq, routing_ExampleService_Example)\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "routing_ExampleService_ExampleWithoutBindings"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+}
+
+func TestApplyTemplateNestedResponseBody(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	field := func(name string) descriptor.FieldPathComponent {
+		return descriptor.FieldPathComponent{
+			Name:   name,
+			Target: &descriptor.Field{FieldDescriptorProto: &descriptorpb.FieldDescriptorProto{Name: proto.String(name)}},
+		}
+	}
+	file.Services[0].Methods[0].Bindings[0].ResponseBody = &descriptor.Body{
+		FieldPath: descriptor.FieldPath{field("result"), field("items")},
+	}
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	want := "func (m response_ExampleService_Example_0) XXX_ResponseBody() interface{} {\n\tresponse := m.Message.(*ExampleMessage)\n\treturn response.GetResult().GetItems()\n}"
+	if !strings.Contains(string(formatted), want) {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+	}
+}
+
+func TestApplyTemplateUnexportedRegisterFuncs(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", UnexportedRegisterFuncs: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	for _, want := range []string{
+		"func registerExampleServiceHandlerServer(",
+		"func registerExampleServiceHandlerFromEndpoint(",
+		"return registerExampleServiceHandler(ctx, mux, conn)",
+		"return registerExampleServiceHandlerClient(ctx, mux, NewExampleServiceClient(conn))",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, want)
+		}
+	}
+	if notWant := "func RegisterExampleService"; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
+
+func TestApplyTemplateRegisterAll(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	ra := &registerAll{Name: "Example", Source: "example.proto", Services: []string{"ExampleService"}}
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", RegisterAll: ra}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	want := "func RegisterAllExampleHandlers(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {\n\tif err := RegisterExampleServiceHandler(ctx, mux, conn); err != nil {\n\t\treturn err\n\t}\n\treturn nil\n}"
+	if !strings.Contains(string(formatted), want) {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+	}
+}
+
+func TestApplyTemplateStdlibPatterns(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	parsed, err := httprule.Parse("/v1/examples/{example.id}")
+	if err != nil {
+		t.Fatalf("httprule.Parse() failed with %v; want success", err)
+	}
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = parsed.Compile()
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", StdlibPatterns: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"func RegisterExampleServiceHandlerStdlib(ctx context.Context, mux *http.ServeMux, rmux *runtime.ServeMux, client ExampleServiceClient) error {",
+		"\tif err := RegisterExampleServiceHandlerClient(ctx, rmux, client); err != nil {\n",
+		"\tif err := rmux.HandleStdlib(mux, \"GET\", pattern_ExampleService_Example_0, \"GET /v1/examples/{example_id}\", map[string]string{\"example_id\": \"example.id\"}); err != nil {\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+}
+
+func TestApplyTemplateStdlibPatternsUnsupported(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	parsed, err := httprule.Parse("/v1/{name=examples/*}")
+	if err != nil {
+		t.Fatalf("httprule.Parse() failed with %v; want success", err)
+	}
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = parsed.Compile()
+	if _, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", StdlibPatterns: true}, descriptor.NewRegistry()); err == nil {
+		t.Errorf("applyTemplate(%#v) succeeded; want an error", file)
+	}
+}
+
+func TestApplyTemplateRouterAdapters(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	parsed, err := httprule.Parse("/v1/examples/{example.id}")
+	if err != nil {
+		t.Fatalf("httprule.Parse() failed with %v; want success", err)
+	}
+	file.Services[0].Methods[0].Bindings[0].PathTmpl = parsed.Compile()
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", RouterAdapters: []string{"chi", "gorilla"}}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"func RegisterExampleServiceHandlerChi(ctx context.Context, router chi.Router, rmux *runtime.ServeMux, client ExampleServiceClient) error {",
+		"\t\th, err := rmux.PathValueHandler(meth, pat, wildcards, chi.URLParam)\n",
+		"\t\trouter.Method(meth, pattern, h)\n",
+		"func RegisterExampleServiceHandlerGorilla(ctx context.Context, router *gorillamux.Router, rmux *runtime.ServeMux, client ExampleServiceClient) error {",
+		"\t\trouter.Handle(pattern, h).Methods(meth)\n",
+		"\tif err := handle(\"GET\", pattern_ExampleService_Example_0, map[string]string{\"example_id\": \"example.id\"}, \"/v1/examples/{example_id}\"); err != nil {\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "HandlerStdlib"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+}
+
+func TestApplyTemplateGenericForwarders(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GenericForwarders: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"\tmux.Handle(\"GET\", pattern_ExampleService_Example_0, runtime.ClientHandler(ctx, mux, false, \"/example.ExampleService/Example\", func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {\n\t\treturn request_ExampleService_Example_0(ctx, marshaler, client, req, pathParams)\n\t}, forward_ExampleService_Example_0))\n",
+		"\tmux.Handle(\"GET\", pattern_ExampleService_Example_0, runtime.ServerHandler(ctx, mux, false, \"/example.ExampleService/Example\", func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {\n\t\treturn local_request_ExampleService_Example_0(ctx, marshaler, server, req, pathParams)\n\t}, forward_ExampleService_Example_0))\n",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+	if notWant := "inboundMarshaler"; strings.Contains(string(formatted), notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, notWant)
+	}
+}
+
+func TestApplyTemplateGenericForwardersServerStreaming(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	file.Services[0].Methods[0].ServerStreaming = proto.Bool(true)
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GenericForwarders: true, UseRequestContext: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	want := "runtime.ClientHandler(ctx, mux, true, \"/example.ExampleService/Example\", func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (func() (proto.Message, error), runtime.ServerMetadata, error) {\n\t\tresp, md, err := request_ExampleService_Example_0(ctx, marshaler, client, req, pathParams)\n\t\tif err != nil {\n\t\t\treturn nil, md, err\n\t\t}\n\t\treturn func() (proto.Message, error) {\n\t\t\treturn resp.Recv()\n\t\t}, md, nil\n\t}, forward_ExampleService_Example_0))\n"
+	if !strings.Contains(string(formatted), want) {
+		t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+	}
+}
+
+func TestApplyTemplateLocalServerStreaming(t *testing.T) {
+	for _, spec := range []struct {
+		localServerStreaming bool
+		want                 []string
+		notWant              string
+	}{
+		{
+			want: []string{
+				"streaming calls are not yet supported in the in-process transport",
+			},
+			notWant: "func local_request_ExampleService_Example_0(",
+		},
+		{
+			localServerStreaming: true,
+			want: []string{
+				"func local_request_ExampleService_Example_0(ctx context.Context, marshaler runtime.Marshaler, server ExampleServiceServer, req *http.Request, pathParams map[string]string) (*runtime.LocalServerStream, runtime.ServerMetadata, error) {",
+				"type local_stream_ExampleService_Example_0 struct {\n\t*runtime.LocalServerStream\n}",
+				"forward_ExampleService_Example_0(ctx, mux, outboundMarshaler, w, req, resp.Recv, mux.GetForwardResponseOptions()...)",
+			},
+			notWant: "streaming calls are not yet supported in the in-process transport",
+		},
+	} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		file.Services[0].Methods[0].ServerStreaming = proto.Bool(true)
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", LocalServerStreaming: spec.localServerStreaming}, descriptor.NewRegistry())
+		if err != nil {
+			t.Errorf("applyTemplate(%#v) failed with %v; want success", file, err)
+			continue
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Errorf("format.Source(%s) failed with %v; want success", got, err)
+			continue
+		}
+		for _, want := range spec.want {
+			if !strings.Contains(string(formatted), want) {
+				t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+			}
+		}
+		if strings.Contains(string(formatted), spec.notWant) {
+			t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, formatted, spec.notWant)
+		}
+	}
+}
+
+func TestApplyTemplateFieldViolations(t *testing.T) {
+	for _, fieldViolations := range []bool{false, true} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", FieldViolations: fieldViolations}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		// Both the request func forwarding to the client and the local one
+		// forwarding to the server decode the body.
+		want := "\t\treturn nil, metadata, status.Errorf(codes.InvalidArgument, \"%v\", err)\n"
+		if fieldViolations {
+			want = "\t\treturn nil, metadata, runtime.FieldViolationError(\"\", err)\n"
+		}
+		if n := strings.Count(string(formatted), want); n != 2 {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s twice, got %d", file, formatted, want, n)
+		}
+	}
+}
+
+func TestApplyTemplateAuthorization(t *testing.T) {
+	for _, spec := range []struct {
+		genericForwarders bool
+		want              string
+	}{
+		{
+			want: "\t\tif err := runtime.Authorize(rctx, mux, req, runtime.AuthorizationRequirements{Roles: []string{\"admin\", \"editor\"}, Scopes: []string{\"example.write\"}}); err != nil {\n\t\t\truntime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)\n\t\t\treturn\n\t\t}\n",
+		},
+		{
+			genericForwarders: true,
+			want:              "\t\tif err := runtime.Authorize(ctx, mux, req, runtime.AuthorizationRequirements{Roles: []string{\"admin\", \"editor\"}, Scopes: []string{\"example.write\"}}); err != nil {\n\t\t\treturn nil, runtime.ServerMetadata{}, err\n\t\t}\n",
+		},
+	} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		file.Services[0].Methods[0].Authorization = &gwoptions.Authorization{
+			Roles:  []string{"admin", "editor"},
+			Scopes: []string{"example.write"},
+		}
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GenericForwarders: spec.genericForwarders}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		// Both the handler forwarding to the client and the one forwarding to
+		// the server authorize the requests.
+		if n := strings.Count(string(formatted), spec.want); n != 2 {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s twice, got %d", file, formatted, spec.want, n)
+		}
+	}
+
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if notWant := "runtime.Authorize("; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
+
+func TestApplyTemplateAuditLog(t *testing.T) {
+	for _, auditLog := range []bool{false, true} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", AuditLog: auditLog}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		// Both the request func forwarding to the client and the local one
+		// forwarding to the server audit the calls.
+		want := 0
+		if auditLog {
+			want = 2
+		}
+		audit := "\truntime.Audit(ctx, &protoReq, msg, err)\n\treturn msg, metadata, err\n"
+		if n := strings.Count(string(formatted), audit); n != want {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s %d times, got %d", file, formatted, audit, want, n)
+		}
+	}
+}
+
+func TestApplyTemplateRecord(t *testing.T) {
+	for _, record := range []bool{false, true} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", AuditLog: true, Record: record}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		want := 0
+		if record {
+			want = 2
+		}
+		rec := "\truntime.Audit(ctx, &protoReq, msg, err)\n\truntime.Record(ctx, &protoReq, msg, metadata, err)\n\treturn msg, metadata, err\n"
+		if n := strings.Count(string(formatted), rec); n != want {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s %d times, got %d", file, formatted, rec, want, n)
+		}
+	}
+}
+
+func TestApplyTemplateStreamEnvelope(t *testing.T) {
+	for _, genericForwarders := range []bool{false, true} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		file.Services[0].Methods[0].ServerStreaming = proto.Bool(true)
+		file.Services[0].Methods[0].StreamEnvelope = &gwoptions.StreamEnvelope{ResultKey: "data", MetadataKey: "meta"}
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GenericForwarders: genericForwarders}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		want := "\t\tctx = runtime.NewStreamEnvelopeContext(ctx, runtime.StreamEnvelope{ResultKey: \"data\", MetadataKey: \"meta\"})\n\t\truntime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv, opts...)\n"
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+
+	file := crossLinkFixture(newExampleFileDescriptor())
+	file.Services[0].Methods[0].ServerStreaming = proto.Bool(true)
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if notWant := "runtime.NewStreamEnvelopeContext("; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
+
+func TestApplyTemplateCacheControl(t *testing.T) {
+	for _, genericForwarders := range []bool{false, true} {
+		file := crossLinkFixture(newExampleFileDescriptor())
+		file.Services[0].Methods[0].CacheControl = "public, max-age=60"
+		got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", GenericForwarders: genericForwarders}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		formatted, err := format.Source([]byte(got))
+		if err != nil {
+			t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+		}
+		want := "\t\tw.Header().Set(\"Cache-Control\", \"public, max-age=60\")\n\t\truntime.ForwardResponseMessage(ctx, mux, marshaler, w, req, resp, opts...)\n"
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if notWant := "\"Cache-Control\""; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
+
+func TestApplyTemplatePoolRequests(t *testing.T) {
+	file := crossLinkFixture(newExampleFileDescriptor())
+	got, err := applyTemplate(param{File: file, RegisterFuncSuffix: "Handler", PoolRequests: true, Hooks: true}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("format.Source(%s) failed with %v; want success", got, err)
+	}
+	for _, want := range []string{
+		"pool_ExampleService_Example_0 = runtime.NewMessagePool(func() proto.Message {",
+		"\tprotoReq := pool_ExampleService_Example_0.Get().(*",
+		"\tdefer pool_ExampleService_Example_0.Put(protoReq)\n",
+		".Decode(protoReq); err != nil",
+		"h.BeforeExample(ctx, protoReq)",
+		"client.Example(ctx, protoReq, grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))",
+		// The requests of the servers in process are not pooled, as the
+		// servers may retain them.
+		"server.Example(ctx, &protoReq)",
+		"h.BeforeExample(ctx, &protoReq)",
+	} {
+		if !strings.Contains(string(formatted), want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, formatted, want)
+		}
+	}
+
+	got, err = applyTemplate(param{File: file, RegisterFuncSuffix: "Handler"}, descriptor.NewRegistry())
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if notWant := "pool_ExampleService_Example_0"; strings.Contains(got, notWant) {
+		t.Errorf("applyTemplate(%#v) = %s; does not want to contain %s", file, got, notWant)
+	}
+}
+
+func TestApplyTemplatePoolRequestsNestedPathParams(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("nested"),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String("NestedMessage"),
+				Number:   proto.Int32(1),
+			},
+		},
+	}
+	nesteddesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("NestedMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:   proto.String("int32"),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				Number: proto.Int32(1),
+			},
+		},
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("ExampleMessage"),
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	nested := &descriptor.Message{
+		DescriptorProto: nesteddesc,
+	}
+	nestedField := &descriptor.Field{
+		Message:              msg,
+		FieldDescriptorProto: msg.GetField()[0],
+	}
+	intField := &descriptor.Field{
+		Message:              nested,
+		FieldDescriptorProto: nested.GetField()[0],
+	}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			Name:        proto.String("example.proto"),
+			Package:     proto.String("example"),
+			MessageType: []*descriptorpb.DescriptorProto{msgdesc, nesteddesc},
+			Service:     []*descriptorpb.ServiceDescriptorProto{svc},
+			Syntax:      proto.String("proto3"),
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg, nested},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings: []*descriptor.Binding{
+							{
+								HTTPMethod: "GET",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{2, 0, 1, 0, 4, 1, 5, 1},
+									Pool:     []string{"v1", "nested.int32"},
+									Template: "/v1/{nested.int32}",
+								},
+								PathParams: []descriptor.Parameter{
+									{
+										FieldPath: descriptor.FieldPath([]descriptor.FieldPathComponent{
+											{
+												Name:   "nested",
+												Target: nestedField,
+											},
+											{
+												Name:   "int32",
+												Target: intField,
+											},
+										}),
+										Target: intField,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, spec := range []struct {
+		poolRequests bool
+		want         string
+	}{
+		{
+			poolRequests: true,
+			want:         `err = runtime.PopulateFieldFromPath(protoReq, "nested.int32", val)`,
+		},
+		{
+			want: `err = runtime.PopulateFieldFromPath(&protoReq, "nested.int32", val)`,
+		},
+	} {
+		got, err := applyTemplate(param{File: crossLinkFixture(&file), RegisterFuncSuffix: "Handler", PoolRequests: spec.poolRequests}, descriptor.NewRegistry())
+		if err != nil {
+			t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+		}
+		if !strings.Contains(got, spec.want) {
+			t.Errorf("applyTemplate(%#v) = %s; want to contain %s", file, got, spec.want)
+		}
+	}
+}
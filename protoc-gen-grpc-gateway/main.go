@@ -9,15 +9,20 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"text/template"
 
 	"github.com/golang/glog"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/codegenerator"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway/internal/gengateway"
 	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
 )
 
 var (
@@ -37,8 +42,58 @@ var (
 	versionFlag                = flag.Bool("version", false, "print the current version")
 	warnOnUnboundMethods       = flag.Bool("warn_on_unbound_methods", false, "emit a warning message if an RPC method has no HttpRule annotation")
 	generateUnboundMethods     = flag.Bool("generate_unbound_methods", false, "generate proxy methods even for RPC methods that have no HttpRule annotation")
+	unboundMethodsPattern      = flag.String("unbound_methods_pattern", "", "path template of the methods generated by generate_unbound_methods, e.g. `/api/{service}/{method}`, where {package}, {service} and {method} are replaced by lower-cased names; Get* and List* methods are bound to GET")
+	separateFiles              = flag.Bool("separate_files", false, "if set, the code of every service is emitted in its own `<service>.pb.gw.go` file instead of one file per proto file")
+	generatePathHelpers        = flag.Bool("generate_path_helpers", false, "if set, a `<Service>_<Method>Path` function building the URL path of every binding from its path parameters is generated")
+	generateHTTPClient         = flag.Bool("generate_http_client", false, "if set, a `<Service>HTTPClient` calling the REST endpoints of the gateway is generated for every service")
+	generateHooks              = flag.Bool("generate_hooks", false, "if set, per-method hook interfaces called with the decoded request and the response of unary methods are generated, registered with `With<Service>Hooks`")
+	validate                   = flag.Bool("validate", false, "if set, decoded requests are validated with runtime.Validate before they are forwarded, failing with a 400 and field-level error details")
+	generateRouteManifest      = flag.Bool("generate_route_manifest", false, "if set, a `*.routes.json` file listing the routes registered by the generated code is emitted next to every generated file")
+	unexportedRegisterFuncs    = flag.Bool("unexported_register_funcs", false, "if set, the `register<Service><Suffix>*` functions are generated unexported, e.g. to be wrapped by hand-written code")
+	generateRegisterAll        = flag.Bool("generate_register_all", false, "if set, a `RegisterAll<File><Suffix>s` function registering all the services of every proto file to a connection is generated")
+	generateStdlibPatterns     = flag.Bool("generate_stdlib_patterns", false, "if set, `Register<Service><Suffix>Stdlib` functions registering the handlers to a net/http ServeMux with Go 1.22 patterns are generated. The generated code then requires Go 1.22")
+	genericForwarders          = flag.Bool("generic_forwarders", false, "if set, the handlers are registered as closures calling the generic runtime.ClientHandler and runtime.ServerHandler instead of a full handler per binding, to shrink the generated code. The generated code then requires Go 1.21")
+	localServerStreaming       = flag.Bool("local_server_streaming", false, "if set, the `Register<Service><Suffix>Server` functions forward server streaming methods to the server in process instead of failing with Unimplemented")
+	fieldViolations            = flag.Bool("field_violations", false, "if set, the errors decoding the path parameters, query parameters and body of requests carry a google.rpc.BadRequest detail naming the offending field and the reason")
+	auditLog                   = flag.Bool("audit_log", false, "if set, the decoded requests and the responses of unary methods are passed to runtime.Audit, which calls the audit function given to runtime.WithAuditLog with their sensitive fields redacted")
+	record                     = flag.Bool("record", false, "if set, the calls of unary methods are passed to runtime.Record, which records their route, decoded request, forwarded metadata and response to the sink given to runtime.WithRecording, to be replayed with runtime.Replay")
+	poolRequests               = flag.Bool("pool_requests", false, "if set, the handlers of unary methods forwarded to clients reuse their request messages from a runtime.MessagePool, reset once the calls return. The hooks of the methods must then not retain the requests")
+	generateEnvoyConfig        = flag.Bool("generate_envoy_config", false, "if set, a `*.envoy.json` file configuring the Envoy grpc_json_transcoder filter to transcode the services like the generated code, and the `*.envoy.pb` descriptor set it reads, are emitted next to every generated file")
+	generateGraphQL            = flag.Bool("generate_graphql", false, "experimental: if set, `Register<Service><Suffix>GraphQL` functions registering the unary methods with bindings as the queries, for GET bindings, and mutations of the schema of a runtime.GraphQLHandler are generated")
+	kubernetesRoutes           = flag.String("kubernetes_routes", "", "if set to `httproute` or `ingress`, a `*.k8s.yaml` Gateway API HTTPRoute or Ingress manifest routing the bindings to the gateway is emitted next to every generated file")
+	kubernetesBackend          = flag.String("kubernetes_backend", "grpc-gateway:80", "the `<service>:<port>` of the Kubernetes service serving the gateway the manifests of kubernetes_routes route to")
+	kubernetesGateway          = flag.String("kubernetes_gateway", "", "the name of the Gateway the HTTPRoutes of kubernetes_routes are attached to")
+	buildTags                  = flag.String("build_tags", "", "a `//go:build` expression of tags combined with `!`, `&&` and `||` the generated files are built with, e.g. `!no_gateway`")
+	generationHeader           = flag.Bool("generation_header", false, "if set, the generated files start with a header recording the plugin version, the plugin parameters and the SHA-256 digest of the source file descriptor")
+	templateFuncsFile          = flag.String("template_funcs", "", "path to a YAML file declaring helper functions for user-supplied templates")
+	templateDir                = flag.String("template_dir", "", "path to a directory of `<section>.tmpl` files overriding the builtin templates of the same name")
+	dumpTemplates              = flag.String("dump_templates", "", "write the builtin templates into the given directory and exit")
 )
 
+var (
+	importSubstitutions stringList
+	routerAdapters      stringList
+	descriptorSets      stringList
+)
+
+func init() {
+	flag.Var(&importSubstitutions, "import_substitution", "with standalone, imports the message packages at a Go import path, or below it, from another path, as `from=to`, e.g. for vendored or buf-generated packages. May be given multiple times.")
+	flag.Var(&descriptorSets, "descriptor_set_in", "path to a binary FileDescriptorSet, e.g. built with `buf build -o`, whose types are loaded in addition to those of the request, e.g. for a gRPC API Configuration spanning several modules. May be given multiple times, files in several sets must have the same definitions.")
+	flag.Var(&routerAdapters, "router_adapter", "a router, `chi` or `gorilla`, functions registering the handlers to are generated for, e.g. `Register<Service><Suffix>Chi`. May be given multiple times.")
+}
+
+// stringList is a flag.Value collecting every value given to a repeated flag.
+type stringList []string
+
+func (m *stringList) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *stringList) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
 // Variables set by goreleaser at build time
 var (
 	version = "dev"
@@ -54,56 +109,138 @@ func main() {
 		fmt.Printf("Version %v, commit %v, built at %v\n", version, commit, date)
 		os.Exit(0)
 	}
+	if *dumpTemplates != "" {
+		if err := gengateway.WriteBuiltinTemplates(*dumpTemplates); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	reg := descriptor.NewRegistry()
 
-	protogen.Options{
+	req, err := codegenerator.ParseRequest(os.Stdin)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	// protogen does not support protobuf editions yet, while the registry
+	// loads the request as is.
+	plugin, err := protogen.Options{
 		// FIXME: ParamFunc is not enough at this point because it does not receive all params.
 		//        Some are swallowed by protogen like "paths".
 		//        This problem will go away when the code generation is completely rewritten
 		//        to support protogen.Plugin.
 		ParamFunc: flag.CommandLine.Set,
-	}.Run(func(plugin *protogen.Plugin) error {
-		// FIXME: still needed to parse request parameter and apply flags manually, see the comment above.
-		parseFlags(reg, plugin.Request.GetParameter())
-		if err := applyFlags(reg); err != nil {
-			return err
-		}
+	}.New(codegenerator.EditionsAsProto2(req))
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if err := generate(reg, plugin, req); err != nil {
+		plugin.Error(err)
+	}
+	resp := plugin.Response()
+	codegenerator.SetSupportedFeatures(resp)
+	buf, err := proto.Marshal(resp)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if _, err := os.Stdout.Write(buf); err != nil {
+		glog.Fatal(err)
+	}
+}
 
-		glog.V(1).Infof("Parsing code generator request")
+// generate generates the gateway files of the files to generate of req in plugin.
+func generate(reg *descriptor.Registry, plugin *protogen.Plugin, req *pluginpb.CodeGeneratorRequest) error {
+	// FIXME: still needed to parse request parameter and apply flags manually, see the comment above.
+	parseFlags(reg, req.GetParameter())
+	if err := applyFlags(reg); err != nil {
+		return err
+	}
+
+	glog.V(1).Infof("Parsing code generator request")
+
+	if err := reg.Load(req); err != nil {
+		return err
+	}
 
-		if err := reg.Load(plugin.Request); err != nil {
+	unboundHTTPRules := reg.UnboundExternalHTTPRules()
+	if len(unboundHTTPRules) != 0 {
+		return fmt.Errorf("HTTP rules without a matching selector: %s", strings.Join(unboundHTTPRules, ", "))
+	}
+
+	var targets []*descriptor.File
+	for _, target := range req.FileToGenerate {
+		f, err := reg.LookupFile(target)
+		if err != nil {
 			return err
 		}
+		targets = append(targets, f)
+	}
 
-		unboundHTTPRules := reg.UnboundExternalHTTPRules()
-		if len(unboundHTTPRules) != 0 {
-			return fmt.Errorf("HTTP rules without a matching selector: %s", strings.Join(unboundHTTPRules, ", "))
+	var templateFuncs template.FuncMap
+	if *templateFuncsFile != "" {
+		var err error
+		templateFuncs, err = gengateway.LoadTemplateFuncsFromYAML(*templateFuncsFile)
+		if err != nil {
+			return err
 		}
+	}
 
-		var targets []*descriptor.File
-		for _, target := range plugin.Request.FileToGenerate {
-			f, err := reg.LookupFile(target)
-			if err != nil {
-				return err
-			}
-			targets = append(targets, f)
+	var genInfo *gengateway.GenerationInfo
+	if *generationHeader {
+		genInfo = &gengateway.GenerationInfo{Version: version, Parameters: req.GetParameter()}
+	}
+	var k8sRoutes *gengateway.KubernetesRoutes
+	if *kubernetesRoutes != "" {
+		var err error
+		k8sRoutes, err = gengateway.ParseKubernetesRoutes(*kubernetesRoutes, *kubernetesBackend, *kubernetesGateway)
+		if err != nil {
+			return err
 		}
-
-		g := gengateway.New(reg, *useRequestContext, *registerFuncSuffix, *pathType, *modulePath, *allowPatchFeature, *standalone)
-		files, err := g.Generate(targets)
-		for _, f := range files {
-			glog.V(1).Infof("NewGeneratedFile %q in %s", f.GetName(), f.GoPkg)
-			genFile := plugin.NewGeneratedFile(f.GetName(), protogen.GoImportPath(f.GoPkg.Path))
-			if _, err := genFile.Write([]byte(f.GetContent())); err != nil {
-				return err
-			}
+	}
+	g := gengateway.New(reg, gengateway.Options{
+		UseRequestContext:       *useRequestContext,
+		RegisterFuncSuffix:      *registerFuncSuffix,
+		Paths:                   *pathType,
+		ModulePath:              *modulePath,
+		AllowPatchFeature:       *allowPatchFeature,
+		Standalone:              *standalone,
+		TemplateFuncs:           templateFuncs,
+		TemplateDir:             *templateDir,
+		SeparateFiles:           *separateFiles,
+		PathHelpers:             *generatePathHelpers,
+		HTTPClient:              *generateHTTPClient,
+		Hooks:                   *generateHooks,
+		Validate:                *validate,
+		RouteManifest:           *generateRouteManifest,
+		UnexportedRegisterFuncs: *unexportedRegisterFuncs,
+		RegisterAll:             *generateRegisterAll,
+		StdlibPatterns:          *generateStdlibPatterns,
+		RouterAdapters:          routerAdapters,
+		GenericForwarders:       *genericForwarders,
+		LocalServerStreaming:    *localServerStreaming,
+		FieldViolations:         *fieldViolations,
+		AuditLog:                *auditLog,
+		Record:                  *record,
+		PoolRequests:            *poolRequests,
+		GraphQL:                 *generateGraphQL,
+		BuildTags:               *buildTags,
+		GenerationInfo:          genInfo,
+		EnvoyConfig:             *generateEnvoyConfig,
+		KubernetesRoutes:        k8sRoutes,
+	})
+	files, err := g.Generate(targets)
+	for _, f := range files {
+		glog.V(1).Infof("NewGeneratedFile %q in %s", f.GetName(), f.GoPkg)
+		genFile := plugin.NewGeneratedFile(f.GetName(), protogen.GoImportPath(f.GoPkg.Path))
+		if _, err := genFile.Write([]byte(f.GetContent())); err != nil {
+			return err
 		}
+	}
 
-		glog.V(1).Info("Processed code generator request")
+	glog.V(1).Info("Processed code generator request")
 
-		return err
-	})
+	return err
 }
 
 func parseFlags(reg *descriptor.Registry, parameter string) {
@@ -137,7 +274,13 @@ func applyFlags(reg *descriptor.Registry) error {
 	if *warnOnUnboundMethods && *generateUnboundMethods {
 		glog.Warningf("Option warn_on_unbound_methods has no effect when generate_unbound_methods is used.")
 	}
+	if len(importSubstitutions) != 0 && !*standalone {
+		return errors.New("option import_substitution requires standalone")
+	}
 	reg.SetStandalone(*standalone)
+	if err := reg.SetImportSubstitutions(importSubstitutions); err != nil {
+		return err
+	}
 	reg.SetPrefix(*importPrefix)
 	reg.SetImportPath(*importPath)
 	reg.SetAllowDeleteBody(*allowDeleteBody)
@@ -145,5 +288,16 @@ func applyFlags(reg *descriptor.Registry) error {
 	reg.SetOmitPackageDoc(*omitPackageDoc)
 	reg.SetWarnOnUnboundMethods(*warnOnUnboundMethods)
 	reg.SetGenerateUnboundMethods(*generateUnboundMethods)
-	return reg.SetRepeatedPathParamSeparator(*repeatedPathParamSeparator)
+	if err := reg.SetUnboundMethodsPattern(*unboundMethodsPattern); err != nil {
+		return err
+	}
+	if err := reg.SetRepeatedPathParamSeparator(*repeatedPathParamSeparator); err != nil {
+		return err
+	}
+	for _, set := range descriptorSets {
+		if err := reg.LoadFileDescriptorSetFromFile(set); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,200 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.12.0
+// source: protoc-gen-grpc-gateway/options/authorization.proto
+
+package options
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// `Authorization` declares the credentials required to call a method through
+// the gateway. The generated handlers pass it to the `runtime.Authorizer` of
+// the mux before forwarding the requests.
+//
+// Example:
+//
+//  service Library {
+//    rpc DeleteBook(DeleteBookRequest) returns (google.protobuf.Empty) {
+//      option (google.api.http) = {
+//        delete: "/v1/{name=shelves/*/books/*}"
+//      };
+//      option (grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation) = {
+//        gateway: {
+//          authorization: {
+//            roles: ["admin", "librarian"]
+//            scopes: ["library.write"]
+//          }
+//        }
+//      };
+//    }
+//  }
+type Authorization struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Roles of which the caller must have at least one.
+	Roles []string `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
+	// Scopes the caller must all have.
+	Scopes []string `protobuf:"bytes,2,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Allows the unauthenticated callers, e.g. for public methods.
+	AllowUnauthenticated bool `protobuf:"varint,3,opt,name=allow_unauthenticated,json=allowUnauthenticated,proto3" json:"allow_unauthenticated,omitempty"`
+}
+
+func (x *Authorization) Reset() {
+	*x = Authorization{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protoc_gen_grpc_gateway_options_authorization_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Authorization) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Authorization) ProtoMessage() {}
+
+func (x *Authorization) ProtoReflect() protoreflect.Message {
+	mi := &file_protoc_gen_grpc_gateway_options_authorization_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Authorization.ProtoReflect.Descriptor instead.
+func (*Authorization) Descriptor() ([]byte, []int) {
+	return file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Authorization) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *Authorization) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *Authorization) GetAllowUnauthenticated() bool {
+	if x != nil {
+		return x.AllowUnauthenticated
+	}
+	return false
+}
+
+var File_protoc_gen_grpc_gateway_options_authorization_proto protoreflect.FileDescriptor
+
+var file_protoc_gen_grpc_gateway_options_authorization_proto_rawDesc = []byte{
+	0x0a, 0x33, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x2d, 0x67, 0x65, 0x6e, 0x2d, 0x67, 0x72, 0x70,
+	0x63, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x2c, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x67, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e, 0x5f, 0x67,
+	0x72, 0x70, 0x63, 0x5f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x22, 0x72, 0x0a, 0x0d, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x6c, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x63,
+	0x6f, 0x70, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x6f, 0x70,
+	0x65, 0x73, 0x12, 0x33, 0x0a, 0x15, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x75, 0x6e, 0x61, 0x75,
+	0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x14, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x55, 0x6e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e,
+	0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x42, 0x4b, 0x5a, 0x49, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x65, 0x63, 0x6f, 0x73, 0x79,
+	0x73, 0x74, 0x65, 0x6d, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61,
+	0x79, 0x2f, 0x76, 0x32, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x2d, 0x67, 0x65, 0x6e, 0x2d,
+	0x67, 0x72, 0x70, 0x63, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescOnce sync.Once
+	file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescData = file_protoc_gen_grpc_gateway_options_authorization_proto_rawDesc
+)
+
+func file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescGZIP() []byte {
+	file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescOnce.Do(func() {
+		file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescData = protoimpl.X.CompressGZIP(file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescData)
+	})
+	return file_protoc_gen_grpc_gateway_options_authorization_proto_rawDescData
+}
+
+var file_protoc_gen_grpc_gateway_options_authorization_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_protoc_gen_grpc_gateway_options_authorization_proto_goTypes = []interface{}{
+	(*Authorization)(nil), // 0: grpc.gateway.protoc_gen_grpc_gateway.options.Authorization
+}
+var file_protoc_gen_grpc_gateway_options_authorization_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protoc_gen_grpc_gateway_options_authorization_proto_init() }
+func file_protoc_gen_grpc_gateway_options_authorization_proto_init() {
+	if File_protoc_gen_grpc_gateway_options_authorization_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protoc_gen_grpc_gateway_options_authorization_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Authorization); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protoc_gen_grpc_gateway_options_authorization_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protoc_gen_grpc_gateway_options_authorization_proto_goTypes,
+		DependencyIndexes: file_protoc_gen_grpc_gateway_options_authorization_proto_depIdxs,
+		MessageInfos:      file_protoc_gen_grpc_gateway_options_authorization_proto_msgTypes,
+	}.Build()
+	File_protoc_gen_grpc_gateway_options_authorization_proto = out.File
+	file_protoc_gen_grpc_gateway_options_authorization_proto_rawDesc = nil
+	file_protoc_gen_grpc_gateway_options_authorization_proto_goTypes = nil
+	file_protoc_gen_grpc_gateway_options_authorization_proto_depIdxs = nil
+}
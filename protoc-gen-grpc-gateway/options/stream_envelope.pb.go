@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.12.0
+// source: protoc-gen-grpc-gateway/options/stream_envelope.proto
+
+package options
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// `StreamEnvelope` describes how the chunks of the responses of a server
+// streaming method wrap its messages and errors, overriding the envelope set
+// with `runtime.WithStreamEnvelope`.
+//
+// Example:
+//
+//  service Library {
+//    rpc WatchBooks(WatchBooksRequest) returns (stream Book) {
+//      option (google.api.http) = {
+//        get: "/v1/books:watch"
+//      };
+//      option (grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation) = {
+//        gateway: {
+//          stream_envelope: {
+//            bare: true
+//          }
+//        }
+//      };
+//    }
+//  }
+type StreamEnvelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Writes the messages and the errors unwrapped.
+	Bare bool `protobuf:"varint,1,opt,name=bare,proto3" json:"bare,omitempty"`
+	// Key of the messages, "result" if empty.
+	ResultKey string `protobuf:"bytes,2,opt,name=result_key,json=resultKey,proto3" json:"result_key,omitempty"`
+	// Key of the errors, "error" if empty.
+	ErrorKey string `protobuf:"bytes,3,opt,name=error_key,json=errorKey,proto3" json:"error_key,omitempty"`
+	// Key of the metadata of the chunks, omitted if empty.
+	MetadataKey string `protobuf:"bytes,4,opt,name=metadata_key,json=metadataKey,proto3" json:"metadata_key,omitempty"`
+}
+
+func (x *StreamEnvelope) Reset() {
+	*x = StreamEnvelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protoc_gen_grpc_gateway_options_stream_envelope_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEnvelope) ProtoMessage() {}
+
+func (x *StreamEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_protoc_gen_grpc_gateway_options_stream_envelope_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEnvelope.ProtoReflect.Descriptor instead.
+func (*StreamEnvelope) Descriptor() ([]byte, []int) {
+	return file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamEnvelope) GetBare() bool {
+	if x != nil {
+		return x.Bare
+	}
+	return false
+}
+
+func (x *StreamEnvelope) GetResultKey() string {
+	if x != nil {
+		return x.ResultKey
+	}
+	return ""
+}
+
+func (x *StreamEnvelope) GetErrorKey() string {
+	if x != nil {
+		return x.ErrorKey
+	}
+	return ""
+}
+
+func (x *StreamEnvelope) GetMetadataKey() string {
+	if x != nil {
+		return x.MetadataKey
+	}
+	return ""
+}
+
+var File_protoc_gen_grpc_gateway_options_stream_envelope_proto protoreflect.FileDescriptor
+
+var file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDesc = []byte{
+	0x0a, 0x35, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x2d, 0x67, 0x65, 0x6e, 0x2d, 0x67, 0x72, 0x70,
+	0x63, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x2f, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x65, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x2c, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x5f, 0x67, 0x65, 0x6e,
+	0x5f, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x83, 0x01, 0x0a, 0x0e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x61, 0x72, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x62, 0x61, 0x72, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x4b, 0x65, 0x79, 0x42, 0x4b, 0x5a, 0x49, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x65,
+	0x63, 0x6f, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x76, 0x32, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x2d,
+	0x67, 0x65, 0x6e, 0x2d, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescOnce sync.Once
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescData = file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDesc
+)
+
+func file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescGZIP() []byte {
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescOnce.Do(func() {
+		file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescData = protoimpl.X.CompressGZIP(file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescData)
+	})
+	return file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDescData
+}
+
+var file_protoc_gen_grpc_gateway_options_stream_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_protoc_gen_grpc_gateway_options_stream_envelope_proto_goTypes = []interface{}{
+	(*StreamEnvelope)(nil), // 0: grpc.gateway.protoc_gen_grpc_gateway.options.StreamEnvelope
+}
+var file_protoc_gen_grpc_gateway_options_stream_envelope_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_protoc_gen_grpc_gateway_options_stream_envelope_proto_init() }
+func file_protoc_gen_grpc_gateway_options_stream_envelope_proto_init() {
+	if File_protoc_gen_grpc_gateway_options_stream_envelope_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protoc_gen_grpc_gateway_options_stream_envelope_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamEnvelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protoc_gen_grpc_gateway_options_stream_envelope_proto_goTypes,
+		DependencyIndexes: file_protoc_gen_grpc_gateway_options_stream_envelope_proto_depIdxs,
+		MessageInfos:      file_protoc_gen_grpc_gateway_options_stream_envelope_proto_msgTypes,
+	}.Build()
+	File_protoc_gen_grpc_gateway_options_stream_envelope_proto = out.File
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_rawDesc = nil
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_goTypes = nil
+	file_protoc_gen_grpc_gateway_options_stream_envelope_proto_depIdxs = nil
+}
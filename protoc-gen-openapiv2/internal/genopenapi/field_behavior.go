@@ -0,0 +1,41 @@
+package genopenapi
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+)
+
+// fieldBehaviors returns the google.api.field_behavior annotations of the field.
+func fieldBehaviors(f *descriptor.Field) []annotations.FieldBehavior {
+	if f.Options == nil || !proto.HasExtension(f.Options, annotations.E_FieldBehavior) {
+		return nil
+	}
+	behaviors, _ := proto.GetExtension(f.Options, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	return behaviors
+}
+
+func hasFieldBehavior(f *descriptor.Field, behavior annotations.FieldBehavior) bool {
+	for _, b := range fieldBehaviors(f) {
+		if b == behavior {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFieldBehavior marks the schema of the field as read-only, write-only or
+// immutable according to its google.api.field_behavior annotations. Required
+// fields are handled by the schema of the enclosing message.
+func applyFieldBehavior(schema *openapiSchemaObject, f *descriptor.Field) {
+	for _, b := range fieldBehaviors(f) {
+		switch b {
+		case annotations.FieldBehavior_OUTPUT_ONLY:
+			schema.ReadOnly = true
+		case annotations.FieldBehavior_INPUT_ONLY:
+			schema.WriteOnly = true
+		case annotations.FieldBehavior_IMMUTABLE:
+			schema.XImmutable = true
+		}
+	}
+}
@@ -183,6 +183,22 @@ func (g *generator) Generate(targets []*descriptor.File) ([]*descriptor.Response
 		targets = append(targets, mergedTarget)
 	}
 
+	if g.reg.GetOutputFormat() == descriptor.OutputFormatJSONSchema {
+		for _, file := range targets {
+			glog.V(1).Infof("Processing %s", file.GetName())
+			schemas, err := renderJSONSchemas(file, g.reg)
+			if err == errNoTargetService {
+				glog.V(1).Infof("%s: %v", file.GetName(), err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, schemas...)
+		}
+		return files, nil
+	}
+
 	var openapis []*wrapper
 	for _, file := range targets {
 		glog.V(1).Infof("Processing %s", file.GetName())
@@ -200,17 +216,30 @@ func (g *generator) Generate(targets []*descriptor.File) ([]*descriptor.Response
 		})
 	}
 
+	encode := encodeOpenAPI
+	if g.reg.GetOutputFormat() == descriptor.OutputFormatPostman {
+		encode = encodePostmanCollection
+	}
+
 	if g.reg.IsAllowMerge() {
 		targetOpenAPI := mergeTargetFile(openapis, g.reg.GetMergeFileName())
-		f, err := encodeOpenAPI(targetOpenAPI)
+		f, err := encode(targetOpenAPI)
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode OpenAPI for %s: %s", g.reg.GetMergeFileName(), err)
 		}
 		files = append(files, f)
 		glog.V(1).Infof("New OpenAPI file will emit")
+
+		if g.reg.GetOutputFormat() == descriptor.OutputFormatOpenAPIv2 {
+			serverFiles, err := encodeServerOpenAPIs(targetOpenAPI, g.reg)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, serverFiles...)
+		}
 	} else {
 		for _, file := range openapis {
-			f, err := encodeOpenAPI(file)
+			f, err := encode(file)
 			if err != nil {
 				return nil, fmt.Errorf("failed to encode OpenAPI for %s: %s", file.fileName, err)
 			}
@@ -221,6 +250,33 @@ func (g *generator) Generate(targets []*descriptor.File) ([]*descriptor.Response
 	return files, nil
 }
 
+// encodeServerOpenAPIs emits a copy of the merged OpenAPI file per server
+// when several servers are configured, as OpenAPI v2 only has room for one
+// host per document.
+func encodeServerOpenAPIs(merged *wrapper, reg *descriptor.Registry) ([]*descriptor.ResponseFile, error) {
+	servers, err := renderServers(reg)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) < 2 {
+		return nil, nil
+	}
+	var files []*descriptor.ResponseFile
+	for i, server := range servers {
+		swagger := *merged.swagger
+		if err := applyServer(&swagger, server); err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s_%s", reg.GetMergeFileName(), reg.GetServers()[i].Name)
+		f, err := encodeOpenAPI(&wrapper{fileName: name, swagger: &swagger})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode OpenAPI for %s: %s", name, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
 // AddErrorDefs Adds google.rpc.Status and google.protobuf.Any
 // to registry (used for error-related API responses)
 func AddErrorDefs(reg *descriptor.Registry) error {
@@ -0,0 +1,115 @@
+package genopenapi
+
+import (
+	"strings"
+)
+
+// inliner replaces references to definitions with the definitions themselves.
+type inliner struct {
+	defs openapiDefinitionsObject
+	// kept are the definitions which are still referenced, because inlining
+	// them would never end.
+	kept map[string]bool
+}
+
+// inlineRefs replaces every reference to a definition in the document with a
+// copy of the definition. References closing a cycle are kept, along with the
+// definitions they point to; every other definition is removed.
+func inlineRefs(s *openapiSwaggerObject) {
+	in := &inliner{defs: s.Definitions, kept: make(map[string]bool)}
+	for _, item := range s.Paths {
+		for _, op := range []*openapiOperationObject{item.Get, item.Delete, item.Post, item.Put, item.Patch} {
+			if op == nil {
+				continue
+			}
+			for i, param := range op.Parameters {
+				if param.Schema != nil {
+					schema := in.schema(*param.Schema, map[string]bool{})
+					op.Parameters[i].Schema = &schema
+				}
+			}
+			for code, resp := range op.Responses {
+				resp.Schema = in.schema(resp.Schema, map[string]bool{})
+				op.Responses[code] = resp
+			}
+		}
+	}
+
+	defs := make(openapiDefinitionsObject)
+	for done := false; !done; {
+		done = true
+		for name := range in.kept {
+			if _, ok := defs[name]; ok {
+				continue
+			}
+			done = false
+			defs[name] = in.schema(in.defs[name], map[string]bool{name: true})
+		}
+	}
+	s.Definitions = defs
+}
+
+// schema returns a copy of s with its references inlined. stack holds the
+// definitions being inlined, references to which are kept.
+func (in *inliner) schema(s openapiSchemaObject, stack map[string]bool) openapiSchemaObject {
+	if name := strings.TrimPrefix(s.Ref, definitionsPrefix); name != s.Ref {
+		def, ok := in.defs[name]
+		if !ok {
+			return s
+		}
+		if stack[name] {
+			in.kept[name] = true
+			return s
+		}
+		stack[name] = true
+		inlined := in.schema(def, stack)
+		delete(stack, name)
+		// The description of a field is more specific than the one of its type.
+		if s.Description != "" {
+			inlined.Description = s.Description
+		}
+		if s.Title != "" {
+			inlined.Title = s.Title
+		}
+		inlined.ReadOnly = inlined.ReadOnly || s.ReadOnly
+		inlined.WriteOnly = inlined.WriteOnly || s.WriteOnly
+		inlined.Deprecated = inlined.Deprecated || s.Deprecated
+		return inlined
+	}
+	if s.Items != nil {
+		s.Items = in.items(s.Items, stack)
+	}
+	if s.AdditionalProperties != nil {
+		additional := in.schema(*s.AdditionalProperties, stack)
+		s.AdditionalProperties = &additional
+	}
+	if s.Properties != nil {
+		props := make(openapiSchemaObjectProperties, len(*s.Properties))
+		for i, kv := range *s.Properties {
+			if v, ok := kv.Value.(openapiSchemaObject); ok {
+				kv.Value = in.schema(v, stack)
+			}
+			props[i] = kv
+		}
+		s.Properties = &props
+	}
+	return s
+}
+
+func (in *inliner) items(items *openapiItemsObject, stack map[string]bool) *openapiItemsObject {
+	if items.Ref == "" {
+		if items.Items != nil {
+			nested := *items
+			nested.Items = in.items(items.Items, stack)
+			return &nested
+		}
+		return items
+	}
+	inlined := in.schema(openapiSchemaObject{schemaCore: schemaCore(*items)}, stack)
+	if inlined.Ref != "" {
+		return items
+	}
+	out := openapiItemsObject(inlined.schemaCore)
+	out.inline = &inlined
+	return &out
+}
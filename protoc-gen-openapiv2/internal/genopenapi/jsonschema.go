@@ -0,0 +1,163 @@
+package genopenapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// jsonSchemaDialect is the meta-schema standalone JSON Schema documents are written against.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// https://json-schema.org/draft/2020-12/json-schema-core.html
+type jsonSchemaDocument struct {
+	Schema      string                 `json:"$schema"`
+	ID          string                 `json:"$id"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Ref         string                 `json:"$ref"`
+	Defs        map[string]interface{} `json:"$defs"`
+}
+
+// renderJSONSchemas renders a standalone JSON Schema document for every
+// request and response message of the services defined in the file.
+func renderJSONSchemas(file *descriptor.File, reg *descriptor.Registry) ([]*descriptor.ResponseFile, error) {
+	if len(file.Services) == 0 {
+		return nil, errNoTargetService
+	}
+	base := strings.TrimSuffix(file.GetName(), filepath.Ext(file.GetName()))
+
+	var files []*descriptor.ResponseFile
+	seen := make(map[string]bool)
+	for _, svc := range file.Services {
+		for _, meth := range svc.Methods {
+			for _, msg := range []*descriptor.Message{meth.RequestType, meth.ResponseType} {
+				if seen[msg.FQMN()] || skipRenderingRef(msg.FQMN()) {
+					continue
+				}
+				seen[msg.FQMN()] = true
+
+				f, err := renderJSONSchema(msg, base, reg)
+				if err != nil {
+					return nil, fmt.Errorf("failed to render JSON Schema for %s: %s", msg.FQMN(), err)
+				}
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// renderJSONSchema renders msg and every message or enum it depends on into a
+// single JSON Schema document named after the message.
+func renderJSONSchema(msg *descriptor.Message, base string, reg *descriptor.Registry) (*descriptor.ResponseFile, error) {
+	swgName, ok := fullyQualifiedNameToOpenAPIName(msg.FQMN(), reg)
+	if !ok {
+		return nil, fmt.Errorf("can't resolve OpenAPI name from '%v'", msg.FQMN())
+	}
+
+	messages := messageMap{msg.FQMN(): msg}
+	enums := enumMap{}
+	customRefs := refMap{}
+	findNestedMessagesAndEnumerations(msg, reg, messages, enums)
+
+	definitions := make(openapiDefinitionsObject)
+	renderMessagesAsDefinition(messages, definitions, reg, customRefs)
+	renderEnumerationsAsDefinition(enums, definitions, reg)
+	addCustomRefs(definitions, reg, customRefs)
+	if reg.GetRecursiveSchemaMode() == recursiveSchemaModeUnroll {
+		unrollRecursiveDefinitions(definitions, reg.GetRecursiveSchemaDepth())
+	}
+
+	doc := jsonSchemaDocument{
+		Schema: jsonSchemaDialect,
+		ID:     fmt.Sprintf("%s.%s.schema.json", base, swgName),
+		Ref:    "#/$defs/" + swgName,
+		Defs:   make(map[string]interface{}, len(definitions)),
+	}
+	if root, ok := definitions[swgName]; ok {
+		doc.Title = root.Title
+		doc.Description = root.Description
+	}
+	for name, def := range definitions {
+		schema, err := openAPISchemaToJSONSchema(def)
+		if err != nil {
+			return nil, err
+		}
+		doc.Defs[name] = schema
+	}
+
+	var formatted bytes.Buffer
+	enc := json.NewEncoder(&formatted)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return &descriptor.ResponseFile{
+		CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+			Name:    proto.String(doc.ID),
+			Content: proto.String(formatted.String()),
+		},
+	}, nil
+}
+
+// openAPISchemaToJSONSchema converts an OpenAPI v2 schema object into its
+// draft 2020-12 JSON Schema equivalent.
+func openAPISchemaToJSONSchema(s openapiSchemaObject) (map[string]interface{}, error) {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(buf, &schema); err != nil {
+		return nil, err
+	}
+	convertSchemaKeywords(schema)
+	return schema, nil
+}
+
+// convertSchemaKeywords rewrites the OpenAPI v2 specific keywords of schema
+// (and of every schema nested in it) in place.
+func convertSchemaKeywords(schema map[string]interface{}) {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema["$ref"] = strings.Replace(ref, "#/definitions/", "#/$defs/", 1)
+	}
+	// OpenAPI v2 uses boolean exclusive bounds which modify maximum and minimum,
+	// JSON Schema uses numeric exclusive bounds instead.
+	for _, bound := range []string{"Maximum", "Minimum"} {
+		exclusive := "exclusive" + bound
+		inclusive := strings.ToLower(bound)
+		if v, ok := schema[exclusive].(bool); ok && v {
+			// A zero bound is omitted from the OpenAPI output.
+			limit, ok := schema[inclusive]
+			if !ok {
+				limit = float64(0)
+			}
+			schema[exclusive] = limit
+			delete(schema, inclusive)
+		}
+	}
+	if example, ok := schema["example"]; ok {
+		schema["examples"] = []interface{}{example}
+		delete(schema, "example")
+	}
+
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, prop := range props {
+			if p, ok := prop.(map[string]interface{}); ok {
+				convertSchemaKeywords(p)
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if nested, ok := schema[key].(map[string]interface{}); ok {
+			convertSchemaKeywords(nested)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package genopenapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// postmanSchema is the version of the collection format the collections are written in.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanPathParam matches the path parameters of OpenAPI paths, with or
+// without a pattern, e.g. '{name}' or '{name=projects/*}'.
+var postmanPathParam = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// https://schema.postman.com/collection/json/v2.1.0/draft-07/docs/index.html
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanItem is either a folder, which has items, or a request.
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []postmanVariable `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Body        *postmanBody      `json:"body,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanVariable `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string `json:"mode"`
+	Raw     string `json:"raw"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	APIKey []postmanVariable `json:"apikey,omitempty"`
+	Basic  []postmanVariable `json:"basic,omitempty"`
+	Bearer []postmanVariable `json:"bearer,omitempty"`
+}
+
+// postmanVariable is used for collection and path variables, headers, query
+// parameters and auth attributes, which share the same shape.
+type postmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// renderPostmanCollection converts a rendered OpenAPI document into a Postman
+// collection. Requests are grouped in folders by their first tag, and the
+// base URL and credentials are left as collection variables.
+func renderPostmanCollection(swagger *openapiSwaggerObject) postmanCollection {
+	c := postmanCollection{
+		Info: postmanInfo{
+			Name:        swagger.Info.Title,
+			Description: swagger.Info.Description,
+			Schema:      postmanSchema,
+		},
+		Variable: []postmanVariable{{Key: "baseUrl", Value: postmanBaseURL(swagger)}},
+	}
+	if auth, vars := postmanAuthFromSecurity(swagger.SecurityDefinitions); auth != nil {
+		c.Auth = auth
+		c.Variable = append(c.Variable, vars...)
+	}
+
+	var folders []postmanItem
+	folderIndex := make(map[string]int)
+	paths := make([]string, 0, len(swagger.Paths))
+	for path := range swagger.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		item := swagger.Paths[path]
+		for _, op := range []struct {
+			method string
+			op     *openapiOperationObject
+		}{
+			{"GET", item.Get},
+			{"POST", item.Post},
+			{"PUT", item.Put},
+			{"PATCH", item.Patch},
+			{"DELETE", item.Delete},
+		} {
+			if op.op == nil {
+				continue
+			}
+			folder := "default"
+			if len(op.op.Tags) > 0 {
+				folder = op.op.Tags[0]
+			}
+			i, ok := folderIndex[folder]
+			if !ok {
+				i = len(folders)
+				folderIndex[folder] = i
+				folders = append(folders, postmanItem{Name: folder})
+			}
+			folders[i].Item = append(folders[i].Item, postmanRequestItem(path, op.method, op.op, swagger.Definitions))
+		}
+	}
+	c.Item = folders
+	if c.Item == nil {
+		c.Item = []postmanItem{}
+	}
+	return c
+}
+
+func postmanBaseURL(swagger *openapiSwaggerObject) string {
+	if swagger.Host == "" {
+		return "http://localhost" + swagger.BasePath
+	}
+	scheme := "https"
+	if len(swagger.Schemes) > 0 {
+		scheme = swagger.Schemes[0]
+	}
+	return scheme + "://" + swagger.Host + swagger.BasePath
+}
+
+// postmanAuthFromSecurity returns the collection auth matching the first
+// security definition (by name), with its credentials as variables.
+func postmanAuthFromSecurity(defs openapiSecurityDefinitionsObject) (*postmanAuth, []postmanVariable) {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := defs[name]
+		switch def.Type {
+		case "apiKey":
+			return &postmanAuth{
+				Type: "apikey",
+				APIKey: []postmanVariable{
+					{Key: "key", Value: def.Name},
+					{Key: "value", Value: "{{apiKey}}"},
+					{Key: "in", Value: def.In},
+				},
+			}, []postmanVariable{{Key: "apiKey"}}
+		case "basic":
+			return &postmanAuth{
+				Type: "basic",
+				Basic: []postmanVariable{
+					{Key: "username", Value: "{{username}}"},
+					{Key: "password", Value: "{{password}}"},
+				},
+			}, []postmanVariable{{Key: "username"}, {Key: "password"}}
+		case "oauth2":
+			return &postmanAuth{
+				Type:   "bearer",
+				Bearer: []postmanVariable{{Key: "token", Value: "{{accessToken}}"}},
+			}, []postmanVariable{{Key: "accessToken"}}
+		}
+	}
+	return nil, nil
+}
+
+func postmanRequestItem(path, method string, op *openapiOperationObject, defs openapiDefinitionsObject) postmanItem {
+	name := op.Summary
+	if name == "" {
+		name = op.OperationID
+	}
+	url := postmanURL{
+		Host: []string{"{{baseUrl}}"},
+	}
+	// The parameters are replaced before splitting the path, as their
+	// templates may span several segments.
+	path = postmanPathParam.ReplaceAllString(path, ":$1")
+	url.Path = strings.Split(strings.TrimPrefix(path, "/"), "/")
+	req := &postmanRequest{
+		Method:      method,
+		Description: op.Description,
+		Header:      []postmanVariable{},
+	}
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			url.Variable = append(url.Variable, postmanVariable{Key: p.Name, Description: p.Description})
+		case "query":
+			// Query parameters are optional, so leave them disabled until filled in.
+			url.Query = append(url.Query, postmanVariable{Key: p.Name, Description: p.Description, Disabled: !p.Required})
+		case "header":
+			req.Header = append(req.Header, postmanVariable{Key: p.Name, Description: p.Description, Disabled: !p.Required})
+		case "body":
+			if p.Schema == nil {
+				continue
+			}
+			example, err := json.MarshalIndent(exampleOfSchema(*p.Schema, defs, map[string]bool{}), "", "  ")
+			if err != nil {
+				continue
+			}
+			req.Body = &postmanBody{Mode: "raw", Raw: string(example)}
+			req.Body.Options.Raw.Language = "json"
+			req.Header = append(req.Header, postmanVariable{Key: "Content-Type", Value: "application/json"})
+		}
+	}
+	url.Raw = "{{baseUrl}}/" + strings.Join(url.Path, "/")
+	if len(url.Query) > 0 {
+		var query []string
+		for _, q := range url.Query {
+			if !q.Disabled {
+				query = append(query, q.Key+"=")
+			}
+		}
+		if len(query) > 0 {
+			url.Raw += "?" + strings.Join(query, "&")
+		}
+	}
+	req.URL = url
+	return postmanItem{Name: name, Request: req}
+}
+
+// exampleOfSchema returns an example value of the schema: its example if it
+// has one, a placeholder of its type otherwise. visited guards against
+// recursive definitions, which are rendered as empty objects.
+func exampleOfSchema(s openapiSchemaObject, defs openapiDefinitionsObject, visited map[string]bool) interface{} {
+	if s.Example != nil {
+		var example interface{}
+		if err := json.Unmarshal(s.Example, &example); err == nil {
+			return example
+		}
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, definitionsPrefix)
+		def, ok := defs[name]
+		if !ok || visited[name] {
+			return map[string]interface{}{}
+		}
+		visited[name] = true
+		defer delete(visited, name)
+		return exampleOfSchema(def, defs, visited)
+	}
+	switch s.Type {
+	case "string":
+		if len(s.Enum) > 0 {
+			return s.Enum[0]
+		}
+		if s.Default != "" {
+			return s.Default
+		}
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		if s.Items == nil {
+			return []interface{}{}
+		}
+		if s.Items.inline != nil {
+			return []interface{}{exampleOfSchema(*s.Items.inline, defs, visited)}
+		}
+		return []interface{}{exampleOfSchema(openapiSchemaObject{schemaCore: schemaCore(*s.Items)}, defs, visited)}
+	}
+	obj := make(map[string]interface{})
+	if s.Properties != nil {
+		for _, prop := range *s.Properties {
+			if schema, ok := prop.Value.(openapiSchemaObject); ok {
+				obj[prop.Key] = exampleOfSchema(schema, defs, visited)
+			}
+		}
+	}
+	return obj
+}
+
+// encodePostmanCollection renders the OpenAPI document of the file as a
+// Postman collection named after the file.
+func encodePostmanCollection(file *wrapper) (*descriptor.ResponseFile, error) {
+	var formatted bytes.Buffer
+	enc := json.NewEncoder(&formatted)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(renderPostmanCollection(file.swagger)); err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(file.fileName, filepath.Ext(file.fileName))
+	return &descriptor.ResponseFile{
+		CodeGeneratorResponse_File: &pluginpb.CodeGeneratorResponse_File{
+			Name:    proto.String(base + ".postman_collection.json"),
+			Content: proto.String(formatted.String()),
+		},
+	}, nil
+}
@@ -0,0 +1,135 @@
+package genopenapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported values of the recursive_schema_mode flag.
+const (
+	// recursiveSchemaModeRef renders self-referential messages with cyclic $refs.
+	recursiveSchemaModeRef = "ref"
+	// recursiveSchemaModeUnroll renders self-referential messages as a chain of
+	// acyclic copies, expanded up to the configured depth.
+	recursiveSchemaModeUnroll = "unroll"
+)
+
+const definitionsPrefix = "#/definitions/"
+
+// unrollRecursiveDefinitions removes the cycles from the definitions by
+// replacing every reference to a recursive definition with a reference to a
+// copy one level deeper, e.g. exampleNode refers to exampleNodeDepth1 which
+// refers to exampleNodeDepth2. References at the last level are replaced with
+// a plain object schema.
+func unrollRecursiveDefinitions(d openapiDefinitionsObject, depth int) {
+	recursive := recursiveDefinitions(d)
+	if len(recursive) == 0 {
+		return
+	}
+	originals := make(map[string]openapiSchemaObject, len(recursive))
+	for name := range recursive {
+		originals[name] = d[name]
+	}
+	for name, schema := range originals {
+		d[name] = unrollSchema(schema, 0, depth, recursive)
+		for level := 1; level <= depth; level++ {
+			d[unrolledDefinitionName(name, level)] = unrollSchema(schema, level, depth, recursive)
+		}
+	}
+}
+
+func unrolledDefinitionName(name string, level int) string {
+	if level == 0 {
+		return name
+	}
+	return fmt.Sprintf("%sDepth%d", name, level)
+}
+
+// unrollSchema returns a copy of s, belonging to the given level, in which the
+// references to recursive definitions point to the next level.
+func unrollSchema(s openapiSchemaObject, level, depth int, recursive map[string]bool) openapiSchemaObject {
+	s.schemaCore = unrollSchemaCore(s.schemaCore, level, depth, recursive)
+	if s.AdditionalProperties != nil {
+		additional := unrollSchema(*s.AdditionalProperties, level, depth, recursive)
+		s.AdditionalProperties = &additional
+	}
+	if s.Properties != nil {
+		props := make(openapiSchemaObjectProperties, len(*s.Properties))
+		for i, kv := range *s.Properties {
+			if v, ok := kv.Value.(openapiSchemaObject); ok {
+				kv.Value = unrollSchema(v, level, depth, recursive)
+			}
+			props[i] = kv
+		}
+		s.Properties = &props
+	}
+	return s
+}
+
+func unrollSchemaCore(c schemaCore, level, depth int, recursive map[string]bool) schemaCore {
+	if name := strings.TrimPrefix(c.Ref, definitionsPrefix); c.Ref != name && recursive[name] {
+		if level >= depth {
+			return schemaCore{Type: "object"}
+		}
+		c.Ref = definitionsPrefix + unrolledDefinitionName(name, level+1)
+	}
+	if c.Items != nil {
+		items := openapiItemsObject(unrollSchemaCore(schemaCore(*c.Items), level, depth, recursive))
+		c.Items = &items
+	}
+	return c
+}
+
+// recursiveDefinitions returns the names of the definitions which refer to
+// themselves, directly or through other definitions.
+func recursiveDefinitions(d openapiDefinitionsObject) map[string]bool {
+	graph := make(map[string][]string, len(d))
+	for name, schema := range d {
+		graph[name] = schemaRefs(schema, nil)
+	}
+	recursive := make(map[string]bool)
+	for name := range graph {
+		seen := make(map[string]bool)
+		stack := append([]string(nil), graph[name]...)
+		for len(stack) > 0 {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if next == name {
+				recursive[name] = true
+				break
+			}
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			stack = append(stack, graph[next]...)
+		}
+	}
+	return recursive
+}
+
+// schemaRefs appends the names of the definitions referenced by s to refs.
+func schemaRefs(s openapiSchemaObject, refs []string) []string {
+	refs = schemaCoreRefs(s.schemaCore, refs)
+	if s.AdditionalProperties != nil {
+		refs = schemaRefs(*s.AdditionalProperties, refs)
+	}
+	if s.Properties != nil {
+		for _, kv := range *s.Properties {
+			if v, ok := kv.Value.(openapiSchemaObject); ok {
+				refs = schemaRefs(v, refs)
+			}
+		}
+	}
+	return refs
+}
+
+func schemaCoreRefs(c schemaCore, refs []string) []string {
+	if strings.HasPrefix(c.Ref, definitionsPrefix) {
+		refs = append(refs, strings.TrimPrefix(c.Ref, definitionsPrefix))
+	}
+	if c.Items != nil {
+		refs = schemaCoreRefs(schemaCore(*c.Items), refs)
+	}
+	return refs
+}
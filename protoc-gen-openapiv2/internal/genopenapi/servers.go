@@ -0,0 +1,91 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+// serverVariablePattern matches the '{variable}' placeholders of server URLs.
+var serverVariablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// renderServers converts the servers configured in the registry into OpenAPI 3
+// server objects. Environment variables in the URLs are expanded, placeholders
+// are kept and documented with their default values.
+func renderServers(reg *descriptor.Registry) ([]openapiServerObject, error) {
+	var servers []openapiServerObject
+	for _, server := range reg.GetServers() {
+		s := openapiServerObject{
+			URL:         os.ExpandEnv(server.URL),
+			Description: server.Name,
+		}
+		for _, match := range serverVariablePattern.FindAllStringSubmatch(s.URL, -1) {
+			name := match[1]
+			def, ok := reg.GetServerVariables()[name]
+			if !ok {
+				return nil, fmt.Errorf("server %q: no default value for variable %q", server.Name, name)
+			}
+			if s.Variables == nil {
+				s.Variables = make(map[string]openapiServerVariableObject)
+			}
+			s.Variables[name] = openapiServerVariableObject{Default: def}
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// resolveServerURL returns the URL of the server with every placeholder
+// replaced by its default value.
+func resolveServerURL(s openapiServerObject) (*url.URL, error) {
+	resolved := serverVariablePattern.ReplaceAllStringFunc(s.URL, func(placeholder string) string {
+		return s.Variables[placeholder[1:len(placeholder)-1]].Default
+	})
+	u, err := url.Parse(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("server %q: %v", s.Description, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("server %q: URL %q has no host", s.Description, resolved)
+	}
+	return u, nil
+}
+
+// applyServer sets the host, base path and scheme of the OpenAPI v2 document
+// to those of the server.
+func applyServer(swagger *openapiSwaggerObject, s openapiServerObject) error {
+	u, err := resolveServerURL(s)
+	if err != nil {
+		return err
+	}
+	swagger.Host = u.Host
+	swagger.BasePath = u.Path
+	if u.Scheme != "" {
+		swagger.Schemes = []string{u.Scheme}
+	}
+	return nil
+}
+
+// addServers documents the servers of the API in the x-servers extension of
+// the document. If there is a single server and the document has no host yet,
+// the host, base path and scheme are taken from it too.
+func addServers(swagger *openapiSwaggerObject, servers []openapiServerObject) error {
+	if len(servers) == 0 {
+		return nil
+	}
+	if len(servers) == 1 && swagger.Host == "" {
+		if err := applyServer(swagger, servers[0]); err != nil {
+			return err
+		}
+	}
+	value, err := json.Marshal(servers)
+	if err != nil {
+		return err
+	}
+	swagger.extensions = append(swagger.extensions, extension{key: "x-servers", value: value})
+	return nil
+}
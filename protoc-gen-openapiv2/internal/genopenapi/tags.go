@@ -0,0 +1,110 @@
+package genopenapi
+
+import (
+	"encoding/json"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+// serviceTag returns the tag of the operations of the service.
+func serviceTag(svc *descriptor.Service, reg *descriptor.Registry) string {
+	pkg := svc.File.GetPackage()
+	if reg.GetTagBy() == "package" && pkg != "" {
+		return pkg
+	}
+	tag := svc.GetName()
+	if pkg != "" && reg.IsIncludePackageInTags() {
+		tag = pkg + "." + tag
+	}
+	return tag
+}
+
+// renderTags returns the tags of the services, with the description and
+// external docs of their openapiv2_tag option, in the order configured with
+// tag_order followed by the order the services are declared in.
+//
+// Services without bindings have no operations and are left out.
+//
+// Tags are only rendered if a service has an openapiv2_tag option or the order
+// or groups of tags are configured, so that the output is unchanged otherwise.
+func renderTags(services []*descriptor.Service, reg *descriptor.Registry) ([]openapiTagObject, error) {
+	render := len(reg.GetTagOrder()) > 0 || len(reg.GetTagGroups()) > 0
+	var tags []openapiTagObject
+	index := make(map[string]int)
+	for _, svc := range services {
+		if !hasBindings(svc) {
+			continue
+		}
+		name := serviceTag(svc, reg)
+		i, ok := index[name]
+		if !ok {
+			i = len(tags)
+			index[name] = i
+			tags = append(tags, openapiTagObject{Name: name})
+		}
+		opts, err := getServiceOpenAPIOption(reg, svc)
+		if err != nil {
+			return nil, err
+		}
+		if opts == nil {
+			continue
+		}
+		render = true
+		if tags[i].Description == "" {
+			tags[i].Description = opts.Description
+		}
+		if tags[i].ExternalDocs == nil {
+			tags[i].ExternalDocs = protoExternalDocumentationToOpenAPIExternalDocumentation(opts.ExternalDocs, reg, svc)
+		}
+	}
+	if !render {
+		return nil, nil
+	}
+
+	ordered := make([]openapiTagObject, 0, len(tags))
+	for _, name := range reg.GetTagOrder() {
+		if i, ok := index[name]; ok && i >= 0 {
+			ordered = append(ordered, tags[i])
+			index[name] = -1
+		}
+	}
+	for _, tag := range tags {
+		if index[tag.Name] >= 0 {
+			ordered = append(ordered, tag)
+		}
+	}
+	return ordered, nil
+}
+
+func hasBindings(svc *descriptor.Service) bool {
+	for _, meth := range svc.Methods {
+		if len(meth.Bindings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addTags sets the tags of the document and the x-tagGroups extension.
+func addTags(swagger *openapiSwaggerObject, services []*descriptor.Service, reg *descriptor.Registry) error {
+	tags, err := renderTags(services, reg)
+	if err != nil {
+		return err
+	}
+	swagger.Tags = tags
+
+	groups := reg.GetTagGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+	tagGroups := make([]openapiTagGroupObject, 0, len(groups))
+	for _, group := range groups {
+		tagGroups = append(tagGroups, openapiTagGroupObject{Name: group.Name, Tags: group.Tags})
+	}
+	value, err := json.Marshal(tagGroups)
+	if err != nil {
+		return err
+	}
+	swagger.extensions = append(swagger.extensions, extension{key: "x-tagGroups", value: value})
+	return nil
+}
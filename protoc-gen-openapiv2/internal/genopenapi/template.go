@@ -19,6 +19,7 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
 	openapi_options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -157,6 +158,10 @@ func nestedQueryParams(message *descriptor.Message, field *descriptor.Field, pre
 			}
 		}
 	}
+	// output only fields are not accepted as input
+	if hasFieldBehavior(field, annotations.FieldBehavior_OUTPUT_ONLY) {
+		return nil, nil
+	}
 	schema := schemaOfField(field, reg, nil)
 	fieldType := field.GetTypeName()
 	if message.File != nil {
@@ -181,7 +186,7 @@ func nestedQueryParams(message *descriptor.Message, field *descriptor.Field, pre
 		}
 
 		// verify if the field is required
-		required := false
+		required := hasFieldBehavior(field, annotations.FieldBehavior_REQUIRED)
 		for _, fieldName := range schema.Required {
 			if fieldName == field.GetName() {
 				required = true
@@ -408,6 +413,11 @@ func renderMessagesAsDefinition(messages messageMap, d openapiDefinitionsObject,
 				panic(err)
 			}
 
+			if f.GetOptions().GetDeprecated() {
+				fieldValue.Deprecated = true
+				fieldValue.Description = appendDeprecationNote(fieldValue.Description, reg)
+			}
+
 			kv := keyVal{Value: fieldValue}
 			if reg.GetUseJSONNamesForFields() {
 				kv.Key = f.GetJsonName()
@@ -418,11 +428,29 @@ func renderMessagesAsDefinition(messages messageMap, d openapiDefinitionsObject,
 				schema.Properties = &openapiSchemaObjectProperties{}
 			}
 			*schema.Properties = append(*schema.Properties, kv)
+
+			if reg.GetValidationRulesPrecedence() != validationPrecedenceIgnore {
+				if c := fieldConstraintsFromOptions(f.GetOptions()); c != nil && c.required && !isRequired(schema.Required, kv.Key) {
+					schema.Required = append(schema.Required, kv.Key)
+				}
+			}
+			if hasFieldBehavior(f, annotations.FieldBehavior_REQUIRED) && !isRequired(schema.Required, kv.Key) {
+				schema.Required = append(schema.Required, kv.Key)
+			}
 		}
 		d[swgName] = schema
 	}
 }
 
+func isRequired(required []string, name string) bool {
+	for _, r := range required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
 // schemaOfField returns a OpenAPI Schema Object for a protobuf field.
 func schemaOfField(f *descriptor.Field, reg *descriptor.Registry, refs refMap) openapiSchemaObject {
 	const (
@@ -450,8 +478,8 @@ func schemaOfField(f *descriptor.Field, reg *descriptor.Registry, refs refMap) o
 
 	switch ft := fd.GetType(); ft {
 	case descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
-		if wktSchema, ok := wktSchemas[fd.GetTypeName()]; ok {
-			core = wktSchema
+		if schema, ok := wktSchema(fd.GetTypeName(), reg); ok {
+			core = schema
 
 			if fd.GetTypeName() == ".google.protobuf.Empty" {
 				props = &openapiSchemaObjectProperties{}
@@ -498,6 +526,10 @@ func schemaOfField(f *descriptor.Field, reg *descriptor.Registry, refs refMap) o
 		ret = openapiSchemaObject{
 			schemaCore: core,
 			Properties: props,
+			Pattern:    wktPattern(fd.GetTypeName(), reg),
+		}
+		if f.GetProto3Optional() {
+			ret.XNullable = true
 		}
 	}
 
@@ -505,6 +537,13 @@ func schemaOfField(f *descriptor.Field, reg *descriptor.Registry, refs refMap) o
 		updateswaggerObjectFromJSONSchema(&ret, j, reg, f)
 	}
 
+	if precedence := reg.GetValidationRulesPrecedence(); precedence != validationPrecedenceIgnore {
+		if c := fieldConstraintsFromOptions(f.GetOptions()); c != nil {
+			c.apply(&ret, precedence == validationPrecedenceValidate)
+		}
+	}
+	applyFieldBehavior(&ret, f)
+
 	return ret
 }
 
@@ -774,6 +813,14 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 		}
 		for methIdx, meth := range svc.Methods {
 			for bIdx, b := range meth.Bindings {
+				bindingOpts, err := b.OpenAPIOptions()
+				if err != nil {
+					return err
+				}
+				if bindingOpts != nil && bindingOpts.Exclude {
+					continue
+				}
+
 				// Iterate over all the OpenAPI parameters
 				parameters := openapiParametersObject{}
 				for _, parameter := range b.PathParams {
@@ -871,7 +918,7 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 							schemaCore: schemaCore{},
 						}
 
-						wknSchemaCore, isWkn := wktSchemas[meth.RequestType.FQMN()]
+						wknSchemaCore, isWkn := wktSchema(meth.RequestType.FQMN(), reg)
 						if !isWkn {
 							err := schema.setRefFromFQN(meth.RequestType.FQMN(), reg)
 							if err != nil {
@@ -919,6 +966,18 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 					}
 					parameters = append(parameters, queryParams...)
 				}
+				if bindingOpts != nil {
+					for _, header := range bindingOpts.Headers {
+						parameters = append(parameters, openapiParameterObject{
+							Name:        header.Name,
+							Description: header.Description,
+							In:          "header",
+							Required:    header.Required,
+							Type:        header.Type,
+							Format:      header.Format,
+						})
+					}
+				}
 
 				pathItemObject, ok := paths[templateToOpenAPIPath(b.PathTmpl.Template, reg, meth.RequestType.Fields, msgs)]
 				if !ok {
@@ -938,7 +997,7 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 					// empty; it's overly verbose.
 					// schema.Properties{} renders it as
 					// well, without a definition
-					wknSchemaCore, isWkn := wktSchemas[meth.ResponseType.FQMN()]
+					wknSchemaCore, isWkn := wktSchema(meth.ResponseType.FQMN(), reg)
 					if !isWkn {
 						err := responseSchema.setRefFromFQN(meth.ResponseType.FQMN(), reg)
 						if err != nil {
@@ -964,7 +1023,6 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 				}
 				if meth.GetServerStreaming() {
 					desc += "(streaming responses)"
-					responseSchema.Type = "object"
 					swgRef, _ := fullyQualifiedNameToOpenAPIName(meth.ResponseType.FQMN(), reg)
 					responseSchema.Title = "Stream result of " + swgRef
 
@@ -972,9 +1030,8 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 						keyVal{
 							Key: "result",
 							Value: openapiSchemaObject{
-								schemaCore: schemaCore{
-									Ref: responseSchema.Ref,
-								},
+								schemaCore: responseSchema.schemaCore,
+								Properties: responseSchema.Properties,
 							},
 						},
 					}
@@ -988,14 +1045,11 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 							},
 						})
 					}
+					responseSchema.schemaCore = schemaCore{Type: "object"}
 					responseSchema.Properties = &props
-					responseSchema.Ref = ""
 				}
 
-				tag := svc.GetName()
-				if pkg := svc.File.GetPackage(); pkg != "" && reg.IsIncludePackageInTags() {
-					tag = pkg + "." + tag
-				}
+				tag := serviceTag(svc, reg)
 
 				operationObject := &openapiOperationObject{
 					Tags:       []string{tag},
@@ -1048,7 +1102,6 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 						panic(err)
 					}
 					operationObject.ExternalDocs = protoExternalDocumentationToOpenAPIExternalDocumentation(opts.ExternalDocs, reg, meth)
-					// TODO(ivucica): this would be better supported by looking whether the method is deprecated in the proto file
 					operationObject.Deprecated = opts.Deprecated
 
 					if opts.Summary != "" {
@@ -1119,6 +1172,11 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 						operationObject.extensions = exts
 					}
 
+					if len(opts.Consumes) > 0 {
+						operationObject.Consumes = make([]string, len(opts.Consumes))
+						copy(operationObject.Consumes, opts.Consumes)
+					}
+
 					if len(opts.Produces) > 0 {
 						operationObject.Produces = make([]string, len(opts.Produces))
 						copy(operationObject.Produces, opts.Produces)
@@ -1126,6 +1184,24 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 
 					// TODO(ivucica): add remaining fields of operation object
 				}
+				if meth.GetOptions().GetDeprecated() {
+					operationObject.Deprecated = true
+				}
+				if bindingOpts != nil {
+					applyOperationOverride(operationObject, bindingOpts.Override)
+					if err := addResponseHeaders(operationObject, bindingOpts.ResponseHeaders); err != nil {
+						return fmt.Errorf("%s: %v", meth.FQMN(), err)
+					}
+				}
+				if operationObject.Deprecated {
+					operationObject.Description = appendDeprecationNote(operationObject.Description, reg)
+				}
+
+				if format := reg.GetStreamingFormat(); format != "" && (meth.GetServerStreaming() || meth.GetClientStreaming()) {
+					if err := addStreamingExtension(operationObject, meth, format); err != nil {
+						return err
+					}
+				}
 
 				switch b.HTTPMethod {
 				case "DELETE":
@@ -1148,6 +1224,42 @@ func renderServices(services []*descriptor.Service, paths openapiPathsObject, re
 	return nil
 }
 
+// streamingContentTypes maps streaming formats to the content type of the streamed response.
+var streamingContentTypes = map[string]string{
+	"ndjson": "application/x-ndjson",
+	"sse":    "text/event-stream",
+}
+
+// addStreamingExtension documents the streaming behavior of meth on the operation
+// with an x-streaming extension. Server-streaming operations additionally produce
+// the content type of the given streaming format unless overridden by method options.
+func addStreamingExtension(operationObject *openapiOperationObject, meth *descriptor.Method, format string) error {
+	contentType := streamingContentTypes[format]
+	if meth.GetServerStreaming() && len(operationObject.Produces) == 0 {
+		operationObject.Produces = []string{contentType}
+	}
+	for _, ext := range operationObject.extensions {
+		if ext.key == "x-streaming" {
+			// Explicitly set through method options.
+			return nil
+		}
+	}
+	streaming := openapiStreamingExtension{
+		ClientStreaming: meth.GetClientStreaming(),
+		ServerStreaming: meth.GetServerStreaming(),
+		Format:          format,
+	}
+	if meth.GetServerStreaming() {
+		streaming.ContentType = contentType
+	}
+	value, err := json.Marshal(streaming)
+	if err != nil {
+		return err
+	}
+	operationObject.extensions = append(operationObject.extensions, extension{key: "x-streaming", value: value})
+	return nil
+}
+
 // This function is called with a param which contains the entire definition of a method.
 func applyTemplate(p param) (*openapiSwaggerObject, error) {
 	// Create the basic template object. This is the object that everything is
@@ -1164,6 +1276,14 @@ func applyTemplate(p param) (*openapiSwaggerObject, error) {
 			Version: "version not set",
 		},
 	}
+	if consumes := p.reg.GetConsumes(); len(consumes) > 0 {
+		s.Consumes = make([]string, len(consumes))
+		copy(s.Consumes, consumes)
+	}
+	if produces := p.reg.GetProduces(); len(produces) > 0 {
+		s.Produces = make([]string, len(produces))
+		copy(s.Produces, produces)
+	}
 
 	// Loops through all the services and their exposed GET/POST/PUT/DELETE definitions
 	// and create entries for all of them.
@@ -1413,13 +1533,73 @@ func applyTemplate(p param) (*openapiSwaggerObject, error) {
 		// should be added here, once supported in the proto.
 	}
 
+	servers, err := renderServers(p.reg)
+	if err != nil {
+		return nil, err
+	}
+	if err := addServers(&s, servers); err != nil {
+		return nil, err
+	}
+
+	if err := addTags(&s, p.Services, p.reg); err != nil {
+		return nil, err
+	}
+
 	// Finally add any references added by users that aren't
 	// otherwise rendered.
 	addCustomRefs(s.Definitions, p.reg, customRefs)
 
+	if p.reg.GetRecursiveSchemaMode() == recursiveSchemaModeUnroll {
+		unrollRecursiveDefinitions(s.Definitions, p.reg.GetRecursiveSchemaDepth())
+	}
+	if p.reg.GetInlineRefs() {
+		inlineRefs(&s)
+	}
+
 	return &s, nil
 }
 
+// applyOperationOverride replaces the fields of the operation set in override.
+func applyOperationOverride(operationObject *openapiOperationObject, override descriptor.OpenAPIOperationOverride) {
+	if override.Summary != "" {
+		operationObject.Summary = override.Summary
+	}
+	if override.Description != "" {
+		operationObject.Description = override.Description
+	}
+	if override.OperationID != "" {
+		operationObject.OperationID = override.OperationID
+	}
+	if len(override.Tags) > 0 {
+		operationObject.Tags = make([]string, len(override.Tags))
+		copy(operationObject.Tags, override.Tags)
+	}
+	if override.Deprecated != nil {
+		operationObject.Deprecated = *override.Deprecated
+	}
+}
+
+// addResponseHeaders documents the headers in the responses of the operation
+// they are sent with. Those responses must be documented already.
+func addResponseHeaders(operationObject *openapiOperationObject, headers []descriptor.OpenAPIResponseHeader) error {
+	for _, header := range headers {
+		resp, ok := operationObject.Responses[header.Status]
+		if !ok {
+			return fmt.Errorf("response header %q is sent with undocumented %s responses", header.Name, header.Status)
+		}
+		if resp.Headers == nil {
+			resp.Headers = make(openapiHeadersObject)
+		}
+		resp.Headers[header.Name] = openapiHeaderObject{
+			Description: header.Description,
+			Type:        header.Type,
+			Format:      header.Format,
+		}
+		operationObject.Responses[header.Status] = resp
+	}
+	return nil
+}
+
 func processExtensions(inputExts map[string]*structpb.Value) ([]extension, error) {
 	exts := []extension{}
 	for k, v := range inputExts {
@@ -1540,6 +1720,9 @@ func enumValueProtoComments(reg *descriptor.Registry, enum *descriptor.Enum) str
 			name = strconv.Itoa(int(value.GetNumber()))
 		}
 		str := protoComments(reg, enum.File, enum.Outers, "EnumType", int32(enum.Index), protoPath, int32(idx))
+		if value.GetOptions().GetDeprecated() {
+			str = appendDeprecationNote(str, reg)
+		}
 		if str != "" {
 			comments = append(comments, name+": "+str)
 		}
@@ -1550,6 +1733,19 @@ func enumValueProtoComments(reg *descriptor.Registry, enum *descriptor.Enum) str
 	return ""
 }
 
+// appendDeprecationNote appends the configured deprecation note, if any, to
+// the description of a deprecated element.
+func appendDeprecationNote(description string, reg *descriptor.Registry) string {
+	note := reg.GetDeprecationNote()
+	if note == "" {
+		return description
+	}
+	if description == "" {
+		return note
+	}
+	return description + "\n\n" + note
+}
+
 func protoComments(reg *descriptor.Registry, file *descriptor.File, outers []string, typeName string, typeIndex int32, fieldPaths ...int32) string {
 	if file.SourceCodeInfo == nil {
 		fmt.Fprintln(os.Stderr, "descriptor.File should not contain nil SourceCodeInfo")
@@ -1742,6 +1938,23 @@ func extractOperationOptionFromMethodDescriptor(meth *descriptorpb.MethodDescrip
 	return opts, nil
 }
 
+// extractTagOptionFromServiceDescriptor extracts the tag of type
+// openapi_options.Tag from a given proto service's descriptor.
+func extractTagOptionFromServiceDescriptor(svc *descriptorpb.ServiceDescriptorProto) (*openapi_options.Tag, error) {
+	if svc.Options == nil {
+		return nil, nil
+	}
+	if !proto.HasExtension(svc.Options, openapi_options.E_Openapiv2Tag) {
+		return nil, nil
+	}
+	ext := proto.GetExtension(svc.Options, openapi_options.E_Openapiv2Tag)
+	opts, ok := ext.(*openapi_options.Tag)
+	if !ok {
+		return nil, fmt.Errorf("extension is %T; want a Tag", ext)
+	}
+	return opts, nil
+}
+
 // extractSchemaOptionFromMessageDescriptor extracts the message of type
 // openapi_options.Schema from a given proto message's descriptor.
 func extractSchemaOptionFromMessageDescriptor(msg *descriptorpb.DescriptorProto) (*openapi_options.Schema, error) {
@@ -1806,6 +2019,21 @@ func getMethodOpenAPIOption(reg *descriptor.Registry, meth *descriptor.Method) (
 	return opts, nil
 }
 
+func getServiceOpenAPIOption(reg *descriptor.Registry, svc *descriptor.Service) (*openapi_options.Tag, error) {
+	opts, err := extractTagOptionFromServiceDescriptor(svc.ServiceDescriptorProto)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		return opts, nil
+	}
+	opts, ok := reg.GetOpenAPIServiceOption(svc.FQSN())
+	if !ok {
+		return nil, nil
+	}
+	return opts, nil
+}
+
 func getMessageOpenAPIOption(reg *descriptor.Registry, msg *descriptor.Message) (*openapi_options.Schema, error) {
 	opts, err := extractSchemaOptionFromMessageDescriptor(msg.DescriptorProto)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -14,6 +15,8 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
 	openapi_options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -2405,6 +2408,23 @@ func TestSchemaOfField(t *testing.T) {
 				},
 			},
 		},
+		{
+			field: &descriptor.Field{
+				FieldDescriptorProto: &descriptorpb.FieldDescriptorProto{
+					Name:           proto.String("optional_field"),
+					Type:           descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					OneofIndex:     proto.Int32(0),
+					Proto3Optional: proto.Bool(true),
+				},
+			},
+			refs: make(refMap),
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{
+					Type:      "string",
+					XNullable: true,
+				},
+			},
+		},
 		{
 			field: &descriptor.Field{
 				FieldDescriptorProto: &descriptorpb.FieldDescriptorProto{
@@ -3640,3 +3660,1391 @@ func Test_getReservedJsonName(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderJSONSchemas(t *testing.T) {
+	nestedDesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("Nested"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("amount"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				JsonName: proto.String("amount"),
+			},
+		},
+	}
+	reqDesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("nested"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".example.Nested"),
+				JsonName: proto.String("nested"),
+			},
+		},
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("Nested"),
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	nested := &descriptor.Message{DescriptorProto: nestedDesc}
+	req := &descriptor.Message{DescriptorProto: reqDesc}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{reqDesc, nestedDesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{req, nested},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           req,
+						ResponseType:          nested,
+					},
+				},
+			},
+		},
+	}
+	reg := descriptor.NewRegistry()
+	fileCL := crossLinkFixture(&file)
+	if err := reg.Load(reqFromFile(fileCL)); err != nil {
+		t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+	}
+	loaded, err := reg.LookupFile(file.GetName())
+	if err != nil {
+		t.Fatalf("reg.LookupFile(%q) failed with %v; want success", file.GetName(), err)
+	}
+	files, err := renderJSONSchemas(loaded, reg)
+	if err != nil {
+		t.Fatalf("renderJSONSchemas(%#v) failed with %v; want success", file, err)
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.GetName())
+	}
+	if want := []string{"example.exampleExampleMessage.schema.json", "example.exampleNested.schema.json"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("renderJSONSchemas(%#v) emitted %v; want %v", file, names, want)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(files[0].GetContent()), &doc); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed with %v; want success", files[0].GetContent(), err)
+	}
+	if want, is := jsonSchemaDialect, doc["$schema"]; is != want {
+		t.Errorf("$schema = %v; want %v", is, want)
+	}
+	if want, is := "#/$defs/exampleExampleMessage", doc["$ref"]; is != want {
+		t.Errorf("$ref = %v; want %v", is, want)
+	}
+	defs := doc["$defs"].(map[string]interface{})
+	if _, ok := defs["exampleNested"]; !ok {
+		t.Errorf("$defs = %v; want the nested message to be included", defs)
+	}
+	props := defs["exampleExampleMessage"].(map[string]interface{})["properties"].(map[string]interface{})
+	if want, is := "#/$defs/exampleNested", props["nested"].(map[string]interface{})["$ref"]; is != want {
+		t.Errorf("properties.nested.$ref = %v; want %v", is, want)
+	}
+}
+
+func TestOpenAPISchemaToJSONSchema(t *testing.T) {
+	s := openapiSchemaObject{
+		schemaCore: schemaCore{
+			Type:    "integer",
+			Format:  "int32",
+			Example: json.RawMessage(`3`),
+		},
+		Maximum:          10,
+		ExclusiveMaximum: true,
+		ExclusiveMinimum: true,
+	}
+	got, err := openAPISchemaToJSONSchema(s)
+	if err != nil {
+		t.Fatalf("openAPISchemaToJSONSchema(%#v) failed with %v; want success", s, err)
+	}
+	want := map[string]interface{}{
+		"type":             "integer",
+		"format":           "int32",
+		"examples":         []interface{}{float64(3)},
+		"exclusiveMaximum": float64(10),
+		"exclusiveMinimum": float64(0),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("openAPISchemaToJSONSchema(%#v) = %s", s, diff)
+	}
+}
+
+func TestApplyTemplateStreamingFormat(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String("Echo"),
+		InputType:       proto.String("ExampleMessage"),
+		OutputType:      proto.String("ExampleMessage"),
+		ServerStreaming: proto.Bool(true),
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings: []*descriptor.Binding{
+							{
+								HTTPMethod: "GET",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{0, 0},
+									Template: "/v1/echo",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, spec := range []struct {
+		format          string
+		wantProduces    []string
+		wantExtension   string
+		wantNoExtension bool
+	}{
+		{
+			format:          "",
+			wantNoExtension: true,
+		},
+		{
+			format:        "ndjson",
+			wantProduces:  []string{"application/x-ndjson"},
+			wantExtension: `{"clientStreaming":false,"serverStreaming":true,"format":"ndjson","contentType":"application/x-ndjson"}`,
+		},
+		{
+			format:        "sse",
+			wantProduces:  []string{"text/event-stream"},
+			wantExtension: `{"clientStreaming":false,"serverStreaming":true,"format":"sse","contentType":"text/event-stream"}`,
+		},
+	} {
+		t.Run(spec.format, func(t *testing.T) {
+			reg := descriptor.NewRegistry()
+			if err := AddErrorDefs(reg); err != nil {
+				t.Fatalf("AddErrorDefs(%#v) failed with %v; want success", reg, err)
+			}
+			if err := reg.SetStreamingFormat(spec.format); err != nil {
+				t.Fatalf("reg.SetStreamingFormat(%q) failed with %v; want success", spec.format, err)
+			}
+			fileCL := crossLinkFixture(&file)
+			if err := reg.Load(reqFromFile(fileCL)); err != nil {
+				t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+			}
+			result, err := applyTemplate(param{File: fileCL, reg: reg})
+			if err != nil {
+				t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+			}
+			op := result.Paths["/v1/echo"].Get
+			if !reflect.DeepEqual(op.Produces, spec.wantProduces) {
+				t.Errorf("op.Produces = %v; want %v", op.Produces, spec.wantProduces)
+			}
+			if spec.wantNoExtension {
+				if len(op.extensions) != 0 {
+					t.Errorf("op.extensions = %v; want none", op.extensions)
+				}
+			} else if len(op.extensions) != 1 || op.extensions[0].key != "x-streaming" || string(op.extensions[0].value) != spec.wantExtension {
+				t.Errorf("op.extensions = %v; want x-streaming: %s", op.extensions, spec.wantExtension)
+			}
+
+			props := *op.Responses["200"].Schema.Properties
+			if want, got := "#/definitions/exampleExampleMessage", props[0].Value.(openapiSchemaObject).Ref; got != want {
+				t.Errorf("chunk result ref = %q; want %q", got, want)
+			}
+			if want, got := "#/definitions/rpcStatus", props[1].Value.(openapiSchemaObject).Ref; got != want {
+				t.Errorf("chunk error ref = %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSchemaOfFieldValidationRules(t *testing.T) {
+	appendMessage := func(b []byte, num protowire.Number, msg []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg)
+	}
+	appendVarint := func(b []byte, num protowire.Number, v uint64) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+	withRules := func(ext protowire.Number, rules []byte) *descriptorpb.FieldOptions {
+		opts := &descriptorpb.FieldOptions{}
+		opts.ProtoReflect().SetUnknown(appendMessage(nil, ext, rules))
+		return opts
+	}
+
+	var stringRules []byte
+	stringRules = appendVarint(stringRules, stringMinLenNumber, 3)
+	stringRules = appendVarint(stringRules, stringMaxLenNumber, 10)
+	stringRules = appendVarint(stringRules, stringUUIDNumber, 1)
+	stringRules = appendMessage(stringRules, stringPatternNumber, []byte("^[a-z]+$"))
+	pgvStringRules := appendMessage(nil, rulesStringNumber, stringRules)
+
+	var int32Rules []byte
+	int32Rules = appendVarint(int32Rules, numericGtNumber, 1)
+	int32Rules = appendVarint(int32Rules, numericLteNumber, 100)
+	protovalidateInt32Rules := appendMessage(nil, rulesInt32Number, int32Rules)
+	protovalidateInt32Rules = appendVarint(protovalidateInt32Rules, rulesRequiredNumber, 1)
+
+	var repeatedRules []byte
+	repeatedRules = appendVarint(repeatedRules, repeatedMinItemsNumber, 1)
+	repeatedRules = appendVarint(repeatedRules, repeatedUniqueNumber, 1)
+	pgvRepeatedRules := appendMessage(nil, rulesRepeatedNumber, repeatedRules)
+
+	for _, test := range []struct {
+		name       string
+		field      *descriptorpb.FieldDescriptorProto
+		precedence string
+		jsonSchema *openapi_options.JSONSchema
+		expected   openapiSchemaObject
+	}{
+		{
+			name: "pgv string rules",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("id"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: withRules(pgvFieldRulesNumber, pgvStringRules),
+			},
+			precedence: "openapi",
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{Type: "string", Format: "uuid"},
+				MinLength:  3,
+				MaxLength:  10,
+				Pattern:    "^[a-z]+$",
+			},
+		},
+		{
+			name: "protovalidate numeric rules",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("count"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				Options: withRules(protovalidateFieldConstraintsNumber, protovalidateInt32Rules),
+			},
+			precedence: "openapi",
+			expected: openapiSchemaObject{
+				schemaCore:       schemaCore{Type: "integer", Format: "int32"},
+				Minimum:          1,
+				ExclusiveMinimum: true,
+				Maximum:          100,
+			},
+		},
+		{
+			name: "pgv repeated rules",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("tags"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Options: withRules(pgvFieldRulesNumber, pgvRepeatedRules),
+			},
+			precedence: "openapi",
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{
+					Type:  "array",
+					Items: &openapiItemsObject{Type: "string"},
+				},
+				MinItems:    1,
+				UniqueItems: true,
+			},
+		},
+		{
+			name: "openapi options win",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("id"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: withRules(pgvFieldRulesNumber, pgvStringRules),
+			},
+			precedence: "openapi",
+			jsonSchema: &openapi_options.JSONSchema{MaxLength: 5},
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{Type: "string", Format: "uuid"},
+				MinLength:  3,
+				MaxLength:  5,
+				Pattern:    "^[a-z]+$",
+			},
+		},
+		{
+			name: "validation rules win",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("id"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: withRules(pgvFieldRulesNumber, pgvStringRules),
+			},
+			precedence: "validate",
+			jsonSchema: &openapi_options.JSONSchema{MaxLength: 5},
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{Type: "string", Format: "uuid"},
+				MinLength:  3,
+				MaxLength:  10,
+				Pattern:    "^[a-z]+$",
+			},
+		},
+		{
+			name: "validation rules ignored",
+			field: &descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("id"),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: withRules(pgvFieldRulesNumber, pgvStringRules),
+			},
+			precedence: "ignore",
+			expected: openapiSchemaObject{
+				schemaCore: schemaCore{Type: "string"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			reg := descriptor.NewRegistry()
+			if err := reg.SetValidationRulesPrecedence(test.precedence); err != nil {
+				t.Fatalf("reg.SetValidationRulesPrecedence(%q) failed with %v; want success", test.precedence, err)
+			}
+			field := test.field
+			if test.jsonSchema != nil {
+				field = proto.Clone(field).(*descriptorpb.FieldDescriptorProto)
+				proto.SetExtension(field.Options, openapi_options.E_Openapiv2Field, test.jsonSchema)
+			}
+			msg := &descriptor.Message{
+				DescriptorProto: &descriptorpb.DescriptorProto{Name: proto.String("ExampleMessage")},
+				File: &descriptor.File{
+					FileDescriptorProto: &descriptorpb.FileDescriptorProto{Package: proto.String("example")},
+				},
+			}
+			got := schemaOfField(&descriptor.Field{Message: msg, FieldDescriptorProto: field}, reg, nil)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Expected schemaOfField(%v) = %v, actual: %v", field, test.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyTemplateMediaTypes(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("ExampleMessage"),
+		Options:    &descriptorpb.MethodOptions{},
+	}
+	proto.SetExtension(meth.Options, openapi_options.E_Openapiv2Operation, &openapi_options.Operation{
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Produces: []string{"text/csv"},
+	})
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings: []*descriptor.Binding{
+							{
+								HTTPMethod: "POST",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{0, 0},
+									Template: "/v1/echo",
+								},
+								Body: &descriptor.Body{FieldPath: nil},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	reg := descriptor.NewRegistry()
+	reg.SetConsumes([]string{"application/json", "application/x-protobuf"})
+	reg.SetProduces([]string{"application/json", "application/x-protobuf"})
+	fileCL := crossLinkFixture(&file)
+	if err := reg.Load(reqFromFile(fileCL)); err != nil {
+		t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+	}
+	result, err := applyTemplate(param{File: fileCL, reg: reg})
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+	if want, got := []string{"application/json", "application/x-protobuf"}, result.Consumes; !reflect.DeepEqual(got, want) {
+		t.Errorf("applyTemplate(%#v).Consumes = %v; want %v", file, got, want)
+	}
+	if want, got := []string{"application/json", "application/x-protobuf"}, result.Produces; !reflect.DeepEqual(got, want) {
+		t.Errorf("applyTemplate(%#v).Produces = %v; want %v", file, got, want)
+	}
+	op := result.Paths["/v1/echo"].Post
+	if want, got := []string{"application/x-www-form-urlencoded"}, op.Consumes; !reflect.DeepEqual(got, want) {
+		t.Errorf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post.Consumes = %v; want %v", file, got, want)
+	}
+	if want, got := []string{"text/csv"}, op.Produces; !reflect.DeepEqual(got, want) {
+		t.Errorf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post.Produces = %v; want %v", file, got, want)
+	}
+}
+
+func TestUnrollRecursiveDefinitions(t *testing.T) {
+	node := func(next string, truncated bool) openapiSchemaObject {
+		ref := schemaCore{Ref: "#/definitions/" + next}
+		if truncated {
+			ref = schemaCore{Type: "object"}
+		}
+		items := openapiItemsObject(ref)
+		return openapiSchemaObject{
+			schemaCore: schemaCore{Type: "object"},
+			Properties: &openapiSchemaObjectProperties{
+				{Key: "parent", Value: openapiSchemaObject{schemaCore: ref}},
+				{Key: "children", Value: openapiSchemaObject{schemaCore: schemaCore{Type: "array", Items: &items}}},
+				{Key: "leaf", Value: openapiSchemaObject{schemaCore: schemaCore{Ref: "#/definitions/exampleLeaf"}}},
+			},
+		}
+	}
+	leaf := openapiSchemaObject{
+		schemaCore: schemaCore{Type: "object"},
+		Properties: &openapiSchemaObjectProperties{
+			{Key: "name", Value: openapiSchemaObject{schemaCore: schemaCore{Type: "string"}}},
+		},
+	}
+
+	d := openapiDefinitionsObject{
+		"exampleNode": node("exampleNode", false),
+		"exampleLeaf": leaf,
+	}
+	unrollRecursiveDefinitions(d, 1)
+
+	want := openapiDefinitionsObject{
+		"exampleNode":       node("exampleNodeDepth1", false),
+		"exampleNodeDepth1": node("", true),
+		"exampleLeaf":       leaf,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("unrollRecursiveDefinitions() = %+v; want %+v", d, want)
+	}
+	if got := recursiveDefinitions(d); len(got) != 0 {
+		t.Errorf("recursiveDefinitions() after unrolling = %v; want none", got)
+	}
+}
+
+func TestApplyTemplateDeprecation(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("name"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				JsonName: proto.String("name"),
+			},
+			{
+				Name:     proto.String("old_name"),
+				Number:   proto.Int32(2),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				JsonName: proto.String("oldName"),
+				Options:  &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+			},
+		},
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("ExampleMessage"),
+		Options:    &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)},
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	msg.Fields = []*descriptor.Field{
+		{Message: msg, FieldDescriptorProto: msgdesc.Field[0]},
+		{Message: msg, FieldDescriptorProto: msgdesc.Field[1]},
+	}
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings: []*descriptor.Binding{
+							{
+								HTTPMethod: "POST",
+								PathTmpl: httprule.Template{
+									Version:  1,
+									OpCodes:  []int{0, 0},
+									Template: "/v1/echo",
+								},
+								Body: &descriptor.Body{FieldPath: nil},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	const note = "Deprecated: will be removed in v2."
+	reg := descriptor.NewRegistry()
+	reg.SetDeprecationNote(note)
+	fileCL := crossLinkFixture(&file)
+	if err := reg.Load(reqFromFile(fileCL)); err != nil {
+		t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+	}
+	result, err := applyTemplate(param{File: fileCL, reg: reg})
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+
+	op := result.Paths["/v1/echo"].Post
+	if !op.Deprecated {
+		t.Errorf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post.Deprecated = false; want true", file)
+	}
+	if op.Description != note {
+		t.Errorf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post.Description = %q; want %q", file, op.Description, note)
+	}
+
+	props := result.Definitions["ExampleMessage"].Properties
+	if props == nil || len(*props) != 2 {
+		t.Fatalf("applyTemplate(%#v).Definitions[\"ExampleMessage\"].Properties = %v; want 2 properties", file, props)
+	}
+	if name := (*props)[0].Value.(openapiSchemaObject); name.Deprecated || name.Description != "" {
+		t.Errorf("property %q = %+v; want not deprecated", (*props)[0].Key, name)
+	}
+	if old := (*props)[1].Value.(openapiSchemaObject); !old.Deprecated || old.Description != note {
+		t.Errorf("property %q = %+v; want deprecated with description %q", (*props)[1].Key, old, note)
+	}
+}
+
+func TestRenderServers(t *testing.T) {
+	if err := os.Setenv("API_DOMAIN", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("API_DOMAIN")
+
+	reg := descriptor.NewRegistry()
+	if err := reg.SetServers([]string{
+		"prod=https://api.${API_DOMAIN}/v1",
+		"regional=https://{region}.${API_DOMAIN}",
+	}); err != nil {
+		t.Fatalf("reg.SetServers() failed with %v; want success", err)
+	}
+	if err := reg.SetServerVariables([]string{"region=eu"}); err != nil {
+		t.Fatalf("reg.SetServerVariables() failed with %v; want success", err)
+	}
+
+	servers, err := renderServers(reg)
+	if err != nil {
+		t.Fatalf("renderServers() failed with %v; want success", err)
+	}
+	want := []openapiServerObject{
+		{URL: "https://api.example.com/v1", Description: "prod"},
+		{
+			URL:         "https://{region}.example.com",
+			Description: "regional",
+			Variables:   map[string]openapiServerVariableObject{"region": {Default: "eu"}},
+		},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("renderServers() = %+v; want %+v", servers, want)
+	}
+
+	var swagger openapiSwaggerObject
+	if err := applyServer(&swagger, servers[1]); err != nil {
+		t.Fatalf("applyServer() failed with %v; want success", err)
+	}
+	if swagger.Host != "eu.example.com" || swagger.BasePath != "" || !reflect.DeepEqual(swagger.Schemes, []string{"https"}) {
+		t.Errorf("applyServer() set host=%q basePath=%q schemes=%v; want eu.example.com, \"\", [https]", swagger.Host, swagger.BasePath, swagger.Schemes)
+	}
+
+	var single openapiSwaggerObject
+	if err := addServers(&single, servers[:1]); err != nil {
+		t.Fatalf("addServers() failed with %v; want success", err)
+	}
+	if single.Host != "api.example.com" || single.BasePath != "/v1" {
+		t.Errorf("addServers() set host=%q basePath=%q; want api.example.com, /v1", single.Host, single.BasePath)
+	}
+	if len(single.extensions) != 1 || single.extensions[0].key != "x-servers" {
+		t.Errorf("addServers() set extensions %v; want x-servers", single.extensions)
+	}
+
+	if err := reg.SetServers([]string{"regional=https://{zone}.example.com"}); err != nil {
+		t.Fatalf("reg.SetServers() failed with %v; want success", err)
+	}
+	if _, err := renderServers(reg); err == nil {
+		t.Errorf("renderServers() succeeded with an undefined variable; want error")
+	}
+}
+
+func TestApplyTemplateOpenAPIBindingOptions(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("ExampleMessage"),
+		Options:    &descriptorpb.MethodOptions{},
+	}
+	proto.SetExtension(meth.Options, openapi_options.E_Openapiv2Operation, &openapi_options.Operation{
+		Extensions: map[string]*structpb.Value{
+			"x-foo": {Kind: &structpb.Value_StringValue{StringValue: "bar"}},
+		},
+		BindingOptions: []*openapi_options.BindingOptions{
+			{Bindings: []int32{1}, Exclude: true},
+		},
+	})
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	binding := func(path string) *descriptor.Binding {
+		return &descriptor.Binding{
+			HTTPMethod: "POST",
+			PathTmpl: httprule.Template{
+				Version:  1,
+				OpCodes:  []int{0, 0},
+				Template: path,
+			},
+			Body: &descriptor.Body{FieldPath: nil},
+		}
+	}
+	bindings := []*descriptor.Binding{binding("/v1/echo"), binding("/v1/legacy/echo")}
+	bindings[1].Index = 1
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings:              bindings,
+					},
+				},
+			},
+		},
+	}
+	reg := descriptor.NewRegistry()
+	fileCL := crossLinkFixture(&file)
+	if err := reg.Load(reqFromFile(fileCL)); err != nil {
+		t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+	}
+	result, err := applyTemplate(param{File: fileCL, reg: reg})
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+
+	if _, ok := result.Paths["/v1/legacy/echo"]; ok {
+		t.Errorf("applyTemplate(%#v).Paths has excluded binding /v1/legacy/echo", file)
+	}
+	op := result.Paths["/v1/echo"].Post
+	if op == nil {
+		t.Fatalf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post = nil; want an operation", file)
+	}
+	if len(op.extensions) != 1 || op.extensions[0].key != "x-foo" {
+		t.Errorf("applyTemplate(%#v).Paths[\"/v1/echo\"].Post extensions = %v; want only x-foo", file, op.extensions)
+	}
+}
+
+func TestApplyTemplateOpenAPIBindingHeaders(t *testing.T) {
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+	}
+	meth := &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String("Echo"),
+		InputType:  proto.String("ExampleMessage"),
+		OutputType: proto.String("ExampleMessage"),
+		Options:    &descriptorpb.MethodOptions{},
+	}
+	proto.SetExtension(meth.Options, openapi_options.E_Openapiv2Operation, &openapi_options.Operation{
+		BindingOptions: []*openapi_options.BindingOptions{{
+			Headers: []*openapi_options.HeaderParameter{{
+				Name:        "X-Tenant-ID",
+				Description: "Tenant of the request.",
+				Required:    true,
+			}},
+		}},
+	})
+	svc := &descriptorpb.ServiceDescriptorProto{
+		Name:   proto.String("ExampleService"),
+		Method: []*descriptorpb.MethodDescriptorProto{meth},
+	}
+	msg := &descriptor.Message{
+		DescriptorProto: msgdesc,
+	}
+	binding := func(path string) *descriptor.Binding {
+		return &descriptor.Binding{
+			HTTPMethod: "POST",
+			PathTmpl: httprule.Template{
+				Version:  1,
+				OpCodes:  []int{0, 0},
+				Template: path,
+			},
+			Body: &descriptor.Body{FieldPath: nil},
+		}
+	}
+	bindings := []*descriptor.Binding{binding("/v1/echo"), binding("/v1/legacy/echo")}
+	bindings[1].Index = 1
+	file := descriptor.File{
+		FileDescriptorProto: &descriptorpb.FileDescriptorProto{
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+			Name:           proto.String("example.proto"),
+			Package:        proto.String("example"),
+			MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+			Service:        []*descriptorpb.ServiceDescriptorProto{svc},
+		},
+		GoPkg: descriptor.GoPackage{
+			Path: "example.com/path/to/example/example.pb",
+			Name: "example_pb",
+		},
+		Messages: []*descriptor.Message{msg},
+		Services: []*descriptor.Service{
+			{
+				ServiceDescriptorProto: svc,
+				Methods: []*descriptor.Method{
+					{
+						MethodDescriptorProto: meth,
+						RequestType:           msg,
+						ResponseType:          msg,
+						Bindings:              bindings,
+					},
+				},
+			},
+		},
+	}
+	reg := descriptor.NewRegistry()
+	fileCL := crossLinkFixture(&file)
+	if err := reg.Load(reqFromFile(fileCL)); err != nil {
+		t.Fatalf("reg.Load(%#v) failed with %v; want success", file, err)
+	}
+	result, err := applyTemplate(param{File: fileCL, reg: reg})
+	if err != nil {
+		t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+	}
+
+	want := openapiParameterObject{
+		Name:        "X-Tenant-ID",
+		Description: "Tenant of the request.",
+		In:          "header",
+		Required:    true,
+		Type:        "string",
+	}
+	for _, path := range []string{"/v1/echo", "/v1/legacy/echo"} {
+		op := result.Paths[path].Post
+		if op == nil {
+			t.Fatalf("applyTemplate(%#v).Paths[%q].Post = nil; want an operation", file, path)
+		}
+		params := op.Parameters
+		if len(params) == 0 || !reflect.DeepEqual(params[len(params)-1], want) {
+			t.Errorf("applyTemplate(%#v).Paths[%q].Post.Parameters = %+v; want to end with %+v", file, path, params, want)
+		}
+	}
+}
+
+func TestWKTSchema(t *testing.T) {
+	for _, spec := range []struct {
+		formats     []string
+		typeName    string
+		wantCore    schemaCore
+		wantPattern string
+	}{
+		{
+			typeName: ".google.protobuf.Timestamp",
+			wantCore: schemaCore{Type: "string", Format: "date-time"},
+		},
+		{
+			formats:  []string{"Timestamp=unix"},
+			typeName: ".google.protobuf.Timestamp",
+			wantCore: schemaCore{Type: "integer", Format: "int64"},
+		},
+		{
+			formats:     []string{"Duration=pattern"},
+			typeName:    ".google.protobuf.Duration",
+			wantCore:    schemaCore{Type: "string"},
+			wantPattern: durationPattern,
+		},
+		{
+			formats:  []string{"Duration=seconds"},
+			typeName: ".google.protobuf.Duration",
+			wantCore: schemaCore{Type: "number", Format: "double"},
+		},
+		{
+			formats:  []string{"FieldMask=string"},
+			typeName: ".google.protobuf.FieldMask",
+			wantCore: schemaCore{Type: "string"},
+		},
+		{
+			formats:  []string{"wrappers=nullable"},
+			typeName: ".google.protobuf.Int64Value",
+			wantCore: schemaCore{Type: "string", Format: "int64", XNullable: true},
+		},
+		{
+			formats:  []string{"wrappers=nullable", "Timestamp=unix"},
+			typeName: ".google.protobuf.Struct",
+			wantCore: schemaCore{Type: "object"},
+		},
+	} {
+		reg := descriptor.NewRegistry()
+		if err := reg.SetWKTFormats(spec.formats); err != nil {
+			t.Fatalf("reg.SetWKTFormats(%q) failed with %v; want success", spec.formats, err)
+		}
+		core, ok := wktSchema(spec.typeName, reg)
+		if !ok {
+			t.Fatalf("wktSchema(%q) with %q is not a well-known type", spec.typeName, spec.formats)
+		}
+		if !reflect.DeepEqual(core, spec.wantCore) {
+			t.Errorf("wktSchema(%q) with %q = %+v; want %+v", spec.typeName, spec.formats, core, spec.wantCore)
+		}
+		if got := wktPattern(spec.typeName, reg); got != spec.wantPattern {
+			t.Errorf("wktPattern(%q) with %q = %q; want %q", spec.typeName, spec.formats, got, spec.wantPattern)
+		}
+	}
+
+	if _, ok := wktSchema(".example.Message", descriptor.NewRegistry()); ok {
+		t.Errorf("wktSchema(%q) succeeded; want not a well-known type", ".example.Message")
+	}
+}
+
+func TestSetWKTFormatsErrors(t *testing.T) {
+	for _, formats := range [][]string{
+		{"Timestamp"},
+		{"Any=object"},
+		{"Duration=iso8601"},
+	} {
+		if err := descriptor.NewRegistry().SetWKTFormats(formats); err == nil {
+			t.Errorf("reg.SetWKTFormats(%q) succeeded; want error", formats)
+		}
+	}
+}
+
+func TestRenderPostmanCollection(t *testing.T) {
+	swagger := &openapiSwaggerObject{
+		Info:     openapiInfoObject{Title: "Echo API"},
+		Host:     "api.example.com",
+		BasePath: "/api",
+		Schemes:  []string{"https"},
+		Paths: openapiPathsObject{
+			"/v1/{name=projects/*}/echo": openapiPathItemObject{
+				Get: &openapiOperationObject{
+					Summary: "Echo",
+					Tags:    []string{"EchoService"},
+					Parameters: openapiParametersObject{
+						{Name: "name", In: "path", Required: true, Type: "string"},
+						{Name: "note", In: "query", Type: "string"},
+						{Name: "X-Request-Id", In: "header", Required: true, Type: "string"},
+					},
+				},
+				Post: &openapiOperationObject{
+					OperationID: "EchoService_EchoBody",
+					Tags:        []string{"EchoService"},
+					Parameters: openapiParametersObject{
+						{Name: "name", In: "path", Required: true, Type: "string"},
+						{
+							Name:   "body",
+							In:     "body",
+							Schema: &openapiSchemaObject{schemaCore: schemaCore{Ref: "#/definitions/exampleMessage"}},
+						},
+					},
+				},
+			},
+		},
+		Definitions: openapiDefinitionsObject{
+			"exampleMessage": openapiSchemaObject{
+				schemaCore: schemaCore{Type: "object"},
+				Properties: &openapiSchemaObjectProperties{
+					{Key: "text", Value: openapiSchemaObject{schemaCore: schemaCore{Type: "string"}}},
+					{Key: "count", Value: openapiSchemaObject{schemaCore: schemaCore{Type: "integer", Format: "int32"}}},
+					{Key: "child", Value: openapiSchemaObject{schemaCore: schemaCore{Ref: "#/definitions/exampleMessage"}}},
+				},
+			},
+		},
+		SecurityDefinitions: openapiSecurityDefinitionsObject{
+			"ApiKeyAuth": openapiSecuritySchemeObject{Type: "apiKey", Name: "X-API-Key", In: "header"},
+		},
+	}
+
+	c := renderPostmanCollection(swagger)
+	if got, want := c.Info.Name, "Echo API"; got != want {
+		t.Errorf("c.Info.Name = %q; want %q", got, want)
+	}
+	wantVars := []postmanVariable{{Key: "baseUrl", Value: "https://api.example.com/api"}, {Key: "apiKey"}}
+	if !reflect.DeepEqual(c.Variable, wantVars) {
+		t.Errorf("c.Variable = %+v; want %+v", c.Variable, wantVars)
+	}
+	if c.Auth == nil || c.Auth.Type != "apikey" {
+		t.Fatalf("c.Auth = %+v; want apikey auth", c.Auth)
+	}
+	if len(c.Item) != 1 || c.Item[0].Name != "EchoService" || len(c.Item[0].Item) != 2 {
+		t.Fatalf("c.Item = %+v; want a single EchoService folder with 2 requests", c.Item)
+	}
+
+	get := c.Item[0].Item[0]
+	if got, want := get.Name, "Echo"; got != want {
+		t.Errorf("get.Name = %q; want %q", got, want)
+	}
+	wantURL := postmanURL{
+		Raw:      "{{baseUrl}}/v1/:name/echo",
+		Host:     []string{"{{baseUrl}}"},
+		Path:     []string{"v1", ":name", "echo"},
+		Query:    []postmanVariable{{Key: "note", Disabled: true}},
+		Variable: []postmanVariable{{Key: "name"}},
+	}
+	if !reflect.DeepEqual(get.Request.URL, wantURL) {
+		t.Errorf("get.Request.URL = %+v; want %+v", get.Request.URL, wantURL)
+	}
+	if want := []postmanVariable{{Key: "X-Request-Id"}}; !reflect.DeepEqual(get.Request.Header, want) {
+		t.Errorf("get.Request.Header = %+v; want %+v", get.Request.Header, want)
+	}
+
+	post := c.Item[0].Item[1]
+	if got, want := post.Name, "EchoService_EchoBody"; got != want {
+		t.Errorf("post.Name = %q; want %q", got, want)
+	}
+	if post.Request.Body == nil {
+		t.Fatalf("post.Request.Body = nil; want an example body")
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(post.Request.Body.Raw), &body); err != nil {
+		t.Fatalf("json.Unmarshal(%q) failed with %v; want success", post.Request.Body.Raw, err)
+	}
+	wantBody := map[string]interface{}{
+		"text":  "string",
+		"count": float64(0),
+		"child": map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("post.Request.Body.Raw = %s; want %v", post.Request.Body.Raw, wantBody)
+	}
+}
+
+func TestRenderTags(t *testing.T) {
+	newService := func(pkg, name, description string) *descriptor.Service {
+		svc := &descriptor.Service{
+			ServiceDescriptorProto: &descriptorpb.ServiceDescriptorProto{Name: proto.String(name)},
+			File: &descriptor.File{
+				FileDescriptorProto: &descriptorpb.FileDescriptorProto{Package: proto.String(pkg)},
+			},
+		}
+		svc.Methods = []*descriptor.Method{{Service: svc, Bindings: []*descriptor.Binding{{HTTPMethod: "GET"}}}}
+		if description != "" {
+			svc.Options = &descriptorpb.ServiceOptions{}
+			proto.SetExtension(svc.Options, openapi_options.E_Openapiv2Tag, &openapi_options.Tag{Description: description})
+		}
+		return svc
+	}
+	services := []*descriptor.Service{
+		newService("example.billing", "InvoiceService", "Manages invoices"),
+		newService("example.billing", "PaymentService", ""),
+		newService("example.users", "UserService", "Manages users"),
+	}
+
+	reg := descriptor.NewRegistry()
+	tags, err := renderTags(services, reg)
+	if err != nil {
+		t.Fatalf("renderTags() failed with %v; want success", err)
+	}
+	want := []openapiTagObject{
+		{Name: "InvoiceService", Description: "Manages invoices"},
+		{Name: "PaymentService"},
+		{Name: "UserService", Description: "Manages users"},
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("renderTags() = %+v; want %+v", tags, want)
+	}
+
+	reg.SetTagOrder([]string{"UserService", "Unknown"})
+	tags, err = renderTags(services, reg)
+	if err != nil {
+		t.Fatalf("renderTags() failed with %v; want success", err)
+	}
+	want = []openapiTagObject{want[2], want[0], want[1]}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("renderTags() with tag_order = %+v; want %+v", tags, want)
+	}
+
+	if err := reg.SetTagBy("package"); err != nil {
+		t.Fatalf("reg.SetTagBy(%q) failed with %v; want success", "package", err)
+	}
+	if err := reg.SetTagGroups([]string{"Accounts=example.billing:example.users"}); err != nil {
+		t.Fatalf("reg.SetTagGroups() failed with %v; want success", err)
+	}
+	reg.SetTagOrder(nil)
+	swagger := &openapiSwaggerObject{}
+	if err := addTags(swagger, services, reg); err != nil {
+		t.Fatalf("addTags() failed with %v; want success", err)
+	}
+	want = []openapiTagObject{
+		{Name: "example.billing", Description: "Manages invoices"},
+		{Name: "example.users", Description: "Manages users"},
+	}
+	if !reflect.DeepEqual(swagger.Tags, want) {
+		t.Errorf("addTags() tags = %+v; want %+v", swagger.Tags, want)
+	}
+	wantExt := `[{"name":"Accounts","tags":["example.billing","example.users"]}]`
+	if len(swagger.extensions) != 1 || swagger.extensions[0].key != "x-tagGroups" || string(swagger.extensions[0].value) != wantExt {
+		t.Errorf("addTags() extensions = %+v; want x-tagGroups %s", swagger.extensions, wantExt)
+	}
+
+	unbound := newService("example.users", "AdminService", "Administers users")
+	unbound.Methods = nil
+	if tags, err := renderTags([]*descriptor.Service{unbound}, descriptor.NewRegistry()); err != nil || tags != nil {
+		t.Errorf("renderTags() without bindings = %+v, %v; want nil, nil", tags, err)
+	}
+
+	if tags, err := renderTags(services[1:2], descriptor.NewRegistry()); err != nil || tags != nil {
+		t.Errorf("renderTags() without options = %+v, %v; want nil, nil", tags, err)
+	}
+}
+
+func TestFieldBehavior(t *testing.T) {
+	withBehavior := func(behaviors ...annotations.FieldBehavior) *descriptorpb.FieldOptions {
+		opts := &descriptorpb.FieldOptions{}
+		proto.SetExtension(opts, annotations.E_FieldBehavior, behaviors)
+		return opts
+	}
+	msgdesc := &descriptorpb.DescriptorProto{
+		Name: proto.String("ExampleMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{
+				Name:     proto.String("name"),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Number:   proto.Int32(1),
+				JsonName: proto.String("name"),
+				Options:  withBehavior(annotations.FieldBehavior_REQUIRED, annotations.FieldBehavior_IMMUTABLE),
+			},
+			{
+				Name:     proto.String("create_time"),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Number:   proto.Int32(2),
+				JsonName: proto.String("createTime"),
+				Options:  withBehavior(annotations.FieldBehavior_OUTPUT_ONLY),
+			},
+			{
+				Name:     proto.String("password"),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Number:   proto.Int32(3),
+				JsonName: proto.String("password"),
+				Options:  withBehavior(annotations.FieldBehavior_INPUT_ONLY),
+			},
+		},
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+		Name:           proto.String("example.proto"),
+		Package:        proto.String("example"),
+		MessageType:    []*descriptorpb.DescriptorProto{msgdesc},
+	}
+	reg := descriptor.NewRegistry()
+	reg.SetUseJSONNamesForFields(true)
+	if err := reg.Load(&pluginpb.CodeGeneratorRequest{ProtoFile: []*descriptorpb.FileDescriptorProto{file}}); err != nil {
+		t.Fatalf("reg.Load() failed with %v; want success", err)
+	}
+	msg, err := reg.LookupMsg("", ".example.ExampleMessage")
+	if err != nil {
+		t.Fatalf("reg.LookupMsg() failed with %v; want success", err)
+	}
+
+	defs := openapiDefinitionsObject{}
+	renderMessagesAsDefinition(messageMap{"ExampleMessage": msg}, defs, reg, refMap{})
+	schema := defs["ExampleMessage"]
+	if want := []string{"name"}; !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("schema.Required = %v; want %v", schema.Required, want)
+	}
+	props := map[string]openapiSchemaObject{}
+	for _, kv := range *schema.Properties {
+		props[kv.Key] = kv.Value.(openapiSchemaObject)
+	}
+	if !props["name"].XImmutable {
+		t.Errorf("name is not x-immutable; want x-immutable")
+	}
+	if !props["createTime"].ReadOnly {
+		t.Errorf("createTime is not readOnly; want readOnly")
+	}
+	if !props["password"].WriteOnly {
+		t.Errorf("password is not writeOnly; want writeOnly")
+	}
+
+	params, err := messageToQueryParameters(msg, reg, []descriptor.Parameter{}, nil)
+	if err != nil {
+		t.Fatalf("messageToQueryParameters() failed with %v; want success", err)
+	}
+	want := []openapiParameterObject{
+		{Name: "name", In: "query", Required: true, Type: "string"},
+		{Name: "password", In: "query", Type: "string"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("messageToQueryParameters() = %+v; want %+v", params, want)
+	}
+}
+
+func TestInlineRefs(t *testing.T) {
+	ref := func(name string) openapiSchemaObject {
+		return openapiSchemaObject{schemaCore: schemaCore{Ref: "#/definitions/" + name}}
+	}
+	leaf := openapiSchemaObject{
+		schemaCore: schemaCore{Type: "object"},
+		Properties: &openapiSchemaObjectProperties{
+			{Key: "value", Value: openapiSchemaObject{schemaCore: schemaCore{Type: "string"}}},
+		},
+	}
+	node := openapiSchemaObject{
+		schemaCore: schemaCore{Type: "object"},
+		Properties: &openapiSchemaObjectProperties{
+			{Key: "leaf", Value: ref("exampleLeaf")},
+			{Key: "leaves", Value: openapiSchemaObject{schemaCore: schemaCore{
+				Type:  "array",
+				Items: &openapiItemsObject{Ref: "#/definitions/exampleLeaf"},
+			}}},
+			{Key: "children", Value: openapiSchemaObject{schemaCore: schemaCore{
+				Type:  "array",
+				Items: &openapiItemsObject{Ref: "#/definitions/exampleNode"},
+			}}},
+		},
+	}
+	body := ref("exampleNode")
+	swagger := &openapiSwaggerObject{
+		Paths: openapiPathsObject{
+			"/v1/nodes": openapiPathItemObject{
+				Post: &openapiOperationObject{
+					Parameters: openapiParametersObject{{Name: "body", In: "body", Schema: &body}},
+					Responses: openapiResponsesObject{
+						"200": openapiResponseObject{Schema: ref("exampleLeaf")},
+					},
+				},
+			},
+		},
+		Definitions: openapiDefinitionsObject{
+			"exampleNode": node,
+			"exampleLeaf": leaf,
+		},
+	}
+
+	inlineRefs(swagger)
+
+	op := swagger.Paths["/v1/nodes"].Post
+	if got := op.Responses["200"].Schema; !reflect.DeepEqual(got, leaf) {
+		t.Errorf("response schema = %+v; want %+v", got, leaf)
+	}
+	if _, ok := swagger.Definitions["exampleLeaf"]; ok {
+		t.Errorf("exampleLeaf is still defined; want it inlined")
+	}
+	if _, ok := swagger.Definitions["exampleNode"]; !ok {
+		t.Errorf("exampleNode is not defined; want it kept for the cyclic reference")
+	}
+
+	got, err := json.Marshal(op.Parameters[0].Schema)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed with %v; want success", err)
+	}
+	want := `{"type":"object","properties":{` +
+		`"leaf":{"type":"object","properties":{"value":{"type":"string"}}},` +
+		`"leaves":{"type":"array","items":{"type":"object","properties":{"value":{"type":"string"}}}},` +
+		`"children":{"type":"array","items":{"$ref":"#/definitions/exampleNode"}}}}`
+	if string(got) != want {
+		t.Errorf("body schema = %s; want %s", got, want)
+	}
+}
+
+func TestAddResponseHeaders(t *testing.T) {
+	op := &openapiOperationObject{
+		Responses: openapiResponsesObject{
+			"200": openapiResponseObject{Description: "A successful response."},
+		},
+	}
+	err := addResponseHeaders(op, []descriptor.OpenAPIResponseHeader{
+		{Name: "ETag", Description: "Version of the resource.", Type: "string", Status: "200"},
+		{Name: "X-Rate-Limit-Remaining", Type: "integer", Format: "int32", Status: "200"},
+	})
+	if err != nil {
+		t.Fatalf("addResponseHeaders() failed with %v; want success", err)
+	}
+	want := openapiHeadersObject{
+		"ETag":                   {Description: "Version of the resource.", Type: "string"},
+		"X-Rate-Limit-Remaining": {Type: "integer", Format: "int32"},
+	}
+	if got := op.Responses["200"].Headers; !reflect.DeepEqual(got, want) {
+		t.Errorf("op.Responses[%q].Headers = %+v; want %+v", "200", got, want)
+	}
+
+	err = addResponseHeaders(op, []descriptor.OpenAPIResponseHeader{{Name: "Retry-After", Type: "integer", Status: "429"}})
+	if err == nil {
+		t.Errorf("addResponseHeaders() with an undocumented response succeeded; want error")
+	}
+}
+
+func TestApplyTemplateNestedResponseBody(t *testing.T) {
+	for _, spec := range []struct {
+		responseBody string
+		wantType     string
+		wantRef      string
+	}{
+		{responseBody: "result.items", wantType: "array"},
+		{responseBody: "result.page", wantRef: "#/definitions/examplePage"},
+	} {
+		t.Run(spec.responseBody, func(t *testing.T) {
+			opts := &descriptorpb.MethodOptions{}
+			proto.SetExtension(opts, annotations.E_Http, &annotations.HttpRule{
+				Pattern:      &annotations.HttpRule_Get{Get: "/v1/items"},
+				ResponseBody: spec.responseBody,
+			})
+			fd := &descriptorpb.FileDescriptorProto{
+				SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+				Name:           proto.String("example.proto"),
+				Package:        proto.String("example"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Envelope"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("result"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".example.Result")},
+						},
+					},
+					{
+						Name: proto.String("Result"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("items"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+							{Name: proto.String("page"), Number: proto.Int32(2), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".example.Page")},
+						},
+					},
+					{
+						Name: proto.String("Page"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("token"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+						},
+					},
+				},
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					{
+						Name: proto.String("ExampleService"),
+						Method: []*descriptorpb.MethodDescriptorProto{
+							{Name: proto.String("List"), InputType: proto.String(".example.Envelope"), OutputType: proto.String(".example.Envelope"), Options: opts},
+						},
+					},
+				},
+			}
+			reg := descriptor.NewRegistry()
+			reg.SetAllowRepeatedFieldsInBody(true)
+			if err := AddErrorDefs(reg); err != nil {
+				t.Fatalf("AddErrorDefs(%#v) failed with %v; want success", reg, err)
+			}
+			if err := reg.Load(&pluginpb.CodeGeneratorRequest{ProtoFile: []*descriptorpb.FileDescriptorProto{fd}, FileToGenerate: []string{fd.GetName()}}); err != nil {
+				t.Fatalf("reg.Load(%#v) failed with %v; want success", fd, err)
+			}
+			file, err := reg.LookupFile(fd.GetName())
+			if err != nil {
+				t.Fatalf("reg.LookupFile(%q) failed with %v; want success", fd.GetName(), err)
+			}
+			result, err := applyTemplate(param{File: file, reg: reg})
+			if err != nil {
+				t.Fatalf("applyTemplate(%#v) failed with %v; want success", file, err)
+			}
+
+			schema := result.Paths["/v1/items"].Get.Responses["200"].Schema
+			if schema.Type != spec.wantType || schema.Ref != spec.wantRef {
+				t.Errorf("response schema = %+v; want type %q and ref %q", schema.schemaCore, spec.wantType, spec.wantRef)
+			}
+			if spec.wantRef != "" {
+				if _, ok := result.Definitions[strings.TrimPrefix(spec.wantRef, "#/definitions/")]; !ok {
+					t.Errorf("result.Definitions = %v; want to define %s", result.Definitions, spec.wantRef)
+				}
+			}
+		})
+	}
+}
@@ -50,6 +50,29 @@ type extension struct {
 	value json.RawMessage
 }
 
+// openapiServerObject is an element of the x-servers extension, which carries
+// the servers of the API in the format of OpenAPI 3.
+// https://github.com/OAI/OpenAPI-Specification/blob/3.0.3/versions/3.0.3.md#serverObject
+type openapiServerObject struct {
+	URL         string                                 `json:"url"`
+	Description string                                 `json:"description,omitempty"`
+	Variables   map[string]openapiServerVariableObject `json:"variables,omitempty"`
+}
+
+// https://github.com/OAI/OpenAPI-Specification/blob/3.0.3/versions/3.0.3.md#serverVariableObject
+type openapiServerVariableObject struct {
+	Default string `json:"default"`
+}
+
+// openapiStreamingExtension is the value of the x-streaming extension of
+// operations backed by streaming methods.
+type openapiStreamingExtension struct {
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+	Format          string `json:"format"`
+	ContentType     string `json:"contentType,omitempty"`
+}
+
 // http://swagger.io/specification/#swaggerObject
 type openapiSwaggerObject struct {
 	Swagger             string                              `json:"swagger"`
@@ -63,11 +86,25 @@ type openapiSwaggerObject struct {
 	Definitions         openapiDefinitionsObject            `json:"definitions"`
 	SecurityDefinitions openapiSecurityDefinitionsObject    `json:"securityDefinitions,omitempty"`
 	Security            []openapiSecurityRequirementObject  `json:"security,omitempty"`
+	Tags                []openapiTagObject                  `json:"tags,omitempty"`
 	ExternalDocs        *openapiExternalDocumentationObject `json:"externalDocs,omitempty"`
 
 	extensions []extension
 }
 
+// http://swagger.io/specification/#tagObject
+type openapiTagObject struct {
+	Name         string                              `json:"name"`
+	Description  string                              `json:"description,omitempty"`
+	ExternalDocs *openapiExternalDocumentationObject `json:"externalDocs,omitempty"`
+}
+
+// openapiTagGroupObject is an element of the x-tagGroups extension.
+type openapiTagGroupObject struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
 // http://swagger.io/specification/#securityDefinitionsObject
 type openapiSecurityDefinitionsObject map[string]openapiSecuritySchemeObject
 
@@ -112,6 +149,7 @@ type openapiOperationObject struct {
 	Parameters  openapiParametersObject `json:"parameters,omitempty"`
 	Tags        []string                `json:"tags,omitempty"`
 	Deprecated  bool                    `json:"deprecated,omitempty"`
+	Consumes    []string                `json:"consumes,omitempty"`
 	Produces    []string                `json:"produces,omitempty"`
 
 	Security     *[]openapiSecurityRequirementObject `json:"security,omitempty"`
@@ -156,6 +194,15 @@ type schemaCore struct {
 	// start from 0 index it will be great. I don't think that is a good assumption.
 	Enum    []string `json:"enum,omitempty"`
 	Default string   `json:"default,omitempty"`
+
+	// XNullable marks wrapper types as nullable when wkt_format=wrappers=nullable is set,
+	// and proto3 optional fields, whose presence is tracked.
+	XNullable bool `json:"x-nullable,omitempty"`
+
+	// inline, if set, is the full schema of the items of an array whose
+	// definition was inlined with inline_refs. It replaces the items when
+	// marshaled, as an items object cannot have properties.
+	inline *openapiSchemaObject
 }
 
 func (s *schemaCore) setRefFromFQN(ref string, reg *descriptor.Registry) error {
@@ -169,6 +216,14 @@ func (s *schemaCore) setRefFromFQN(ref string, reg *descriptor.Registry) error {
 
 type openapiItemsObject schemaCore
 
+func (o openapiItemsObject) MarshalJSON() ([]byte, error) {
+	if o.inline != nil {
+		return json.Marshal(o.inline)
+	}
+	type alias openapiItemsObject
+	return json.Marshal(alias(o))
+}
+
 // http://swagger.io/specification/#responsesObject
 type openapiResponsesObject map[string]openapiResponseObject
 
@@ -176,11 +231,22 @@ type openapiResponsesObject map[string]openapiResponseObject
 type openapiResponseObject struct {
 	Description string                 `json:"description"`
 	Schema      openapiSchemaObject    `json:"schema"`
+	Headers     openapiHeadersObject   `json:"headers,omitempty"`
 	Examples    map[string]interface{} `json:"examples,omitempty"`
 
 	extensions []extension
 }
 
+// http://swagger.io/specification/#headersObject
+type openapiHeadersObject map[string]openapiHeaderObject
+
+// http://swagger.io/specification/#headerObject
+type openapiHeaderObject struct {
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+}
+
 type keyVal struct {
 	Key   string
 	Value interface{}
@@ -224,7 +290,9 @@ type openapiSchemaObject struct {
 
 	ExternalDocs *openapiExternalDocumentationObject `json:"externalDocs,omitempty"`
 
+	Deprecated       bool     `json:"deprecated,omitempty"`
 	ReadOnly         bool     `json:"readOnly,omitempty"`
+	WriteOnly        bool     `json:"writeOnly,omitempty"`
 	MultipleOf       float64  `json:"multipleOf,omitempty"`
 	Maximum          float64  `json:"maximum,omitempty"`
 	ExclusiveMaximum bool     `json:"exclusiveMaximum,omitempty"`
@@ -239,6 +307,9 @@ type openapiSchemaObject struct {
 	MaxProperties    uint64   `json:"maxProperties,omitempty"`
 	MinProperties    uint64   `json:"minProperties,omitempty"`
 	Required         []string `json:"required,omitempty"`
+
+	// XImmutable marks fields annotated with google.api.field_behavior IMMUTABLE.
+	XImmutable bool `json:"x-immutable,omitempty"`
 }
 
 // http://swagger.io/specification/#definitionsObject
@@ -0,0 +1,367 @@
+package genopenapi
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Validation rules are read from the unknown fields of the field options, so
+// neither protoc-gen-validate nor protovalidate have to be linked into this
+// plugin. Both share the field numbers of their rule messages, protovalidate's
+// FieldConstraints being the successor of protoc-gen-validate's FieldRules.
+const (
+	// https://github.com/envoyproxy/protoc-gen-validate/blob/main/validate/validate.proto
+	pgvFieldRulesNumber = 1071
+	// https://github.com/bufbuild/protovalidate/blob/main/proto/protovalidate/buf/validate/validate.proto
+	protovalidateFieldConstraintsNumber = 1159
+
+	// Fields of FieldRules and FieldConstraints.
+	rulesFloatNumber     = 1
+	rulesDoubleNumber    = 2
+	rulesInt32Number     = 3
+	rulesInt64Number     = 4
+	rulesUInt32Number    = 5
+	rulesUInt64Number    = 6
+	rulesSInt32Number    = 7
+	rulesSInt64Number    = 8
+	rulesFixed32Number   = 9
+	rulesFixed64Number   = 10
+	rulesSFixed32Number  = 11
+	rulesSFixed64Number  = 12
+	rulesStringNumber    = 14
+	rulesMessageNumber   = 17
+	rulesRepeatedNumber  = 18
+	rulesAnyNumber       = 20
+	rulesDurationNumber  = 21
+	rulesTimestampNumber = 22
+	rulesRequiredNumber  = 25
+
+	// Fields of the numeric rules (e.g. Int32Rules).
+	numericConstNumber = 1
+	numericLtNumber    = 2
+	numericLteNumber   = 3
+	numericGtNumber    = 4
+	numericGteNumber   = 5
+
+	// Fields of StringRules.
+	stringConstNumber    = 1
+	stringMinLenNumber   = 2
+	stringMaxLenNumber   = 3
+	stringPatternNumber  = 6
+	stringInNumber       = 10
+	stringEmailNumber    = 12
+	stringHostnameNumber = 13
+	stringIPv4Number     = 15
+	stringIPv6Number     = 16
+	stringURINumber      = 17
+	stringURIRefNumber   = 18
+	stringLenNumber      = 19
+	stringUUIDNumber     = 22
+
+	// Fields of RepeatedRules.
+	repeatedMinItemsNumber = 1
+	repeatedMaxItemsNumber = 2
+	repeatedUniqueNumber   = 3
+
+	// Required field of MessageRules (protoc-gen-validate only).
+	messageRequiredNumber = 2
+	// Required field of AnyRules, DurationRules and TimestampRules (protoc-gen-validate only).
+	wktRequiredNumber = 1
+)
+
+// Supported values of the validation_rules_precedence flag.
+const (
+	// validationPrecedenceOpenAPI fills in constraints not set by openapiv2 options.
+	validationPrecedenceOpenAPI = "openapi"
+	// validationPrecedenceValidate overrides constraints set by openapiv2 options.
+	validationPrecedenceValidate = "validate"
+	// validationPrecedenceIgnore does not render validation rules at all.
+	validationPrecedenceIgnore = "ignore"
+)
+
+// stringFormats maps the well-known string rules to OpenAPI formats.
+var stringFormats = map[protowire.Number]string{
+	stringEmailNumber:    "email",
+	stringHostnameNumber: "hostname",
+	stringIPv4Number:     "ipv4",
+	stringIPv6Number:     "ipv6",
+	stringURINumber:      "uri",
+	stringURIRefNumber:   "uri-reference",
+	stringUUIDNumber:     "uuid",
+}
+
+// fieldConstraints are the OpenAPI constraints derived from the validation
+// rules of a field.
+type fieldConstraints struct {
+	required bool
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum bool
+
+	minLength, maxLength *uint64
+	pattern              string
+	format               string
+	enum                 []string
+
+	minItems, maxItems *uint64
+	uniqueItems        bool
+}
+
+// fieldConstraintsFromOptions extracts the validation rules of a field.
+// protovalidate rules are preferred over protoc-gen-validate rules when a
+// field is annotated with both. It returns nil if the field has no rules.
+func fieldConstraintsFromOptions(opts *descriptorpb.FieldOptions) *fieldConstraints {
+	if opts == nil {
+		return nil
+	}
+	unknown := opts.ProtoReflect().GetUnknown()
+	if len(unknown) == 0 {
+		return nil
+	}
+	var pgv, protovalidate []byte
+	found := false
+	rangeFields(unknown, func(num protowire.Number, typ protowire.Type, v []byte) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case pgvFieldRulesNumber:
+			pgv = append(pgv, v...)
+			found = true
+		case protovalidateFieldConstraintsNumber:
+			protovalidate = append(protovalidate, v...)
+			found = true
+		}
+	})
+	if !found {
+		return nil
+	}
+	c := &fieldConstraints{}
+	c.merge(pgv, false)
+	c.merge(protovalidate, true)
+	return c
+}
+
+// merge decodes the serialized FieldRules or FieldConstraints b into c.
+func (c *fieldConstraints) merge(b []byte, isProtovalidate bool) {
+	rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		switch num {
+		case rulesFloatNumber, rulesDoubleNumber,
+			rulesInt32Number, rulesInt64Number, rulesUInt32Number, rulesUInt64Number,
+			rulesSInt32Number, rulesSInt64Number, rulesFixed32Number, rulesFixed64Number,
+			rulesSFixed32Number, rulesSFixed64Number:
+			c.mergeNumeric(num, v)
+		case rulesStringNumber:
+			c.mergeString(v)
+		case rulesRepeatedNumber:
+			c.mergeRepeated(v)
+		case rulesMessageNumber, rulesAnyNumber, rulesDurationNumber, rulesTimestampNumber:
+			if isProtovalidate {
+				return
+			}
+			required := protowire.Number(wktRequiredNumber)
+			if num == rulesMessageNumber {
+				required = messageRequiredNumber
+			}
+			rangeFields(v, func(num protowire.Number, typ protowire.Type, v []byte) {
+				if num == required && typ == protowire.VarintType {
+					c.required = decodeBool(v)
+				}
+			})
+		case rulesRequiredNumber:
+			if isProtovalidate && typ == protowire.VarintType {
+				c.required = decodeBool(v)
+			}
+		}
+	})
+}
+
+func (c *fieldConstraints) mergeNumeric(rulesType protowire.Number, b []byte) {
+	rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		value, ok := decodeNumber(rulesType, typ, v)
+		if !ok {
+			return
+		}
+		switch num {
+		case numericConstNumber:
+			c.minimum, c.maximum = &value, &value
+			c.exclusiveMinimum, c.exclusiveMaximum = false, false
+		case numericLtNumber:
+			c.maximum, c.exclusiveMaximum = &value, true
+		case numericLteNumber:
+			c.maximum, c.exclusiveMaximum = &value, false
+		case numericGtNumber:
+			c.minimum, c.exclusiveMinimum = &value, true
+		case numericGteNumber:
+			c.minimum, c.exclusiveMinimum = &value, false
+		}
+	})
+}
+
+func (c *fieldConstraints) mergeString(b []byte) {
+	rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		switch typ {
+		case protowire.BytesType:
+			switch num {
+			case stringConstNumber:
+				c.enum = []string{string(v)}
+			case stringInNumber:
+				c.enum = append(c.enum, string(v))
+			case stringPatternNumber:
+				c.pattern = string(v)
+			}
+		case protowire.VarintType:
+			n, _ := protowire.ConsumeVarint(v)
+			switch num {
+			case stringLenNumber:
+				c.minLength, c.maxLength = &n, &n
+			case stringMinLenNumber:
+				c.minLength = &n
+			case stringMaxLenNumber:
+				c.maxLength = &n
+			default:
+				if format, ok := stringFormats[num]; ok && n != 0 {
+					c.format = format
+				}
+			}
+		}
+	})
+}
+
+func (c *fieldConstraints) mergeRepeated(b []byte) {
+	rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		if typ != protowire.VarintType {
+			return
+		}
+		n, _ := protowire.ConsumeVarint(v)
+		switch num {
+		case repeatedMinItemsNumber:
+			c.minItems = &n
+		case repeatedMaxItemsNumber:
+			c.maxItems = &n
+		case repeatedUniqueNumber:
+			c.uniqueItems = n != 0
+		}
+	})
+}
+
+// apply sets the constraints on the schema of the field. Unless override is
+// set, constraints already present in the schema are left untouched.
+func (c *fieldConstraints) apply(s *openapiSchemaObject, override bool) {
+	if s.Type == "integer" || s.Type == "number" {
+		if c.minimum != nil && (override || s.Minimum == 0) {
+			s.Minimum = *c.minimum
+			s.ExclusiveMinimum = c.exclusiveMinimum
+		}
+		if c.maximum != nil && (override || s.Maximum == 0) {
+			s.Maximum = *c.maximum
+			s.ExclusiveMaximum = c.exclusiveMaximum
+		}
+	}
+	if s.Type == "string" {
+		if c.minLength != nil && (override || s.MinLength == 0) {
+			s.MinLength = *c.minLength
+		}
+		if c.maxLength != nil && (override || s.MaxLength == 0) {
+			s.MaxLength = *c.maxLength
+		}
+		if c.pattern != "" && (override || s.Pattern == "") {
+			s.Pattern = c.pattern
+		}
+		if c.format != "" && (override || s.Format == "") {
+			s.Format = c.format
+		}
+		if len(c.enum) > 0 && (override || len(s.Enum) == 0) {
+			s.Enum = c.enum
+		}
+	}
+	if s.Type == "array" {
+		if c.minItems != nil && (override || s.MinItems == 0) {
+			s.MinItems = *c.minItems
+		}
+		if c.maxItems != nil && (override || s.MaxItems == 0) {
+			s.MaxItems = *c.maxItems
+		}
+		if c.uniqueItems {
+			s.UniqueItems = true
+		}
+	}
+}
+
+// rangeFields calls f for every well-formed field of the serialized message b.
+// The value passed to f is the payload of length-delimited fields and the raw
+// encoding of all other fields.
+func rangeFields(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return
+		}
+		v := b[:m]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		f(num, typ, v)
+		b = b[m:]
+	}
+}
+
+func decodeBool(v []byte) bool {
+	n, _ := protowire.ConsumeVarint(v)
+	return n != 0
+}
+
+// decodeNumber decodes a bound of the numeric rules identified by rulesType.
+func decodeNumber(rulesType protowire.Number, typ protowire.Type, v []byte) (float64, bool) {
+	switch typ {
+	case protowire.VarintType:
+		n, m := protowire.ConsumeVarint(v)
+		if m < 0 {
+			return 0, false
+		}
+		switch rulesType {
+		case rulesInt32Number:
+			return float64(int32(n)), true
+		case rulesInt64Number:
+			return float64(int64(n)), true
+		case rulesSInt32Number, rulesSInt64Number:
+			return float64(protowire.DecodeZigZag(n)), true
+		case rulesUInt32Number, rulesUInt64Number:
+			return float64(n), true
+		}
+	case protowire.Fixed32Type:
+		n, m := protowire.ConsumeFixed32(v)
+		if m < 0 {
+			return 0, false
+		}
+		switch rulesType {
+		case rulesFloatNumber:
+			return float64(math.Float32frombits(n)), true
+		case rulesFixed32Number:
+			return float64(n), true
+		case rulesSFixed32Number:
+			return float64(int32(n)), true
+		}
+	case protowire.Fixed64Type:
+		n, m := protowire.ConsumeFixed64(v)
+		if m < 0 {
+			return 0, false
+		}
+		switch rulesType {
+		case rulesDoubleNumber:
+			return math.Float64frombits(n), true
+		case rulesFixed64Number:
+			return float64(n), true
+		case rulesSFixed64Number:
+			return float64(int64(n)), true
+		}
+	}
+	return 0, false
+}
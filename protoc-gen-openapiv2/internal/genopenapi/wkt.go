@@ -0,0 +1,50 @@
+package genopenapi
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/descriptor"
+)
+
+// durationPattern matches the JSON representation of google.protobuf.Duration.
+const durationPattern = `^-?[0-9]+(\.[0-9]{1,9})?s$`
+
+// wrapperTypes are the fully qualified names of the wrapper well-known types.
+var wrapperTypes = map[string]bool{
+	".google.protobuf.StringValue": true,
+	".google.protobuf.BytesValue":  true,
+	".google.protobuf.Int32Value":  true,
+	".google.protobuf.UInt32Value": true,
+	".google.protobuf.Int64Value":  true,
+	".google.protobuf.UInt64Value": true,
+	".google.protobuf.FloatValue":  true,
+	".google.protobuf.DoubleValue": true,
+	".google.protobuf.BoolValue":   true,
+}
+
+// wktSchema returns the schema of the well-known type with the given fully
+// qualified name, in the format selected with the wkt_format option.
+func wktSchema(typeName string, reg *descriptor.Registry) (schemaCore, bool) {
+	core, ok := wktSchemas[typeName]
+	if !ok {
+		return core, false
+	}
+	switch {
+	case typeName == ".google.protobuf.Timestamp" && reg.GetWKTFormat("Timestamp") == "unix":
+		core = schemaCore{Type: "integer", Format: "int64"}
+	case typeName == ".google.protobuf.Duration" && reg.GetWKTFormat("Duration") == "seconds":
+		core = schemaCore{Type: "number", Format: "double"}
+	case typeName == ".google.protobuf.FieldMask" && reg.GetWKTFormat("FieldMask") == "string":
+		core = schemaCore{Type: "string"}
+	case wrapperTypes[typeName] && reg.GetWKTFormat("wrappers") == "nullable":
+		core.XNullable = true
+	}
+	return core, true
+}
+
+// wktPattern returns the pattern the value of the well-known type with the
+// given fully qualified name must match, if any.
+func wktPattern(typeName string, reg *descriptor.Registry) string {
+	if typeName == ".google.protobuf.Duration" && reg.GetWKTFormat("Duration") == "pattern" {
+		return durationPattern
+	}
+	return ""
+}
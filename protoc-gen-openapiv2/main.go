@@ -33,8 +33,51 @@ var (
 	simpleOperationIDs         = flag.Bool("simple_operation_ids", false, "whether to remove the service prefix in the operationID generation. Can introduce duplicate operationIDs, use with caution.")
 	openAPIConfiguration       = flag.String("openapi_configuration", "", "path to OpenAPI Configuration in YAML format")
 	generateUnboundMethods     = flag.Bool("generate_unbound_methods", false, "generate swagger metadata even for RPC methods that have no HttpRule annotation")
+	unboundMethodsPattern      = flag.String("unbound_methods_pattern", "", "path template of the methods documented by generate_unbound_methods, e.g. `/api/{service}/{method}`, where {package}, {service} and {method} are replaced by lower-cased names; Get* and List* methods are bound to GET")
+	streamingFormat            = flag.String("streaming_format", "", "if set, streaming methods are documented with the given wire format. Allowed values are `ndjson` (application/x-ndjson) and `sse` (text/event-stream).")
+	validationRulesPrecedence  = flag.String("validation_rules_precedence", "openapi", "configures how protoc-gen-validate and protovalidate rules are rendered as OpenAPI constraints. Allowed values are `openapi` (openapiv2 options win on conflict), `validate` (validation rules win on conflict) and `ignore` (validation rules are not rendered).")
+	outputFormat               = flag.String("output_format", "openapiv2", "configures the kind of documents to generate. Allowed values are `openapiv2`, `jsonschema` and `postman`. `jsonschema` emits a standalone JSON Schema (draft 2020-12) document for every request and response message. `postman` emits a Postman collection (v2.1) instead of an OpenAPI document.")
+	recursiveSchemaMode        = flag.String("recursive_schema_mode", "ref", "configures how self-referential messages are rendered. Allowed values are `ref` (cyclic $refs) and `unroll` (acyclic copies expanded up to `recursive_schema_depth` levels).")
+	recursiveSchemaDepth       = flag.Int("recursive_schema_depth", 2, "number of levels a self-referential message is expanded to when `recursive_schema_mode=unroll`")
+	inlineRefs                 = flag.Bool("inline_refs", false, "if set, every $ref is replaced with the definition it points to, for consumers that cannot resolve references. References are kept where needed to break cycles, see also `recursive_schema_mode`.")
+	tagBy                      = flag.String("tag_by", "service", "configures what operations are tagged by. Allowed values are `service` (the gRPC service name) and `package` (the proto package, falling back to the service name for files without a package).")
+	deprecationNote            = flag.String("deprecation_note", "", "if set, the note is appended to the description of methods, fields and enum values marked `deprecated = true`")
 )
 
+var (
+	produces        stringList
+	consumes        stringList
+	servers         stringList
+	serverVariables stringList
+	wktFormats      stringList
+	tagOrder        stringList
+	tagGroups       stringList
+	descriptorSets  stringList
+)
+
+func init() {
+	flag.Var(&produces, "produces", "a MIME type the API can produce, e.g. one registered with runtime.WithMarshalerOption. May be given multiple times, replaces the default `application/json`.")
+	flag.Var(&consumes, "consumes", "a MIME type the API can consume, e.g. one registered with runtime.WithMarshalerOption. May be given multiple times, replaces the default `application/json`.")
+	flag.Var(&servers, "server", "a server the API is available at, as `name=url`, e.g. `prod=https://api.example.com/v1`. The URL may contain `{variable}` placeholders and `$ENV` references, which are expanded at generation time. May be given multiple times.")
+	flag.Var(&serverVariables, "server_variable", "the default value of a server URL placeholder, as `variable=default`, e.g. `region=us-east-1`. May be given multiple times.")
+	flag.Var(&tagOrder, "tag_order", "a tag to list first in the tags of the OpenAPI output. May be given multiple times, tags are listed in the given order followed by the remaining tags in declaration order.")
+	flag.Var(&tagGroups, "tag_group", "a group of tags rendered in the `x-tagGroups` extension, as `name=tag1:tag2`. May be given multiple times.")
+	flag.Var(&descriptorSets, "descriptor_set_in", "path to a binary FileDescriptorSet, e.g. built with `buf build -o`, whose types are loaded in addition to those of the request, e.g. for a gRPC API Configuration spanning several modules. May be given multiple times, files in several sets must have the same definitions.")
+	flag.Var(&wktFormats, "wkt_format", "the format the schemas of a well-known type are rendered in, as `type=format`. Allowed values are `Timestamp=date-time` (default) or `Timestamp=unix`, `Duration=string` (default), `Duration=pattern` or `Duration=seconds`, `FieldMask=array` (default) or `FieldMask=string`, and `wrappers=value` (default) or `wrappers=nullable`. May be given multiple times.")
+}
+
+// stringList is a flag.Value collecting every value given to a repeated flag.
+type stringList []string
+
+func (m *stringList) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *stringList) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
 // Variables set by goreleaser at build time
 var (
 	version = "dev"
@@ -89,13 +132,68 @@ func main() {
 	reg.SetDisableDefaultErrors(*disableDefaultErrors)
 	reg.SetSimpleOperationIDs(*simpleOperationIDs)
 	reg.SetGenerateUnboundMethods(*generateUnboundMethods)
+	if err := reg.SetUnboundMethodsPattern(*unboundMethodsPattern); err != nil {
+		emitError(err)
+		return
+	}
+	reg.SetProduces(produces)
+	reg.SetConsumes(consumes)
+	reg.SetInlineRefs(*inlineRefs)
+	reg.SetDeprecationNote(*deprecationNote)
+	if err := reg.SetServers(servers); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetServerVariables(serverVariables); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetTagBy(*tagBy); err != nil {
+		emitError(err)
+		return
+	}
+	reg.SetTagOrder(tagOrder)
+	if err := reg.SetTagGroups(tagGroups); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetWKTFormats(wktFormats); err != nil {
+		emitError(err)
+		return
+	}
 	if err := reg.SetRepeatedPathParamSeparator(*repeatedPathParamSeparator); err != nil {
 		emitError(err)
 		return
 	}
+	if err := reg.SetOutputFormat(*outputFormat); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetStreamingFormat(*streamingFormat); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetValidationRulesPrecedence(*validationRulesPrecedence); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetRecursiveSchemaMode(*recursiveSchemaMode); err != nil {
+		emitError(err)
+		return
+	}
+	if err := reg.SetRecursiveSchemaDepth(*recursiveSchemaDepth); err != nil {
+		emitError(err)
+		return
+	}
 	for k, v := range pkgMap {
 		reg.AddPkgMap(k, v)
 	}
+	for _, set := range descriptorSets {
+		if err := reg.LoadFileDescriptorSetFromFile(set); err != nil {
+			emitError(err)
+			return
+		}
+	}
 
 	if *grpcAPIConfiguration != "" {
 		if err := reg.LoadGrpcAPIServiceFromYAML(*grpcAPIConfiguration); err != nil {
@@ -146,7 +244,9 @@ func emitFiles(out []*descriptor.ResponseFile) {
 	for idx, item := range out {
 		files[idx] = item.CodeGeneratorResponse_File
 	}
-	emitResp(&pluginpb.CodeGeneratorResponse{File: files})
+	resp := &pluginpb.CodeGeneratorResponse{File: files}
+	codegenerator.SetSupportedFeatures(resp)
+	emitResp(resp)
 }
 
 func emitError(err error) {
@@ -184,3 +184,21 @@ func clearFlags() {
 	*includePackageInTags = false
 	*mergeFileName = "apidocs"
 }
+
+func TestParseReqParamMediaTypes(t *testing.T) {
+	f := flag.CommandLine
+	defer func() {
+		produces = nil
+		consumes = nil
+	}()
+	err := parseReqParam("produces=application/json,produces=application/x-protobuf,consumes=application/x-protobuf", f, map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected parse error '%v'", err)
+	}
+	if want := (stringList{"application/json", "application/x-protobuf"}); !reflect.DeepEqual(produces, want) {
+		t.Errorf("produces misparsed, expected '%v', got '%v'", want, produces)
+	}
+	if want := (stringList{"application/x-protobuf"}); !reflect.DeepEqual(consumes, want) {
+		t.Errorf("consumes misparsed, expected '%v', got '%v'", want, consumes)
+	}
+}
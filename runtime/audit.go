@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RedactedString replaces the values of the sensitive string fields of the
+// messages redacted by Redact.
+const RedactedString = "[REDACTED]"
+
+// Field numbers of the field options marking sensitive fields: debug_redact,
+// see google/protobuf/descriptor.proto, and the sensitive field of the
+// gateway options held by the openapiv2_field extension. They are read from
+// the serialized options, as the vendored descriptor does not declare
+// debug_redact and the extension may not be linked in.
+const (
+	debugRedactFieldNumber protowire.Number = 16
+	openapiv2FieldNumber   protowire.Number = 1042
+	fieldGatewayNumber     protowire.Number = 1001
+	gatewaySensitiveNumber protowire.Number = 1
+)
+
+// AuditRecord describes a call to a unary method, with the sensitive fields
+// of its request and response redacted.
+type AuditRecord struct {
+	// RPCMethod is the method called, in the format of "/package.service/method".
+	RPCMethod string
+	// Request is the decoded request.
+	Request proto.Message
+	// Response is the response, nil if the call failed.
+	Response proto.Message
+	// Err is the error of the call, if any.
+	Err error
+}
+
+// AuditFunc is the signature used to log the calls audited with Audit.
+type AuditFunc func(ctx context.Context, record AuditRecord)
+
+type auditKey struct{}
+
+// WithAuditLog returns a ServeMuxOption passing the calls of the handlers
+// generated with audit_log=true to fn, e.g. for compliance logging. The
+// fields of the messages marked with the debug_redact field option or the
+// sensitive gateway option are redacted.
+func WithAuditLog(fn AuditFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.audit = fn
+	}
+}
+
+// Audit passes the call of a unary method with req, resp and err to the
+// AuditFunc of the ServeMux which annotated the context, if any. The messages
+// are redacted with Redact, so that the AuditFunc may keep them.
+func Audit(ctx context.Context, req, resp proto.Message, err error) {
+	fn, ok := ctx.Value(auditKey{}).(AuditFunc)
+	if !ok {
+		return
+	}
+	record := AuditRecord{Request: Redact(req), Err: err}
+	record.RPCMethod, _ = RPCMethod(ctx)
+	if err == nil {
+		record.Response = Redact(resp)
+	}
+	fn(ctx, record)
+}
+
+func withAudit(ctx context.Context, fn AuditFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, auditKey{}, fn)
+}
+
+// Redact returns a copy of msg whose sensitive fields, marked with the
+// debug_redact field option or the sensitive gateway option, are masked, in
+// msg and in the messages it holds. The sensitive strings are replaced with
+// RedactedString and the other sensitive values are cleared.
+func Redact(msg proto.Message) proto.Message {
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return msg
+	}
+	clone := proto.Clone(msg)
+	redact(clone.ProtoReflect())
+	return clone
+}
+
+func redact(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case isSensitive(fd):
+			redactField(m, fd)
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					redact(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				l := v.List()
+				for i := 0; i < l.Len(); i++ {
+					redact(l.Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			redact(v.Message())
+		}
+		return true
+	})
+}
+
+func redactField(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	switch {
+	case fd.Kind() != protoreflect.StringKind || fd.IsMap():
+		m.Clear(fd)
+	case fd.IsList():
+		l := m.Mutable(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			l.Set(i, protoreflect.ValueOfString(RedactedString))
+		}
+	default:
+		m.Set(fd, protoreflect.ValueOfString(RedactedString))
+	}
+}
+
+// sensitiveFields caches whether the field descriptors are sensitive.
+var sensitiveFields sync.Map
+
+// isSensitive returns whether fd is marked with the debug_redact field option
+// or the sensitive gateway option.
+func isSensitive(fd protoreflect.FieldDescriptor) bool {
+	if v, ok := sensitiveFields.Load(fd); ok {
+		return v.(bool)
+	}
+	sensitive := false
+	if b, err := proto.Marshal(fd.Options()); err == nil {
+		gateway := messageFields(messageFields(b, openapiv2FieldNumber), fieldGatewayNumber)
+		sensitive = validateRulesBool(b, debugRedactFieldNumber) || validateRulesBool(gateway, gatewaySensitiveNumber)
+	}
+	sensitiveFields.Store(fd, sensitive)
+	return sensitive
+}
+
+// messageFields returns the concatenated payloads of the message fields
+// numbered num of b, the wire encoding of a message, which are merged as one.
+func messageFields(b []byte, num protowire.Number) []byte {
+	var fields []byte
+	rangeRuleFields(b, func(n protowire.Number, typ protowire.Type, v []byte) {
+		if n == num && typ == protowire.BytesType {
+			fields = append(fields, v...)
+		}
+	})
+	return fields
+}
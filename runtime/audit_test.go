@@ -0,0 +1,151 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// sensitiveOption returns field options with the given fields, as unknown
+// fields.
+func sensitiveOption(fields ...[]byte) *descriptorpb.FieldOptions {
+	opts := &descriptorpb.FieldOptions{}
+	opts.ProtoReflect().SetUnknown(bytes.Join(fields, nil))
+	return opts
+}
+
+// newAuditedMessage returns a dynamic message with a plain field, sensitive
+// fields and a nested message of the same type.
+func newAuditedMessage(t *testing.T) *dynamicpb.Message {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, opts *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    optional,
+			Type:     typ.Enum(),
+			Options:  opts,
+		}
+	}
+	// openapiv2_field = {gateway: {sensitive: true}}
+	tokens := field("tokens", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, sensitiveOption(ruleMessage(1042, ruleMessage(1001, ruleVarint(1, 1)))))
+	tokens.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	child := field("child", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, nil)
+	child.TypeName = proto.String(".example.AuditedMessage")
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("audited.proto"),
+		Package: proto.String("example"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("AuditedMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, nil),
+				// debug_redact = true
+				field("email", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, sensitiveOption(ruleVarint(16, 1))),
+				tokens,
+				field("pin", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, sensitiveOption(ruleVarint(16, 1))),
+				child,
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, new(protoregistry.Files))
+	if err != nil {
+		t.Fatalf("protodesc.NewFile(%v) failed with %v; want success", fd, err)
+	}
+	return dynamicpb.NewMessage(file.Messages().Get(0))
+}
+
+func setAuditedFields(msg *dynamicpb.Message) {
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("name"), protoreflect.ValueOfString("alice"))
+	msg.Set(fields.ByName("email"), protoreflect.ValueOfString("alice@example.com"))
+	tokens := msg.Mutable(fields.ByName("tokens")).List()
+	tokens.Append(protoreflect.ValueOfString("secret1"))
+	tokens.Append(protoreflect.ValueOfString("secret2"))
+	msg.Set(fields.ByName("pin"), protoreflect.ValueOfInt32(1234))
+}
+
+func TestRedact(t *testing.T) {
+	msg := newAuditedMessage(t)
+	setAuditedFields(msg)
+	child := msg.Mutable(msg.Descriptor().Fields().ByName("child")).Message().Interface().(*dynamicpb.Message)
+	setAuditedFields(child)
+
+	redacted := runtime.Redact(msg).(*dynamicpb.Message)
+	for _, m := range []*dynamicpb.Message{redacted, redacted.Get(redacted.Descriptor().Fields().ByName("child")).Message().Interface().(*dynamicpb.Message)} {
+		fields := m.Descriptor().Fields()
+		if got, want := m.Get(fields.ByName("name")).String(), "alice"; got != want {
+			t.Errorf("name = %q; want %q", got, want)
+		}
+		if got, want := m.Get(fields.ByName("email")).String(), runtime.RedactedString; got != want {
+			t.Errorf("email = %q; want %q", got, want)
+		}
+		tokens := m.Get(fields.ByName("tokens")).List()
+		if tokens.Len() != 2 {
+			t.Errorf("len(tokens) = %d; want 2", tokens.Len())
+		}
+		for i := 0; i < tokens.Len(); i++ {
+			if got, want := tokens.Get(i).String(), runtime.RedactedString; got != want {
+				t.Errorf("tokens[%d] = %q; want %q", i, got, want)
+			}
+		}
+		if m.Has(fields.ByName("pin")) {
+			t.Errorf("pin = %v; want cleared", m.Get(fields.ByName("pin")))
+		}
+	}
+
+	if got, want := msg.Get(msg.Descriptor().Fields().ByName("email")).String(), "alice@example.com"; got != want {
+		t.Errorf("email of the original = %q; want %q", got, want)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var records []runtime.AuditRecord
+	mux := runtime.NewServeMux(runtime.WithAuditLog(func(_ context.Context, record runtime.AuditRecord) {
+		records = append(records, record)
+	}))
+	r := httptest.NewRequest("POST", "/v1/users", nil)
+	ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/CreateUser")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+	}
+
+	req, resp := newAuditedMessage(t), newAuditedMessage(t)
+	setAuditedFields(req)
+	setAuditedFields(resp)
+	runtime.Audit(ctx, req, resp, nil)
+	callErr := errors.New("failed")
+	runtime.Audit(ctx, req, resp, callErr)
+	// Contexts not annotated by a mux with an audit log are not audited.
+	runtime.Audit(r.Context(), req, resp, nil)
+
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d; want 2", len(records))
+	}
+	for i, record := range records {
+		if got, want := record.RPCMethod, "/example.Example/CreateUser"; got != want {
+			t.Errorf("records[%d].RPCMethod = %q; want %q", i, got, want)
+		}
+		email := record.Request.ProtoReflect().Get(req.Descriptor().Fields().ByName("email")).String()
+		if email != runtime.RedactedString {
+			t.Errorf("records[%d].Request email = %q; want %q", i, email, runtime.RedactedString)
+		}
+	}
+	if records[0].Response == nil || records[0].Err != nil {
+		t.Errorf("records[0] = %+v; want a response and no error", records[0])
+	}
+	if records[1].Response != nil || records[1].Err != callErr {
+		t.Errorf("records[1] = %+v; want no response and the error", records[1])
+	}
+}
@@ -0,0 +1,162 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationRequirements are the requirements declared by the
+// authorization field of the gateway options of a method, which the generated
+// handlers check with Authorize.
+type AuthorizationRequirements struct {
+	// Roles are the roles of which the caller must have at least one.
+	Roles []string
+	// Scopes are the scopes the caller must all have.
+	Scopes []string
+	// AllowUnauthenticated allows the callers without credentials.
+	AllowUnauthenticated bool
+}
+
+// Credentials are the credentials of the caller of a request.
+type Credentials struct {
+	// Subject identifies the caller.
+	Subject string
+	// Roles are the roles of the caller.
+	Roles []string
+	// Scopes are the scopes granted to the caller.
+	Scopes []string
+}
+
+// CredentialsFunc is the signature used to parse the credentials of the
+// requests. It returns nil credentials for unauthenticated requests.
+type CredentialsFunc func(*http.Request) (*Credentials, error)
+
+// Authorizer decides whether the caller of a request to an annotated method
+// meets the requirements of the method.
+type Authorizer interface {
+	// Authorize returns a non-nil error to reject the request to rpcMethod
+	// with credentials, which are nil if the request is unauthenticated. The
+	// request is rejected with the error if it is a gRPC status, or else with
+	// PermissionDenied.
+	Authorize(ctx context.Context, rpcMethod string, requirements AuthorizationRequirements, credentials *Credentials) error
+}
+
+// AuthorizerFunc adapts a function to an Authorizer.
+type AuthorizerFunc func(ctx context.Context, rpcMethod string, requirements AuthorizationRequirements, credentials *Credentials) error
+
+// Authorize calls f.
+func (f AuthorizerFunc) Authorize(ctx context.Context, rpcMethod string, requirements AuthorizationRequirements, credentials *Credentials) error {
+	return f(ctx, rpcMethod, requirements, credentials)
+}
+
+// DefaultAuthorizer rejects the unauthenticated requests with Unauthenticated
+// unless they are allowed, and the callers without one of the roles or with
+// a missing scope with PermissionDenied.
+var DefaultAuthorizer Authorizer = AuthorizerFunc(authorizeRequirements)
+
+func authorizeRequirements(_ context.Context, _ string, requirements AuthorizationRequirements, credentials *Credentials) error {
+	if credentials == nil {
+		if requirements.AllowUnauthenticated {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	if len(requirements.Roles) > 0 && !containsAny(credentials.Roles, requirements.Roles) {
+		return status.Error(codes.PermissionDenied, "missing role")
+	}
+	for _, scope := range requirements.Scopes {
+		if !containsAny(credentials.Scopes, []string{scope}) {
+			return status.Errorf(codes.PermissionDenied, "missing scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DefaultCredentials parses the credentials from the claims of the bearer
+// token validated with WithTokenIntrospection: the subject from "sub", the
+// space-separated scopes from "scope" and the roles from "roles". The requests
+// without claims are unauthenticated.
+func DefaultCredentials(req *http.Request) (*Credentials, error) {
+	claims, ok := TokenClaims(req.Context())
+	if !ok {
+		return nil, nil
+	}
+	credentials := &Credentials{}
+	credentials.Subject, _ = claims["sub"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		credentials.Scopes = strings.Fields(scope)
+	}
+	switch roles := claims["roles"].(type) {
+	case string:
+		credentials.Roles = strings.Fields(roles)
+	case []interface{}:
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				credentials.Roles = append(credentials.Roles, role)
+			}
+		}
+	}
+	return credentials, nil
+}
+
+// WithAuthorizer returns a ServeMuxOption checking the requests to the methods
+// with an authorization annotation with authorizer, instead of
+// DefaultAuthorizer.
+func WithAuthorizer(authorizer Authorizer) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.authorizer = authorizer
+	}
+}
+
+// WithCredentials returns a ServeMuxOption parsing the credentials given to
+// the Authorizer with fn, instead of DefaultCredentials.
+func WithCredentials(fn CredentialsFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.credentials = fn
+	}
+}
+
+// Authorize checks that the caller of req meets the requirements of the
+// method of ctx with the Authorizer of mux. It is called by the generated
+// handlers of the methods with an authorization annotation, which reply with
+// the returned error if any.
+func Authorize(ctx context.Context, mux *ServeMux, req *http.Request, requirements AuthorizationRequirements) error {
+	parse := mux.credentials
+	if parse == nil {
+		parse = DefaultCredentials
+	}
+	credentials, err := parse(req)
+	if err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Error(codes.Unauthenticated, err.Error())
+		}
+		return err
+	}
+	authorizer := mux.authorizer
+	if authorizer == nil {
+		authorizer = DefaultAuthorizer
+	}
+	rpcMethod, _ := RPCMethod(ctx)
+	if err := authorizer.Authorize(ctx, rpcMethod, requirements, credentials); err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Error(codes.PermissionDenied, err.Error())
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorize(t *testing.T) {
+	credentials := func(r *http.Request) (*runtime.Credentials, error) {
+		switch r.Header.Get("X-User") {
+		case "":
+			return nil, nil
+		case "admin":
+			return &runtime.Credentials{Subject: "admin", Roles: []string{"admin"}, Scopes: []string{"read", "write"}}, nil
+		default:
+			return &runtime.Credentials{Subject: "reader", Roles: []string{"reader"}, Scopes: []string{"read"}}, nil
+		}
+	}
+	for _, spec := range []struct {
+		name         string
+		user         string
+		requirements runtime.AuthorizationRequirements
+		code         codes.Code
+	}{
+		{
+			name: "unauthenticated",
+			code: codes.Unauthenticated,
+		},
+		{
+			name:         "unauthenticated allowed",
+			requirements: runtime.AuthorizationRequirements{AllowUnauthenticated: true},
+			code:         codes.OK,
+		},
+		{
+			name:         "one of the roles",
+			user:         "reader",
+			requirements: runtime.AuthorizationRequirements{Roles: []string{"admin", "reader"}},
+			code:         codes.OK,
+		},
+		{
+			name:         "missing role",
+			user:         "reader",
+			requirements: runtime.AuthorizationRequirements{Roles: []string{"admin"}},
+			code:         codes.PermissionDenied,
+		},
+		{
+			name:         "all the scopes",
+			user:         "admin",
+			requirements: runtime.AuthorizationRequirements{Scopes: []string{"read", "write"}},
+			code:         codes.OK,
+		},
+		{
+			name:         "missing scope",
+			user:         "reader",
+			requirements: runtime.AuthorizationRequirements{Scopes: []string{"read", "write"}},
+			code:         codes.PermissionDenied,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithCredentials(credentials))
+			r := httptest.NewRequest("DELETE", "/v1/books/1", nil)
+			if spec.user != "" {
+				r.Header.Set("X-User", spec.user)
+			}
+			err := runtime.Authorize(r.Context(), mux, r, spec.requirements)
+			if got := status.Code(err); got != spec.code {
+				t.Errorf("runtime.Authorize(ctx, mux, r, %+v) = %v; want code %v", spec.requirements, err, spec.code)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWithAuthorizer(t *testing.T) {
+	var rpcMethod string
+	var subject string
+	mux := runtime.NewServeMux(
+		runtime.WithCredentials(func(*http.Request) (*runtime.Credentials, error) {
+			return &runtime.Credentials{Subject: "alice"}, nil
+		}),
+		runtime.WithAuthorizer(runtime.AuthorizerFunc(func(_ context.Context, method string, _ runtime.AuthorizationRequirements, credentials *runtime.Credentials) error {
+			rpcMethod, subject = method, credentials.Subject
+			return errors.New("denied by policy")
+		})),
+	)
+	r := httptest.NewRequest("DELETE", "/v1/books/1", nil)
+	ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/DeleteBook")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+	}
+	err = runtime.Authorize(ctx, mux, r, runtime.AuthorizationRequirements{Roles: []string{"admin"}})
+	if got, want := status.Code(err), codes.PermissionDenied; got != want {
+		t.Errorf("runtime.Authorize(ctx, mux, r, requirements) = %v; want code %v", err, want)
+	}
+	if want := "/example.Example/DeleteBook"; rpcMethod != want {
+		t.Errorf("rpcMethod = %q; want %q", rpcMethod, want)
+	}
+	if want := "alice"; subject != want {
+		t.Errorf("credentials.Subject = %q; want %q", subject, want)
+	}
+}
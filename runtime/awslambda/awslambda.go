@@ -0,0 +1,79 @@
+// Package awslambda serves the proxy events of AWS Lambda with an
+// http.Handler, e.g. a runtime.ServeMux, so that a gateway can be deployed
+// as a function behind API Gateway or an Application Load Balancer without
+// other glue.
+package awslambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Handler serves the proxy events of the REST APIs (payload format 1.0) and
+// HTTP APIs (payload format 2.0) of API Gateway, of the Lambda function URLs
+// and of the ALBs with an http.Handler.
+//
+// Handler implements the Handler interface of
+// github.com/aws/aws-lambda-go/lambda, to be started with
+// lambda.StartHandler(awslambda.NewHandler(mux)).
+type Handler struct {
+	handler http.Handler
+}
+
+// NewHandler returns a Handler serving the events with h.
+func NewHandler(h http.Handler) *Handler {
+	return &Handler{handler: h}
+}
+
+// Invoke serves the event payload and returns its response.
+//
+// The base64 bodies of the events are decoded, and the multi-value headers
+// and query parameters are preferred over the single-value ones when the
+// events have both. The responses are buffered, server streams included,
+// and their bodies are base64-encoded unless their Content-Type is textual.
+// They have multi-value headers unless the events are from the HTTP APIs or
+// from ALBs without multi-value headers.
+func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var e event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, fmt.Errorf("awslambda: parsing event: %w", err)
+	}
+	r, err := e.request(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("awslambda: %w", err)
+	}
+	w := &bufferedWriter{header: make(http.Header)}
+	h.handler.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return json.Marshal(e.response(w.status, w.header, w.body.Bytes()))
+}
+
+// bufferedWriter is a response writer keeping the response in memory.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// Flush is a no-op, the response being sent as a whole.
+func (w *bufferedWriter) Flush() {}
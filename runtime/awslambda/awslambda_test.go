@@ -0,0 +1,210 @@
+package awslambda_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/awslambda"
+)
+
+// request is the request the handler of the tests received.
+type request struct {
+	method     string
+	uri        string
+	host       string
+	remoteAddr string
+	values     []string
+	cookie     string
+	body       string
+}
+
+func newMux(t *testing.T, got *request, contentType string, respBody []byte) *runtime.ServeMux {
+	mux := runtime.NewServeMux()
+	if err := mux.HandlePath("POST", "/v1/{name=orders/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("ioutil.ReadAll(r.Body) failed with %v; want success", err)
+		}
+		*got = request{
+			method:     r.Method,
+			uri:        r.URL.RequestURI(),
+			host:       r.Host,
+			remoteAddr: r.RemoteAddr,
+			values:     r.Header.Values("X-Value"),
+			cookie:     r.Header.Get("Cookie"),
+			body:       string(body),
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.WriteHeader(http.StatusCreated)
+		if _, err := w.Write(respBody); err != nil {
+			t.Errorf("w.Write(body) failed with %v; want success", err)
+		}
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/{name=orders/*}", err)
+	}
+	return mux
+}
+
+func TestHandlerInvoke(t *testing.T) {
+	for _, spec := range []struct {
+		name        string
+		event       string
+		contentType string
+		respBody    []byte
+		want        request
+		wantResp    map[string]interface{}
+	}{
+		{
+			name: "rest api",
+			event: `{
+				"httpMethod": "POST",
+				"path": "/v1/orders/1",
+				"queryStringParameters": {"q": "last"},
+				"multiValueQueryStringParameters": {"q": ["a b", "last"]},
+				"headers": {"Host": "api.example.com", "X-Value": "2"},
+				"multiValueHeaders": {"Host": ["api.example.com"], "X-Value": ["1", "2"]},
+				"body": "eyJpZCI6MX0=",
+				"isBase64Encoded": true,
+				"requestContext": {"identity": {"sourceIp": "192.0.2.1"}}
+			}`,
+			contentType: "application/json",
+			respBody:    []byte(`{"id":1}`),
+			want: request{
+				method:     "POST",
+				uri:        "/v1/orders/1?q=a+b&q=last",
+				host:       "api.example.com",
+				remoteAddr: "192.0.2.1:0",
+				values:     []string{"1", "2"},
+				body:       `{"id":1}`,
+			},
+			wantResp: map[string]interface{}{
+				"statusCode": 201.0,
+				"multiValueHeaders": map[string]interface{}{
+					"Content-Type": []interface{}{"application/json"},
+					"Set-Cookie":   []interface{}{"a=1", "b=2"},
+				},
+				"body":            `{"id":1}`,
+				"isBase64Encoded": false,
+			},
+		},
+		{
+			name: "http api",
+			event: `{
+				"version": "2.0",
+				"rawPath": "/v1/orders/1",
+				"rawQueryString": "q=a%20b",
+				"cookies": ["c=1", "d=2"],
+				"headers": {"host": "api.example.com", "x-value": "1,2"},
+				"body": "{\"id\":1}",
+				"requestContext": {"http": {"method": "POST", "sourceIp": "2001:db8::1"}}
+			}`,
+			contentType: "application/octet-stream",
+			respBody:    []byte{0xff, 0x00},
+			want: request{
+				method:     "POST",
+				uri:        "/v1/orders/1?q=a%20b",
+				host:       "api.example.com",
+				remoteAddr: "[2001:db8::1]:0",
+				values:     []string{"1,2"},
+				cookie:     "c=1; d=2",
+				body:       `{"id":1}`,
+			},
+			wantResp: map[string]interface{}{
+				"statusCode": 201.0,
+				"headers": map[string]interface{}{
+					"Content-Type": "application/octet-stream",
+				},
+				"cookies":         []interface{}{"a=1", "b=2"},
+				"body":            "/wA=",
+				"isBase64Encoded": true,
+			},
+		},
+		{
+			name: "alb",
+			event: `{
+				"httpMethod": "POST",
+				"path": "/v1/orders/1",
+				"queryStringParameters": {"q": "a%20b"},
+				"headers": {"host": "api.example.com", "x-value": "1"},
+				"body": "",
+				"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/gateway/1"}}
+			}`,
+			contentType: "text/plain; charset=utf-8",
+			respBody:    []byte("created"),
+			want: request{
+				method: "POST",
+				uri:    "/v1/orders/1?q=a+b",
+				host:   "api.example.com",
+				values: []string{"1"},
+			},
+			wantResp: map[string]interface{}{
+				"statusCode":        201.0,
+				"statusDescription": "201 Created",
+				"headers": map[string]interface{}{
+					"Content-Type": "text/plain; charset=utf-8",
+					"Set-Cookie":   "b=2",
+				},
+				"body":            "created",
+				"isBase64Encoded": false,
+			},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			var got request
+			h := awslambda.NewHandler(newMux(t, &got, spec.contentType, spec.respBody))
+			b, err := h.Invoke(context.Background(), []byte(spec.event))
+			if err != nil {
+				t.Fatalf("h.Invoke(ctx, event) failed with %v; want success", err)
+			}
+			if !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("request = %+v; want %+v", got, spec.want)
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(b, &resp); err != nil {
+				t.Fatalf("json.Unmarshal(%s, &resp) failed with %v; want success", b, err)
+			}
+			if !reflect.DeepEqual(resp, spec.wantResp) {
+				t.Errorf("h.Invoke(ctx, event) = %s; want %v", b, spec.wantResp)
+			}
+		})
+	}
+}
+
+func TestHandlerInvokeErrors(t *testing.T) {
+	h := awslambda.NewHandler(runtime.NewServeMux())
+	for _, event := range []string{
+		`not json`,
+		`{"httpMethod": "POST", "path": "/", "body": "!", "isBase64Encoded": true}`,
+	} {
+		if b, err := h.Invoke(context.Background(), []byte(event)); err == nil {
+			t.Errorf("h.Invoke(ctx, %s) = %s; want an error", event, b)
+		}
+	}
+}
+
+func TestHandlerHandleStream(t *testing.T) {
+	var got request
+	h := awslambda.NewHandler(newMux(t, &got, "application/x-ndjson", []byte("{\"id\":1}\n")))
+	event := `{
+		"version": "2.0",
+		"rawPath": "/v1/orders/1",
+		"requestContext": {"http": {"method": "POST"}}
+	}`
+	var buf bytes.Buffer
+	if err := h.HandleStream(context.Background(), []byte(event), &buf); err != nil {
+		t.Fatalf("h.HandleStream(ctx, event, w) failed with %v; want success", err)
+	}
+	want := `{"statusCode":201,"headers":{"Content-Type":"application/x-ndjson"},"cookies":["a=1","b=2"]}` +
+		"\x00\x00\x00\x00\x00\x00\x00\x00" + "{\"id\":1}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("h.HandleStream(ctx, event, w) wrote %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package awslambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// event is a proxy event of API Gateway, with either payload format, or of
+// an ALB.
+type event struct {
+	// Version is "2.0" for the payload format 2.0 of the HTTP APIs.
+	Version string `json:"version"`
+
+	// HTTPMethod, Path, the query string parameters and Headers or
+	// MultiValueHeaders are those of the payload format 1.0 and of the ALBs.
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+
+	// RawPath, RawQueryString and Cookies are those of the payload format
+	// 2.0, whose Headers join their values with commas.
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  struct {
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		ELB *struct {
+			TargetGroupArn string `json:"targetGroupArn"`
+		} `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// response is the response to a proxy event. The responses to the payload
+// format 2.0 have Cookies and no MultiValueHeaders, and those to the ALBs
+// have a StatusDescription.
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+func (e *event) isV2() bool {
+	return e.Version == "2.0"
+}
+
+func (e *event) isALB() bool {
+	return e.RequestContext.ELB != nil
+}
+
+// request returns the HTTP request of e.
+func (e *event) request(ctx context.Context) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+			return nil, fmt.Errorf("decoding base64 body: %w", err)
+		}
+	}
+
+	u := &url.URL{}
+	method, sourceIP := e.HTTPMethod, e.RequestContext.Identity.SourceIP
+	header := make(http.Header)
+	if e.isV2() {
+		method, sourceIP = e.RequestContext.HTTP.Method, e.RequestContext.HTTP.SourceIP
+		u.Path = e.RawPath
+		u.RawQuery = e.RawQueryString
+		for k, v := range e.Headers {
+			header.Set(k, v)
+		}
+		if len(e.Cookies) > 0 {
+			header.Set("Cookie", strings.Join(e.Cookies, "; "))
+		}
+	} else {
+		u.Path = e.Path
+		u.RawQuery = e.query().Encode()
+		if e.MultiValueHeaders != nil {
+			for k, vs := range e.MultiValueHeaders {
+				for _, v := range vs {
+					header.Add(k, v)
+				}
+			}
+		} else {
+			for k, v := range e.Headers {
+				header.Set(k, v)
+			}
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = header
+	r.Host = header.Get("Host")
+	header.Del("Host")
+	r.RequestURI = u.RequestURI()
+	if sourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+	}
+	return r, nil
+}
+
+// query returns the query of an event with the payload format 1.0 or of an
+// ALB, whose parameters are still percent-encoded.
+func (e *event) query() url.Values {
+	query := make(url.Values)
+	unescape := func(s string) string {
+		if !e.isALB() {
+			return s
+		}
+		if unescaped, err := url.QueryUnescape(s); err == nil {
+			return unescaped
+		}
+		return s
+	}
+	if e.MultiValueQueryStringParameters != nil {
+		for k, vs := range e.MultiValueQueryStringParameters {
+			for _, v := range vs {
+				query.Add(unescape(k), unescape(v))
+			}
+		}
+		return query
+	}
+	for k, v := range e.QueryStringParameters {
+		query.Add(unescape(k), unescape(v))
+	}
+	return query
+}
+
+// response returns the response to e with status, header and body.
+func (e *event) response(status int, header http.Header, body []byte) *response {
+	resp := &response{StatusCode: status}
+	if isText(header.Get("Content-Type"), body) {
+		resp.Body = string(body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.IsBase64Encoded = true
+	}
+	switch {
+	case e.isV2():
+		resp.Headers = make(map[string]string, len(header))
+		for k, vs := range header {
+			if k == "Set-Cookie" {
+				resp.Cookies = vs
+				continue
+			}
+			resp.Headers[k] = strings.Join(vs, ",")
+		}
+	case e.isALB() && e.MultiValueHeaders == nil:
+		// The ALBs without multi-value headers reply with a single value
+		// per header.
+		resp.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		resp.Headers = make(map[string]string, len(header))
+		for k, vs := range header {
+			resp.Headers[k] = vs[len(vs)-1]
+		}
+	default:
+		if e.isALB() {
+			resp.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		}
+		resp.MultiValueHeaders = header
+	}
+	return resp
+}
+
+// isText determines if the body with contentType can be written as is in
+// the responses, which are otherwise base64-encoded.
+func isText(contentType string, body []byte) bool {
+	if !utf8.Valid(body) {
+		return false
+	}
+	if contentType == "" {
+		return len(body) == 0
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if _, ok := params["charset"]; ok {
+		return true
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/x-ndjson", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamContentType is the Content-Type of the responses HandleStream
+// writes, to be given to the Lambda runtime API along with the streaming
+// response mode.
+const StreamContentType = "application/vnd.awslambda.http-integration-response"
+
+// streamDelimiter separates the prelude of the streamed responses from
+// their body.
+var streamDelimiter = make([]byte, 8)
+
+// streamPrelude is the prelude of the streamed responses.
+type streamPrelude struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Cookies    []string          `json:"cookies,omitempty"`
+}
+
+// HandleStream serves the event payload and streams its response to w, for
+// the functions whose invoke mode is RESPONSE_STREAM, e.g. behind a Lambda
+// function URL, so that the server streams reach the clients as they are
+// written rather than once they end.
+//
+// The response is written in the format of the HTTP integrations: a JSON
+// prelude with the status, headers and cookies of the response, eight null
+// bytes and then the body as is. The chunks of the body are flushed if w is
+// an http.Flusher or has a Flush method returning an error.
+func (h *Handler) HandleStream(ctx context.Context, payload []byte, w io.Writer) error {
+	var e event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return fmt.Errorf("awslambda: parsing event: %w", err)
+	}
+	r, err := e.request(ctx)
+	if err != nil {
+		return fmt.Errorf("awslambda: %w", err)
+	}
+	sw := &streamWriter{w: w, header: make(http.Header)}
+	h.handler.ServeHTTP(sw, r)
+	sw.WriteHeader(http.StatusOK)
+	return sw.err
+}
+
+// streamWriter is a response writer streaming the response in the format
+// of the HTTP integrations.
+type streamWriter struct {
+	w      io.Writer
+	header http.Header
+
+	wroteHeader bool
+	// err is the first error writing to w.
+	err error
+}
+
+func (w *streamWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	prelude := streamPrelude{StatusCode: status, Headers: make(map[string]string, len(w.header))}
+	for k, vs := range w.header {
+		if k == "Set-Cookie" {
+			prelude.Cookies = vs
+			continue
+		}
+		prelude.Headers[k] = strings.Join(vs, ",")
+	}
+	b, err := json.Marshal(prelude)
+	if err != nil {
+		w.err = err
+		return
+	}
+	if _, err := w.w.Write(append(b, streamDelimiter...)); err != nil {
+		w.err = err
+	}
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *streamWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+	switch f := w.w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface{ Flush() error }:
+		if err := f.Flush(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BackendSelectorFunc is the signature of the callbacks selecting the
+// connection a call is made on given the route of its request, e.g. a replica
+// pool for the read methods and the primary for the others. ctx is the
+// context of the call.
+type BackendSelectorFunc func(ctx context.Context, route Route) grpc.ClientConnInterface
+
+// NewBackendSelector returns a connection making each call on the connection
+// fn selects for the route of its request to mux. It is to be given to the
+// generated NewXXXClient functions, whose clients are then registered with
+// the generated RegisterXXXHandlerClient functions:
+//
+//	conn := runtime.NewBackendSelector(mux, func(ctx context.Context, route runtime.Route) grpc.ClientConnInterface {
+//		if strings.HasPrefix(path.Base(route.RPCMethod), "Get") {
+//			return replicas
+//		}
+//		return primary
+//	})
+//	err := pb.RegisterLibraryServiceHandlerClient(ctx, mux, pb.NewLibraryServiceClient(conn))
+//
+// The calls for which fn returns nil fail with Unavailable. The routes of the
+// calls made outside of the handlers of mux only have their RPCMethod.
+//
+// NewBackendSelector must be called before mux serves requests.
+func NewBackendSelector(mux *ServeMux, fn BackendSelectorFunc) grpc.ClientConnInterface {
+	mux.callRoutes = true
+	return backendSelector(fn)
+}
+
+type backendSelector BackendSelectorFunc
+
+// Invoke performs a unary call on the selected connection.
+func (s backendSelector) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := s.selectConn(ctx, method)
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream starts a streaming call on the selected connection.
+func (s backendSelector) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := s.selectConn(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
+
+func (s backendSelector) selectConn(ctx context.Context, method string) (grpc.ClientConnInterface, error) {
+	route := Route{RPCMethod: method}
+	if m, ok := ctx.Value(routeKey{}).(*matchedRoute); ok {
+		route.Pattern = m.pattern.String()
+		route.PathParams = m.pathParams
+	}
+	conn := s(ctx, route)
+	if conn == nil {
+		return nil, status.Errorf(codes.Unavailable, "no backend for %s", method)
+	}
+	return conn, nil
+}
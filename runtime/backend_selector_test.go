@@ -0,0 +1,109 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeConn is a connection recording the methods of its calls.
+type fakeConn struct {
+	methods []string
+}
+
+func (c *fakeConn) Invoke(_ context.Context, method string, _, _ interface{}, _ ...grpc.CallOption) error {
+	c.methods = append(c.methods, method)
+	return nil
+}
+
+func (c *fakeConn) NewStream(_ context.Context, _ *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	c.methods = append(c.methods, method)
+	return nil, nil
+}
+
+func TestNewBackendSelector(t *testing.T) {
+	primary, replica := &fakeConn{}, &fakeConn{}
+	mux := runtime.NewServeMux()
+	var routes []runtime.Route
+	conn := runtime.NewBackendSelector(mux, func(_ context.Context, route runtime.Route) grpc.ClientConnInterface {
+		routes = append(routes, route)
+		switch route.RPCMethod {
+		case "/example.Library/GetShelf":
+			return replica
+		case "/example.Library/UpdateShelf":
+			return primary
+		}
+		return nil
+	})
+	for _, spec := range []struct {
+		method    string
+		rpcMethod string
+	}{
+		{method: "GET", rpcMethod: "/example.Library/GetShelf"},
+		{method: "PATCH", rpcMethod: "/example.Library/UpdateShelf"},
+		{method: "DELETE", rpcMethod: "/example.Library/DeleteShelf"},
+	} {
+		spec := spec
+		if err := mux.HandlePath(spec.method, "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			// The generated handlers annotate the context of the registration
+			// unless they use the context of the requests.
+			ctx, err := runtime.AnnotateContext(context.Background(), mux, r, spec.rpcMethod)
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, mux, r, %q) failed with %v; want success", spec.rpcMethod, err)
+			}
+			if err := conn.Invoke(ctx, spec.rpcMethod, &pb.SimpleMessage{}, &pb.SimpleMessage{}); err != nil {
+				w.WriteHeader(runtime.HTTPStatusFromCode(status.Code(err)))
+			}
+		}); err != nil {
+			t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", spec.method, "/v1/{name=shelves/*}", err)
+		}
+	}
+
+	for _, method := range []string{"GET", "PATCH"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, "/v1/shelves/1", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: w.Code = %d; want %d", method, w.Code, http.StatusOK)
+		}
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/shelves/1", nil))
+	if want := runtime.HTTPStatusFromCode(codes.Unavailable); w.Code != want {
+		t.Errorf("DELETE: w.Code = %d; want %d", w.Code, want)
+	}
+
+	if len(replica.methods) != 1 || replica.methods[0] != "/example.Library/GetShelf" {
+		t.Errorf("replica calls = %q; want %q", replica.methods, []string{"/example.Library/GetShelf"})
+	}
+	if len(primary.methods) != 1 || primary.methods[0] != "/example.Library/UpdateShelf" {
+		t.Errorf("primary calls = %q; want %q", primary.methods, []string{"/example.Library/UpdateShelf"})
+	}
+	for _, route := range routes {
+		if route.Pattern != "/v1/{name=shelves/*}" || route.PathParams["name"] != "shelves/1" {
+			t.Errorf("route = %+v; want the pattern and the path parameters of the request", route)
+		}
+	}
+}
+
+func TestNewBackendSelectorOutsideHandlers(t *testing.T) {
+	var got runtime.Route
+	conn := runtime.NewBackendSelector(runtime.NewServeMux(), func(_ context.Context, route runtime.Route) grpc.ClientConnInterface {
+		got = route
+		return &fakeConn{}
+	})
+
+	if _, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/example.Library/WatchShelves"); err != nil {
+		t.Fatalf("conn.NewStream(ctx, desc, method) failed with %v; want success", err)
+	}
+
+	if want := (runtime.Route{RPCMethod: "/example.Library/WatchShelves"}); got.RPCMethod != want.RPCMethod || got.Pattern != "" || got.PathParams != nil {
+		t.Errorf("route = %+v; want %+v", got, want)
+	}
+}
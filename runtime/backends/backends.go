@@ -0,0 +1,78 @@
+// Package backends configures the gRPC client connections of a gateway to
+// its backends, i.e. their name resolution, load balancing, keepalives,
+// maximum age and compression.
+package backends
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Config configures the connections to the backends.
+type Config struct {
+	// Resolver is the scheme of the name resolver of the endpoints which
+	// have none, e.g. "dns" or "xds". The endpoints are given to the default
+	// resolver of gRPC if empty. The xds resolver must be registered by
+	// importing google.golang.org/grpc/xds.
+	Resolver string
+	// BalancingPolicy is the load balancing policy of the connections, e.g.
+	// "round_robin", unless the resolver provides a service config. The
+	// default of gRPC is "pick_first".
+	BalancingPolicy string
+	// Keepalive configures the keepalive pings of the connections if set.
+	Keepalive keepalive.ClientParameters
+	// MaxConnectionAge is how long the connections of a Pool are used before
+	// being replaced by new ones, if positive, so that the calls spread over
+	// the backends which started since.
+	MaxConnectionAge time.Duration
+	// MaxConnectionAgeGrace is how long the replaced connections of a Pool
+	// are kept for their pending calls before being closed, 30 seconds if
+	// zero.
+	MaxConnectionAgeGrace time.Duration
+	// Compression configures the compression of the requests of the unary
+	// calls if set.
+	Compression *Compression
+	// DialOptions are the other options of the connections, e.g. their
+	// transport credentials.
+	DialOptions []grpc.DialOption
+}
+
+// Target returns the dial target of endpoint, e.g. "dns:///backend:443" for
+// "backend:443" with the dns resolver.
+func (c Config) Target(endpoint string) string {
+	if c.Resolver == "" || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	return c.Resolver + ":///" + endpoint
+}
+
+// Options returns the dial options of the connections. Together with Target,
+// they are to be given to the generated RegisterXXXHandlerFromEndpoint
+// functions:
+//
+//	err := pb.RegisterEchoServiceHandlerFromEndpoint(ctx, mux, config.Target(endpoint), config.Options())
+//
+// The connections dialed by these functions are not replaced after
+// MaxConnectionAge, unlike those of a Pool.
+func (c Config) Options() []grpc.DialOption {
+	opts := append([]grpc.DialOption(nil), c.DialOptions...)
+	if c.BalancingPolicy != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, c.BalancingPolicy)))
+	}
+	if c.Keepalive != (keepalive.ClientParameters{}) {
+		opts = append(opts, grpc.WithKeepaliveParams(c.Keepalive))
+	}
+	if c.Compression != nil {
+		opts = append(opts, c.Compression.options()...)
+	}
+	return opts
+}
+
+// Dial returns a new connection to endpoint.
+func (c Config) Dial(endpoint string) (*grpc.ClientConn, error) {
+	return grpc.Dial(c.Target(endpoint), c.Options()...)
+}
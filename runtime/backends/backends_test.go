@@ -0,0 +1,76 @@
+package backends_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/backends"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestConfigTarget(t *testing.T) {
+	for _, spec := range []struct {
+		resolver string
+		endpoint string
+		want     string
+	}{
+		{endpoint: "backend:443", want: "backend:443"},
+		{resolver: "dns", endpoint: "backend:443", want: "dns:///backend:443"},
+		{resolver: "xds", endpoint: "backend", want: "xds:///backend"},
+		{resolver: "dns", endpoint: "unix:///tmp/backend.sock", want: "unix:///tmp/backend.sock"},
+	} {
+		config := backends.Config{Resolver: spec.resolver}
+		if got := config.Target(spec.endpoint); got != spec.want {
+			t.Errorf("backends.Config{Resolver: %q}.Target(%q) = %q; want %q", spec.resolver, spec.endpoint, got, spec.want)
+		}
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		config backends.Config
+		want   int
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:   "dial options",
+			config: backends.Config{DialOptions: []grpc.DialOption{grpc.WithInsecure()}},
+			want:   1,
+		},
+		{
+			name: "all",
+			config: backends.Config{
+				BalancingPolicy: "round_robin",
+				Keepalive:       keepalive.ClientParameters{Time: time.Minute},
+				DialOptions:     []grpc.DialOption{grpc.WithInsecure()},
+			},
+			want: 3,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			if got := spec.config.Options(); len(got) != spec.want {
+				t.Errorf("config.Options() = %d options; want %d", len(got), spec.want)
+			}
+		})
+	}
+}
+
+func TestConfigDial(t *testing.T) {
+	_, addr := listen(t)
+	config := backends.Config{Resolver: "dns", BalancingPolicy: "round_robin", DialOptions: []grpc.DialOption{grpc.WithInsecure()}}
+
+	conn, err := config.Dial(addr)
+	if err != nil {
+		t.Fatalf("config.Dial(%q) failed with %v; want success", addr, err)
+	}
+	defer conn.Close()
+
+	if got, want := conn.Target(), "dns:///"+addr; got != want {
+		t.Errorf("conn.Target() = %q; want %q", got, want)
+	}
+	check(t, conn)
+}
@@ -0,0 +1,130 @@
+package backends
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor
+	"google.golang.org/grpc/stats"
+	"google.golang.org/protobuf/proto"
+)
+
+// Compression configures the compression of the requests of the unary calls
+// to the backends. The responses are compressed as the backends choose among
+// the registered compressors.
+type Compression struct {
+	// Compressor is the name of the compressor of the requests, "gzip" if
+	// empty. Other compressors, e.g. zstd, must be registered with
+	// encoding.RegisterCompressor, and by the backends.
+	Compressor string
+	// MinSize is the size in bytes of the smallest requests compressed, so
+	// that the small ones are not compressed for nothing.
+	MinSize int
+	// Stats is called with the compression stats of each call to the
+	// backends if set. It takes the stats handler of the connections, which
+	// is not to be set among the DialOptions of the Config.
+	Stats CompressionStatsFunc
+}
+
+// CompressionStatsFunc is the signature of the callbacks given the compression
+// stats of the calls to the backends, e.g. to export them as metrics. ctx is
+// the context of the call.
+type CompressionStatsFunc func(ctx context.Context, stats CompressionStats)
+
+// CompressionStats are the sizes of the messages of a call to a backend, as
+// serialized and on the wire, i.e. compressed and framed.
+type CompressionStats struct {
+	// Method is the full name of the method of the call, e.g.
+	// "/grpc.health.v1.Health/Check".
+	Method string
+	// Compressor is the name of the compressor of the requests, or empty if
+	// they are not compressed.
+	Compressor string
+	// SentBytes and SentWireBytes are the sizes of the requests.
+	SentBytes     int
+	SentWireBytes int
+	// ReceivedBytes and ReceivedWireBytes are the sizes of the responses.
+	ReceivedBytes     int
+	ReceivedWireBytes int
+}
+
+// options returns the dial options compressing the requests of the calls.
+func (c *Compression) options() []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(c.intercept)}
+	if c.Stats != nil {
+		opts = append(opts, grpc.WithStatsHandler(&compressionStatsHandler{fn: c.Stats}))
+	}
+	return opts
+}
+
+type compressorKey struct{}
+
+// intercept compresses the requests of at least MinSize bytes, unless the
+// caller chose a compressor.
+func (c *Compression) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	for _, opt := range opts {
+		if opt, ok := opt.(grpc.CompressorCallOption); ok {
+			return invoker(context.WithValue(ctx, compressorKey{}, opt.CompressorType), method, req, reply, cc, opts...)
+		}
+	}
+	msg, ok := protoMessage(req)
+	if !ok || proto.Size(msg) < c.MinSize {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	name := c.Compressor
+	if name == "" {
+		name = "gzip"
+	}
+	opts = append(opts[:len(opts):len(opts)], grpc.UseCompressor(name))
+	return invoker(context.WithValue(ctx, compressorKey{}, name), method, req, reply, cc, opts...)
+}
+
+// compressionStatsHandler is a stats.Handler calling fn with the compression
+// stats of the calls.
+type compressionStatsHandler struct {
+	fn CompressionStatsFunc
+}
+
+type compressionStatsKey struct{}
+
+// callCompressionStats are the compression stats of a call, whose messages
+// may be sent and received concurrently.
+type callCompressionStats struct {
+	mu    sync.Mutex
+	stats CompressionStats
+}
+
+func (h *compressionStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	s := &callCompressionStats{stats: CompressionStats{Method: info.FullMethodName}}
+	s.stats.Compressor, _ = ctx.Value(compressorKey{}).(string)
+	return context.WithValue(ctx, compressionStatsKey{}, s)
+}
+
+func (h *compressionStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	s, ok := ctx.Value(compressionStatsKey{}).(*callCompressionStats)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	switch rs := rs.(type) {
+	case *stats.OutPayload:
+		s.stats.SentBytes += rs.Length
+		s.stats.SentWireBytes += rs.WireLength
+	case *stats.InPayload:
+		s.stats.ReceivedBytes += rs.Length
+		s.stats.ReceivedWireBytes += rs.WireLength
+	case *stats.End:
+		cs := s.stats
+		s.mu.Unlock()
+		h.fn(ctx, cs)
+		return
+	}
+	s.mu.Unlock()
+}
+
+func (h *compressionStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *compressionStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
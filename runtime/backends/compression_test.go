@@ -0,0 +1,51 @@
+package backends_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/backends"
+	"google.golang.org/grpc"
+)
+
+func TestConfigCompression(t *testing.T) {
+	for _, spec := range []struct {
+		name    string
+		minSize int
+		want    string
+	}{
+		{name: "compressed", want: "gzip"},
+		{name: "below min size", minSize: 1},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			_, addr := listen(t)
+			var got []backends.CompressionStats
+			config := backends.Config{
+				Compression: &backends.Compression{
+					MinSize: spec.minSize,
+					Stats: func(_ context.Context, stats backends.CompressionStats) {
+						got = append(got, stats)
+					},
+				},
+				DialOptions: []grpc.DialOption{grpc.WithInsecure()},
+			}
+			conn, err := config.Dial(addr)
+			if err != nil {
+				t.Fatalf("config.Dial(%q) failed with %v; want success", addr, err)
+			}
+			defer conn.Close()
+
+			check(t, conn)
+
+			if len(got) != 1 {
+				t.Fatalf("%d compression stats; want 1", len(got))
+			}
+			if got[0].Method != "/grpc.health.v1.Health/Check" || got[0].Compressor != spec.want {
+				t.Errorf("stats = %+v; want the method %q and the compressor %q", got[0], "/grpc.health.v1.Health/Check", spec.want)
+			}
+			if got[0].SentWireBytes == 0 || got[0].ReceivedWireBytes == 0 {
+				t.Errorf("stats = %+v; want the wire sizes of the messages", got[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	anypb "github.com/golang/protobuf/ptypes/any"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// Protocol is a protocol of the backends served over HTTP/1.1 by an
+// HTTPConn.
+type Protocol int
+
+const (
+	// Connect is the Connect protocol, e.g. of the connect-go services,
+	// with binary messages.
+	Connect Protocol = iota
+	// GRPCWeb is the gRPC-Web protocol, with binary messages.
+	GRPCWeb
+)
+
+// HTTPConfig configures an HTTPConn.
+type HTTPConfig struct {
+	// Protocol is the protocol of the backend, Connect if zero.
+	Protocol Protocol
+	// Client makes the requests of the calls, http.DefaultClient if nil,
+	// e.g. with the TLS configuration of the backend.
+	Client *http.Client
+	// MaxRecvMsgSize is the size in bytes of the largest message received,
+	// 4 MiB if zero as for the gRPC connections.
+	MaxRecvMsgSize int
+}
+
+// HTTPConn is a connection to a backend serving the Connect or gRPC-Web
+// protocol over HTTP/1.1, for the services deployed behind load balancers
+// or proxies without HTTP/2 support.
+type HTTPConn struct {
+	baseURL string
+	config  HTTPConfig
+}
+
+var _ grpc.ClientConnInterface = (*HTTPConn)(nil)
+
+// NewHTTPConn returns a connection to the backend at baseURL, e.g.
+// "https://backend.example.com", to which the full method names are
+// appended, e.g. "/library.v1.LibraryService/GetBook". It is to be given to
+// the generated NewXXXClient functions of the services served by the
+// backend, whose clients are then registered with the generated
+// RegisterXXXHandlerClient functions:
+//
+//	conn := backends.NewHTTPConn("https://backend.example.com", backends.HTTPConfig{Protocol: backends.GRPCWeb})
+//	err := pb.RegisterEchoServiceHandlerClient(ctx, mux, pb.NewEchoServiceClient(conn))
+//
+// As HTTP/1.1 is half-duplex, the messages of the client streams are sent
+// once the streams are closed, before their responses are received.
+// The messages are neither compressed nor accepted compressed.
+func NewHTTPConn(baseURL string, config HTTPConfig) *HTTPConn {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.MaxRecvMsgSize == 0 {
+		config.MaxRecvMsgSize = 4 << 20
+	}
+	return &HTTPConn{baseURL: strings.TrimSuffix(baseURL, "/"), config: config}
+}
+
+// Invoke performs a unary call on the connection.
+func (c *HTTPConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	if c.config.Protocol == GRPCWeb {
+		// The unary calls of gRPC-Web are streams of a message each way.
+		return c.invokeStream(ctx, method, args, reply, opts)
+	}
+	in, out, err := messages(args, reply)
+	if err != nil {
+		return err
+	}
+	b, err := proto.Marshal(in)
+	if err != nil {
+		return status.Errorf(codes.Internal, "marshaling request: %v", err)
+	}
+	req, err := c.newRequest(ctx, method, "application/proto", b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Connect-Protocol-Version", "1")
+	resp, err := c.config.Client.Do(req)
+	if err != nil {
+		return transportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	header, trailer := make(metadata.MD), make(metadata.MD)
+	for k, vs := range resp.Header {
+		key := strings.ToLower(k)
+		if strings.HasPrefix(key, "trailer-") {
+			trailer[strings.TrimPrefix(key, "trailer-")] = decodeValues(key, vs)
+		} else if !reservedHeaders[key] {
+			header[key] = decodeValues(key, vs)
+		}
+	}
+	setMetadata(opts, header, trailer)
+	body, err := c.readBody(resp.Body)
+	if err != nil {
+		return transportError(ctx, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return connectError(resp.StatusCode, body)
+	}
+	if err := proto.Unmarshal(body, out); err != nil {
+		return status.Errorf(codes.Internal, "unmarshaling response: %v", err)
+	}
+	return nil
+}
+
+// invokeStream performs a unary call as a stream.
+func (c *HTTPConn) invokeStream(ctx context.Context, method string, args, reply interface{}, opts []grpc.CallOption) error {
+	_, out, err := messages(args, reply)
+	if err != nil {
+		return err
+	}
+	s, err := c.NewStream(ctx, &grpc.StreamDesc{}, method, opts...)
+	if err != nil {
+		return err
+	}
+	if err := s.SendMsg(args); err != nil {
+		return err
+	}
+	if err := s.CloseSend(); err != nil {
+		return err
+	}
+	if err := s.RecvMsg(reply); err != nil {
+		if err == io.EOF {
+			return status.Error(codes.Internal, "no response message")
+		}
+		return err
+	}
+	switch err := s.RecvMsg(out.ProtoReflect().New().Interface()); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return status.Error(codes.Internal, "more than one response message")
+	default:
+		return err
+	}
+}
+
+// NewStream starts a streaming call on the connection.
+func (c *HTTPConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &httpStream{
+		conn:   c,
+		ctx:    ctx,
+		cancel: cancel,
+		method: method,
+		opts:   opts,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// newRequest returns the request of a call to method with the metadata of
+// ctx.
+func (c *HTTPConn) newRequest(ctx context.Context, method, contentType string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "creating request: %v", err)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, vs := range md {
+		if reservedHeaders[k] {
+			continue
+		}
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if deadline, ok := ctx.Deadline(); ok {
+		ms := int64(math.Ceil(float64(time.Until(deadline)) / float64(time.Millisecond)))
+		if ms < 1 {
+			ms = 1
+		}
+		if c.config.Protocol == GRPCWeb {
+			req.Header.Set("Grpc-Timeout", strconv.FormatInt(ms, 10)+"m")
+		} else {
+			req.Header.Set("Connect-Timeout-Ms", strconv.FormatInt(ms, 10))
+		}
+	}
+	if c.config.Protocol == GRPCWeb {
+		req.Header.Set("X-Grpc-Web", "1")
+	}
+	return req, nil
+}
+
+// readBody reads the body of a unary response.
+func (c *HTTPConn) readBody(body io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(body, int64(c.config.MaxRecvMsgSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > c.config.MaxRecvMsgSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "response larger than %d bytes", c.config.MaxRecvMsgSize)
+	}
+	return b, nil
+}
+
+// reservedHeaders are the headers of the protocols, not forwarded as
+// metadata.
+var reservedHeaders = map[string]bool{
+	"connection":               true,
+	"content-encoding":         true,
+	"content-length":           true,
+	"content-type":             true,
+	"connect-accept-encoding":  true,
+	"connect-content-encoding": true,
+	"connect-protocol-version": true,
+	"connect-timeout-ms":       true,
+	"date":                     true,
+	"grpc-accept-encoding":     true,
+	"grpc-encoding":            true,
+	"grpc-message":             true,
+	"grpc-status":              true,
+	"grpc-status-details-bin":  true,
+	"grpc-timeout":             true,
+	"host":                     true,
+	"te":                       true,
+	"trailer":                  true,
+	"transfer-encoding":        true,
+	"x-grpc-web":               true,
+}
+
+// decodeValues returns the metadata values of the header values vs of key,
+// decoding those of the binary keys.
+func decodeValues(key string, vs []string) []string {
+	if !strings.HasSuffix(key, "-bin") {
+		return vs
+	}
+	values := make([]string, 0, len(vs))
+	for _, v := range vs {
+		if b, err := decodeBase64(v); err == nil {
+			v = string(b)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// decodeBase64 decodes s, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	if len(s)%4 == 0 {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// setMetadata sets the header and trailer of the calls with opts.
+func setMetadata(opts []grpc.CallOption, header, trailer metadata.MD) {
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			if header != nil {
+				*opt.HeaderAddr = header
+			}
+		case grpc.TrailerCallOption:
+			if trailer != nil {
+				*opt.TrailerAddr = trailer
+			}
+		}
+	}
+}
+
+// messages returns the request and response messages of a call.
+func messages(args, reply interface{}) (proto.Message, proto.Message, error) {
+	in, ok := protoMessage(args)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Internal, "request %T is not a proto.Message", args)
+	}
+	out, ok := protoMessage(reply)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Internal, "response %T is not a proto.Message", reply)
+	}
+	return in, out, nil
+}
+
+// protoMessage returns v as a proto.Message, wrapping the messages generated
+// for the first version of the API, e.g. those of the health service of grpc.
+func protoMessage(v interface{}) (proto.Message, bool) {
+	switch m := v.(type) {
+	case proto.Message:
+		return m, true
+	case protoiface.MessageV1:
+		return protoimpl.X.ProtoMessageV2Of(m), true
+	}
+	return nil, false
+}
+
+// transportError returns the status of the call whose request failed with
+// err.
+func transportError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// httpStatusCode returns the code of the calls whose response has the HTTP
+// status code and no status of its own.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// connectCodes maps the codes of the Connect errors to the gRPC codes.
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+// connectWireError is the JSON of a Connect error.
+type connectWireError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"details"`
+}
+
+// status returns the status of e, with the code of httpStatus if e has
+// none.
+func (e *connectWireError) status(httpStatus int) error {
+	code, ok := connectCodes[e.Code]
+	if !ok {
+		code = httpStatusCode(httpStatus)
+	}
+	s := &statuspb.Status{Code: int32(code), Message: e.Message}
+	for _, d := range e.Details {
+		value, err := decodeBase64(d.Value)
+		if err != nil {
+			continue
+		}
+		s.Details = append(s.Details, &anypb.Any{TypeUrl: "type.googleapis.com/" + d.Type, Value: value})
+	}
+	return status.ErrorProto(s)
+}
+
+// connectError returns the status of the unary call whose response has the
+// HTTP status code and body.
+func connectError(code int, body []byte) error {
+	var e connectWireError
+	if err := json.Unmarshal(body, &e); err != nil {
+		return status.Errorf(httpStatusCode(code), "unexpected HTTP status %d %s", code, http.StatusText(code))
+	}
+	return e.status(code)
+}
+
+// grpcWebStatus returns the status of the gRPC-Web call whose trailers are
+// md, or nil if it succeeded.
+func grpcWebStatus(md metadata.MD) error {
+	values := md.Get("grpc-status")
+	if len(values) == 0 {
+		return status.Error(codes.Internal, "missing grpc-status")
+	}
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return status.Errorf(codes.Internal, "invalid grpc-status %q", values[0])
+	}
+	if codes.Code(code) == codes.OK {
+		return nil
+	}
+	var message string
+	if values := md.Get("grpc-message"); len(values) > 0 {
+		message = unescapeGRPCMessage(values[0])
+	}
+	if values := md.Get("grpc-status-details-bin"); len(values) > 0 {
+		s := &statuspb.Status{}
+		if err := proto.Unmarshal([]byte(values[0]), s); err == nil && s.Code == int32(code) {
+			return status.ErrorProto(s)
+		}
+	}
+	return status.Error(codes.Code(code), message)
+}
+
+// unescapeGRPCMessage decodes the percent-encoding of the grpc-message
+// values.
+func unescapeGRPCMessage(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// protocolError returns an Internal status error of a response violating
+// its protocol.
+func protocolError(format string, a ...interface{}) error {
+	return status.Error(codes.Internal, fmt.Sprintf(format, a...))
+}
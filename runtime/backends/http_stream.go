@@ -0,0 +1,311 @@
+package backends
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// compressedFlag flags the compressed messages of both protocols.
+	compressedFlag = 0x01
+	// connectEndStreamFlag flags the end of stream message of Connect.
+	connectEndStreamFlag = 0x02
+	// grpcWebTrailerFlag flags the trailers of gRPC-Web.
+	grpcWebTrailerFlag = 0x80
+)
+
+// httpStream is a streaming call of an HTTPConn. Its request is made once
+// it is closed for sending, with the messages it was sent.
+type httpStream struct {
+	conn   *HTTPConn
+	ctx    context.Context
+	cancel context.CancelFunc
+	method string
+	opts   []grpc.CallOption
+
+	// body is the request, written by SendMsg until CloseSend.
+	body   bytes.Buffer
+	closed bool
+
+	// done is closed once the response is received or the request failed
+	// with err.
+	done   chan struct{}
+	resp   *http.Response
+	header metadata.MD
+
+	// trailer and err are set once the stream ends, err being io.EOF if it
+	// succeeded.
+	trailer metadata.MD
+	err     error
+}
+
+var _ grpc.ClientStream = (*httpStream)(nil)
+
+func (s *httpStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *httpStream) SendMsg(m interface{}) error {
+	if s.closed {
+		return status.Error(codes.Internal, "SendMsg called after CloseSend")
+	}
+	msg, ok := protoMessage(m)
+	if !ok {
+		return status.Errorf(codes.Internal, "request %T is not a proto.Message", m)
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return status.Errorf(codes.Internal, "marshaling request: %v", err)
+	}
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(b)))
+	s.body.Write(prefix[:])
+	s.body.Write(b)
+	return nil
+}
+
+func (s *httpStream) CloseSend() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	go s.do()
+	return nil
+}
+
+// do makes the request of the stream. Its context is canceled once done is
+// closed if the request failed, for wait to report the failure.
+func (s *httpStream) do() {
+	err := s.request()
+	if err != nil {
+		s.trailer, s.err = make(metadata.MD), err
+		setMetadata(s.opts, nil, s.trailer)
+	}
+	close(s.done)
+	if err != nil {
+		s.cancel()
+	}
+}
+
+// request sends the request of the stream and receives its response.
+func (s *httpStream) request() error {
+	contentType := "application/connect+proto"
+	if s.conn.config.Protocol == GRPCWeb {
+		contentType = "application/grpc-web+proto"
+	}
+	req, err := s.conn.newRequest(s.ctx, s.method, contentType, s.body.Bytes())
+	if err != nil {
+		return err
+	}
+	resp, err := s.conn.config.Client.Do(req)
+	if err != nil {
+		return transportError(s.ctx, err)
+	}
+	s.header = make(metadata.MD, len(resp.Header))
+	for k, vs := range resp.Header {
+		if key := strings.ToLower(k); !reservedHeaders[key] {
+			s.header[key] = decodeValues(key, vs)
+		}
+	}
+	setMetadata(s.opts, s.header, nil)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := s.conn.readBody(resp.Body)
+		resp.Body.Close()
+		if s.conn.config.Protocol == GRPCWeb {
+			return status.Errorf(httpStatusCode(resp.StatusCode), "unexpected HTTP status %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return connectError(resp.StatusCode, body)
+	}
+	s.resp = resp
+	return nil
+}
+
+// fail ends the stream with err.
+func (s *httpStream) fail(err error) {
+	s.finish(nil, err)
+}
+
+// finish ends the stream with trailer and err.
+func (s *httpStream) finish(trailer metadata.MD, err error) {
+	if trailer == nil {
+		trailer = make(metadata.MD)
+	}
+	s.trailer, s.err = trailer, err
+	setMetadata(s.opts, nil, trailer)
+	if s.resp != nil {
+		s.resp.Body.Close()
+	}
+	s.cancel()
+}
+
+// wait waits for the response of the stream.
+func (s *httpStream) wait() error {
+	// The context of the stream is canceled once it ends.
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-s.ctx.Done():
+		return transportError(s.ctx, s.ctx.Err())
+	}
+}
+
+func (s *httpStream) Header() (metadata.MD, error) {
+	if err := s.wait(); err != nil {
+		return nil, err
+	}
+	if s.header == nil {
+		return nil, s.err
+	}
+	return s.header, nil
+}
+
+func (s *httpStream) Trailer() metadata.MD {
+	return s.trailer
+}
+
+func (s *httpStream) RecvMsg(m interface{}) error {
+	if err := s.wait(); err != nil {
+		return err
+	}
+	if s.err != nil {
+		return s.err
+	}
+	msg, ok := protoMessage(m)
+	if !ok {
+		return status.Errorf(codes.Internal, "response %T is not a proto.Message", m)
+	}
+	flags, b, err := s.readFrame()
+	switch {
+	case err == io.EOF:
+		s.finish(s.trailersOnly())
+	case err != nil:
+		s.fail(err)
+	case flags&compressedFlag != 0:
+		s.fail(protocolError("compressed message received"))
+	case s.conn.config.Protocol == GRPCWeb && flags&grpcWebTrailerFlag != 0:
+		md, err := parseGRPCWebTrailer(b)
+		if err != nil {
+			s.fail(err)
+		} else if err := grpcWebStatus(md); err != nil {
+			s.finish(trailerMetadata(md), err)
+		} else {
+			s.finish(trailerMetadata(md), io.EOF)
+		}
+	case s.conn.config.Protocol == Connect && flags&connectEndStreamFlag != 0:
+		s.finish(parseConnectEndStream(b))
+	default:
+		if err := proto.Unmarshal(b, msg); err != nil {
+			s.fail(status.Errorf(codes.Internal, "unmarshaling response: %v", err))
+			return s.err
+		}
+		return nil
+	}
+	return s.err
+}
+
+// readFrame reads the next message of the response, returning io.EOF at the
+// end of the body.
+func (s *httpStream) readFrame() (byte, []byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(s.resp.Body, prefix[:]); err != nil {
+		if err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		return 0, nil, transportError(s.ctx, err)
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if uint64(n) > uint64(s.conn.config.MaxRecvMsgSize) {
+		return 0, nil, status.Errorf(codes.ResourceExhausted, "message of %d bytes larger than %d bytes", n, s.conn.config.MaxRecvMsgSize)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(s.resp.Body, b); err != nil {
+		return 0, nil, transportError(s.ctx, err)
+	}
+	return prefix[0], b, nil
+}
+
+// trailersOnly returns the trailers and status of the stream whose body
+// ended without them, which the gRPC-Web responses without messages have
+// in their headers.
+func (s *httpStream) trailersOnly() (metadata.MD, error) {
+	if s.conn.config.Protocol == Connect {
+		return nil, protocolError("missing end of stream message")
+	}
+	md := make(metadata.MD, len(s.resp.Header))
+	for k, vs := range s.resp.Header {
+		key := strings.ToLower(k)
+		md[key] = decodeValues(key, vs)
+	}
+	err := grpcWebStatus(md)
+	if err == nil {
+		err = io.EOF
+	}
+	return trailerMetadata(md), err
+}
+
+// parseGRPCWebTrailer parses the trailers of a gRPC-Web response, written
+// as an HTTP/1 header block.
+func parseGRPCWebTrailer(b []byte) (metadata.MD, error) {
+	r := textproto.NewReader(bufio.NewReader(io.MultiReader(bytes.NewReader(b), strings.NewReader("\r\n"))))
+	h, err := r.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, protocolError("invalid trailers: %v", err)
+	}
+	md := make(metadata.MD, len(h))
+	for k, vs := range h {
+		key := strings.ToLower(k)
+		md[key] = decodeValues(key, vs)
+	}
+	return md, nil
+}
+
+// parseConnectEndStream parses the end of stream message of a Connect
+// response into its trailers and status.
+func parseConnectEndStream(b []byte) (metadata.MD, error) {
+	var end struct {
+		Error    *connectWireError   `json:"error"`
+		Metadata map[string][]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(&end); err != nil {
+		return nil, protocolError("invalid end of stream message: %v", err)
+	}
+	trailer := make(metadata.MD, len(end.Metadata))
+	for k, vs := range end.Metadata {
+		key := strings.ToLower(k)
+		trailer[key] = decodeValues(key, vs)
+	}
+	if end.Error != nil {
+		return trailer, end.Error.status(http.StatusOK)
+	}
+	return trailer, io.EOF
+}
+
+// trailerMetadata returns the trailers md without the headers of the
+// protocols, e.g. the status.
+func trailerMetadata(md metadata.MD) metadata.MD {
+	trailer := make(metadata.MD, len(md))
+	for k, vs := range md {
+		if !reservedHeaders[k] {
+			trailer[k] = vs
+		}
+	}
+	return trailer
+}
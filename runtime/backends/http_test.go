@@ -0,0 +1,211 @@
+package backends_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/backends"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// envelope returns the message b framed with flags.
+func envelope(flags byte, b []byte) []byte {
+	prefix := make([]byte, 5)
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(b)))
+	return append(prefix, b...)
+}
+
+func marshal(t *testing.T, m proto.Message) []byte {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("proto.Marshal(%v) failed with %v; want success", m, err)
+	}
+	return b
+}
+
+// newHTTPConn returns a connection to a server handling the requests with
+// h, after checking their method and Content-Type.
+func newHTTPConn(t *testing.T, protocol backends.Protocol, contentType string, h func(w http.ResponseWriter, body []byte)) *backends.HTTPConn {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != contentType {
+			t.Errorf("Content-Type = %q; want %q", got, contentType)
+		}
+		if got, want := r.Header.Get("X-User"), "alice"; got != want {
+			t.Errorf("X-User = %q; want %q", got, want)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("ioutil.ReadAll(r.Body) failed with %v; want success", err)
+		}
+		h(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return backends.NewHTTPConn(srv.URL, backends.HTTPConfig{Protocol: protocol})
+}
+
+func outgoingContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "x-user", "alice")
+}
+
+func TestHTTPConnConnect(t *testing.T) {
+	serving := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}
+	conn := newHTTPConn(t, backends.Connect, "application/proto", func(w http.ResponseWriter, body []byte) {
+		req := &healthpb.HealthCheckRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("proto.Unmarshal(body, req) failed with %v; want success", err)
+		}
+		if req.Service == "unknown" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"code": "not_found", "message": "unknown service"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/proto")
+		w.Header().Set("X-Backend", "a")
+		w.Header().Set("Trailer-X-Cost", "3")
+		w.Write(marshal(t, serving))
+	})
+	client := healthpb.NewHealthClient(conn)
+
+	var header, trailer metadata.MD
+	resp, err := client.Check(outgoingContext(t), &healthpb.HealthCheckRequest{}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("client.Check(ctx, req) failed with %v; want success", err)
+	}
+	if !proto.Equal(resp, serving) {
+		t.Errorf("client.Check(ctx, req) = %v; want %v", resp, serving)
+	}
+	if got, want := header.Get("x-backend"), []string{"a"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("header[x-backend] = %q; want %q", got, want)
+	}
+	if got, want := trailer.Get("x-cost"), []string{"3"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("trailer[x-cost] = %q; want %q", got, want)
+	}
+
+	_, err = client.Check(outgoingContext(t), &healthpb.HealthCheckRequest{Service: "unknown"})
+	if s := status.Convert(err); s.Code() != codes.NotFound || s.Message() != "unknown service" {
+		t.Errorf("client.Check(ctx, req) failed with %v; want NotFound", err)
+	}
+}
+
+func TestHTTPConnConnectStream(t *testing.T) {
+	conn := newHTTPConn(t, backends.Connect, "application/connect+proto", func(w http.ResponseWriter, body []byte) {
+		w.Header().Set("Content-Type", "application/connect+proto")
+		w.Write(envelope(0, marshal(t, &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})))
+		w.Write(envelope(0, marshal(t, &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})))
+		if len(body) > 5 {
+			// The service is set.
+			w.Write(envelope(2, []byte(`{"error": {"code": "unavailable", "message": "draining"}}`)))
+			return
+		}
+		w.Write(envelope(2, []byte(`{"metadata": {"x-cost": ["3"]}}`)))
+	})
+	client := healthpb.NewHealthClient(conn)
+
+	stream, err := client.Watch(outgoingContext(t), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("client.Watch(ctx, req) failed with %v; want success", err)
+	}
+	for _, want := range []healthpb.HealthCheckResponse_ServingStatus{healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING} {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("stream.Recv() failed with %v; want success", err)
+		}
+		if resp.Status != want {
+			t.Errorf("stream.Recv() = %v; want the status %v", resp, want)
+		}
+	}
+	if resp, err := stream.Recv(); err != io.EOF {
+		t.Errorf("stream.Recv() = %v, %v; want io.EOF", resp, err)
+	}
+	if got := stream.Trailer().Get("x-cost"); len(got) != 1 || got[0] != "3" {
+		t.Errorf("stream.Trailer()[x-cost] = %q; want %q", got, "3")
+	}
+
+	stream, err = client.Watch(outgoingContext(t), &healthpb.HealthCheckRequest{Service: "foo"})
+	if err != nil {
+		t.Fatalf("client.Watch(ctx, req) failed with %v; want success", err)
+	}
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if s := status.Convert(err); s.Code() != codes.Unavailable || s.Message() != "draining" {
+		t.Errorf("stream.Recv() failed with %v; want Unavailable", err)
+	}
+}
+
+func TestHTTPConnGRPCWeb(t *testing.T) {
+	serving := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}
+	conn := newHTTPConn(t, backends.GRPCWeb, "application/grpc-web+proto", func(w http.ResponseWriter, body []byte) {
+		req := &healthpb.HealthCheckRequest{}
+		if len(body) < 5 {
+			t.Errorf("body = %q; want an enveloped message", body)
+		} else if err := proto.Unmarshal(body[5:], req); err != nil {
+			t.Errorf("proto.Unmarshal(body, req) failed with %v; want success", err)
+		}
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		if req.Service == "unknown" {
+			// Trailers-only response.
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown%20service")
+			return
+		}
+		w.Header().Set("X-Backend", "a")
+		w.Write(envelope(0, marshal(t, serving)))
+		w.Write(envelope(0x80, []byte("grpc-status: 0\r\nx-cost: 3\r\n")))
+	})
+	client := healthpb.NewHealthClient(conn)
+
+	var header, trailer metadata.MD
+	resp, err := client.Check(outgoingContext(t), &healthpb.HealthCheckRequest{}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("client.Check(ctx, req) failed with %v; want success", err)
+	}
+	if !proto.Equal(resp, serving) {
+		t.Errorf("client.Check(ctx, req) = %v; want %v", resp, serving)
+	}
+	if got := header.Get("x-backend"); len(got) != 1 || got[0] != "a" {
+		t.Errorf("header[x-backend] = %q; want %q", got, "a")
+	}
+	if got := trailer.Get("x-cost"); len(got) != 1 || got[0] != "3" {
+		t.Errorf("trailer[x-cost] = %q; want %q", got, "3")
+	}
+	if got := trailer.Get("grpc-status"); len(got) != 0 {
+		t.Errorf("trailer[grpc-status] = %q; want none", got)
+	}
+
+	_, err = client.Check(outgoingContext(t), &healthpb.HealthCheckRequest{Service: "unknown"})
+	if s := status.Convert(err); s.Code() != codes.NotFound || s.Message() != "unknown service" {
+		t.Errorf("client.Check(ctx, req) failed with %v; want NotFound", err)
+	}
+}
+
+func TestHTTPConnHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	for _, protocol := range []backends.Protocol{backends.Connect, backends.GRPCWeb} {
+		client := healthpb.NewHealthClient(backends.NewHTTPConn(srv.URL, backends.HTTPConfig{Protocol: protocol}))
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if got := status.Code(err); got != codes.Unavailable {
+			t.Errorf("client.Check(ctx, req) with protocol %d failed with %v; want Unavailable", protocol, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/grpclog"
+)
+
+// ErrPoolClosed is returned by the connections of a closed Pool.
+var ErrPoolClosed = errors.New("backends: pool closed")
+
+// Pool shares a connection per endpoint between the services of a gateway.
+type Pool struct {
+	config Config
+
+	mu     sync.Mutex
+	conns  map[string]*Conn
+	closed bool
+}
+
+// NewPool returns a Pool whose connections are configured with config.
+func NewPool(config Config) *Pool {
+	if config.MaxConnectionAgeGrace == 0 {
+		config.MaxConnectionAgeGrace = 30 * time.Second
+	}
+	return &Pool{config: config, conns: make(map[string]*Conn)}
+}
+
+// Conn returns the connection of the pool to endpoint, dialing it if needed.
+// It is to be given to the generated NewXXXClient functions of the services
+// served by endpoint, whose clients are then registered with the generated
+// RegisterXXXHandlerClient functions:
+//
+//	conn, err := pool.Conn(endpoint)
+//	...
+//	err = pb.RegisterEchoServiceHandlerClient(ctx, mux, pb.NewEchoServiceClient(conn))
+func (p *Pool) Conn(endpoint string) (*Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if c, ok := p.conns[endpoint]; ok {
+		return c, nil
+	}
+	cc, err := p.config.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{pool: p, endpoint: endpoint, cc: cc, dialed: time.Now()}
+	p.conns[endpoint] = c
+	return c, nil
+}
+
+// Close closes the connections of the pool.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var err error
+	for _, c := range p.conns {
+		if cerr := c.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Conn is a connection of a Pool, replaced by a new one after the maximum
+// connection age of the pool.
+type Conn struct {
+	pool     *Pool
+	endpoint string
+
+	mu     sync.Mutex
+	cc     *grpc.ClientConn
+	dialed time.Time
+	closed bool
+}
+
+var _ grpc.ClientConnInterface = (*Conn)(nil)
+
+// Invoke performs a unary call on the connection.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	cc, err := c.current()
+	if err != nil {
+		return err
+	}
+	return cc.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream starts a streaming call on the connection.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cc, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	return cc.NewStream(ctx, desc, method, opts...)
+}
+
+// current returns the connection the calls are made on, replacing it if it
+// is older than the maximum connection age.
+func (c *Conn) current() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrPoolClosed
+	}
+	config := c.pool.config
+	if config.MaxConnectionAge <= 0 || time.Since(c.dialed) < config.MaxConnectionAge {
+		return c.cc, nil
+	}
+	cc, err := config.Dial(c.endpoint)
+	if err != nil {
+		grpclog.Infof("Failed to replace conn to %s: %v", c.endpoint, err)
+		return c.cc, nil
+	}
+	old := c.cc
+	c.cc, c.dialed = cc, time.Now()
+	time.AfterFunc(config.MaxConnectionAgeGrace, func() {
+		if err := old.Close(); err != nil {
+			grpclog.Infof("Failed to close conn to %s: %v", c.endpoint, err)
+		}
+	})
+	return cc, nil
+}
+
+func (c *Conn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return c.cc.Close()
+}
@@ -0,0 +1,118 @@
+package backends_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/backends"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// countingListener counts the connections it accepts.
+type countingListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+// listen starts a gRPC server with the health service, and returns its
+// listener and its address.
+func listen(t *testing.T) (*countingListener, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen(%q, %q) failed with %v; want success", "tcp", "127.0.0.1:0", err)
+	}
+	lis := &countingListener{Listener: l}
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis, l.Addr().String()
+}
+
+// check calls the health service of conn.
+func check(t *testing.T, conn grpc.ClientConnInterface) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+		t.Errorf("client.Check(ctx, req) failed with %v; want success", err)
+	}
+}
+
+func TestPoolConn(t *testing.T) {
+	lis, addr := listen(t)
+	pool := backends.NewPool(backends.Config{DialOptions: []grpc.DialOption{grpc.WithInsecure()}})
+	defer pool.Close()
+
+	conn, err := pool.Conn(addr)
+	if err != nil {
+		t.Fatalf("pool.Conn(%q) failed with %v; want success", addr, err)
+	}
+	again, err := pool.Conn(addr)
+	if err != nil {
+		t.Fatalf("pool.Conn(%q) failed with %v; want success", addr, err)
+	}
+	if conn != again {
+		t.Errorf("pool.Conn(%q) returned a new connection; want the shared one", addr)
+	}
+	check(t, conn)
+	check(t, again)
+
+	if got := atomic.LoadInt32(&lis.accepted); got != 1 {
+		t.Errorf("%d connections accepted; want 1", got)
+	}
+}
+
+func TestPoolMaxConnectionAge(t *testing.T) {
+	lis, addr := listen(t)
+	pool := backends.NewPool(backends.Config{
+		MaxConnectionAge:      10 * time.Millisecond,
+		MaxConnectionAgeGrace: time.Millisecond,
+		DialOptions:           []grpc.DialOption{grpc.WithInsecure()},
+	})
+	defer pool.Close()
+	conn, err := pool.Conn(addr)
+	if err != nil {
+		t.Fatalf("pool.Conn(%q) failed with %v; want success", addr, err)
+	}
+
+	check(t, conn)
+	time.Sleep(20 * time.Millisecond)
+	check(t, conn)
+
+	if got := atomic.LoadInt32(&lis.accepted); got != 2 {
+		t.Errorf("%d connections accepted; want 2 once the first one is replaced", got)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	_, addr := listen(t)
+	pool := backends.NewPool(backends.Config{DialOptions: []grpc.DialOption{grpc.WithInsecure()}})
+	conn, err := pool.Conn(addr)
+	if err != nil {
+		t.Fatalf("pool.Conn(%q) failed with %v; want success", addr, err)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("pool.Close() failed with %v; want success", err)
+	}
+
+	if err := conn.Invoke(context.Background(), "/grpc.health.v1.Health/Check", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}); err != backends.ErrPoolClosed {
+		t.Errorf("conn.Invoke(ctx, method, req, resp) failed with %v; want %v", err, backends.ErrPoolClosed)
+	}
+	if _, err := pool.Conn(addr); err != backends.ErrPoolClosed {
+		t.Errorf("pool.Conn(%q) failed with %v; want %v", addr, err, backends.ErrPoolClosed)
+	}
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BodyDecoderFunc returns the reader of the body of r decoded from body, e.g.
+// decompressed or decrypted, or an error if body is not in its encoding.
+type BodyDecoderFunc func(r *http.Request, body io.Reader) (io.Reader, error)
+
+// DecodeGzipBody is a BodyDecoderFunc decompressing the gzip bodies.
+func DecodeGzipBody(_ *http.Request, body io.Reader) (io.Reader, error) {
+	return gzip.NewReader(body)
+}
+
+// DecodeBase64Body is a BodyDecoderFunc decoding the standard base64 bodies,
+// possibly split in lines, e.g. those of the webhooks of some providers with
+// a "Content-Transfer-Encoding: base64" header.
+func DecodeBase64Body(_ *http.Request, body io.Reader) (io.Reader, error) {
+	return base64.NewDecoder(base64.StdEncoding, body), nil
+}
+
+// WithBodyDecoder returns a ServeMuxOption decoding with decoder the bodies
+// of the requests whose Content-Transfer-Encoding or Content-Encoding header
+// lists encoding, e.g. "gzip" with DecodeGzipBody, before their marshaler
+// unmarshals them.
+//
+// The encodings of a body are decoded in the reverse order they were
+// applied, the Content-Transfer-Encoding first and then those of the
+// Content-Encoding from the last listed, so that the decoders chain. The
+// requests are then handled without the headers, and those with an encoding
+// other than "identity" without a decoder are rejected with InvalidArgument.
+// The bodies are decoded after the signatures of WithSignatureVerification
+// are verified over the encoded bodies.
+func WithBodyDecoder(encoding string, decoder BodyDecoderFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if serveMux.bodyDecoders == nil {
+			serveMux.bodyDecoders = make(map[string]BodyDecoderFunc)
+		}
+		serveMux.bodyDecoders[strings.ToLower(encoding)] = decoder
+	}
+}
+
+// decodeBody replies with an error and returns false if the body of r has an
+// encoding the mux cannot decode. It returns r with the decoded body
+// otherwise.
+func (s *ServeMux) decodeBody(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.bodyDecoders == nil {
+		return r, true
+	}
+	var encodings []string
+	for _, v := range r.Header.Values("Content-Encoding") {
+		encodings = append(encodings, strings.Split(v, ",")...)
+	}
+	encodings = append(encodings, r.Header.Values("Content-Transfer-Encoding")...)
+	if len(encodings) == 0 {
+		return r, true
+	}
+
+	var body io.Reader = r.Body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		decoder, ok := s.bodyDecoders[encoding]
+		var err error
+		if !ok {
+			err = status.Errorf(codes.InvalidArgument, "unsupported body encoding %q", encoding)
+		} else if body, err = decoder(r, body); err != nil {
+			err = status.Errorf(codes.InvalidArgument, "invalid %s body: %v", encoding, err)
+		}
+		if err != nil {
+			_, outboundMarshaler := MarshalerForRequest(s, r)
+			HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+			return r, false
+		}
+	}
+
+	r2 := *r
+	r2.Header = r.Header.Clone()
+	r2.Header.Del("Content-Encoding")
+	r2.Header.Del("Content-Transfer-Encoding")
+	r2.Header.Del("Content-Length")
+	r2.ContentLength = -1
+	r2.Body = decodedBody{Reader: body, Closer: r.Body}
+	return &r2, true
+}
+
+// decodedBody reads a decoded body, closing the body it is decoded from.
+type decodedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,148 @@
+package runtime_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestWithBodyDecoder(t *testing.T) {
+	const body = `{"id":"foo"}`
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	if _, err := gw.Write([]byte(body)); err != nil {
+		t.Fatalf("gw.Write(body) failed with %v; want success", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gw.Close() failed with %v; want success", err)
+	}
+
+	// reverse stands for a custom decoder, e.g. one decrypting the bodies.
+	reverse := func(_ *http.Request, body io.Reader) (io.Reader, error) {
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return bytes.NewReader(b), nil
+	}
+	reversed, err := reverse(nil, bytes.NewReader(gzipped.Bytes()))
+	if err != nil {
+		t.Fatalf("reverse(nil, gzipped) failed with %v; want success", err)
+	}
+	reversedGzipped, err := ioutil.ReadAll(reversed)
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll(reversed) failed with %v; want success", err)
+	}
+
+	for _, spec := range []struct {
+		name     string
+		opts     []runtime.ServeMuxOption
+		body     []byte
+		headers  map[string]string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "no encoding",
+			opts:     []runtime.ServeMuxOption{runtime.WithBodyDecoder("gzip", runtime.DecodeGzipBody)},
+			body:     []byte(body),
+			wantCode: http.StatusOK,
+			wantBody: body,
+		},
+		{
+			name:     "gzip",
+			opts:     []runtime.ServeMuxOption{runtime.WithBodyDecoder("gzip", runtime.DecodeGzipBody)},
+			body:     gzipped.Bytes(),
+			headers:  map[string]string{"Content-Encoding": "gzip"},
+			wantCode: http.StatusOK,
+			wantBody: body,
+		},
+		{
+			name: "chain",
+			opts: []runtime.ServeMuxOption{
+				runtime.WithBodyDecoder("gzip", runtime.DecodeGzipBody),
+				runtime.WithBodyDecoder("base64", runtime.DecodeBase64Body),
+				runtime.WithBodyDecoder("x-reversed", reverse),
+			},
+			body: []byte(base64.StdEncoding.EncodeToString(reversedGzipped)),
+			headers: map[string]string{
+				"Content-Encoding":          "gzip, X-Reversed",
+				"Content-Transfer-Encoding": "base64",
+			},
+			wantCode: http.StatusOK,
+			wantBody: body,
+		},
+		{
+			name:     "unsupported encoding",
+			opts:     []runtime.ServeMuxOption{runtime.WithBodyDecoder("gzip", runtime.DecodeGzipBody)},
+			body:     []byte(body),
+			headers:  map[string]string{"Content-Encoding": "br"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid body",
+			opts:     []runtime.ServeMuxOption{runtime.WithBodyDecoder("gzip", runtime.DecodeGzipBody)},
+			body:     []byte(body),
+			headers:  map[string]string{"Content-Encoding": "gzip"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "no decoders",
+			body:     []byte(body),
+			headers:  map[string]string{"Content-Encoding": "br"},
+			wantCode: http.StatusOK,
+			wantBody: body,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(spec.opts...)
+			var got string
+			var encoding string
+			if err := mux.HandlePath("POST", "/v1/example", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("ioutil.ReadAll(r.Body) failed with %v; want success", err)
+				}
+				got = string(b)
+				encoding = r.Header.Get("Content-Encoding")
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/example", err)
+			}
+
+			r := httptest.NewRequest("POST", "/v1/example", bytes.NewReader(spec.body))
+			r.Header.Set("Content-Type", "application/json")
+			for k, v := range spec.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != spec.wantCode {
+				t.Fatalf("w.Code = %d; want %d", w.Code, spec.wantCode)
+			}
+			if spec.wantCode != http.StatusOK {
+				return
+			}
+			if got != spec.wantBody {
+				t.Errorf("r.Body = %q; want %q", got, spec.wantBody)
+			}
+			wantEncoding := spec.headers["Content-Encoding"]
+			if len(spec.opts) > 0 {
+				wantEncoding = ""
+			}
+			if encoding != wantEncoding {
+				t.Errorf("Content-Encoding = %q; want %q", encoding, wantEncoding)
+			}
+		})
+	}
+}
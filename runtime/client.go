@@ -0,0 +1,381 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Client calls the REST endpoints of a gateway. It is used by the generated
+// HTTP clients, which build requests the way the generated handlers parse them:
+// path parameters are taken from the fields bound by the path pattern, the body
+// from the body field, and the remaining fields are sent as query parameters.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	marshaler  Marshaler
+	header     http.Header
+}
+
+// ClientOption is an option to configure a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient returns a ClientOption sending the requests with the given
+// http.Client instead of http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithClientMarshaler returns a ClientOption marshaling the request bodies and
+// unmarshaling the response bodies with the given Marshaler. It must match the
+// marshaler registered on the gateway for its content type.
+func WithClientMarshaler(marshaler Marshaler) ClientOption {
+	return func(c *Client) {
+		c.marshaler = marshaler
+	}
+}
+
+// WithClientHeader returns a ClientOption adding the given header to every request.
+func WithClientHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// NewClient returns a new Client calling the gateway at baseURL, e.g.
+// "https://api.example.com". Requests are sent with http.DefaultClient and
+// JSON bodies unless configured otherwise.
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		marshaler:  defaultMarshaler,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ClientCall describes the binding a Client calls.
+type ClientCall struct {
+	// HTTPMethod is the HTTP method of the binding.
+	HTTPMethod string
+	// Pattern is the path pattern of the binding.
+	Pattern Pattern
+	// Body is the field path of the request body: "*" for the whole request,
+	// empty for no body.
+	Body string
+	// ResponseBody is the field path of the response body, empty for the
+	// whole response.
+	ResponseBody string
+	// PathParamSeparator separates the values of repeated path parameters,
+	// "," if empty.
+	PathParamSeparator string
+}
+
+// Invoke calls the binding with the request, and unmarshals the response into
+// resp. Error responses are returned as gRPC status errors.
+func (c *Client) Invoke(ctx context.Context, call ClientCall, req, resp proto.Message) error {
+	msg := req.ProtoReflect()
+	pathParams := make(map[string]string, len(call.Pattern.vars))
+	bound := make(map[string]bool)
+	sep := call.PathParamSeparator
+	if sep == "" {
+		sep = ","
+	}
+	for _, v := range call.Pattern.vars {
+		value, err := fieldPathString(msg, v, sep)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "path parameter %q: %v", v, err)
+		}
+		pathParams[v] = value
+		bound[v] = true
+	}
+
+	var body []byte
+	query := make(url.Values)
+	switch call.Body {
+	case "*":
+		b, err := c.marshaler.Marshal(req)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to marshal request: %v", err)
+		}
+		body = b
+	case "":
+		if err := addQueryValues(query, msg, "", bound); err != nil {
+			return status.Errorf(codes.InvalidArgument, "query parameters: %v", err)
+		}
+	default:
+		value, fd, err := fieldPathValue(msg, call.Body)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "body: %v", err)
+		}
+		v, err := bodyOf(value, fd)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "body: %v", err)
+		}
+		b, err := c.marshaler.Marshal(v)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to marshal request body: %v", err)
+		}
+		body = b
+		bound[call.Body] = true
+		if err := addQueryValues(query, msg, "", bound); err != nil {
+			return status.Errorf(codes.InvalidArgument, "query parameters: %v", err)
+		}
+	}
+
+	u := c.baseURL + call.Pattern.Build(pathParams)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	httpReq, err := http.NewRequest(call.HTTPMethod, u, bytes.NewReader(body))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to create request: %v", err)
+	}
+	httpReq = httpReq.WithContext(ctx)
+	for k, vs := range c.header {
+		httpReq.Header[k] = append([]string(nil), vs...)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", c.marshaler.ContentType(req))
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer httpResp.Body.Close()
+	buf, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		s := new(statuspb.Status)
+		if err := c.marshaler.Unmarshal(buf, s); err != nil || s.GetCode() == 0 {
+			return status.Errorf(codeFromHTTPStatus(httpResp.StatusCode), "unexpected HTTP status %d: %s", httpResp.StatusCode, buf)
+		}
+		return status.ErrorProto(s)
+	}
+
+	if call.ResponseBody == "" {
+		if err := c.marshaler.Unmarshal(buf, resp); err != nil {
+			return status.Errorf(codes.Internal, "failed to unmarshal response: %v", err)
+		}
+		return nil
+	}
+	respMsg := resp.ProtoReflect()
+	_, fd, err := fieldPathValue(respMsg, call.ResponseBody)
+	if err != nil {
+		return status.Errorf(codes.Internal, "response body: %v", err)
+	}
+	if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return status.Errorf(codes.Unimplemented, "response body %q: only singular message fields are supported", call.ResponseBody)
+	}
+	parent := respMsg
+	path := strings.Split(call.ResponseBody, ".")
+	for _, name := range path[:len(path)-1] {
+		parent = parent.Mutable(parent.Descriptor().Fields().ByName(protoreflect.Name(name))).Message()
+	}
+	if err := c.marshaler.Unmarshal(buf, parent.Mutable(fd).Message().Interface()); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal response: %v", err)
+	}
+	return nil
+}
+
+// bodyOf returns the value to marshal as the body for a field of the request.
+func bodyOf(value protoreflect.Value, fd protoreflect.FieldDescriptor) (interface{}, error) {
+	switch {
+	case fd.IsMap():
+		return nil, fmt.Errorf("map fields cannot be request bodies")
+	case fd.IsList():
+		list := value.List()
+		values := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if fd.Message() != nil {
+				values = append(values, list.Get(i).Message().Interface())
+			} else {
+				values = append(values, list.Get(i).Interface())
+			}
+		}
+		return values, nil
+	case fd.Message() != nil:
+		return value.Message().Interface(), nil
+	}
+	return value.Interface(), nil
+}
+
+// fieldPathValue returns the value of the field at the dotted path of msg.
+func fieldPathValue(msg protoreflect.Message, path string) (protoreflect.Value, protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return protoreflect.Value{}, nil, fmt.Errorf("no field %q in %s", name, msg.Descriptor().FullName())
+		}
+		if i == len(names)-1 {
+			return msg.Get(fd), fd, nil
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return protoreflect.Value{}, nil, fmt.Errorf("%q is not a message", name)
+		}
+		msg = msg.Get(fd).Message()
+	}
+	return protoreflect.Value{}, nil, fmt.Errorf("empty field path")
+}
+
+// fieldPathString returns the value of the field at the dotted path of msg as
+// a path parameter, joining the values of repeated fields with sep.
+func fieldPathString(msg protoreflect.Message, path, sep string) (string, error) {
+	value, fd, err := fieldPathValue(msg, path)
+	if err != nil {
+		return "", err
+	}
+	if fd.IsMap() {
+		return "", fmt.Errorf("map fields cannot be path parameters")
+	}
+	if fd.IsList() {
+		list := value.List()
+		values := make([]string, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			s, err := scalarString(fd, list.Get(i))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, s)
+		}
+		return strings.Join(values, sep), nil
+	}
+	if fd.Kind() == protoreflect.BytesKind {
+		// Unlike queries, paths are parsed from the URL-safe encoding too,
+		// which does not need '/' to be escaped.
+		return base64.URLEncoding.EncodeToString(value.Bytes()), nil
+	}
+	return scalarString(fd, value)
+}
+
+// addQueryValues adds the populated fields of msg, but the bound ones, to
+// query, named by their field path.
+func addQueryValues(query url.Values, msg protoreflect.Message, prefix string, bound map[string]bool) error {
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		name := prefix + string(fd.Name())
+		if bound[name] || fd.IsMap() {
+			return true
+		}
+		if fd.IsList() {
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				var s string
+				if s, err = scalarString(fd, list.Get(i)); err != nil {
+					return false
+				}
+				query.Add(name, s)
+			}
+			return true
+		}
+		if fd.Message() != nil && !isWellKnownMessage(fd.Message()) {
+			err = addQueryValues(query, value.Message(), name+".", bound)
+			return err == nil
+		}
+		var s string
+		if s, err = scalarString(fd, value); err != nil {
+			return false
+		}
+		query.Set(name, s)
+		return true
+	})
+	return err
+}
+
+// isWellKnownMessage reports whether the message is a well-known type, which
+// the query parser reads from a single value.
+func isWellKnownMessage(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf"
+}
+
+// scalarString formats a singular value the way the gateway parses it from
+// paths and queries.
+func scalarString(fd protoreflect.FieldDescriptor, value protoreflect.Value) (string, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return value.String(), nil
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool()), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return strconv.Itoa(int(value.Enum())), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 32), nil
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64), nil
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(value.Bytes()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// Well-known types are written in their JSON form, without the quotes
+		// of JSON strings.
+		b, err := protojson.Marshal(value.Message().Interface())
+		if err != nil {
+			return "", err
+		}
+		if s, err := strconv.Unquote(string(b)); err == nil {
+			return s, nil
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unsupported field kind %s of %s", fd.Kind(), fd.FullName())
+}
+
+// codeFromHTTPStatus is the reverse of HTTPStatusFromCode, for error responses
+// without a status body.
+func codeFromHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case 499:
+		return codes.Canceled
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,139 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClientCertificatePolicyFunc is the signature used to accept or reject the
+// requests by their verified client certificate, which is nil for requests
+// without one. A non-nil error rejects the request, with the error if it is a
+// gRPC status, or else with PermissionDenied.
+type ClientCertificatePolicyFunc func(context.Context, *x509.Certificate) error
+
+// ClientCertificateForwarding configures the forwarding of the verified client
+// certificates of the requests to the gRPC server, enabled with
+// WithClientCertificate. The attributes whose metadata key is empty are not
+// forwarded.
+type ClientCertificateForwarding struct {
+	// SubjectKey is the metadata key of the subject of the certificate, e.g.
+	// "CN=client,O=Example".
+	SubjectKey string
+	// SANKey is the metadata key of the subject alternative names of the
+	// certificate, one value per name: DNS names, email addresses, IP
+	// addresses and URIs.
+	SANKey string
+	// FingerprintKey is the metadata key of the hex-encoded SHA-256 hash of
+	// the DER encoding of the certificate.
+	FingerprintKey string
+	// PEMKey is the metadata key of the URL-encoded PEM encoding of the
+	// certificate, as metadata values cannot contain newlines.
+	PEMKey string
+	// Policy rejects the requests whose certificate fails it, if set.
+	Policy ClientCertificatePolicyFunc
+}
+
+// DefaultClientCertificateForwarding forwards the subject, subject alternative
+// names and fingerprint of the client certificates.
+var DefaultClientCertificateForwarding = ClientCertificateForwarding{
+	SubjectKey:     "x-client-cert-subject",
+	SANKey:         "x-client-cert-san",
+	FingerprintKey: "x-client-cert-fingerprint",
+}
+
+// WithClientCertificate returns a ServeMuxOption forwarding the attributes of
+// the client certificates verified by the TLS server of the gateway as
+// metadata, and rejecting the requests whose certificate fails the policy of
+// forwarding if any. The headers of the clients mapped to the metadata keys
+// of the attributes are dropped, so that the backends cannot be passed
+// attributes which were not verified.
+//
+// Only the leaf of the first verified chain is forwarded, so the server must
+// verify client certificates, e.g. with tls.VerifyClientCertIfGiven.
+func WithClientCertificate(forwarding ClientCertificateForwarding) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.clientCert = &forwarding
+	}
+}
+
+// verifiedClientCertificate returns the leaf of the first verified chain of
+// the client certificates of r, or nil if there is none.
+func verifiedClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// checkClientCertificate replies with an error and returns false if the client
+// certificate of r fails the policy of the mux.
+func (s *ServeMux) checkClientCertificate(w http.ResponseWriter, r *http.Request) bool {
+	if s.clientCert == nil || s.clientCert.Policy == nil {
+		return true
+	}
+	err := s.clientCert.Policy(r.Context(), verifiedClientCertificate(r))
+	if err == nil {
+		return true
+	}
+	if _, ok := status.FromError(err); !ok {
+		err = status.Error(codes.PermissionDenied, err.Error())
+	}
+	_, outboundMarshaler := MarshalerForRequest(s, r)
+	HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+	return false
+}
+
+// pairs returns the metadata pairs forwarding the client certificate of req.
+func (f *ClientCertificateForwarding) pairs(req *http.Request) []string {
+	cert := verifiedClientCertificate(req)
+	if cert == nil {
+		return nil
+	}
+	var pairs []string
+	if f.SubjectKey != "" {
+		pairs = append(pairs, f.SubjectKey, cert.Subject.String())
+	}
+	if f.SANKey != "" {
+		for _, name := range cert.DNSNames {
+			pairs = append(pairs, f.SANKey, name)
+		}
+		for _, email := range cert.EmailAddresses {
+			pairs = append(pairs, f.SANKey, email)
+		}
+		for _, ip := range cert.IPAddresses {
+			pairs = append(pairs, f.SANKey, ip.String())
+		}
+		for _, uri := range cert.URIs {
+			pairs = append(pairs, f.SANKey, uri.String())
+		}
+	}
+	if f.FingerprintKey != "" {
+		sum := sha256.Sum256(cert.Raw)
+		pairs = append(pairs, f.FingerprintKey, hex.EncodeToString(sum[:]))
+	}
+	if f.PEMKey != "" {
+		b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		pairs = append(pairs, f.PEMKey, url.QueryEscape(string(b)))
+	}
+	return pairs
+}
+
+// forwards reports whether key is the metadata key of an attribute forwarded
+// by f.
+func (f *ClientCertificateForwarding) forwards(key string) bool {
+	for _, k := range []string{f.SubjectKey, f.SANKey, f.FingerprintKey, f.PEMKey} {
+		if k != "" && strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func newClientCertificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed with %v; want success", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client", Organization: []string{"Example"}},
+		DNSNames:     []string{"client.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() failed with %v; want success", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() failed with %v; want success", err)
+	}
+	return cert
+}
+
+func TestAnnotateContext_ClientCertificate(t *testing.T) {
+	cert := newClientCertificate(t)
+	request, err := http.NewRequest("GET", "https://example.com", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "GET", "https://example.com", err)
+	}
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	mux := runtime.NewServeMux(runtime.WithClientCertificate(runtime.DefaultClientCertificateForwarding))
+	annotated, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.Example/Example")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", request, err)
+	}
+	md, _ := metadata.FromOutgoingContext(annotated)
+	sum := sha256.Sum256(cert.Raw)
+	for key, want := range map[string][]string{
+		"x-client-cert-subject":     {"CN=client,O=Example"},
+		"x-client-cert-san":         {"client.example.com"},
+		"x-client-cert-fingerprint": {hex.EncodeToString(sum[:])},
+	} {
+		if got := md[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("md[%q] = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestAnnotateContext_ClientCertificateSpoofed(t *testing.T) {
+	cert := newClientCertificate(t)
+	mux := runtime.NewServeMux(runtime.WithClientCertificate(runtime.DefaultClientCertificateForwarding))
+	for _, spec := range []struct {
+		name string
+		tls  *tls.ConnectionState
+		want []string
+	}{
+		{
+			name: "without certificate",
+		},
+		{
+			name: "with certificate",
+			tls:  &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			want: []string{"CN=client,O=Example"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/v1/books", nil)
+			request.TLS = spec.tls
+			request.Header.Set("Grpc-Metadata-X-Client-Cert-Subject", "CN=admin")
+			request.Header.Set("Grpc-Metadata-X-Client-Cert-Fingerprint", "00")
+			annotated, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.Example/Example")
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", request, err)
+			}
+			md, _ := metadata.FromOutgoingContext(annotated)
+			if got := md["x-client-cert-subject"]; !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("md[%q] = %q; want %q", "x-client-cert-subject", got, spec.want)
+			}
+			if got := md["x-client-cert-fingerprint"]; spec.tls == nil && got != nil {
+				t.Errorf("md[%q] = %q; want none", "x-client-cert-fingerprint", got)
+			}
+		})
+	}
+}
+
+func TestServeMuxClientCertificatePolicy(t *testing.T) {
+	cert := newClientCertificate(t)
+	policy := func(_ context.Context, cert *x509.Certificate) error {
+		if cert == nil || cert.Subject.CommonName != "client" {
+			return errors.New("client certificate required")
+		}
+		return nil
+	}
+	forwarding := runtime.DefaultClientCertificateForwarding
+	forwarding.Policy = policy
+	mux := runtime.NewServeMux(runtime.WithClientCertificate(forwarding))
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+
+	for _, spec := range []struct {
+		name   string
+		tls    *tls.ConnectionState
+		status int
+	}{
+		{
+			name:   "without certificate",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "with certificate",
+			tls:    &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			status: http.StatusOK,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/books", nil)
+			r.TLS = spec.tls
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != spec.status {
+				t.Errorf("w.Code = %d; want %d", w.Code, spec.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	timestamppb "github.com/golang/protobuf/ptypes/timestamp"
+	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// newEchoGateway returns a gateway echoing the requests it parses from the
+// path, query and body of GET and POST /v1/{string_value}.
+func newEchoGateway(t *testing.T) (*httptest.Server, runtime.Pattern) {
+	t.Helper()
+	pattern := runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 1, 0, 4, 1, 5, 1}, []string{"v1", "string_value"}, ""))
+	mux := runtime.NewServeMux()
+	echo := func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		inbound, outbound := runtime.MarshalerForRequest(mux, r)
+		msg := &examplepb.Proto3Message{}
+		if r.Method == "POST" {
+			msg.Nested = &examplepb.Proto3Message{}
+			if err := inbound.NewDecoder(r.Body).Decode(msg.Nested); err != nil {
+				runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+				return
+			}
+		}
+		for k, v := range pathParams {
+			if err := runtime.PopulateFieldFromPath(msg, k, v); err != nil {
+				runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+				return
+			}
+		}
+		if err := runtime.PopulateQueryParameters(msg, r.URL.Query(), utilities.NewDoubleArray(nil)); err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		if msg.StringValue == "missing" {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.NotFound, "no such message"))
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, r, msg)
+	}
+	mux.Handle("GET", pattern, echo)
+	mux.Handle("POST", pattern, echo)
+	return httptest.NewServer(mux), pattern
+}
+
+func TestClientInvoke(t *testing.T) {
+	server, pattern := newEchoGateway(t)
+	defer server.Close()
+	client := runtime.NewClient(server.URL + "/")
+
+	for _, spec := range []struct {
+		name string
+		call runtime.ClientCall
+		req  *examplepb.Proto3Message
+	}{
+		{
+			name: "path and query",
+			call: runtime.ClientCall{HTTPMethod: "GET", Pattern: pattern},
+			req: &examplepb.Proto3Message{
+				StringValue:       "a b?",
+				Int32Value:        42,
+				BoolValue:         true,
+				RepeatedValue:     []string{"x", "y"},
+				EnumValue:         examplepb.EnumValue_Y,
+				BytesValue:        []byte{0xfb, 0xff},
+				Nested:            &examplepb.Proto3Message{Int64Value: -7, DoubleValue: 1.5},
+				TimestampValue:    &timestamppb.Timestamp{Seconds: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Unix()},
+				WrapperInt32Value: &wrapperspb.Int32Value{Value: 5},
+			},
+		},
+		{
+			name: "body field",
+			call: runtime.ClientCall{HTTPMethod: "POST", Pattern: pattern, Body: "nested"},
+			req: &examplepb.Proto3Message{
+				StringValue: "id",
+				Uint64Value: 9,
+				Nested:      &examplepb.Proto3Message{StringValue: "in body", RepeatedValue: []string{"z"}},
+			},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			resp := &examplepb.Proto3Message{}
+			if err := client.Invoke(context.Background(), spec.call, spec.req, resp); err != nil {
+				t.Fatalf("client.Invoke(%v) failed with %v; want success", spec.req, err)
+			}
+			if diff := cmp.Diff(resp, spec.req, protocmp.Transform()); diff != "" {
+				t.Errorf("client.Invoke(%v) echoed %v; diff: %s", spec.req, resp, diff)
+			}
+		})
+	}
+}
+
+func TestClientInvokeError(t *testing.T) {
+	server, pattern := newEchoGateway(t)
+	defer server.Close()
+	client := runtime.NewClient(server.URL)
+
+	req := &examplepb.Proto3Message{StringValue: "missing"}
+	err := client.Invoke(context.Background(), runtime.ClientCall{HTTPMethod: "GET", Pattern: pattern}, req, &examplepb.Proto3Message{})
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.NotFound || s.Message() != "no such message" {
+		t.Errorf("client.Invoke(%v) failed with %v; want a NotFound status error", req, err)
+	}
+}
@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// BackendConnStats is a snapshot of the statistics of the connections from
+// the gateway to a single backend address.
+type BackendConnStats struct {
+	// Connects is the number of connections established to the backend.
+	Connects uint64
+	// Reconnects is the number of connections established after the first one.
+	Reconnects uint64
+	// Disconnects is the number of connections to the backend that were closed.
+	Disconnects uint64
+	// Inflight is the number of RPCs currently in progress on the backend.
+	Inflight int64
+	// Picks is the number of RPCs that have been assigned to the backend.
+	Picks uint64
+	// PickLatency is the total time spent between starting an RPC and
+	// assigning it to the backend. Divide by Picks for the average.
+	PickLatency time.Duration
+}
+
+// ConnStatsHooks are called by a ConnStatsHandler as connection-level events
+// occur. Any of the hooks may be nil. Hooks are called synchronously from
+// gRPC, so they should not block.
+type ConnStatsHooks struct {
+	// OnConnect is called when a connection to backend is established.
+	// reconnect is true for every connection after the first one.
+	OnConnect func(backend string, reconnect bool)
+	// OnDisconnect is called when a connection to backend is closed.
+	OnDisconnect func(backend string)
+	// OnPick is called when the RPC method has been assigned to backend, with
+	// the time it took to pick a connection.
+	OnPick func(ctx context.Context, method, backend string, latency time.Duration)
+	// OnInflightChange is called whenever the number of RPCs in progress on
+	// backend changes.
+	OnInflightChange func(backend string, inflight int64)
+}
+
+// ConnStatsHandler is a gRPC stats.Handler recording the connection-level
+// statistics of the connections from the gateway to its backends, so that
+// latency caused by connection churn can be told apart from latency caused by
+// the backend handlers.
+//
+// Install it on the client connections used by the gateway, e.g.
+//
+//	h := runtime.NewConnStatsHandler(runtime.ConnStatsHooks{})
+//	err := pb.RegisterEchoServiceHandlerFromEndpoint(ctx, mux, endpoint, []grpc.DialOption{
+//		grpc.WithInsecure(),
+//		grpc.WithStatsHandler(h),
+//	})
+type ConnStatsHandler struct {
+	hooks ConnStatsHooks
+
+	mu       sync.Mutex
+	backends map[string]*BackendConnStats
+}
+
+// NewConnStatsHandler returns a ConnStatsHandler calling the given hooks.
+func NewConnStatsHandler(hooks ConnStatsHooks) *ConnStatsHandler {
+	return &ConnStatsHandler{
+		hooks:    hooks,
+		backends: make(map[string]*BackendConnStats),
+	}
+}
+
+// Stats returns a snapshot of the statistics of every backend seen so far,
+// keyed by backend address.
+func (h *ConnStatsHandler) Stats() map[string]BackendConnStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := make(map[string]BackendConnStats, len(h.backends))
+	for backend, s := range h.backends {
+		snapshot[backend] = *s
+	}
+	return snapshot
+}
+
+type connStatsKey struct{}
+
+type rpcStatsKey struct{}
+
+// rpcConnStats tracks a single RPC from the moment it starts until it ends.
+type rpcConnStats struct {
+	method  string
+	begin   time.Time
+	backend string
+}
+
+// TagConn implements stats.Handler.
+func (h *ConnStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	if info.RemoteAddr == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, connStatsKey{}, info.RemoteAddr.String())
+}
+
+// HandleConn implements stats.Handler.
+func (h *ConnStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	if !s.IsClient() {
+		return
+	}
+	backend, ok := ctx.Value(connStatsKey{}).(string)
+	if !ok {
+		return
+	}
+	switch s.(type) {
+	case *stats.ConnBegin:
+		h.mu.Lock()
+		bs := h.backend(backend)
+		bs.Connects++
+		reconnect := bs.Connects > 1
+		if reconnect {
+			bs.Reconnects++
+		}
+		h.mu.Unlock()
+		if h.hooks.OnConnect != nil {
+			h.hooks.OnConnect(backend, reconnect)
+		}
+	case *stats.ConnEnd:
+		h.mu.Lock()
+		h.backend(backend).Disconnects++
+		h.mu.Unlock()
+		if h.hooks.OnDisconnect != nil {
+			h.hooks.OnDisconnect(backend)
+		}
+	}
+}
+
+// TagRPC implements stats.Handler.
+func (h *ConnStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcStatsKey{}, &rpcConnStats{method: info.FullMethodName})
+}
+
+// HandleRPC implements stats.Handler.
+func (h *ConnStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if !s.IsClient() {
+		return
+	}
+	rs, ok := ctx.Value(rpcStatsKey{}).(*rpcConnStats)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		rs.begin = s.BeginTime
+	case *stats.OutHeader:
+		// The header is sent once a transport has been picked for the RPC.
+		if s.RemoteAddr == nil || rs.backend != "" {
+			return
+		}
+		rs.backend = s.RemoteAddr.String()
+		var latency time.Duration
+		if !rs.begin.IsZero() {
+			latency = time.Since(rs.begin)
+		}
+		h.mu.Lock()
+		bs := h.backend(rs.backend)
+		bs.Picks++
+		bs.PickLatency += latency
+		bs.Inflight++
+		inflight := bs.Inflight
+		h.mu.Unlock()
+		if h.hooks.OnPick != nil {
+			h.hooks.OnPick(ctx, rs.method, rs.backend, latency)
+		}
+		if h.hooks.OnInflightChange != nil {
+			h.hooks.OnInflightChange(rs.backend, inflight)
+		}
+	case *stats.End:
+		if rs.backend == "" {
+			return
+		}
+		h.mu.Lock()
+		bs := h.backend(rs.backend)
+		bs.Inflight--
+		inflight := bs.Inflight
+		h.mu.Unlock()
+		if h.hooks.OnInflightChange != nil {
+			h.hooks.OnInflightChange(rs.backend, inflight)
+		}
+	}
+}
+
+// backend returns the statistics of the backend, creating them if needed.
+// h.mu must be held.
+func (h *ConnStatsHandler) backend(backend string) *BackendConnStats {
+	bs, ok := h.backends[backend]
+	if !ok {
+		bs = &BackendConnStats{}
+		h.backends[backend] = bs
+	}
+	return bs
+}
@@ -0,0 +1,86 @@
+package runtime_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/stats"
+)
+
+func TestConnStatsHandler(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 9090}
+	backend := addr.String()
+
+	var (
+		connects, reconnects, disconnects int
+		picks                             []string
+		inflight                          []int64
+	)
+	h := runtime.NewConnStatsHandler(runtime.ConnStatsHooks{
+		OnConnect: func(b string, reconnect bool) {
+			connects++
+			if reconnect {
+				reconnects++
+			}
+		},
+		OnDisconnect: func(b string) {
+			disconnects++
+		},
+		OnPick: func(ctx context.Context, method, b string, latency time.Duration) {
+			picks = append(picks, method)
+		},
+		OnInflightChange: func(b string, n int64) {
+			inflight = append(inflight, n)
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{RemoteAddr: addr})
+		h.HandleConn(ctx, &stats.ConnBegin{Client: true})
+		h.HandleConn(ctx, &stats.ConnEnd{Client: true})
+	}
+
+	ctx := context.Background()
+	ctx1 := h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/example.Service/A"})
+	ctx2 := h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/example.Service/B"})
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		h.HandleRPC(ctx, &stats.Begin{Client: true, BeginTime: time.Now()})
+		h.HandleRPC(ctx, &stats.OutHeader{Client: true, RemoteAddr: addr})
+	}
+	h.HandleRPC(ctx1, &stats.End{Client: true})
+
+	if connects != 2 || reconnects != 1 || disconnects != 2 {
+		t.Errorf("got connects=%d reconnects=%d disconnects=%d; want 2, 1, 2", connects, reconnects, disconnects)
+	}
+	if want := []string{"/example.Service/A", "/example.Service/B"}; len(picks) != 2 || picks[0] != want[0] || picks[1] != want[1] {
+		t.Errorf("picks = %v; want %v", picks, want)
+	}
+	if want := []int64{1, 2, 1}; len(inflight) != 3 || inflight[0] != want[0] || inflight[1] != want[1] || inflight[2] != want[2] {
+		t.Errorf("inflight changes = %v; want %v", inflight, want)
+	}
+
+	got, ok := h.Stats()[backend]
+	if !ok {
+		t.Fatalf("h.Stats() has no entry for %q", backend)
+	}
+	if got.Connects != 2 || got.Reconnects != 1 || got.Disconnects != 2 || got.Inflight != 1 || got.Picks != 2 {
+		t.Errorf("h.Stats()[%q] = %+v; want Connects=2 Reconnects=1 Disconnects=2 Inflight=1 Picks=2", backend, got)
+	}
+}
+
+func TestConnStatsHandlerIgnoresServerEvents(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 9090}
+	h := runtime.NewConnStatsHandler(runtime.ConnStatsHooks{})
+
+	ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{RemoteAddr: addr})
+	h.HandleConn(ctx, &stats.ConnBegin{Client: false})
+	ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/example.Service/A"})
+	h.HandleRPC(ctx, &stats.OutHeader{Client: false, RemoteAddr: addr})
+
+	if got := h.Stats(); len(got) != 0 {
+		t.Errorf("h.Stats() = %v; want empty", got)
+	}
+}
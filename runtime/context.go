@@ -56,7 +56,8 @@ AnnotateContext adds context information such as metadata from the request.
 
 At a minimum, the RemoteAddr is included in the fashion of "X-Forwarded-For",
 except that the forwarded destination is not another HTTP service but rather
-a gRPC service.
+a gRPC service. With WithForwardedHeaders, the client resolved from the
+forwarding headers of the request is included instead.
 */
 func AnnotateContext(ctx context.Context, mux *ServeMux, req *http.Request, rpcMethodName string) (context.Context, error) {
 	ctx, md, err := annotateContext(ctx, mux, req, rpcMethodName)
@@ -86,24 +87,34 @@ func AnnotateIncomingContext(ctx context.Context, mux *ServeMux, req *http.Reque
 
 func annotateContext(ctx context.Context, mux *ServeMux, req *http.Request, rpcMethodName string) (context.Context, metadata.MD, error) {
 	ctx = withRPCMethod(ctx, rpcMethodName)
+	ctx = withHooks(ctx, mux.hooks)
+	ctx = withValidator(ctx, mux.validator)
+	ctx = withAudit(ctx, mux.audit)
+	ctx = withRecord(ctx, mux.record)
+	ctx = withRequestModifiers(ctx, mux.requestModifiers)
+	ctx = withMatchedRoute(ctx, mux, req)
 	var pairs []string
-	timeout := DefaultContextTimeout
-	if tm := req.Header.Get(metadataGrpcTimeout); tm != "" {
-		var err error
-		timeout, err = timeoutDecode(tm)
-		if err != nil {
-			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid grpc-timeout: %s", tm)
-		}
+	timeout, err := requestTimeout(mux, req)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	for key, vals := range req.Header {
 		key = textproto.CanonicalMIMEHeaderKey(key)
+		if key == mux.requestIDHeader {
+			// The resolved request ID is forwarded below instead.
+			continue
+		}
 		for _, val := range vals {
 			// For backwards-compatibility, pass through 'authorization' header with no prefix.
 			if key == "Authorization" {
 				pairs = append(pairs, "authorization", val)
 			}
 			if h, ok := mux.incomingHeaderMatcher(key); ok {
+				if mux.reservedMetadataKey(h) {
+					// The metadata forwarded by the mux cannot be set by the clients.
+					continue
+				}
 				// Handles "-bin" metadata in grpc, since grpc will do another base64
 				// encode before sending to server, we need to decode it first.
 				if strings.HasSuffix(key, metadataHeaderBinarySuffix) {
@@ -118,18 +129,42 @@ func annotateContext(ctx context.Context, mux *ServeMux, req *http.Request, rpcM
 			}
 		}
 	}
-	if host := req.Header.Get(xForwardedHost); host != "" {
-		pairs = append(pairs, strings.ToLower(xForwardedHost), host)
-	} else if req.Host != "" {
-		pairs = append(pairs, strings.ToLower(xForwardedHost), req.Host)
+	pairs = append(pairs, mux.requestIDPairs(ctx)...)
+	if mux.clientCert != nil {
+		pairs = append(pairs, mux.clientCert.pairs(req)...)
+	}
+	if mux.introspector != nil {
+		pairs = append(pairs, mux.introspector.pairs(req.Context())...)
+	}
+	if mux.filter != nil {
+		pairs = append(pairs, mux.filter.pairs(req.Context())...)
+	}
+	if mux.lastEventID != nil {
+		pairs = append(pairs, mux.lastEventID.pairs(req)...)
 	}
+	if mux.tenant != nil {
+		pairs = append(pairs, mux.tenant.pairs(req.Context())...)
+	}
+	if mux.longPoll != nil {
+		pairs = append(pairs, mux.longPoll.pairs(req.Context())...)
+	}
+
+	if mux.forwarded != nil {
+		pairs = append(pairs, mux.forwarded.pairs(req)...)
+	} else {
+		if host := req.Header.Get(xForwardedHost); host != "" {
+			pairs = append(pairs, strings.ToLower(xForwardedHost), host)
+		} else if req.Host != "" {
+			pairs = append(pairs, strings.ToLower(xForwardedHost), req.Host)
+		}
 
-	if addr := req.RemoteAddr; addr != "" {
-		if remoteIP, _, err := net.SplitHostPort(addr); err == nil {
-			if fwd := req.Header.Get(xForwardedFor); fwd == "" {
-				pairs = append(pairs, strings.ToLower(xForwardedFor), remoteIP)
-			} else {
-				pairs = append(pairs, strings.ToLower(xForwardedFor), fmt.Sprintf("%s, %s", fwd, remoteIP))
+		if addr := req.RemoteAddr; addr != "" {
+			if remoteIP, _, err := net.SplitHostPort(addr); err == nil {
+				if fwd := req.Header.Get(xForwardedFor); fwd == "" {
+					pairs = append(pairs, strings.ToLower(xForwardedFor), remoteIP)
+				} else {
+					pairs = append(pairs, strings.ToLower(xForwardedFor), fmt.Sprintf("%s, %s", fwd, remoteIP))
+				}
 			}
 		}
 	}
@@ -144,9 +179,31 @@ func annotateContext(ctx context.Context, mux *ServeMux, req *http.Request, rpcM
 	for _, mda := range mux.metadataAnnotators {
 		md = metadata.Join(md, mda(ctx, req))
 	}
+	if len(mux.routeAnnotators) > 0 {
+		md = metadata.Join(md, annotateRoute(ctx, mux, req, rpcMethodName))
+	}
 	return ctx, md, nil
 }
 
+// reservedMetadataKey reports whether the metadata key is forwarded by the mux
+// itself, e.g. as the client address, the tenant, the client certificate or
+// the token claims of the request, in place of the headers of the clients.
+func (s *ServeMux) reservedMetadataKey(key string) bool {
+	if s.forwarded != nil && s.forwarded.forwards(key) {
+		return true
+	}
+	if s.tenant != nil && strings.EqualFold(key, s.tenant.MetadataKey) {
+		return true
+	}
+	if s.clientCert != nil && s.clientCert.forwards(key) {
+		return true
+	}
+	if s.introspector != nil && s.introspector.forwards(key) {
+		return true
+	}
+	return false
+}
+
 // ServerMetadata consists of metadata sent from gRPC server.
 type ServerMetadata struct {
 	HeaderMD  metadata.MD
@@ -223,6 +280,41 @@ func (s *ServerTransportStream) SetTrailer(md metadata.MD) error {
 	return nil
 }
 
+// requestTimeout returns the timeout of the call forwarding req. The
+// Grpc-Timeout header takes precedence over the headers registered with
+// WithTimeoutHeader, and DefaultContextTimeout applies if none is present.
+// The timeout is bounded by the maximum set with WithMaxTimeout, if any.
+func requestTimeout(mux *ServeMux, req *http.Request) (time.Duration, error) {
+	timeout := DefaultContextTimeout
+	if tm := req.Header.Get(metadataGrpcTimeout); tm != "" {
+		var err error
+		timeout, err = timeoutDecode(tm)
+		if err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "invalid grpc-timeout: %s", tm)
+		}
+	} else {
+		for _, h := range mux.timeoutHeaders {
+			tm := req.Header.Get(h)
+			if tm == "" {
+				continue
+			}
+			d, err := timeoutDecode(tm)
+			if err != nil {
+				d, err = time.ParseDuration(tm)
+			}
+			if err != nil || d <= 0 {
+				return 0, status.Errorf(codes.InvalidArgument, "invalid %s: %s", strings.ToLower(h), tm)
+			}
+			timeout = d
+			break
+		}
+	}
+	if mux.maxTimeout > 0 && (timeout == 0 || timeout > mux.maxTimeout) {
+		timeout = mux.maxTimeout
+	}
+	return timeout, nil
+}
+
 func timeoutDecode(s string) (time.Duration, error) {
 	size := len(s)
 	if size < 2 {
@@ -310,3 +402,17 @@ func RPCMethod(ctx context.Context) (string, bool) {
 func withRPCMethod(ctx context.Context, rpcMethodName string) context.Context {
 	return context.WithValue(ctx, rpcMethodKey{}, rpcMethodName)
 }
+
+type allowedMethodsKey struct{}
+
+// AllowedMethods returns the HTTP methods bound to the path of a request whose
+// method is not, in the context given to the routing error handler for
+// http.StatusMethodNotAllowed. The methods are sorted.
+func AllowedMethods(ctx context.Context) ([]string, bool) {
+	methods, ok := ctx.Value(allowedMethodsKey{}).([]string)
+	return methods, ok
+}
+
+func withAllowedMethods(ctx context.Context, methods []string) context.Context {
+	return context.WithValue(ctx, allowedMethodsKey{}, methods)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/base64"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -219,6 +222,106 @@ func TestAnnotateContext_SupportsTimeouts(t *testing.T) {
 		}
 	}
 }
+func TestAnnotateContext_SupportsTimeoutHeaders(t *testing.T) {
+	defer func(d time.Duration) { runtime.DefaultContextTimeout = d }(runtime.DefaultContextTimeout)
+	runtime.DefaultContextTimeout = 0
+
+	const acceptableError = 50 * time.Millisecond
+	for _, spec := range []struct {
+		name    string
+		headers map[string]string
+		opts    []runtime.ServeMuxOption
+		want    time.Duration
+	}{
+		{
+			name:    "unregistered header",
+			headers: map[string]string{"X-Request-Timeout": "5s"},
+		},
+		{
+			name:    "duration",
+			headers: map[string]string{"X-Request-Timeout": "1.5s"},
+			opts:    []runtime.ServeMuxOption{runtime.WithTimeoutHeader("x-request-timeout")},
+			want:    1500 * time.Millisecond,
+		},
+		{
+			name:    "grpc timeout format",
+			headers: map[string]string{"X-Request-Timeout": "1009m"},
+			opts:    []runtime.ServeMuxOption{runtime.WithTimeoutHeader("X-Request-Timeout")},
+			want:    1009 * time.Millisecond,
+		},
+		{
+			name:    "grpc timeout precedence",
+			headers: map[string]string{"Grpc-Timeout": "3S", "X-Request-Timeout": "5s"},
+			opts:    []runtime.ServeMuxOption{runtime.WithTimeoutHeader("X-Request-Timeout")},
+			want:    3 * time.Second,
+		},
+		{
+			name:    "bounded",
+			headers: map[string]string{"X-Request-Timeout": "1H"},
+			opts:    []runtime.ServeMuxOption{runtime.WithTimeoutHeader("X-Request-Timeout"), runtime.WithMaxTimeout(10 * time.Second)},
+			want:    10 * time.Second,
+		},
+		{
+			name:    "bounded grpc timeout",
+			headers: map[string]string{"Grpc-Timeout": "1H"},
+			opts:    []runtime.ServeMuxOption{runtime.WithMaxTimeout(10 * time.Second)},
+			want:    10 * time.Second,
+		},
+		{
+			name:    "within bound",
+			headers: map[string]string{"Grpc-Timeout": "2S"},
+			opts:    []runtime.ServeMuxOption{runtime.WithMaxTimeout(10 * time.Second)},
+			want:    2 * time.Second,
+		},
+		{
+			name: "maximum without timeout",
+			opts: []runtime.ServeMuxOption{runtime.WithMaxTimeout(10 * time.Second)},
+			want: 10 * time.Second,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://example.com", nil)
+			if err != nil {
+				t.Fatalf(`http.NewRequest("GET", "http://example.com", nil failed with %v; want success`, err)
+			}
+			for k, v := range spec.headers {
+				request.Header.Set(k, v)
+			}
+			annotated, err := runtime.AnnotateContext(context.Background(), runtime.NewServeMux(spec.opts...), request, "/example.Example/Example")
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", request, err)
+			}
+			deadline, ok := annotated.Deadline()
+			if spec.want == 0 {
+				if ok {
+					t.Errorf("annotated.Deadline() = _, true; want _, false")
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("annotated.Deadline() = _, false; want _, true")
+			}
+			if got, want := time.Until(deadline), spec.want; got-want > acceptableError || got-want < -acceptableError {
+				t.Errorf("time.Until(deadline) = %v; want %v; with error %v", got, want, acceptableError)
+			}
+		})
+	}
+}
+
+func TestAnnotateContext_InvalidTimeoutHeader(t *testing.T) {
+	for _, value := range []string{"soon", "-5s", "0S"} {
+		request, err := http.NewRequest("GET", "http://example.com", nil)
+		if err != nil {
+			t.Fatalf(`http.NewRequest("GET", "http://example.com", nil failed with %v; want success`, err)
+		}
+		request.Header.Set("X-Request-Timeout", value)
+		mux := runtime.NewServeMux(runtime.WithTimeoutHeader("X-Request-Timeout"))
+		if _, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.Example/Example"); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("runtime.AnnotateContext(ctx, %#v) failed with %v; want an InvalidArgument error", request, err)
+		}
+	}
+}
+
 func TestAnnotateContext_SupportsCustomAnnotators(t *testing.T) {
 	md1 := func(context.Context, *http.Request) metadata.MD { return metadata.New(map[string]string{"foo": "bar"}) }
 	md2 := func(context.Context, *http.Request) metadata.MD { return metadata.New(map[string]string{"baz": "qux"}) }
@@ -246,6 +349,40 @@ func TestAnnotateContext_SupportsCustomAnnotators(t *testing.T) {
 	}
 }
 
+func TestAnnotateContext_SupportsRouteAnnotators(t *testing.T) {
+	var got runtime.Route
+	annotator := func(_ context.Context, _ *http.Request, route runtime.Route) metadata.MD {
+		got = route
+		return metadata.Pairs("route", route.Pattern)
+	}
+	mux := runtime.NewServeMux(runtime.WithRouteMetadata(annotator))
+	var md metadata.MD
+	err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		annotated, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/GetShelf")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", r, err)
+		}
+		md, _ = metadata.FromOutgoingContext(annotated)
+	})
+	if err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/shelves/1", nil))
+
+	want := runtime.Route{
+		Pattern:    "/v1/{name=shelves/*}",
+		PathParams: map[string]string{"name": "shelves/1"},
+		RPCMethod:  "/example.Example/GetShelf",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("annotator got route %#v; want %#v", got, want)
+	}
+	if got, want := md["route"], []string{"/v1/{name=shelves/*}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`md["route"] = %v; want %v`, got, want)
+	}
+}
+
 func TestAnnotateIncomingContext_WorksWithEmpty(t *testing.T) {
 	ctx := context.Background()
 	expectedRPCName := "/example.Example/Example"
@@ -0,0 +1,221 @@
+// Package contracttest checks that a gateway serves its REST surface as its
+// generated OpenAPI document describes it. It derives requests from the
+// parameters and schemas of the operations of the document, serves them with
+// the mux of the gateway, and checks the status codes and the bodies of the
+// responses against the document, so that a drift between the generator and
+// the runtime fails in CI:
+//
+//	func TestContract(t *testing.T) {
+//		mux := runtime.NewServeMux()
+//		if err := pb.RegisterLibraryServiceHandlerClient(context.Background(), mux, pb.NewLibraryServiceClient(mock.NewConn(mock.Config{}))); err != nil {
+//			t.Fatal(err)
+//		}
+//		doc, err := ioutil.ReadFile("library.swagger.json")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		contracttest.Run(t, mux, doc, contracttest.Config{})
+//	}
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Config configures Check.
+type Config struct {
+	// Requests is the number of requests of each operation, 5 if zero. The
+	// first one has the required parameters and properties only, and the
+	// others each of the optional ones at random.
+	Requests int
+	// Seed seeds the generation of the requests, so that the failures can
+	// be reproduced. The same requests are generated for a given seed.
+	Seed int64
+	// Skip reports whether op is skipped if set, e.g. the operations whose
+	// requests the document cannot describe.
+	Skip func(op Operation) bool
+	// Prepare is called with the requests before they are served if set,
+	// e.g. to authenticate them.
+	Prepare func(r *http.Request)
+	// Accept reports whether the responses of op with a status code the
+	// document does not list are expected if set, e.g. the 404 of the
+	// backends not knowing the resources of the requests. The bodies of the
+	// responses are checked against the default response of op regardless.
+	Accept func(op Operation, status int) bool
+}
+
+// Operation is an operation of the document.
+type Operation struct {
+	// Method is the HTTP method of the operation, e.g. "GET".
+	Method string
+	// Path is the path of the operation in the document, e.g.
+	// "/v1/{name=shelves/*}".
+	Path string
+	// ID is the operationId of the operation, e.g.
+	// "LibraryService_GetShelf".
+	ID string
+}
+
+func (op Operation) String() string {
+	return op.Method + " " + op.Path
+}
+
+// Violation is a response of an operation which does not match the document.
+type Violation struct {
+	Operation Operation
+	// Request is the method and the URL of the request, e.g.
+	// "GET /v1/shelves/a?view=FULL".
+	Request string
+	// Message describes the mismatch, e.g.
+	// `response 200: $.id: got 42, want a string of format int64`.
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (%s): %s", v.Operation, v.Request, v.Message)
+}
+
+// Check serves the requests derived from the OpenAPI v2 document doc with h
+// and returns the responses not matching it. It fails if the document cannot
+// be read.
+//
+// The responses must have a status code listed by their operation, and their
+// JSON bodies match the schema of the status code, or that of the default
+// response. The objects must have the required properties and no other than
+// those of their schema, unless it has additionalProperties.
+func Check(h http.Handler, doc []byte, config Config) ([]Violation, error) {
+	var d document
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI document: %v", err)
+	}
+	if config.Requests <= 0 {
+		config.Requests = 5
+	}
+	g := &generator{doc: &d, rnd: rand.New(rand.NewSource(config.Seed))}
+
+	var violations []Violation
+	seen := make(map[string]bool)
+	for _, path := range sortedPaths(d.Paths) {
+		item := d.Paths[path]
+		for _, key := range sortedMethods(item) {
+			var op operationObject
+			if err := json.Unmarshal(item[key], &op); err != nil {
+				return nil, fmt.Errorf("failed to read the operation %s %s: %v", operationMethods[key], path, err)
+			}
+			operation := Operation{Method: operationMethods[key], Path: path, ID: op.OperationID}
+			if config.Skip != nil && config.Skip(operation) {
+				continue
+			}
+			for i := 0; i < config.Requests; i++ {
+				g.minimal = i == 0
+				r, err := g.request(operation.Method, path, &op)
+				if err != nil {
+					return nil, fmt.Errorf("failed to generate a request of %s: %v", operation, err)
+				}
+				if config.Prepare != nil {
+					config.Prepare(r)
+				}
+				request := r.Method + " " + r.URL.RequestURI()
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				for _, msg := range d.check(operation, &op, w, config.Accept) {
+					// A mismatch is reported once per operation.
+					if key := operation.String() + "\n" + msg; !seen[key] {
+						seen[key] = true
+						violations = append(violations, Violation{Operation: operation, Request: request, Message: msg})
+					}
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// Run is Check reporting the violations as errors of t.
+func Run(t testing.TB, h http.Handler, doc []byte, config Config) {
+	t.Helper()
+	violations, err := Check(h, doc, config)
+	if err != nil {
+		t.Fatalf("contracttest.Check() failed with %v", err)
+	}
+	for _, v := range violations {
+		t.Errorf("%s", v)
+	}
+}
+
+// check returns the mismatches of the response w of op with the document.
+func (d *document) check(operation Operation, op *operationObject, w *httptest.ResponseRecorder, accept func(Operation, int) bool) []string {
+	var msgs []string
+	status := strconv.Itoa(w.Code)
+	resp, ok := op.Responses[status]
+	if !ok {
+		if accept == nil || !accept(operation, w.Code) {
+			msgs = append(msgs, fmt.Sprintf("undocumented response %d: %s", w.Code, excerpt(w.Body.String())))
+		}
+		resp = op.Responses["default"]
+	}
+	if resp == nil || resp.Schema == nil || w.Body.Len() == 0 {
+		return msgs
+	}
+	if mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type")); err != nil || mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") && mediaType != "application/x-ndjson" {
+		// The bodies of google.api.HttpBody and of the other marshalers
+		// are not described by the schemas.
+		return msgs
+	}
+
+	// The streams are written as a JSON value per message.
+	dec := json.NewDecoder(w.Body)
+	dec.UseNumber()
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return append(msgs, fmt.Sprintf("response %d: invalid JSON: %v", w.Code, err))
+		}
+		for _, msg := range d.validate(v, resp.Schema, "$") {
+			msgs = append(msgs, fmt.Sprintf("response %d: %s", w.Code, msg))
+		}
+	}
+	return msgs
+}
+
+// excerpt returns the beginning of the body of a response.
+func excerpt(body string) string {
+	body = strings.TrimSpace(body)
+	if len(body) > 200 {
+		return body[:197] + "..."
+	}
+	return body
+}
+
+func sortedPaths(paths map[string]map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedMethods returns the keys of the operations of the path item.
+func sortedMethods(item map[string]json.RawMessage) []string {
+	var keys []string
+	for k := range item {
+		if _, ok := operationMethods[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,225 @@
+package contracttest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/contracttest"
+)
+
+const libraryDocument = `{
+  "swagger": "2.0",
+  "paths": {
+    "/v1/shelves": {
+      "post": {
+        "operationId": "Library_CreateShelf",
+        "parameters": [
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Shelf"}}
+        ],
+        "responses": {
+          "200": {"description": "", "schema": {"$ref": "#/definitions/Shelf"}},
+          "default": {"description": "", "schema": {"$ref": "#/definitions/rpcStatus"}}
+        }
+      }
+    },
+    "/v1/{name=shelves/*}": {
+      "get": {
+        "operationId": "Library_GetShelf",
+        "parameters": [
+          {"name": "name", "in": "path", "required": true, "type": "string", "pattern": "shelves/[^/]+"},
+          {"name": "view", "in": "query", "required": false, "type": "string", "enum": ["BASIC", "FULL"]},
+          {"name": "tags", "in": "query", "required": false, "type": "array", "items": {"type": "string"}, "collectionFormat": "multi"}
+        ],
+        "responses": {
+          "200": {"description": "", "schema": {"$ref": "#/definitions/Shelf"}},
+          "default": {"description": "", "schema": {"$ref": "#/definitions/rpcStatus"}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Shelf": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "id": {"type": "string", "format": "int64"},
+        "size": {"type": "integer", "format": "int32", "minimum": 1, "maximum": 10},
+        "tags": {"type": "array", "items": {"type": "string"}},
+        "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+        "createTime": {"type": "string", "format": "date-time", "readOnly": true}
+      },
+      "required": ["name"]
+    },
+    "protobufAny": {
+      "type": "object",
+      "properties": {
+        "typeUrl": {"type": "string"},
+        "value": {"type": "string", "format": "byte"}
+      }
+    },
+    "rpcStatus": {
+      "type": "object",
+      "properties": {
+        "code": {"type": "integer", "format": "int32"},
+        "message": {"type": "string"},
+        "details": {"type": "array", "items": {"$ref": "#/definitions/protobufAny"}}
+      }
+    }
+  }
+}`
+
+// newLibraryMux returns a mux serving the library, whose shelves are written
+// by shelf.
+func newLibraryMux(t *testing.T, shelf func(name string) map[string]interface{}) *runtime.ServeMux {
+	mux := runtime.NewServeMux()
+	write := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("json.NewEncoder(w).Encode(%v) failed with %v; want success", v, err)
+		}
+	}
+	if err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if view := r.URL.Query().Get("view"); view != "" && view != "BASIC" && view != "FULL" {
+			t.Errorf("view = %q; want a value of the enum", view)
+		}
+		write(w, shelf(pathParams["name"]))
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+	}
+	if err := mux.HandlePath("POST", "/v1/shelves", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("json.NewDecoder(r.Body).Decode(&req) failed with %v; want success", err)
+		}
+		if _, ok := req["createTime"]; ok {
+			t.Errorf("req = %v; want no read-only property", req)
+		}
+		if size, ok := req["size"].(float64); ok && (size < 1 || size > 10) {
+			t.Errorf("req.size = %v; want a size between the minimum and the maximum", size)
+		}
+		name, ok := req["name"].(string)
+		if !ok {
+			t.Errorf("req = %v; want the required name", req)
+		}
+		if name == "invalid" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code": 3, "message": "invalid name"}`)
+			return
+		}
+		write(w, shelf("shelves/"+name))
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/shelves", err)
+	}
+	return mux
+}
+
+func TestCheck(t *testing.T) {
+	var names []string
+	mux := newLibraryMux(t, func(name string) map[string]interface{} {
+		names = append(names, name)
+		return map[string]interface{}{
+			"name":       name,
+			"id":         "42",
+			"tags":       []string{"a"},
+			"labels":     map[string]string{"k": "v"},
+			"createTime": "2006-01-02T15:04:05Z",
+		}
+	})
+	violations, err := contracttest.Check(mux, []byte(libraryDocument), contracttest.Config{Requests: 3})
+	if err != nil {
+		t.Fatalf("contracttest.Check(mux, doc, config) failed with %v; want success", err)
+	}
+	for _, v := range violations {
+		t.Errorf("contracttest.Check(mux, doc, config) reported %s; want no violation", v)
+	}
+	if got, want := len(names), 6; got != want {
+		t.Errorf("%d shelves served; want %d", got, want)
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "shelves/") || strings.Count(name, "/") != 1 {
+			t.Errorf("name = %q; want a name matching shelves/*", name)
+		}
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	mux := newLibraryMux(t, func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":       42,
+			"size":     "3",
+			"labels":   map[string]interface{}{"k": 1},
+			"shelfId":  "a",
+			"metadata": nil,
+		}
+	})
+	violations, err := contracttest.Check(mux, []byte(libraryDocument), contracttest.Config{
+		Requests: 1,
+		Skip: func(op contracttest.Operation) bool {
+			return op.ID == "Library_CreateShelf"
+		},
+	})
+	if err != nil {
+		t.Fatalf("contracttest.Check(mux, doc, config) failed with %v; want success", err)
+	}
+	var got []string
+	for _, v := range violations {
+		if v.Operation.Method != "GET" || v.Operation.Path != "/v1/{name=shelves/*}" || !strings.HasPrefix(v.Request, "GET /v1/shelves/") {
+			t.Errorf("violation %s; want one of GET /v1/{name=shelves/*}", v)
+		}
+		got = append(got, v.Message)
+	}
+	want := []string{
+		`response 200: $.id: got 42, want a string of format int64`,
+		`response 200: $.labels.k: got 1, want a string`,
+		`response 200: $: got the undocumented property "metadata"`,
+		`response 200: $: got the undocumented property "shelfId"`,
+		`response 200: $.size: got "3", want an integer of format int32`,
+		`response 200: $: missing the required property "name"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("contracttest.Check(mux, doc, config) reported\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	doc := strings.Replace(libraryDocument, `"default": {"description": "", "schema": {"$ref": "#/definitions/rpcStatus"}}`, `"404": {"description": "", "schema": {"$ref": "#/definitions/rpcStatus"}}`, -1)
+	mux := runtime.NewServeMux()
+	if err := mux.HandlePath("POST", "/v1/shelves", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		fmt.Fprint(w, `{"code": 12, "message": "not implemented"}`)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/shelves", err)
+	}
+	for _, spec := range []struct {
+		name   string
+		accept func(contracttest.Operation, int) bool
+		want   int
+	}{
+		{name: "undocumented", want: 1},
+		{name: "accepted", accept: func(op contracttest.Operation, status int) bool { return status == http.StatusNotImplemented }},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			violations, err := contracttest.Check(mux, []byte(doc), contracttest.Config{
+				Accept: spec.accept,
+				Skip: func(op contracttest.Operation) bool {
+					return op.Method != "POST"
+				},
+			})
+			if err != nil {
+				t.Fatalf("contracttest.Check(mux, doc, config) failed with %v; want success", err)
+			}
+			if len(violations) != spec.want {
+				t.Fatalf("contracttest.Check(mux, doc, config) = %v; want %d violations", violations, spec.want)
+			}
+			if spec.want > 0 && !strings.HasPrefix(violations[0].Message, "undocumented response 501: ") {
+				t.Errorf("violation %s; want an undocumented 501", violations[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package contracttest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// document is the part of an OpenAPI v2 document the requests are derived
+// from and the responses checked against.
+type document struct {
+	BasePath    string                                `json:"basePath"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]*schemaObject              `json:"definitions"`
+}
+
+// operationMethods are the keys of the operations of a path item, by HTTP
+// method.
+var operationMethods = map[string]string{
+	"get":     "GET",
+	"put":     "PUT",
+	"post":    "POST",
+	"delete":  "DELETE",
+	"options": "OPTIONS",
+	"head":    "HEAD",
+	"patch":   "PATCH",
+}
+
+type operationObject struct {
+	OperationID string                     `json:"operationId"`
+	Parameters  []*parameterObject         `json:"parameters"`
+	Responses   map[string]*responseObject `json:"responses"`
+}
+
+// parameterObject is a parameter, whose schema is that of the body
+// parameters and whose type is that of the others.
+type parameterObject struct {
+	schemaObject
+	Name             string        `json:"name"`
+	In               string        `json:"in"`
+	Required         bool          `json:"required"`
+	CollectionFormat string        `json:"collectionFormat"`
+	Schema           *schemaObject `json:"schema"`
+}
+
+type responseObject struct {
+	Schema *schemaObject `json:"schema"`
+}
+
+type schemaObject struct {
+	Ref                  string                   `json:"$ref"`
+	Type                 string                   `json:"type"`
+	Format               string                   `json:"format"`
+	Items                *schemaObject            `json:"items"`
+	Properties           map[string]*schemaObject `json:"properties"`
+	AdditionalProperties json.RawMessage          `json:"additionalProperties"`
+	Required             []string                 `json:"required"`
+	Enum                 []interface{}            `json:"enum"`
+	ReadOnly             bool                     `json:"readOnly"`
+	Nullable             bool                     `json:"x-nullable"`
+	Minimum              *float64                 `json:"minimum"`
+	Maximum              *float64                 `json:"maximum"`
+	MinLength            int                      `json:"minLength"`
+	MaxLength            int                      `json:"maxLength"`
+	MinItems             int                      `json:"minItems"`
+}
+
+// additional returns the schema of the additional properties of s, and
+// whether they are allowed.
+func (s *schemaObject) additional() (*schemaObject, bool) {
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); raw {
+	case "", "false":
+		return nil, false
+	case "true", "{}":
+		return nil, true
+	}
+	var additional schemaObject
+	if err := json.Unmarshal(s.AdditionalProperties, &additional); err != nil {
+		return nil, true
+	}
+	return &additional, true
+}
+
+// isAny reports whether s is the schema of a google.protobuf.Any, whose JSON
+// has the fields of the message it packs.
+func (s *schemaObject) isAny() bool {
+	if _, ok := s.Properties["@type"]; ok {
+		return true
+	}
+	_, typeURL := s.Properties["typeUrl"]
+	_, value := s.Properties["value"]
+	return len(s.Properties) == 2 && typeURL && value
+}
+
+// resolve returns the definition s refers to, if any.
+func (d *document) resolve(s *schemaObject) (*schemaObject, error) {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		def, ok := d.Definitions[name]
+		if !ok || depth > len(d.Definitions) {
+			return nil, fmt.Errorf("unknown definition %q", s.Ref)
+		}
+		s = def
+	}
+	return s, nil
+}
+
+// validate returns the mismatches of the JSON value v, as decoded with
+// UseNumber, with s. path is the path of v in the response, e.g. "$.books[0]".
+func (d *document) validate(v interface{}, s *schemaObject, path string) []string {
+	s, err := d.resolve(s)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", path, err)}
+	}
+	if s == nil {
+		return nil
+	}
+	if v == nil {
+		if s.Type == "" || s.Nullable {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: got null, want %s", path, describe(s))}
+	}
+	if len(s.Enum) > 0 {
+		if !inEnum(s.Enum, v) {
+			return []string{fmt.Sprintf("%s: got %s, want one of %v", path, jsonOf(v), s.Enum)}
+		}
+		return nil
+	}
+
+	mismatch := func() []string {
+		return []string{fmt.Sprintf("%s: got %s, want %s", path, jsonOf(v), describe(s))}
+	}
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		return d.validateObject(obj, s, path)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		var errs []string
+		for i, item := range items {
+			errs = append(errs, d.validate(item, s.Items, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "string":
+		str, ok := v.(string)
+		if !ok || !validString(str, s.Format) {
+			return mismatch()
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok || !validInteger(string(n), s.Format) {
+			return mismatch()
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	case "":
+		// The schemas without a type, e.g. that of google.protobuf.Value,
+		// allow any value.
+		if obj, ok := v.(map[string]interface{}); ok && len(s.Properties) > 0 {
+			return d.validateObject(obj, s, path)
+		}
+	}
+	return nil
+}
+
+func (d *document) validateObject(obj map[string]interface{}, s *schemaObject, path string) []string {
+	if s.isAny() {
+		return nil
+	}
+	additional, allowed := s.additional()
+	// The objects without properties, e.g. google.protobuf.Struct, allow
+	// any property.
+	allowed = allowed || len(s.Properties) == 0
+
+	var errs []string
+	for _, name := range sortedKeys(obj) {
+		prop, ok := s.Properties[name]
+		switch {
+		case ok:
+			errs = append(errs, d.validate(obj[name], prop, path+"."+name)...)
+		case additional != nil:
+			errs = append(errs, d.validate(obj[name], additional, path+"."+name)...)
+		case !allowed:
+			errs = append(errs, fmt.Sprintf("%s: got the undocumented property %q", path, name))
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: missing the required property %q", path, name))
+		}
+	}
+	return errs
+}
+
+// validString reports whether s is a string of the format.
+func validString(s, format string) bool {
+	switch format {
+	case "int64", "uint64", "int32", "uint32":
+		return validInteger(s, format)
+	case "byte":
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if _, err := enc.DecodeString(s); err == nil {
+				return true
+			}
+		}
+		return false
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}
+	return true
+}
+
+// validInteger reports whether s is an integer of the format.
+func validInteger(s, format string) bool {
+	switch format {
+	case "int32":
+		_, err := strconv.ParseInt(s, 10, 32)
+		return err == nil
+	case "uint32":
+		_, err := strconv.ParseUint(s, 10, 32)
+		return err == nil
+	case "uint64":
+		_, err := strconv.ParseUint(s, 10, 64)
+		return err == nil
+	case "int64":
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	}
+	// The integers of other formats are within the range of a float64.
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && f == math.Trunc(f)
+}
+
+// inEnum reports whether v is a value of enum, the enums of integers being
+// decoded as float64 and the values as json.Number.
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if jsonOf(e) == jsonOf(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe returns the type and format of s, e.g. "a string of format int64".
+func describe(s *schemaObject) string {
+	article := "a"
+	if s.Type == "object" || s.Type == "array" || s.Type == "integer" {
+		article = "an"
+	}
+	if s.Format == "" {
+		return fmt.Sprintf("%s %s", article, s.Type)
+	}
+	return fmt.Sprintf("%s %s of format %s", article, s.Type, s.Format)
+}
+
+// jsonOf returns v as JSON, shortened for the messages.
+func jsonOf(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > 64 {
+		return string(b[:61]) + "..."
+	}
+	return string(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
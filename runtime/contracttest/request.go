@@ -0,0 +1,292 @@
+package contracttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDepth is the depth of the nested objects beyond which their optional
+// properties are left unset, bounding the recursive definitions.
+const maxDepth = 4
+
+// pathParam matches the parameters of the paths, e.g. "{name}" or
+// "{name=shelves/*}".
+var pathParam = regexp.MustCompile(`\{([^}=]+)(?:=([^}]*))?\}`)
+
+// generator generates the requests of the operations of a document.
+type generator struct {
+	doc *document
+	rnd *rand.Rand
+	// minimal is whether the requests have their required parameters and
+	// properties only.
+	minimal bool
+}
+
+// request returns a request of op, whose path is path.
+func (g *generator) request(method, path string, op *operationObject) (*http.Request, error) {
+	params := make(map[string]*parameterObject)
+	query := make(url.Values)
+	header := make(http.Header)
+	var body []byte
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			params[p.Name] = p
+		case "query", "header":
+			if !p.Required && (g.minimal || g.rnd.Intn(2) == 0) {
+				continue
+			}
+			v, err := g.value(&p.schemaObject, 0)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %q: %v", p.Name, err)
+			}
+			values := paramValues(v, p.CollectionFormat)
+			if p.In == "header" {
+				header.Set(p.Name, strings.Join(values, ","))
+			} else {
+				query[p.Name] = append(query[p.Name], values...)
+			}
+		case "body":
+			v, err := g.value(p.Schema, 0)
+			if err != nil {
+				return nil, fmt.Errorf("body: %v", err)
+			}
+			if body, err = json.Marshal(v); err != nil {
+				return nil, fmt.Errorf("body: %v", err)
+			}
+		}
+	}
+
+	var err error
+	path = pathParam.ReplaceAllStringFunc(path, func(m string) string {
+		sub := pathParam.FindStringSubmatch(m)
+		name, tmpl := sub[1], sub[2]
+		if tmpl != "" {
+			return g.template(tmpl)
+		}
+		p, ok := params[name]
+		if !ok {
+			err = fmt.Errorf("no parameter of the path parameter %q", name)
+			return m
+		}
+		v, verr := g.value(&p.schemaObject, 0)
+		if verr != nil {
+			err = fmt.Errorf("parameter %q: %v", name, verr)
+			return m
+		}
+		return url.PathEscape(strings.Join(paramValues(v, "csv"), ","))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target := strings.TrimSuffix(g.doc.BasePath, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var r *http.Request
+	if body != nil {
+		r = httptest.NewRequest(method, target, bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	return r, nil
+}
+
+// template returns a path matching the path template of a parameter, e.g.
+// "shelves/*".
+func (g *generator) template(tmpl string) string {
+	segments := strings.Split(tmpl, "/")
+	for i, s := range segments {
+		switch s {
+		case "*":
+			segments[i] = g.word(1, 8)
+		case "**":
+			segments[i] = g.word(1, 8) + "/" + g.word(1, 8)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// value returns a JSON value of s.
+func (g *generator) value(s *schemaObject, depth int) (interface{}, error) {
+	s, err := g.doc.resolve(s)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return map[string]interface{}{}, nil
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[g.rnd.Intn(len(s.Enum))], nil
+	}
+	switch s.Type {
+	case "object", "":
+		return g.object(s, depth)
+	case "array":
+		n := s.MinItems
+		if !g.minimal && n < 2 {
+			n += g.rnd.Intn(3 - n)
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := g.value(s.Items, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case "string":
+		return g.string(s), nil
+	case "integer":
+		return int64(g.number(s, 0, 100)), nil
+	case "number":
+		return float64(int(g.number(s, 0, 100)*4)) / 4, nil
+	case "boolean":
+		return g.rnd.Intn(2) == 0, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", s.Type)
+}
+
+// object returns a JSON object of s, without its read-only properties.
+func (g *generator) object(s *schemaObject, depth int) (interface{}, error) {
+	obj := make(map[string]interface{})
+	if s.isAny() {
+		// The JSON of an Any depends on the type it packs.
+		return obj, nil
+	}
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop.ReadOnly || !required[name] && (g.minimal || depth >= maxDepth || g.rnd.Intn(2) == 0) {
+			continue
+		}
+		v, err := g.value(prop, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		obj[name] = v
+	}
+	if additional, _ := s.additional(); additional != nil && !g.minimal && depth < maxDepth {
+		// The keys of the maps are numbers, which are valid keys whatever
+		// their type but bool.
+		for i := g.rnd.Intn(3); i > 0; i-- {
+			v, err := g.value(additional, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj[strconv.Itoa(g.rnd.Intn(1000))] = v
+		}
+	}
+	return obj, nil
+}
+
+// string returns a string of s.
+func (g *generator) string(s *schemaObject) string {
+	switch s.Format {
+	case "int64", "int32", "uint64", "uint32":
+		return strconv.FormatInt(int64(g.number(s, 0, 1e6)), 10)
+	case "byte":
+		b := make([]byte, 1+g.rnd.Intn(8))
+		g.rnd.Read(b)
+		return base64.StdEncoding.EncodeToString(b)
+	case "date-time":
+		return time.Unix(g.rnd.Int63n(4e9), 0).UTC().Format(time.RFC3339)
+	}
+	min, max := 1, 8
+	if s.MinLength > min {
+		min = s.MinLength
+	}
+	if s.MaxLength > 0 && s.MaxLength < max {
+		max = s.MaxLength
+	}
+	if max < min {
+		max = min
+	}
+	return g.word(min, max)
+}
+
+// word returns a random lower case word of min to max letters.
+func (g *generator) word(min, max int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, min+g.rnd.Intn(max-min+1))
+	for i := range b {
+		b[i] = letters[g.rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// number returns an integer between the minimum and the maximum of s, or
+// between min and max, non-negative for the unsigned formats.
+func (g *generator) number(s *schemaObject, min, max float64) float64 {
+	if s.Minimum != nil {
+		min = *s.Minimum
+	} else if !strings.HasPrefix(s.Format, "uint") {
+		min = -max
+	}
+	if s.Maximum != nil {
+		max = *s.Maximum
+	}
+	if max < min {
+		max = min
+	}
+	return min + float64(g.rnd.Int63n(int64(max-min)+1))
+}
+
+// paramValues returns the values of the parameter of value v, split or joined
+// by the collection format.
+func paramValues(v interface{}, collectionFormat string) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return []string{scalarString(v)}
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = scalarString(item)
+	}
+	switch collectionFormat {
+	case "multi":
+		return values
+	case "ssv":
+		return []string{strings.Join(values, " ")}
+	case "tsv":
+		return []string{strings.Join(values, "\t")}
+	case "pipes":
+		return []string{strings.Join(values, "|")}
+	}
+	return []string{strings.Join(values, ",")}
+}
+
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -46,7 +46,7 @@ func BoolSlice(val, sep string) ([]bool, error) {
 
 // Float64 converts the given string representation into representation of a floating point number into float64.
 func Float64(val string) (float64, error) {
-	return strconv.ParseFloat(val, 64)
+	return strconv.ParseFloat(pathNumber(val), 64)
 }
 
 // Float64Slice converts 'val' where individual floating point numbers are separated by
@@ -66,7 +66,7 @@ func Float64Slice(val, sep string) ([]float64, error) {
 
 // Float32 converts the given string representation of a floating point number into float32.
 func Float32(val string) (float32, error) {
-	f, err := strconv.ParseFloat(val, 32)
+	f, err := strconv.ParseFloat(pathNumber(val), 32)
 	if err != nil {
 		return 0, err
 	}
@@ -90,7 +90,7 @@ func Float32Slice(val, sep string) ([]float32, error) {
 
 // Int64 converts the given string representation of an integer into int64.
 func Int64(val string) (int64, error) {
-	return strconv.ParseInt(val, 0, 64)
+	return strconv.ParseInt(pathNumber(val), 0, 64)
 }
 
 // Int64Slice converts 'val' where individual integers are separated by
@@ -110,7 +110,7 @@ func Int64Slice(val, sep string) ([]int64, error) {
 
 // Int32 converts the given string representation of an integer into int32.
 func Int32(val string) (int32, error) {
-	i, err := strconv.ParseInt(val, 0, 32)
+	i, err := strconv.ParseInt(pathNumber(val), 0, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -134,7 +134,7 @@ func Int32Slice(val, sep string) ([]int32, error) {
 
 // Uint64 converts the given string representation of an integer into uint64.
 func Uint64(val string) (uint64, error) {
-	return strconv.ParseUint(val, 0, 64)
+	return strconv.ParseUint(pathNumber(val), 0, 64)
 }
 
 // Uint64Slice converts 'val' where individual integers are separated by
@@ -154,7 +154,7 @@ func Uint64Slice(val, sep string) ([]uint64, error) {
 
 // Uint32 converts the given string representation of an integer into uint32.
 func Uint32(val string) (uint32, error) {
-	i, err := strconv.ParseUint(val, 0, 32)
+	i, err := strconv.ParseUint(pathNumber(val), 0, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -210,6 +210,9 @@ func Timestamp(val string) (*timestamppb.Timestamp, error) {
 	unmarshaler := &protojson.UnmarshalOptions{}
 	err := unmarshaler.Unmarshal([]byte(val), &r)
 	if err != nil {
+		if p := localizedParser(); p != nil && len(p.TimeLayouts) > 0 {
+			return p.parseTime(val)
+		}
 		return nil, err
 	}
 	return &r, nil
@@ -236,14 +239,14 @@ func Enum(val string, enumValMap map[string]int32) (int32, error) {
 
 	i, err := Int32(val)
 	if err != nil {
-		return 0, fmt.Errorf("%s is not valid", val)
+		return 0, &enumValueError{fmt.Sprintf("%s is not valid", val)}
 	}
 	for _, v := range enumValMap {
 		if v == i {
 			return i, nil
 		}
 	}
-	return 0, fmt.Errorf("%s is not valid", val)
+	return 0, &enumValueError{fmt.Sprintf("%s is not valid", val)}
 }
 
 // EnumSlice converts 'val' where individual enums are separated by 'sep'
@@ -0,0 +1,90 @@
+package runtime
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/textproto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CSRFProtection configures the protection of the requests with mutating
+// methods against cross-site request forgery, enabled with WithCSRFProtection.
+type CSRFProtection struct {
+	// Header is the header the requests must have, "X-CSRF-Token" if empty.
+	// Browsers only send custom headers cross-site when allowed by CORS.
+	Header string
+	// Cookie enables the double-submit check if set: the value of the header
+	// must then equal the value of the cookie of this name, which the client
+	// can only read from the origin of the gateway.
+	Cookie string
+	// ExemptRoutes are the path templates of the routes not to protect, e.g.
+	// "/v1/webhooks/{id}", as given to HandlePath or in the http rules.
+	ExemptRoutes []string
+}
+
+// WithCSRFProtection returns a ServeMuxOption protecting the requests with
+// mutating methods, i.e. other than GET, HEAD, OPTIONS and TRACE, against
+// cross-site request forgery, which gateways forwarding cookies to their
+// backends are otherwise exposed to. The requests failing the check are
+// replied to with a PermissionDenied error, i.e. 403 Forbidden.
+//
+// WithCSRFProtection panics if an exempt route is not a valid path template.
+func WithCSRFProtection(protection CSRFProtection) ServeMuxOption {
+	protection.Header = textproto.CanonicalMIMEHeaderKey(protection.Header)
+	if protection.Header == "" {
+		protection.Header = "X-Csrf-Token"
+	}
+	exempt := make(map[string]bool, len(protection.ExemptRoutes))
+	for _, route := range protection.ExemptRoutes {
+		pattern, err := normalizeRoute(route)
+		if err != nil {
+			panic(fmt.Sprintf("runtime: invalid CSRF exempt route %q: %v", route, err))
+		}
+		exempt[pattern] = true
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.csrf = &csrfProtection{CSRFProtection: protection, exempt: exempt}
+	}
+}
+
+type csrfProtection struct {
+	CSRFProtection
+	exempt map[string]bool
+}
+
+// allow determines if r to the route pat passes the check.
+func (p *csrfProtection) allow(r *http.Request, pat Pattern) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	if p.exempt[pat.String()] {
+		return true
+	}
+	token := r.Header.Get(p.Header)
+	if token == "" {
+		return false
+	}
+	if p.Cookie == "" {
+		return true
+	}
+	cookie, err := r.Cookie(p.Cookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) == 1
+}
+
+// checkCSRF replies with an error and returns false if r to the route pat
+// fails the CSRF protection of the mux.
+func (s *ServeMux) checkCSRF(w http.ResponseWriter, r *http.Request, pat Pattern) bool {
+	if s.csrf == nil || s.csrf.allow(r, pat) {
+		return true
+	}
+	_, outboundMarshaler := MarshalerForRequest(s, r)
+	HTTPError(r.Context(), s, outboundMarshaler, w, r, status.Error(codes.PermissionDenied, "missing or invalid CSRF token"))
+	return false
+}
@@ -0,0 +1,96 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestServeMuxCSRFProtection(t *testing.T) {
+	for _, spec := range []struct {
+		name       string
+		protection runtime.CSRFProtection
+		method     string
+		path       string
+		header     string
+		cookie     string
+		status     int
+	}{
+		{
+			name:   "safe method",
+			method: "GET",
+			path:   "/v1/books/1",
+			status: http.StatusOK,
+		},
+		{
+			name:   "missing header",
+			method: "POST",
+			path:   "/v1/books/1",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "custom header",
+			method: "DELETE",
+			path:   "/v1/books/1",
+			header: "1",
+			status: http.StatusOK,
+		},
+		{
+			name:       "exempt route",
+			protection: runtime.CSRFProtection{ExemptRoutes: []string{"/v1/books/{id}"}},
+			method:     "POST",
+			path:       "/v1/books/1",
+			status:     http.StatusOK,
+		},
+		{
+			name:       "double submit",
+			protection: runtime.CSRFProtection{Cookie: "csrf"},
+			method:     "POST",
+			path:       "/v1/books/1",
+			header:     "token",
+			cookie:     "token",
+			status:     http.StatusOK,
+		},
+		{
+			name:       "double submit mismatch",
+			protection: runtime.CSRFProtection{Cookie: "csrf"},
+			method:     "POST",
+			path:       "/v1/books/1",
+			header:     "token",
+			cookie:     "other",
+			status:     http.StatusForbidden,
+		},
+		{
+			name:       "double submit without cookie",
+			protection: runtime.CSRFProtection{Cookie: "csrf"},
+			method:     "POST",
+			path:       "/v1/books/1",
+			header:     "token",
+			status:     http.StatusForbidden,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithCSRFProtection(spec.protection))
+			for _, method := range []string{"GET", "POST", "DELETE"} {
+				if err := mux.HandlePath(method, "/v1/books/{id}", func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {}); err != nil {
+					t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", method, "/v1/books/{id}", err)
+				}
+			}
+			r := httptest.NewRequest(spec.method, spec.path, nil)
+			if spec.header != "" {
+				r.Header.Set("X-CSRF-Token", spec.header)
+			}
+			if spec.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "csrf", Value: spec.cookie})
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != spec.status {
+				t.Errorf("w.Code = %d; want %d", w.Code, spec.status)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/status"
@@ -17,6 +20,10 @@ type ErrorHandlerFunc func(context.Context, *ServeMux, Marshaler, http.ResponseW
 // StreamErrorHandlerFunc is the signature used to configure stream error handling.
 type StreamErrorHandlerFunc func(context.Context, error) *status.Status
 
+// ErrorTransformerFunc is the signature used to transform the status of errors
+// before they are handled, e.g. to strip internal details from them.
+type ErrorTransformerFunc func(context.Context, *status.Status) *status.Status
+
 // RoutingErrorHandlerFunc is the signature used to configure error handling for routing errors.
 type RoutingErrorHandlerFunc func(context.Context, *ServeMux, Marshaler, http.ResponseWriter, *http.Request, int)
 
@@ -65,22 +72,88 @@ func HTTPStatusFromCode(code codes.Code) int {
 	return http.StatusInternalServerError
 }
 
-// HTTPError uses the mux-configured error handler.
+// HTTPError uses the mux-configured error handler, after localizing the
+// message of the error and transforming it with the mux-configured error
+// transformer if any.
 func HTTPError(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, r *http.Request, err error) {
-	mux.errorHandler(ctx, mux, marshaler, w, r, err)
+	writeServerTiming(w, r)
+	mux.errorHandler(ctx, mux, marshaler, w, r, mux.processError(ctx, r, err))
+}
+
+// HTTPStatusError is an error replied to with the given HTTP status, instead of
+// the status mapped from its gRPC code by HTTPStatusFromCode.
+type HTTPStatusError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (e *HTTPStatusError) Error() string {
+	return e.Err.Error()
+}
+
+// GRPCStatus returns the status of the wrapped error, so that status.FromError
+// and status.Convert see through an HTTPStatusError.
+func (e *HTTPStatusError) GRPCStatus() *status.Status {
+	return status.Convert(e.Err)
+}
+
+// processError localizes and transforms err, keeping the HTTP status of an
+// HTTPStatusError.
+func (s *ServeMux) processError(ctx context.Context, r *http.Request, err error) error {
+	if statusErr, ok := err.(*HTTPStatusError); ok {
+		return &HTTPStatusError{HTTPStatus: statusErr.HTTPStatus, Err: s.processError(ctx, r, statusErr.Err)}
+	}
+	return s.transformError(ctx, s.localizeError(r, err))
+}
+
+// transformError returns err transformed by the error transformer of the mux,
+// or err itself if there is none.
+func (s *ServeMux) transformError(ctx context.Context, err error) error {
+	if s.errorTransformer == nil {
+		return err
+	}
+	return s.transformStatus(ctx, status.Convert(err)).Err()
+}
+
+// transformStatus returns st transformed by the error transformer of the mux.
+// A nil status returned by the transformer leaves st unchanged.
+func (s *ServeMux) transformStatus(ctx context.Context, st *status.Status) *status.Status {
+	if s.errorTransformer == nil {
+		return st
+	}
+	if transformed := s.errorTransformer(ctx, st); transformed != nil {
+		return transformed
+	}
+	return st
 }
 
 // DefaultHTTPErrorHandler is the default error handler.
 // If "err" is a gRPC Status, the function replies with the status code mapped by HTTPStatusFromCode.
 // If otherwise, it replies with http.StatusInternalServerError.
+// If "err" is an HTTPStatusError, it replies with its HTTP status instead.
+// If the status has a google.rpc.RetryInfo detail, its retry delay is set as
+// the Retry-After header, in seconds.
 //
-// The response body written by this function is a Status message marshaled by the Marshaler.
+// The response body written by this function is a Status message marshaled by the Marshaler,
+// or the message shaped for its HTTP status with WithErrorBody.
 func DefaultHTTPErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, r *http.Request, err error) {
 	// return Internal when Marshal failed
 	const fallback = `{"code": 13, "message": "failed to marshal error message"}`
 
+	var customStatus *HTTPStatusError
+	if errors.As(err, &customStatus) {
+		err = customStatus.Err
+	}
+
 	s := status.Convert(err)
-	pb := s.Proto()
+	st := HTTPStatusFromCode(s.Code())
+	if mux.quotaFailureTooManyRequests && hasQuotaFailure(s) {
+		st = http.StatusTooManyRequests
+	}
+	if customStatus != nil {
+		st = customStatus.HTTPStatus
+	}
+	pb := mux.errorBody(ctx, s, st)
 
 	w.Header().Del("Trailer")
 	w.Header().Del("Transfer-Encoding")
@@ -104,6 +177,7 @@ func DefaultHTTPErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marsh
 	}
 
 	handleForwardResponseServerMetadata(w, mux, md)
+	md = handleForwardResponsePromotedTrailers(w, mux, md)
 
 	// RFC 7230 https://tools.ietf.org/html/rfc7230#section-4.1.2
 	// Unless the request includes a TE header field indicating "trailers"
@@ -118,7 +192,7 @@ func DefaultHTTPErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marsh
 		w.Header().Set("Transfer-Encoding", "chunked")
 	}
 
-	st := HTTPStatusFromCode(s.Code())
+	setRetryAfter(w, s)
 	w.WriteHeader(st)
 	if _, err := w.Write(buf); err != nil {
 		grpclog.Infof("Failed to write response: %v", err)
@@ -129,6 +203,41 @@ func DefaultHTTPErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marsh
 	}
 }
 
+// setRetryAfter sets the Retry-After header from the RetryInfo detail of s, if
+// any and unless the header is already set. The delay is rounded up to whole
+// seconds.
+func setRetryAfter(w http.ResponseWriter, s *status.Status) {
+	if w.Header().Get("Retry-After") != "" {
+		return
+	}
+	for _, detail := range s.Details() {
+		info, ok := detail.(*errdetails.RetryInfo)
+		if !ok || info.GetRetryDelay() == nil {
+			continue
+		}
+		delay := info.GetRetryDelay()
+		seconds := delay.GetSeconds()
+		if delay.GetNanos() > 0 {
+			seconds++
+		}
+		if seconds < 0 {
+			return
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+		return
+	}
+}
+
+// hasQuotaFailure determines if s has a QuotaFailure detail.
+func hasQuotaFailure(s *status.Status) bool {
+	for _, detail := range s.Details() {
+		if _, ok := detail.(*errdetails.QuotaFailure); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func DefaultStreamErrorHandler(_ context.Context, err error) *status.Status {
 	return status.Convert(err)
 }
@@ -139,15 +248,24 @@ func DefaultStreamErrorHandler(_ context.Context, err error) *status.Status {
 //   StatusBadRequest -> grpc.InvalidArgument
 //   MethodNotAllowed -> grpc.Unimplemented
 //   Other -> grpc.Internal, method is not expecting to be called for anything else
+//
+// MethodNotAllowed errors are replied to with 405 and an Allow header listing
+// the methods bound to the path of the request.
 func DefaultRoutingErrorHandler(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
 	sterr := status.Error(codes.Internal, "Unexpected routing error")
 	switch httpStatus {
 	case http.StatusBadRequest:
 		sterr = status.Error(codes.InvalidArgument, http.StatusText(httpStatus))
 	case http.StatusMethodNotAllowed:
-		sterr = status.Error(codes.Unimplemented, http.StatusText(httpStatus))
+		sterr = &HTTPStatusError{
+			HTTPStatus: httpStatus,
+			Err:        status.Error(codes.Unimplemented, http.StatusText(httpStatus)),
+		}
+		if methods, ok := AllowedMethods(ctx); ok {
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+		}
 	case http.StatusNotFound:
 		sterr = status.Error(codes.NotFound, http.StatusText(httpStatus))
 	}
-	mux.errorHandler(ctx, mux, marshaler, w, r, sterr)
+	HTTPError(ctx, mux, marshaler, w, r, sterr)
 }
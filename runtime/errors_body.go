@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrorBodyFunc is the signature used to shape the body of the errors replied
+// to by the default error handler, from their status.
+type ErrorBodyFunc func(context.Context, *status.Status) proto.Message
+
+// DetailedErrorBody is the default ErrorBodyFunc, replying with the status
+// of the error with its code, message and details.
+func DetailedErrorBody(_ context.Context, s *status.Status) proto.Message {
+	return s.Proto()
+}
+
+// MinimalErrorBody is an ErrorBodyFunc replying with the code and message of
+// the status of the error only, without its details.
+func MinimalErrorBody(_ context.Context, s *status.Status) proto.Message {
+	return &statuspb.Status{Code: int32(s.Code()), Message: s.Message()}
+}
+
+// WithErrorBody returns a ServeMuxOption shaping with body the body of the
+// errors replied to with the HTTP statuses matching pattern by the default
+// error handler. The pattern is either an HTTP status, e.g. "404", or a class
+// of HTTP statuses, e.g. "5xx". A status takes precedence over its class, and
+// the errors whose status matches no pattern get a DetailedErrorBody.
+//
+// For instance, the following replies to server errors without their details:
+//
+//	mux := runtime.NewServeMux(runtime.WithErrorBody("5xx", runtime.MinimalErrorBody))
+//
+// WithErrorBody panics if pattern is neither a status nor a class of statuses.
+func WithErrorBody(pattern string, body ErrorBodyFunc) ServeMuxOption {
+	pattern = strings.ToLower(pattern)
+	if !isErrorBodyPattern(pattern) {
+		panic(fmt.Sprintf("runtime: invalid error body pattern %q", pattern))
+	}
+	return func(serveMux *ServeMux) {
+		if serveMux.errorBodies == nil {
+			serveMux.errorBodies = make(map[string]ErrorBodyFunc)
+		}
+		serveMux.errorBodies[pattern] = body
+	}
+}
+
+func isErrorBodyPattern(pattern string) bool {
+	if len(pattern) != 3 || pattern[0] < '1' || pattern[0] > '5' {
+		return false
+	}
+	if pattern[1:] == "xx" {
+		return true
+	}
+	_, err := strconv.Atoi(pattern)
+	return err == nil
+}
+
+// errorBody returns the body of an error with status st replied to with the
+// HTTP status httpStatus.
+func (s *ServeMux) errorBody(ctx context.Context, st *status.Status, httpStatus int) proto.Message {
+	if body, ok := s.errorBodies[strconv.Itoa(httpStatus)]; ok {
+		return body(ctx, st)
+	}
+	if body, ok := s.errorBodies[fmt.Sprintf("%dxx", httpStatus/100)]; ok {
+		return body(ctx, st)
+	}
+	return DetailedErrorBody(ctx, st)
+}
@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// WithLocalizedErrorMessages returns a ServeMuxOption replacing the message of
+// the errors having google.rpc.LocalizedMessage details with the message whose
+// locale best matches the Accept-Language header of the request. The locales
+// in fallback are tried in order when none matches the header, and the message
+// is left unchanged when none matches either.
+//
+// A locale matches a language tag of the header if they are equal, ignoring
+// case, or else if their primary language subtags are, e.g. "en-GB" matches
+// "en-US". The wildcard "*" matches any locale.
+func WithLocalizedErrorMessages(fallback ...string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.localizeErrors = true
+		serveMux.localizedErrorFallback = fallback
+	}
+}
+
+// localizeError returns err with the message of its LocalizedMessage detail
+// best matching the languages accepted by r, if the mux localizes errors.
+func (s *ServeMux) localizeError(r *http.Request, err error) error {
+	if !s.localizeErrors {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return s.localizeStatus(r, st).Err()
+}
+
+func (s *ServeMux) localizeStatus(r *http.Request, st *status.Status) *status.Status {
+	if !s.localizeErrors || r == nil {
+		return st
+	}
+	var messages []*errdetails.LocalizedMessage
+	for _, detail := range st.Details() {
+		if m, ok := detail.(*errdetails.LocalizedMessage); ok {
+			messages = append(messages, m)
+		}
+	}
+	if len(messages) == 0 {
+		return st
+	}
+	tags := append(acceptedLanguages(r.Header.Get("Accept-Language")), s.localizedErrorFallback...)
+	for _, tag := range tags {
+		if m := matchLocalizedMessage(messages, tag); m != nil {
+			pb := st.Proto()
+			pb.Message = m.GetMessage()
+			return status.FromProto(pb)
+		}
+	}
+	return st
+}
+
+// matchLocalizedMessage returns the message whose locale matches the language
+// tag exactly, or else by primary language, or nil if there is none.
+func matchLocalizedMessage(messages []*errdetails.LocalizedMessage, tag string) *errdetails.LocalizedMessage {
+	if tag == "*" {
+		return messages[0]
+	}
+	for _, m := range messages {
+		if strings.EqualFold(m.GetLocale(), tag) {
+			return m
+		}
+	}
+	for _, m := range messages {
+		if strings.EqualFold(primaryLanguage(m.GetLocale()), primaryLanguage(tag)) {
+			return m
+		}
+	}
+	return nil
+}
+
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// acceptedLanguages returns the language tags of an Accept-Language header in
+// decreasing order of quality, without those of quality 0.
+func acceptedLanguages(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.TrimSpace(params[0])
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[len("q="):], 64); err == nil {
+				quality = q
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, language{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	tags := make([]string, 0, len(languages))
+	for _, l := range languages {
+		tags = append(tags, l.tag)
+	}
+	return tags
+}
@@ -9,11 +9,14 @@ import (
 	"strings"
 	"testing"
 
+	durationpb "github.com/golang/protobuf/ptypes/duration"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	statuspb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestDefaultHTTPError(t *testing.T) {
@@ -97,3 +100,259 @@ func TestDefaultHTTPError(t *testing.T) {
 		})
 	}
 }
+
+// redactInternal replaces the message of internal errors.
+func redactInternal(ctx context.Context, st *status.Status) *status.Status {
+	if st.Code() != codes.Internal {
+		return nil
+	}
+	return status.New(codes.Unavailable, "try again later")
+}
+
+func TestHTTPErrorWithErrorTransformer(t *testing.T) {
+	ctx := context.Background()
+	mux := runtime.NewServeMux(runtime.WithErrorTransformer(redactInternal))
+	marshaler := &runtime.JSONPb{}
+
+	for _, spec := range []struct {
+		err    error
+		status int
+		msg    string
+	}{
+		{
+			err:    status.Error(codes.Internal, "panic: runtime error at server.go:42"),
+			status: http.StatusServiceUnavailable,
+			msg:    "try again later",
+		},
+		{
+			err:    status.Error(codes.NotFound, "no such resource"),
+			status: http.StatusNotFound,
+			msg:    "no such resource",
+		},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("", "", nil)
+		runtime.HTTPError(ctx, mux, marshaler, w, req, spec.err)
+
+		if got, want := w.Code, spec.status; got != want {
+			t.Errorf("w.Code = %d; want %d; on spec.err=%v", got, want, spec.err)
+		}
+		var st statuspb.Status
+		if err := marshaler.Unmarshal(w.Body.Bytes(), &st); err != nil {
+			t.Fatalf("marshaler.Unmarshal(%q, &body) failed with %v; want success", w.Body.Bytes(), err)
+		}
+		if got, want := st.Message, spec.msg; got != want {
+			t.Errorf("st.Message = %q; want %q; on spec.err=%v", got, want, spec.err)
+		}
+	}
+}
+
+func TestForwardResponseStreamWithErrorTransformer(t *testing.T) {
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	mux := runtime.NewServeMux(runtime.WithErrorTransformer(redactInternal))
+	marshaler := &runtime.JSONPb{}
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+
+	sent := false
+	recv := func() (proto.Message, error) {
+		if !sent {
+			sent = true
+			return &pb.SimpleMessage{Id: "one"}, nil
+		}
+		return nil, status.Error(codes.Internal, "panic: runtime error at server.go:42")
+	}
+	runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv)
+
+	want, err := marshaler.Marshal(map[string]proto.Message{
+		"error": status.New(codes.Unavailable, "try again later").Proto(),
+	})
+	if err != nil {
+		t.Fatalf("marshaler.Marshal() failed with %v; want success", err)
+	}
+	if body := w.Body.String(); !strings.HasSuffix(body, string(want)) {
+		t.Errorf("ForwardResponseStream() = %q; want suffix %q", body, want)
+	}
+}
+
+func TestDefaultHTTPErrorRetryInfo(t *testing.T) {
+	ctx := context.Background()
+	retry, _ := status.New(codes.Unavailable, "overloaded").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: &durationpb.Duration{Seconds: 1, Nanos: 500000000}},
+	)
+	quota, _ := status.New(codes.FailedPrecondition, "quota exceeded").WithDetails(
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "project:example"}}},
+	)
+
+	for _, spec := range []struct {
+		name       string
+		err        error
+		opts       []runtime.ServeMuxOption
+		status     int
+		retryAfter string
+	}{
+		{
+			name:       "retry info",
+			err:        retry.Err(),
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "2",
+		},
+		{
+			name:   "quota failure",
+			err:    quota.Err(),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "quota failure as too many requests",
+			err:    quota.Err(),
+			opts:   []runtime.ServeMuxOption{runtime.WithQuotaFailureTooManyRequests()},
+			status: http.StatusTooManyRequests,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("", "", nil)
+			runtime.HTTPError(ctx, runtime.NewServeMux(spec.opts...), &runtime.JSONPb{}, w, req, spec.err)
+
+			if got, want := w.Code, spec.status; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Retry-After"), spec.retryAfter; got != want {
+				t.Errorf(`w.Header().Get("Retry-After") = %q; want %q`, got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultHTTPErrorBody(t *testing.T) {
+	ctx := context.Background()
+	detailed, _ := status.New(codes.Internal, "database unavailable").WithDetails(
+		&errdetails.DebugInfo{Detail: "connection refused"},
+	)
+	notFound, _ := status.New(codes.NotFound, "no such resource").WithDetails(
+		&errdetails.ResourceInfo{ResourceName: "shelves/1"},
+	)
+	mux := runtime.NewServeMux(
+		runtime.WithErrorBody("5xx", runtime.MinimalErrorBody),
+		runtime.WithErrorBody("4xx", runtime.MinimalErrorBody),
+		runtime.WithErrorBody("404", runtime.DetailedErrorBody),
+	)
+
+	for _, spec := range []struct {
+		name    string
+		err     error
+		details int
+	}{
+		{
+			name:    "class",
+			err:     detailed.Err(),
+			details: 0,
+		},
+		{
+			name:    "status over class",
+			err:     notFound.Err(),
+			details: 1,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("", "", nil)
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, req, spec.err)
+
+			var body statuspb.Status
+			if err := (&runtime.JSONPb{}).Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("marshaler.Unmarshal(%s) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			st := status.Convert(spec.err)
+			if got, want := body.GetMessage(), st.Message(); got != want {
+				t.Errorf("body.GetMessage() = %q; want %q", got, want)
+			}
+			if got, want := len(body.GetDetails()), spec.details; got != want {
+				t.Errorf("len(body.GetDetails()) = %d; want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestWithErrorBodyInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"", "5", "6xx", "4x4", "abc", "5000"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("runtime.WithErrorBody(%q, body) did not panic; want panic", pattern)
+				}
+			}()
+			runtime.WithErrorBody(pattern, runtime.MinimalErrorBody)
+		}()
+	}
+}
+
+func TestHTTPErrorLocalizedMessages(t *testing.T) {
+	ctx := context.Background()
+	localized, _ := status.New(codes.NotFound, "no such resource").WithDetails(
+		&errdetails.LocalizedMessage{Locale: "de-DE", Message: "Ressource nicht gefunden"},
+		&errdetails.LocalizedMessage{Locale: "fr", Message: "ressource introuvable"},
+	)
+	marshaler := &runtime.JSONPb{}
+
+	for _, spec := range []struct {
+		name           string
+		acceptLanguage string
+		opts           []runtime.ServeMuxOption
+		msg            string
+	}{
+		{
+			name:           "disabled",
+			acceptLanguage: "fr",
+			msg:            "no such resource",
+		},
+		{
+			name:           "exact match",
+			acceptLanguage: "de-DE",
+			opts:           []runtime.ServeMuxOption{runtime.WithLocalizedErrorMessages()},
+			msg:            "Ressource nicht gefunden",
+		},
+		{
+			name:           "primary language match",
+			acceptLanguage: "fr-CA, de;q=0.5",
+			opts:           []runtime.ServeMuxOption{runtime.WithLocalizedErrorMessages()},
+			msg:            "ressource introuvable",
+		},
+		{
+			name:           "quality order",
+			acceptLanguage: "fr;q=0.4, de;q=0.9",
+			opts:           []runtime.ServeMuxOption{runtime.WithLocalizedErrorMessages()},
+			msg:            "Ressource nicht gefunden",
+		},
+		{
+			name:           "fallback",
+			acceptLanguage: "ja",
+			opts:           []runtime.ServeMuxOption{runtime.WithLocalizedErrorMessages("fr")},
+			msg:            "ressource introuvable",
+		},
+		{
+			name:           "no match",
+			acceptLanguage: "ja, fr;q=0",
+			opts:           []runtime.ServeMuxOption{runtime.WithLocalizedErrorMessages()},
+			msg:            "no such resource",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+			req.Header.Set("Accept-Language", spec.acceptLanguage)
+			runtime.HTTPError(ctx, runtime.NewServeMux(spec.opts...), marshaler, w, req, localized.Err())
+
+			var st statuspb.Status
+			if err := marshaler.Unmarshal(w.Body.Bytes(), &st); err != nil {
+				t.Fatalf("marshaler.Unmarshal(%q, &body) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			if got, want := st.Message, spec.msg; got != want {
+				t.Errorf("st.Message = %q; want %q", got, want)
+			}
+			if got, want := len(st.Details), 2; got != want {
+				t.Errorf("len(st.Details) = %d; want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FieldError is an error setting a field of a request from a path or query
+// parameter, as returned by PopulateFieldFromPath and PopulateQueryParameters.
+type FieldError struct {
+	// Field is the path of the field in the request, e.g. "user.age".
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// enumValueError is the error of a value which is neither the name nor the
+// number of a value of an enum.
+type enumValueError struct {
+	msg string
+}
+
+func (e *enumValueError) Error() string {
+	return e.msg
+}
+
+// FieldViolationError returns an InvalidArgument status error for a request
+// field whose value could not be decoded, with a google.rpc.BadRequest detail
+// naming the field and the reason, e.g. "type mismatch", "invalid enum value"
+// or "value out of range", so that clients can point at the field.
+//
+// The field of a FieldError in err takes precedence over field, which may be
+// empty for the errors decoding a whole request.
+func FieldViolationError(field string, err error) error {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) && fieldErr.Field != "" {
+		field = fieldErr.Field
+	}
+	reason := fieldErrorReason(err)
+	msg := fmt.Sprintf("%s: %v", reason, err)
+	if field != "" {
+		msg = fmt.Sprintf("%s, parameter: %s, error: %v", reason, field, err)
+	}
+	st := status.New(codes.InvalidArgument, msg)
+	br := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+		Field:       field,
+		Description: fmt.Sprintf("%s: %v", reason, err),
+	}}}
+	if withDetails, derr := st.WithDetails(br); derr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// fieldErrorReason classifies an error decoding the value of a field.
+func fieldErrorReason(err error) string {
+	var enumErr *enumValueError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &enumErr):
+		return "invalid enum value"
+	case errors.As(err, &numErr) && numErr.Err == strconv.ErrRange:
+		return "value out of range"
+	case errors.As(err, &numErr):
+		return "type mismatch"
+	}
+	return "invalid value"
+}
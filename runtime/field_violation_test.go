@@ -0,0 +1,80 @@
+package runtime_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestFieldViolationError(t *testing.T) {
+	for _, spec := range []struct {
+		values url.Values
+		want   *errdetails.BadRequest_FieldViolation
+	}{
+		{
+			values: url.Values{"int32_value": {"abc"}},
+			want: &errdetails.BadRequest_FieldViolation{
+				Field:       "int32_value",
+				Description: `type mismatch: parsing field "int32_value": strconv.ParseInt: parsing "abc": invalid syntax`,
+			},
+		},
+		{
+			values: url.Values{"int32_value": {"2147483648"}},
+			want: &errdetails.BadRequest_FieldViolation{
+				Field:       "int32_value",
+				Description: `value out of range: parsing field "int32_value": strconv.ParseInt: parsing "2147483648": value out of range`,
+			},
+		},
+		{
+			values: url.Values{"enum_value": {"W"}},
+			want: &errdetails.BadRequest_FieldViolation{
+				Field:       "enum_value",
+				Description: `invalid enum value: parsing field "enum_value": "W" is not a valid value`,
+			},
+		},
+	} {
+		msg := &examplepb.Proto3Message{}
+		perr := runtime.PopulateQueryParameters(msg, spec.values, utilities.NewDoubleArray(nil))
+		if perr == nil {
+			t.Errorf("runtime.PopulateQueryParameters(msg, %v, nil) succeeded; want failure", spec.values)
+			continue
+		}
+		var fieldErr *runtime.FieldError
+		if !errors.As(perr, &fieldErr) || fieldErr.Field != spec.want.Field {
+			t.Errorf("runtime.PopulateQueryParameters(msg, %v, nil) = %v; want a FieldError for %q", spec.values, perr, spec.want.Field)
+		}
+
+		err := runtime.FieldViolationError("", perr)
+		s, _ := status.FromError(err)
+		if s.Code() != codes.InvalidArgument {
+			t.Errorf("runtime.FieldViolationError(%q, %v) = %v; want code %v", "", perr, err, codes.InvalidArgument)
+			continue
+		}
+		details := s.Details()
+		if len(details) != 1 {
+			t.Errorf("runtime.FieldViolationError(%q, %v) details = %v; want one BadRequest", "", perr, details)
+			continue
+		}
+		want := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{spec.want}}
+		if diff := cmp.Diff(details[0], want, protocmp.Transform()); diff != "" {
+			t.Errorf("runtime.FieldViolationError(%q, %v) details = %v; diff: %s", "", perr, details[0], diff)
+		}
+	}
+}
+
+func TestFieldViolationErrorWithoutField(t *testing.T) {
+	err := runtime.FieldViolationError("", errors.New("unexpected EOF"))
+	s, _ := status.FromError(err)
+	if want := "invalid value: unexpected EOF"; s.Message() != want {
+		t.Errorf("runtime.FieldViolationError(%q, err).Message() = %q; want %q", "", s.Message(), want)
+	}
+}
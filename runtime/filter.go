@@ -0,0 +1,582 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
+)
+
+// FilterKind is the kind of a node of the syntax tree of an AIP-160 filter.
+type FilterKind string
+
+const (
+	// FilterAnd is the conjunction of its Args, written with AND or juxtaposed.
+	FilterAnd FilterKind = "and"
+	// FilterOr is the disjunction of its Args.
+	FilterOr FilterKind = "or"
+	// FilterNot is the negation of its only Arg, written with NOT or "-".
+	FilterNot FilterKind = "not"
+	// FilterRestriction compares its first Arg to its second with its
+	// Comparator.
+	FilterRestriction FilterKind = "restriction"
+	// FilterMember is the field of its Path, e.g. "author.name".
+	FilterMember FilterKind = "member"
+	// FilterFunction is the call of the function of its Path with its Args,
+	// e.g. "regex(name, \"^a\")".
+	FilterFunction FilterKind = "function"
+	// FilterValue is a literal, or a restriction of all the fields of the
+	// resources on its own.
+	FilterValue FilterKind = "value"
+)
+
+// FilterExpr is a node of the syntax tree of an AIP-160 filter, as returned by
+// ParseFilter. See https://google.aip.dev/160.
+type FilterExpr struct {
+	Kind FilterKind `json:"kind"`
+	// Args are the operands of the And, Or and Not nodes, the compared
+	// operands of Restriction nodes and the arguments of Function nodes.
+	Args []*FilterExpr `json:"args,omitempty"`
+	// Comparator is the comparator of Restriction nodes: "=", "!=", "<",
+	// "<=", ">", ">=" or ":".
+	Comparator string `json:"comparator,omitempty"`
+	// Path is the field path of Member nodes and the qualified name of
+	// Function nodes.
+	Path []string `json:"path,omitempty"`
+	// Value is the unquoted text of Value nodes.
+	Value string `json:"value,omitempty"`
+	// Quoted is set for the Value nodes which are quoted strings.
+	Quoted bool `json:"quoted,omitempty"`
+}
+
+// String returns the canonical form of e, which parses to e.
+func (e *FilterExpr) String() string {
+	switch e.Kind {
+	case FilterAnd, FilterOr:
+		sep := " AND "
+		if e.Kind == FilterOr {
+			sep = " OR "
+		}
+		parts := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			// OR binds tighter than AND.
+			parts[i] = arg.operand(arg.Kind == FilterAnd || (e.Kind == FilterOr && arg.Kind == FilterOr))
+		}
+		return strings.Join(parts, sep)
+	case FilterNot:
+		return "NOT " + e.Args[0].operand(e.Args[0].Kind == FilterAnd || e.Args[0].Kind == FilterOr || e.Args[0].Kind == FilterNot)
+	case FilterRestriction:
+		arg := e.Args[1]
+		return fmt.Sprintf("%s %s %s", e.Args[0], e.Comparator, arg.operand(arg.Kind == FilterAnd || arg.Kind == FilterOr || arg.Kind == FilterNot || arg.Kind == FilterRestriction))
+	case FilterMember:
+		return filterPath(e.Path)
+	case FilterFunction:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = arg.operand(arg.Kind == FilterAnd || arg.Kind == FilterOr || arg.Kind == FilterNot || arg.Kind == FilterRestriction)
+		}
+		return fmt.Sprintf("%s(%s)", filterPath(e.Path), strings.Join(args, ", "))
+	case FilterValue:
+		if e.Quoted {
+			return quoteFilterString(e.Value)
+		}
+		return e.Value
+	}
+	return ""
+}
+
+func (e *FilterExpr) operand(parenthesize bool) string {
+	if parenthesize {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// Walk calls fn with e and its descendants, depth first, skipping the
+// descendants of the nodes for which fn returns false.
+func (e *FilterExpr) Walk(fn func(*FilterExpr) bool) {
+	if !fn(e) {
+		return
+	}
+	for _, arg := range e.Args {
+		arg.Walk(fn)
+	}
+}
+
+// filterPath joins the components of path, quoting those which are not
+// plain texts.
+func filterPath(path []string) string {
+	components := make([]string, len(path))
+	for i, c := range path {
+		components[i] = c
+		if c == "" || strings.ContainsAny(c, filterSeparators+".-") {
+			components[i] = quoteFilterString(c)
+		}
+	}
+	return strings.Join(components, ".")
+}
+
+func quoteFilterString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// filterSeparators are the characters ending the texts of filters.
+const filterSeparators = " \t\n\r()\"',<>!=:"
+
+// maxFilterDepth bounds the nesting of the parentheses and function calls of
+// the filters.
+const maxFilterDepth = 32
+
+// ParseFilter parses an AIP-160 filter, e.g.
+// `author.name = "Jane" AND (year >= 2000 OR NOT archived) labels:urgent`.
+// It returns nil for an empty filter.
+//
+// The juxtaposed terms of a sequence are parsed as a conjunction, and the
+// single-segment operands compared to members are parsed as values.
+func ParseFilter(filter string) (*FilterExpr, error) {
+	tokens, err := lexFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+	p := &filterParser{tokens: tokens}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterEOF {
+		return nil, tok.unexpected()
+	}
+	return expr, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterEOF filterTokenKind = iota
+	filterText
+	filterString
+	filterLParen
+	filterRParen
+	filterDot
+	filterComma
+	filterMinus
+	filterComparator
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+	// spaced is whether whitespace precedes the token, which separates the
+	// name of a function from its arguments.
+	spaced bool
+}
+
+func (t filterToken) unexpected() error {
+	if t.kind == filterEOF {
+		return errors.New("unexpected end of filter")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.kind == filterText && t.text == keyword
+}
+
+// lexFilter splits filter into tokens, ending with a filterEOF one.
+func lexFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	isDigit := func(i int) bool { return i < len(filter) && filter[i] >= '0' && filter[i] <= '9' }
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '.' || c == ',':
+			kind := map[byte]filterTokenKind{'(': filterLParen, ')': filterRParen, '.': filterDot, ',': filterComma}[c]
+			tokens = append(tokens, filterToken{kind: kind, text: string(c), pos: start})
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(filter) {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				if filter[i] == c {
+					i++
+					break
+				}
+				if filter[i] == '\\' && i+1 < len(filter) {
+					i++
+				}
+				b.WriteByte(filter[i])
+			}
+			tokens = append(tokens, filterToken{kind: filterString, text: b.String(), pos: start})
+		case c == '<' || c == '>' || c == '!' || c == '=' || c == ':':
+			i++
+			if i < len(filter) && filter[i] == '=' && c != '=' && c != ':' {
+				i++
+			}
+			if filter[start:i] == "!" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", "!", start)
+			}
+			tokens = append(tokens, filterToken{kind: filterComparator, text: filter[start:i], pos: start})
+		case c == '-' && !isDigit(i+1):
+			tokens = append(tokens, filterToken{kind: filterMinus, text: "-", pos: start})
+			i++
+		default:
+			// Numbers, e.g. -2.5, are single tokens, while the other texts
+			// are split at the dots of field paths.
+			numeric := c == '-' || isDigit(i)
+			for i < len(filter) && !strings.ContainsRune(filterSeparators, rune(filter[i])) && (filter[i] != '.' || numeric) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: filterText, text: filter[start:i], pos: start})
+		}
+	}
+	for i := range tokens {
+		if pos := tokens[i].pos; pos > 0 {
+			c := filter[pos-1]
+			tokens[i].spaced = c == ' ' || c == '\t' || c == '\n' || c == '\r'
+		}
+	}
+	return append(tokens, filterToken{kind: filterEOF, pos: len(filter)}), nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	depth  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != filterEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) enter() error {
+	if p.depth++; p.depth > maxFilterDepth {
+		return fmt.Errorf("filter nested deeper than %d levels", maxFilterDepth)
+	}
+	return nil
+}
+
+// expression parses `sequence {AND sequence}`.
+func (p *filterParser) expression() (*FilterExpr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	var args []*FilterExpr
+	for {
+		seq, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, seq)
+		if !p.peek().isKeyword("AND") {
+			return filterJunction(FilterAnd, args), nil
+		}
+		p.next()
+	}
+}
+
+// sequence parses `factor {factor}`.
+func (p *filterParser) sequence() (*FilterExpr, error) {
+	var args []*FilterExpr
+	for {
+		factor, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, factor)
+		switch tok := p.peek(); {
+		case tok.kind == filterText && !tok.isKeyword("AND") && !tok.isKeyword("OR"),
+			tok.kind == filterString, tok.kind == filterLParen, tok.kind == filterMinus:
+		default:
+			return filterJunction(FilterAnd, args), nil
+		}
+	}
+}
+
+// factor parses `term {OR term}`.
+func (p *filterParser) factor() (*FilterExpr, error) {
+	var args []*FilterExpr
+	for {
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, term)
+		if !p.peek().isKeyword("OR") {
+			return filterJunction(FilterOr, args), nil
+		}
+		p.next()
+	}
+}
+
+// term parses `[NOT | -] simple`, where simple is `restriction` or
+// `( expression )`.
+func (p *filterParser) term() (*FilterExpr, error) {
+	negated := false
+	if tok := p.peek(); tok.isKeyword("NOT") || tok.kind == filterMinus {
+		p.next()
+		negated = true
+	}
+	var simple *FilterExpr
+	var err error
+	if p.peek().kind == filterLParen {
+		simple, err = p.composite()
+	} else {
+		simple, err = p.restriction()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		return &FilterExpr{Kind: FilterNot, Args: []*FilterExpr{simple}}, nil
+	}
+	return simple, nil
+}
+
+// composite parses `( expression )`.
+func (p *filterParser) composite() (*FilterExpr, error) {
+	p.next()
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != filterRParen {
+		return nil, tok.unexpected()
+	}
+	return expr, nil
+}
+
+// restriction parses `comparable [comparator arg]`.
+func (p *filterParser) restriction() (*FilterExpr, error) {
+	comparable, err := p.comparable()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != filterComparator {
+		return filterOperand(comparable), nil
+	}
+	comparator := p.next().text
+	var arg *FilterExpr
+	if p.peek().kind == filterLParen {
+		arg, err = p.composite()
+	} else {
+		arg, err = p.comparable()
+		arg = filterOperand(arg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FilterExpr{Kind: FilterRestriction, Comparator: comparator, Args: []*FilterExpr{comparable, arg}}, nil
+}
+
+// comparable parses a member `value {. field}`, or a function
+// `name {. name} ( [arg {, arg}] )` whose parenthesis follows its name
+// without whitespace.
+func (p *filterParser) comparable() (*FilterExpr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	tok := p.next()
+	switch {
+	case tok.kind == filterString:
+		return &FilterExpr{Kind: FilterValue, Value: tok.text, Quoted: true}, nil
+	case tok.kind != filterText || tok.isKeyword("AND") || tok.isKeyword("OR") || tok.isKeyword("NOT"):
+		return nil, tok.unexpected()
+	}
+	path := []string{tok.text}
+	for p.peek().kind == filterDot {
+		p.next()
+		field := p.next()
+		if field.kind != filterText && field.kind != filterString {
+			return nil, field.unexpected()
+		}
+		path = append(path, field.text)
+	}
+	if tok := p.peek(); tok.kind != filterLParen || tok.spaced {
+		return &FilterExpr{Kind: FilterMember, Path: path}, nil
+	}
+
+	p.next()
+	fn := &FilterExpr{Kind: FilterFunction, Path: path}
+	if p.peek().kind == filterRParen {
+		p.next()
+		return fn, nil
+	}
+	for {
+		var arg *FilterExpr
+		var err error
+		if p.peek().kind == filterLParen {
+			arg, err = p.composite()
+		} else {
+			arg, err = p.comparable()
+			arg = filterOperand(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fn.Args = append(fn.Args, arg)
+		switch tok := p.next(); tok.kind {
+		case filterComma:
+		case filterRParen:
+			return fn, nil
+		default:
+			return nil, tok.unexpected()
+		}
+	}
+}
+
+// filterOperand returns the single-segment members as values.
+func filterOperand(e *FilterExpr) *FilterExpr {
+	if e != nil && e.Kind == FilterMember && len(e.Path) == 1 {
+		return &FilterExpr{Kind: FilterValue, Value: e.Path[0]}
+	}
+	return e
+}
+
+// filterJunction returns the conjunction or disjunction of args, flattened.
+func filterJunction(kind FilterKind, args []*FilterExpr) *FilterExpr {
+	if len(args) == 1 {
+		return args[0]
+	}
+	junction := &FilterExpr{Kind: kind}
+	for _, arg := range args {
+		if arg.Kind == kind {
+			junction.Args = append(junction.Args, arg.Args...)
+		} else {
+			junction.Args = append(junction.Args, arg)
+		}
+	}
+	return junction
+}
+
+// FilterParsing configures the parsing of the AIP-160 filters of the
+// requests, enabled with WithFilterParsing.
+type FilterParsing struct {
+	// Param is the query parameter of the filters, "filter" if empty.
+	Param string
+	// MetadataKey is the metadata key of the JSON encoding of the syntax tree
+	// of the filters, "x-filter-ast-bin" if empty.
+	MetadataKey string
+	// Normalize replaces the filters of the requests with their canonical
+	// form, e.g. for the "filter" field of the request messages populated
+	// from the query.
+	Normalize bool
+	// Validate rejects the requests whose filter fails it with an
+	// InvalidArgument error, e.g. for the filters of unknown fields.
+	Validate func(r *http.Request, filter *FilterExpr) error
+}
+
+// WithFilterParsing returns a ServeMuxOption parsing the AIP-160 filter
+// query parameter of the requests with ParseFilter, so that the list methods
+// share the same filtering syntax. The syntax tree of the filter is
+// forwarded as metadata, and is available with Filter.
+//
+// The requests whose filter is invalid are replied to with an
+// InvalidArgument error, i.e. 400 Bad Request.
+func WithFilterParsing(parsing FilterParsing) ServeMuxOption {
+	if parsing.Param == "" {
+		parsing.Param = "filter"
+	}
+	if parsing.MetadataKey == "" {
+		parsing.MetadataKey = "x-filter-ast-bin"
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.filter = &parsing
+	}
+}
+
+type filterKey struct{}
+
+// Filter returns the syntax tree of the filter of the request of ctx, as
+// parsed with WithFilterParsing.
+func Filter(ctx context.Context) (*FilterExpr, bool) {
+	expr, ok := ctx.Value(filterKey{}).(*FilterExpr)
+	return expr, ok
+}
+
+// pairs returns the metadata pairs forwarding the filter of the request of
+// ctx.
+func (f *FilterParsing) pairs(ctx context.Context) []string {
+	expr, ok := Filter(ctx)
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(expr)
+	if err != nil {
+		grpclog.Infof("Failed to marshal the filter: %v", err)
+		return nil
+	}
+	return []string{f.MetadataKey, string(b)}
+}
+
+// checkFilter replies with an error and returns false if the filter of r is
+// invalid, if the mux parses filters. It returns r with the syntax tree of
+// its filter in its context otherwise.
+func (s *ServeMux) checkFilter(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.filter == nil {
+		return r, true
+	}
+	query := r.URL.Query()
+	values := query[s.filter.Param]
+	if len(values) == 0 {
+		return r, true
+	}
+	var expr *FilterExpr
+	var err error
+	if len(values) > 1 {
+		err = errors.New("multiple filters")
+	} else if expr, err = ParseFilter(values[0]); err == nil && expr != nil && s.filter.Validate != nil {
+		err = s.filter.Validate(r, expr)
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = FieldViolationError(s.filter.Param, err)
+		}
+		_, outboundMarshaler := MarshalerForRequest(s, r)
+		HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+		return r, false
+	}
+	if expr == nil {
+		return r, true
+	}
+	r = r.WithContext(context.WithValue(r.Context(), filterKey{}, expr))
+	if s.filter.Normalize {
+		query.Set(s.filter.Param, expr.String())
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		r.URL = &u
+	}
+	return r, true
+}
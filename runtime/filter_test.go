@@ -0,0 +1,140 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, spec := range []struct {
+		filter string
+		want   string
+	}{
+		{filter: "", want: "<nil>"},
+		{filter: "a = 1", want: "a = 1"},
+		{filter: "a=1", want: "a = 1"},
+		{
+			filter: `author.name = "Jane" AND (year >= 2000 OR NOT archived) labels:urgent`,
+			want:   `author.name = "Jane" AND year >= 2000 OR NOT archived AND labels : urgent`,
+		},
+		{filter: "-a.b:c", want: "NOT a.b : c"},
+		{filter: "a OR (b AND c)", want: "a OR (b AND c)"},
+		{filter: "(a OR b) c", want: "a OR b AND c"},
+		{filter: `regex(name, "^J.*") price < -2.5`, want: `regex(name, "^J.*") AND price < -2.5`},
+		{filter: "x.y()", want: "x.y()"},
+		{filter: `a = 'b "c"'`, want: `a = "b \"c\""`},
+		{filter: `labels."app-name" != web`, want: `labels."app-name" != web`},
+	} {
+		expr, err := runtime.ParseFilter(spec.filter)
+		if err != nil {
+			t.Errorf("runtime.ParseFilter(%q) failed with %v; want success", spec.filter, err)
+			continue
+		}
+		got := "<nil>"
+		if expr != nil {
+			got = expr.String()
+		}
+		if got != spec.want {
+			t.Errorf("runtime.ParseFilter(%q) = %s; want %s", spec.filter, got, spec.want)
+		}
+		if expr == nil {
+			continue
+		}
+		// The canonical form parses to the same filter.
+		again, err := runtime.ParseFilter(got)
+		if err != nil || again.String() != got {
+			t.Errorf("runtime.ParseFilter(%q) = %v, %v; want %s", got, again, err, got)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		"a AND",
+		"(a",
+		"a = ",
+		"a !b",
+		`a = "b`,
+		"AND a",
+		"a.",
+		"f(a b)",
+		"a)",
+		strings.Repeat("(", 40) + "a" + strings.Repeat(")", 40),
+	} {
+		if expr, err := runtime.ParseFilter(filter); err == nil {
+			t.Errorf("runtime.ParseFilter(%q) = %s; want an error", filter, expr)
+		}
+	}
+}
+
+func TestServeMuxFilterParsing(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithFilterParsing(runtime.FilterParsing{
+		Normalize: true,
+		Validate: func(_ *http.Request, filter *runtime.FilterExpr) error {
+			var err error
+			filter.Walk(func(e *runtime.FilterExpr) bool {
+				if e.Kind == runtime.FilterMember && e.Path[0] == "secret" {
+					err = errors.New("cannot filter by secret")
+				}
+				return true
+			})
+			return err
+		},
+	}))
+	var query string
+	var parsed *runtime.FilterExpr
+	var md metadata.MD
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		query = r.URL.Query().Get("filter")
+		parsed, _ = runtime.Filter(r.Context())
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/ListBooks")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		md, _ = metadata.FromOutgoingContext(ctx)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+
+	for _, spec := range []struct {
+		filter string
+		status int
+	}{
+		{filter: "year>=2000 (author=Jane OR author=John)", status: http.StatusOK},
+		{filter: "year >=", status: http.StatusBadRequest},
+		{filter: "secret.code = 1", status: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/books?filter="+url.QueryEscape(spec.filter), nil))
+		if w.Code != spec.status {
+			t.Errorf("filter %q: w.Code = %d; want %d", spec.filter, w.Code, spec.status)
+		}
+	}
+
+	want := "year >= 2000 AND author = Jane OR author = John"
+	if query != want {
+		t.Errorf(`r.URL.Query().Get("filter") = %q; want %q`, query, want)
+	}
+	if parsed == nil || parsed.String() != want {
+		t.Errorf("runtime.Filter(ctx) = %v; want %s", parsed, want)
+	}
+	values := md.Get("x-filter-ast-bin")
+	if len(values) != 1 {
+		t.Fatalf(`md.Get("x-filter-ast-bin") = %q; want one value`, values)
+	}
+	var forwarded runtime.FilterExpr
+	if err := json.Unmarshal([]byte(values[0]), &forwarded); err != nil {
+		t.Fatalf("json.Unmarshal(%s, &forwarded) failed with %v; want success", values[0], err)
+	}
+	if got := forwarded.String(); got != want {
+		t.Errorf("forwarded filter = %s; want %s", got, want)
+	}
+}
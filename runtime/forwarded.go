@@ -0,0 +1,234 @@
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedMetadataKeys are the gRPC metadata keys the client IP, scheme and
+// host of the requests are forwarded as by WithForwardedHeaders. Those whose
+// key is empty are not forwarded.
+type ForwardedMetadataKeys struct {
+	ClientIP string
+	Proto    string
+	Host     string
+}
+
+// DefaultForwardedMetadataKeys forwards the client IP, scheme and host of the
+// requests as "x-forwarded-for", "x-forwarded-proto" and "x-forwarded-host".
+var DefaultForwardedMetadataKeys = ForwardedMetadataKeys{
+	ClientIP: "x-forwarded-for",
+	Proto:    "x-forwarded-proto",
+	Host:     "x-forwarded-host",
+}
+
+// WithForwardedHeaders returns a ServeMuxOption resolving the client IP,
+// scheme and host of the requests from their Forwarded (RFC 7239) header, or
+// else their X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host headers,
+// and forwarding them as the metadata keys, in place of the headers the
+// clients may set them with. It replaces the default, which appends the remote
+// address of the requests to their X-Forwarded-For header.
+//
+// The headers are only trusted as far as they were set by trustedProxies,
+// which are IP addresses or CIDR ranges, e.g. "10.0.0.0/8": the client IP is
+// the last hop of the chain of proxies which is not trusted, and the requests
+// from an untrusted remote address are resolved from the address and the
+// request alone.
+//
+// WithForwardedHeaders panics if a trusted proxy is neither an IP address nor
+// a CIDR range.
+func WithForwardedHeaders(trustedProxies []string, keys ForwardedMetadataKeys) ServeMuxOption {
+	f := &forwardedHeaders{keys: keys}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				panic(fmt.Sprintf("runtime: invalid trusted proxy %q", proxy))
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			f.trusted = append(f.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			panic(fmt.Sprintf("runtime: invalid trusted proxy %q: %v", proxy, err))
+		}
+		f.trusted = append(f.trusted, ipNet)
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.forwarded = f
+	}
+}
+
+type forwardedHeaders struct {
+	trusted []*net.IPNet
+	keys    ForwardedMetadataKeys
+}
+
+// forwardedHop is an element of a Forwarded header, or of an X-Forwarded-For
+// header.
+type forwardedHop struct {
+	node  string
+	proto string
+	host  string
+}
+
+// pairs returns the metadata pairs forwarding the client of req.
+func (f *forwardedHeaders) pairs(req *http.Request) []string {
+	clientIP, proto, host := f.resolve(req)
+	var pairs []string
+	if f.keys.ClientIP != "" && clientIP != "" {
+		pairs = append(pairs, f.keys.ClientIP, clientIP)
+	}
+	if f.keys.Proto != "" && proto != "" {
+		pairs = append(pairs, f.keys.Proto, proto)
+	}
+	if f.keys.Host != "" && host != "" {
+		pairs = append(pairs, f.keys.Host, host)
+	}
+	return pairs
+}
+
+// forwards reports whether key is one of the metadata keys of f.
+func (f *forwardedHeaders) forwards(key string) bool {
+	for _, k := range []string{f.keys.ClientIP, f.keys.Proto, f.keys.Host} {
+		if k != "" && strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the client IP, scheme and host of req, walking the hops of
+// its forwarding headers from the closest one as long as they are trusted.
+func (f *forwardedHeaders) resolve(req *http.Request) (clientIP, proto, host string) {
+	clientIP = forwardedNode(req.RemoteAddr)
+	proto = "http"
+	if req.TLS != nil {
+		proto = "https"
+	}
+	host = req.Host
+	if !f.isTrusted(clientIP) {
+		return clientIP, proto, host
+	}
+
+	var hops []forwardedHop
+	if values := req.Header.Values("Forwarded"); len(values) > 0 {
+		hops = parseForwarded(values)
+	} else {
+		for _, value := range req.Header.Values(xForwardedFor) {
+			for _, node := range strings.Split(value, ",") {
+				if node = strings.TrimSpace(node); node != "" {
+					hops = append(hops, forwardedHop{node: forwardedNode(node)})
+				}
+			}
+		}
+		if v := firstForwardedValue(req.Header.Get("X-Forwarded-Proto")); v != "" {
+			proto = strings.ToLower(v)
+		}
+		if v := firstForwardedValue(req.Header.Get(xForwardedHost)); v != "" {
+			host = v
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if hop.proto != "" {
+			proto = strings.ToLower(hop.proto)
+		}
+		if hop.host != "" {
+			host = hop.host
+		}
+		if hop.node == "" {
+			break
+		}
+		clientIP = hop.node
+		if !f.isTrusted(clientIP) {
+			break
+		}
+	}
+	return clientIP, proto, host
+}
+
+func (f *forwardedHeaders) isTrusted(node string) bool {
+	ip := net.ParseIP(node)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range f.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwarded parses the elements of Forwarded headers, e.g.
+// `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`.
+func parseForwarded(values []string) []forwardedHop {
+	var hops []forwardedHop
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var hop forwardedHop
+			for _, pair := range splitQuoted(element, ';') {
+				i := strings.IndexByte(pair, '=')
+				if i < 0 {
+					continue
+				}
+				key := strings.ToLower(strings.TrimSpace(pair[:i]))
+				val := strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+				switch key {
+				case "for":
+					hop.node = forwardedNode(val)
+				case "proto":
+					hop.proto = val
+				case "host":
+					hop.host = val
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s around sep, except within quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// forwardedNode returns the IP address of a node, without its port and the
+// brackets of IPv6 addresses. Obfuscated and unknown nodes are returned as is.
+func forwardedNode(node string) string {
+	node = strings.TrimSpace(node)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+}
+
+func firstForwardedValue(value string) string {
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,128 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAnnotateContext_ForwardedHeaders(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.200"}
+	for _, spec := range []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		keys       runtime.ForwardedMetadataKeys
+		want       metadata.MD
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "198.51.100.1:12345",
+			header:     http.Header{"X-Forwarded-For": {"192.0.2.100"}, "X-Forwarded-Proto": {"https"}},
+			keys:       runtime.DefaultForwardedMetadataKeys,
+			want: metadata.MD{
+				"x-forwarded-for":   {"198.51.100.1"},
+				"x-forwarded-proto": {"http"},
+				"x-forwarded-host":  {"bar.foo.example.com"},
+			},
+		},
+		{
+			name:       "x-forwarded headers",
+			remoteAddr: "192.0.2.200:12345",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.7, 198.51.100.1, 10.0.0.1"},
+				"X-Forwarded-Proto": {"HTTPS"},
+				"X-Forwarded-Host":  {"api.example.com"},
+			},
+			keys: runtime.DefaultForwardedMetadataKeys,
+			want: metadata.MD{
+				"x-forwarded-for":   {"198.51.100.1"},
+				"x-forwarded-proto": {"https"},
+				"x-forwarded-host":  {"api.example.com"},
+			},
+		},
+		{
+			name:       "forwarded header",
+			remoteAddr: "10.1.2.3:12345",
+			header: http.Header{
+				"Forwarded":       {`for="[2001:db8:cafe::17]:4711";proto=https;host=api.example.com, for=10.0.0.1`},
+				"X-Forwarded-For": {"192.0.2.100"},
+			},
+			keys: runtime.ForwardedMetadataKeys{ClientIP: "x-real-ip", Proto: "x-scheme"},
+			want: metadata.MD{
+				"x-real-ip": {"2001:db8:cafe::17"},
+				"x-scheme":  {"https"},
+			},
+		},
+		{
+			name:       "all trusted",
+			remoteAddr: "10.1.2.3:12345",
+			header:     http.Header{"X-Forwarded-For": {"10.0.0.2, 10.0.0.1"}},
+			keys:       runtime.ForwardedMetadataKeys{ClientIP: "x-real-ip"},
+			want:       metadata.MD{"x-real-ip": {"10.0.0.2"}},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://bar.foo.example.com", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "GET", "http://bar.foo.example.com", err)
+			}
+			request.Header = spec.header
+			request.RemoteAddr = spec.remoteAddr
+
+			mux := runtime.NewServeMux(
+				runtime.WithForwardedHeaders(trusted, spec.keys),
+				runtime.WithIncomingHeaderMatcher(func(string) (string, bool) { return "", false }),
+			)
+			annotated, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.Example/Example")
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", request, err)
+			}
+			md, _ := metadata.FromOutgoingContext(annotated)
+			if !reflect.DeepEqual(md, spec.want) {
+				t.Errorf("metadata.FromOutgoingContext(annotated) = %v; want %v", md, spec.want)
+			}
+		})
+	}
+}
+
+func TestAnnotateContext_ForwardedHeadersSpoofed(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://bar.foo.example.com", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "GET", "http://bar.foo.example.com", err)
+	}
+	request.RemoteAddr = "203.0.113.9:12345"
+	request.Header.Set("Grpc-Metadata-X-Forwarded-For", "1.2.3.4")
+	request.Header.Set("Grpc-Metadata-X-Forwarded-Proto", "https")
+	request.Header.Set("Grpc-Metadata-X-Forwarded-Host", "admin.example.com")
+
+	mux := runtime.NewServeMux(runtime.WithForwardedHeaders(nil, runtime.DefaultForwardedMetadataKeys))
+	annotated, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.Example/Example")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, %#v) failed with %v; want success", request, err)
+	}
+	md, _ := metadata.FromOutgoingContext(annotated)
+	want := map[string][]string{
+		"x-forwarded-for":   {"203.0.113.9"},
+		"x-forwarded-proto": {"http"},
+		"x-forwarded-host":  {"bar.foo.example.com"},
+	}
+	for key, vals := range want {
+		if got := md.Get(key); !reflect.DeepEqual(got, vals) {
+			t.Errorf("md.Get(%q) = %q; want %q", key, got, vals)
+		}
+	}
+}
+
+func TestWithForwardedHeadersInvalidProxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("runtime.WithForwardedHeaders(%q, keys) did not panic; want panic", "10.0.0.0/33")
+		}
+	}()
+	runtime.WithForwardedHeaders([]string{"10.0.0.0/33"}, runtime.DefaultForwardedMetadataKeys)
+}
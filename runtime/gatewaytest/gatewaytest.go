@@ -0,0 +1,196 @@
+// Package gatewaytest tests the handlers of a gateway against an in-memory
+// gRPC server. It serves the services of the test with a gRPC server
+// listening on a bufconn, registers the generated handlers to a mux with a
+// connection to it, and issues the requests of the test to the mux, whose
+// responses are decoded and compared to the expected messages:
+//
+//	func TestGetShelf(t *testing.T) {
+//		gw := gatewaytest.New(t, gatewaytest.Config{
+//			Services: func(s *grpc.Server) { pb.RegisterLibraryServiceServer(s, &server{}) },
+//			Handlers: []gatewaytest.RegisterFunc{pb.RegisterLibraryServiceHandler},
+//		})
+//		resp := gw.Do("GET", "/v1/shelves/1", nil)
+//		resp.AssertMessage(&pb.Shelf{Name: "shelves/1"})
+//	}
+package gatewaytest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// bufSize is the size of the buffers of the in-memory connections.
+const bufSize = 1 << 20
+
+// RegisterFunc registers the handlers of a service to mux, calling it on
+// conn. It is the signature of the generated Register<Service>Handler
+// functions.
+type RegisterFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// Config configures a Gateway.
+type Config struct {
+	// Services registers the implementations of the services to the gRPC
+	// server.
+	Services func(s *grpc.Server)
+	// Handlers are the handlers registered to the mux.
+	Handlers []RegisterFunc
+	// MuxOptions are the options of the mux.
+	MuxOptions []runtime.ServeMuxOption
+	// ServerOptions are the options of the gRPC server, e.g. its
+	// interceptors.
+	ServerOptions []grpc.ServerOption
+	// DialOptions are the options of the connection of the handlers to the
+	// gRPC server, in addition to those dialing it in memory.
+	DialOptions []grpc.DialOption
+}
+
+// Gateway is a mux whose handlers call a gRPC server in memory.
+type Gateway struct {
+	// Mux is the mux the handlers are registered to.
+	Mux *runtime.ServeMux
+	// Conn is the connection to the gRPC server, e.g. to call it directly.
+	Conn *grpc.ClientConn
+
+	t testing.TB
+}
+
+// New starts the gRPC server and registers the handlers as configured. The
+// server is stopped and the connection closed when the test completes. New
+// fails the test if the handlers cannot be registered.
+func New(t testing.TB, config Config) *Gateway {
+	t.Helper()
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer(config.ServerOptions...)
+	if config.Services != nil {
+		config.Services(s)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure(),
+	}, config.DialOptions...)
+	conn, err := grpc.DialContext(ctx, "bufnet", opts...)
+	if err != nil {
+		t.Fatalf("grpc.DialContext(ctx, %q) failed with %v; want success", "bufnet", err)
+	}
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Logf("conn.Close() failed with %v", err)
+		}
+	})
+
+	mux := runtime.NewServeMux(config.MuxOptions...)
+	for _, register := range config.Handlers {
+		if err := register(ctx, mux, conn); err != nil {
+			t.Fatalf("register(ctx, mux, conn) failed with %v; want success", err)
+		}
+	}
+	return &Gateway{Mux: mux, Conn: conn, t: t}
+}
+
+// NewRequest returns a request to target, whose body is body marshaled by
+// the marshaler of the mux for JSON, if not nil. The headers of the request
+// can be set before it is given to Serve.
+func (g *Gateway) NewRequest(method, target string, body proto.Message) *http.Request {
+	g.t.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	if body == nil {
+		return r
+	}
+	r.Header.Set("Content-Type", "application/json")
+	inbound, _ := runtime.MarshalerForRequest(g.Mux, r)
+	buf, err := inbound.Marshal(body)
+	if err != nil {
+		g.t.Fatalf("Marshal(%v) failed with %v; want success", body, err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	r.ContentLength = int64(len(buf))
+	return r
+}
+
+// Serve serves r with the mux.
+func (g *Gateway) Serve(r *http.Request) *Response {
+	w := httptest.NewRecorder()
+	g.Mux.ServeHTTP(w, r)
+	return &Response{ResponseRecorder: w, t: g.t, mux: g.Mux, req: r}
+}
+
+// Do serves a request to target, whose body is body if not nil, with the mux.
+func (g *Gateway) Do(method, target string, body proto.Message) *Response {
+	g.t.Helper()
+	return g.Serve(g.NewRequest(method, target, body))
+}
+
+// Response is a response of the mux.
+type Response struct {
+	*httptest.ResponseRecorder
+
+	t   testing.TB
+	mux *runtime.ServeMux
+	req *http.Request
+}
+
+// Decode unmarshals the body of the response into m with the marshaler of
+// the mux for the request, failing the test if it cannot.
+func (r *Response) Decode(m proto.Message) {
+	r.t.Helper()
+	_, outbound := runtime.MarshalerForRequest(r.mux, r.req)
+	if err := outbound.Unmarshal(r.Body.Bytes(), m); err != nil {
+		r.t.Fatalf("Unmarshal(%q) failed with %v; want a %T", r.Body.String(), err, m)
+	}
+}
+
+// AssertCode reports an error if the status code of the response is not
+// code.
+func (r *Response) AssertCode(code int) {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("%s %s: status code = %d; want %d (body %s)", r.req.Method, r.req.URL.RequestURI(), r.Code, code, r.Body.String())
+	}
+}
+
+// AssertMessage reports an error if the response is not a 200 OK whose body
+// is want.
+func (r *Response) AssertMessage(want proto.Message) {
+	r.t.Helper()
+	r.AssertCode(http.StatusOK)
+	if r.Code != http.StatusOK {
+		return
+	}
+	got := want.ProtoReflect().New().Interface()
+	r.Decode(got)
+	if !proto.Equal(got, want) {
+		r.t.Errorf("%s %s: got %s; want %s", r.req.Method, r.req.URL.RequestURI(), protojson.Format(got), protojson.Format(want))
+	}
+}
+
+// AssertError reports an error if the response is not that of an error of
+// the gRPC code code, as written by the default error handler.
+func (r *Response) AssertError(code codes.Code) {
+	r.t.Helper()
+	r.AssertCode(runtime.HTTPStatusFromCode(code))
+	var s spb.Status
+	r.Decode(&s)
+	if got := codes.Code(s.GetCode()); got != code {
+		r.t.Errorf("%s %s: code = %v; want %v (message %q)", r.req.Method, r.req.URL.RequestURI(), got, code, s.GetMessage())
+	}
+}
@@ -0,0 +1,100 @@
+package gatewaytest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/gatewaytest"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type nestedServer struct {
+	examplepb.UnimplementedNestedServiceServer
+}
+
+func (nestedServer) Update(ctx context.Context, req *examplepb.UpdateNestedRequest) (*examplepb.NestedOuter, error) {
+	if req.GetNested().GetOne() == nil {
+		return nil, status.Error(codes.InvalidArgument, "nested.one is required")
+	}
+	return req.GetNested(), nil
+}
+
+// registerNestedServiceHandler registers the handler of Update as the
+// generated code does, binding it to PATCH /v1/nested with the body as
+// nested.
+func registerNestedServiceHandler(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	client := examplepb.NewNestedServiceClient(conn)
+	return mux.HandlePath("PATCH", "/v1/nested", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(mux, r)
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.gateway.runtime.internal.examplepb.NestedService/Update")
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		req := &examplepb.UpdateNestedRequest{Nested: &examplepb.NestedOuter{}}
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = inbound.Unmarshal(body, req.Nested)
+		}
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		resp, err := client.Update(ctx, req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outbound, w, r, resp)
+	})
+}
+
+func newGateway(t *testing.T, config gatewaytest.Config) *gatewaytest.Gateway {
+	config.Services = func(s *grpc.Server) {
+		examplepb.RegisterNestedServiceServer(s, nestedServer{})
+	}
+	config.Handlers = []gatewaytest.RegisterFunc{registerNestedServiceHandler}
+	return gatewaytest.New(t, config)
+}
+
+func TestGatewayDo(t *testing.T) {
+	gw := newGateway(t, gatewaytest.Config{})
+	nested := &examplepb.NestedOuter{
+		One: &examplepb.NestedOne{Two: &examplepb.NestedTwo{Three: &examplepb.NestedThree{A: true}}},
+	}
+	resp := gw.Do("PATCH", "/v1/nested", nested)
+	resp.AssertMessage(nested)
+
+	gw.Do("PATCH", "/v1/nested", &examplepb.NestedOuter{}).AssertError(codes.InvalidArgument)
+	gw.Do("GET", "/v1/unknown", nil).AssertError(codes.NotFound)
+}
+
+func TestGatewayServe(t *testing.T) {
+	var got string
+	gw := newGateway(t, gatewaytest.Config{
+		ServerOptions: []grpc.ServerOption{
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				got = info.FullMethod
+				return handler(ctx, req)
+			}),
+		},
+	})
+	r := gw.NewRequest("PATCH", "/v1/nested", &examplepb.NestedOuter{One: &examplepb.NestedOne{}})
+	r.Header.Set("Accept", "application/json")
+	resp := gw.Serve(r)
+	resp.AssertCode(http.StatusOK)
+	var outer examplepb.NestedOuter
+	resp.Decode(&outer)
+	if outer.GetOne() == nil {
+		t.Errorf("outer.One = nil; want the nested message of the request")
+	}
+	if want := "/grpc.gateway.runtime.internal.examplepb.NestedService/Update"; got != want {
+		t.Errorf("info.FullMethod = %q; want %q", got, want)
+	}
+}
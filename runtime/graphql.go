@@ -0,0 +1,645 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/casing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// GraphQLField is a field of the Query or Mutation type of the schema of a
+// GraphQLHandler, resolved by calling a unary method. Its arguments are the
+// fields of the request message, and its type is the response message.
+type GraphQLField struct {
+	// Mutation is whether the field belongs to the Mutation type rather than
+	// the Query one.
+	Mutation bool
+	// Name is the name of the field.
+	Name string
+	// Definition is the definition of the field in the schema, e.g.
+	// "getBook(name: String): Book".
+	Definition string
+	// RPCMethod is the full name of the method, e.g. "/pkg.Service/Method",
+	// the metadata of the requests being forwarded as for the REST routes of
+	// the method.
+	RPCMethod string
+	// Request and Response are messages of the types of the request and the
+	// response of the method.
+	Request  proto.Message
+	Response proto.Message
+	// Call calls the method with req, of the type of Request.
+	Call func(ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// GraphQLHandler is an experimental http.Handler serving the unary methods
+// registered with the generated Register<Service>GraphQL functions as the
+// fields of a GraphQL schema, over a single endpoint.
+//
+// The queries are sent as JSON bodies of POST requests, or as the "query",
+// "operationName" and "variables" query parameters of GET requests, the
+// mutations requiring POST. A GET request without a query is replied to with
+// the schema in the GraphQL schema definition language.
+//
+// The arguments of a field are unmarshaled into the request of its method
+// with protojson, and the fields selected from the response marshaled with
+// protojson, the well-known types and the maps being of the JSON scalar
+// type. The errors of the methods are reported in the "errors" of the
+// response, with the gRPC code as the "code" extension. The introspection
+// and subscriptions are not supported.
+type GraphQLHandler struct {
+	mux *ServeMux
+
+	mu     sync.RWMutex
+	fields map[bool]map[string]*GraphQLField
+	types  map[string]string
+}
+
+// maxGraphQLRequestSize is the maximum size of the body of a GraphQL request.
+const maxGraphQLRequestSize = 1 << 20
+
+// NewGraphQLHandler returns a GraphQLHandler forwarding the metadata of the
+// requests as configured in mux.
+func NewGraphQLHandler(mux *ServeMux) *GraphQLHandler {
+	return &GraphQLHandler{
+		mux:    mux,
+		fields: map[bool]map[string]*GraphQLField{false: {}, true: {}},
+		types:  make(map[string]string),
+	}
+}
+
+// Handle adds the field to the Query or Mutation type of the schema. It
+// fails if the type already has a field of the same name.
+func (h *GraphQLHandler) Handle(field GraphQLField) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.fields[field.Mutation][field.Name]; ok {
+		return fmt.Errorf("duplicate GraphQL field %s.%s", graphQLRootType(field.Mutation), field.Name)
+	}
+	h.fields[field.Mutation][field.Name] = &field
+	return nil
+}
+
+// DefineType adds the definition of the named type to the schema, e.g.
+// "type Book {\n  name: String\n}". It fails if the type is already defined
+// differently.
+func (h *GraphQLHandler) DefineType(name, definition string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if def, ok := h.types[name]; ok && def != definition {
+		return fmt.Errorf("conflicting definitions of GraphQL type %s", name)
+	}
+	h.types[name] = definition
+	return nil
+}
+
+// Schema returns the schema in the GraphQL schema definition language.
+func (h *GraphQLHandler) Schema() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var b strings.Builder
+	b.WriteString("scalar JSON\n")
+	for _, mutation := range []bool{false, true} {
+		fields := h.fields[mutation]
+		if len(fields) == 0 && mutation {
+			continue
+		}
+		fmt.Fprintf(&b, "\ntype %s {\n", graphQLRootType(mutation))
+		if len(fields) == 0 {
+			// A schema requires a Query type, which requires a field.
+			b.WriteString("  _empty: Boolean\n")
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s\n", fields[name].Definition)
+		}
+		b.WriteString("}\n")
+	}
+	names := make([]string, 0, len(h.types))
+	for name := range h.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s\n", h.types[name])
+	}
+	return b.String()
+}
+
+func graphQLRootType(mutation bool) string {
+	if mutation {
+		return "Mutation"
+	}
+	return "Query"
+}
+
+// graphQLTypeName returns the name of the GraphQL type of the message, that
+// of the generated code, e.g. "Outer_Inner".
+func graphQLTypeName(md protoreflect.MessageDescriptor) string {
+	var names []string
+	var d protoreflect.Descriptor = md
+	for ; d != nil; d = d.Parent() {
+		if _, ok := d.(protoreflect.FileDescriptor); ok {
+			break
+		}
+		names = append([]string{casing.Camel(string(d.Name()))}, names...)
+	}
+	return strings.Join(names, "_")
+}
+
+// graphQLScalarMessages are the well-known types whose JSON representation
+// is not an object of their fields, of the JSON or of a scalar type.
+var graphQLScalarMessages = map[protoreflect.FullName]bool{
+	"google.protobuf.Any":         true,
+	"google.protobuf.Duration":    true,
+	"google.protobuf.Empty":       true,
+	"google.protobuf.FieldMask":   true,
+	"google.protobuf.ListValue":   true,
+	"google.protobuf.Struct":      true,
+	"google.protobuf.Timestamp":   true,
+	"google.protobuf.Value":       true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// isGraphQLObject reports whether the field is of a GraphQL object type,
+// with a selection of subfields.
+func isGraphQLObject(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsMap() || fd.Message() == nil || graphQLScalarMessages[fd.Message().FullName()] {
+		return false
+	}
+	// The messages without fields are of the JSON type too.
+	return fd.Message().Fields().Len() > 0
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []string               `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []graphQLError `json:"errors,omitempty"`
+}
+
+// graphQLObject is a JSON object whose keys are in the order of the
+// selection set.
+type graphQLObject []graphQLEntry
+
+type graphQLEntry struct {
+	key   string
+	value interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o graphQLObject) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(e.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *GraphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if req.Query == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(h.Schema()))
+			return
+		}
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid variables: %v", err))
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize))
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read the request: %v", err))
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+			req.Query = string(body)
+			req.OperationName = r.URL.Query().Get("operationName")
+			break
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+		return
+	}
+
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid query: %v", err))
+		return
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if op.kind == "mutation" && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		h.writeError(w, http.StatusMethodNotAllowed, "mutations require POST")
+		return
+	}
+	e := &graphQLExecution{handler: h, doc: doc, vars: make(map[string]interface{})}
+	for _, v := range op.variables {
+		if value, ok := req.Variables[v.name]; ok {
+			e.vars[v.name] = value
+		} else if v.defaultValue != nil {
+			e.vars[v.name], _ = v.defaultValue.resolve(nil)
+		}
+	}
+	mutation := op.kind == "mutation"
+	keys, fields, err := e.collect(graphQLRootType(mutation), op.selections, nil)
+	if err == nil {
+		err = e.validateRoot(mutation, keys, fields)
+	}
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data := make(graphQLObject, 0, len(keys))
+	var errs []graphQLError
+	for _, key := range keys {
+		value, err := e.resolveRoot(r, mutation, fields[key])
+		if err != nil {
+			st := status.Convert(err)
+			errs = append(errs, graphQLError{
+				Message:    st.Message(),
+				Path:       []string{key},
+				Extensions: map[string]interface{}{"code": st.Code().String()},
+			})
+		}
+		data = append(data, graphQLEntry{key: key, value: value})
+	}
+	h.write(w, http.StatusOK, graphQLResponse{Data: data, Errors: errs})
+}
+
+func (h *GraphQLHandler) writeError(w http.ResponseWriter, code int, msg string) {
+	h.write(w, code, graphQLResponse{Errors: []graphQLError{{Message: msg}}})
+}
+
+func (h *GraphQLHandler) write(w http.ResponseWriter, code int, resp graphQLResponse) {
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		grpclog.Infof("Failed to marshal the GraphQL response: %v", err)
+		code = http.StatusInternalServerError
+		buf = []byte(`{"errors":[{"message":"failed to marshal the response"}]}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err := w.Write(buf); err != nil {
+		grpclog.Infof("Failed to write the GraphQL response: %v", err)
+	}
+}
+
+// operation returns the operation of the document to execute.
+func (d *gqlDocument) operation(name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// graphQLExecution is the execution of an operation of a document.
+type graphQLExecution struct {
+	handler *GraphQLHandler
+	doc     *gqlDocument
+	vars    map[string]interface{}
+}
+
+// collect returns the response keys of the fields of the selection set of
+// an object of the type, in order, and the fields of each key, skipping the
+// fields excluded by the directives and expanding the fragments. spreads
+// are the names of the fragments being expanded.
+func (e *graphQLExecution) collect(typeName string, sels []*gqlSelection, spreads []string) ([]string, map[string][]*gqlSelection, error) {
+	var keys []string
+	fields := make(map[string][]*gqlSelection)
+	var walk func(sels []*gqlSelection, spreads []string) error
+	walk = func(sels []*gqlSelection, spreads []string) error {
+		for _, sel := range sels {
+			include, err := e.included(sel)
+			if err != nil {
+				return err
+			}
+			if !include {
+				continue
+			}
+			switch sel.kind {
+			case gqlField:
+				key := sel.responseKey()
+				if _, ok := fields[key]; !ok {
+					keys = append(keys, key)
+				}
+				fields[key] = append(fields[key], sel)
+			case gqlInlineFragment:
+				if sel.typeCondition != "" && sel.typeCondition != typeName {
+					continue
+				}
+				if err := walk(sel.selections, spreads); err != nil {
+					return err
+				}
+			case gqlFragmentSpread:
+				f, ok := e.doc.fragments[sel.name]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", sel.name)
+				}
+				for _, name := range spreads {
+					if name == sel.name {
+						return fmt.Errorf("fragment %q spreads itself", sel.name)
+					}
+				}
+				if f.typeCondition != typeName {
+					continue
+				}
+				if err := walk(f.selections, append(spreads[:len(spreads):len(spreads)], sel.name)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(sels, spreads); err != nil {
+		return nil, nil, err
+	}
+	return keys, fields, nil
+}
+
+// included evaluates the @skip and @include directives of the selection.
+func (e *graphQLExecution) included(sel *gqlSelection) (bool, error) {
+	for _, d := range sel.directives {
+		value, _ := d.value.resolve(e.vars)
+		cond, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("the \"if\" argument of @%s must be a boolean", d.name)
+		}
+		if cond == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// validateRoot checks the fields selected on the root type.
+func (e *graphQLExecution) validateRoot(mutation bool, keys []string, fields map[string][]*gqlSelection) error {
+	e.handler.mu.RLock()
+	defer e.handler.mu.RUnlock()
+	for _, key := range keys {
+		sel := fields[key][0]
+		switch {
+		case sel.name == "__typename", sel.name == "_empty" && !mutation && len(e.handler.fields[false]) == 0:
+			if err := e.noSubselection(sel, fields[key]); err != nil {
+				return err
+			}
+			continue
+		}
+		f, ok := e.handler.fields[mutation][sel.name]
+		if !ok {
+			return fmt.Errorf("cannot query field %q on type %q", sel.name, graphQLRootType(mutation))
+		}
+		if err := e.sameField(key, fields[key]); err != nil {
+			return err
+		}
+		if _, err := e.project(f.Response.ProtoReflect().Descriptor(), subselections(fields[key]), nil, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameField checks that the fields of the same response key have the same
+// name and arguments.
+func (e *graphQLExecution) sameField(key string, sels []*gqlSelection) error {
+	for _, sel := range sels[1:] {
+		if sel.name != sels[0].name || len(sel.arguments) != len(sels[0].arguments) {
+			return fmt.Errorf("fields %q conflict", key)
+		}
+		for i, arg := range sel.arguments {
+			if arg.name != sels[0].arguments[i].name {
+				return fmt.Errorf("fields %q conflict", key)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *graphQLExecution) noSubselection(sel *gqlSelection, sels []*gqlSelection) error {
+	for _, s := range sels {
+		if len(s.selections) > 0 {
+			return fmt.Errorf("field %q must not have a selection of subfields", sel.name)
+		}
+		if len(s.arguments) > 0 {
+			return fmt.Errorf("unknown argument %q of field %q", s.arguments[0].name, sel.name)
+		}
+	}
+	return nil
+}
+
+func subselections(sels []*gqlSelection) []*gqlSelection {
+	var all []*gqlSelection
+	for _, sel := range sels {
+		all = append(all, sel.selections...)
+	}
+	return all
+}
+
+// resolveRoot calls the method of the root field, and returns the fields of
+// the response it selects.
+func (e *graphQLExecution) resolveRoot(r *http.Request, mutation bool, sels []*gqlSelection) (interface{}, error) {
+	sel := sels[0]
+	switch sel.name {
+	case "__typename":
+		return graphQLRootType(mutation), nil
+	case "_empty":
+		return nil, nil
+	}
+	e.handler.mu.RLock()
+	f := e.handler.fields[mutation][sel.name]
+	e.handler.mu.RUnlock()
+
+	args := make(map[string]interface{}, len(sel.arguments))
+	for _, arg := range sel.arguments {
+		if value, ok := arg.value.resolve(e.vars); ok {
+			args[arg.name] = value
+		}
+	}
+	buf, err := json.Marshal(args)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	req := f.Request.ProtoReflect().New().Interface()
+	if err := protojson.Unmarshal(buf, req); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid arguments: %v", err)
+	}
+
+	ctx, err := AnnotateContext(r.Context(), e.handler.mux, r, f.RPCMethod)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal the response: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	var value map[string]interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode the response: %v", err)
+	}
+	return e.project(resp.ProtoReflect().Descriptor(), subselections(sels), value, false)
+}
+
+// project returns the fields of value, the JSON representation of a message
+// of the type md, selected by sels. It only checks the selections if
+// validate, descending into all the selected messages.
+func (e *graphQLExecution) project(md protoreflect.MessageDescriptor, sels []*gqlSelection, value map[string]interface{}, validate bool) (graphQLObject, error) {
+	typeName := graphQLTypeName(md)
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("a field of type %q must have a selection of subfields", typeName)
+	}
+	keys, fields, err := e.collect(typeName, sels, nil)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(graphQLObject, 0, len(keys))
+	for _, key := range keys {
+		sel := fields[key][0]
+		if sel.name == "__typename" {
+			if err := e.noSubselection(sel, fields[key]); err != nil {
+				return nil, err
+			}
+			obj = append(obj, graphQLEntry{key: key, value: typeName})
+			continue
+		}
+		fd := md.Fields().ByJSONName(sel.name)
+		if fd == nil {
+			return nil, fmt.Errorf("cannot query field %q on type %q", sel.name, typeName)
+		}
+		if err := e.sameField(key, fields[key]); err != nil {
+			return nil, err
+		}
+		if len(sel.arguments) > 0 {
+			return nil, fmt.Errorf("unknown argument %q of field %q", sel.arguments[0].name, sel.name)
+		}
+		if !isGraphQLObject(fd) {
+			if err := e.noSubselection(sel, fields[key]); err != nil {
+				return nil, err
+			}
+			if !validate {
+				obj = append(obj, graphQLEntry{key: key, value: value[fd.JSONName()]})
+			}
+			continue
+		}
+		subsels := subselections(fields[key])
+		if validate {
+			if _, err := e.project(fd.Message(), subsels, nil, true); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		field, err := e.projectValue(fd.Message(), subsels, value[fd.JSONName()])
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, graphQLEntry{key: key, value: field})
+	}
+	return obj, nil
+}
+
+// projectValue returns the fields selected from the JSON representation of
+// a message or a list of messages.
+func (e *graphQLExecution) projectValue(md protoreflect.MessageDescriptor, sels []*gqlSelection, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return e.project(md, sels, v, false)
+	case []interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			projected, err := e.projectValue(md, sels, elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, projected)
+		}
+		return list, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,765 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// gqlDocument is a parsed GraphQL document, of the subset of the language
+// executed by GraphQLHandler.
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	// kind is "query" or "mutation".
+	kind       string
+	name       string
+	variables  []gqlVariable
+	selections []*gqlSelection
+}
+
+type gqlVariable struct {
+	name string
+	// defaultValue is the default value of the variable, nil if none.
+	defaultValue *gqlValue
+}
+
+type gqlFragment struct {
+	typeCondition string
+	selections    []*gqlSelection
+}
+
+type gqlSelectionKind int
+
+const (
+	gqlField gqlSelectionKind = iota
+	gqlFragmentSpread
+	gqlInlineFragment
+)
+
+// gqlSelection is a field, a fragment spread or an inline fragment of a
+// selection set.
+type gqlSelection struct {
+	kind gqlSelectionKind
+	// alias and name are those of a field, name being that of the fragment of
+	// a spread.
+	alias      string
+	name       string
+	arguments  []gqlArgument
+	directives []gqlArgument
+	// typeCondition is that of an inline fragment, empty if none.
+	typeCondition string
+	selections    []*gqlSelection
+}
+
+// responseKey returns the key of the field in the response.
+func (s *gqlSelection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// gqlArgument is an argument of a field or a directive, the arguments of
+// the directives being their only "if" argument.
+type gqlArgument struct {
+	name  string
+	value *gqlValue
+}
+
+type gqlValueKind int
+
+const (
+	gqlVariableValue gqlValueKind = iota
+	gqlIntValue
+	gqlFloatValue
+	gqlStringValue
+	gqlBooleanValue
+	gqlNullValue
+	gqlEnumValue
+	gqlListValue
+	gqlObjectValue
+)
+
+type gqlValue struct {
+	kind gqlValueKind
+	// raw is the name of a variable or an enum value, the text of a number,
+	// the unescaped string or "true" or "false".
+	raw    string
+	list   []*gqlValue
+	fields []gqlArgument
+}
+
+// resolve returns the JSON value of v, the variables being looked up in vars.
+// It returns false if v is a variable which is not set.
+func (v *gqlValue) resolve(vars map[string]interface{}) (interface{}, bool) {
+	switch v.kind {
+	case gqlVariableValue:
+		value, ok := vars[v.raw]
+		return value, ok
+	case gqlIntValue, gqlFloatValue:
+		return json.Number(v.raw), true
+	case gqlStringValue, gqlEnumValue:
+		return v.raw, true
+	case gqlBooleanValue:
+		return v.raw == "true", true
+	case gqlListValue:
+		list := make([]interface{}, 0, len(v.list))
+		for _, e := range v.list {
+			value, ok := e.resolve(vars)
+			if !ok {
+				value = nil
+			}
+			list = append(list, value)
+		}
+		return list, true
+	case gqlObjectValue:
+		object := make(map[string]interface{}, len(v.fields))
+		for _, f := range v.fields {
+			if value, ok := f.value.resolve(vars); ok {
+				object[f.name] = value
+			}
+		}
+		return object, true
+	}
+	return nil, true
+}
+
+// parseGraphQL parses a GraphQL document. The type system definitions and
+// the subscriptions are not supported, and the types of the variables are
+// not checked.
+func parseGraphQL(src string) (*gqlDocument, error) {
+	p := &gqlParser{lexer: gqlLexer{src: src}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := &gqlDocument{fragments: make(map[string]*gqlFragment)}
+	for p.tok.kind != gqlEOF {
+		switch {
+		case p.tok.is(gqlPunctuator, "{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: sels})
+		case p.tok.is(gqlName, "query"), p.tok.is(gqlName, "mutation"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.is(gqlName, "fragment"):
+			name, f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[name]; ok {
+				return nil, fmt.Errorf("duplicate fragment %q", name)
+			}
+			doc.fragments[name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no operation")
+	}
+	return doc, nil
+}
+
+type gqlParser struct {
+	lexer gqlLexer
+	tok   gqlToken
+}
+
+func (p *gqlParser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *gqlParser) unexpected() error {
+	if p.tok.kind == gqlEOF {
+		return fmt.Errorf("unexpected end of document")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", p.tok.text, p.tok.offset)
+}
+
+// expect consumes the punctuator text.
+func (p *gqlParser) expect(text string) error {
+	if !p.tok.is(gqlPunctuator, text) {
+		return p.unexpected()
+	}
+	return p.next()
+}
+
+// skip consumes the punctuator text, reporting whether it was present.
+func (p *gqlParser) skip(text string) (bool, error) {
+	if !p.tok.is(gqlPunctuator, text) {
+		return false, nil
+	}
+	return true, p.next()
+}
+
+func (p *gqlParser) name() (string, error) {
+	if p.tok.kind != gqlName {
+		return "", p.unexpected()
+	}
+	name := p.tok.text
+	return name, p.next()
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	op := &gqlOperation{kind: p.tok.text}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == gqlName {
+		op.name = p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for {
+			if ok, err := p.skip(")"); err != nil {
+				return nil, err
+			} else if ok {
+				break
+			}
+			v, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, v)
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *gqlParser) variableDefinition() (gqlVariable, error) {
+	var v gqlVariable
+	if err := p.expect("$"); err != nil {
+		return v, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return v, err
+	}
+	v.name = name
+	if err := p.expect(":"); err != nil {
+		return v, err
+	}
+	if err := p.typeReference(); err != nil {
+		return v, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return v, err
+	} else if ok {
+		if v.defaultValue, err = p.value(true); err != nil {
+			return v, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// typeReference consumes the type of a variable.
+func (p *gqlParser) typeReference() error {
+	if ok, err := p.skip("["); err != nil {
+		return err
+	} else if ok {
+		if err := p.typeReference(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	_, err := p.skip("!")
+	return err
+}
+
+func (p *gqlParser) fragment() (string, *gqlFragment, error) {
+	if err := p.next(); err != nil {
+		return "", nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return "", nil, err
+	}
+	if name == "on" {
+		return "", nil, fmt.Errorf("invalid fragment name %q", name)
+	}
+	if !p.tok.is(gqlName, "on") {
+		return "", nil, p.unexpected()
+	}
+	if err := p.next(); err != nil {
+		return "", nil, err
+	}
+	f := new(gqlFragment)
+	if f.typeCondition, err = p.name(); err != nil {
+		return "", nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return "", nil, err
+	}
+	if f.selections, err = p.selectionSet(); err != nil {
+		return "", nil, err
+	}
+	return name, f, nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*gqlSelection
+	for {
+		if ok, err := p.skip("}"); err != nil {
+			return nil, err
+		} else if ok {
+			break
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return sels, nil
+}
+
+func (p *gqlParser) selection() (*gqlSelection, error) {
+	sel := new(gqlSelection)
+	var err error
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		sel.kind = gqlInlineFragment
+		if p.tok.kind == gqlName && p.tok.text != "on" {
+			sel.kind = gqlFragmentSpread
+			sel.name = p.tok.text
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if sel.directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			return sel, nil
+		}
+		if p.tok.is(gqlName, "on") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if sel.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return sel, nil
+	}
+
+	if sel.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.tok.is(gqlPunctuator, "{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *gqlParser) arguments(constant bool) ([]gqlArgument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []gqlArgument
+	for {
+		if ok, err := p.skip(")"); err != nil {
+			return nil, err
+		} else if ok {
+			break
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, gqlArgument{name: name, value: value})
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty arguments")
+	}
+	return args, nil
+}
+
+// directives parses the directives, keeping only the "if" argument of
+// @skip and @include.
+func (p *gqlParser) directives() ([]gqlArgument, error) {
+	var directives []gqlArgument
+	for p.tok.is(gqlPunctuator, "@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		if name != "skip" && name != "include" {
+			continue
+		}
+		if len(args) != 1 || args[0].name != "if" {
+			return nil, fmt.Errorf("directive @%s requires a single \"if\" argument", name)
+		}
+		directives = append(directives, gqlArgument{name: name, value: args[0].value})
+	}
+	return directives, nil
+}
+
+// value parses a value, without variables if constant.
+func (p *gqlParser) value(constant bool) (*gqlValue, error) {
+	tok := p.tok
+	switch {
+	case tok.is(gqlPunctuator, "$"):
+		if constant {
+			return nil, p.unexpected()
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return &gqlValue{kind: gqlVariableValue, raw: name}, nil
+	case tok.is(gqlPunctuator, "["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		v := &gqlValue{kind: gqlListValue}
+		for {
+			if ok, err := p.skip("]"); err != nil {
+				return nil, err
+			} else if ok {
+				return v, nil
+			}
+			e, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			v.list = append(v.list, e)
+		}
+	case tok.is(gqlPunctuator, "{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		v := &gqlValue{kind: gqlObjectValue}
+		for {
+			if ok, err := p.skip("}"); err != nil {
+				return nil, err
+			} else if ok {
+				return v, nil
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			f, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			v.fields = append(v.fields, gqlArgument{name: name, value: f})
+		}
+	}
+
+	v := &gqlValue{raw: tok.text}
+	switch tok.kind {
+	case gqlInt:
+		v.kind = gqlIntValue
+	case gqlFloat:
+		v.kind = gqlFloatValue
+	case gqlString:
+		v.kind = gqlStringValue
+	case gqlName:
+		switch tok.text {
+		case "true", "false":
+			v.kind = gqlBooleanValue
+		case "null":
+			v.kind = gqlNullValue
+		default:
+			v.kind = gqlEnumValue
+		}
+	default:
+		return nil, p.unexpected()
+	}
+	return v, p.next()
+}
+
+type gqlTokenKind int
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunctuator
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind gqlTokenKind
+	// text is the text of the token, unescaped for a string.
+	text   string
+	offset int
+}
+
+func (t gqlToken) is(kind gqlTokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+type gqlLexer struct {
+	src string
+	pos int
+}
+
+func (l *gqlLexer) next() (gqlToken, error) {
+	// Skip the ignored tokens: white space, line terminators, commas,
+	// comments and the byte order mark.
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return l.token()
+		}
+	}
+	return gqlToken{kind: gqlEOF, offset: l.pos}, nil
+}
+
+func (l *gqlLexer) token() (gqlToken, error) {
+	start := l.pos
+	c := l.src[start]
+	switch {
+	case strings.HasPrefix(l.src[start:], "..."):
+		l.pos += 3
+		return gqlToken{kind: gqlPunctuator, text: "...", offset: start}, nil
+	case strings.IndexByte("!$&()*:=@[]{}|", c) >= 0:
+		l.pos++
+		return gqlToken{kind: gqlPunctuator, text: string(c), offset: start}, nil
+	case c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+		for l.pos < len(l.src) && isGraphQLNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return gqlToken{kind: gqlName, text: l.src[start:l.pos], offset: start}, nil
+	case c == '-' || '0' <= c && c <= '9':
+		return l.number()
+	case strings.HasPrefix(l.src[start:], `"""`):
+		return l.blockString()
+	case c == '"':
+		return l.string()
+	}
+	return gqlToken{}, fmt.Errorf("unexpected character %q at offset %d", c, start)
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+func (l *gqlLexer) number() (gqlToken, error) {
+	start := l.pos
+	kind := gqlInt
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && '0' <= l.src[l.pos] && l.src[l.pos] <= '9' {
+			l.pos++
+			n++
+		}
+		return n
+	}
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	intStart := l.pos
+	if digits() == 0 || l.pos-intStart > 1 && l.src[intStart] == '0' {
+		return gqlToken{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = gqlFloat
+		l.pos++
+		if digits() == 0 {
+			return gqlToken{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = gqlFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return gqlToken{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '.' || isGraphQLNameChar(l.src[l.pos])) {
+		return gqlToken{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+	return gqlToken{kind: kind, text: l.src[start:l.pos], offset: start}, nil
+}
+
+func (l *gqlLexer) string() (gqlToken, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return gqlToken{kind: gqlString, text: b.String(), offset: start}, nil
+		case c == '\n' || c == '\r':
+			return gqlToken{}, fmt.Errorf("unterminated string at offset %d", start)
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return gqlToken{}, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			switch e := l.src[l.pos+1]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					return gqlToken{}, fmt.Errorf("invalid escape sequence at offset %d", l.pos)
+				}
+				r, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 16)
+				if err != nil {
+					return gqlToken{}, fmt.Errorf("invalid escape sequence at offset %d", l.pos)
+				}
+				b.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return gqlToken{}, fmt.Errorf("invalid escape sequence at offset %d", l.pos)
+			}
+			l.pos += 2
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return gqlToken{}, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+// blockString lexes a block string, removing its common indentation and its
+// leading and trailing blank lines.
+func (l *gqlLexer) blockString() (gqlToken, error) {
+	start := l.pos
+	l.pos += 3
+	end := strings.Index(strings.ReplaceAll(l.src[l.pos:], `\"""`, "\x00\x00\x00\x00"), `"""`)
+	if end < 0 {
+		return gqlToken{}, fmt.Errorf("unterminated string at offset %d", start)
+	}
+	raw := strings.ReplaceAll(l.src[l.pos:l.pos+end], `\"""`, `"""`)
+	l.pos += end + 3
+
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return gqlToken{kind: gqlString, text: strings.Join(lines, "\n"), offset: start}, nil
+}
@@ -0,0 +1,244 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func newGraphQLHandler(t *testing.T) *runtime.GraphQLHandler {
+	h := runtime.NewGraphQLHandler(runtime.NewServeMux())
+	for _, field := range []runtime.GraphQLField{
+		{
+			Name:       "get",
+			Definition: "get(id: String): ABitOfEverything",
+			RPCMethod:  "/grpc.gateway.runtime.internal.examplepb.Example/Get",
+			Request:    new(examplepb.SimpleMessage),
+			Response:   new(examplepb.ABitOfEverything),
+			Call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				id := req.(*examplepb.SimpleMessage).Id
+				if id == "missing" {
+					return nil, status.Error(codes.NotFound, "not found")
+				}
+				md, _ := metadata.FromOutgoingContext(ctx)
+				return &examplepb.ABitOfEverything{
+					Uuid:       id,
+					Int64Value: 42,
+					EnumValue:  examplepb.NumericEnum_ONE,
+					SingleNested: &examplepb.ABitOfEverything_Nested{
+						Name:   strings.Join(md.Get("authorization"), ","),
+						Amount: 7,
+					},
+					Nested:            []*examplepb.ABitOfEverything_Nested{{Name: "a"}, {Name: "b"}},
+					MappedStringValue: map[string]string{"k": "v"},
+				}, nil
+			},
+		},
+		{
+			Mutation:   true,
+			Name:       "echo",
+			Definition: "echo(id: String): SimpleMessage",
+			RPCMethod:  "/grpc.gateway.runtime.internal.examplepb.Example/Echo",
+			Request:    new(examplepb.SimpleMessage),
+			Response:   new(examplepb.SimpleMessage),
+			Call: func(ctx context.Context, req proto.Message) (proto.Message, error) {
+				return req, nil
+			},
+		},
+	} {
+		if err := h.Handle(field); err != nil {
+			t.Fatalf("h.Handle(%q) failed with %v; want success", field.Name, err)
+		}
+	}
+	for name, def := range map[string]string{
+		"SimpleMessage":    "type SimpleMessage {\n  id: String\n}",
+		"ABitOfEverything": "type ABitOfEverything {\n  uuid: String\n}",
+	} {
+		if err := h.DefineType(name, def); err != nil {
+			t.Fatalf("h.DefineType(%q) failed with %v; want success", name, err)
+		}
+	}
+	return h
+}
+
+func serveGraphQL(h http.Handler, method, query string, variables map[string]interface{}) (int, string) {
+	var r *http.Request
+	if method == http.MethodGet {
+		r = httptest.NewRequest(method, "/graphql?query="+url.QueryEscape(query), nil)
+	} else {
+		body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+		r = httptest.NewRequest(method, "/graphql", strings.NewReader(string(body)))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	h := newGraphQLHandler(t)
+	for _, spec := range []struct {
+		name      string
+		method    string
+		query     string
+		variables map[string]interface{}
+		wantCode  int
+		want      string
+	}{
+		{
+			name:   "query",
+			method: http.MethodGet,
+			query: `{
+				__typename
+				get(id: "foo") {
+					uuid
+					int64Value
+					enumValue
+					singleNested { __typename name amount }
+					mappedStringValue
+				}
+			}`,
+			wantCode: http.StatusOK,
+			want:     `{"data":{"__typename":"Query","get":{"uuid":"foo","int64Value":"42","enumValue":"ONE","singleNested":{"__typename":"ABitOfEverything_Nested","name":"Bearer token","amount":7},"mappedStringValue":{"k":"v"}}}}`,
+		},
+		{
+			name:   "aliases, variables, fragments and directives",
+			method: http.MethodPost,
+			query: `query Get($id: String = "bar", $full: Boolean!) {
+				first: get(id: $id) { ...Fields }
+				second: get(id: "baz") @include(if: $full) { uuid }
+				third: get(id: "baz") @skip(if: $full) { uuid }
+			}
+			fragment Fields on ABitOfEverything {
+				uuid
+				nested { ... on ABitOfEverything_Nested { name } }
+			}`,
+			variables: map[string]interface{}{"full": true},
+			wantCode:  http.StatusOK,
+			want:      `{"data":{"first":{"uuid":"bar","nested":[{"name":"a"},{"name":"b"}]},"second":{"uuid":"baz"}}}`,
+		},
+		{
+			name:     "mutation",
+			method:   http.MethodPost,
+			query:    `mutation { echo(id: "foo") { id } }`,
+			wantCode: http.StatusOK,
+			want:     `{"data":{"echo":{"id":"foo"}}}`,
+		},
+		{
+			name:     "method error",
+			method:   http.MethodPost,
+			query:    `{ ok: get(id: "foo") { uuid } ko: get(id: "missing") { uuid } }`,
+			wantCode: http.StatusOK,
+			want:     `{"data":{"ok":{"uuid":"foo"},"ko":null},"errors":[{"message":"not found","path":["ko"],"extensions":{"code":"NotFound"}}]}`,
+		},
+		{
+			name:     "invalid arguments",
+			method:   http.MethodPost,
+			query:    `{ get(unknown: 1) { uuid } }`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "mutation with GET",
+			method:   http.MethodGet,
+			query:    `mutation { echo(id: "foo") { id } }`,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "unknown field",
+			method:   http.MethodPost,
+			query:    `{ get(id: "foo") { unknown } }`,
+			wantCode: http.StatusBadRequest,
+			want:     `{"errors":[{"message":"cannot query field \"unknown\" on type \"ABitOfEverything\""}]}`,
+		},
+		{
+			name:     "missing selection",
+			method:   http.MethodPost,
+			query:    `{ get(id: "foo") }`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "selection of a scalar",
+			method:   http.MethodPost,
+			query:    `{ get(id: "foo") { uuid { id } } }`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "syntax error",
+			method:   http.MethodPost,
+			query:    `{ get(id: "foo" { uuid } }`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "recursive fragment",
+			method:   http.MethodPost,
+			query:    `{ ...F } fragment F on Query { ...F }`,
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			code, body := serveGraphQL(h, spec.method, spec.query, spec.variables)
+			if code != spec.wantCode {
+				t.Errorf("code = %d; want %d; body = %s", code, spec.wantCode, body)
+			}
+			if spec.want != "" && body != spec.want {
+				t.Errorf("body = %s; want %s", body, spec.want)
+			}
+			if spec.want == "" && !strings.Contains(body, `"errors"`) {
+				t.Errorf("body = %s; want errors", body)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandlerSchema(t *testing.T) {
+	h := newGraphQLHandler(t)
+	want := `scalar JSON
+
+type Query {
+  get(id: String): ABitOfEverything
+}
+
+type Mutation {
+  echo(id: String): SimpleMessage
+}
+
+type ABitOfEverything {
+  uuid: String
+}
+
+type SimpleMessage {
+  id: String
+}
+`
+	if got := h.Schema(); got != want {
+		t.Errorf("h.Schema() = %s; want %s", got, want)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Body.String(); got != want {
+		t.Errorf("GET /graphql = %s; want %s", got, want)
+	}
+
+	if err := h.Handle(runtime.GraphQLField{Name: "get"}); err == nil {
+		t.Errorf("h.Handle(%q) succeeded; want a duplicate error", "get")
+	}
+	if err := h.DefineType("SimpleMessage", "type SimpleMessage {\n  id: String\n}"); err != nil {
+		t.Errorf("h.DefineType(%q) failed with %v; want success for the same definition", "SimpleMessage", err)
+	}
+	if err := h.DefineType("SimpleMessage", "type SimpleMessage {\n  name: String\n}"); err == nil {
+		t.Errorf("h.DefineType(%q) succeeded; want a conflict error", "SimpleMessage")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -22,6 +23,12 @@ func ForwardResponseStream(ctx context.Context, mux *ServeMux, marshaler Marshal
 		http.Error(w, "unexpected type of web server", http.StatusInternalServerError)
 		return
 	}
+	flush := f.Flush
+	if mux.streamFlush != (StreamFlushPolicy{}) {
+		bw := newBufferedStreamWriter(w, f, mux.streamFlush)
+		defer bw.close()
+		w, f, flush = bw, bw, bw.chunkWritten
+	}
 
 	md, ok := ServerMetadataFromContext(ctx)
 	if !ok {
@@ -30,6 +37,10 @@ func ForwardResponseStream(ctx context.Context, mux *ServeMux, marshaler Marshal
 		return
 	}
 	handleForwardResponseServerMetadata(w, mux, md)
+	writeServerTiming(w, req)
+	if forwardLongPoll(ctx, mux, marshaler, w, req, recv, opts) {
+		return
+	}
 
 	w.Header().Set("Transfer-Encoding", "chunked")
 	if err := handleForwardResponseOptions(ctx, w, nil, opts); err != nil {
@@ -44,56 +55,146 @@ func ForwardResponseStream(ctx context.Context, mux *ServeMux, marshaler Marshal
 		delimiter = []byte("\n")
 	}
 
-	var wroteHeader bool
+	sel := mux.responseFieldSelection(req)
+	envelope := mux.envelopeFor(ctx)
+	if _, ok := marshaler.(*NDJSONMarshaler); ok {
+		envelope = StreamEnvelope{Bare: true}
+	}
+	// index is the number of chunks written, wroteHeader is set once a chunk
+	// or a heartbeat is written, and array is set when the messages are
+	// written as the elements of a JSON array, rather than delimited chunks.
+	var index int
+	var wroteHeader, array bool
+	var heartbeat []byte
+	interval := mux.heartbeat.interval(req)
+	if interval > 0 && isJSONContentType(marshaler.ContentType(nil)) {
+		heartbeat = append(append([]byte(nil), mux.heartbeat.Frame...), delimiter...)
+		if mux.streamJSONArray {
+			heartbeat = []byte("\n")
+		}
+	} else {
+		interval = 0
+	}
+	var done <-chan struct{}
+	if mux.streamDisconnect != nil {
+		done = req.Context().Done()
+	}
+	if interval > 0 || done != nil {
+		recv = asyncRecv(recv, interval, done)
+	}
 	for {
 		resp, err := recv()
+		if err != nil && err != io.EOF && err != errHeartbeat && mux.streamDisconnect != nil && req.Context().Err() != nil {
+			mux.notifyStreamDisconnect(ctx, req, index, req.Context().Err())
+			return
+		}
+		if err == errHeartbeat {
+			if heartbeat == nil {
+				continue
+			}
+			if !wroteHeader {
+				w.Header().Set("Content-Type", streamContentType(marshaler, nil))
+			}
+			if _, err := w.Write(heartbeat); err != nil {
+				grpclog.Infof("Failed to send heartbeat: %v", err)
+				mux.notifyStreamDisconnect(ctx, req, index, err)
+				return
+			}
+			wroteHeader = true
+			f.Flush()
+			continue
+		}
 		if err == io.EOF {
+			if index == 0 && mux.streamJSONArray && isJSONContentType(streamContentType(marshaler, nil)) {
+				w.Header().Set("Content-Type", streamContentType(marshaler, nil))
+				if _, err := w.Write([]byte("[]")); err != nil {
+					grpclog.Infof("Failed to send response chunk: %v", err)
+				}
+				return
+			}
+			if array {
+				if _, err := w.Write([]byte("]")); err != nil {
+					grpclog.Infof("Failed to send response chunk: %v", err)
+				}
+			}
 			return
 		}
 		if err != nil {
-			handleForwardResponseStreamError(ctx, wroteHeader, marshaler, w, req, mux, err)
+			handleForwardResponseStreamArrayError(ctx, wroteHeader, index, array, envelope, marshaler, w, req, mux, err)
 			return
 		}
+		if resp != nil {
+			if resp, err = mux.rewriteResponse(ctx, w, resp); err != nil {
+				handleForwardResponseStreamArrayError(ctx, wroteHeader, index, array, envelope, marshaler, w, req, mux, err)
+				return
+			}
+		}
 		if err := handleForwardResponseOptions(ctx, w, resp, opts); err != nil {
-			handleForwardResponseStreamError(ctx, wroteHeader, marshaler, w, req, mux, err)
+			handleForwardResponseStreamArrayError(ctx, wroteHeader, index, array, envelope, marshaler, w, req, mux, err)
 			return
 		}
 
-		if !wroteHeader {
-			w.Header().Set("Content-Type", marshaler.ContentType(resp))
+		httpBody, isHTTPBody := resp.(*httpbody.HttpBody)
+		if index == 0 {
+			contentType := streamContentType(marshaler, resp)
+			w.Header().Set("Content-Type", contentType)
+			array = mux.streamJSONArray && resp != nil && !isHTTPBody && isJSONContentType(contentType)
+		}
+		if isHTTPBody {
+			heartbeat = nil
 		}
 
 		var buf []byte
-		httpBody, isHTTPBody := resp.(*httpbody.HttpBody)
 		switch {
 		case resp == nil:
-			buf, err = marshaler.Marshal(errorChunk(status.New(codes.Internal, "empty response")))
+			buf, err = marshaler.Marshal(envelope.errorChunk(status.New(codes.Internal, "empty response"), index))
 		case isHTTPBody:
 			buf = httpBody.GetData()
 		default:
-			result := map[string]interface{}{"result": resp}
+			var body interface{} = resp
 			if rb, ok := resp.(responseBody); ok {
-				result["result"] = rb.XXX_ResponseBody()
+				body = rb.XXX_ResponseBody()
+			}
+			if body, err = selectFields(body, sel); err == nil {
+				if array {
+					buf, err = marshaler.Marshal(body)
+				} else {
+					buf, err = marshaler.Marshal(envelope.resultChunk(body, index))
+				}
 			}
-
-			buf, err = marshaler.Marshal(result)
 		}
 
 		if err != nil {
 			grpclog.Infof("Failed to marshal response chunk: %v", err)
-			handleForwardResponseStreamError(ctx, wroteHeader, marshaler, w, req, mux, err)
+			handleForwardResponseStreamArrayError(ctx, wroteHeader, index, array, envelope, marshaler, w, req, mux, err)
 			return
 		}
+		if array {
+			separator := ","
+			if index == 0 {
+				separator = "["
+			}
+			if _, err = w.Write([]byte(separator)); err != nil {
+				grpclog.Infof("Failed to send delimiter chunk: %v", err)
+				mux.notifyStreamDisconnect(ctx, req, index, err)
+				return
+			}
+		}
 		if _, err = w.Write(buf); err != nil {
 			grpclog.Infof("Failed to send response chunk: %v", err)
+			mux.notifyStreamDisconnect(ctx, req, index, err)
 			return
 		}
+		index++
 		wroteHeader = true
-		if _, err = w.Write(delimiter); err != nil {
-			grpclog.Infof("Failed to send delimiter chunk: %v", err)
-			return
+		if !array {
+			if _, err = w.Write(delimiter); err != nil {
+				grpclog.Infof("Failed to send delimiter chunk: %v", err)
+				mux.notifyStreamDisconnect(ctx, req, index, err)
+				return
+			}
 		}
-		f.Flush()
+		flush()
 	}
 }
 
@@ -107,6 +208,26 @@ func handleForwardResponseServerMetadata(w http.ResponseWriter, mux *ServeMux, m
 	}
 }
 
+// handleForwardResponsePromotedTrailers writes the trailers of md selected by
+// the trailer header matcher of mux as headers, and returns md without them.
+func handleForwardResponsePromotedTrailers(w http.ResponseWriter, mux *ServeMux, md ServerMetadata) ServerMetadata {
+	if mux.trailerHeaderMatcher == nil || len(md.TrailerMD) == 0 {
+		return md
+	}
+	trailers := metadata.MD{}
+	for k, vs := range md.TrailerMD {
+		if h, ok := mux.trailerHeaderMatcher(k); ok {
+			for _, v := range vs {
+				w.Header().Add(h, v)
+			}
+			continue
+		}
+		trailers[k] = vs
+	}
+	md.TrailerMD = trailers
+	return md
+}
+
 func handleForwardResponseTrailerHeader(w http.ResponseWriter, md ServerMetadata) {
 	for k := range md.TrailerMD {
 		tKey := textproto.CanonicalMIMEHeaderKey(fmt.Sprintf("%s%s", MetadataTrailerPrefix, k))
@@ -137,7 +258,15 @@ func ForwardResponseMessage(ctx context.Context, mux *ServeMux, marshaler Marsha
 	}
 
 	handleForwardResponseServerMetadata(w, mux, md)
+	md = handleForwardResponsePromotedTrailers(w, mux, md)
 	handleForwardResponseTrailerHeader(w, md)
+	writeServerTiming(w, req)
+	resp, err := mux.rewriteResponse(ctx, w, resp)
+	if err != nil {
+		HTTPError(ctx, mux, marshaler, w, req, err)
+		return
+	}
+	mux.writePaginationLinks(w, req, resp)
 
 	contentType := marshaler.ContentType(resp)
 	w.Header().Set("Content-Type", contentType)
@@ -146,13 +275,16 @@ func ForwardResponseMessage(ctx context.Context, mux *ServeMux, marshaler Marsha
 		HTTPError(ctx, mux, marshaler, w, req, err)
 		return
 	}
-	var buf []byte
-	var err error
+	var body interface{} = resp
 	if rb, ok := resp.(responseBody); ok {
-		buf, err = marshaler.Marshal(rb.XXX_ResponseBody())
-	} else {
-		buf, err = marshaler.Marshal(resp)
+		body = rb.XXX_ResponseBody()
 	}
+	body, err = selectFields(body, mux.responseFieldSelection(req))
+	if err != nil {
+		HTTPError(ctx, mux, marshaler, w, req, err)
+		return
+	}
+	buf, err := marshaler.Marshal(body)
 	if err != nil {
 		grpclog.Infof("Marshal error: %v", err)
 		HTTPError(ctx, mux, marshaler, w, req, err)
@@ -179,22 +311,51 @@ func handleForwardResponseOptions(ctx context.Context, w http.ResponseWriter, re
 	return nil
 }
 
-func handleForwardResponseStreamError(ctx context.Context, wroteHeader bool, marshaler Marshaler, w http.ResponseWriter, req *http.Request, mux *ServeMux, err error) {
-	st := mux.streamErrorHandler(ctx, err)
+// handleForwardResponseStreamError writes err as the index-th chunk of a
+// stream, with its status if nothing was written yet.
+func handleForwardResponseStreamError(ctx context.Context, wroteHeader bool, index int, envelope StreamEnvelope, marshaler Marshaler, w http.ResponseWriter, req *http.Request, mux *ServeMux, err error) {
+	st := mux.transformStatus(ctx, mux.localizeStatus(req, mux.streamErrorHandler(ctx, err)))
 	if !wroteHeader {
+		setRetryAfter(w, st)
 		w.WriteHeader(HTTPStatusFromCode(st.Code()))
 	}
-	buf, merr := marshaler.Marshal(errorChunk(st))
+	buf, merr := marshaler.Marshal(envelope.errorChunk(st, index))
 	if merr != nil {
 		grpclog.Infof("Failed to marshal an error: %v", merr)
 		return
 	}
+	if _, ok := marshaler.(*NDJSONMarshaler); ok {
+		buf = append(buf, '\n')
+	}
 	if _, werr := w.Write(buf); werr != nil {
 		grpclog.Infof("Failed to notify error to client: %v", werr)
 		return
 	}
 }
 
-func errorChunk(st *status.Status) map[string]proto.Message {
-	return map[string]proto.Message{"error": st.Proto()}
+// streamContentType returns the Content-Type of the stream of v marshaled by
+// marshaler.
+func streamContentType(marshaler Marshaler, v interface{}) string {
+	if m, ok := marshaler.(StreamContentType); ok {
+		return m.StreamContentType(v)
+	}
+	return marshaler.ContentType(v)
+}
+
+// handleForwardResponseStreamArrayError writes err as the last element of the
+// JSON array of a stream if array is set and the array was started, and as a
+// delimited error otherwise.
+func handleForwardResponseStreamArrayError(ctx context.Context, wroteHeader bool, index int, array bool, envelope StreamEnvelope, marshaler Marshaler, w http.ResponseWriter, req *http.Request, mux *ServeMux, err error) {
+	if !array || index == 0 {
+		handleForwardResponseStreamError(ctx, wroteHeader, index, envelope, marshaler, w, req, mux, err)
+		return
+	}
+	if _, werr := w.Write([]byte(",")); werr != nil {
+		grpclog.Infof("Failed to notify error to client: %v", werr)
+		return
+	}
+	handleForwardResponseStreamError(ctx, wroteHeader, index, envelope, marshaler, w, req, mux, err)
+	if _, werr := w.Write([]byte("]")); werr != nil {
+		grpclog.Infof("Failed to notify error to client: %v", werr)
+	}
 }
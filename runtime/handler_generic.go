@@ -0,0 +1,82 @@
+//go:build go1.21
+// +build go1.21
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// RequestFunc decodes req and calls a gRPC method, returning its response,
+// e.g. a message or a function receiving the messages of a stream.
+type RequestFunc[T any] func(ctx context.Context, marshaler Marshaler, req *http.Request, pathParams map[string]string) (T, ServerMetadata, error)
+
+// ForwardFunc forwards the response of a gRPC method to the HTTP client, like
+// ForwardResponseMessage and ForwardResponseStream.
+type ForwardFunc[T any] func(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, req *http.Request, resp T, opts ...func(context.Context, http.ResponseWriter, proto.Message) error)
+
+// ClientHandler returns the handler calling the gRPC method rpcMethodName of
+// a client with request, and forwarding its response with forward. The calls
+// are made with a context derived from ctx, or from the context of the
+// requests if useRequestContext is set.
+//
+// The code generated with generic_forwarders registers ClientHandler instead
+// of a handler per binding.
+func ClientHandler[T any](ctx context.Context, mux *ServeMux, useRequestContext bool, rpcMethodName string, request RequestFunc[T], forward ForwardFunc[T]) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+		ctx, cancel := context.WithCancel(handlerContext(ctx, req, useRequestContext))
+		defer cancel()
+		inboundMarshaler, outboundMarshaler := MarshalerForRequest(mux, req)
+		rctx, err := AnnotateContext(ctx, mux, req, rpcMethodName)
+		if err != nil {
+			HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		resp, md, err := request(rctx, inboundMarshaler, req, pathParams)
+		ctx = NewServerMetadataContext(ctx, md)
+		if err != nil {
+			HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		forward(ctx, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
+	}
+}
+
+// ServerHandler is like ClientHandler, but calls the gRPC method of a server
+// in process with request.
+func ServerHandler[T any](ctx context.Context, mux *ServeMux, useRequestContext bool, rpcMethodName string, request RequestFunc[T], forward ForwardFunc[T]) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+		ctx, cancel := context.WithCancel(handlerContext(ctx, req, useRequestContext))
+		defer cancel()
+		var stream ServerTransportStream
+		ctx = grpc.NewContextWithServerTransportStream(ctx, &stream)
+		inboundMarshaler, outboundMarshaler := MarshalerForRequest(mux, req)
+		rctx, err := AnnotateIncomingContext(ctx, mux, req, rpcMethodName)
+		if err != nil {
+			HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		resp, md, err := request(rctx, inboundMarshaler, req, pathParams)
+		md.HeaderMD, md.TrailerMD = metadata.Join(md.HeaderMD, stream.Header()), metadata.Join(md.TrailerMD, stream.Trailer())
+		ctx = NewServerMetadataContext(ctx, md)
+		if err != nil {
+			HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		forward(ctx, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
+	}
+}
+
+// handlerContext returns the context the handlers registered with ctx derive
+// the context of the calls of req from.
+func handlerContext(ctx context.Context, req *http.Request, useRequestContext bool) context.Context {
+	if useRequestContext {
+		return req.Context()
+	}
+	return ctx
+}
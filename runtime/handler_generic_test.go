@@ -0,0 +1,72 @@
+//go:build go1.21
+// +build go1.21
+
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClientHandler(t *testing.T) {
+	mux := runtime.NewServeMux()
+	request := func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
+		if m, ok := runtime.RPCMethod(ctx); !ok || m != "/example.Example/Get" {
+			t.Errorf("runtime.RPCMethod(ctx) = %q, %t; want %q, true", m, ok, "/example.Example/Get")
+		}
+		if pathParams["id"] == "missing" {
+			return nil, runtime.ServerMetadata{}, status.Error(codes.NotFound, "not found")
+		}
+		md := runtime.ServerMetadata{HeaderMD: metadata.Pairs("foo", "bar")}
+		return &wrapperspb.StringValue{Value: pathParams["id"]}, md, nil
+	}
+	h := runtime.ClientHandler(context.Background(), mux, true, "/example.Example/Get", request, runtime.ForwardResponseMessage)
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/v1/42", nil), map[string]string{"id": "42"})
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+	if got, want := w.Body.String(), `"42"`; got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+	if got, want := w.Header().Get("Grpc-Metadata-Foo"), "bar"; got != want {
+		t.Errorf("w.Header().Get(%q) = %q; want %q", "Grpc-Metadata-Foo", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/v1/missing", nil), map[string]string{"id": "missing"})
+	if got, want := w.Code, http.StatusNotFound; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+}
+
+func TestServerHandler(t *testing.T) {
+	mux := runtime.NewServeMux()
+	request := func(ctx context.Context, marshaler runtime.Marshaler, req *http.Request, pathParams map[string]string) (proto.Message, runtime.ServerMetadata, error) {
+		if err := grpc.SetHeader(ctx, metadata.Pairs("foo", "bar")); err != nil {
+			t.Errorf("grpc.SetHeader(ctx, ...) failed with %v; want success", err)
+		}
+		return &wrapperspb.StringValue{Value: pathParams["id"]}, runtime.ServerMetadata{}, nil
+	}
+	h := runtime.ServerHandler(context.Background(), mux, false, "/example.Example/Get", request, runtime.ForwardResponseMessage)
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/v1/42", nil), map[string]string{"id": "42"})
+	if got, want := w.Body.String(), `"42"`; got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+	if got, want := w.Header().Get("Grpc-Metadata-Foo"), "bar"; got != want {
+		t.Errorf("w.Header().Get(%q) = %q; want %q", "Grpc-Metadata-Foo", got, want)
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -312,3 +314,34 @@ func TestForwardResponseMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestForwardResponseMessagePromotedTrailers(t *testing.T) {
+	md := runtime.ServerMetadata{
+		TrailerMD: metadata.Pairs("x-cache-status", "hit", "x-checksum", "abc"),
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), md)
+	mux := runtime.NewServeMux(runtime.WithTrailerHeaderMatcher(func(key string) (string, bool) {
+		if key == "x-cache-status" {
+			return "X-Cache-Status", true
+		}
+		return "", false
+	}))
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	resp := httptest.NewRecorder()
+
+	runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, resp, req, &pb.SimpleMessage{Id: "One"})
+
+	w := resp.Result()
+	if got, want := w.Header.Get("X-Cache-Status"), "hit"; got != want {
+		t.Errorf(`w.Header.Get("X-Cache-Status") = %q; want %q`, got, want)
+	}
+	if got, want := w.Header.Values("Trailer"), []string{"Grpc-Trailer-X-Checksum"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`w.Header.Values("Trailer") = %q; want %q`, got, want)
+	}
+	if got := w.Trailer.Get("Grpc-Trailer-X-Cache-Status"); got != "" {
+		t.Errorf(`w.Trailer.Get("Grpc-Trailer-X-Cache-Status") = %q; want none`, got)
+	}
+	if got, want := w.Trailer.Get("Grpc-Trailer-X-Checksum"), "abc"; got != want {
+		t.Errorf(`w.Trailer.Get("Grpc-Trailer-X-Checksum") = %q; want %q`, got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Hedging configures the hedged calls of NewHedgedBackend.
+type Hedging struct {
+	// Delay is how long the first attempt of a call runs before a second
+	// one is sent.
+	Delay time.Duration
+	// Routes overrides Delay for the calls of the routes with these path
+	// templates, e.g. "/v1/{name=shelves/*}", as given to HandlePath or in
+	// the http rules. A non-positive delay disables the hedging of a route.
+	Routes map[string]time.Duration
+	// MaxConcurrent is the number of second attempts in flight at once, if
+	// positive. The calls are not hedged while it is reached.
+	MaxConcurrent int
+}
+
+// NewHedgedBackend returns a connection hedging the unary calls of the GET
+// requests to mux on conn: when the first attempt of a call takes longer than
+// the delay of hedging, a second one is sent, e.g. to another backend of a
+// load balanced conn, and the first to succeed is kept while the other is
+// canceled. The failed attempts are not retried.
+//
+// Only the methods bound to GET, which are expected to be idempotent, are to
+// be hedged. The streaming calls and the calls made outside of the handlers
+// of mux are not hedged. The requests of the hedged calls are copied for
+// their attempts, which may outlive them. The connection is to be given to the generated
+// NewXXXClient functions, like that of NewBackendSelector.
+//
+// NewHedgedBackend panics if a route is not a valid path template. It must be
+// called before mux serves requests.
+func NewHedgedBackend(mux *ServeMux, conn grpc.ClientConnInterface, hedging Hedging) grpc.ClientConnInterface {
+	routes := make(map[string]time.Duration, len(hedging.Routes))
+	for route, delay := range hedging.Routes {
+		pattern, err := normalizeRoute(route)
+		if err != nil {
+			panic(fmt.Sprintf("runtime: invalid hedging route %q: %v", route, err))
+		}
+		routes[pattern] = delay
+	}
+	mux.callRoutes = true
+	h := &hedgedBackend{ClientConnInterface: conn, delay: hedging.Delay, routes: routes}
+	if hedging.MaxConcurrent > 0 {
+		h.sem = make(chan struct{}, hedging.MaxConcurrent)
+	}
+	return h
+}
+
+type hedgedBackend struct {
+	grpc.ClientConnInterface
+	delay  time.Duration
+	routes map[string]time.Duration
+	// sem holds a token per second attempt in flight if their number is
+	// capped.
+	sem chan struct{}
+}
+
+// hedgeDelay returns the delay of the second attempt of the calls with ctx, or
+// 0 if they are not hedged.
+func (h *hedgedBackend) hedgeDelay(ctx context.Context) time.Duration {
+	m, ok := ctx.Value(routeKey{}).(*matchedRoute)
+	if !ok || m.httpMethod != "GET" {
+		return 0
+	}
+	if delay, ok := h.routes[m.pattern.String()]; ok {
+		return delay
+	}
+	return h.delay
+}
+
+// acquire returns whether a second attempt may be sent, and then holds a
+// token until release.
+func (h *hedgedBackend) acquire() bool {
+	if h.sem == nil {
+		return true
+	}
+	select {
+	case h.sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (h *hedgedBackend) release() {
+	if h.sem != nil {
+		<-h.sem
+	}
+}
+
+// Invoke performs a unary call on the connection, hedging it if its route is.
+func (h *hedgedBackend) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	delay := h.hedgeDelay(ctx)
+	msg, ok := reply.(proto.Message)
+	if delay <= 0 || !ok {
+		return h.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+	}
+
+	// The attempts may still send the request once the call returns, when it
+	// may be reused, e.g. by the handlers generated with pool_requests.
+	if in, ok := args.(proto.Message); ok {
+		args = proto.Clone(in)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The attempts left pending once the call returns are canceled, and the
+	// buffered results let them return.
+	results := make(chan *hedgedAttempt, 2)
+	send := func(second bool) {
+		a := newHedgedAttempt(msg, opts)
+		go func() {
+			if second {
+				defer h.release()
+			}
+			a.err = h.ClientConnInterface.Invoke(ctx, method, args, a.reply, a.opts...)
+			results <- a
+		}()
+	}
+	send(false)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedge := timer.C
+	var err error
+	for pending > 0 {
+		select {
+		case <-hedge:
+			hedge = nil
+			if h.acquire() {
+				send(true)
+				pending++
+			}
+		case a := <-results:
+			pending--
+			if a.err == nil {
+				a.commit(msg, opts)
+				return nil
+			}
+			// A failed call is not hedged, but a pending attempt may still
+			// succeed.
+			hedge = nil
+			err = a.err
+		}
+	}
+	return err
+}
+
+// hedgedAttempt is an attempt of a hedged call, with its own reply and
+// metadata.
+type hedgedAttempt struct {
+	reply   proto.Message
+	header  metadata.MD
+	trailer metadata.MD
+	opts    []grpc.CallOption
+	err     error
+}
+
+func newHedgedAttempt(reply proto.Message, opts []grpc.CallOption) *hedgedAttempt {
+	a := &hedgedAttempt{reply: reply.ProtoReflect().New().Interface()}
+	for _, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption:
+			opt = grpc.Header(&a.header)
+		case grpc.TrailerCallOption:
+			opt = grpc.Trailer(&a.trailer)
+		}
+		a.opts = append(a.opts, opt)
+	}
+	return a
+}
+
+// commit sets reply and the metadata of opts to those of the attempt.
+func (a *hedgedAttempt) commit(reply proto.Message, opts []grpc.CallOption) {
+	proto.Reset(reply)
+	proto.Merge(reply, a.reply)
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			*opt.HeaderAddr = a.header
+		case grpc.TrailerCallOption:
+			*opt.TrailerAddr = a.trailer
+		}
+	}
+}
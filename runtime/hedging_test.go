@@ -0,0 +1,277 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// hedgingConn is a connection whose first call is slow, replying "first"
+// after wait unless canceled, and whose other calls reply "second" at once.
+type hedgingConn struct {
+	wait time.Duration
+
+	mu       sync.Mutex
+	attempts int
+	canceled bool
+}
+
+func (c *hedgingConn) Invoke(ctx context.Context, _ string, _, reply interface{}, opts ...grpc.CallOption) error {
+	c.mu.Lock()
+	c.attempts++
+	attempt := c.attempts
+	c.mu.Unlock()
+	id := "second"
+	if attempt == 1 {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.canceled = true
+			c.mu.Unlock()
+			return ctx.Err()
+		case <-time.After(c.wait):
+		}
+		id = "first"
+	}
+	reply.(*pb.SimpleMessage).Id = id
+	for _, opt := range opts {
+		if h, ok := opt.(grpc.HeaderCallOption); ok {
+			*h.HeaderAddr = metadata.Pairs("attempt", id)
+		}
+	}
+	return nil
+}
+
+func (c *hedgingConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, nil
+}
+
+func TestNewHedgedBackend(t *testing.T) {
+	for _, spec := range []struct {
+		name         string
+		method       string
+		hedging      runtime.Hedging
+		wait         time.Duration
+		want         string
+		wantAttempts int
+	}{
+		{
+			name:         "hedged",
+			method:       "GET",
+			hedging:      runtime.Hedging{Delay: 5 * time.Millisecond},
+			wait:         5 * time.Second,
+			want:         "second",
+			wantAttempts: 2,
+		},
+		{
+			name:         "route delay",
+			method:       "GET",
+			hedging:      runtime.Hedging{Routes: map[string]time.Duration{"/v1/{name=shelves/*}": 5 * time.Millisecond}},
+			wait:         5 * time.Second,
+			want:         "second",
+			wantAttempts: 2,
+		},
+		{
+			name:         "route disabled",
+			method:       "GET",
+			hedging:      runtime.Hedging{Delay: 5 * time.Millisecond, Routes: map[string]time.Duration{"/v1/{name=shelves/*}": 0}},
+			wait:         20 * time.Millisecond,
+			want:         "first",
+			wantAttempts: 1,
+		},
+		{
+			name:         "not GET",
+			method:       "DELETE",
+			hedging:      runtime.Hedging{Delay: 5 * time.Millisecond},
+			wait:         20 * time.Millisecond,
+			want:         "first",
+			wantAttempts: 1,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux()
+			backend := &hedgingConn{wait: spec.wait}
+			conn := runtime.NewHedgedBackend(mux, backend, spec.hedging)
+			var reply pb.SimpleMessage
+			var header metadata.MD
+			if err := mux.HandlePath(spec.method, "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				ctx, err := runtime.AnnotateContext(context.Background(), mux, r, "/example.Library/GetShelf")
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+				}
+				if err := conn.Invoke(ctx, "/example.Library/GetShelf", &pb.SimpleMessage{}, &reply, grpc.Header(&header)); err != nil {
+					t.Errorf("conn.Invoke(ctx, method, req, reply) failed with %v; want success", err)
+				}
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", spec.method, "/v1/{name=shelves/*}", err)
+			}
+
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(spec.method, "/v1/shelves/1", nil))
+
+			if reply.Id != spec.want {
+				t.Errorf("reply.Id = %q; want %q", reply.Id, spec.want)
+			}
+			if got := header.Get("attempt"); len(got) != 1 || got[0] != spec.want {
+				t.Errorf("header = %v; want the header of the %s attempt", header, spec.want)
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				backend.mu.Lock()
+				attempts, canceled := backend.attempts, backend.canceled
+				backend.mu.Unlock()
+				if attempts != spec.wantAttempts {
+					t.Fatalf("%d attempts; want %d", attempts, spec.wantAttempts)
+				}
+				if spec.wantAttempts == 1 || canceled {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("the first attempt was not canceled once the second succeeded")
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+// gatedConn is a connection whose calls reply once release is closed.
+type gatedConn struct {
+	release chan struct{}
+
+	mu       sync.Mutex
+	attempts int
+}
+
+func (c *gatedConn) Invoke(ctx context.Context, _ string, _, _ interface{}, _ ...grpc.CallOption) error {
+	c.mu.Lock()
+	c.attempts++
+	c.mu.Unlock()
+	select {
+	case <-c.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *gatedConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, nil
+}
+
+func (c *gatedConn) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.attempts
+}
+
+func TestNewHedgedBackendMaxConcurrent(t *testing.T) {
+	mux := runtime.NewServeMux()
+	backend := &gatedConn{release: make(chan struct{})}
+	conn := runtime.NewHedgedBackend(mux, backend, runtime.Hedging{Delay: time.Millisecond, MaxConcurrent: 1})
+	if err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(context.Background(), mux, r, "/example.Library/GetShelf")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		if err := conn.Invoke(ctx, "/example.Library/GetShelf", &pb.SimpleMessage{}, &pb.SimpleMessage{}); err != nil {
+			t.Errorf("conn.Invoke(ctx, method, req, reply) failed with %v; want success", err)
+		}
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+	}
+	waitAttempts := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for backend.count() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d attempts; want %d", backend.count(), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	var wg sync.WaitGroup
+	serve := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/shelves/1", nil))
+		}()
+	}
+
+	// The first call is hedged, which reaches the cap.
+	serve()
+	waitAttempts(2)
+	serve()
+	waitAttempts(3)
+	time.Sleep(20 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	if got := backend.count(); got != 3 {
+		t.Errorf("%d attempts; want 3 as the second call is not hedged", got)
+	}
+}
+
+// lateReadConn is a connection whose first call reads its request once reset
+// is closed, after the hedged call returned, and whose other calls reply at
+// once.
+type lateReadConn struct {
+	reset chan struct{}
+	seen  chan string
+
+	mu       sync.Mutex
+	attempts int
+}
+
+func (c *lateReadConn) Invoke(ctx context.Context, _ string, args, _ interface{}, _ ...grpc.CallOption) error {
+	c.mu.Lock()
+	c.attempts++
+	attempt := c.attempts
+	c.mu.Unlock()
+	if attempt == 1 {
+		<-c.reset
+		c.seen <- args.(*pb.SimpleMessage).Id
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (c *lateReadConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, nil
+}
+
+func TestNewHedgedBackendReusedRequest(t *testing.T) {
+	mux := runtime.NewServeMux()
+	backend := &lateReadConn{reset: make(chan struct{}), seen: make(chan string, 1)}
+	conn := runtime.NewHedgedBackend(mux, backend, runtime.Hedging{Delay: time.Millisecond})
+	if err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(context.Background(), mux, r, "/example.Library/GetShelf")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		req := &pb.SimpleMessage{Id: "shelves/1"}
+		if err := conn.Invoke(ctx, "/example.Library/GetShelf", req, &pb.SimpleMessage{}); err != nil {
+			t.Errorf("conn.Invoke(ctx, method, req, reply) failed with %v; want success", err)
+		}
+		// The request is reset once the call returns, like those of a
+		// runtime.MessagePool.
+		proto.Reset(req)
+		close(backend.reset)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/shelves/1", nil))
+
+	if got := <-backend.seen; got != "shelves/1" {
+		t.Errorf("the pending attempt sent the request with id %q; want %q", got, "shelves/1")
+	}
+}
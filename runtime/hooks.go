@@ -0,0 +1,38 @@
+package runtime
+
+import (
+	"context"
+)
+
+type hooksKey struct{}
+
+// WithHooks returns a ServeMuxOption registering hooks around the methods of
+// the given service, named by its fully qualified name, e.g.
+// "example.UserService". It backs the With<Service>Hooks options of the
+// generated code, which document the per-method interfaces hooks may
+// implement.
+func WithHooks(service string, hooks interface{}) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		if serveMux.hooks == nil {
+			serveMux.hooks = make(map[string]interface{})
+		}
+		serveMux.hooks[service] = hooks
+	}
+}
+
+// Hooks returns the hooks registered for the service on the ServeMux which
+// annotated the context, or nil if none were.
+func Hooks(ctx context.Context, service string) interface{} {
+	hooks, ok := ctx.Value(hooksKey{}).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return hooks[service]
+}
+
+func withHooks(ctx context.Context, hooks map[string]interface{}) context.Context {
+	if len(hooks) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, hooksKey{}, hooks)
+}
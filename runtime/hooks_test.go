@@ -0,0 +1,44 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+type testHooks struct{ name string }
+
+func TestHooks(t *testing.T) {
+	hooks := &testHooks{name: "users"}
+	mux := runtime.NewServeMux(runtime.WithHooks("example.UserService", hooks))
+	request, err := http.NewRequest("GET", "http://www.example.com", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "GET", "http://www.example.com", err)
+	}
+
+	for _, annotate := range []func(context.Context, *runtime.ServeMux, *http.Request, string) (context.Context, error){
+		runtime.AnnotateContext,
+		runtime.AnnotateIncomingContext,
+	} {
+		ctx, err := annotate(context.Background(), mux, request, "/example.UserService/GetUser")
+		if err != nil {
+			t.Fatalf("annotating the context failed with %v; want success", err)
+		}
+		if got := runtime.Hooks(ctx, "example.UserService"); got != hooks {
+			t.Errorf("runtime.Hooks(ctx, %q) = %v; want %v", "example.UserService", got, hooks)
+		}
+		if got := runtime.Hooks(ctx, "example.OtherService"); got != nil {
+			t.Errorf("runtime.Hooks(ctx, %q) = %v; want nil", "example.OtherService", got)
+		}
+	}
+
+	ctx, err := runtime.AnnotateContext(context.Background(), runtime.NewServeMux(), request, "/example.UserService/GetUser")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext() failed with %v; want success", err)
+	}
+	if got := runtime.Hooks(ctx, "example.UserService"); got != nil {
+		t.Errorf("runtime.Hooks(ctx, %q) without hooks = %v; want nil", "example.UserService", got)
+	}
+}
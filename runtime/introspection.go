@@ -0,0 +1,272 @@
+package runtime
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
+)
+
+// TokenIntrospection configures the validation of the opaque OAuth2 bearer
+// tokens of the requests with an RFC 7662 introspection endpoint, enabled
+// with WithTokenIntrospection.
+type TokenIntrospection struct {
+	// Endpoint is the URL of the introspection endpoint. It is required.
+	Endpoint string
+	// ClientID and ClientSecret authenticate the gateway to the endpoint with
+	// HTTP basic authentication, if ClientID is set.
+	ClientID     string
+	ClientSecret string
+	// Client sends the introspection requests, http.DefaultClient if nil.
+	Client *http.Client
+	// CacheTTL is how long the active tokens are cached, bounded by their
+	// expiry. The tokens are introspected for every request if negative, and
+	// cached for a minute if zero.
+	CacheTTL time.Duration
+	// CacheSize is the number of tokens cached, the least recently used ones
+	// being evicted first. It is 10000 if zero.
+	CacheSize int
+	// ClaimKeys maps the claims of the introspection responses to the metadata
+	// keys they are forwarded as. The subject, scope and client ID are
+	// forwarded as "x-token-subject", "x-token-scope" and "x-token-client-id"
+	// if nil.
+	ClaimKeys map[string]string
+	// Optional forwards the requests without a bearer token, without claims,
+	// instead of rejecting them.
+	Optional bool
+}
+
+// defaultIntrospectionCacheSize is the number of tokens cached if
+// TokenIntrospection.CacheSize is zero.
+const defaultIntrospectionCacheSize = 10000
+
+// WithTokenIntrospection returns a ServeMuxOption validating the bearer
+// tokens of the Authorization header of the requests with an RFC 7662
+// introspection endpoint, and forwarding the claims of the active ones as
+// metadata, in place of the headers the clients may set them with. The claims
+// are also available with TokenClaims.
+//
+// The requests whose token is missing or not active are replied to with an
+// Unauthenticated error, i.e. 401 Unauthorized, and those whose token cannot
+// be introspected with an Unavailable error.
+func WithTokenIntrospection(introspection TokenIntrospection) ServeMuxOption {
+	i := &tokenIntrospector{
+		TokenIntrospection: introspection,
+		lru:                list.New(),
+		cache:              make(map[[sha256.Size]byte]*list.Element),
+	}
+	if i.Client == nil {
+		i.Client = http.DefaultClient
+	}
+	if i.CacheTTL == 0 {
+		i.CacheTTL = time.Minute
+	}
+	if i.CacheSize <= 0 {
+		i.CacheSize = defaultIntrospectionCacheSize
+	}
+	if i.ClaimKeys == nil {
+		i.ClaimKeys = map[string]string{
+			"sub":       "x-token-subject",
+			"scope":     "x-token-scope",
+			"client_id": "x-token-client-id",
+		}
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.introspector = i
+	}
+}
+
+type tokenClaimsKey struct{}
+
+// TokenClaims returns the claims of the introspected bearer token of the
+// request of ctx, as validated with WithTokenIntrospection.
+func TokenClaims(ctx context.Context) (map[string]interface{}, bool) {
+	claims, ok := ctx.Value(tokenClaimsKey{}).(map[string]interface{})
+	return claims, ok
+}
+
+type tokenIntrospector struct {
+	TokenIntrospection
+
+	mu sync.Mutex
+	// lru holds the *introspectedToken of the cached tokens, the most
+	// recently used first.
+	lru *list.List
+	// cache holds the elements of lru by the hash of their token.
+	cache map[[sha256.Size]byte]*list.Element
+}
+
+type introspectedToken struct {
+	key     [sha256.Size]byte
+	claims  map[string]interface{}
+	expires time.Time
+}
+
+// introspect returns the claims of token if it is active.
+func (i *tokenIntrospector) introspect(ctx context.Context, token string) (map[string]interface{}, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	if i.CacheTTL > 0 {
+		if claims, ok := i.cached(key, now); ok {
+			return claims, nil
+		}
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest(http.MethodPost, i.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to introspect the token: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if i.ClientID != "" {
+		req.SetBasicAuth(i.ClientID, i.ClientSecret)
+	}
+	resp, err := i.Client.Do(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to introspect the token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, status.Errorf(codes.Unavailable, "failed to introspect the token: %s", resp.Status)
+	}
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to introspect the token: %v", err)
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, status.Error(codes.Unauthenticated, "inactive bearer token")
+	}
+
+	if i.CacheTTL > 0 {
+		expires := now.Add(i.CacheTTL)
+		if exp, ok := claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(expires) {
+			expires = time.Unix(int64(exp), 0)
+		}
+		i.store(&introspectedToken{key: key, claims: claims, expires: expires})
+	}
+	return claims, nil
+}
+
+// cached returns the claims of the cached token hashed as key, unless it has
+// expired by now.
+func (i *tokenIntrospector) cached(key [sha256.Size]byte, now time.Time) (map[string]interface{}, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	e, ok := i.cache[key]
+	if !ok {
+		return nil, false
+	}
+	token := e.Value.(*introspectedToken)
+	if !now.Before(token.expires) {
+		i.lru.Remove(e)
+		delete(i.cache, key)
+		return nil, false
+	}
+	i.lru.MoveToFront(e)
+	return token.claims, true
+}
+
+// store caches token, evicting the least recently used tokens beyond
+// CacheSize.
+func (i *tokenIntrospector) store(token *introspectedToken) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if e, ok := i.cache[token.key]; ok {
+		e.Value = token
+		i.lru.MoveToFront(e)
+		return
+	}
+	i.cache[token.key] = i.lru.PushFront(token)
+	for i.lru.Len() > i.CacheSize {
+		evicted := i.lru.Remove(i.lru.Back()).(*introspectedToken)
+		delete(i.cache, evicted.key)
+	}
+}
+
+// pairs returns the metadata pairs forwarding the claims of the token of the
+// request of ctx.
+func (i *tokenIntrospector) pairs(ctx context.Context) []string {
+	claims, ok := TokenClaims(ctx)
+	if !ok {
+		return nil
+	}
+	var pairs []string
+	for claim, key := range i.ClaimKeys {
+		switch v := claims[claim].(type) {
+		case nil:
+		case string:
+			pairs = append(pairs, key, v)
+		case []interface{}:
+			for _, e := range v {
+				pairs = append(pairs, key, fmt.Sprint(e))
+			}
+		case float64:
+			pairs = append(pairs, key, fmt.Sprint(int64(v)))
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				grpclog.Infof("Failed to marshal the claim %q: %v", claim, err)
+				continue
+			}
+			pairs = append(pairs, key, string(b))
+		}
+	}
+	return pairs
+}
+
+// forwards reports whether key is the metadata key of a claim forwarded by i.
+func (i *tokenIntrospector) forwards(key string) bool {
+	for _, k := range i.ClaimKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkToken replies with an error and returns false if the bearer token of r
+// is missing or not active, if the mux introspects tokens. It returns r with
+// the claims of its token in its context otherwise.
+func (s *ServeMux) checkToken(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.introspector == nil {
+		return r, true
+	}
+	var err error
+	token := bearerToken(r)
+	if token == "" {
+		if s.introspector.Optional {
+			return r, true
+		}
+		err = status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	if err == nil {
+		var claims map[string]interface{}
+		if claims, err = s.introspector.introspect(r.Context(), token); err == nil {
+			return r.WithContext(context.WithValue(r.Context(), tokenClaimsKey{}, claims)), true
+		}
+	}
+	_, outboundMarshaler := MarshalerForRequest(s, r)
+	HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+	return r, false
+}
+
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(auth[len(prefix):])
+}
@@ -0,0 +1,152 @@
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServeMuxTokenIntrospection(t *testing.T) {
+	var introspections int
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections++
+		if user, pass, ok := r.BasicAuth(); !ok || user != "gateway" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostFormValue("token") != "valid" {
+			fmt.Fprint(w, `{"active":false}`)
+			return
+		}
+		fmt.Fprint(w, `{"active":true,"sub":"alice","scope":"read write","client_id":"app"}`)
+	}))
+	defer endpoint.Close()
+
+	mux := runtime.NewServeMux(runtime.WithTokenIntrospection(runtime.TokenIntrospection{
+		Endpoint:     endpoint.URL,
+		ClientID:     "gateway",
+		ClientSecret: "secret",
+	}))
+	var md metadata.MD
+	var subject interface{}
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		claims, _ := runtime.TokenClaims(r.Context())
+		subject = claims["sub"]
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/ListBooks")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		md, _ = metadata.FromOutgoingContext(ctx)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+
+	for _, spec := range []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "missing token", status: http.StatusUnauthorized},
+		{name: "inactive token", authorization: "Bearer revoked", status: http.StatusUnauthorized},
+		{name: "active token", authorization: "Bearer valid", status: http.StatusOK},
+		{name: "cached token", authorization: "bearer valid", status: http.StatusOK},
+	} {
+		r := httptest.NewRequest("GET", "/v1/books", nil)
+		if spec.authorization != "" {
+			r.Header.Set("Authorization", spec.authorization)
+		}
+		r.Header.Set("Grpc-Metadata-X-Token-Subject", "admin")
+		r.Header.Set("Grpc-Metadata-X-Token-Scope", "admin")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != spec.status {
+			t.Errorf("%s: w.Code = %d; want %d", spec.name, w.Code, spec.status)
+		}
+	}
+
+	if introspections != 2 {
+		t.Errorf("introspections = %d; want 2", introspections)
+	}
+	if subject != "alice" {
+		t.Errorf(`runtime.TokenClaims(ctx)["sub"] = %v; want "alice"`, subject)
+	}
+	for key, want := range map[string][]string{
+		"x-token-subject":   {"alice"},
+		"x-token-scope":     {"read write"},
+		"x-token-client-id": {"app"},
+	} {
+		if got := md[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("md[%q] = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestServeMuxTokenIntrospectionOptional(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithTokenIntrospection(runtime.TokenIntrospection{
+		Endpoint: "http://127.0.0.1:0",
+		Optional: true,
+	}))
+	var md metadata.MD
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/ListBooks")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		md, _ = metadata.FromOutgoingContext(ctx)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+
+	r := httptest.NewRequest("GET", "/v1/books", nil)
+	r.Header.Set("Grpc-Metadata-X-Token-Subject", "admin")
+	r.Header.Set("Grpc-Metadata-X-Token-Client-Id", "app")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("w.Code = %d; want %d", w.Code, http.StatusOK)
+	}
+	for _, key := range []string{"x-token-subject", "x-token-client-id"} {
+		if got := md[key]; got != nil {
+			t.Errorf("md[%q] = %q; want none", key, got)
+		}
+	}
+}
+
+func TestServeMuxTokenIntrospectionCacheSize(t *testing.T) {
+	introspections := make(map[string]int)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections[r.PostFormValue("token")]++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"active":true,"sub":"alice"}`)
+	}))
+	defer endpoint.Close()
+
+	mux := runtime.NewServeMux(runtime.WithTokenIntrospection(runtime.TokenIntrospection{
+		Endpoint:  endpoint.URL,
+		CacheSize: 2,
+	}))
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+
+	// "b" is the least recently used token when "c" is cached, and is
+	// evicted.
+	for _, token := range []string{"a", "b", "a", "c", "a", "b"} {
+		r := httptest.NewRequest("GET", "/v1/books", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("w.Code = %d; want %d", w.Code, http.StatusOK)
+		}
+	}
+	if want := map[string]int{"a": 1, "b": 2, "c": 1}; !reflect.DeepEqual(introspections, want) {
+		t.Errorf("introspections = %v; want %v", introspections, want)
+	}
+}
@@ -0,0 +1,146 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"google.golang.org/grpc/grpclog"
+)
+
+// maxJSONPCallbackLength is the length of the longest JSONP callback name.
+const maxJSONPCallbackLength = 128
+
+// jsonpCallbackPattern matches the JSONP callback names, JavaScript
+// identifiers possibly qualified, e.g. "widgets.render", so that they cannot
+// inject script.
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+var (
+	lineSeparator      = []byte("\u2028")
+	paragraphSeparator = []byte("\u2029")
+)
+
+// WithJSONP returns a ServeMuxOption honoring the query parameter param,
+// "callback" if empty, of the GET requests, e.g. "?callback=render", by
+// wrapping their JSON responses in a call to the function it names, e.g.
+// `/**/render({...});`, for the legacy embedders loading them with script
+// tags.
+//
+// The wrapped responses have the "application/javascript" Content-Type and
+// the "X-Content-Type-Options: nosniff" header, and keep their status. The
+// parameter is removed from the query of the requests, and those with an
+// invalid callback name are rejected with InvalidArgument. The server streams
+// are not wrapped.
+//
+// As any page can then read the responses to the requests it makes on behalf
+// of its visitors, JSONP is only to be enabled for public data.
+func WithJSONP(param string) ServeMuxOption {
+	if param == "" {
+		param = "callback"
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.jsonpParam = param
+	}
+}
+
+// jsonp returns h wrapping the JSON responses of the GET requests with a JSONP
+// callback in a call to it, if the mux honors them.
+func (s *ServeMux) jsonp(h HandlerFunc) HandlerFunc {
+	if s.jsonpParam == "" {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if r.Method != http.MethodGet {
+			h(w, r, pathParams)
+			return
+		}
+		query := r.URL.Query()
+		values, ok := query[s.jsonpParam]
+		if !ok {
+			h(w, r, pathParams)
+			return
+		}
+		callback := values[0]
+		var err error
+		switch {
+		case len(values) > 1:
+			err = errors.New("multiple values")
+		case len(callback) > maxJSONPCallbackLength || !jsonpCallbackPattern.MatchString(callback):
+			err = errors.New("invalid callback name")
+		}
+		if err != nil {
+			_, outboundMarshaler := MarshalerForRequest(s, r)
+			HTTPError(r.Context(), s, outboundMarshaler, w, r, FieldViolationError(s.jsonpParam, err))
+			return
+		}
+		query.Del(s.jsonpParam)
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		r2 := *r
+		r2.URL = &u
+
+		jw := &jsonpWriter{ResponseWriter: w, callback: callback}
+		h(jw, &r2, pathParams)
+		if jw.wrapped {
+			if _, err := w.Write([]byte(");")); err != nil {
+				grpclog.Infof("Failed to write JSONP callback: %v", err)
+			}
+		}
+	}
+}
+
+// jsonpWriter is a response writer wrapping the JSON body of the response in
+// a call to callback.
+type jsonpWriter struct {
+	http.ResponseWriter
+	callback string
+
+	wroteHeader bool
+	// wrapped is set once the call is opened.
+	wrapped bool
+}
+
+func (w *jsonpWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if isJSONContentType(h.Get("Content-Type")) && h.Get("Transfer-Encoding") != "chunked" {
+		h.Set("Content-Type", "application/javascript; charset=utf-8")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Del("Content-Length")
+		w.ResponseWriter.WriteHeader(status)
+		// The comment keeps the body from starting with bytes of the
+		// callback, which content sniffers may take for another format.
+		if _, err := w.ResponseWriter.Write([]byte("/**/" + w.callback + "(")); err != nil {
+			grpclog.Infof("Failed to write JSONP callback: %v", err)
+		}
+		w.wrapped = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *jsonpWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if !w.wrapped || !bytes.Contains(p, lineSeparator) && !bytes.Contains(p, paragraphSeparator) {
+		return w.ResponseWriter.Write(p)
+	}
+	// The separators are valid in JSON strings but not in those of the
+	// older JavaScript engines.
+	escaped := bytes.Replace(p, lineSeparator, []byte(`\u2028`), -1)
+	escaped = bytes.Replace(escaped, paragraphSeparator, []byte(`\u2029`), -1)
+	if _, err := w.ResponseWriter.Write(escaped); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *jsonpWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
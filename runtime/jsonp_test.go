@@ -0,0 +1,110 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+)
+
+func TestWithJSONP(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithJSONP(""))
+	var query string
+	for _, meth := range []string{"GET", "POST"} {
+		if err := mux.HandlePath(meth, "/v1/example", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			query = r.URL.RawQuery
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, r, &pb.SimpleMessage{Id: "foo\u2028bar"})
+		}); err != nil {
+			t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", meth, "/v1/example", err)
+		}
+	}
+
+	for _, spec := range []struct {
+		method          string
+		target          string
+		wantCode        int
+		wantQuery       string
+		wantContentType string
+		wantPrefix      string
+	}{
+		{
+			method:          "GET",
+			target:          "/v1/example?callback=widgets.render&page_size=10",
+			wantCode:        http.StatusOK,
+			wantQuery:       "page_size=10",
+			wantContentType: "application/javascript; charset=utf-8",
+			wantPrefix:      "/**/widgets.render(",
+		},
+		{
+			method:          "GET",
+			target:          "/v1/example?page_size=10",
+			wantCode:        http.StatusOK,
+			wantQuery:       "page_size=10",
+			wantContentType: "application/json",
+			wantPrefix:      "{",
+		},
+		{
+			// Only the GET requests are wrapped.
+			method:          "POST",
+			target:          "/v1/example?callback=render",
+			wantCode:        http.StatusOK,
+			wantQuery:       "callback=render",
+			wantContentType: "application/json",
+			wantPrefix:      "{",
+		},
+		{
+			method:   "GET",
+			target:   "/v1/example?callback=alert(1)//",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			method:   "GET",
+			target:   "/v1/example?callback=a&callback=b",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			method:   "GET",
+			target:   "/v1/example?callback=" + strings.Repeat("a", 129),
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		query = ""
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(spec.method, spec.target, nil))
+
+		if w.Code != spec.wantCode {
+			t.Errorf("%s %s: w.Code = %d; want %d", spec.method, spec.target, w.Code, spec.wantCode)
+			continue
+		}
+		if spec.wantCode != http.StatusOK {
+			continue
+		}
+		if query != spec.wantQuery {
+			t.Errorf("%s %s: query = %q; want %q", spec.method, spec.target, query, spec.wantQuery)
+		}
+		if got := w.Header().Get("Content-Type"); got != spec.wantContentType {
+			t.Errorf("%s %s: Content-Type = %q; want %q", spec.method, spec.target, got, spec.wantContentType)
+		}
+		body := w.Body.String()
+		if !strings.HasPrefix(body, spec.wantPrefix) {
+			t.Errorf("%s %s: w.Body = %q; want the prefix %q", spec.method, spec.target, body, spec.wantPrefix)
+		}
+		if spec.wantPrefix == "{" {
+			continue
+		}
+		if got, want := w.Header().Get("X-Content-Type-Options"), "nosniff"; got != want {
+			t.Errorf("%s %s: X-Content-Type-Options = %q; want %q", spec.method, spec.target, got, want)
+		}
+		if !strings.HasSuffix(body, ");") {
+			t.Errorf("%s %s: w.Body = %q; want the suffix %q", spec.method, spec.target, body, ");")
+		}
+		if !strings.Contains(body, `foo\u2028bar`) {
+			t.Errorf("%s %s: w.Body = %q; want the line separator escaped", spec.method, spec.target, body)
+		}
+	}
+}
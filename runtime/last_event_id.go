@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"net/http"
+)
+
+// lastEventIDHeader is the header of the clients reconnecting to an event
+// stream with the ID of the last event they received.
+const lastEventIDHeader = "Last-Event-Id"
+
+// LastEventID configures the forwarding of the Last-Event-ID header of the
+// requests, enabled with WithLastEventID.
+type LastEventID struct {
+	// MetadataKey is the gRPC metadata key the header is forwarded as,
+	// "last-event-id" if empty.
+	MetadataKey string
+	// Field is the path of the request field set to the header if set, e.g.
+	// "resume_token", as if it were given as a query parameter. An actual
+	// query parameter setting the field takes precedence.
+	Field string
+}
+
+// WithLastEventID returns a ServeMuxOption forwarding the Last-Event-ID
+// header of the requests to the backends, so that the clients reconnecting
+// to a server stream can resume it from the last event they received, as
+// event stream clients do.
+func WithLastEventID(lastEventID LastEventID) ServeMuxOption {
+	if lastEventID.MetadataKey == "" {
+		lastEventID.MetadataKey = "last-event-id"
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.lastEventID = &lastEventID
+	}
+}
+
+// pairs returns the metadata pairs forwarding the Last-Event-ID header of req.
+func (l *LastEventID) pairs(req *http.Request) []string {
+	id := req.Header.Get(lastEventIDHeader)
+	if id == "" {
+		return nil
+	}
+	return []string{l.MetadataKey, id}
+}
+
+// withLastEventID returns r with its Last-Event-ID header as the query
+// parameter of the request field of the mux, if any.
+func (s *ServeMux) withLastEventID(r *http.Request) *http.Request {
+	if s.lastEventID == nil || s.lastEventID.Field == "" {
+		return r
+	}
+	id := r.Header.Get(lastEventIDHeader)
+	if id == "" {
+		return r
+	}
+	query := r.URL.Query()
+	if _, ok := query[s.lastEventID.Field]; ok {
+		return r
+	}
+	query.Set(s.lastEventID.Field, id)
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	r2 := *r
+	r2.URL = &u
+	return &r2
+}
@@ -0,0 +1,80 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithLastEventID(t *testing.T) {
+	for _, spec := range []struct {
+		name        string
+		lastEventID runtime.LastEventID
+		target      string
+		header      string
+		wantQuery   string
+		wantMD      []string
+	}{
+		{
+			name:      "metadata",
+			target:    "/v1/events",
+			header:    "42",
+			wantQuery: "",
+			wantMD:    []string{"42"},
+		},
+		{
+			name:        "request field",
+			lastEventID: runtime.LastEventID{Field: "resume_token"},
+			target:      "/v1/events?page_size=10",
+			header:      "42",
+			wantQuery:   "page_size=10&resume_token=42",
+			wantMD:      []string{"42"},
+		},
+		{
+			name:        "query parameter precedence",
+			lastEventID: runtime.LastEventID{Field: "resume_token"},
+			target:      "/v1/events?resume_token=7",
+			header:      "42",
+			wantQuery:   "resume_token=7",
+			wantMD:      []string{"42"},
+		},
+		{
+			name:        "no header",
+			lastEventID: runtime.LastEventID{Field: "resume_token"},
+			target:      "/v1/events",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithLastEventID(spec.lastEventID))
+			var query string
+			var md metadata.MD
+			if err := mux.HandlePath("GET", "/v1/events", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				query = r.URL.RawQuery
+				ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/WatchEvents")
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+				}
+				md, _ = metadata.FromOutgoingContext(ctx)
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/events", err)
+			}
+			r := httptest.NewRequest("GET", spec.target, nil)
+			if spec.header != "" {
+				r.Header.Set("Last-Event-ID", spec.header)
+			}
+
+			mux.ServeHTTP(httptest.NewRecorder(), r)
+
+			if query != spec.wantQuery {
+				t.Errorf("r.URL.RawQuery = %q; want %q", query, spec.wantQuery)
+			}
+			if got := md.Get("last-event-id"); !reflect.DeepEqual(got, spec.wantMD) {
+				t.Errorf(`md.Get("last-event-id") = %q; want %q`, got, spec.wantMD)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// LocalServerStream is the server stream of a server streaming method called
+// in process, handing the messages the method sends over to the gateway
+// handler receiving them.
+// It should only be used by the generated files to support server streaming
+// methods outside of gRPC server use.
+type LocalServerStream struct {
+	ctx  context.Context
+	msgs chan proto.Message
+	// headerSent is closed once the header is sent, and done once the method
+	// returned err.
+	headerSent chan struct{}
+	headerOnce sync.Once
+	done       chan struct{}
+	err        error
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+// NewLocalServerStream returns a stream of a method called with ctx.
+func NewLocalServerStream(ctx context.Context) *LocalServerStream {
+	return &LocalServerStream{
+		ctx:        ctx,
+		msgs:       make(chan proto.Message),
+		headerSent: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start calls method, sending its messages to s, in a goroutine. It returns
+// once the method sends its header or its first message, or returns, with the
+// header metadata or the error the method returned.
+func (s *LocalServerStream) Start(method func() error) (metadata.MD, error) {
+	go func() {
+		s.err = method()
+		close(s.done)
+		s.sendHeader()
+	}()
+	<-s.headerSent
+	select {
+	case <-s.done:
+		if s.err != nil {
+			return s.Header(), s.err
+		}
+	default:
+	}
+	return s.Header(), nil
+}
+
+// Recv returns the next message sent by the method, io.EOF once the method
+// returned successfully or the error it returned.
+func (s *LocalServerStream) Recv() (proto.Message, error) {
+	select {
+	case msg := <-s.msgs:
+		return msg, nil
+	case <-s.done:
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+}
+
+// Header returns the header metadata of the stream.
+func (s *LocalServerStream) Header() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header.Copy()
+}
+
+// Trailer returns the trailer metadata of the stream.
+func (s *LocalServerStream) Trailer() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trailer.Copy()
+}
+
+// SetHeader sets the header metadata, failing once it is sent.
+func (s *LocalServerStream) SetHeader(md metadata.MD) error {
+	select {
+	case <-s.headerSent:
+		return errors.New("the header was already sent")
+	default:
+	}
+	s.mu.Lock()
+	s.header = metadata.Join(s.header, md)
+	s.mu.Unlock()
+	return nil
+}
+
+// SendHeader sends the header metadata.
+func (s *LocalServerStream) SendHeader(md metadata.MD) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+	s.sendHeader()
+	return nil
+}
+
+// SetTrailer sets the trailer metadata.
+func (s *LocalServerStream) SetTrailer(md metadata.MD) {
+	s.mu.Lock()
+	s.trailer = metadata.Join(s.trailer, md)
+	s.mu.Unlock()
+}
+
+// Context returns the context of the method.
+func (s *LocalServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg sends a copy of the message m to the gateway handler, blocking
+// until it is received or the context of the method is done.
+func (s *LocalServerStream) SendMsg(m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return errors.New("the message is not a proto.Message")
+	}
+	s.sendHeader()
+	select {
+	case s.msgs <- proto.Clone(msg):
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// RecvMsg returns io.EOF, the request of the method being given to it
+// directly.
+func (s *LocalServerStream) RecvMsg(m interface{}) error {
+	return io.EOF
+}
+
+func (s *LocalServerStream) sendHeader() {
+	s.headerOnce.Do(func() { close(s.headerSent) })
+}
@@ -0,0 +1,95 @@
+package runtime_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLocalServerStream(t *testing.T) {
+	stream := runtime.NewLocalServerStream(context.Background())
+	header, err := stream.Start(func() error {
+		if err := stream.SendHeader(metadata.Pairs("foo", "bar")); err != nil {
+			return err
+		}
+		if err := stream.SetHeader(metadata.Pairs("baz", "qux")); err == nil {
+			t.Errorf("stream.SetHeader() succeeded after the header was sent; want an error")
+		}
+		for _, v := range []string{"a", "b"} {
+			if err := stream.SendMsg(&wrapperspb.StringValue{Value: v}); err != nil {
+				return err
+			}
+		}
+		stream.SetTrailer(metadata.Pairs("trailer", "value"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream.Start() failed with %v; want success", err)
+	}
+	if got, want := header.Get("foo"), []string{"bar"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("header.Get(%q) = %q; want %q", "foo", got, want)
+	}
+
+	for _, v := range []string{"a", "b"} {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("stream.Recv() failed with %v; want success", err)
+		}
+		if want := (&wrapperspb.StringValue{Value: v}); !proto.Equal(msg, want) {
+			t.Errorf("stream.Recv() = %v; want %v", msg, want)
+		}
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("stream.Recv() failed with %v; want io.EOF", err)
+	}
+	if got := stream.Trailer().Get("trailer"); len(got) != 1 || got[0] != "value" {
+		t.Errorf("stream.Trailer().Get(%q) = %q; want [value]", "trailer", got)
+	}
+}
+
+func TestLocalServerStream_Error(t *testing.T) {
+	stream := runtime.NewLocalServerStream(context.Background())
+	_, err := stream.Start(func() error {
+		return status.Error(codes.NotFound, "not found")
+	})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Errorf("status.Code(stream.Start()) = %v; want %v", got, want)
+	}
+
+	stream = runtime.NewLocalServerStream(context.Background())
+	if _, err := stream.Start(func() error {
+		if err := stream.SendMsg(&wrapperspb.StringValue{Value: "a"}); err != nil {
+			return err
+		}
+		return status.Error(codes.Internal, "failed")
+	}); err != nil {
+		t.Fatalf("stream.Start() failed with %v; want success", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("stream.Recv() failed with %v; want success", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Errorf("stream.Recv() failed with %v; want %v", err, codes.Internal)
+	}
+}
+
+func TestLocalServerStream_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := runtime.NewLocalServerStream(ctx)
+	if _, err := stream.Start(func() error {
+		return stream.SendMsg(&wrapperspb.StringValue{Value: "a"})
+	}); err != nil {
+		t.Fatalf("stream.Start() failed with %v; want success", err)
+	}
+	cancel()
+	if _, err := stream.Recv(); err != context.Canceled && err != nil {
+		t.Errorf("stream.Recv() failed with %v; want %v or a message", err, context.Canceled)
+	}
+}
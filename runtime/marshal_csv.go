@@ -0,0 +1,255 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CSVMarshaler is a Marshaler writing the list responses as CSV, or TSV, for
+// the export endpoints consumed by spreadsheets, e.g. registered with
+// WithMarshalerOption for "text/csv" so that the clients accepting it get
+// their responses in CSV.
+//
+// The rows are the messages of the repeated message field of the responses,
+// e.g. the books of a ListBooksResponse, or the responses themselves if they
+// have none. The first row is the header of the columns, which are the paths
+// of the fields of the rows. The errors, google.rpc.Status messages, are
+// written as a row.
+//
+// The strings, numbers and booleans are written as is, the enums by name and
+// the bytes in base64. The messages, the repeated fields and the maps are
+// written in JSON. CSVMarshaler does not unmarshal, nor write the server
+// streams.
+type CSVMarshaler struct {
+	// Comma is the field delimiter, ',' if zero, e.g. '\t' for TSV.
+	Comma rune
+	// RowsField is the name of the repeated message field of the rows, the
+	// first of the responses if empty.
+	RowsField string
+	// Columns are the paths of the fields of the columns, e.g.
+	// "author.name", in order. They are the fields of the rows other than
+	// the messages, the repeated fields and the maps if empty.
+	Columns []string
+}
+
+// ContentType returns "text/tab-separated-values" if the delimiter of m is a
+// tab, and "text/csv" otherwise.
+func (m *CSVMarshaler) ContentType(_ interface{}) string {
+	if m.Comma == '\t' {
+		return "text/tab-separated-values"
+	}
+	return "text/csv"
+}
+
+// Marshal marshals "v", a message or a slice of messages like the response
+// body of a method, into CSV.
+func (m *CSVMarshaler) Marshal(v interface{}) ([]byte, error) {
+	md, rows, err := m.rows(v)
+	if err != nil {
+		return nil, err
+	}
+	paths := m.Columns
+	if len(paths) == 0 {
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			if fd := fields.Get(i); !fd.IsList() && !fd.IsMap() && fd.Message() == nil {
+				paths = append(paths, string(fd.Name()))
+			}
+		}
+	}
+	columns := make([][]protoreflect.FieldDescriptor, len(paths))
+	for i, path := range paths {
+		if columns[i], err = csvColumn(md, path); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if m.Comma != 0 {
+		w.Comma = m.Comma
+	}
+	if err := w.Write(paths); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			if record[i], err = csvValue(row, column); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rows returns the rows of v and their descriptor.
+func (m *CSVMarshaler) rows(v interface{}) (protoreflect.MessageDescriptor, []protoreflect.Message, error) {
+	if p, ok := v.(proto.Message); ok {
+		msg := p.ProtoReflect()
+		md := msg.Descriptor()
+		if md.FullName() == "google.rpc.Status" {
+			return md, []protoreflect.Message{msg}, nil
+		}
+		fd, err := m.rowsField(md)
+		if err != nil || fd == nil {
+			return md, []protoreflect.Message{msg}, err
+		}
+		list := msg.Get(fd).List()
+		rows := make([]protoreflect.Message, list.Len())
+		for i := range rows {
+			rows[i] = list.Get(i).Message()
+		}
+		return fd.Message(), rows, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Slice || !rv.Type().Elem().Implements(protoMessageType) {
+		return nil, nil, fmt.Errorf("cannot marshal %T as CSV", v)
+	}
+	md := reflect.Zero(rv.Type().Elem()).Interface().(proto.Message).ProtoReflect().Descriptor()
+	rows := make([]protoreflect.Message, rv.Len())
+	for i := range rows {
+		rows[i] = rv.Index(i).Interface().(proto.Message).ProtoReflect()
+	}
+	return md, rows, nil
+}
+
+// rowsField returns the repeated message field of the rows of the messages of
+// md, or nil if they have none.
+func (m *CSVMarshaler) rowsField(md protoreflect.MessageDescriptor) (protoreflect.FieldDescriptor, error) {
+	fields := md.Fields()
+	if m.RowsField != "" {
+		fd := fields.ByName(protoreflect.Name(m.RowsField))
+		if fd == nil || !fd.IsList() || fd.Message() == nil {
+			return nil, fmt.Errorf("no repeated message field %q in %s", m.RowsField, md.FullName())
+		}
+		return fd, nil
+	}
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); fd.IsList() && fd.Message() != nil {
+			return fd, nil
+		}
+	}
+	return nil, nil
+}
+
+// csvColumn returns the fields of path from the messages of md, all singular
+// messages but the last.
+func csvColumn(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	var column []protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil, fmt.Errorf("invalid CSV column %q: %s is not a message", path, column[len(column)-1].FullName())
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("invalid CSV column %q: no field %q in %s", path, name, md.FullName())
+		}
+		column = append(column, fd)
+		md = nil
+		if !fd.IsList() && !fd.IsMap() {
+			md = fd.Message()
+		}
+	}
+	return column, nil
+}
+
+// csvValue returns the value of column in row, empty if a message of its
+// path is not set.
+func csvValue(row protoreflect.Message, column []protoreflect.FieldDescriptor) (string, error) {
+	for _, fd := range column[:len(column)-1] {
+		if !row.Has(fd) {
+			return "", nil
+		}
+		row = row.Get(fd).Message()
+	}
+	fd := column[len(column)-1]
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil {
+		if !row.Has(fd) {
+			return "", nil
+		}
+		// The field is marshaled as the only one of a message.
+		msg := row.New()
+		msg.Set(fd, row.Get(fd))
+		b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg.Interface())
+		if err != nil {
+			return "", err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, fields[string(fd.Name())]); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	v := row.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return v.String(), nil
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool()), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return strconv.Itoa(int(v.Enum())), nil
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+	return strconv.FormatInt(v.Int(), 10), nil
+}
+
+// Unmarshal always fails, as CSVMarshaler does not unmarshal.
+func (*CSVMarshaler) Unmarshal(_ []byte, _ interface{}) error {
+	return errors.New("unable to unmarshal CSV")
+}
+
+// NewDecoder returns a Decoder which always fails, as CSVMarshaler does not
+// unmarshal.
+func (m *CSVMarshaler) NewDecoder(_ io.Reader) Decoder {
+	return DecoderFunc(func(v interface{}) error {
+		return m.Unmarshal(nil, v)
+	})
+}
+
+// NewEncoder returns an Encoder which writes CSV into "w".
+func (m *CSVMarshaler) NewEncoder(w io.Writer) Encoder {
+	return EncoderFunc(func(v interface{}) error {
+		b, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+}
@@ -0,0 +1,127 @@
+package runtime_test
+
+import (
+	"testing"
+
+	timestamppb "github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCSVMarshaler(t *testing.T) {
+	list := &examplepb.ABitOfEverything{
+		Uuid: "list",
+		Nested: []*examplepb.ABitOfEverything_Nested{
+			{Name: "foo", Amount: 1, Ok: examplepb.ABitOfEverything_Nested_TRUE},
+			{Name: "bar, baz", Amount: 2},
+		},
+	}
+	rows := []*examplepb.ABitOfEverything{
+		{
+			Uuid:                "one",
+			SingleNested:        &examplepb.ABitOfEverything_Nested{Name: "foo"},
+			RepeatedStringValue: []string{"a", "b"},
+			BytesValue:          []byte("xyz"),
+			TimestampValue:      &timestamppb.Timestamp{Seconds: 1},
+		},
+		{
+			Uuid: "two",
+		},
+	}
+	for _, spec := range []struct {
+		name string
+		m    *runtime.CSVMarshaler
+		v    interface{}
+		want string
+	}{
+		{
+			name: "repeated field",
+			m:    &runtime.CSVMarshaler{},
+			v:    list,
+			want: "name,amount,ok\nfoo,1,TRUE\n\"bar, baz\",2,FALSE\n",
+		},
+		{
+			name: "tsv",
+			m:    &runtime.CSVMarshaler{Comma: '\t', Columns: []string{"ok", "name"}},
+			v:    list,
+			want: "ok\tname\nTRUE\tfoo\nFALSE\tbar, baz\n",
+		},
+		{
+			name: "response body",
+			m: &runtime.CSVMarshaler{Columns: []string{
+				"uuid", "single_nested.name", "repeated_string_value", "bytes_value", "timestamp_value",
+			}},
+			v: rows,
+			want: "uuid,single_nested.name,repeated_string_value,bytes_value,timestamp_value\n" +
+				"one,foo,\"[\"\"a\"\",\"\"b\"\"]\",eHl6,\"\"\"1970-01-01T00:00:01Z\"\"\"\n" +
+				"two,,,,\n",
+		},
+		{
+			name: "empty",
+			m:    &runtime.CSVMarshaler{RowsField: "nested"},
+			v:    &examplepb.ABitOfEverything{},
+			want: "name,amount,ok\n",
+		},
+		{
+			name: "error",
+			m:    &runtime.CSVMarshaler{},
+			v:    status.New(codes.NotFound, "not found").Proto(),
+			want: "code,message\n5,not found\n",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			b, err := spec.m.Marshal(spec.v)
+			if err != nil {
+				t.Fatalf("m.Marshal(%v) failed with %v; want success", spec.v, err)
+			}
+			if got := string(b); got != spec.want {
+				t.Errorf("m.Marshal(%v) = %q; want %q", spec.v, got, spec.want)
+			}
+		})
+	}
+}
+
+func TestCSVMarshalerErrors(t *testing.T) {
+	for _, spec := range []struct {
+		name string
+		m    *runtime.CSVMarshaler
+		v    interface{}
+	}{
+		{
+			name: "unknown column",
+			m:    &runtime.CSVMarshaler{Columns: []string{"unknown"}},
+			v:    &examplepb.ABitOfEverything{},
+		},
+		{
+			name: "column through a repeated field",
+			m:    &runtime.CSVMarshaler{Columns: []string{"nested.name"}},
+			v:    []*examplepb.ABitOfEverything{},
+		},
+		{
+			name: "unknown rows field",
+			m:    &runtime.CSVMarshaler{RowsField: "uuid"},
+			v:    &examplepb.ABitOfEverything{},
+		},
+		{
+			name: "not a message",
+			m:    &runtime.CSVMarshaler{},
+			v:    map[string]interface{}{"result": "foo"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			if b, err := spec.m.Marshal(spec.v); err == nil {
+				t.Errorf("m.Marshal(%v) = %q; want an error", spec.v, b)
+			}
+		})
+	}
+
+	m := &runtime.CSVMarshaler{}
+	if got, want := m.ContentType(nil), "text/csv"; got != want {
+		t.Errorf("m.ContentType(nil) = %q; want %q", got, want)
+	}
+	if err := m.Unmarshal([]byte("a,b\n"), &examplepb.ABitOfEverything{}); err == nil {
+		t.Errorf("m.Unmarshal(data, msg) succeeded; want an error")
+	}
+}
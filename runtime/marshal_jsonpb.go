@@ -9,6 +9,7 @@ import (
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // JSONPb is a Marshaler which marshals/unmarshals into/from JSON
@@ -20,6 +21,28 @@ import (
 type JSONPb struct {
 	protojson.MarshalOptions
 	protojson.UnmarshalOptions
+	// DecodeWindow is the size in bytes of the input the decoders of
+	// NewDecoder buffer at once, if positive, e.g. to bound the memory of the
+	// requests with large bodies. Their proto messages are then decoded token
+	// by token, and fail to decode if a value which is not a message, a list
+	// or a map, e.g. a string, is larger than the window.
+	DecodeWindow int
+	// Int64AsNumbers marshals the values of the 64-bit integer fields of the
+	// messages as JSON numbers instead of strings, for the clients parsing
+	// them as 64-bit integers natively. The values past 2^53 lose precision
+	// in the clients parsing the JSON numbers as doubles, e.g. JavaScript.
+	Int64AsNumbers bool
+	// NonFinite is how the NaN and infinite values of the float and double
+	// fields of the messages are marshaled, NonFiniteString if empty.
+	NonFinite NonFiniteEncoding
+	// Bytes is how the values of the bytes fields of the messages are
+	// marshaled, BytesBase64 if empty.
+	Bytes BytesEncoding
+	// Codecs are the custom JSON codecs of the messages by type, e.g.
+	// "google.type.Money", applied to the messages of their type at any depth
+	// of the messages marshaled and unmarshaled, but not within the
+	// well-known types like google.protobuf.Any.
+	Codecs map[protoreflect.FullName]JSONCodec
 }
 
 // ContentType always returns "application/json".
@@ -54,6 +77,11 @@ func (j *JSONPb) marshalTo(w io.Writer, v interface{}) error {
 	if err != nil {
 		return err
 	}
+	if j.rewrites() {
+		if b, err = j.rewrite(b, p); err != nil {
+			return err
+		}
+	}
 
 	_, err = w.Write(b)
 	return err
@@ -137,28 +165,67 @@ func (j *JSONPb) marshalNonProtoField(v interface{}) ([]byte, error) {
 
 // Unmarshal unmarshals JSON "data" into "v"
 func (j *JSONPb) Unmarshal(data []byte, v interface{}) error {
+	if p, ok := v.(proto.Message); ok && len(j.Codecs) > 0 {
+		var err error
+		if data, err = j.canonical(data, p); err != nil {
+			return err
+		}
+	}
 	return unmarshalJSONPb(data, j.UnmarshalOptions, v)
 }
 
 // NewDecoder returns a Decoder which reads JSON stream from "r".
 func (j *JSONPb) NewDecoder(r io.Reader) Decoder {
+	if j.DecodeWindow > 0 {
+		w := &windowReader{r: r, window: int64(j.DecodeWindow), limit: int64(j.DecodeWindow)}
+		d := json.NewDecoder(w)
+		return DecoderWrapper{
+			Decoder:          d,
+			UnmarshalOptions: j.UnmarshalOptions,
+			window:           &windowDecoder{d: d, w: w, UnmarshalOptions: j.UnmarshalOptions, codecs: j.codecs()},
+		}
+	}
 	d := json.NewDecoder(r)
 	return DecoderWrapper{
 		Decoder:          d,
 		UnmarshalOptions: j.UnmarshalOptions,
+		codecs:           j.codecs(),
 	}
 }
 
+// codecs returns j if it has custom codecs, or nil.
+func (j *JSONPb) codecs() *JSONPb {
+	if len(j.Codecs) == 0 {
+		return nil
+	}
+	return j
+}
+
 // DecoderWrapper is a wrapper around a *json.Decoder that adds
 // support for protos to the Decode method.
 type DecoderWrapper struct {
 	*json.Decoder
 	protojson.UnmarshalOptions
+	// window decodes the protos token by token if the JSONPb has a decode
+	// window.
+	window *windowDecoder
+	// codecs is the JSONPb if it has custom codecs.
+	codecs *JSONPb
 }
 
 // Decode wraps the embedded decoder's Decode method to support
 // protos using a jsonpb.Unmarshaler.
 func (d DecoderWrapper) Decode(v interface{}) error {
+	if d.window != nil {
+		return d.window.decode(v)
+	}
+	if p, ok := v.(proto.Message); ok && d.codecs != nil {
+		var b json.RawMessage
+		if err := d.Decoder.Decode(&b); err != nil {
+			return err
+		}
+		return d.codecs.Unmarshal(b, p)
+	}
 	return decodeJSONPb(d.Decoder, d.UnmarshalOptions, v)
 }
 
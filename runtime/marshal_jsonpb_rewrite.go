@@ -0,0 +1,400 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NonFiniteEncoding is how JSONPb marshals the NaN and infinite values of the
+// float and double fields.
+type NonFiniteEncoding string
+
+const (
+	// NonFiniteString marshals them as the strings "NaN", "Infinity" and
+	// "-Infinity", as in the canonical JSON mapping.
+	NonFiniteString NonFiniteEncoding = "string"
+	// NonFiniteNull marshals them as null.
+	NonFiniteNull NonFiniteEncoding = "null"
+	// NonFiniteError fails to marshal the messages with one.
+	NonFiniteError NonFiniteEncoding = "error"
+)
+
+// BytesEncoding is how JSONPb marshals the values of the bytes fields.
+type BytesEncoding string
+
+const (
+	// BytesBase64 marshals them in standard base64 with padding, as in the
+	// canonical JSON mapping.
+	BytesBase64 BytesEncoding = "base64"
+	// BytesBase64URL marshals them in URL-safe base64 without padding, which
+	// JSONPb unmarshals as well.
+	BytesBase64URL BytesEncoding = "base64url"
+	// BytesHex marshals them in lower case hexadecimal, which JSONPb does not
+	// unmarshal.
+	BytesHex BytesEncoding = "hex"
+)
+
+// JSONCodec marshals and unmarshals the messages of a type with a custom JSON
+// representation, e.g. a google.type.Money as a decimal string or a date as
+// "YYYY-MM-DD", with the Codecs of JSONPb.
+type JSONCodec struct {
+	// Marshal returns the JSON of m. The messages are marshaled as in the
+	// canonical mapping if nil.
+	Marshal func(m proto.Message) ([]byte, error)
+	// Unmarshal unmarshals data, which is not null, into m, a new message.
+	// The messages are unmarshaled as in the canonical mapping if nil.
+	Unmarshal func(data []byte, m proto.Message) error
+}
+
+// rewrites returns whether the JSON of the messages marshaled by protojson
+// is to be rewritten for the options of j.
+func (j *JSONPb) rewrites() bool {
+	return j.Int64AsNumbers ||
+		(j.NonFinite != "" && j.NonFinite != NonFiniteString) ||
+		(j.Bytes != "" && j.Bytes != BytesBase64) ||
+		len(j.Codecs) > 0
+}
+
+// rewrite returns b, the JSON of m marshaled by protojson, with the values of
+// the fields of m rewritten for the options of j.
+func (j *JSONPb) rewrite(b []byte, m proto.Message) ([]byte, error) {
+	s := &jsonpbScanner{j: j, b: b, d: json.NewDecoder(bytes.NewReader(b))}
+	if err := s.message(m.ProtoReflect()); err != nil {
+		return nil, err
+	}
+	return s.apply(), nil
+}
+
+// canonical returns b, the JSON of a message of the type of m, with the
+// values of the messages with a custom codec in the canonical mapping, for
+// protojson to unmarshal it.
+func (j *JSONPb) canonical(b []byte, m proto.Message) ([]byte, error) {
+	s := &jsonpbScanner{j: j, b: b, d: json.NewDecoder(bytes.NewReader(b)), unmarshal: true}
+	if err := s.message(m.ProtoReflect().New()); err != nil {
+		return nil, err
+	}
+	return s.apply(), nil
+}
+
+// jsonEdit replaces the bytes from start to end with value.
+type jsonEdit struct {
+	start, end int
+	value      []byte
+}
+
+// jsonpbScanner scans the JSON of a message for the values to rewrite. The
+// well-known types other than the wrappers, e.g. google.protobuf.Any, are left
+// as they are.
+type jsonpbScanner struct {
+	j *JSONPb
+	b []byte
+	d *json.Decoder
+	// unmarshal is whether b is unmarshaled, in which case only the messages
+	// with a custom codec are rewritten, to the canonical mapping. The
+	// messages scanned are then new ones of their type.
+	unmarshal bool
+	// edits are the edits of b, in order.
+	edits []jsonEdit
+}
+
+// apply returns b with the edits of s.
+func (s *jsonpbScanner) apply() []byte {
+	if len(s.edits) == 0 {
+		return s.b
+	}
+	out := make([]byte, 0, len(s.b))
+	last := 0
+	for _, e := range s.edits {
+		out = append(out, s.b[last:e.start]...)
+		out = append(out, e.value...)
+		last = e.end
+	}
+	return append(out, s.b[last:]...)
+}
+
+func (s *jsonpbScanner) message(m protoreflect.Message) error {
+	md := m.Descriptor()
+	if codec, ok := s.j.Codecs[md.FullName()]; ok {
+		if s.unmarshal && codec.Unmarshal != nil || !s.unmarshal && codec.Marshal != nil {
+			return s.codec(m, codec)
+		}
+	}
+	if !s.unmarshal {
+		switch md.FullName() {
+		case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+			return s.integer()
+		case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+			return s.float(md.FullName())
+		case "google.protobuf.BytesValue":
+			return s.bytes()
+		}
+	}
+	if isWellKnownMessage(md) {
+		return s.skip()
+	}
+	tok, err := s.d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		return nil
+	case json.Delim('{'):
+	default:
+		return fmt.Errorf("unexpected token %v; want a JSON object for %s", tok, md.FullName())
+	}
+	fields := md.Fields()
+	for s.d.More() {
+		tok, err := s.d.Token()
+		if err != nil {
+			return err
+		}
+		fd := fieldByJSONKey(fields, tok.(string))
+		switch {
+		case fd == nil:
+			err = s.skip()
+		case fd.IsList():
+			err = s.list(m, fd)
+		case fd.IsMap():
+			err = s.mapValues(m, fd)
+		default:
+			err = s.value(fd, func() protoreflect.Value {
+				if s.unmarshal {
+					return m.NewField(fd)
+				}
+				return m.Get(fd)
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.d.Token()
+	return err
+}
+
+func (s *jsonpbScanner) list(m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	tok, err := s.d.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	for i := 0; s.d.More(); i++ {
+		if err := s.value(fd, func() protoreflect.Value {
+			if s.unmarshal {
+				return m.NewField(fd).List().NewElement()
+			}
+			return m.Get(fd).List().Get(i)
+		}); err != nil {
+			return err
+		}
+	}
+	_, err = s.d.Token()
+	return err
+}
+
+func (s *jsonpbScanner) mapValues(m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	tok, err := s.d.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	for s.d.More() {
+		// The keys remain strings, as JSON requires.
+		tok, err := s.d.Token()
+		if err != nil {
+			return err
+		}
+		if err := s.value(fd.MapValue(), func() protoreflect.Value {
+			if s.unmarshal {
+				return m.NewField(fd).Map().NewValue()
+			}
+			k, err := mapKey(fd.MapKey(), tok.(string))
+			if err != nil {
+				return protoreflect.Value{}
+			}
+			return m.Get(fd).Map().Get(k)
+		}); err != nil {
+			return err
+		}
+	}
+	_, err = s.d.Token()
+	return err
+}
+
+// value scans a value of fd, or an element of it if it is a list. v returns
+// the value, a message, if fd is a message field.
+func (s *jsonpbScanner) value(fd protoreflect.FieldDescriptor, v func() protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return s.integer()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return s.float(fd.FullName())
+	case protoreflect.BytesKind:
+		return s.bytes()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		m := v()
+		if !m.IsValid() {
+			return fmt.Errorf("no value of %s", fd.FullName())
+		}
+		return s.message(m.Message())
+	}
+	return s.skip()
+}
+
+// codec rewrites the JSON of m with codec, from the canonical mapping or to
+// it if s unmarshals.
+func (s *jsonpbScanner) codec(m protoreflect.Message, codec JSONCodec) error {
+	var raw json.RawMessage
+	if err := s.d.Decode(&raw); err != nil {
+		return err
+	}
+	if string(raw) == "null" {
+		return nil
+	}
+	end := int(s.d.InputOffset())
+	var b []byte
+	var err error
+	if s.unmarshal {
+		if err = codec.Unmarshal(raw, m.Interface()); err != nil {
+			return err
+		}
+		b, err = protojson.MarshalOptions{}.Marshal(m.Interface())
+	} else {
+		b, err = codec.Marshal(m.Interface())
+		if err == nil && !json.Valid(b) {
+			err = fmt.Errorf("invalid JSON %q of %s", b, m.Descriptor().FullName())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.edits = append(s.edits, jsonEdit{start: end - len(raw), end: end, value: b})
+	return nil
+}
+
+// integer unquotes the 64-bit integer value if Int64AsNumbers is set.
+func (s *jsonpbScanner) integer() error {
+	if s.unmarshal || !s.j.Int64AsNumbers {
+		return s.skip()
+	}
+	v, start, err := s.stringValue()
+	if err != nil || start < 0 {
+		return err
+	}
+	s.edits = append(s.edits, jsonEdit{start: start, end: start + len(v) + 2, value: []byte(v)})
+	return nil
+}
+
+// float rewrites the float value of name if it is not finite.
+func (s *jsonpbScanner) float(name protoreflect.FullName) error {
+	if s.unmarshal || s.j.NonFinite != NonFiniteNull && s.j.NonFinite != NonFiniteError {
+		return s.skip()
+	}
+	v, start, err := s.stringValue()
+	if err != nil || start < 0 {
+		return err
+	}
+	if s.j.NonFinite == NonFiniteError {
+		return fmt.Errorf("non-finite value %s of %s", v, name)
+	}
+	s.edits = append(s.edits, jsonEdit{start: start, end: start + len(v) + 2, value: []byte("null")})
+	return nil
+}
+
+// bytes reencodes the base64 bytes value with the Bytes encoding.
+func (s *jsonpbScanner) bytes() error {
+	if s.unmarshal || s.j.Bytes != BytesBase64URL && s.j.Bytes != BytesHex {
+		return s.skip()
+	}
+	v, start, err := s.stringValue()
+	if err != nil || start < 0 {
+		return err
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return err
+	}
+	var encoded string
+	if s.j.Bytes == BytesHex {
+		encoded = hex.EncodeToString(b)
+	} else {
+		encoded = base64.RawURLEncoding.EncodeToString(b)
+	}
+	s.edits = append(s.edits, jsonEdit{start: start, end: start + len(v) + 2, value: []byte(`"` + encoded + `"`)})
+	return nil
+}
+
+// stringValue returns the next value and the offset of its opening quote if
+// it is a string, or a negative offset otherwise.
+func (s *jsonpbScanner) stringValue() (string, int, error) {
+	tok, err := s.d.Token()
+	if err != nil {
+		return "", -1, err
+	}
+	v, ok := tok.(string)
+	if !ok {
+		return "", -1, nil
+	}
+	// The strings rewritten, numbers and base64, have no escaped characters.
+	end := int(s.d.InputOffset())
+	start := end - len(v) - 2
+	if start < 0 || s.b[start] != '"' {
+		return "", -1, fmt.Errorf("unexpected JSON string %q", v)
+	}
+	return v, start, nil
+}
+
+func (s *jsonpbScanner) skip() error {
+	var b json.RawMessage
+	return s.d.Decode(&b)
+}
+
+// mapKey returns the map key of fd written as key in JSON.
+func mapKey(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	var v protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(key)
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		v = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		v = protoreflect.ValueOfUint64(n)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("invalid map key kind %v", fd.Kind())
+	}
+	return v.MapKey(), nil
+}
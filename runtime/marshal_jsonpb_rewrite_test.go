@@ -0,0 +1,309 @@
+package runtime_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestJSONPbInt64AsNumbers(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		Int64Value:    -1 << 62,
+		Uint64Value:   math.MaxUint64,
+		Fixed64Value:  1,
+		Sfixed64Value: -2,
+		Sint64Value:   3,
+		StringValue:   "12",
+		SingleNested:  &examplepb.ABitOfEverything_Nested{Amount: 4},
+		Nested: []*examplepb.ABitOfEverything_Nested{
+			{Name: "foo"},
+		},
+		MappedStringValue: map[string]string{"5": "6"},
+	}
+	for _, spec := range []struct {
+		m    *runtime.JSONPb
+		msg  proto.Message
+		want interface{}
+	}{
+		{
+			m:   &runtime.JSONPb{Int64AsNumbers: true},
+			msg: msg,
+			want: map[string]interface{}{
+				"int64Value":        json.Number("-4611686018427387904"),
+				"uint64Value":       json.Number("18446744073709551615"),
+				"fixed64Value":      json.Number("1"),
+				"sfixed64Value":     json.Number("-2"),
+				"sint64Value":       json.Number("3"),
+				"stringValue":       "12",
+				"singleNested":      map[string]interface{}{"amount": json.Number("4")},
+				"nested":            []interface{}{map[string]interface{}{"name": "foo"}},
+				"mappedStringValue": map[string]interface{}{"5": "6"},
+			},
+		},
+		{
+			m: &runtime.JSONPb{
+				MarshalOptions: protojson.MarshalOptions{Multiline: true, Indent: "  ", UseProtoNames: true},
+				Int64AsNumbers: true,
+			},
+			msg: &examplepb.ABitOfEverything{Int64Value: 1, Sint64Value: -1},
+			want: map[string]interface{}{
+				"int64_value":  json.Number("1"),
+				"sint64_value": json.Number("-1"),
+			},
+		},
+		{
+			m:    &runtime.JSONPb{Int64AsNumbers: true},
+			msg:  &wrapperspb.UInt64Value{Value: 7},
+			want: json.Number("7"),
+		},
+		{
+			m:   &runtime.JSONPb{},
+			msg: &examplepb.ABitOfEverything{Int64Value: 1},
+			want: map[string]interface{}{
+				"int64Value": "1",
+			},
+		},
+	} {
+		buf, err := spec.m.Marshal(spec.msg)
+		if err != nil {
+			t.Errorf("m.Marshal(%v) failed with %v; want success", spec.msg, err)
+			continue
+		}
+		d := json.NewDecoder(bytes.NewReader(buf))
+		d.UseNumber()
+		var got interface{}
+		if err := d.Decode(&got); err != nil {
+			t.Errorf("m.Marshal(%v) = %q, which is not valid JSON: %v", spec.msg, buf, err)
+			continue
+		}
+		if diff := cmp.Diff(spec.want, got); diff != "" {
+			t.Errorf("m.Marshal(%v) = %q; diff (-want +got): %s", spec.msg, buf, diff)
+		}
+
+		// The JSON numbers unmarshal as the same integers.
+		unmarshaled := spec.msg.ProtoReflect().New().Interface()
+		if err := spec.m.Unmarshal(buf, unmarshaled); err != nil {
+			t.Errorf("m.Unmarshal(%q, msg) failed with %v; want success", buf, err)
+			continue
+		}
+		if diff := cmp.Diff(spec.msg, unmarshaled, protocmp.Transform()); diff != "" {
+			t.Errorf("m.Unmarshal(%q, msg); diff (-want +got): %s", buf, diff)
+		}
+	}
+}
+
+func TestJSONPbNonFinite(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		FloatValue:  float32(math.Inf(-1)),
+		DoubleValue: math.NaN(),
+	}
+	for _, spec := range []struct {
+		nonFinite runtime.NonFiniteEncoding
+		msg       proto.Message
+		want      interface{}
+		wantErr   bool
+	}{
+		{
+			msg: msg,
+			want: map[string]interface{}{
+				"floatValue":  "-Infinity",
+				"doubleValue": "NaN",
+			},
+		},
+		{
+			nonFinite: runtime.NonFiniteNull,
+			msg:       msg,
+			want: map[string]interface{}{
+				"floatValue":  nil,
+				"doubleValue": nil,
+			},
+		},
+		{
+			nonFinite: runtime.NonFiniteNull,
+			msg:       &examplepb.ABitOfEverything{DoubleValue: 1.5},
+			want: map[string]interface{}{
+				"doubleValue": json.Number("1.5"),
+			},
+		},
+		{
+			nonFinite: runtime.NonFiniteNull,
+			msg:       &wrapperspb.DoubleValue{Value: math.Inf(1)},
+			want:      nil,
+		},
+		{
+			nonFinite: runtime.NonFiniteError,
+			msg:       msg,
+			wantErr:   true,
+		},
+	} {
+		m := &runtime.JSONPb{NonFinite: spec.nonFinite}
+		buf, err := m.Marshal(spec.msg)
+		if spec.wantErr {
+			if err == nil {
+				t.Errorf("m.Marshal(%v) = %q; want an error with NonFinite %q", spec.msg, buf, spec.nonFinite)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("m.Marshal(%v) failed with %v; want success", spec.msg, err)
+			continue
+		}
+		d := json.NewDecoder(bytes.NewReader(buf))
+		d.UseNumber()
+		var got interface{}
+		if err := d.Decode(&got); err != nil {
+			t.Errorf("m.Marshal(%v) = %q, which is not valid JSON: %v", spec.msg, buf, err)
+			continue
+		}
+		if diff := cmp.Diff(spec.want, got); diff != "" {
+			t.Errorf("m.Marshal(%v) = %q; diff (-want +got): %s", spec.msg, buf, diff)
+		}
+	}
+}
+
+func TestJSONPbBytes(t *testing.T) {
+	msg := &examplepb.ABitOfEverything{
+		BytesValue: []byte{0xfb, 0xff, 0x01},
+		SingleNested: &examplepb.ABitOfEverything_Nested{
+			Name: "+/8=",
+		},
+	}
+	for _, spec := range []struct {
+		encoding runtime.BytesEncoding
+		want     string
+	}{
+		{want: "+/8B"},
+		{encoding: runtime.BytesBase64, want: "+/8B"},
+		{encoding: runtime.BytesBase64URL, want: "-_8B"},
+		{encoding: runtime.BytesHex, want: "fbff01"},
+	} {
+		m := &runtime.JSONPb{Bytes: spec.encoding}
+		buf, err := m.Marshal(msg)
+		if err != nil {
+			t.Errorf("m.Marshal(%v) failed with %v; want success", msg, err)
+			continue
+		}
+		var got struct {
+			BytesValue   string `json:"bytesValue"`
+			SingleNested struct {
+				Name string `json:"name"`
+			} `json:"singleNested"`
+		}
+		if err := json.Unmarshal(buf, &got); err != nil {
+			t.Errorf("m.Marshal(%v) = %q, which is not valid JSON: %v", msg, buf, err)
+			continue
+		}
+		if got.BytesValue != spec.want {
+			t.Errorf("m.Marshal(%v) = %q; bytesValue = %q; want %q with Bytes %q", msg, buf, got.BytesValue, spec.want, spec.encoding)
+		}
+		// The strings are not bytes.
+		if got.SingleNested.Name != "+/8=" {
+			t.Errorf("m.Marshal(%v) = %q; singleNested.name = %q; want %q", msg, buf, got.SingleNested.Name, "+/8=")
+		}
+	}
+
+	// The bytes in URL-safe base64 unmarshal as well.
+	m := &runtime.JSONPb{Bytes: runtime.BytesBase64URL}
+	buf, err := m.Marshal(msg)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", msg, err)
+	}
+	var got examplepb.ABitOfEverything
+	if err := m.Unmarshal(buf, &got); err != nil {
+		t.Fatalf("m.Unmarshal(%q, &got) failed with %v; want success", buf, err)
+	}
+	if diff := cmp.Diff(msg, &got, protocmp.Transform()); diff != "" {
+		t.Errorf("m.Unmarshal(%q, &got); diff (-want +got): %s", buf, diff)
+	}
+}
+
+func TestJSONPbCodecs(t *testing.T) {
+	// The nested messages are written as "name:amount".
+	codec := runtime.JSONCodec{
+		Marshal: func(m proto.Message) ([]byte, error) {
+			n := m.(*examplepb.ABitOfEverything_Nested)
+			return json.Marshal(fmt.Sprintf("%s:%d", n.Name, n.Amount))
+		},
+		Unmarshal: func(data []byte, m proto.Message) error {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return err
+			}
+			n := m.(*examplepb.ABitOfEverything_Nested)
+			_, err := fmt.Sscanf(strings.Replace(s, ":", " ", 1), "%s %d", &n.Name, &n.Amount)
+			return err
+		},
+	}
+	msg := &examplepb.ABitOfEverything{
+		SingleNested: &examplepb.ABitOfEverything_Nested{Name: "foo", Amount: 1},
+		Nested: []*examplepb.ABitOfEverything_Nested{
+			{Name: "bar", Amount: 2},
+			{Name: "baz", Amount: 3},
+		},
+		MappedNestedValue: map[string]*examplepb.ABitOfEverything_Nested{
+			"qux": {Name: "quux", Amount: 4},
+		},
+		Int64Value: 5,
+	}
+	want := map[string]interface{}{
+		"singleNested":      "foo:1",
+		"nested":            []interface{}{"bar:2", "baz:3"},
+		"mappedNestedValue": map[string]interface{}{"qux": "quux:4"},
+		"int64Value":        "5",
+	}
+	for _, m := range []*runtime.JSONPb{
+		{Codecs: map[protoreflect.FullName]runtime.JSONCodec{"grpc.gateway.runtime.internal.examplepb.ABitOfEverything.Nested": codec}},
+		{Codecs: map[protoreflect.FullName]runtime.JSONCodec{"grpc.gateway.runtime.internal.examplepb.ABitOfEverything.Nested": codec}, DecodeWindow: 64},
+	} {
+		buf, err := m.Marshal(msg)
+		if err != nil {
+			t.Fatalf("m.Marshal(%v) failed with %v; want success", msg, err)
+		}
+		var got interface{}
+		if err := json.Unmarshal(buf, &got); err != nil {
+			t.Fatalf("m.Marshal(%v) = %q, which is not valid JSON: %v", msg, buf, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("m.Marshal(%v) = %q; diff (-want +got): %s", msg, buf, diff)
+		}
+
+		var unmarshaled examplepb.ABitOfEverything
+		if err := m.Unmarshal(buf, &unmarshaled); err != nil {
+			t.Fatalf("m.Unmarshal(%q, &unmarshaled) failed with %v; want success", buf, err)
+		}
+		if diff := cmp.Diff(msg, &unmarshaled, protocmp.Transform()); diff != "" {
+			t.Errorf("m.Unmarshal(%q, &unmarshaled); diff (-want +got): %s", buf, diff)
+		}
+
+		var decoded examplepb.ABitOfEverything
+		if err := m.NewDecoder(bytes.NewReader(buf)).Decode(&decoded); err != nil {
+			t.Fatalf("m.NewDecoder(%q).Decode(&decoded) failed with %v; want success", buf, err)
+		}
+		if diff := cmp.Diff(msg, &decoded, protocmp.Transform()); diff != "" {
+			t.Errorf("m.NewDecoder(%q).Decode(&decoded) with DecodeWindow %d; diff (-want +got): %s", buf, m.DecodeWindow, diff)
+		}
+	}
+
+	// The messages of the type are written with the codec at the top level
+	// too.
+	m := &runtime.JSONPb{Codecs: map[protoreflect.FullName]runtime.JSONCodec{"grpc.gateway.runtime.internal.examplepb.ABitOfEverything.Nested": codec}}
+	buf, err := m.Marshal(msg.SingleNested)
+	if err != nil {
+		t.Fatalf("m.Marshal(%v) failed with %v; want success", msg.SingleNested, err)
+	}
+	if got, want := string(buf), `"foo:1"`; got != want {
+		t.Errorf("m.Marshal(%v) = %q; want %q", msg.SingleNested, got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// windowReader is a reader failing once the bytes read through it go past
+// limit, which the decoders move forward as they consume their input.
+type windowReader struct {
+	r      io.Reader
+	window int64
+	read   int64
+	limit  int64
+}
+
+func (w *windowReader) Read(p []byte) (int, error) {
+	if w.read >= w.limit {
+		return 0, fmt.Errorf("JSON value larger than the decode window of %d bytes", w.window)
+	}
+	if max := w.limit - w.read; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := w.r.Read(p)
+	w.read += int64(n)
+	return n, err
+}
+
+// windowDecoder decodes the proto messages token by token, buffering at most
+// a window of their input at once: the nested messages, the elements of the
+// lists and the entries of the maps are decoded one after the other, and only
+// the other values are buffered whole, each unmarshaled by protojson as the
+// only field of a message.
+type windowDecoder struct {
+	d *json.Decoder
+	w *windowReader
+	protojson.UnmarshalOptions
+	// codecs is the JSONPb if it has custom codecs, whose messages are
+	// buffered whole.
+	codecs *JSONPb
+}
+
+// hasCodec returns whether the messages of md have a custom codec.
+func (s *windowDecoder) hasCodec(md protoreflect.MessageDescriptor) bool {
+	if s.codecs == nil {
+		return false
+	}
+	_, ok := s.codecs.Codecs[md.FullName()]
+	return ok
+}
+
+// next lets the decoder buffer up to a window past what it consumed.
+func (s *windowDecoder) next() {
+	s.w.limit = s.d.InputOffset() + s.w.window
+}
+
+func (s *windowDecoder) decode(v interface{}) error {
+	s.next()
+	p, ok := v.(proto.Message)
+	if !ok {
+		return decodeNonProtoField(s.d, s.UnmarshalOptions, v)
+	}
+	m := p.ProtoReflect()
+	if isWellKnownMessage(m.Descriptor()) || s.hasCodec(m.Descriptor()) {
+		var b json.RawMessage
+		if err := s.d.Decode(&b); err != nil {
+			return err
+		}
+		if s.codecs != nil {
+			return s.codecs.Unmarshal([]byte(b), p)
+		}
+		return s.Unmarshal([]byte(b), p)
+	}
+	tok, err := s.d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("unexpected token %v; want a JSON object for %s", tok, m.Descriptor().FullName())
+	}
+	proto.Reset(p)
+	if err := s.decodeMessage(m); err != nil {
+		return err
+	}
+	if s.AllowPartial {
+		return nil
+	}
+	return proto.CheckInitialized(p)
+}
+
+// decodeMessage decodes the fields of the JSON object into m, its opening
+// delimiter being consumed.
+func (s *windowDecoder) decodeMessage(m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	seen := make(map[protoreflect.FieldNumber]bool)
+	oneofs := make(map[protoreflect.Name]bool)
+	for s.d.More() {
+		s.next()
+		tok, err := s.d.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		fd := fieldByJSONKey(fields, key)
+		if fd != nil {
+			if seen[fd.Number()] {
+				return fmt.Errorf("duplicate field %q of %s", key, m.Descriptor().FullName())
+			}
+			seen[fd.Number()] = true
+			if od := fd.ContainingOneof(); od != nil {
+				if oneofs[od.Name()] {
+					return fmt.Errorf("duplicate field of oneof %s of %s", od.Name(), m.Descriptor().FullName())
+				}
+				oneofs[od.Name()] = true
+			}
+		}
+		switch {
+		case fd != nil && fd.IsList():
+			err = s.decodeList(m, fd, key)
+		case fd != nil && fd.IsMap():
+			err = s.decodeMap(m, fd, key)
+		case fd != nil && fd.Message() != nil && !isWellKnownMessage(fd.Message()) && !s.hasCodec(fd.Message()):
+			err = s.decodeMessageField(m, fd)
+		default:
+			err = s.decodeValue(m, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.d.Token()
+	return err
+}
+
+func (s *windowDecoder) decodeMessageField(m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	s.next()
+	tok, err := s.d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		return nil
+	case json.Delim('{'):
+		return s.decodeMessage(m.Mutable(fd).Message())
+	}
+	return fmt.Errorf("unexpected token %v; want a JSON object for %s", tok, fd.FullName())
+}
+
+func (s *windowDecoder) decodeList(m protoreflect.Message, fd protoreflect.FieldDescriptor, key string) error {
+	s.next()
+	tok, err := s.d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		return nil
+	case json.Delim('['):
+	default:
+		return fmt.Errorf("unexpected token %v; want a JSON array for %s", tok, fd.FullName())
+	}
+	for s.d.More() {
+		s.next()
+		var b json.RawMessage
+		if err := s.d.Decode(&b); err != nil {
+			return err
+		}
+		if err := s.merge(m, key, "["+string(b)+"]"); err != nil {
+			return err
+		}
+	}
+	_, err = s.d.Token()
+	return err
+}
+
+func (s *windowDecoder) decodeMap(m protoreflect.Message, fd protoreflect.FieldDescriptor, key string) error {
+	s.next()
+	tok, err := s.d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		return nil
+	case json.Delim('{'):
+	default:
+		return fmt.Errorf("unexpected token %v; want a JSON object for %s", tok, fd.FullName())
+	}
+	for s.d.More() {
+		s.next()
+		tok, err := s.d.Token()
+		if err != nil {
+			return err
+		}
+		entryKey, err := json.Marshal(tok.(string))
+		if err != nil {
+			return err
+		}
+		s.next()
+		var b json.RawMessage
+		if err := s.d.Decode(&b); err != nil {
+			return err
+		}
+		if err := s.merge(m, key, "{"+string(entryKey)+":"+string(b)+"}"); err != nil {
+			return err
+		}
+	}
+	_, err = s.d.Token()
+	return err
+}
+
+func (s *windowDecoder) decodeValue(m protoreflect.Message, key string) error {
+	s.next()
+	var b json.RawMessage
+	if err := s.d.Decode(&b); err != nil {
+		return err
+	}
+	return s.merge(m, key, string(b))
+}
+
+// merge merges into m the message whose only field in JSON is key with value.
+func (s *windowDecoder) merge(m protoreflect.Message, key, value string) error {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	field := m.New().Interface()
+	b := []byte("{" + string(k) + ":" + value + "}")
+	if s.codecs != nil {
+		if b, err = s.codecs.canonical(b, field); err != nil {
+			return err
+		}
+	}
+	opts := s.UnmarshalOptions
+	// The required fields are checked once the whole message is decoded.
+	opts.AllowPartial = true
+	if err := opts.Unmarshal(b, field); err != nil {
+		return err
+	}
+	proto.Merge(m.Interface(), field)
+	return nil
+}
+
+// fieldByJSONKey returns the field of key, its JSON or its proto name, or nil.
+func fieldByJSONKey(fields protoreflect.FieldDescriptors, key string) protoreflect.FieldDescriptor {
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.JSONName() == key || string(fd.Name()) == key {
+			return fd
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package runtime_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestJSONPbDecodeWindow(t *testing.T) {
+	for _, data := range []string{
+		`{
+			"uuid": "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+			"nested": [
+				{"name": "foo", "amount": 12345},
+				{"name": "bar", "amount": 1, "ok": "TRUE"}
+			],
+			"uint64Value": 18446744073709551615,
+			"enumValue": "ONE",
+			"oneofString": "bar",
+			"mapValue": {
+				"a": 1,
+				"b": "ZERO"
+			}
+		}`,
+		`{
+			"single_nested": {"name": "foo", "amount": 12345},
+			"repeated_string_value": ["a", "b", "c"],
+			"mappedNestedValue": {
+				"a": {"name": "foo"},
+				"b": {"name": "bar"}
+			},
+			"timestampValue": "2016-12-15T12:23:32.000000049Z",
+			"bytesValue": "Ynl0ZXM=",
+			"repeatedEnumValue": ["ONE", 0]
+		}`,
+		`{"singleNested": null, "nested": null, "mapValue": null}`,
+		`{}`,
+	} {
+		var want examplepb.ABitOfEverything
+		if err := protojson.Unmarshal([]byte(data), &want); err != nil {
+			t.Fatalf("protojson.Unmarshal(%q, &want) failed with %v; want success", data, err)
+		}
+
+		m := runtime.JSONPb{DecodeWindow: 64}
+		var got examplepb.ABitOfEverything
+		if err := m.NewDecoder(strings.NewReader(data)).Decode(&got); err != nil {
+			t.Errorf("dec.Decode(&got) failed with %v; want success; data=%q", err, data)
+			continue
+		}
+		if diff := cmp.Diff(&got, &want, protocmp.Transform()); diff != "" {
+			t.Errorf("data %q: %s", data, diff)
+		}
+	}
+}
+
+func TestJSONPbDecodeWindowLimit(t *testing.T) {
+	values := make([]string, 100)
+	for i := range values {
+		values[i] = fmt.Sprintf("%q", strings.Repeat("a", 50))
+	}
+	m := runtime.JSONPb{DecodeWindow: 128}
+
+	// The elements of the list are decoded one by one.
+	data := fmt.Sprintf(`{"repeatedStringValue": [%s]}`, strings.Join(values, ", "))
+	var got examplepb.ABitOfEverything
+	if err := m.NewDecoder(strings.NewReader(data)).Decode(&got); err != nil {
+		t.Fatalf("dec.Decode(&got) failed with %v; want success", err)
+	}
+	if len(got.RepeatedStringValue) != len(values) {
+		t.Errorf("got %d values; want %d", len(got.RepeatedStringValue), len(values))
+	}
+
+	data = fmt.Sprintf(`{"stringValue": %q}`, strings.Repeat("a", 1000))
+	if err := m.NewDecoder(strings.NewReader(data)).Decode(&got); err == nil {
+		t.Errorf("dec.Decode(&got) succeeded with a value larger than the window; want an error")
+	}
+}
+
+func TestJSONPbDecodeWindowErrors(t *testing.T) {
+	var got examplepb.ABitOfEverything
+	m := runtime.JSONPb{DecodeWindow: 64}
+	if err := m.NewDecoder(strings.NewReader("")).Decode(&got); err != io.EOF {
+		t.Errorf("dec.Decode(&got) failed with %v; want %v", err, io.EOF)
+	}
+	for _, data := range []string{
+		`{"unknown": 1}`,
+		`{"uuid": "a", "uuid": "b"}`,
+		`{"oneofString": "a", "oneofEmpty": {}}`,
+		`{"singleNested": 1}`,
+		`[]`,
+	} {
+		if err := m.NewDecoder(strings.NewReader(data)).Decode(&got); err == nil {
+			t.Errorf("dec.Decode(&got) succeeded; want an error; data=%q", data)
+		}
+	}
+
+	m.UnmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := m.NewDecoder(strings.NewReader(`{"unknown": {"a": [1]}, "uuid": "a"}`)).Decode(&got); err != nil {
+		t.Errorf("dec.Decode(&got) failed with %v; want success", err)
+	}
+	if got.Uuid != "a" {
+		t.Errorf("got.Uuid = %q; want %q", got.Uuid, "a")
+	}
+}
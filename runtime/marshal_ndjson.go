@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// MIMENDJSON is the media type of the newline delimited JSON streams.
+const MIMENDJSON = "application/x-ndjson"
+
+// NDJSONMarshaler is a Marshaler writing the responses of the server
+// streaming methods as newline delimited JSON, one message per line without
+// the envelope of the streams, e.g. `{"id":1}` and `{"id":2}` as two lines,
+// with the Content-Type "application/x-ndjson", for the log and export
+// style APIs. An error ending a stream is written as its last line. The
+// other responses are marshaled by its Marshaler, e.g. a JSONPb, whose
+// content type they have, on a single line too.
+//
+// The muxes register one wrapping the default marshaler for MIMENDJSON, so
+// that the clients accepting "application/x-ndjson" get their streams in
+// NDJSON.
+type NDJSONMarshaler struct {
+	Marshaler
+}
+
+// StreamContentType returns "application/x-ndjson", or the content type of v
+// if it is a google.api.HttpBody message, which is written as is.
+func (m *NDJSONMarshaler) StreamContentType(v interface{}) string {
+	if _, ok := v.(*httpbody.HttpBody); ok {
+		return m.Marshaler.ContentType(v)
+	}
+	return MIMENDJSON
+}
+
+// Marshal marshals v with the Marshaler of m, on a single line.
+func (m *NDJSONMarshaler) Marshal(v interface{}) ([]byte, error) {
+	b, err := m.Marshaler.Marshal(v)
+	if err != nil || bytes.IndexByte(b, '\n') < 0 {
+		return b, err
+	}
+	if _, ok := v.(*httpbody.HttpBody); ok {
+		return b, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Delimiter returns the newline ending the lines of the streams.
+func (m *NDJSONMarshaler) Delimiter() []byte {
+	return []byte("\n")
+}
@@ -0,0 +1,108 @@
+package runtime_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardResponseStreamNDJSON(t *testing.T) {
+	for _, spec := range []struct {
+		name  string
+		msgs  []proto.Message
+		err   error
+		want  []string
+		multi bool
+	}{
+		{
+			name: "messages",
+			msgs: []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}},
+			want: []string{`{"id":"One"}`, `{"id":"Two"}`},
+		},
+		{
+			name:  "multiline marshaler",
+			msgs:  []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}},
+			want:  []string{`{"id":"One"}`, `{"id":"Two"}`},
+			multi: true,
+		},
+		{
+			name: "stream error",
+			msgs: []proto.Message{&pb.SimpleMessage{Id: "One"}},
+			err:  status.Errorf(codes.OutOfRange, "out of range"),
+			want: []string{`{"id":"One"}`, `{"code":11,"message":"out of range"}`},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			msgs := spec.msgs
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					if spec.err != nil {
+						return nil, spec.err
+					}
+					return nil, io.EOF
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			marshaler := &runtime.NDJSONMarshaler{Marshaler: &runtime.JSONPb{
+				MarshalOptions: protojson.MarshalOptions{Multiline: spec.multi},
+			}}
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			mux := runtime.NewServeMux()
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("w.Code = %d; want %d", w.Code, http.StatusOK)
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/x-ndjson"; got != want {
+				t.Errorf("Content-Type = %q; want %q", got, want)
+			}
+			body := w.Body.String()
+			if !strings.HasSuffix(body, "\n") {
+				t.Errorf("w.Body = %q; want it to end with a newline", body)
+			}
+			lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+			if len(lines) != len(spec.want) {
+				t.Fatalf("w.Body = %q; want %d lines", body, len(spec.want))
+			}
+			for i, line := range lines {
+				// protojson adds spaces randomly.
+				if got := strings.Replace(line, " ", "", -1); got != strings.Replace(spec.want[i], " ", "", -1) {
+					t.Errorf("line %d = %q; want %q", i, line, spec.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNDJSONMarshalerRegistered(t *testing.T) {
+	mux := runtime.NewServeMux()
+	r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	r.Header.Set("Accept", runtime.MIMENDJSON)
+	_, outbound := runtime.MarshalerForRequest(mux, r)
+	m, ok := outbound.(*runtime.NDJSONMarshaler)
+	if !ok {
+		t.Fatalf("runtime.MarshalerForRequest(mux, r) = %T; want *runtime.NDJSONMarshaler", outbound)
+	}
+	// The unary responses have the content type of the JSON marshaler.
+	if got, want := m.ContentType(&pb.SimpleMessage{}), "application/json"; got != want {
+		t.Errorf("m.ContentType(msg) = %q; want %q", got, want)
+	}
+	if got, want := m.StreamContentType(&pb.SimpleMessage{}), runtime.MIMENDJSON; got != want {
+		t.Errorf("m.StreamContentType(msg) = %q; want %q", got, want)
+	}
+}
@@ -48,3 +48,11 @@ type Delimited interface {
 	// Delimiter returns the record separator for the stream.
 	Delimiter() []byte
 }
+
+// StreamContentType defines the content type of the streams.
+type StreamContentType interface {
+	// StreamContentType returns the Content-Type of the responses of the
+	// server streaming methods, instead of that of ContentType. The parameter
+	// is the first message of the stream, or nil if there is none.
+	StreamContentType(v interface{}) string
+}
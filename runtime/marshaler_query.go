@@ -0,0 +1,176 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MarshalerQueryOptions enables the query parameters adjusting how the
+// responses of a request are marshaled by JSONPb, with
+// WithMarshalerQueryOptions.
+type MarshalerQueryOptions struct {
+	// Pretty enables "pretty=true", indenting the JSON of the responses, and
+	// "pretty=false".
+	Pretty bool
+	// Enum enables "enum=int" and "enum=name", writing the enum values as
+	// numbers or names.
+	Enum bool
+	// FieldsAs enables "fields_as=camel" and "fields_as=proto", writing the
+	// field names in lowerCamelCase or as in the proto files.
+	FieldsAs bool
+	// Int64 enables "int64=number" and "int64=string", writing the 64-bit
+	// integers as JSON numbers or strings.
+	Int64 bool
+}
+
+// WithMarshalerQueryOptions returns a ServeMuxOption letting the clients
+// adjust the emit options of the JSONPb marshaler of their requests with the
+// query parameters enabled by opts, e.g. to debug with "?pretty=true", instead
+// of serving them with separate muxes.
+//
+// The enabled parameters are removed from the query of the requests, so they
+// are not parsed as fields of the request messages. The requests with an
+// invalid value are rejected with InvalidArgument. The outbound marshalers
+// which are not a JSONPb, possibly wrapped in an HTTPBodyMarshaler or an
+// NDJSONMarshaler, are not adjusted.
+func WithMarshalerQueryOptions(opts MarshalerQueryOptions) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.marshalerQuery = &opts
+	}
+}
+
+type marshalerQueryKey struct{}
+
+// marshalerQuery are the emit options set by the query of a request, nil if
+// not set.
+type marshalerQuery struct {
+	pretty       *bool
+	enumNumbers  *bool
+	protoNames   *bool
+	int64Numbers *bool
+}
+
+// checkMarshalerQuery replies with an error and returns false if the query of
+// r has an invalid marshaler option. It returns r without them, and with them
+// in its context, otherwise.
+func (s *ServeMux) checkMarshalerQuery(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.marshalerQuery == nil || r.URL.RawQuery == "" {
+		return r, true
+	}
+	query := r.URL.Query()
+	q := &marshalerQuery{}
+	for _, param := range []struct {
+		name    string
+		enabled bool
+		dst     **bool
+		parse   func(string) (bool, error)
+	}{
+		{name: "pretty", enabled: s.marshalerQuery.Pretty, dst: &q.pretty, parse: strconv.ParseBool},
+		{name: "enum", enabled: s.marshalerQuery.Enum, dst: &q.enumNumbers, parse: parseChoice("int", "name")},
+		{name: "fields_as", enabled: s.marshalerQuery.FieldsAs, dst: &q.protoNames, parse: parseChoice("proto", "camel")},
+		{name: "int64", enabled: s.marshalerQuery.Int64, dst: &q.int64Numbers, parse: parseChoice("number", "string")},
+	} {
+		values, ok := query[param.name]
+		if !param.enabled || !ok {
+			continue
+		}
+		v, err := param.parse(values[0])
+		if err == nil && len(values) > 1 {
+			err = errors.New("multiple values")
+		}
+		if err != nil {
+			_, outboundMarshaler := MarshalerForRequest(s, r)
+			HTTPError(r.Context(), s, outboundMarshaler, w, r, FieldViolationError(param.name, err))
+			return r, false
+		}
+		*param.dst = &v
+		query.Del(param.name)
+	}
+	if *q == (marshalerQuery{}) {
+		return r, true
+	}
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	r2 := *r
+	r2.URL = &u
+	return r2.WithContext(context.WithValue(r.Context(), marshalerQueryKey{}, q)), true
+}
+
+// parseChoice returns a func parsing yes as true and no as false.
+func parseChoice(yes, no string) func(string) (bool, error) {
+	return func(v string) (bool, error) {
+		switch v {
+		case yes:
+			return true, nil
+		case no:
+			return false, nil
+		}
+		return false, fmt.Errorf("%q is neither %q nor %q", v, yes, no)
+	}
+}
+
+// withMarshalerQuery returns m adjusted by the marshaler options of the query
+// of r, if any.
+func withMarshalerQuery(r *http.Request, m Marshaler) Marshaler {
+	q, ok := r.Context().Value(marshalerQueryKey{}).(*marshalerQuery)
+	if !ok {
+		return m
+	}
+	switch m := m.(type) {
+	case *JSONPb:
+		return q.apply(m)
+	case *HTTPBodyMarshaler:
+		if j, ok := m.Marshaler.(*JSONPb); ok {
+			return &HTTPBodyMarshaler{Marshaler: q.apply(j)}
+		}
+	case *NDJSONMarshaler:
+		return &NDJSONMarshaler{Marshaler: withMarshalerQuery(r, m.Marshaler)}
+	}
+	return m
+}
+
+// apply returns a copy of j with the emit options of q.
+func (q *marshalerQuery) apply(j *JSONPb) *JSONPb {
+	c := *j
+	if q.pretty != nil {
+		c.Multiline = *q.pretty
+		c.Indent = ""
+		if c.Multiline {
+			c.Indent = "  "
+		}
+	}
+	if q.enumNumbers != nil {
+		c.UseEnumNumbers = *q.enumNumbers
+	}
+	if q.protoNames != nil {
+		c.UseProtoNames = *q.protoNames
+	}
+	if q.int64Numbers != nil {
+		c.Int64AsNumbers = *q.int64Numbers
+	}
+	return &c
+}
+
+// String returns the options of q set, e.g. to tell apart the responses of
+// requests whose queries differ by them.
+func (q *marshalerQuery) String() string {
+	var opts []string
+	for _, opt := range []struct {
+		name string
+		v    *bool
+	}{
+		{"pretty", q.pretty},
+		{"enum_numbers", q.enumNumbers},
+		{"proto_names", q.protoNames},
+		{"int64_numbers", q.int64Numbers},
+	} {
+		if opt.v != nil {
+			opts = append(opts, fmt.Sprintf("%s=%t", opt.name, *opt.v))
+		}
+	}
+	return strings.Join(opts, "&")
+}
@@ -0,0 +1,146 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+)
+
+func TestWithMarshalerQueryOptions(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithMarshalerQueryOptions(runtime.MarshalerQueryOptions{
+		Pretty: true,
+		Enum:   true,
+		Int64:  true,
+	}))
+	var query string
+	if err := mux.HandlePath("GET", "/v1/example", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		query = r.URL.RawQuery
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		b, err := outbound.Marshal(&pb.ABitOfEverything{StringValue: "foo", EnumValue: pb.NumericEnum_ONE, Int64Value: 1})
+		if err != nil {
+			t.Fatalf("outbound.Marshal(msg) failed with %v; want success", err)
+		}
+		w.Write(b)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/example", err)
+	}
+
+	for _, spec := range []struct {
+		target    string
+		wantCode  int
+		wantQuery string
+		// want are in the body once its whitespace is removed, since
+		// protojson adds some randomly.
+		want       []string
+		wantPretty bool
+	}{
+		{
+			target:    "/v1/example?string_value=foo",
+			wantCode:  http.StatusOK,
+			wantQuery: "string_value=foo",
+			want:      []string{`"stringValue":"foo"`, `"enumValue":"ONE"`, `"int64Value":"1"`},
+		},
+		{
+			target:     "/v1/example?pretty=true&enum=int&string_value=foo",
+			wantCode:   http.StatusOK,
+			wantQuery:  "string_value=foo",
+			want:       []string{`"stringValue":"foo"`, `"enumValue":1`},
+			wantPretty: true,
+		},
+		{
+			target:    "/v1/example?pretty=false&enum=name",
+			wantCode:  http.StatusOK,
+			wantQuery: "",
+			want:      []string{`"enumValue":"ONE"`},
+		},
+		{
+			target:    "/v1/example?int64=number",
+			wantCode:  http.StatusOK,
+			wantQuery: "",
+			want:      []string{`"int64Value":1`},
+		},
+		{
+			// fields_as is not enabled, so it is left to the request message.
+			target:    "/v1/example?fields_as=proto",
+			wantCode:  http.StatusOK,
+			wantQuery: "fields_as=proto",
+			want:      []string{`"stringValue":"foo"`},
+		},
+		{
+			target:   "/v1/example?pretty=yes",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			target:   "/v1/example?enum=number",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			target:   "/v1/example?int64=float",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			target:   "/v1/example?pretty=true&pretty=false",
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		query = ""
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", spec.target, nil))
+
+		if w.Code != spec.wantCode {
+			t.Errorf("GET %s: w.Code = %d; want %d", spec.target, w.Code, spec.wantCode)
+			continue
+		}
+		if spec.wantCode != http.StatusOK {
+			continue
+		}
+		if query != spec.wantQuery {
+			t.Errorf("GET %s: query = %q; want %q", spec.target, query, spec.wantQuery)
+		}
+		body := w.Body.String()
+		compact := strings.NewReplacer(" ", "", "\n", "").Replace(body)
+		for _, s := range spec.want {
+			if !strings.Contains(compact, s) {
+				t.Errorf("GET %s: body = %q; want it to contain %q", spec.target, body, s)
+			}
+		}
+		if pretty := strings.Contains(body, "\n  \""); pretty != spec.wantPretty {
+			t.Errorf("GET %s: body = %q; indented = %t; want %t", spec.target, body, pretty, spec.wantPretty)
+		}
+	}
+}
+
+func TestWithMarshalerQueryOptionsFieldsAs(t *testing.T) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerQueryOptions(runtime.MarshalerQueryOptions{FieldsAs: true}),
+		runtime.WithMarshalerOption("application/x-custom", &runtime.ProtoMarshaller{}),
+	)
+	var outbound runtime.Marshaler
+	if err := mux.HandlePath("GET", "/v1/example", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		_, outbound = runtime.MarshalerForRequest(mux, r)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/example", err)
+	}
+
+	r := httptest.NewRequest("GET", "/v1/example?fields_as=proto", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	b, err := outbound.Marshal(&pb.ABitOfEverything{StringValue: "foo"})
+	if err != nil {
+		t.Fatalf("outbound.Marshal(msg) failed with %v; want success", err)
+	}
+	if want := `"string_value":`; !strings.Contains(string(b), want) {
+		t.Errorf("outbound.Marshal(msg) = %q; want it to contain %q", b, want)
+	}
+
+	// The marshalers which are not a JSONPb are not adjusted.
+	r = httptest.NewRequest("GET", "/v1/example?fields_as=proto", nil)
+	r.Header.Set("Accept", "application/x-custom")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if _, ok := outbound.(*runtime.ProtoMarshaller); !ok {
+		t.Errorf("outbound = %T; want *runtime.ProtoMarshaller", outbound)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"mime"
 	"net/http"
+	"sync"
 
 	"google.golang.org/grpc/grpclog"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -13,6 +14,10 @@ import (
 // a registered MIME type.
 const MIMEWildcard = "*"
 
+// maxCachedContentTypes is the number of Content-Type header values whose
+// marshalers are cached, bounding the cache as the values are set by clients.
+const maxCachedContentTypes = 256
+
 var (
 	acceptHeader      = http.CanonicalHeaderKey("Accept")
 	contentTypeHeader = http.CanonicalHeaderKey("Content-Type")
@@ -44,12 +49,7 @@ func MarshalerForRequest(mux *ServeMux, r *http.Request) (inbound Marshaler, out
 	}
 
 	for _, contentTypeVal := range r.Header[contentTypeHeader] {
-		contentType, _, err := mime.ParseMediaType(contentTypeVal)
-		if err != nil {
-			grpclog.Infof("Failed to parse Content-Type %s: %v", contentTypeVal, err)
-			continue
-		}
-		if m, ok := mux.marshalers.mimeMap[contentType]; ok {
+		if m, ok := mux.marshalers.forContentType(contentTypeVal); ok {
 			inbound = m
 			break
 		}
@@ -62,12 +62,51 @@ func MarshalerForRequest(mux *ServeMux, r *http.Request) (inbound Marshaler, out
 		outbound = inbound
 	}
 
-	return inbound, outbound
+	return inbound, withMarshalerQuery(r, outbound)
 }
 
 // marshalerRegistry is a mapping from MIME types to Marshalers.
 type marshalerRegistry struct {
 	mimeMap map[string]Marshaler
+	// contentTypes caches the marshalers of the Content-Type header values
+	// with parameters, e.g. "application/json; charset=utf-8", so that they
+	// are only parsed once.
+	contentTypes *contentTypeCache
+}
+
+type contentTypeCache struct {
+	mu sync.RWMutex
+	// values are the marshalers of the header values, nil for the values
+	// matching none.
+	values map[string]Marshaler
+}
+
+// forContentType returns the marshaler of the MIME type of the Content-Type
+// header value v, and whether there is one. It does not allocate once v is
+// cached.
+func (m marshalerRegistry) forContentType(v string) (Marshaler, bool) {
+	if marshaler, ok := m.mimeMap[v]; ok {
+		return marshaler, true
+	}
+	cache := m.contentTypes
+	cache.mu.RLock()
+	marshaler, ok := cache.values[v]
+	cache.mu.RUnlock()
+	if ok {
+		return marshaler, marshaler != nil
+	}
+	contentType, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		grpclog.Infof("Failed to parse Content-Type %s: %v", v, err)
+		return nil, false
+	}
+	marshaler = m.mimeMap[contentType]
+	cache.mu.Lock()
+	if len(cache.values) < maxCachedContentTypes {
+		cache.values[v] = marshaler
+	}
+	cache.mu.Unlock()
+	return marshaler, marshaler != nil
 }
 
 // add adds a marshaler for a case-sensitive MIME type string ("*" to match any
@@ -94,7 +133,9 @@ func makeMarshalerMIMERegistry() marshalerRegistry {
 	return marshalerRegistry{
 		mimeMap: map[string]Marshaler{
 			MIMEWildcard: defaultMarshaler,
+			MIMENDJSON:   &NDJSONMarshaler{Marshaler: defaultMarshaler},
 		},
+		contentTypes: &contentTypeCache{values: make(map[string]Marshaler)},
 	}
 }
 
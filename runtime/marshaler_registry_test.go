@@ -91,6 +91,72 @@ func TestMarshalerForRequest(t *testing.T) {
 	}
 }
 
+func TestMarshalerForRequestCachedContentType(t *testing.T) {
+	in, out := dummyMarshaler(0), dummyMarshaler(1)
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &out),
+		runtime.WithMarshalerOption("application/x-in", &in),
+	)
+	for _, spec := range []struct {
+		contentType string
+		want        runtime.Marshaler
+	}{
+		{contentType: "application/x-in", want: &in},
+		{contentType: "application/x-in; charset=UTF-8", want: &in},
+		{contentType: "Application/X-In", want: &in},
+		{contentType: "application/x-another; charset=UTF-8", want: &out},
+		{contentType: "invalid;;", want: &out},
+	} {
+		r, err := http.NewRequest("POST", "http://example.com", nil)
+		if err != nil {
+			t.Fatalf(`http.NewRequest("POST", "http://example.com", nil) failed with %v; want success`, err)
+		}
+		r.Header.Set("Content-Type", spec.contentType)
+		// The second lookup is served from the cache.
+		for i := 0; i < 2; i++ {
+			if got, _ := runtime.MarshalerForRequest(mux, r); got != spec.want {
+				t.Errorf("%q: in = %#v; want %#v", spec.contentType, got, spec.want)
+			}
+		}
+		if spec.want == &in {
+			if allocs := testing.AllocsPerRun(100, func() { runtime.MarshalerForRequest(mux, r) }); allocs != 0 {
+				t.Errorf("%q: MarshalerForRequest(mux, r) allocates %v times; want 0", spec.contentType, allocs)
+			}
+		}
+	}
+}
+
+func BenchmarkMarshalerForRequest(b *testing.B) {
+	mux := runtime.NewServeMux()
+	for _, spec := range []struct {
+		name        string
+		contentType string
+		accept      string
+	}{
+		{name: "exact", contentType: "application/json", accept: "application/json"},
+		{name: "parameters", contentType: "application/json; charset=utf-8", accept: "application/json"},
+		{name: "no headers"},
+	} {
+		b.Run(spec.name, func(b *testing.B) {
+			r, err := http.NewRequest("POST", "http://example.com", nil)
+			if err != nil {
+				b.Fatalf(`http.NewRequest("POST", "http://example.com", nil) failed with %v; want success`, err)
+			}
+			if spec.contentType != "" {
+				r.Header.Set("Content-Type", spec.contentType)
+			}
+			if spec.accept != "" {
+				r.Header.Set("Accept", spec.accept)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.MarshalerForRequest(mux, r)
+			}
+		})
+	}
+}
+
 type dummyMarshaler int
 
 func (dummyMarshaler) ContentType(_ interface{}) string { return "" }
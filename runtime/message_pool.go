@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MessagePool is a pool of the request messages of a method, used by the
+// handlers generated with pool_requests to cut the allocations of the
+// gateways serving many requests.
+//
+// A message got from the pool is owned by its handler until it is put back,
+// once the call it is sent with returns, and must not be retained past that
+// point. The hooks given the requests must copy what they keep, e.g. with
+// proto.Clone, and so must the interceptors and connections sending the
+// requests after the calls return, e.g. those of the attempts of the calls
+// left pending by NewHedgedBackend, which copies them, or asynchronous
+// interceptors.
+type MessagePool struct {
+	pool sync.Pool
+}
+
+// NewMessagePool returns a pool of the messages returned by newMessage.
+func NewMessagePool(newMessage func() proto.Message) *MessagePool {
+	p := &MessagePool{}
+	p.pool.New = func() interface{} {
+		return newMessage()
+	}
+	return p
+}
+
+// Get returns an empty message of the pool.
+func (p *MessagePool) Get() proto.Message {
+	return p.pool.Get().(proto.Message)
+}
+
+// Put resets msg and puts it back into the pool.
+func (p *MessagePool) Put(msg proto.Message) {
+	proto.Reset(msg)
+	p.pool.Put(msg)
+}
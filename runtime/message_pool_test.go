@@ -0,0 +1,25 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessagePool(t *testing.T) {
+	pool := runtime.NewMessagePool(func() proto.Message { return new(pb.SimpleMessage) })
+
+	msg, ok := pool.Get().(*pb.SimpleMessage)
+	if !ok {
+		t.Fatalf("pool.Get() = %T; want a *pb.SimpleMessage", msg)
+	}
+	msg.Id = "foo"
+	pool.Put(msg)
+
+	// The pool may drop its messages, but never returns them unreset.
+	if got := pool.Get().(*pb.SimpleMessage); got.Id != "" {
+		t.Errorf("pool.Get() = %v; want an empty message", got)
+	}
+}
@@ -0,0 +1,236 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// exampleTime is the example of the timestamps, 2006-01-02T15:04:05Z.
+const exampleTime = 1136214245
+
+// Fill sets the fields of m to an example value, m being reset first. The
+// example of the openapiv2_schema option of a message is used if it is
+// valid, and the default of the openapiv2_field option of a field, its
+// minimum and maximum, its minimum and maximum length and its minimum and
+// maximum number of items are respected. Otherwise, the strings are the
+// names of their fields, the numbers 1, the enums their first non-zero
+// value, and only the first field of the oneofs is set.
+func Fill(m proto.Message, config Config) {
+	proto.Reset(m)
+	f := filler{config: config.withDefaults()}
+	f.message(m.ProtoReflect(), 0)
+}
+
+type filler struct {
+	config Config
+}
+
+func (f filler) message(m protoreflect.Message, depth int) {
+	md := m.Descriptor()
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		m.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(exampleTime))
+		return
+	case "google.protobuf.Duration":
+		m.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(1))
+		return
+	case "google.protobuf.Value":
+		m.Set(md.Fields().ByName("string_value"), protoreflect.ValueOfString("value"))
+		return
+	}
+	if schema, ok := proto.GetExtension(md.Options(), options.E_Openapiv2Schema).(*options.Schema); ok && schema.GetExample() != "" {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(schema.GetExample()), m.Interface()); err == nil {
+			return
+		}
+		proto.Reset(m.Interface())
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if od := fd.ContainingOneof(); od != nil && !isSynthetic(od) && od.Fields().Get(0) != fd {
+			continue
+		}
+		nested := fd.Message()
+		if fd.IsMap() {
+			nested = fd.MapValue().Message()
+		}
+		if nested != nil && (isUnset(nested) || !isWellKnown(nested) && depth >= f.config.MaxDepth) {
+			continue
+		}
+		schema, _ := proto.GetExtension(fd.Options(), options.E_Openapiv2Field).(*options.JSONSchema)
+		if f.fieldDefault(m, fd, schema) {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			mp := m.Mutable(fd).Map()
+			for i := 0; i < f.items(schema, fd.MapKey().Kind() == protoreflect.BoolKind); i++ {
+				mp.Set(f.mapKey(fd.MapKey(), i), f.value(fd.MapValue(), mp.NewValue, nil, depth))
+			}
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			for i := 0; i < f.items(schema, false); i++ {
+				list.Append(f.value(fd, list.NewElement, schema, depth))
+			}
+		default:
+			m.Set(fd, f.value(fd, func() protoreflect.Value { return m.NewField(fd) }, schema, depth))
+		}
+	}
+}
+
+// fieldDefault sets the field to the default of its schema, reporting
+// whether it is valid.
+func (f filler) fieldDefault(m protoreflect.Message, fd protoreflect.FieldDescriptor, schema *options.JSONSchema) bool {
+	if schema.GetDefault() == "" {
+		return false
+	}
+	b, err := json.Marshal(map[string]json.RawMessage{fd.JSONName(): json.RawMessage(schema.GetDefault())})
+	if err != nil {
+		return false
+	}
+	field := m.New()
+	if err := protojson.Unmarshal(b, field.Interface()); err != nil {
+		return false
+	}
+	m.Set(fd, field.Get(fd))
+	return true
+}
+
+// items returns the number of items of a repeated field or a map, at most
+// two if the keys are booleans.
+func (f filler) items(schema *options.JSONSchema, boolKeys bool) int {
+	n := f.config.RepeatedLength
+	if min := int(schema.GetMinItems()); n < min {
+		n = min
+	}
+	if max := schema.GetMaxItems(); max > 0 && uint64(n) > max {
+		n = int(max)
+	}
+	if boolKeys && n > 2 {
+		n = 2
+	}
+	return n
+}
+
+func (f filler) mapKey(fd protoreflect.FieldDescriptor, i int) protoreflect.MapKey {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if i == 0 {
+			return protoreflect.ValueOfString("key").MapKey()
+		}
+		return protoreflect.ValueOfString(fmt.Sprintf("key%d", i)).MapKey()
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(i == 0).MapKey()
+	}
+	return f.number(fd, float64(i+1), nil).MapKey()
+}
+
+// value returns an example value of the field, the messages being created
+// with newMessage.
+func (f filler) value(fd protoreflect.FieldDescriptor, newMessage func() protoreflect.Value, schema *options.JSONSchema, depth int) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v := newMessage()
+		f.message(v.Message(), depth+1)
+		return v
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			if values.Get(i).Number() != 0 {
+				return protoreflect.ValueOfEnum(values.Get(i).Number())
+			}
+		}
+		return protoreflect.ValueOfEnum(0)
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(exampleString(string(fd.Name()), schema))
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(exampleString(string(fd.Name()), schema)))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return f.number(fd, 1.5, schema)
+	}
+	return f.number(fd, 1, schema)
+}
+
+// number returns the number n of the kind of the field, within the minimum
+// and maximum of its schema.
+func (f filler) number(fd protoreflect.FieldDescriptor, n float64, schema *options.JSONSchema) protoreflect.Value {
+	if schema.GetMaximum() != 0 || schema.GetMinimum() != 0 {
+		min, max := schema.GetMinimum(), schema.GetMaximum()
+		if max == 0 && min > 0 {
+			max = math.Inf(1)
+		}
+		step := 0.5
+		if fd.Kind() != protoreflect.FloatKind && fd.Kind() != protoreflect.DoubleKind {
+			step = 1
+		}
+		if n < min || n == min && schema.GetExclusiveMinimum() {
+			n = math.Ceil(min)
+			if n == min && schema.GetExclusiveMinimum() {
+				n += step
+			}
+		}
+		if n > max || n == max && schema.GetExclusiveMaximum() {
+			n = math.Floor(max)
+			if n == max && schema.GetExclusiveMaximum() {
+				n -= step
+			}
+		}
+	}
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(int64(n))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(math.Max(n, 0)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(math.Max(n, 0)))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(n))
+	}
+	return protoreflect.ValueOfFloat64(n)
+}
+
+// exampleString returns name within the minimum and maximum length of the
+// schema.
+func exampleString(name string, schema *options.JSONSchema) string {
+	if min := int(schema.GetMinLength()); len(name) < min {
+		name += strings.Repeat("x", min-len(name))
+	}
+	if max := schema.GetMaxLength(); max > 0 && uint64(len(name)) > max {
+		name = name[:max]
+	}
+	return name
+}
+
+// isSynthetic reports whether the oneof is that of a proto3 optional field.
+func isSynthetic(od protoreflect.OneofDescriptor) bool {
+	return od.Fields().Len() == 1 && od.Fields().Get(0).HasOptionalKeyword()
+}
+
+// isUnset reports whether the fields of the message type are left unset: an
+// Any without a type is not valid JSON, and the paths of a FieldMask depend
+// on the message it applies to.
+func isUnset(md protoreflect.MessageDescriptor) bool {
+	switch md.FullName() {
+	case "google.protobuf.Any", "google.protobuf.FieldMask":
+		return true
+	}
+	return false
+}
+
+// isWellKnown reports whether the message is a well-known type, which is
+// always set regardless of the depth.
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf"
+}
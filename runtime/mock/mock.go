@@ -0,0 +1,274 @@
+// Package mock serves the REST surface of services without their backends,
+// for the development of their frontends and contract tests. Its Conn
+// replies to the calls with example responses, which the handlers of the
+// gateway serialize as they would those of the backends:
+//
+//	conn := mock.NewConn(mock.Config{Echo: true})
+//	err := pb.RegisterLibraryServiceHandlerClient(ctx, mux, pb.NewLibraryServiceClient(conn))
+package mock
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Config configures a Conn.
+type Config struct {
+	// Echo copies the fields of the requests to the fields of the responses
+	// of the same name and type, e.g. their path parameters.
+	Echo bool
+	// Responses are the responses of the methods, keyed by their full name,
+	// e.g. "/library.v1.LibraryService/GetBook", replacing the examples.
+	Responses map[string]proto.Message
+	// Errors are the errors the methods fail with, keyed by their full name.
+	Errors map[string]error
+	// StreamLength is the number of messages of the server streams, 3 if
+	// zero. The bidirectional streams reply to every message instead.
+	StreamLength int
+	// RepeatedLength is the number of items of the repeated fields and maps,
+	// 1 if zero and none if negative.
+	RepeatedLength int
+	// MaxDepth is the depth of the nested messages beyond which they are
+	// left unset, bounding the recursive messages, 3 if zero.
+	MaxDepth int
+}
+
+func (c Config) withDefaults() Config {
+	if c.StreamLength == 0 {
+		c.StreamLength = 3
+	}
+	if c.RepeatedLength == 0 {
+		c.RepeatedLength = 1
+	} else if c.RepeatedLength < 0 {
+		c.RepeatedLength = 0
+	}
+	if c.MaxDepth == 0 {
+		c.MaxDepth = 3
+	}
+	return c
+}
+
+// Conn is a connection replying to the calls with example responses, as
+// generated by Fill, instead of calling a backend.
+type Conn struct {
+	config Config
+}
+
+var _ grpc.ClientConnInterface = (*Conn)(nil)
+
+// NewConn returns a Conn configured by config. It is to be given to the
+// generated NewXXXClient functions of the services to mock, whose clients
+// are then registered with the generated RegisterXXXHandlerClient
+// functions.
+func NewConn(config Config) *Conn {
+	return &Conn{config: config.withDefaults()}
+}
+
+// Invoke performs a unary call on the connection.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	in, ok := args.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "request %T is not a proto.Message", args)
+	}
+	out, ok := reply.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "response %T is not a proto.Message", reply)
+	}
+	if err := ctx.Err(); err != nil {
+		return contextError(err)
+	}
+	return c.respond(method, in, out)
+}
+
+// NewStream starts a streaming call on the connection.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return &stream{
+		conn:   c,
+		ctx:    ctx,
+		desc:   desc,
+		method: method,
+		notify: make(chan struct{}, 1),
+	}, nil
+}
+
+// respond sets reply to the response of method to req, or returns its
+// error.
+func (c *Conn) respond(method string, req, reply proto.Message) error {
+	if err, ok := c.config.Errors[method]; ok {
+		return err
+	}
+	if resp, ok := c.config.Responses[method]; ok {
+		proto.Reset(reply)
+		proto.Merge(reply, resp)
+		return nil
+	}
+	Fill(reply, c.config)
+	if c.config.Echo && req != nil {
+		echo(proto.Clone(req).ProtoReflect(), reply.ProtoReflect())
+	}
+	return nil
+}
+
+// echo copies the fields of req to the fields of reply of the same name and
+// type.
+func echo(req, reply protoreflect.Message) {
+	fields := reply.Descriptor().Fields()
+	req.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if rfd := fields.ByName(fd.Name()); rfd != nil && sameType(fd, rfd) {
+			reply.Set(rfd, v)
+		}
+		return true
+	})
+}
+
+// sameType reports whether the fields are of the same type.
+func sameType(a, b protoreflect.FieldDescriptor) bool {
+	if a.IsMap() || b.IsMap() {
+		return a.IsMap() && b.IsMap() && sameType(a.MapKey(), b.MapKey()) && sameType(a.MapValue(), b.MapValue())
+	}
+	if a.Kind() != b.Kind() || a.Cardinality() != b.Cardinality() {
+		return false
+	}
+	switch a.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return a.Message().FullName() == b.Message().FullName()
+	case protoreflect.EnumKind:
+		return a.Enum().FullName() == b.Enum().FullName()
+	}
+	return true
+}
+
+// contextError returns the status of a call whose context is done with err.
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Canceled, err.Error())
+}
+
+// stream is a streaming call of a Conn.
+type stream struct {
+	conn   *Conn
+	ctx    context.Context
+	desc   *grpc.StreamDesc
+	method string
+
+	mu sync.Mutex
+	// pending are the messages sent and not yet replied to by a
+	// bidirectional stream.
+	pending []proto.Message
+	// last is the last message sent, echoed by the other streams.
+	last   proto.Message
+	closed bool
+	// received is the number of messages received.
+	received int
+	// notify is signaled when a message is sent or the stream is closed.
+	notify chan struct{}
+}
+
+var _ grpc.ClientStream = (*stream)(nil)
+
+func (s *stream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+func (s *stream) Trailer() metadata.MD {
+	return nil
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stream) SendMsg(m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "request %T is not a proto.Message", m)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return status.Error(codes.Internal, "SendMsg called after CloseSend")
+	}
+	s.last = proto.Clone(msg)
+	if s.desc.ClientStreams && s.desc.ServerStreams {
+		s.pending = append(s.pending, s.last)
+	}
+	s.signal()
+	return nil
+}
+
+func (s *stream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.signal()
+	return nil
+}
+
+// signal wakes up RecvMsg, s.mu being held.
+func (s *stream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// RecvMsg receives a reply to every message of a bidirectional stream, the
+// number of messages of the configuration from a server stream, and a
+// message from a client stream, once it is closed.
+func (s *stream) RecvMsg(m interface{}) error {
+	out, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "response %T is not a proto.Message", m)
+	}
+	for {
+		s.mu.Lock()
+		req, ok, done := s.next()
+		s.mu.Unlock()
+		if ok {
+			return s.conn.respond(s.method, req, out)
+		}
+		if done {
+			return io.EOF
+		}
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return contextError(s.ctx.Err())
+		}
+	}
+}
+
+// next returns the request the next message replies to, if any, s.mu being
+// held. It reports whether the stream ended.
+func (s *stream) next() (proto.Message, bool, bool) {
+	if s.desc.ClientStreams && s.desc.ServerStreams {
+		if len(s.pending) > 0 {
+			req := s.pending[0]
+			s.pending = s.pending[1:]
+			return req, true, false
+		}
+		return nil, false, s.closed
+	}
+	if !s.closed {
+		return nil, false, false
+	}
+	n := 1
+	if s.desc.ServerStreams {
+		n = s.conn.config.StreamLength
+	}
+	if s.received >= n {
+		return nil, false, true
+	}
+	s.received++
+	return s.last, true, false
+}
@@ -0,0 +1,219 @@
+package mock_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestFill(t *testing.T) {
+	m := new(examplepb.ABitOfEverything)
+	mock.Fill(m, mock.Config{})
+	for _, spec := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{name: "uuid", got: m.Uuid, want: "uuid"},
+		{name: "float_value", got: m.FloatValue, want: float32(1.5)},
+		{name: "int64_value", got: m.Int64Value, want: int64(1)},
+		{name: "bool_value", got: m.BoolValue, want: true},
+		{name: "bytes_value", got: string(m.BytesValue), want: "bytes_value"},
+		{name: "enum_value", got: m.EnumValue, want: examplepb.NumericEnum_ONE},
+		{name: "single_nested.ok", got: m.GetSingleNested().GetOk(), want: examplepb.ABitOfEverything_Nested_TRUE},
+		{name: "len(nested)", got: len(m.Nested), want: 1},
+		{name: "nested[0].amount", got: m.GetNested()[0].GetAmount(), want: uint32(1)},
+		{name: "mapped_string_value", got: m.MappedStringValue["key"], want: "value"},
+		{name: "oneof_empty", got: m.GetOneofEmpty() != nil, want: true},
+		{name: "timestamp_value", got: m.GetTimestampValue().GetSeconds(), want: int64(1136214245)},
+		{name: "repeated_string_value", got: len(m.RepeatedStringValue), want: 1},
+	} {
+		if spec.got != spec.want {
+			t.Errorf("%s = %v; want %v", spec.name, spec.got, spec.want)
+		}
+	}
+	if _, err := protojson.Marshal(m); err != nil {
+		t.Errorf("protojson.Marshal(%v) failed with %v; want success", m, err)
+	}
+}
+
+func TestFillDepth(t *testing.T) {
+	m := new(examplepb.Proto3Message)
+	mock.Fill(m, mock.Config{MaxDepth: 2, RepeatedLength: 2})
+	if m.GetNested().GetNested() == nil {
+		t.Fatalf("nested.nested = nil; want a message")
+	}
+	if got := m.GetNested().GetNested().GetNested(); got != nil {
+		t.Errorf("nested.nested.nested = %v; want nil beyond the maximum depth", got)
+	}
+	if got, want := m.GetNested().GetNested().GetWrapperStringValue().GetValue(), "value"; got != want {
+		t.Errorf("nested.nested.wrapper_string_value = %q; want %q", got, want)
+	}
+	if got, want := len(m.RepeatedValue), 2; got != want {
+		t.Errorf("len(repeated_value) = %d; want %d", got, want)
+	}
+	if got, want := m.MapValue3[2], "value"; got != want {
+		t.Errorf("map_value3[2] = %q; want %q", got, want)
+	}
+	if got := m.GetFieldmaskValue(); got != nil {
+		t.Errorf("fieldmask_value = %v; want nil", got)
+	}
+}
+
+func TestFillOpenAPIOptions(t *testing.T) {
+	fieldOptions := func(schema *options.JSONSchema) *descriptorpb.FieldOptions {
+		opts := new(descriptorpb.FieldOptions)
+		proto.SetExtension(opts, options.E_Openapiv2Field, schema)
+		return opts
+	}
+	messageOptions := new(descriptorpb.MessageOptions)
+	proto.SetExtension(messageOptions, options.E_Openapiv2Schema, &options.Schema{Example: `{"name": "example"}`})
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("mock_test.proto"),
+		Package: proto.String("mock.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Constrained"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("name"), Options: fieldOptions(&options.JSONSchema{MinLength: 6, MaxLength: 8})},
+					{Name: proto.String("count"), Number: proto.Int32(2), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), JsonName: proto.String("count"), Options: fieldOptions(&options.JSONSchema{Minimum: 10, Maximum: 20, ExclusiveMinimum: true})},
+					{Name: proto.String("color"), Number: proto.Int32(3), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("color"), Options: fieldOptions(&options.JSONSchema{Default: `"red"`})},
+					{Name: proto.String("tags"), Number: proto.Int32(4), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("tags"), Options: fieldOptions(&options.JSONSchema{MinItems: 2})},
+				},
+			},
+			{
+				Name: proto.String("WithExample"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("name")},
+					{Name: proto.String("count"), Number: proto.Int32(2), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), JsonName: proto.String("count")},
+				},
+				Options: messageOptions,
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() failed with %v; want success", err)
+	}
+
+	for name, want := range map[string]string{
+		"Constrained": `{"name":"namexx","count":11,"color":"red","tags":["tags","tags"]}`,
+		"WithExample": `{"name":"example"}`,
+	} {
+		m := dynamicpb.NewMessage(file.Messages().ByName(protoreflect.Name(name)))
+		mock.Fill(m, mock.Config{})
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			t.Fatalf("protojson.Marshal(%v) failed with %v; want success", m, err)
+		}
+		got, wantMsg := dynamicpb.NewMessage(m.Descriptor()), dynamicpb.NewMessage(m.Descriptor())
+		if err := protojson.Unmarshal(b, got); err != nil {
+			t.Fatalf("protojson.Unmarshal(%s) failed with %v; want success", b, err)
+		}
+		if err := protojson.Unmarshal([]byte(want), wantMsg); err != nil {
+			t.Fatalf("protojson.Unmarshal(%s) failed with %v; want success", want, err)
+		}
+		if !proto.Equal(got, wantMsg) {
+			t.Errorf("Fill(%s) = %s; want %s", name, b, want)
+		}
+	}
+}
+
+func TestConnInvoke(t *testing.T) {
+	notFound := status.Error(codes.NotFound, "not found")
+	conn := mock.NewConn(mock.Config{
+		Echo:      true,
+		Errors:    map[string]error{"/example.Service/Fail": notFound},
+		Responses: map[string]proto.Message{"/example.Service/Fixed": &examplepb.Proto3Message{StringValue: "fixed"}},
+	})
+	ctx := context.Background()
+
+	reply := new(examplepb.Proto3Message)
+	if err := conn.Invoke(ctx, "/example.Service/Echo", &examplepb.Proto3Message{StringValue: "echoed"}, reply); err != nil {
+		t.Fatalf("conn.Invoke() failed with %v; want success", err)
+	}
+	if got, want := reply.StringValue, "echoed"; got != want {
+		t.Errorf("reply.string_value = %q; want %q", got, want)
+	}
+	if got, want := reply.Int32Value, int32(1); got != want {
+		t.Errorf("reply.int32_value = %d; want %d", got, want)
+	}
+
+	if err := conn.Invoke(ctx, "/example.Service/Fixed", new(examplepb.Proto3Message), reply); err != nil {
+		t.Fatalf("conn.Invoke() failed with %v; want success", err)
+	}
+	if want := (&examplepb.Proto3Message{StringValue: "fixed"}); !proto.Equal(reply, want) {
+		t.Errorf("reply = %v; want %v", reply, want)
+	}
+
+	if err := conn.Invoke(ctx, "/example.Service/Fail", new(examplepb.Proto3Message), reply); err != notFound {
+		t.Errorf("conn.Invoke() failed with %v; want %v", err, notFound)
+	}
+}
+
+func TestConnServerStream(t *testing.T) {
+	conn := mock.NewConn(mock.Config{Echo: true, StreamLength: 2})
+	s, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/example.Service/List")
+	if err != nil {
+		t.Fatalf("conn.NewStream() failed with %v; want success", err)
+	}
+	if err := s.SendMsg(&examplepb.SimpleMessage{Id: "foo"}); err != nil {
+		t.Fatalf("s.SendMsg() failed with %v; want success", err)
+	}
+	if err := s.CloseSend(); err != nil {
+		t.Fatalf("s.CloseSend() failed with %v; want success", err)
+	}
+	for i := 0; i < 2; i++ {
+		msg := new(examplepb.SimpleMessage)
+		if err := s.RecvMsg(msg); err != nil {
+			t.Fatalf("s.RecvMsg() failed with %v; want success", err)
+		}
+		if got, want := msg.Id, "foo"; got != want {
+			t.Errorf("msg.id = %q; want %q", got, want)
+		}
+	}
+	if err := s.RecvMsg(new(examplepb.SimpleMessage)); err != io.EOF {
+		t.Errorf("s.RecvMsg() failed with %v; want io.EOF", err)
+	}
+}
+
+func TestConnBidiStream(t *testing.T) {
+	conn := mock.NewConn(mock.Config{Echo: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, "/example.Service/Chat")
+	if err != nil {
+		t.Fatalf("conn.NewStream() failed with %v; want success", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := s.SendMsg(&examplepb.SimpleMessage{Id: id}); err != nil {
+			t.Fatalf("s.SendMsg() failed with %v; want success", err)
+		}
+	}
+	for _, want := range []string{"a", "b"} {
+		msg := new(examplepb.SimpleMessage)
+		if err := s.RecvMsg(msg); err != nil {
+			t.Fatalf("s.RecvMsg() failed with %v; want success", err)
+		}
+		if msg.Id != want {
+			t.Errorf("msg.id = %q; want %q", msg.Id, want)
+		}
+	}
+	cancel()
+	if err := s.RecvMsg(new(examplepb.SimpleMessage)); status.Code(err) != codes.Canceled {
+		t.Errorf("s.RecvMsg() failed with %v; want a Canceled error", err)
+	}
+}
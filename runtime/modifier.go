@@ -0,0 +1,79 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/protobuf/proto"
+)
+
+// RequestModifierFunc modifies a decoded request before it is forwarded, e.g.
+// to inject the tenant of the caller or default its fields. The requests of
+// all the methods are passed to it, to be told apart with a type switch.
+type RequestModifierFunc func(ctx context.Context, req proto.Message) error
+
+// ForwardResponseRewriter returns the message forwarded in place of resp, e.g.
+// resp itself modified, or another message. Like the options given to
+// WithForwardResponseOption, it is passed the responses of all the methods,
+// and every message of the server streams.
+type ForwardResponseRewriter func(ctx context.Context, w http.ResponseWriter, resp proto.Message) (proto.Message, error)
+
+type requestModifiersKey struct{}
+
+// WithRequestModifier returns a ServeMuxOption passing the requests of the
+// generated handlers to fn once they are decoded, before they are validated
+// and passed to the per-method hooks. The
+// modifiers are called in the order they are given, and an error fails the
+// call with it.
+func WithRequestModifier(fn RequestModifierFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.requestModifiers = append(serveMux.requestModifiers, fn)
+	}
+}
+
+// WithForwardResponseRewriter returns a ServeMuxOption replacing the
+// responses with the messages returned by fn before they are forwarded, and
+// before the forward response options are called with them. The rewriters
+// are called in the order they are given, and an error is written as the
+// error of the call.
+func WithForwardResponseRewriter(fn ForwardResponseRewriter) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.responseRewriters = append(serveMux.responseRewriters, fn)
+	}
+}
+
+// ModifyRequest passes req to the RequestModifierFuncs of the ServeMux which
+// annotated the context, if any, and returns the first error.
+func ModifyRequest(ctx context.Context, req proto.Message) error {
+	modifiers, ok := ctx.Value(requestModifiersKey{}).([]RequestModifierFunc)
+	if !ok {
+		return nil
+	}
+	for _, fn := range modifiers {
+		if err := fn(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func withRequestModifiers(ctx context.Context, modifiers []RequestModifierFunc) context.Context {
+	if len(modifiers) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestModifiersKey{}, modifiers)
+}
+
+// rewriteResponse returns resp rewritten by the ForwardResponseRewriters of
+// s.
+func (s *ServeMux) rewriteResponse(ctx context.Context, w http.ResponseWriter, resp proto.Message) (proto.Message, error) {
+	for _, fn := range s.responseRewriters {
+		var err error
+		if resp, err = fn(ctx, w, resp); err != nil {
+			grpclog.Infof("Error rewriting the response: %v", err)
+			return nil, err
+		}
+	}
+	return resp, nil
+}
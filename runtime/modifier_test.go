@@ -0,0 +1,170 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestModifyRequest(t *testing.T) {
+	var calls []string
+	mux := runtime.NewServeMux(
+		runtime.WithRequestModifier(func(ctx context.Context, req proto.Message) error {
+			calls = append(calls, "tenant")
+			if msg, ok := req.(*pb.NonStandardMessage); ok {
+				msg.Id = "tenant-a"
+			}
+			return nil
+		}),
+		runtime.WithRequestModifier(func(ctx context.Context, req proto.Message) error {
+			calls = append(calls, "default")
+			if msg, ok := req.(*pb.NonStandardMessage); ok && msg.LangIdent == "" {
+				msg.LangIdent = "en"
+			}
+			return nil
+		}),
+	)
+	r := httptest.NewRequest("POST", "/v1/messages", nil)
+	ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.gateway.runtime.internal.examplepb.NonStandardService/Update")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+	}
+
+	msg := &pb.NonStandardMessage{}
+	if err := runtime.ModifyRequest(ctx, msg); err != nil {
+		t.Fatalf("runtime.ModifyRequest(ctx, msg) failed with %v; want success", err)
+	}
+	if want := (&pb.NonStandardMessage{Id: "tenant-a", LangIdent: "en"}); !proto.Equal(msg, want) {
+		t.Errorf("msg = %v; want %v", msg, want)
+	}
+	if got, want := strings.Join(calls, ","), "tenant,default"; got != want {
+		t.Errorf("calls = %q; want %q", got, want)
+	}
+
+	// Contexts not annotated by a mux with request modifiers are not
+	// modified.
+	msg = &pb.NonStandardMessage{}
+	if err := runtime.ModifyRequest(r.Context(), msg); err != nil || !proto.Equal(msg, &pb.NonStandardMessage{}) {
+		t.Errorf("runtime.ModifyRequest(r.Context(), msg) = %v, msg = %v; want success and no change", err, msg)
+	}
+}
+
+func TestModifyRequestError(t *testing.T) {
+	wantErr := errors.New("no tenant")
+	called := false
+	mux := runtime.NewServeMux(
+		runtime.WithRequestModifier(func(ctx context.Context, req proto.Message) error {
+			return wantErr
+		}),
+		runtime.WithRequestModifier(func(ctx context.Context, req proto.Message) error {
+			called = true
+			return nil
+		}),
+	)
+	r := httptest.NewRequest("POST", "/v1/messages", nil)
+	ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.gateway.runtime.internal.examplepb.NonStandardService/Update")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+	}
+	if err := runtime.ModifyRequest(ctx, &pb.NonStandardMessage{}); err != wantErr {
+		t.Errorf("runtime.ModifyRequest(ctx, msg) = %v; want %v", err, wantErr)
+	}
+	if called {
+		t.Errorf("the modifier after the failing one was called; want it skipped")
+	}
+}
+
+// redactID is a ForwardResponseRewriter replacing the id of the
+// NonStandardMessages with a new message.
+func redactID(ctx context.Context, w http.ResponseWriter, resp proto.Message) (proto.Message, error) {
+	msg, ok := resp.(*pb.NonStandardMessage)
+	if !ok {
+		return resp, nil
+	}
+	if msg.Id == "fail" {
+		return nil, errors.New("rewrite failed")
+	}
+	rewritten := proto.Clone(msg).(*pb.NonStandardMessage)
+	rewritten.Id = "redacted"
+	return rewritten, nil
+}
+
+func TestForwardResponseRewriter(t *testing.T) {
+	var forwarded proto.Message
+	mux := runtime.NewServeMux(
+		runtime.WithForwardResponseRewriter(redactID),
+		runtime.WithForwardResponseOption(func(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+			forwarded = resp
+			return nil
+		}),
+	)
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	marshaler := &runtime.JSONPb{}
+
+	for _, spec := range []struct {
+		id       string
+		wantCode int
+		want     *pb.NonStandardMessage
+	}{
+		{id: "a", wantCode: http.StatusOK, want: &pb.NonStandardMessage{Id: "redacted", LangIdent: "en"}},
+		{id: "fail", wantCode: http.StatusInternalServerError},
+	} {
+		t.Run(spec.id, func(t *testing.T) {
+			forwarded = nil
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/v1/messages/"+spec.id, nil)
+			resp := &pb.NonStandardMessage{Id: spec.id, LangIdent: "en"}
+			runtime.ForwardResponseMessage(ctx, mux, marshaler, w, r, resp, mux.GetForwardResponseOptions()...)
+			if w.Code != spec.wantCode {
+				t.Fatalf("w.Code = %d; want %d", w.Code, spec.wantCode)
+			}
+			if spec.wantCode != http.StatusOK {
+				return
+			}
+			got := &pb.NonStandardMessage{}
+			if err := protojson.Unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatalf("protojson.Unmarshal(%q, got) failed with %v; want success", w.Body, err)
+			}
+			if !proto.Equal(got, spec.want) {
+				t.Errorf("w.Body = %q; want %v", w.Body, spec.want)
+			}
+			if resp.Id != spec.id {
+				t.Errorf("resp.Id = %q; want the response unchanged", resp.Id)
+			}
+			if msg, ok := forwarded.(*pb.NonStandardMessage); !ok || msg.Id != "redacted" {
+				t.Errorf("forward response option called with %v; want the rewritten message", forwarded)
+			}
+		})
+	}
+}
+
+func TestForwardResponseRewriterStream(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithForwardResponseRewriter(redactID))
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	msgs := []*pb.NonStandardMessage{{Id: "a"}, {Id: "b"}}
+	recv := func() (proto.Message, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+		msg := msgs[0]
+		msgs = msgs[1:]
+		return msg, nil
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/v1/messages:stream", nil)
+	runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, r, recv)
+
+	want := `{"result":{"id":"redacted"}}` + "\n" + `{"result":{"id":"redacted"}}` + "\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+}
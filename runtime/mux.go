@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/textproto"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
 	"google.golang.org/grpc/codes"
@@ -31,6 +33,96 @@ type ServeMux struct {
 	streamErrorHandler        StreamErrorHandlerFunc
 	routingErrorHandler       RoutingErrorHandlerFunc
 	disablePathLengthFallback bool
+	// hooks maps fully qualified service names to the hooks registered for them.
+	hooks map[string]interface{}
+	// validator validates the decoded requests if set.
+	validator ValidatorFunc
+	// timeoutHeaders are the headers besides Grpc-Timeout read the timeout of a call from.
+	timeoutHeaders []string
+	// maxTimeout bounds the timeout of the calls if positive.
+	maxTimeout time.Duration
+	// errorTransformer transforms the status of the errors before they are handled if set.
+	errorTransformer ErrorTransformerFunc
+	// quotaFailureTooManyRequests replies to the errors with a QuotaFailure detail with 429.
+	quotaFailureTooManyRequests bool
+	// localizeErrors replaces the message of the errors with their localized message.
+	localizeErrors bool
+	// localizedErrorFallback are the locales of the localized messages used when none matches the request.
+	localizedErrorFallback []string
+	// errorBodies maps HTTP statuses and classes of HTTP statuses, e.g. "5xx", to the shape of the body of their errors.
+	errorBodies map[string]ErrorBodyFunc
+	// forwarded resolves the client of the requests from their forwarding headers if set.
+	forwarded *forwardedHeaders
+	// requestIDHeader is the header the ID of the requests is read from and echoed in if set.
+	requestIDHeader string
+	// requestIDGenerator generates the ID of the requests which have none.
+	requestIDGenerator RequestIDGeneratorFunc
+	// serverTiming adds a Server-Timing header to the responses.
+	serverTiming bool
+	// trailerHeaderMatcher selects the trailers written as headers in unary responses if set.
+	trailerHeaderMatcher HeaderMatcherFunc
+	// routeAnnotators are the metadata annotators given the route of the requests.
+	routeAnnotators []RouteAnnotatorFunc
+	// rateLimiter limits the rate of the requests to each route if set.
+	rateLimiter Limiter
+	// rateLimitKey returns the key the requests are limited by if set, instead of their client IP.
+	rateLimitKey RateLimitKeyFunc
+	// csrf protects the requests with mutating methods against cross-site request forgery if set.
+	csrf *csrfProtection
+	// clientCert forwards the verified client certificates of the requests and checks them if set.
+	clientCert *ClientCertificateForwarding
+	// signature verifies the HMAC signatures of the requests if set.
+	signature *SignatureVerification
+	// introspector validates the bearer tokens of the requests if set.
+	introspector *tokenIntrospector
+	// authorizer checks the requests to the annotated methods if set, instead of DefaultAuthorizer.
+	authorizer Authorizer
+	// credentials parses the credentials of the requests if set, instead of DefaultCredentials.
+	credentials CredentialsFunc
+	// audit logs the calls of the unary methods if set.
+	audit AuditFunc
+	// record records the calls of the unary methods if set.
+	record RecordFunc
+	// requestModifiers modify the decoded requests before they are forwarded.
+	requestModifiers []RequestModifierFunc
+	// responseRewriters replace the responses before they are forwarded.
+	responseRewriters []ForwardResponseRewriter
+	// tenant reads the tenant of the requests from their host or path prefix if set.
+	tenant *TenantExtraction
+	// partialResponse prunes the responses to the fields selected by the requests if set.
+	partialResponse bool
+	// filter parses the AIP-160 filters of the requests if set.
+	filter *FilterParsing
+	// pagination adds Link headers to the responses of paginated list methods if set.
+	pagination *PaginationFields
+	// streamJSONArray writes the server streams as JSON arrays if set.
+	streamJSONArray bool
+	// streamEnvelope is the envelope of the chunks of the server streams.
+	streamEnvelope StreamEnvelope
+	// streamFlush controls when the chunks of the server streams are flushed.
+	streamFlush StreamFlushPolicy
+	// heartbeat writes heartbeats to the idle server streams if set.
+	heartbeat *streamHeartbeat
+	// streamDisconnect is notified of the clients disconnecting from server streams if set.
+	streamDisconnect StreamDisconnectFunc
+	// lastEventID forwards the Last-Event-ID header of the requests if set.
+	lastEventID *LastEventID
+	// longPoll long-polls the server streams of its routes if set.
+	longPoll *streamLongPoll
+	// multiplexing serves the WebSocket endpoint multiplexing the streams of the clients if set.
+	multiplexing *StreamMultiplexing
+	// callRoutes keeps the route of the requests in the context of their calls for NewBackendSelector and NewHedgedBackend.
+	callRoutes bool
+	// responseCache serves the GET requests from the cached responses if set.
+	responseCache *responseCache
+	// marshalerQuery enables the query parameters adjusting the JSONPb marshaling of the responses if set.
+	marshalerQuery *MarshalerQueryOptions
+	// jsonpParam is the query parameter of the JSONP callbacks if set.
+	jsonpParam string
+	// bodyDecoders decode the bodies of the requests by encoding if set.
+	bodyDecoders map[string]BodyDecoderFunc
+	// webhooks maps the path templates of the routes to the adapters of their webhooks.
+	webhooks map[string]*WebhookAdapter
 }
 
 // ServeMuxOption is an option that can be given to a ServeMux on construction.
@@ -94,6 +186,20 @@ func WithOutgoingHeaderMatcher(fn HeaderMatcherFunc) ServeMuxOption {
 	}
 }
 
+// WithTrailerHeaderMatcher returns a ServeMuxOption promoting the trailers of
+// unary calls selected by fn to response headers, for the HTTP clients and
+// CDNs which cannot read HTTP trailers.
+//
+// The matcher is called with the key of each trailer metadata of the response.
+// If it returns true, the trailer is written as a header named as returned,
+// instead of as a trailer. The trailers of server-streaming calls are received
+// after the headers have been written, and are never promoted.
+func WithTrailerHeaderMatcher(fn HeaderMatcherFunc) ServeMuxOption {
+	return func(mux *ServeMux) {
+		mux.trailerHeaderMatcher = fn
+	}
+}
+
 // WithMetadata returns a ServeMuxOption for passing metadata to a gRPC context.
 //
 // This can be used by services that need to read from http.Request and modify gRPC context. A common use case
@@ -104,6 +210,16 @@ func WithMetadata(annotator func(context.Context, *http.Request) metadata.MD) Se
 	}
 }
 
+// WithRouteMetadata returns a ServeMuxOption for passing metadata to a gRPC
+// context, like WithMetadata, from the request and the route it was matched
+// to, e.g. to make per-route authorization decisions without parsing the URL
+// of the request again.
+func WithRouteMetadata(annotator RouteAnnotatorFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.routeAnnotators = append(serveMux.routeAnnotators, annotator)
+	}
+}
+
 // WithErrorHandler returns a ServeMuxOption for configuring a custom error handler.
 //
 // This can be used to configure a custom error response.
@@ -127,6 +243,20 @@ func WithStreamErrorHandler(fn StreamErrorHandlerFunc) ServeMuxOption {
 	}
 }
 
+// WithErrorTransformer returns a ServeMuxOption transforming the status of
+// every error before it is handled, by the error handler or, for errors
+// occurring once a server-streaming response has been started, by the stream
+// error handler.
+//
+// This can be used to strip internal debug details, stack traces or sensitive
+// messages from the errors, or to map them to user-facing codes, in a single
+// place.
+func WithErrorTransformer(fn ErrorTransformerFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.errorTransformer = fn
+	}
+}
+
 // WithRoutingErrorHandler returns a ServeMuxOption for configuring a custom error handler to  handle http routing errors.
 //
 // Method called for errors which can happen before gRPC route selected or executed.
@@ -144,6 +274,34 @@ func WithDisablePathLengthFallback() ServeMuxOption {
 	}
 }
 
+// WithQuotaFailureTooManyRequests returns a ServeMuxOption replying to the
+// errors with a google.rpc.QuotaFailure detail with 429 Too Many Requests,
+// whatever their code, in the default error handler.
+func WithQuotaFailureTooManyRequests() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.quotaFailureTooManyRequests = true
+	}
+}
+
+// WithTimeoutHeader returns a ServeMuxOption reading the timeout of the
+// forwarded calls from header, e.g. "X-Request-Timeout", when the request
+// has no Grpc-Timeout header. The value is either in the Grpc-Timeout format,
+// e.g. "100m", or a Go duration, e.g. "1.5s".
+func WithTimeoutHeader(header string) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.timeoutHeaders = append(serveMux.timeoutHeaders, textproto.CanonicalMIMEHeaderKey(header))
+	}
+}
+
+// WithMaxTimeout returns a ServeMuxOption bounding the timeout of the
+// forwarded calls to max, whatever the timeout requested by the client.
+// Calls without timeout get max as their timeout.
+func WithMaxTimeout(max time.Duration) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.maxTimeout = max
+	}
+}
+
 // NewServeMux returns a new ServeMux whose internal mapping is empty.
 func NewServeMux(opts ...ServeMuxOption) *ServeMux {
 	serveMux := &ServeMux{
@@ -193,8 +351,59 @@ func (s *ServeMux) HandlePath(meth string, pathPattern string, h HandlerFunc) er
 	return nil
 }
 
+// PathValueFunc returns the value of the path variable "name" of a request
+// routed by another router, e.g. chi.URLParam.
+type PathValueFunc func(r *http.Request, name string) string
+
+// PathValueHandler returns the handler registered to s for the pair of HTTP
+// method and path pattern as an http.Handler, to be registered to another
+// router. wildcards maps the names of the path variables of the router to the
+// names of the path parameters of the handler, and pathValue returns their
+// values.
+//
+// The requests are handled as configured by s, e.g. with its marshalers, error
+// handler and tenant extraction, but are not matched against s.
+func (s *ServeMux) PathValueHandler(meth string, pat Pattern, wildcards map[string]string, pathValue PathValueFunc) (http.Handler, error) {
+	var h HandlerFunc
+	for _, hh := range s.handlers[meth] {
+		if hh.pat.String() == pat.String() {
+			h = hh.h
+			break
+		}
+	}
+	if h == nil {
+		return nil, fmt.Errorf("no handler registered for %s %s", meth, pat)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = s.withRequestID(w, r)
+		r = s.withServerTiming(r)
+		var ok bool
+		if r, ok = s.withTenant(w, r); !ok {
+			return
+		}
+		pathParams := make(map[string]string, len(wildcards))
+		for wildcard, param := range wildcards {
+			pathParams[param] = pathValue(r, wildcard)
+		}
+		if r, ok = s.admit(w, r, pat); !ok {
+			return
+		}
+		s.jsonp(s.cached(h))(w, s.withRoute(r, pat, pathParams), pathParams)
+	}), nil
+}
+
 // ServeHTTP dispatches the request to the first handler whose pattern matches to r.Method and r.Path.
 func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.multiplexing != nil && r.URL.Path == s.multiplexing.Path {
+		s.serveMultiplexed(w, r)
+		return
+	}
+	r = s.withRequestID(w, r)
+	r = s.withServerTiming(r)
+	var ok bool
+	if r, ok = s.withTenant(w, r); !ok {
+		return
+	}
 	ctx := r.Context()
 
 	path := r.URL.Path
@@ -223,7 +432,7 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
 			_, outboundMarshaler := MarshalerForRequest(s, r)
 			sterr := status.Error(codes.InvalidArgument, err.Error())
-			s.errorHandler(ctx, s, outboundMarshaler, w, r, sterr)
+			HTTPError(ctx, s, outboundMarshaler, w, r, sterr)
 			return
 		}
 	}
@@ -232,12 +441,17 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			continue
 		}
-		h.h(w, r, pathParams)
+		var ok bool
+		if r, ok = s.admit(w, r, h.pat); !ok {
+			return
+		}
+		s.jsonp(s.cached(h.h))(w, s.withRoute(r, h.pat, pathParams), pathParams)
 		return
 	}
 
 	// lookup other methods to handle fallback from GET to POST and
-	// to determine if it is NotImplemented or NotFound.
+	// to determine if it is MethodNotAllowed or NotFound.
+	var allowed []string
 	for m, handlers := range s.handlers {
 		if m == r.Method {
 			continue
@@ -252,17 +466,26 @@ func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				if err := r.ParseForm(); err != nil {
 					_, outboundMarshaler := MarshalerForRequest(s, r)
 					sterr := status.Error(codes.InvalidArgument, err.Error())
-					s.errorHandler(ctx, s, outboundMarshaler, w, r, sterr)
+					HTTPError(ctx, s, outboundMarshaler, w, r, sterr)
+					return
+				}
+				var ok bool
+				if r, ok = s.admit(w, r, h.pat); !ok {
 					return
 				}
-				h.h(w, r, pathParams)
+				s.jsonp(s.cached(h.h))(w, s.withRoute(r, h.pat, pathParams), pathParams)
 				return
 			}
-			_, outboundMarshaler := MarshalerForRequest(s, r)
-			s.routingErrorHandler(ctx, s, outboundMarshaler, w, r, http.StatusMethodNotAllowed)
-			return
+			allowed = append(allowed, m)
+			break
 		}
 	}
+	if len(allowed) > 0 {
+		sort.Strings(allowed)
+		_, outboundMarshaler := MarshalerForRequest(s, r)
+		s.routingErrorHandler(withAllowedMethods(ctx, allowed), s, outboundMarshaler, w, r, http.StatusMethodNotAllowed)
+		return
+	}
 
 	_, outboundMarshaler := MarshalerForRequest(s, r)
 	s.routingErrorHandler(ctx, s, outboundMarshaler, w, r, http.StatusNotFound)
@@ -273,6 +496,41 @@ func (s *ServeMux) GetForwardResponseOptions() []func(context.Context, http.Resp
 	return s.forwardResponseOptions
 }
 
+// admit replies with an error and returns false if r to the route pat is
+// rejected by the client certificate policy, the signature verification, the
+// token introspection, the CSRF protection, the rate limiter, the filter
+// parsing, the long poll continuation tokens, the marshaler query options,
+// the body decoders or the webhook adapters of the mux. It returns r with the
+// context, the query and the body the request is handled with otherwise.
+func (s *ServeMux) admit(w http.ResponseWriter, r *http.Request, pat Pattern) (*http.Request, bool) {
+	if !s.checkClientCertificate(w, r) || !s.checkSignature(w, r) {
+		return r, false
+	}
+	r, ok := s.checkToken(w, r)
+	if !ok {
+		return r, false
+	}
+	if !s.checkCSRF(w, r, pat) || !s.rateLimit(w, r, pat) {
+		return r, false
+	}
+	if r, ok = s.checkFilter(w, r); !ok {
+		return r, false
+	}
+	if r, ok = s.checkLongPoll(w, r, pat); !ok {
+		return r, false
+	}
+	if r, ok = s.checkMarshalerQuery(w, r); !ok {
+		return r, false
+	}
+	if r, ok = s.decodeBody(w, r); !ok {
+		return r, false
+	}
+	if r, ok = s.adaptWebhook(w, r, pat); !ok {
+		return r, false
+	}
+	return s.withLastEventID(r), true
+}
+
 func (s *ServeMux) isPathLengthFallback(r *http.Request) bool {
 	return !s.disablePathLengthFallback && r.Method == "POST" && r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
 }
@@ -2,9 +2,11 @@ package runtime_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -107,7 +109,7 @@ func TestMuxServeHTTP(t *testing.T) {
 			},
 			reqMethod:  "DELETE",
 			reqPath:    "/foo",
-			respStatus: http.StatusNotImplemented,
+			respStatus: http.StatusMethodNotAllowed,
 		},
 		{
 			patterns: []stubPattern{
@@ -138,7 +140,7 @@ func TestMuxServeHTTP(t *testing.T) {
 			headers: map[string]string{
 				"Content-Type": "application/x-www-form-urlencoded",
 			},
-			respStatus:                http.StatusNotImplemented,
+			respStatus:                http.StatusMethodNotAllowed,
 			disablePathLengthFallback: true,
 		},
 		{
@@ -198,7 +200,7 @@ func TestMuxServeHTTP(t *testing.T) {
 			headers: map[string]string{
 				"Content-Type": "application/json",
 			},
-			respStatus: http.StatusNotImplemented,
+			respStatus: http.StatusMethodNotAllowed,
 		},
 		{
 			patterns: []stubPattern{
@@ -439,3 +441,73 @@ func TestServeMux_HandlePath(t *testing.T) {
 	}
 
 }
+
+func TestServeMux_PathValueHandler(t *testing.T) {
+	pat := runtime.MustPattern(runtime.NewPattern(1, []int{
+		int(utilities.OpLitPush), 0,
+		int(utilities.OpPush), 0,
+		int(utilities.OpConcatN), 1,
+		int(utilities.OpCapture), 1,
+	}, []string{"v1", "user.id"}, ""))
+	mux := runtime.NewServeMux()
+	mux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		fmt.Fprint(w, pathParams["user.id"])
+	})
+
+	pathValue := func(r *http.Request, name string) string {
+		return r.Header.Get("Path-" + name)
+	}
+	h, err := mux.PathValueHandler("GET", pat, map[string]string{"user_id": "user.id"}, pathValue)
+	if err != nil {
+		t.Fatalf("mux.PathValueHandler(...) failed with %v; want success", err)
+	}
+	r := httptest.NewRequest("GET", "http://example.com/users/42", nil)
+	r.Header.Set("Path-user_id", "42")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "42"; got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+
+	if _, err := mux.PathValueHandler("POST", pat, nil, pathValue); err == nil {
+		t.Errorf("mux.PathValueHandler(%q, ...) succeeded; want an error for a pattern without handler", "POST")
+	}
+}
+
+func TestMuxServeHTTPMethodNotAllowed(t *testing.T) {
+	pat := runtime.MustPattern(runtime.NewPattern(1, []int{int(utilities.OpLitPush), 0}, []string{"foo"}, ""))
+	var routedMethods []string
+	for _, spec := range []struct {
+		name string
+		opts []runtime.ServeMuxOption
+	}{
+		{name: "default"},
+		{
+			name: "custom",
+			opts: []runtime.ServeMuxOption{runtime.WithRoutingErrorHandler(func(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+				routedMethods, _ = runtime.AllowedMethods(ctx)
+				runtime.DefaultRoutingErrorHandler(ctx, mux, marshaler, w, r, httpStatus)
+			})},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(spec.opts...)
+			for _, method := range []string{"PUT", "GET"} {
+				mux.Handle(method, pat, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {})
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("DELETE", "http://host.example/foo", nil))
+
+			if got, want := w.Code, http.StatusMethodNotAllowed; got != want {
+				t.Errorf("w.Code = %d; want %d", got, want)
+			}
+			if got, want := w.Header().Get("Allow"), "GET, PUT"; got != want {
+				t.Errorf(`w.Header().Get("Allow") = %q; want %q`, got, want)
+			}
+		})
+	}
+	if want := []string{"GET", "PUT"}; !reflect.DeepEqual(routedMethods, want) {
+		t.Errorf("runtime.AllowedMethods(ctx) = %q; want %q", routedMethods, want)
+	}
+}
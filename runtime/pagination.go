@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PaginationFields are the names of the fields of the list methods following
+// AIP-158 pagination, as proto or JSON names.
+type PaginationFields struct {
+	// PageToken is the request field, populated by the query parameter of
+	// the same name, holding the token of the requested page.
+	PageToken string
+	// NextPageToken is the response field holding the token of the next page.
+	NextPageToken string
+	// PrevPageToken is the response field holding the token of the previous
+	// page, if the method returns one.
+	PrevPageToken string
+}
+
+// DefaultPaginationFields are the pagination fields of AIP-158.
+var DefaultPaginationFields = PaginationFields{
+	PageToken:     "page_token",
+	NextPageToken: "next_page_token",
+	PrevPageToken: "prev_page_token",
+}
+
+// WithPaginationLinks returns a ServeMuxOption adding RFC 8288 Link headers
+// to the responses of the GET requests of paginated list methods, so that
+// generic REST clients can browse them. A response with a non-empty next page
+// token gets a "next" link, one with a non-empty previous page token a "prev"
+// link, and the response to a request for a page other than the first one a
+// "first" link.
+//
+// The links are the URL of the request as sent by the client with the page
+// token query parameter replaced, so the other parameters, such as the page
+// size, are kept. Their scheme and host are resolved by WithForwardedHeaders
+// if set, and are those of the request otherwise.
+func WithPaginationLinks(fields PaginationFields) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.pagination = &fields
+	}
+}
+
+// writePaginationLinks adds the Link headers of resp, the response to req, to w.
+func (s *ServeMux) writePaginationLinks(w http.ResponseWriter, req *http.Request, resp proto.Message) {
+	p := s.pagination
+	if p == nil || req.Method != http.MethodGet || resp == nil || !resp.ProtoReflect().IsValid() {
+		return
+	}
+	m := resp.ProtoReflect()
+	next := stringField(m, p.NextPageToken)
+	prev := stringField(m, p.PrevPageToken)
+	query := req.URL.Query()
+	current := query.Get(p.PageToken)
+	if next == "" && prev == "" && current == "" {
+		return
+	}
+
+	u := s.externalURL(req)
+	link := func(token, rel string) {
+		q := u.Query()
+		q.Del(p.PageToken)
+		if token != "" {
+			q.Set(p.PageToken, token)
+		}
+		l := *u
+		l.RawQuery = q.Encode()
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=%q", l.String(), rel))
+	}
+	if next != "" {
+		link(next, "next")
+	}
+	if prev != "" {
+		link(prev, "prev")
+	}
+	if current != "" {
+		link("", "first")
+	}
+}
+
+// externalURL returns the absolute URL of req as sent by the client.
+func (s *ServeMux) externalURL(req *http.Request) *url.URL {
+	u := *req.URL
+	// The URL of the request may have been rewritten on its way to the mux,
+	// e.g. by http.StripPrefix, unlike the request URI.
+	if req.RequestURI != "" {
+		if ru, err := url.ParseRequestURI(req.RequestURI); err == nil {
+			u.Path, u.RawPath = ru.Path, ru.RawPath
+		}
+	}
+	u.Scheme, u.Host = "http", req.Host
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	if s.forwarded != nil {
+		_, u.Scheme, u.Host = s.forwarded.resolve(req)
+	}
+	u.User, u.Fragment = nil, ""
+	return &u
+}
+
+// stringField returns the value of the string field of m named name, or ""
+// if m has no such field.
+func stringField(m protoreflect.Message, name string) string {
+	if name == "" {
+		return ""
+	}
+	fd := getFieldByName(m.Descriptor().Fields(), name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.Cardinality() == protoreflect.Repeated {
+		return ""
+	}
+	return m.Get(fd).String()
+}
@@ -0,0 +1,126 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// newListResponse returns a dynamic list response with the given page tokens.
+func newListResponse(t *testing.T, next, prev string) *dynamicpb.Message {
+	field := func(name, jsonName string, number int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+	}
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("list.proto"),
+		Package: proto.String("example"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("ListBooksResponse"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("next_page_token", "nextPageToken", 1),
+				field("prev_page_token", "prevPageToken", 2),
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, new(protoregistry.Files))
+	if err != nil {
+		t.Fatalf("protodesc.NewFile(%v) failed with %v; want success", fd, err)
+	}
+	msg := dynamicpb.NewMessage(file.Messages().Get(0))
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("next_page_token"), protoreflect.ValueOfString(next))
+	msg.Set(fields.ByName("prev_page_token"), protoreflect.ValueOfString(prev))
+	return msg
+}
+
+func TestForwardResponseMessagePaginationLinks(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		method string
+		target string
+		header map[string]string
+		next   string
+		prev   string
+		opts   []runtime.ServeMuxOption
+		want   []string
+	}{
+		{
+			name:   "first page",
+			method: "GET",
+			target: "http://example.com/v1/books?page_size=10",
+			next:   "abc",
+			want:   []string{`<http://example.com/v1/books?page_size=10&page_token=abc>; rel="next"`},
+		},
+		{
+			name:   "middle page",
+			method: "GET",
+			target: "http://example.com/v1/shelves/1/books?page_token=abc&page_size=10",
+			next:   "d/e f",
+			prev:   "0",
+			want: []string{
+				`<http://example.com/v1/shelves/1/books?page_size=10&page_token=d%2Fe+f>; rel="next"`,
+				`<http://example.com/v1/shelves/1/books?page_size=10&page_token=0>; rel="prev"`,
+				`<http://example.com/v1/shelves/1/books?page_size=10>; rel="first"`,
+			},
+		},
+		{
+			name:   "last page",
+			method: "GET",
+			target: "http://example.com/v1/books?page_token=abc",
+			want:   []string{`<http://example.com/v1/books>; rel="first"`},
+		},
+		{
+			name:   "single page",
+			method: "GET",
+			target: "http://example.com/v1/books",
+		},
+		{
+			name:   "not a GET request",
+			method: "POST",
+			target: "http://example.com/v1/books:search",
+			next:   "abc",
+		},
+		{
+			name:   "forwarded",
+			method: "GET",
+			target: "http://example.com/v1/books",
+			header: map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "api.example.com"},
+			next:   "abc",
+			opts:   []runtime.ServeMuxOption{runtime.WithForwardedHeaders([]string{"192.0.2.0/24"}, runtime.DefaultForwardedMetadataKeys)},
+			want:   []string{`<https://api.example.com/v1/books?page_token=abc>; rel="next"`},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			opts := append([]runtime.ServeMuxOption{runtime.WithPaginationLinks(runtime.DefaultPaginationFields)}, spec.opts...)
+			mux := runtime.NewServeMux(opts...)
+			req := httptest.NewRequest(spec.method, spec.target, nil)
+			for k, v := range spec.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, req, newListResponse(t, spec.next, spec.prev))
+
+			if got := w.Header().Values("Link"); !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("Link = %q; want %q", got, spec.want)
+			}
+		})
+	}
+}
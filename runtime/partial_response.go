@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// partialResponseParams are the query parameters selecting the fields of the
+// responses, by precedence.
+var partialResponseParams = []string{"$fields", "fields"}
+
+// WithPartialResponse returns a ServeMuxOption pruning the responses to the
+// fields selected by the "$fields" or "fields" query parameter of the
+// requests, so that clients can only fetch what they need. The parameter is
+// a comma-separated list of FieldMask paths, e.g.
+// "?fields=uuid,single_nested.name", whose components are the proto or JSON
+// names of the fields. A "*" component selects all the fields of a message.
+// The requests selecting an unknown field are replied to with an
+// InvalidArgument error.
+//
+// The "fields" parameter also populates a request field named "fields", so
+// the requests of methods with one should use "$fields".
+func WithPartialResponse() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.partialResponse = true
+	}
+}
+
+// fieldSelection maps the names of the selected fields of a message to the
+// selection of their subfields. A field selected as a whole maps to nil.
+type fieldSelection map[string]fieldSelection
+
+// responseFieldSelection returns the selection of the response fields of r,
+// or nil if the mux does not prune responses or r selects no field.
+func (s *ServeMux) responseFieldSelection(r *http.Request) fieldSelection {
+	if !s.partialResponse {
+		return nil
+	}
+	query := r.URL.Query()
+	var values []string
+	for _, param := range partialResponseParams {
+		if values = query[param]; len(values) > 0 {
+			break
+		}
+	}
+	var sel fieldSelection
+	for _, value := range values {
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path == "" {
+				continue
+			}
+			if sel == nil {
+				sel = make(fieldSelection)
+			}
+			sel.add(strings.Split(path, "."))
+		}
+	}
+	return sel
+}
+
+func (sel fieldSelection) add(path []string) {
+	child, ok := sel[path[0]]
+	switch {
+	case len(path) == 1:
+		sel[path[0]] = nil
+	case ok && child == nil:
+		// The field is already selected as a whole.
+	default:
+		if child == nil {
+			child = make(fieldSelection)
+			sel[path[0]] = child
+		}
+		child.add(path[1:])
+	}
+}
+
+// selectFields returns a copy of body pruned to the fields of sel if it is a
+// message, or body as is otherwise.
+func selectFields(body interface{}, sel fieldSelection) (interface{}, error) {
+	msg, ok := body.(proto.Message)
+	if sel == nil || !ok || msg == nil || !msg.ProtoReflect().IsValid() {
+		return body, nil
+	}
+	if _, ok := msg.(*httpbody.HttpBody); ok {
+		return body, nil
+	}
+	if err := sel.validate(msg.ProtoReflect().Descriptor()); err != nil {
+		return nil, err
+	}
+	clone := proto.Clone(msg)
+	sel.prune(clone.ProtoReflect())
+	return clone, nil
+}
+
+// validate returns an error if sel selects a field md does not have.
+func (sel fieldSelection) validate(md protoreflect.MessageDescriptor) error {
+	for name, child := range sel {
+		if name == "*" {
+			if child != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid field selection: \"*\" must be the last component of a path")
+			}
+			continue
+		}
+		fd := getFieldByName(md.Fields(), name)
+		if fd == nil {
+			return status.Errorf(codes.InvalidArgument, "invalid field selection: no field %q in %q", name, md.FullName())
+		}
+		if child == nil {
+			continue
+		}
+		sub := fd.Message()
+		if fd.IsMap() {
+			sub = fd.MapValue().Message()
+		}
+		if sub == nil {
+			return status.Errorf(codes.InvalidArgument, "invalid field selection: field %q of %q has no subfields", name, md.FullName())
+		}
+		if err := child.validate(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune clears the fields of m which are not selected by sel.
+func (sel fieldSelection) prune(m protoreflect.Message) {
+	if _, ok := sel["*"]; ok {
+		return
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := sel[string(fd.Name())]
+		if !ok {
+			child, ok = sel[fd.JSONName()]
+		}
+		switch {
+		case !ok:
+			m.Clear(fd)
+		case child == nil:
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				child.prune(mv.Message())
+				return true
+			})
+		case fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				child.prune(l.Get(i).Message())
+			}
+		default:
+			child.prune(v.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,113 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardResponseMessagePartialResponse(t *testing.T) {
+	msg := &pb.ABitOfEverything{
+		Uuid:         "6EC2446F-7E89-4127-B3E6-5C05E6BECBA7",
+		StringValue:  "strprefix/foo",
+		SingleNested: &pb.ABitOfEverything_Nested{Name: "foo", Amount: 10},
+		Nested: []*pb.ABitOfEverything_Nested{
+			{Name: "bar", Amount: 20},
+			{Name: "baz", Amount: 30},
+		},
+		MappedNestedValue: map[string]*pb.ABitOfEverything_Nested{
+			"a": {Name: "qux", Amount: 40},
+		},
+	}
+	for _, spec := range []struct {
+		name   string
+		query  string
+		status int
+		want   *pb.ABitOfEverything
+	}{
+		{
+			name:   "no selection",
+			status: http.StatusOK,
+			want:   msg,
+		},
+		{
+			name:   "proto names",
+			query:  "fields=uuid,single_nested.name",
+			status: http.StatusOK,
+			want: &pb.ABitOfEverything{
+				Uuid:         msg.Uuid,
+				SingleNested: &pb.ABitOfEverything_Nested{Name: "foo"},
+			},
+		},
+		{
+			name:   "JSON names",
+			query:  "$fields=singleNested,nested.amount&fields=uuid",
+			status: http.StatusOK,
+			want: &pb.ABitOfEverything{
+				SingleNested: msg.SingleNested,
+				Nested: []*pb.ABitOfEverything_Nested{
+					{Amount: 20},
+					{Amount: 30},
+				},
+			},
+		},
+		{
+			name:   "map values",
+			query:  "fields=mapped_nested_value.amount",
+			status: http.StatusOK,
+			want: &pb.ABitOfEverything{
+				MappedNestedValue: map[string]*pb.ABitOfEverything_Nested{
+					"a": {Amount: 40},
+				},
+			},
+		},
+		{
+			name:   "wildcard",
+			query:  "fields=single_nested.*",
+			status: http.StatusOK,
+			want:   &pb.ABitOfEverything{SingleNested: msg.SingleNested},
+		},
+		{
+			name:   "unknown field",
+			query:  "fields=uuid,unknown",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "scalar subfield",
+			query:  "fields=uuid.name",
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			mux := runtime.NewServeMux(runtime.WithPartialResponse())
+			req := httptest.NewRequest("GET", "http://example.com/foo?"+spec.query, nil)
+			w := httptest.NewRecorder()
+			marshaler := &runtime.JSONPb{}
+
+			runtime.ForwardResponseMessage(ctx, mux, marshaler, w, req, msg)
+
+			if w.Code != spec.status {
+				t.Fatalf("w.Code = %d; want %d", w.Code, spec.status)
+			}
+			if spec.want == nil {
+				return
+			}
+			got := &pb.ABitOfEverything{}
+			if err := marshaler.Unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatalf("marshaler.Unmarshal(%s, got) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			if !proto.Equal(got, spec.want) {
+				t.Errorf("response = %v; want %v", got, spec.want)
+			}
+		})
+	}
+	if msg.StringValue == "" || msg.SingleNested.Amount != 10 {
+		t.Errorf("msg = %v; want the response left unchanged", msg)
+	}
+}
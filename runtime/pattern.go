@@ -3,6 +3,7 @@ package runtime
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
@@ -205,6 +206,65 @@ func (p Pattern) Match(components []string, verb string) (map[string]string, err
 	return bindings, nil
 }
 
+// Build renders the Pattern into a path, substituting the variables with the
+// given values. It is the reverse of Match: the values of variables matching a
+// single path component are escaped as a whole, while the values of variables
+// matching several components are split at '/' and each component is escaped.
+// Variables without a value are rendered empty.
+func (p Pattern) Build(values map[string]string) string {
+	type item struct {
+		value string
+		// single is true for items matching a single path component.
+		single bool
+	}
+	stack := make([]item, 0, p.stacksize)
+	for _, op := range p.ops {
+		switch op.code {
+		case utilities.OpNop:
+			continue
+		case utilities.OpPush:
+			stack = append(stack, item{value: "*", single: true})
+		case utilities.OpLitPush:
+			stack = append(stack, item{value: p.pool[op.operand]})
+		case utilities.OpPushM:
+			stack = append(stack, item{value: "**"})
+		case utilities.OpConcatN:
+			n := op.operand
+			if n == 1 {
+				continue
+			}
+			l := len(stack) - n
+			segs := make([]string, 0, n)
+			for _, it := range stack[l:] {
+				segs = append(segs, it.value)
+			}
+			stack = append(stack[:l], item{value: strings.Join(segs, "/")})
+		case utilities.OpCapture:
+			n := len(stack) - 1
+			value := values[p.vars[op.operand]]
+			if stack[n].single {
+				value = url.PathEscape(value)
+			} else {
+				segs := strings.Split(value, "/")
+				for i, seg := range segs {
+					segs[i] = url.PathEscape(seg)
+				}
+				value = strings.Join(segs, "/")
+			}
+			stack[n] = item{value: value}
+		}
+	}
+	segs := make([]string, 0, len(stack))
+	for _, it := range stack {
+		segs = append(segs, it.value)
+	}
+	path := "/" + strings.Join(segs, "/")
+	if p.verb != "" {
+		path += ":" + p.verb
+	}
+	return path
+}
+
 // Verb returns the verb part of the Pattern.
 func (p Pattern) Verb() string { return p.verb }
 
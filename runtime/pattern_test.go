@@ -588,3 +588,85 @@ func TestPatternString(t *testing.T) {
 		}
 	}
 }
+
+func TestPatternBuild(t *testing.T) {
+	for _, spec := range []struct {
+		ops    []int
+		pool   []string
+		verb   string
+		values map[string]string
+
+		want string
+	}{
+		{
+			want: "/",
+		},
+		{
+			ops:  []int{int(utilities.OpLitPush), 0},
+			pool: []string{"endpoint"},
+			want: "/endpoint",
+		},
+		{
+			ops: []int{
+				int(utilities.OpLitPush), 0,
+				int(utilities.OpLitPush), 1,
+				int(utilities.OpPush), anything,
+				int(utilities.OpConcatN), 1,
+				int(utilities.OpCapture), 2,
+			},
+			pool:   []string{"v1", "users", "id"},
+			values: map[string]string{"id": "a/b c"},
+			want:   "/v1/users/a%2Fb%20c",
+		},
+		{
+			ops: []int{
+				int(utilities.OpLitPush), 0,
+				int(utilities.OpPush), anything,
+				int(utilities.OpConcatN), 1,
+				int(utilities.OpCapture), 1,
+			},
+			pool:   []string{"v1", "name"},
+			verb:   "undelete",
+			values: map[string]string{"name": "my name"},
+			want:   "/v1/my%20name:undelete",
+		},
+		{
+			ops: []int{
+				int(utilities.OpLitPush), 0,
+				int(utilities.OpLitPush), 1,
+				int(utilities.OpPush), anything,
+				int(utilities.OpConcatN), 2,
+				int(utilities.OpCapture), 2,
+				int(utilities.OpLitPush), 3,
+				int(utilities.OpPushM), anything,
+				int(utilities.OpConcatN), 2,
+				int(utilities.OpCapture), 4,
+			},
+			pool: []string{"v1", "buckets", "bucket_name", "objects", "name"},
+			values: map[string]string{
+				"bucket_name": "buckets/my bucket",
+				"name":        "objects/dir/file?.txt",
+			},
+			want: "/v1/buckets/my%20bucket/objects/dir/file%3F.txt",
+		},
+		{
+			ops: []int{
+				int(utilities.OpLitPush), 0,
+				int(utilities.OpPush), anything,
+				int(utilities.OpConcatN), 1,
+				int(utilities.OpCapture), 1,
+			},
+			pool: []string{"v1", "id"},
+			want: "/v1/",
+		},
+	} {
+		p, err := NewPattern(validVersion, spec.ops, spec.pool, spec.verb)
+		if err != nil {
+			t.Errorf("NewPattern(%d, %v, %q, %q) failed with %v; want success", validVersion, spec.ops, spec.pool, spec.verb, err)
+			continue
+		}
+		if got, want := p.Build(spec.values), spec.want; got != want {
+			t.Errorf("%v.Build(%v) = %q; want %q", p, spec.values, got, want)
+		}
+	}
+}
@@ -40,6 +40,11 @@ type defaultQueryParser struct{}
 // Parse populates "values" into "msg".
 // A value is ignored if its key starts with one of the elements in "filter".
 func (*defaultQueryParser) Parse(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	return populateQueryValues(msg, values, filter, parseField)
+}
+
+// populateQueryValues populates "values" into "msg", parsing every value with "parse".
+func populateQueryValues(msg proto.Message, values url.Values, filter *utilities.DoubleArray, parse fieldParser) error {
 	for key, values := range values {
 		match := valuesKeyRegexp.FindStringSubmatch(key)
 		if len(match) == 3 {
@@ -50,8 +55,8 @@ func (*defaultQueryParser) Parse(msg proto.Message, values url.Values, filter *u
 		if filter.HasCommonPrefix(fieldPath) {
 			continue
 		}
-		if err := populateFieldValueFromPath(msg.ProtoReflect(), fieldPath, values); err != nil {
-			return err
+		if err := populateFieldValueFromPath(msg.ProtoReflect(), fieldPath, values, parse); err != nil {
+			return &FieldError{Field: key, Err: err}
 		}
 	}
 	return nil
@@ -60,10 +65,20 @@ func (*defaultQueryParser) Parse(msg proto.Message, values url.Values, filter *u
 // PopulateFieldFromPath sets a value in a nested Protobuf structure.
 func PopulateFieldFromPath(msg proto.Message, fieldPathString string, value string) error {
 	fieldPath := strings.Split(fieldPathString, ".")
-	return populateFieldValueFromPath(msg.ProtoReflect(), fieldPath, []string{value})
+	parse := parseField
+	if p := localizedParser(); p != nil {
+		parse = p.parseField
+	}
+	if err := populateFieldValueFromPath(msg.ProtoReflect(), fieldPath, []string{value}, parse); err != nil {
+		return &FieldError{Field: fieldPathString, Err: err}
+	}
+	return nil
 }
 
-func populateFieldValueFromPath(msgValue protoreflect.Message, fieldPath []string, values []string) error {
+// fieldParser parses the string representation of a value of a field.
+type fieldParser func(fieldDescriptor protoreflect.FieldDescriptor, value string) (protoreflect.Value, error)
+
+func populateFieldValueFromPath(msgValue protoreflect.Message, fieldPath []string, values []string, parse fieldParser) error {
 	if len(fieldPath) < 1 {
 		return errors.New("no field path")
 	}
@@ -101,8 +116,9 @@ func populateFieldValueFromPath(msgValue protoreflect.Message, fieldPath []strin
 		msgValue = msgValue.Mutable(fieldDescriptor).Message()
 	}
 
-	// Check if oneof already set
-	if of := fieldDescriptor.ContainingOneof(); of != nil {
+	// Check if oneof already set. The synthetic oneofs of proto3 optional
+	// fields only track their presence.
+	if of := fieldDescriptor.ContainingOneof(); of != nil && !of.IsSynthetic() {
 		if f := msgValue.WhichOneof(of); f != nil {
 			return fmt.Errorf("field already set for oneof %q", of.FullName().Name())
 		}
@@ -110,20 +126,20 @@ func populateFieldValueFromPath(msgValue protoreflect.Message, fieldPath []strin
 
 	switch {
 	case fieldDescriptor.IsList():
-		return populateRepeatedField(fieldDescriptor, msgValue.Mutable(fieldDescriptor).List(), values)
+		return populateRepeatedField(fieldDescriptor, msgValue.Mutable(fieldDescriptor).List(), values, parse)
 	case fieldDescriptor.IsMap():
-		return populateMapField(fieldDescriptor, msgValue.Mutable(fieldDescriptor).Map(), values)
+		return populateMapField(fieldDescriptor, msgValue.Mutable(fieldDescriptor).Map(), values, parse)
 	}
 
 	if len(values) > 1 {
 		return fmt.Errorf("too many values for field %q: %s", fieldDescriptor.FullName().Name(), strings.Join(values, ", "))
 	}
 
-	return populateField(fieldDescriptor, msgValue, values[0])
+	return populateField(fieldDescriptor, msgValue, values[0], parse)
 }
 
-func populateField(fieldDescriptor protoreflect.FieldDescriptor, msgValue protoreflect.Message, value string) error {
-	v, err := parseField(fieldDescriptor, value)
+func populateField(fieldDescriptor protoreflect.FieldDescriptor, msgValue protoreflect.Message, value string, parse fieldParser) error {
+	v, err := parse(fieldDescriptor, value)
 	if err != nil {
 		return fmt.Errorf("parsing field %q: %w", fieldDescriptor.FullName().Name(), err)
 	}
@@ -132,9 +148,9 @@ func populateField(fieldDescriptor protoreflect.FieldDescriptor, msgValue protor
 	return nil
 }
 
-func populateRepeatedField(fieldDescriptor protoreflect.FieldDescriptor, list protoreflect.List, values []string) error {
+func populateRepeatedField(fieldDescriptor protoreflect.FieldDescriptor, list protoreflect.List, values []string, parse fieldParser) error {
 	for _, value := range values {
-		v, err := parseField(fieldDescriptor, value)
+		v, err := parse(fieldDescriptor, value)
 		if err != nil {
 			return fmt.Errorf("parsing list %q: %w", fieldDescriptor.FullName().Name(), err)
 		}
@@ -144,17 +160,17 @@ func populateRepeatedField(fieldDescriptor protoreflect.FieldDescriptor, list pr
 	return nil
 }
 
-func populateMapField(fieldDescriptor protoreflect.FieldDescriptor, mp protoreflect.Map, values []string) error {
+func populateMapField(fieldDescriptor protoreflect.FieldDescriptor, mp protoreflect.Map, values []string, parse fieldParser) error {
 	if len(values) != 2 {
 		return fmt.Errorf("more than one value provided for key %q in map %q", values[0], fieldDescriptor.FullName())
 	}
 
-	key, err := parseField(fieldDescriptor.MapKey(), values[0])
+	key, err := parse(fieldDescriptor.MapKey(), values[0])
 	if err != nil {
 		return fmt.Errorf("parsing map key %q: %w", fieldDescriptor.FullName().Name(), err)
 	}
 
-	value, err := parseField(fieldDescriptor.MapValue(), values[1])
+	value, err := parse(fieldDescriptor.MapValue(), values[1])
 	if err != nil {
 		return fmt.Errorf("parsing map value %q: %w", fieldDescriptor.FullName().Name(), err)
 	}
@@ -185,12 +201,12 @@ func parseField(fieldDescriptor protoreflect.FieldDescriptor, value string) (pro
 		if v == nil {
 			i, err := strconv.Atoi(value)
 			if err != nil {
-				return protoreflect.Value{}, fmt.Errorf("%q is not a valid value", value)
+				return protoreflect.Value{}, &enumValueError{fmt.Sprintf("%q is not a valid value", value)}
 			}
 			// Look for enum by number
 			v = enum.Descriptor().Values().ByNumber(protoreflect.EnumNumber(i))
 			if v == nil {
-				return protoreflect.Value{}, fmt.Errorf("%q is not a valid value", value)
+				return protoreflect.Value{}, &enumValueError{fmt.Sprintf("%q is not a valid value", value)}
 			}
 		}
 		return protoreflect.ValueOfEnum(v.Number()), nil
@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	timestamppb "github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// LocalizedQueryParser is a QueryParameterParser which, in addition to the
+// formats accepted by the default parser, accepts numbers formatted according
+// to a locale and timestamps in a configurable list of layouts. This is useful
+// for public APIs consumed by non-programmatic clients such as spreadsheet
+// integrations. The path parameters of the requests are parsed with it too.
+//
+// It is enabled with SetQueryParameterParser, for example for German formatted
+// numbers and dates:
+//
+//	mux := runtime.NewServeMux(runtime.SetQueryParameterParser(&runtime.LocalizedQueryParser{
+//		DecimalSeparator: ',',
+//		GroupSeparators:  ".",
+//		TimeLayouts:      []string{"02.01.2006 15:04", "02.01.2006"},
+//	}))
+type LocalizedQueryParser struct {
+	// DecimalSeparator separates the integer part of a number from its
+	// fractional part. Defaults to '.'.
+	DecimalSeparator rune
+	// GroupSeparators lists the characters which may group the digits of a
+	// number, e.g. "," for "1,000.5". They are dropped before parsing.
+	GroupSeparators string
+	// TimeLayouts are tried in order when a timestamp is not formatted
+	// according to RFC 3339. See time.Parse for the format of a layout.
+	TimeLayouts []string
+	// Location is the time zone of timestamps whose layout carries no time
+	// zone information. Defaults to UTC.
+	Location *time.Location
+}
+
+// Parse populates "values" into "msg".
+// A value is ignored if its key starts with one of the elements in "filter".
+func (p *LocalizedQueryParser) Parse(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	return populateQueryValues(msg, values, filter, p.parseField)
+}
+
+func (p *LocalizedQueryParser) parseField(fieldDescriptor protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch fieldDescriptor.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		return parseField(fieldDescriptor, p.normalizeNumber(value))
+	case protoreflect.MessageKind:
+		switch fieldDescriptor.Message().FullName() {
+		case "google.protobuf.Timestamp":
+			return p.parseTimestamp(fieldDescriptor, value)
+		case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+			"google.protobuf.Int64Value", "google.protobuf.Int32Value",
+			"google.protobuf.UInt64Value", "google.protobuf.UInt32Value":
+			return parseField(fieldDescriptor, p.normalizeNumber(value))
+		}
+	}
+	return parseField(fieldDescriptor, value)
+}
+
+// normalizeNumber converts a localized number into the format understood by strconv.
+func (p *LocalizedQueryParser) normalizeNumber(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case p.DecimalSeparator != 0 && r == p.DecimalSeparator:
+			return '.'
+		case strings.ContainsRune(p.GroupSeparators, r):
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+func (p *LocalizedQueryParser) parseTimestamp(fieldDescriptor protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	v, err := parseField(fieldDescriptor, value)
+	if err == nil || len(p.TimeLayouts) == 0 {
+		return v, err
+	}
+
+	ts, err := p.parseTime(value)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	return protoreflect.ValueOfMessage(ts.ProtoReflect()), nil
+}
+
+// parseTime parses value with the first of the layouts of p it matches.
+func (p *LocalizedQueryParser) parseTime(value string) (*timestamppb.Timestamp, error) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range p.TimeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		return ptypes.TimestampProto(t)
+	}
+	return nil, fmt.Errorf("%q is neither an RFC 3339 timestamp nor matches any of the layouts %q", value, p.TimeLayouts)
+}
+
+// localizedParser returns the LocalizedQueryParser set with
+// SetQueryParameterParser, if any, to parse the path parameters with.
+func localizedParser() *LocalizedQueryParser {
+	p, _ := currentQueryParser.(*LocalizedQueryParser)
+	return p
+}
+
+// pathNumber converts a number of a path parameter into the format understood
+// by strconv, if it is localized.
+func pathNumber(value string) string {
+	if p := localizedParser(); p != nil {
+		return p.normalizeNumber(value)
+	}
+	return value
+}
@@ -0,0 +1,38 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+)
+
+func TestLocalizedPathParameters(t *testing.T) {
+	berlin := time.FixedZone("CET", 60*60)
+	defer func(parser QueryParameterParser) { currentQueryParser = parser }(currentQueryParser)
+	NewServeMux(SetQueryParameterParser(&LocalizedQueryParser{
+		DecimalSeparator: ',',
+		GroupSeparators:  ".",
+		TimeLayouts:      []string{"02.01.2006"},
+		Location:         berlin,
+	}))
+
+	if got, err := Float64("1.234,5"); err != nil || got != 1234.5 {
+		t.Errorf("Float64(%q) = %v, %v; want 1234.5", "1.234,5", got, err)
+	}
+	if got, err := Uint64Slice("1.000;2.000", ";"); err != nil || len(got) != 2 || got[0] != 1000 || got[1] != 2000 {
+		t.Errorf("Uint64Slice(%q, %q) = %v, %v; want [1000 2000]", "1.000;2.000", ";", got, err)
+	}
+	want := time.Date(2016, time.December, 15, 0, 0, 0, 0, berlin).Unix()
+	if got, err := Timestamp("15.12.2016"); err != nil || got.GetSeconds() != want {
+		t.Errorf("Timestamp(%q) = %v, %v; want %d seconds", "15.12.2016", got, err, want)
+	}
+	if _, err := Timestamp("12/15/2016"); err == nil {
+		t.Errorf("Timestamp(%q) succeeded; want error", "12/15/2016")
+	}
+
+	msg := &examplepb.Proto3Message{}
+	if err := PopulateFieldFromPath(msg, "double_value", "2,5"); err != nil || msg.DoubleValue != 2.5 {
+		t.Errorf("PopulateFieldFromPath(msg, %q, %q) = %v, msg = %v; want 2.5", "double_value", "2,5", err, msg)
+	}
+}
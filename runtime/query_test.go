@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
+	timestamppb "github.com/golang/protobuf/ptypes/timestamp"
 	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/go-cmp/cmp"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -15,7 +16,12 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 func BenchmarkPopulateQueryParameters(b *testing.B) {
@@ -607,3 +613,195 @@ func TestPopulateQueryParametersWithInvalidNestedParameters(t *testing.T) {
 		}
 	}
 }
+
+// newProto3OptionalMessage returns a message with the proto3 optional fields
+// "count" and "name", and the plain field "plain".
+func newProto3OptionalMessage(t *testing.T) proto.Message {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("optional.proto"),
+		Package: proto.String("example"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("OptionalMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:           proto.String("count"),
+					JsonName:       proto.String("count"),
+					Number:         proto.Int32(1),
+					Label:          descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:           descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+					OneofIndex:     proto.Int32(0),
+					Proto3Optional: proto.Bool(true),
+				},
+				{
+					Name:           proto.String("name"),
+					JsonName:       proto.String("name"),
+					Number:         proto.Int32(2),
+					Label:          descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:           descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					OneofIndex:     proto.Int32(1),
+					Proto3Optional: proto.Bool(true),
+				},
+				{
+					Name:     proto.String("plain"),
+					JsonName: proto.String("plain"),
+					Number:   proto.Int32(3),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+			},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{
+				{Name: proto.String("_count")},
+				{Name: proto.String("_name")},
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, new(protoregistry.Files))
+	if err != nil {
+		t.Fatalf("protodesc.NewFile(%v) failed with %v; want success", fd, err)
+	}
+	return dynamicpb.NewMessage(file.Messages().Get(0))
+}
+
+func TestPopulateQueryParametersProto3Optional(t *testing.T) {
+	for _, spec := range []struct {
+		values   url.Values
+		wantSet  []string
+		wantNone []string
+	}{
+		{
+			values:   url.Values{},
+			wantNone: []string{"count", "name", "plain"},
+		},
+		{
+			// Zero values of optional fields are set, unlike those of
+			// plain fields.
+			values:   url.Values{"count": {"0"}, "name": {""}, "plain": {""}},
+			wantSet:  []string{"count", "name"},
+			wantNone: []string{"plain"},
+		},
+		{
+			values:   url.Values{"name": {"foo"}},
+			wantSet:  []string{"name"},
+			wantNone: []string{"count", "plain"},
+		},
+	} {
+		msg := newProto3OptionalMessage(t)
+		if err := runtime.PopulateQueryParameters(msg, spec.values, utilities.NewDoubleArray(nil)); err != nil {
+			t.Errorf("runtime.PopulateQueryParameters(msg, %v, nil) failed with %v; want success", spec.values, err)
+			continue
+		}
+		fields := msg.ProtoReflect().Descriptor().Fields()
+		for _, name := range spec.wantSet {
+			if !msg.ProtoReflect().Has(fields.ByName(protoreflect.Name(name))) {
+				t.Errorf("runtime.PopulateQueryParameters(msg, %v, nil) did not set %q; want it set", spec.values, name)
+			}
+		}
+		for _, name := range spec.wantNone {
+			if msg.ProtoReflect().Has(fields.ByName(protoreflect.Name(name))) {
+				t.Errorf("runtime.PopulateQueryParameters(msg, %v, nil) set %q; want it unset", spec.values, name)
+			}
+		}
+	}
+}
+
+func TestPopulateFieldFromPathProto3Optional(t *testing.T) {
+	msg := newProto3OptionalMessage(t)
+	if err := runtime.PopulateFieldFromPath(msg, "count", "1"); err != nil {
+		t.Fatalf("runtime.PopulateFieldFromPath(msg, %q, %q) failed with %v; want success", "count", "1", err)
+	}
+	// The synthetic oneof of an optional field does not prevent setting it again.
+	if err := runtime.PopulateFieldFromPath(msg, "count", "2"); err != nil {
+		t.Fatalf("runtime.PopulateFieldFromPath(msg, %q, %q) failed with %v; want success", "count", "2", err)
+	}
+	count := msg.ProtoReflect().Descriptor().Fields().ByName("count")
+	if got := msg.ProtoReflect().Get(count).Int(); got != 2 {
+		t.Errorf("count = %d; want 2", got)
+	}
+}
+
+func TestLocalizedQueryParser(t *testing.T) {
+	berlin := time.FixedZone("CET", 60*60)
+	parser := &runtime.LocalizedQueryParser{
+		DecimalSeparator: ',',
+		GroupSeparators:  ". ",
+		TimeLayouts:      []string{"02.01.2006 15:04", "02.01.2006"},
+		Location:         berlin,
+	}
+	for _, spec := range []struct {
+		values  url.Values
+		want    proto.Message
+		wantErr bool
+	}{
+		{
+			values: url.Values{
+				"double_value":         {"1.234,5"},
+				"float_value":          {"0,25"},
+				"int64_value":          {"-1 000 000"},
+				"uint32_value":         {"4.096"},
+				"wrapper_double_value": {"2,5"},
+				"string_value":         {"1.234,5"},
+			},
+			want: &examplepb.Proto3Message{
+				DoubleValue:        1234.5,
+				FloatValue:         0.25,
+				Int64Value:         -1000000,
+				Uint32Value:        4096,
+				WrapperDoubleValue: &wrapperspb.DoubleValue{Value: 2.5},
+				StringValue:        "1.234,5",
+			},
+		},
+		{
+			values: url.Values{
+				"timestamp_value": {"2016-12-15T12:23:32Z"},
+			},
+			want: &examplepb.Proto3Message{
+				TimestampValue: &timestamppb.Timestamp{Seconds: time.Date(2016, time.December, 15, 12, 23, 32, 0, time.UTC).Unix()},
+			},
+		},
+		{
+			values: url.Values{
+				"timestamp_value": {"15.12.2016 13:23"},
+			},
+			want: &examplepb.Proto3Message{
+				TimestampValue: &timestamppb.Timestamp{Seconds: time.Date(2016, time.December, 15, 13, 23, 0, 0, berlin).Unix()},
+			},
+		},
+		{
+			values: url.Values{
+				"timestamp_value": {"15.12.2016"},
+			},
+			want: &examplepb.Proto3Message{
+				TimestampValue: &timestamppb.Timestamp{Seconds: time.Date(2016, time.December, 15, 0, 0, 0, 0, berlin).Unix()},
+			},
+		},
+		{
+			values: url.Values{
+				"timestamp_value": {"12/15/2016"},
+			},
+			wantErr: true,
+		},
+		{
+			values: url.Values{
+				"int32_value": {"1,5"},
+			},
+			wantErr: true,
+		},
+	} {
+		msg := &examplepb.Proto3Message{}
+		err := parser.Parse(msg, spec.values, utilities.NewDoubleArray(nil))
+		if spec.wantErr {
+			if err == nil {
+				t.Errorf("parser.Parse(msg, %v) did not fail; want error", spec.values)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parser.Parse(msg, %v) failed with %v; want success", spec.values, err)
+			continue
+		}
+		if diff := cmp.Diff(spec.want, msg, protocmp.Transform()); diff != "" {
+			t.Errorf("parser.Parse(msg, %v) = %s", spec.values, diff)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package runtime
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimit is the decision of a Limiter on a request.
+type RateLimit struct {
+	// Allowed reports whether the request may be handled.
+	Allowed bool
+	// Limit is the number of requests allowed in the window of the quota.
+	Limit int
+	// Remaining is the number of requests left in the quota.
+	Remaining int
+	// Reset is the time until the quota is fully restored, or for rejected
+	// requests until a request may be retried.
+	Reset time.Duration
+}
+
+// Limiter limits the rate of the requests to the routes of a ServeMux.
+// Implementations may be in-memory, like TokenBucketLimiter, or backed by a
+// shared store, e.g. Redis, to limit the requests across gateway replicas.
+type Limiter interface {
+	// Allow consumes a request from the quota of the client key to the route
+	// whose path template is route, e.g. "/v1/{name=shelves/*}".
+	Allow(ctx context.Context, route, key string) (RateLimit, error)
+}
+
+// RateLimitKeyFunc returns the key the requests are limited by, e.g. the IP
+// address or the API key of their client.
+type RateLimitKeyFunc func(*http.Request) string
+
+// WithRateLimiter returns a ServeMuxOption limiting the rate of the requests
+// to each route with limiter. The requests are keyed by the IP address of
+// their client, as resolved with WithForwardedHeaders if configured, unless
+// WithRateLimitKey is given.
+//
+// The responses have RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers, and the rejected requests are replied to with a ResourceExhausted
+// error, i.e. 429 Too Many Requests, whose google.rpc.RetryInfo detail sets
+// the Retry-After header. Requests are allowed when limiter fails.
+func WithRateLimiter(limiter Limiter) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.rateLimiter = limiter
+	}
+}
+
+// WithRateLimitKey returns a ServeMuxOption keying the requests limited with
+// WithRateLimiter with fn.
+func WithRateLimitKey(fn RateLimitKeyFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.rateLimitKey = fn
+	}
+}
+
+// rateLimit consults the rate limiter of the mux for r to the route pat, and
+// replies with an error and returns false if r is rejected.
+func (s *ServeMux) rateLimit(w http.ResponseWriter, r *http.Request, pat Pattern) bool {
+	if s.rateLimiter == nil {
+		return true
+	}
+	var key string
+	if s.rateLimitKey != nil {
+		key = s.rateLimitKey(r)
+	} else {
+		key = s.clientIP(r)
+	}
+	limit, err := s.rateLimiter.Allow(r.Context(), pat.String(), key)
+	if err != nil {
+		grpclog.Infof("Failed to consult the rate limiter: %v", err)
+		return true
+	}
+	if limit.Limit > 0 {
+		reset := int64(math.Ceil(limit.Reset.Seconds()))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+	}
+	if limit.Allowed {
+		return true
+	}
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if limit.Reset > 0 {
+		if withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(limit.Reset)}); err == nil {
+			st = withDetails
+		}
+	}
+	_, outboundMarshaler := MarshalerForRequest(s, r)
+	HTTPError(r.Context(), s, outboundMarshaler, w, r, st.Err())
+	return false
+}
+
+// clientIP returns the IP address of the client of r.
+func (s *ServeMux) clientIP(r *http.Request) string {
+	if s.forwarded != nil {
+		clientIP, _, _ := s.forwarded.resolve(r)
+		return clientIP
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// TokenBucketLimiter is an in-memory Limiter giving each pair of route and key
+// a bucket of tokens, refilled at a constant rate up to its burst size, from
+// which each request takes a token.
+type TokenBucketLimiter struct {
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[tokenBucketKey]*tokenBucket
+	calls   int
+}
+
+type tokenBucketKey struct {
+	route string
+	key   string
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// tokenBucketSweepInterval is the number of calls between the removals of the
+// full buckets of a TokenBucketLimiter, which are equivalent to no bucket.
+const tokenBucketSweepInterval = 1024
+
+// NewTokenBucketLimiter returns a TokenBucketLimiter allowing rate requests
+// per second, with bursts of up to burst requests, to each route per key.
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		rate:    rate,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[tokenBucketKey]*tokenBucket),
+	}
+}
+
+// Allow implements Limiter.
+func (l *TokenBucketLimiter) Allow(_ context.Context, route, key string) (RateLimit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%tokenBucketSweepInterval == 0 {
+		l.sweep(now)
+	}
+
+	k := tokenBucketKey{route: route, key: key}
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[k] = b
+	}
+	l.refill(b, now)
+
+	limit := RateLimit{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		limit.Allowed = true
+	}
+	limit.Remaining = int(b.tokens)
+	if l.rate > 0 {
+		limit.Reset = time.Duration((float64(l.burst) - b.tokens) / l.rate * float64(time.Second))
+	}
+	if !limit.Allowed && l.rate > 0 {
+		// The request may be retried once a token is refilled.
+		limit.Reset = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	return limit, nil
+}
+
+func (l *TokenBucketLimiter) refill(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(l.burst), b.tokens+elapsed.Seconds()*l.rate)
+	}
+	b.last = now
+}
+
+// sweep removes the buckets which are full at now.
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
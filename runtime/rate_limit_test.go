@@ -0,0 +1,83 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestServeMuxRateLimiter(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithRateLimiter(runtime.NewTokenBucketLimiter(0.001, 2)))
+	for _, path := range []string{"/v1/shelves/{shelf}", "/v1/books"} {
+		if err := mux.HandlePath("GET", path, func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {}); err != nil {
+			t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", path, err)
+		}
+	}
+
+	for i, spec := range []struct {
+		path       string
+		remoteAddr string
+		status     int
+		remaining  string
+	}{
+		{path: "/v1/shelves/1", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "1"},
+		{path: "/v1/shelves/2", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "0"},
+		{path: "/v1/shelves/3", remoteAddr: "192.0.2.1:1234", status: http.StatusTooManyRequests, remaining: "0"},
+		// The quotas are per route and per client.
+		{path: "/v1/books", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "1"},
+		{path: "/v1/shelves/1", remoteAddr: "192.0.2.2:1234", status: http.StatusOK, remaining: "1"},
+	} {
+		r := httptest.NewRequest("GET", spec.path, nil)
+		r.RemoteAddr = spec.remoteAddr
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		if w.Code != spec.status {
+			t.Errorf("#%d: w.Code = %d; want %d", i, w.Code, spec.status)
+		}
+		if got, want := w.Header().Get("RateLimit-Limit"), "2"; got != want {
+			t.Errorf(`#%d: w.Header().Get("RateLimit-Limit") = %q; want %q`, i, got, want)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != spec.remaining {
+			t.Errorf(`#%d: w.Header().Get("RateLimit-Remaining") = %q; want %q`, i, got, spec.remaining)
+		}
+		if spec.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf(`#%d: w.Header().Get("Retry-After") = ""; want a delay`, i)
+		}
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, string) (runtime.RateLimit, error) {
+	return runtime.RateLimit{}, errors.New("store unavailable")
+}
+
+func TestServeMuxRateLimiterFailure(t *testing.T) {
+	var keys []string
+	mux := runtime.NewServeMux(
+		runtime.WithRateLimiter(failingLimiter{}),
+		runtime.WithRateLimitKey(func(r *http.Request) string {
+			keys = append(keys, r.Header.Get("X-Api-Key"))
+			return r.Header.Get("X-Api-Key")
+		}),
+	)
+	if err := mux.HandlePath("GET", "/v1/books", func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/books", err)
+	}
+	r := httptest.NewRequest("GET", "/v1/books", nil)
+	r.Header.Set("X-Api-Key", "key")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("w.Code = %d; want %d", w.Code, http.StatusOK)
+	}
+	if len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("rate limit keys = %q; want %q", keys, []string{"key"})
+	}
+}
@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Recording is a call to a unary method recorded by Record, as the gateway
+// transcoded it: the route the request matched, the decoded request, the
+// metadata forwarded with it and the response of the backend. The sensitive
+// fields of the messages are redacted as by Redact.
+type Recording struct {
+	// Time is when the call completed.
+	Time time.Time `json:"time"`
+	// HTTPMethod is the method of the request, e.g. "GET".
+	HTTPMethod string `json:"httpMethod,omitempty"`
+	// Pattern is the path template of the route, e.g. "/v1/{name=shelves/*}".
+	Pattern string `json:"pattern,omitempty"`
+	// PathParams are the values of the path parameters of the request.
+	PathParams map[string]string `json:"pathParams,omitempty"`
+	// RPCMethod is the method called, in the format of "/package.service/method".
+	RPCMethod string `json:"rpcMethod"`
+	// Request is the decoded request, as JSON.
+	Request json.RawMessage `json:"request"`
+	// Metadata is the metadata forwarded with the request.
+	Metadata metadata.MD `json:"metadata,omitempty"`
+	// Response is the response, as JSON, unless the call failed.
+	Response json.RawMessage `json:"response,omitempty"`
+	// Header and Trailer are the metadata of the response.
+	Header  metadata.MD `json:"header,omitempty"`
+	Trailer metadata.MD `json:"trailer,omitempty"`
+	// Code and Message are the status of the call.
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+// RecordFunc is the signature of the sinks of the calls recorded with Record.
+type RecordFunc func(ctx context.Context, rec Recording)
+
+type recordKey struct{}
+
+// WithRecording returns a ServeMuxOption passing the calls of the handlers
+// generated with record=true to fn, e.g. one of NewRecordingWriter, to debug
+// transcoding discrepancies by replaying them with Replay.
+func WithRecording(fn RecordFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.record = fn
+		serveMux.callRoutes = true
+	}
+}
+
+// Record passes the call of a unary method with req, resp, the metadata md of
+// the response and err to the RecordFunc of the ServeMux which annotated the
+// context, if any.
+func Record(ctx context.Context, req, resp proto.Message, md ServerMetadata, err error) {
+	fn, ok := ctx.Value(recordKey{}).(RecordFunc)
+	if !ok {
+		return
+	}
+	rec := Recording{
+		Time:    time.Now(),
+		Header:  md.HeaderMD,
+		Trailer: md.TrailerMD,
+	}
+	rec.RPCMethod, _ = RPCMethod(ctx)
+	if m, ok := ctx.Value(routeKey{}).(*matchedRoute); ok {
+		rec.HTTPMethod = m.httpMethod
+		rec.Pattern = m.pattern.String()
+		rec.PathParams = m.pathParams
+	}
+	rec.Metadata, _ = metadata.FromOutgoingContext(ctx)
+	if rec.Metadata == nil {
+		// The handlers calling servers in process annotate the incoming
+		// metadata instead.
+		rec.Metadata, _ = metadata.FromIncomingContext(ctx)
+	}
+	var merr error
+	if rec.Request, merr = protojson.Marshal(Redact(req)); merr != nil {
+		grpclog.Infof("Failed to record the request of %s: %v", rec.RPCMethod, merr)
+		return
+	}
+	if err != nil {
+		s := status.Convert(err)
+		rec.Code, rec.Message = s.Code(), s.Message()
+	} else if rec.Response, merr = protojson.Marshal(Redact(resp)); merr != nil {
+		grpclog.Infof("Failed to record the response of %s: %v", rec.RPCMethod, merr)
+		return
+	}
+	fn(ctx, rec)
+}
+
+func withRecord(ctx context.Context, fn RecordFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, recordKey{}, fn)
+}
+
+// NewRecordingWriter returns a RecordFunc writing the recordings to w as JSON,
+// one per line, to be read with ReadRecordings. The writes are serialized.
+func NewRecordingWriter(w io.Writer) RecordFunc {
+	var mu sync.Mutex
+	return func(ctx context.Context, rec Recording) {
+		buf, err := json.Marshal(rec)
+		if err != nil {
+			grpclog.Infof("Failed to marshal the recording of %s: %v", rec.RPCMethod, err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			grpclog.Infof("Failed to write the recording of %s: %v", rec.RPCMethod, err)
+		}
+	}
+}
+
+// ReadRecordings reads the recordings written by NewRecordingWriter.
+func ReadRecordings(r io.Reader) ([]Recording, error) {
+	var recs []Recording
+	dec := json.NewDecoder(r)
+	for {
+		var rec Recording
+		if err := dec.Decode(&rec); err == io.EOF {
+			return recs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read recording %d: %w", len(recs)+1, err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// Replay issues the recorded call rec to conn again, with its request and
+// metadata, and returns the response, to be compared with rec.Response, e.g.
+// to tell whether a discrepancy comes from the gateway or from the backend.
+// The types of the messages of the method are those of the generated code
+// linked in, or dynamic messages of its descriptor otherwise. The redacted
+// fields are replayed redacted.
+func Replay(ctx context.Context, conn grpc.ClientConnInterface, rec Recording) (proto.Message, error) {
+	md, err := methodDescriptor(rec.RPCMethod)
+	if err != nil {
+		return nil, err
+	}
+	req, resp := newMessage(md.Input()), newMessage(md.Output())
+	if err := protojson.Unmarshal(rec.Request, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the request of %s: %w", rec.RPCMethod, err)
+	}
+	if len(rec.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, rec.Metadata.Copy())
+	}
+	if err := conn.Invoke(ctx, rec.RPCMethod, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// methodDescriptor returns the descriptor of the method of the full name
+// method, in the format of "/package.service/method".
+func methodDescriptor(method string) (protoreflect.MethodDescriptor, error) {
+	i := strings.LastIndex(method, "/")
+	if !strings.HasPrefix(method, "/") || i <= 0 {
+		return nil, fmt.Errorf("invalid method name %q", method)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(method[1:i]))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service of %s: %w", method, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", d.FullName())
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method[i+1:]))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %s", method)
+	}
+	return md, nil
+}
+
+// newMessage returns a message of the type of md.
+func newMessage(md protoreflect.MessageDescriptor) proto.Message {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt.New().Interface()
+	}
+	return dynamicpb.NewMessage(md)
+}
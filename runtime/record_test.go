@@ -0,0 +1,111 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const nonStandardUpdateMethod = "/grpc.gateway.runtime.internal.examplepb.NonStandardService/Update"
+
+// replayConn replies to the calls with resp, keeping their method, request
+// and metadata.
+type replayConn struct {
+	resp   proto.Message
+	method string
+	req    proto.Message
+	md     metadata.MD
+}
+
+func (c *replayConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	c.method, c.req = method, args.(proto.Message)
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	proto.Merge(reply.(proto.Message), c.resp)
+	return nil
+}
+
+func (c *replayConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	mux := runtime.NewServeMux(runtime.WithRecording(runtime.NewRecordingWriter(&buf)))
+	req := &examplepb.NonStandardUpdateRequest{Body: &examplepb.NonStandardMessage{Id: "a", LineNum: 42}}
+	resp := &examplepb.NonStandardMessage{Id: "a", LangIdent: "en"}
+	if err := mux.HandlePath("PATCH", "/v1/{name=messages/*}", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, nonStandardUpdateMethod)
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		md := runtime.ServerMetadata{HeaderMD: metadata.Pairs("x-upstream", "a")}
+		runtime.Record(ctx, req, resp, md, nil)
+		runtime.Record(ctx, req, nil, md, status.Error(codes.NotFound, "not found"))
+		// Contexts not annotated by a mux recording the calls are not
+		// recorded.
+		runtime.Record(r.Context(), req, resp, md, nil)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "PATCH", "/v1/{name=messages/*}", err)
+	}
+	r := httptest.NewRequest("PATCH", "/v1/messages/a", nil)
+	r.Header.Set("Grpc-Metadata-Tenant", "acme")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	recs, err := runtime.ReadRecordings(&buf)
+	if err != nil {
+		t.Fatalf("runtime.ReadRecordings(buf) failed with %v; want success", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("len(recs) = %d; want 2", len(recs))
+	}
+	rec := recs[0]
+	if rec.HTTPMethod != "PATCH" || rec.Pattern != "/v1/{name=messages/*}" || rec.PathParams["name"] != "messages/a" || rec.RPCMethod != nonStandardUpdateMethod {
+		t.Errorf("recs[0] = %+v; want the route and the method of the call", rec)
+	}
+	if got := rec.Metadata.Get("tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("recs[0].Metadata = %v; want the forwarded metadata", rec.Metadata)
+	}
+	if got := rec.Header.Get("x-upstream"); len(got) != 1 || got[0] != "a" {
+		t.Errorf("recs[0].Header = %v; want the header of the response", rec.Header)
+	}
+	if rec.Code != codes.OK || len(rec.Response) == 0 {
+		t.Errorf("recs[0] = %+v; want a response", rec)
+	}
+	if recs[1].Code != codes.NotFound || recs[1].Message != "not found" || recs[1].Response != nil {
+		t.Errorf("recs[1] = %+v; want the status of the error and no response", recs[1])
+	}
+
+	conn := &replayConn{resp: resp}
+	got, err := runtime.Replay(context.Background(), conn, rec)
+	if err != nil {
+		t.Fatalf("runtime.Replay(ctx, conn, rec) failed with %v; want success", err)
+	}
+	if !proto.Equal(got, resp) {
+		t.Errorf("runtime.Replay(ctx, conn, rec) = %v; want %v", got, resp)
+	}
+	if conn.method != nonStandardUpdateMethod || !proto.Equal(conn.req, req) {
+		t.Errorf("conn called with %s(%v); want %s(%v)", conn.method, conn.req, nonStandardUpdateMethod, req)
+	}
+	if got := conn.md.Get("tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("metadata = %v; want the recorded metadata", conn.md)
+	}
+}
+
+func TestReplayUnknownMethod(t *testing.T) {
+	for _, method := range []string{"", "Update", "/unknown.Service/Update", "/grpc.gateway.runtime.internal.examplepb.NonStandardService/Unknown"} {
+		if _, err := runtime.Replay(context.Background(), &replayConn{}, runtime.Recording{RPCMethod: method}); err == nil {
+			t.Errorf("runtime.Replay(ctx, conn, {RPCMethod: %q}) succeeded; want failure", method)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// maxRequestIDLength bounds the length of the request IDs read from requests,
+// longer ones being replaced with generated ones.
+const maxRequestIDLength = 128
+
+// RequestIDGeneratorFunc is the signature used to generate the ID of the
+// requests which have none.
+type RequestIDGeneratorFunc func() string
+
+// DefaultRequestIDGenerator generates random request IDs of 32 hexadecimal
+// characters.
+func DefaultRequestIDGenerator() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a ServeMuxOption reading the ID of the requests from
+// header, e.g. "X-Request-Id", or generating it with generate for those which
+// have none, and:
+//
+//   - forwarding it to the gRPC server as the metadata key of the lowercase header;
+//   - echoing it in the header of the response, errors included;
+//   - exposing it to the error handlers, the forward response options and the
+//     handlers with RequestID.
+//
+// The request IDs are generated with DefaultRequestIDGenerator if generate is
+// nil. Request IDs longer than 128 characters or with non-printable characters
+// are ignored.
+func WithRequestID(header string, generate RequestIDGeneratorFunc) ServeMuxOption {
+	if generate == nil {
+		generate = DefaultRequestIDGenerator
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.requestIDHeader = textproto.CanonicalMIMEHeaderKey(header)
+		serveMux.requestIDGenerator = generate
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request of ctx, as read or generated with
+// WithRequestID.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// withRequestID resolves the ID of r, echoes it in w and returns r with the
+// ID in its context, if the mux handles request IDs.
+func (s *ServeMux) withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if s.requestIDHeader == "" {
+		return r
+	}
+	if _, ok := RequestID(r.Context()); ok {
+		return r
+	}
+	id := r.Header.Get(s.requestIDHeader)
+	if !isValidRequestID(id) {
+		id = s.requestIDGenerator()
+	}
+	if id == "" {
+		return r
+	}
+	w.Header().Set(s.requestIDHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDPairs returns the metadata pairs forwarding the ID of the request of
+// ctx.
+func (s *ServeMux) requestIDPairs(ctx context.Context) []string {
+	if s.requestIDHeader == "" {
+		return nil
+	}
+	id, ok := RequestID(ctx)
+	if !ok {
+		return nil
+	}
+	return []string{strings.ToLower(s.requestIDHeader), id}
+}
@@ -0,0 +1,90 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServeMuxRequestID(t *testing.T) {
+	for _, spec := range []struct {
+		name      string
+		requestID string
+		want      string
+	}{
+		{
+			name:      "read",
+			requestID: "abc-123",
+			want:      "abc-123",
+		},
+		{
+			name: "generated",
+			want: "generated-id",
+		},
+		{
+			name:      "invalid",
+			requestID: strings.Repeat("a", 129),
+			want:      "generated-id",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithRequestID("X-Request-Id", func() string { return "generated-id" }))
+			var md metadata.MD
+			var handlerID string
+			err := mux.HandlePath("GET", "/v1/ping", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				handlerID, _ = runtime.RequestID(r.Context())
+				ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/Ping")
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+				}
+				md, _ = metadata.FromOutgoingContext(ctx)
+			})
+			if err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/ping", err)
+			}
+
+			r := httptest.NewRequest("GET", "/v1/ping", nil)
+			if spec.requestID != "" {
+				r.Header.Set("X-Request-Id", spec.requestID)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if got := w.Header().Get("X-Request-Id"); got != spec.want {
+				t.Errorf(`w.Header().Get("X-Request-Id") = %q; want %q`, got, spec.want)
+			}
+			if handlerID != spec.want {
+				t.Errorf("runtime.RequestID(ctx) = %q; want %q", handlerID, spec.want)
+			}
+			if got, want := md["x-request-id"], []string{spec.want}; !reflect.DeepEqual(got, want) {
+				t.Errorf(`md["x-request-id"] = %v; want %v`, got, want)
+			}
+		})
+	}
+}
+
+func TestServeMuxRequestIDRoutingError(t *testing.T) {
+	var errorID string
+	mux := runtime.NewServeMux(
+		runtime.WithRequestID("X-Request-Id", nil),
+		runtime.WithRoutingErrorHandler(func(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, status int) {
+			errorID, _ = runtime.RequestID(ctx)
+			w.WriteHeader(status)
+		}),
+	)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/unknown", nil))
+
+	if len(errorID) != 32 {
+		t.Errorf("runtime.RequestID(ctx) = %q; want a generated ID", errorID)
+	}
+	if got := w.Header().Get("X-Request-Id"); got != errorID {
+		t.Errorf(`w.Header().Get("X-Request-Id") = %q; want %q`, got, errorID)
+	}
+}
@@ -0,0 +1,337 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/grpclog"
+)
+
+// CacheEntry is a response stored by a CacheStore.
+type CacheEntry struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Stored is when the response was stored, from which the Age header of
+	// the responses served from the cache is set.
+	Stored time.Time
+	// Expires is when the response stops being served from the cache.
+	Expires time.Time
+}
+
+// CacheStore stores the responses of WithResponseCache, e.g. in memory with
+// NewMemoryCacheStore, or in a store shared by the gateways like Redis. The
+// entries are grouped by resource, the path of their requests prefixed with
+// their tenant if any, and keyed within it by variant, derived from the host,
+// the query parameters and the headers of their requests, so that a hash per
+// resource can hold them.
+//
+// The methods of a CacheStore may be called concurrently. The errors they
+// return are logged, the requests being then served without the cache.
+type CacheStore interface {
+	// Get returns the entry of the variant of resource, or nil if there is
+	// none.
+	Get(ctx context.Context, resource, variant string) (*CacheEntry, error)
+	// Set stores entry as the variant of resource, at least until it expires.
+	Set(ctx context.Context, resource, variant string, entry *CacheEntry) error
+	// Invalidate removes the entries of all the variants of resource.
+	Invalidate(ctx context.Context, resource string) error
+}
+
+// ResponseCache configures the response cache of WithResponseCache.
+type ResponseCache struct {
+	// Store stores the responses.
+	Store CacheStore
+	// Headers are the request headers the responses vary with besides
+	// Accept, e.g. "Accept-Language".
+	Headers []string
+	// MaxEntrySize is the size of the largest body stored, 1 MiB if zero.
+	MaxEntrySize int
+	// Invalidate returns the paths of the resources the successful POST, PUT,
+	// PATCH and DELETE requests invalidate, e.g. their path and that of their
+	// parent collection, within the tenant of the requests. Only their path is
+	// invalidated if nil.
+	Invalidate func(*http.Request) []string
+}
+
+// WithResponseCache returns a ServeMuxOption serving the GET requests from a
+// read-through cache of their responses, keyed by tenant, host, path, query
+// parameters and the Accept header and headers of cache.
+//
+// Only the 200 responses whose Cache-Control header has a max-age or
+// s-maxage directive are stored, until they expire, e.g. those of the methods
+// with the cache_control gateway option, which the generated handlers set as
+// their header. The responses that are private, no-cache, no-store, streamed
+// or that set cookies are not stored, nor those of the requests with an
+// Authorization or a Cookie header unless they are public or have an s-maxage
+// directive.
+// The requests with a no-cache directive are forwarded and refresh the cache,
+// and those with a no-store one bypass it.
+//
+// The successful POST, PUT, PATCH and DELETE requests invalidate the responses
+// of their resources before they are replied to.
+//
+// WithResponseCache panics if cache has no Store.
+func WithResponseCache(cache ResponseCache) ServeMuxOption {
+	if cache.Store == nil {
+		panic("runtime: response cache without a store")
+	}
+	if cache.MaxEntrySize == 0 {
+		cache.MaxEntrySize = 1 << 20
+	}
+	if cache.Invalidate == nil {
+		cache.Invalidate = func(r *http.Request) []string {
+			return []string{r.URL.Path}
+		}
+	}
+	headers := append([]string{"Accept"}, cache.Headers...)
+	for i, h := range headers {
+		headers[i] = http.CanonicalHeaderKey(h)
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.responseCache = &responseCache{ResponseCache: cache, headers: headers}
+	}
+}
+
+type responseCache struct {
+	ResponseCache
+	// headers are the canonical keys of the request headers the responses
+	// vary with.
+	headers []string
+}
+
+// cached returns h serving the GET requests from the response cache of the
+// mux, if any, and invalidating it on the successful requests of the methods
+// modifying resources.
+func (s *ServeMux) cached(h HandlerFunc) HandlerFunc {
+	c := s.responseCache
+	if c == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if s.isPathLengthFallback(r) {
+			h(w, r, pathParams)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			c.serve(w, r, pathParams, h)
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.invalidate(w, r, pathParams, h)
+		default:
+			h(w, r, pathParams)
+		}
+	}
+}
+
+// serve replies to r from the cache, or with h storing its response.
+func (c *responseCache) serve(w http.ResponseWriter, r *http.Request, pathParams map[string]string, h HandlerFunc) {
+	ctx := r.Context()
+	directives := parseCacheControl(r.Header.Values("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		h(w, r, pathParams)
+		return
+	}
+	resource, variant := c.resource(r, r.URL.Path), c.variant(r)
+	if _, ok := directives["no-cache"]; !ok {
+		entry, err := c.Store.Get(ctx, resource, variant)
+		if err != nil {
+			grpclog.Infof("Failed to get cached response of %s: %v", resource, err)
+		}
+		if entry != nil && time.Now().Before(entry.Expires) {
+			writeCacheEntry(w, entry)
+			return
+		}
+	}
+
+	// The headers set before h, e.g. the request ID, are not those of the
+	// response.
+	before := w.Header().Clone()
+	rec := &cacheRecorder{ResponseWriter: w, max: c.MaxEntrySize}
+	rec.commit = func(status int) {
+		if status == http.StatusOK {
+			rec.entry = c.newEntry(r, before, w.Header())
+		}
+	}
+	h(rec, r, pathParams)
+	if rec.entry == nil || rec.flushed || rec.body == nil {
+		return
+	}
+	rec.entry.Body = rec.body
+	if err := c.Store.Set(ctx, resource, variant, rec.entry); err != nil {
+		grpclog.Infof("Failed to cache response of %s: %v", resource, err)
+	}
+}
+
+// invalidate serves r with h, invalidating the responses of its resources if
+// it succeeds.
+func (c *responseCache) invalidate(w http.ResponseWriter, r *http.Request, pathParams map[string]string, h HandlerFunc) {
+	rec := &cacheRecorder{ResponseWriter: w}
+	rec.commit = func(status int) {
+		if status >= http.StatusBadRequest {
+			return
+		}
+		for _, path := range c.Invalidate(r) {
+			resource := c.resource(r, path)
+			if err := c.Store.Invalidate(r.Context(), resource); err != nil {
+				grpclog.Infof("Failed to invalidate cached responses of %s: %v", resource, err)
+			}
+		}
+	}
+	h(rec, r, pathParams)
+	if !rec.wroteHeader {
+		// The handlers writing nothing succeed.
+		rec.WriteHeader(http.StatusOK)
+	}
+}
+
+// resource returns the resource of path in the tenant of r, if any, whose
+// responses are not served to the other tenants.
+func (c *responseCache) resource(r *http.Request, path string) string {
+	tenant, ok := Tenant(r.Context())
+	if !ok {
+		return path
+	}
+	// The escaped tenant has no slash, which the paths start with.
+	return url.PathEscape(tenant) + ":" + path
+}
+
+// variant returns the key of the responses to r among those of its resource.
+func (c *responseCache) variant(r *http.Request) string {
+	// The form of the requests whose method is overridden holds their query
+	// parameters.
+	query := r.Form
+	if query == nil {
+		query = r.URL.Query()
+	}
+	var b strings.Builder
+	b.WriteString(query.Encode())
+	fmt.Fprintf(&b, "\nHost: %s", r.Host)
+	for _, h := range c.headers {
+		fmt.Fprintf(&b, "\n%s: %s", h, strings.Join(r.Header.Values(h), ", "))
+	}
+	// The marshaler options are removed from the query once parsed.
+	if q, ok := r.Context().Value(marshalerQueryKey{}).(*marshalerQuery); ok {
+		fmt.Fprintf(&b, "\n%s", q)
+	}
+	return b.String()
+}
+
+// newEntry returns the entry of the response to r with header, without its
+// body, or nil if it is not to be stored.
+func (c *responseCache) newEntry(r *http.Request, before, header http.Header) *CacheEntry {
+	if header.Get("Set-Cookie") != "" || header.Get("Trailer") != "" {
+		return nil
+	}
+	directives := parseCacheControl(header.Values("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return nil
+		}
+	}
+	maxAge, shared := directives["s-maxage"]
+	if !shared {
+		maxAge = directives["max-age"]
+	}
+	seconds, err := strconv.Atoi(maxAge)
+	if err != nil || seconds <= 0 {
+		return nil
+	}
+	// The responses to authenticated requests are those of their users.
+	authenticated := r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+	if _, public := directives["public"]; authenticated && !public && !shared {
+		return nil
+	}
+
+	entry := &CacheEntry{Status: http.StatusOK, Header: make(http.Header), Stored: time.Now()}
+	entry.Expires = entry.Stored.Add(time.Duration(seconds) * time.Second)
+	for k, v := range header {
+		if _, ok := before[k]; ok || k == "Server-Timing" {
+			continue
+		}
+		entry.Header[k] = append([]string(nil), v...)
+	}
+	return entry
+}
+
+// writeCacheEntry replies with entry.
+func writeCacheEntry(w http.ResponseWriter, entry *CacheEntry) {
+	for k, v := range entry.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.Stored)/time.Second)))
+	w.WriteHeader(entry.Status)
+	if _, err := w.Write(entry.Body); err != nil {
+		grpclog.Infof("Failed to write response: %v", err)
+	}
+}
+
+// parseCacheControl returns the directives of the Cache-Control header values,
+// keyed by lower case name.
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			name, value := d, ""
+			if i := strings.IndexByte(d, '='); i >= 0 {
+				name, value = d[:i], strings.Trim(strings.TrimSpace(d[i+1:]), `"`)
+			}
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				directives[name] = value
+			}
+		}
+	}
+	return directives
+}
+
+// cacheRecorder is a response writer calling commit with the status of the
+// response before it is written, and recording its body up to max bytes if
+// max is positive.
+type cacheRecorder struct {
+	http.ResponseWriter
+	commit func(status int)
+	max    int
+
+	wroteHeader bool
+	flushed     bool
+	// body is the recorded body, nil if it is larger than max.
+	body  []byte
+	entry *CacheEntry
+}
+
+func (w *cacheRecorder) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.commit(status)
+	if w.max > 0 {
+		w.body = []byte{}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheRecorder) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.body != nil {
+		if len(w.body)+len(p) > w.max {
+			w.body = nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes the response, which is then not stored.
+func (w *cacheRecorder) Flush() {
+	w.flushed = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// NewMemoryCacheStore returns a CacheStore keeping up to maxEntries responses
+// in memory, evicting the least recently used ones first. It panics if
+// maxEntries is not positive.
+func NewMemoryCacheStore(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		panic("runtime: memory cache store without entries")
+	}
+	return &memoryCacheStore{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		resources:  make(map[string]map[string]*list.Element),
+	}
+}
+
+type memoryCacheStore struct {
+	maxEntries int
+
+	mu sync.Mutex
+	// lru holds the *memoryCacheItem of the entries, the most recently used
+	// first.
+	lru *list.List
+	// resources holds the elements of lru by resource and variant.
+	resources map[string]map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	resource string
+	variant  string
+	entry    *CacheEntry
+}
+
+func (s *memoryCacheStore) Get(_ context.Context, resource, variant string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.resources[resource][variant]
+	if !ok {
+		return nil, nil
+	}
+	item := e.Value.(*memoryCacheItem)
+	if !time.Now().Before(item.entry.Expires) {
+		s.remove(e)
+		return nil, nil
+	}
+	s.lru.MoveToFront(e)
+	return item.entry, nil
+}
+
+func (s *memoryCacheStore) Set(_ context.Context, resource, variant string, entry *CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	variants, ok := s.resources[resource]
+	if !ok {
+		variants = make(map[string]*list.Element)
+		s.resources[resource] = variants
+	}
+	if e, ok := variants[variant]; ok {
+		e.Value.(*memoryCacheItem).entry = entry
+		s.lru.MoveToFront(e)
+		return nil
+	}
+	variants[variant] = s.lru.PushFront(&memoryCacheItem{resource: resource, variant: variant, entry: entry})
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *memoryCacheStore) Invalidate(_ context.Context, resource string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.resources[resource] {
+		s.lru.Remove(e)
+	}
+	delete(s.resources, resource)
+	return nil
+}
+
+// remove removes the entry of e. s.mu must be held.
+func (s *memoryCacheStore) remove(e *list.Element) {
+	item := s.lru.Remove(e).(*memoryCacheItem)
+	variants := s.resources[item.resource]
+	delete(variants, item.variant)
+	if len(variants) == 0 {
+		delete(s.resources, item.resource)
+	}
+}
@@ -0,0 +1,48 @@
+package runtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestMemoryCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := runtime.NewMemoryCacheStore(2)
+	set := func(resource, variant string, ttl time.Duration) {
+		entry := &runtime.CacheEntry{Status: 200, Body: []byte(resource + variant), Stored: time.Now(), Expires: time.Now().Add(ttl)}
+		if err := store.Set(ctx, resource, variant, entry); err != nil {
+			t.Fatalf("store.Set(ctx, %q, %q, entry) failed with %v; want success", resource, variant, err)
+		}
+	}
+	check := func(resource, variant string, want bool) {
+		t.Helper()
+		entry, err := store.Get(ctx, resource, variant)
+		if err != nil {
+			t.Fatalf("store.Get(ctx, %q, %q) failed with %v; want success", resource, variant, err)
+		}
+		if got := entry != nil; got != want {
+			t.Errorf("store.Get(ctx, %q, %q) = %v; want an entry: %t", resource, variant, entry, want)
+		}
+	}
+
+	set("/v1/shelves/1", "a", time.Minute)
+	set("/v1/shelves/1", "b", time.Minute)
+	check("/v1/shelves/1", "a", true)
+	// The least recently used entry is evicted.
+	set("/v1/shelves/2", "a", time.Minute)
+	check("/v1/shelves/1", "b", false)
+	check("/v1/shelves/1", "a", true)
+	check("/v1/shelves/2", "a", true)
+
+	if err := store.Invalidate(ctx, "/v1/shelves/1"); err != nil {
+		t.Fatalf("store.Invalidate(ctx, %q) failed with %v; want success", "/v1/shelves/1", err)
+	}
+	check("/v1/shelves/1", "a", false)
+	check("/v1/shelves/2", "a", true)
+
+	set("/v1/shelves/3", "a", -time.Second)
+	check("/v1/shelves/3", "a", false)
+}
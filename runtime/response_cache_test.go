@@ -0,0 +1,225 @@
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestWithResponseCache(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		header http.Header
+	}
+	get := func(path string) request { return request{method: "GET", path: path} }
+	for _, spec := range []struct {
+		name         string
+		cacheControl string
+		requests     []request
+		wantCalls    int
+	}{
+		{
+			name:         "cached",
+			cacheControl: "max-age=60",
+			requests:     []request{get("/v1/shelves/1"), get("/v1/shelves/1")},
+			wantCalls:    1,
+		},
+		{
+			name:         "normalized query",
+			cacheControl: "max-age=60",
+			requests:     []request{get("/v1/shelves/1?a=1&b=2"), get("/v1/shelves/1?b=2&a=1"), get("/v1/shelves/1?a=2&b=2")},
+			wantCalls:    2,
+		},
+		{
+			name:         "selected header",
+			cacheControl: "max-age=60",
+			requests: []request{
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Accept-Language": {"en"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Accept-Language": {"fr"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Accept-Language": {"en"}, "X-Other": {"1"}}},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:      "no max-age",
+			requests:  []request{get("/v1/shelves/1"), get("/v1/shelves/1")},
+			wantCalls: 2,
+		},
+		{
+			name:         "private",
+			cacheControl: "private, max-age=60",
+			requests:     []request{get("/v1/shelves/1"), get("/v1/shelves/1")},
+			wantCalls:    2,
+		},
+		{
+			name:         "authorization",
+			cacheControl: "max-age=60",
+			requests: []request{
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Authorization": {"Bearer token"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Authorization": {"Bearer token"}}},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:         "public authorization",
+			cacheControl: "public, max-age=60",
+			requests: []request{
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Authorization": {"Bearer token"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Authorization": {"Bearer token"}}},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:         "cookie",
+			cacheControl: "max-age=60",
+			requests: []request{
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Cookie": {"session=alice"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Cookie": {"session=bob"}}},
+				get("/v1/shelves/1"),
+			},
+			wantCalls: 3,
+		},
+		{
+			name:         "shared cookie",
+			cacheControl: "max-age=60, s-maxage=60",
+			requests: []request{
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Cookie": {"session=alice"}}},
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Cookie": {"session=bob"}}},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:         "request no-cache",
+			cacheControl: "max-age=60",
+			requests: []request{
+				get("/v1/shelves/1"),
+				{method: "GET", path: "/v1/shelves/1", header: http.Header{"Cache-Control": {"no-cache"}}},
+				get("/v1/shelves/1"),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:         "invalidated",
+			cacheControl: "max-age=60",
+			requests:     []request{get("/v1/shelves/1"), {method: "PATCH", path: "/v1/shelves/1"}, get("/v1/shelves/1")},
+			wantCalls:    2,
+		},
+		{
+			name:         "other resource",
+			cacheControl: "max-age=60",
+			requests:     []request{get("/v1/shelves/1"), {method: "PATCH", path: "/v1/shelves/2"}, get("/v1/shelves/1")},
+			wantCalls:    1,
+		},
+		{
+			name:         "failed mutation",
+			cacheControl: "max-age=60",
+			requests:     []request{get("/v1/shelves/1"), {method: "DELETE", path: "/v1/shelves/1"}, get("/v1/shelves/1")},
+			wantCalls:    1,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(runtime.WithResponseCache(runtime.ResponseCache{
+				Store:   runtime.NewMemoryCacheStore(10),
+				Headers: []string{"accept-language"},
+			}))
+			calls := 0
+			if err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				calls++
+				if spec.cacheControl != "" {
+					w.Header().Set("Cache-Control", spec.cacheControl)
+				}
+				fmt.Fprintf(w, "response %d", calls)
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+			}
+			if err := mux.HandlePath("PATCH", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "PATCH", "/v1/{name=shelves/*}", err)
+			}
+			if err := mux.HandlePath("DELETE", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				w.WriteHeader(http.StatusNotFound)
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "DELETE", "/v1/{name=shelves/*}", err)
+			}
+
+			for _, req := range spec.requests {
+				r := httptest.NewRequest(req.method, req.path, nil)
+				for k, v := range req.header {
+					r.Header[k] = v
+				}
+				mux.ServeHTTP(httptest.NewRecorder(), r)
+			}
+
+			if calls != spec.wantCalls {
+				t.Errorf("%d calls; want %d", calls, spec.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithResponseCacheHit(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithResponseCache(runtime.ResponseCache{Store: runtime.NewMemoryCacheStore(10)}))
+	if err := mux.HandlePath("GET", "/v1/{name=shelves/*}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name":"shelves/1"}`)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=shelves/*}", err)
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/shelves/1", nil))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/shelves/1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("w.Code = %d; want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Body.String(), `{"name":"shelves/1"}`; got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+	for k, want := range map[string]string{"Cache-Control": "max-age=60", "Content-Type": "application/json", "Age": "0"} {
+		if got := w.Header().Get(k); got != want {
+			t.Errorf("w.Header().Get(%q) = %q; want %q", k, got, want)
+		}
+	}
+}
+
+func TestWithResponseCacheTenants(t *testing.T) {
+	mux := runtime.NewServeMux(
+		runtime.WithTenantExtraction(runtime.TenantExtraction{Domain: "example.com", PathPrefix: "/tenants"}),
+		runtime.WithResponseCache(runtime.ResponseCache{Store: runtime.NewMemoryCacheStore(10)}),
+	)
+	if err := mux.HandlePath("GET", "/v1/data", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		tenant, _ := runtime.Tenant(r.Context())
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "secret of %s", tenant)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/data", err)
+	}
+
+	for _, spec := range []struct {
+		host   string
+		target string
+		want   string
+	}{
+		{target: "/tenants/acme/v1/data", want: "secret of acme"},
+		{target: "/tenants/other/v1/data", want: "secret of other"},
+		{target: "/tenants/acme/v1/data", want: "secret of acme"},
+		{host: "acme.example.com", target: "/v1/data", want: "secret of acme"},
+		{host: "other.example.com", target: "/v1/data", want: "secret of other"},
+		{target: "/v1/data", want: "secret of "},
+	} {
+		r := httptest.NewRequest("GET", spec.target, nil)
+		if spec.host != "" {
+			r.Host = spec.host
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if got := w.Body.String(); got != spec.want {
+			t.Errorf("GET %s%s: w.Body = %q; want %q", spec.host, spec.target, got, spec.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/internal/httprule"
+	"google.golang.org/grpc/metadata"
+)
+
+// Route describes the route a request was matched to.
+type Route struct {
+	// Pattern is the path template of the route, e.g. "/v1/{name=shelves/*}".
+	Pattern string
+	// PathParams are the values of the path parameters of the request, keyed
+	// by field path.
+	PathParams map[string]string
+	// RPCMethod is the gRPC method the request is forwarded to, in the format
+	// "/package.service/method".
+	RPCMethod string
+}
+
+// RouteAnnotatorFunc is the signature of the metadata annotators given the
+// route of the requests, registered with WithRouteMetadata.
+type RouteAnnotatorFunc func(context.Context, *http.Request, Route) metadata.MD
+
+type routeKey struct{}
+
+// matchedRoute is the route of a request, without its method which is only
+// known once its context is annotated.
+type matchedRoute struct {
+	pattern    Pattern
+	pathParams map[string]string
+	// httpMethod is the HTTP method of the request.
+	httpMethod string
+}
+
+// withRoute returns r with its route in its context, if the mux has route
+// annotators or per-route stream heartbeats, or keeps the routes of the calls.
+func (s *ServeMux) withRoute(r *http.Request, pat Pattern, pathParams map[string]string) *http.Request {
+	if len(s.routeAnnotators) == 0 && (s.heartbeat == nil || len(s.heartbeat.routes) == 0) && !s.callRoutes {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, &matchedRoute{pattern: pat, pathParams: pathParams, httpMethod: r.Method}))
+}
+
+// withMatchedRoute returns ctx with the route of req, if mux keeps the routes
+// of the calls.
+func withMatchedRoute(ctx context.Context, mux *ServeMux, req *http.Request) context.Context {
+	if !mux.callRoutes {
+		return ctx
+	}
+	if m, ok := req.Context().Value(routeKey{}).(*matchedRoute); ok {
+		return context.WithValue(ctx, routeKey{}, m)
+	}
+	return ctx
+}
+
+// annotateRoute returns the metadata of the route annotators of mux for req,
+// forwarded to rpcMethodName.
+func annotateRoute(ctx context.Context, mux *ServeMux, req *http.Request, rpcMethodName string) metadata.MD {
+	if len(mux.routeAnnotators) == 0 {
+		return nil
+	}
+	route := Route{RPCMethod: rpcMethodName}
+	if m, ok := req.Context().Value(routeKey{}).(*matchedRoute); ok {
+		route.Pattern = m.pattern.String()
+		route.PathParams = m.pathParams
+	}
+	var md metadata.MD
+	for _, annotator := range mux.routeAnnotators {
+		md = metadata.Join(md, annotator(ctx, req, route))
+	}
+	return md
+}
+
+// normalizeRoute returns the path template tmpl as returned by Pattern.String,
+// e.g. "/v1/{name=*}" for "/v1/{name}".
+func normalizeRoute(tmpl string) (string, error) {
+	compiler, err := httprule.Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	tp := compiler.Compile()
+	pattern, err := NewPattern(tp.Version, tp.OpCodes, tp.Pool, tp.Verb)
+	if err != nil {
+		return "", err
+	}
+	return pattern.String(), nil
+}
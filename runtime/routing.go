@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MetadataHeaderRequestParams is the gRPC metadata header carrying the routing
+// parameters of a request, as extracted by RoutingHeader.
+const MetadataHeaderRequestParams = "x-goog-request-params"
+
+// RoutingParameter extracts a routing parameter from a field of the request,
+// as declared by a google.api.routing annotation.
+type RoutingParameter struct {
+	// Field is the dotted path to the string field the value is extracted from.
+	Field string
+	// Key is the key of the parameter, the name of the only variable of Pattern.
+	Key string
+	// Pattern is matched against the field value, capturing the parameter.
+	Pattern Pattern
+}
+
+// RoutingHeader returns the value of the x-goog-request-params header of msg,
+// the URL-encoded 'key=value' pairs extracted by params joined by '&'.
+// Parameters whose field is empty or does not match their pattern are
+// skipped; when several parameters have the same key, the last matching one
+// wins. It returns "" if no parameter matches.
+func RoutingHeader(msg proto.Message, params []RoutingParameter) string {
+	var keys []string
+	values := make(map[string]string)
+	for _, param := range params {
+		value, fd, err := fieldPathValue(msg.ProtoReflect(), param.Field)
+		if err != nil || fd.Kind() != protoreflect.StringKind || fd.IsList() || value.String() == "" {
+			continue
+		}
+		vars, err := param.Pattern.Match(strings.Split(value.String(), "/"), "")
+		if err != nil || vars[param.Key] == "" {
+			continue
+		}
+		if _, ok := values[param.Key]; !ok {
+			keys = append(keys, param.Key)
+		}
+		values[param.Key] = vars[param.Key]
+	}
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(values[key]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// AppendRoutingHeader returns ctx with the x-goog-request-params header of
+// msg, as returned by RoutingHeader, appended to its outgoing metadata.
+func AppendRoutingHeader(ctx context.Context, msg proto.Message, params []RoutingParameter) context.Context {
+	if v := RoutingHeader(msg, params); v != "" {
+		return metadata.AppendToOutgoingContext(ctx, MetadataHeaderRequestParams, v)
+	}
+	return ctx
+}
+
+// AppendIncomingRoutingHeader returns ctx with the x-goog-request-params
+// header of msg, as returned by RoutingHeader, appended to its incoming
+// metadata, for requests handled by an in-process server.
+func AppendIncomingRoutingHeader(ctx context.Context, msg proto.Message, params []RoutingParameter) context.Context {
+	if v := RoutingHeader(msg, params); v != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return metadata.NewIncomingContext(ctx, metadata.Join(md, metadata.Pairs(MetadataHeaderRequestParams, v)))
+	}
+	return ctx
+}
@@ -0,0 +1,90 @@
+package runtime_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRoutingHeader(t *testing.T) {
+	// {routing_id=projects/*}/**
+	projectPattern := runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 1, 0, 4, 2, 5, 1, 3, 0}, []string{"projects", "routing_id"}, ""))
+	// {routing_id=**}
+	wholePattern := runtime.MustPattern(runtime.NewPattern(1, []int{3, 0, 4, 1, 5, 0}, []string{"routing_id"}, ""))
+	// {nested.string_value=**}
+	nestedPattern := runtime.MustPattern(runtime.NewPattern(1, []int{3, 0, 4, 1, 5, 0}, []string{"nested.string_value"}, ""))
+	params := []runtime.RoutingParameter{
+		{Field: "string_value", Key: "routing_id", Pattern: wholePattern},
+		{Field: "string_value", Key: "routing_id", Pattern: projectPattern},
+		{Field: "nested.string_value", Key: "nested.string_value", Pattern: nestedPattern},
+	}
+
+	for _, spec := range []struct {
+		name string
+		msg  *examplepb.Proto3Message
+		want string
+	}{
+		{
+			name: "last match wins",
+			msg:  &examplepb.Proto3Message{StringValue: "projects/p1/instances/i1"},
+			want: "routing_id=projects%2Fp1",
+		},
+		{
+			name: "fallback match",
+			msg:  &examplepb.Proto3Message{StringValue: "organizations/o1"},
+			want: "routing_id=organizations%2Fo1",
+		},
+		{
+			name: "several keys",
+			msg: &examplepb.Proto3Message{
+				StringValue: "projects/p 1",
+				Nested:      &examplepb.Proto3Message{StringValue: "profile"},
+			},
+			want: "routing_id=projects%2Fp+1&nested.string_value=profile",
+		},
+		{
+			name: "empty fields",
+			msg:  &examplepb.Proto3Message{},
+			want: "",
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			if got := runtime.RoutingHeader(spec.msg, params); got != spec.want {
+				t.Errorf("runtime.RoutingHeader(%v) = %q; want %q", spec.msg, got, spec.want)
+			}
+		})
+	}
+}
+
+func TestAppendRoutingHeader(t *testing.T) {
+	// {routing_id=**}
+	pattern := runtime.MustPattern(runtime.NewPattern(1, []int{3, 0, 4, 1, 5, 0}, []string{"routing_id"}, ""))
+	params := []runtime.RoutingParameter{{Field: "string_value", Key: "routing_id", Pattern: pattern}}
+	msg := &examplepb.Proto3Message{StringValue: "shard-1"}
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("foo", "bar"))
+	md, _ := metadata.FromOutgoingContext(runtime.AppendRoutingHeader(ctx, msg, params))
+	if got := md.Get(runtime.MetadataHeaderRequestParams); len(got) != 1 || got[0] != "routing_id=shard-1" {
+		t.Errorf("outgoing %s = %q; want %q", runtime.MetadataHeaderRequestParams, got, "routing_id=shard-1")
+	}
+	if got := md.Get("foo"); len(got) != 1 || got[0] != "bar" {
+		t.Errorf("outgoing foo = %q; want %q", got, "bar")
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("foo", "bar"))
+	md, _ = metadata.FromIncomingContext(runtime.AppendIncomingRoutingHeader(ctx, msg, params))
+	if got := md.Get(runtime.MetadataHeaderRequestParams); len(got) != 1 || got[0] != "routing_id=shard-1" {
+		t.Errorf("incoming %s = %q; want %q", runtime.MetadataHeaderRequestParams, got, "routing_id=shard-1")
+	}
+	if got := md.Get("foo"); len(got) != 1 || got[0] != "bar" {
+		t.Errorf("incoming foo = %q; want %q", got, "bar")
+	}
+
+	ctx = context.Background()
+	if got := runtime.AppendRoutingHeader(ctx, &examplepb.Proto3Message{}, params); got != ctx {
+		t.Errorf("runtime.AppendRoutingHeader() without routing parameters = %v; want %v", got, ctx)
+	}
+}
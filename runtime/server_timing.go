@@ -0,0 +1,83 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithServerTiming returns a ServeMuxOption adding a Server-Timing header to
+// the responses, with the time spent in the gateway and in the upstream gRPC
+// call in milliseconds, e.g. "gateway;dur=0.3, upstream;dur=12.5", so that
+// clients and browser devtools can attribute latency.
+//
+// The upstream call is timed from the call of the gRPC client or server by the
+// generated handler, once the request is decoded and validated, until its
+// response or error is forwarded, and for server-streaming calls until the
+// stream is established. The custom handlers calling gRPC methods report it
+// by calling StartUpstreamTiming.
+func WithServerTiming() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.serverTiming = true
+	}
+}
+
+type serverTimingKey struct{}
+
+// serverTiming records the times of a request, shared by the contexts derived
+// from the one of the request.
+type serverTiming struct {
+	start         time.Time
+	upstreamStart time.Time
+	written       bool
+}
+
+// withServerTiming returns r with a serverTiming in its context, if the mux
+// times the requests.
+func (s *ServeMux) withServerTiming(r *http.Request) *http.Request {
+	if !s.serverTiming || serverTimingFromRequest(r) != nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), serverTimingKey{}, &serverTiming{start: time.Now()}))
+}
+
+func serverTimingFromRequest(r *http.Request) *serverTiming {
+	if r == nil {
+		return nil
+	}
+	t, _ := r.Context().Value(serverTimingKey{}).(*serverTiming)
+	return t
+}
+
+// StartUpstreamTiming records the start of the upstream call of the request of
+// ctx, if it is timed with WithServerTiming. The generated handlers call it
+// right before they call the gRPC client or server.
+func StartUpstreamTiming(ctx context.Context) {
+	if t, ok := ctx.Value(serverTimingKey{}).(*serverTiming); ok {
+		t.upstreamStart = time.Now()
+	}
+}
+
+// writeServerTiming sets the Server-Timing header of the response to r, if
+// timed and not set yet.
+func writeServerTiming(w http.ResponseWriter, r *http.Request) {
+	t := serverTimingFromRequest(r)
+	if t == nil || t.written {
+		return
+	}
+	t.written = true
+	now := time.Now()
+	total := now.Sub(t.start)
+	if t.upstreamStart.IsZero() {
+		w.Header().Set("Server-Timing", fmt.Sprintf("gateway;dur=%s", formatTimingDuration(total)))
+		return
+	}
+	upstream := now.Sub(t.upstreamStart)
+	w.Header().Set("Server-Timing", fmt.Sprintf("gateway;dur=%s, upstream;dur=%s", formatTimingDuration(total-upstream), formatTimingDuration(upstream)))
+}
+
+// formatTimingDuration formats d in milliseconds, as Server-Timing durations.
+func formatTimingDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
+}
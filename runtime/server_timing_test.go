@@ -0,0 +1,59 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	emptypb "github.com/golang/protobuf/ptypes/empty"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestServeMuxServerTiming(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithServerTiming())
+	err := mux.HandlePath("GET", "/v1/ping", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/Ping")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		// The time spent before the call, e.g. decoding the request, is
+		// spent in the gateway.
+		time.Sleep(10 * time.Millisecond)
+		runtime.StartUpstreamTiming(ctx)
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{})
+		runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, r, &emptypb.Empty{})
+	})
+	if err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/ping", err)
+	}
+
+	for _, spec := range []struct {
+		path string
+		want *regexp.Regexp
+	}{
+		{
+			path: "/v1/ping",
+			want: regexp.MustCompile(`^gateway;dur=(1\d|[2-9]\d|\d{3,})\.\d{3}, upstream;dur=\d+\.\d{3}$`),
+		},
+		{
+			path: "/v1/unknown",
+			want: regexp.MustCompile(`^gateway;dur=\d+\.\d{3}$`),
+		},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", spec.path, nil))
+		if got := w.Header().Get("Server-Timing"); !spec.want.MatchString(got) {
+			t.Errorf(`w.Header().Get("Server-Timing") = %q for %s; want to match %s`, got, spec.path, spec.want)
+		}
+	}
+}
+
+func TestServeMuxWithoutServerTiming(t *testing.T) {
+	w := httptest.NewRecorder()
+	runtime.NewServeMux().ServeHTTP(w, httptest.NewRequest("GET", "/v1/unknown", nil))
+	if got := w.Header().Get("Server-Timing"); got != "" {
+		t.Errorf(`w.Header().Get("Server-Timing") = %q; want none`, got)
+	}
+}
@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SignatureKeyFunc is the signature used to look up the secret key a request
+// was signed with by its ID.
+type SignatureKeyFunc func(ctx context.Context, keyID string) ([]byte, error)
+
+// SignatureVerification configures the verification of the HMAC signatures of
+// the requests, enabled with WithSignatureVerification.
+//
+// The signature of a request is the hex-encoded HMAC of the following lines,
+// joined by "\n": the value of its timestamp header, its method, its request
+// URI as sent, i.e. its path and query, and its body.
+type SignatureVerification struct {
+	// Key looks up the secret keys. It is required.
+	Key SignatureKeyFunc
+	// KeyIDHeader is the header of the ID of the key, "X-Signature-Key-Id"
+	// if empty.
+	KeyIDHeader string
+	// SignatureHeader is the header of the signature, "X-Signature" if empty.
+	SignatureHeader string
+	// TimestampHeader is the header of the time of the signature in seconds
+	// since the Unix epoch, "X-Signature-Timestamp" if empty.
+	TimestampHeader string
+	// MaxClockSkew bounds the difference between the time of the signatures
+	// and the time of the gateway, 5 minutes if zero.
+	MaxClockSkew time.Duration
+	// Hash is the hash function of the HMAC, sha256.New if nil.
+	Hash func() hash.Hash
+}
+
+// WithSignatureVerification returns a ServeMuxOption verifying the HMAC
+// signature of the requests over their method, path and body before they are
+// forwarded, e.g. for partner APIs. The requests without a valid signature
+// are replied to with an Unauthenticated error, i.e. 401 Unauthorized.
+//
+// The body of the requests is read in memory to be verified.
+func WithSignatureVerification(verification SignatureVerification) ServeMuxOption {
+	v := verification
+	v.KeyIDHeader = canonicalHeaderOr(v.KeyIDHeader, "X-Signature-Key-Id")
+	v.SignatureHeader = canonicalHeaderOr(v.SignatureHeader, "X-Signature")
+	v.TimestampHeader = canonicalHeaderOr(v.TimestampHeader, "X-Signature-Timestamp")
+	if v.MaxClockSkew == 0 {
+		v.MaxClockSkew = 5 * time.Minute
+	}
+	if v.Hash == nil {
+		v.Hash = sha256.New
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.signature = &v
+	}
+}
+
+func canonicalHeaderOr(header, fallback string) string {
+	if header == "" {
+		return fallback
+	}
+	return textproto.CanonicalMIMEHeaderKey(header)
+}
+
+// verify returns an error if r is not signed.
+func (v *SignatureVerification) verify(r *http.Request) error {
+	keyID := r.Header.Get(v.KeyIDHeader)
+	signature, err := hex.DecodeString(r.Header.Get(v.SignatureHeader))
+	if keyID == "" || err != nil || len(signature) == 0 {
+		return status.Error(codes.Unauthenticated, "missing or malformed request signature")
+	}
+	timestamp := r.Header.Get(v.TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "missing or malformed request signature timestamp")
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > v.MaxClockSkew || skew < -v.MaxClockSkew {
+		return status.Error(codes.Unauthenticated, "request signature timestamp out of range")
+	}
+	key, err := v.Key(r.Context(), keyID)
+	if err != nil || len(key) == 0 {
+		return status.Error(codes.Unauthenticated, "unknown request signature key")
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	mac := hmac.New(v.Hash, key)
+	mac.Write([]byte(timestamp + "\n" + r.Method + "\n" + requestURI(r) + "\n"))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return status.Error(codes.Unauthenticated, "invalid request signature")
+	}
+	return nil
+}
+
+// checkSignature replies with an error and returns false if r is not signed,
+// if the mux verifies the signatures of the requests.
+func (s *ServeMux) checkSignature(w http.ResponseWriter, r *http.Request) bool {
+	if s.signature == nil {
+		return true
+	}
+	if err := s.signature.verify(r); err != nil {
+		_, outboundMarshaler := MarshalerForRequest(s, r)
+		HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func signRequest(r *http.Request, keyID string, key []byte, timestamp time.Time, body string) {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts + "\n" + r.Method + "\n" + r.URL.RequestURI() + "\n" + body))
+	r.Header.Set("X-Signature-Key-Id", keyID)
+	r.Header.Set("X-Signature-Timestamp", ts)
+	r.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestServeMuxSignatureVerification(t *testing.T) {
+	key := []byte("secret")
+	lookup := func(_ context.Context, keyID string) ([]byte, error) {
+		if keyID != "partner" {
+			return nil, errors.New("unknown key")
+		}
+		return key, nil
+	}
+	mux := runtime.NewServeMux(runtime.WithSignatureVerification(runtime.SignatureVerification{Key: lookup}))
+	var gotBody string
+	if err := mux.HandlePath("POST", "/v1/orders", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/orders", err)
+	}
+
+	const body = `{"item":"book"}`
+	for _, spec := range []struct {
+		name   string
+		sign   func(r *http.Request)
+		status int
+	}{
+		{
+			name:   "signed",
+			sign:   func(r *http.Request) { signRequest(r, "partner", key, time.Now(), body) },
+			status: http.StatusOK,
+		},
+		{
+			name:   "unsigned",
+			sign:   func(r *http.Request) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "unknown key",
+			sign:   func(r *http.Request) { signRequest(r, "other", key, time.Now(), body) },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "tampered body",
+			sign:   func(r *http.Request) { signRequest(r, "partner", key, time.Now(), `{"item":"pen"}`) },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "expired",
+			sign:   func(r *http.Request) { signRequest(r, "partner", key, time.Now().Add(-time.Hour), body) },
+			status: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			gotBody = ""
+			r := httptest.NewRequest("POST", "/v1/orders?dry_run=true", strings.NewReader(body))
+			spec.sign(r)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != spec.status {
+				t.Errorf("w.Code = %d; want %d", w.Code, spec.status)
+			}
+			if spec.status == http.StatusOK && gotBody != body {
+				t.Errorf("handler read body %q; want %q", gotBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.22
+// +build go1.22
+
+package runtime
+
+import (
+	"net/http"
+)
+
+// HandleStdlib registers to the net/http "mux" with the Go 1.22 pattern
+// stdPattern, e.g. "GET /v1/users/{id}", the handler registered to s for the
+// pair of HTTP method and path pattern, see PathValueHandler. The main module
+// must require Go 1.22 or later, or set the httpmuxgo121=0 GODEBUG setting,
+// for the mux to read the pattern as such.
+func (s *ServeMux) HandleStdlib(mux *http.ServeMux, meth string, pat Pattern, stdPattern string, wildcards map[string]string) error {
+	h, err := s.PathValueHandler(meth, pat, wildcards, (*http.Request).PathValue)
+	if err != nil {
+		return err
+	}
+	mux.Handle(stdPattern, h)
+	return nil
+}
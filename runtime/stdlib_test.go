@@ -0,0 +1,90 @@
+//go:build go1.22
+// +build go1.22
+
+// The patterns of net/http are those of Go 1.22 only if the module requires
+// it, which this one does not.
+//go:debug httpmuxgo121=0
+
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+)
+
+func TestHandleStdlib(t *testing.T) {
+	pat := runtime.MustPattern(runtime.NewPattern(1, []int{
+		int(utilities.OpLitPush), 0,
+		int(utilities.OpPush), 0,
+		int(utilities.OpConcatN), 1,
+		int(utilities.OpCapture), 1,
+	}, []string{"v1", "user.id"}, ""))
+	rmux := runtime.NewServeMux()
+	rmux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		fmt.Fprint(w, pathParams["user.id"])
+	})
+
+	mux := http.NewServeMux()
+	if err := rmux.HandleStdlib(mux, "GET", pat, "GET /v1/{user_id}", map[string]string{"user_id": "user.id"}); err != nil {
+		t.Fatalf("rmux.HandleStdlib(...) failed with %v; want success", err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/v1/a%2Fb", nil))
+	if got, want := w.Body.String(), "a/b"; got != want {
+		t.Errorf("w.Body = %q; want %q", got, want)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "http://example.com/v1/42", nil))
+	if got, want := w.Code, http.StatusMethodNotAllowed; got != want {
+		t.Errorf("w.Code = %d; want %d", got, want)
+	}
+}
+
+func TestHandleStdlibTenant(t *testing.T) {
+	pat := runtime.MustPattern(runtime.NewPattern(1, []int{
+		int(utilities.OpLitPush), 0,
+		int(utilities.OpPush), 0,
+		int(utilities.OpConcatN), 1,
+		int(utilities.OpCapture), 1,
+	}, []string{"v1", "user.id"}, ""))
+	rmux := runtime.NewServeMux(runtime.WithTenantExtraction(runtime.TenantExtraction{
+		Domain:     "example.com",
+		PathPrefix: "/tenants",
+		Required:   true,
+	}))
+	rmux.Handle("GET", pat, func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		tenant, _ := runtime.Tenant(r.Context())
+		fmt.Fprintf(w, "%s %s %s", tenant, r.URL.Path, pathParams["user.id"])
+	})
+
+	mux := http.NewServeMux()
+	for _, stdPattern := range []string{"GET /v1/{user_id}", "GET /tenants/{tenant}/v1/{user_id}"} {
+		if err := rmux.HandleStdlib(mux, "GET", pat, stdPattern, map[string]string{"user_id": "user.id"}); err != nil {
+			t.Fatalf("rmux.HandleStdlib(mux, %q, pat, %q, wildcards) failed with %v; want success", "GET", stdPattern, err)
+		}
+	}
+	for _, spec := range []struct {
+		target   string
+		wantCode int
+		want     string
+	}{
+		{target: "http://acme.example.com/v1/42", wantCode: http.StatusOK, want: "acme /v1/42 42"},
+		{target: "http://example.com/tenants/acme/v1/42", wantCode: http.StatusOK, want: "acme /v1/42 42"},
+		{target: "http://example.com/v1/42", wantCode: http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", spec.target, nil))
+		if w.Code != spec.wantCode {
+			t.Errorf("GET %s: w.Code = %d; want %d", spec.target, w.Code, spec.wantCode)
+			continue
+		}
+		if spec.want != "" && w.Body.String() != spec.want {
+			t.Errorf("GET %s: w.Body = %q; want %q", spec.target, w.Body.String(), spec.want)
+		}
+	}
+}
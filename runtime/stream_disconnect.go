@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+)
+
+// StreamDisconnect describes a server stream whose HTTP client disconnected
+// before its end.
+type StreamDisconnect struct {
+	// Delivered is the number of messages written to the client before it
+	// disconnected. The last ones may not have been received.
+	Delivered int
+	// Err is the error the disconnect was detected with, i.e. the error of
+	// the context of the request or of a write to the client.
+	Err error
+}
+
+// StreamDisconnectFunc is the signature of the callbacks notified of the
+// clients disconnecting from server streams, registered with
+// WithStreamDisconnectHandler. ctx is the context the stream is forwarded
+// with and req the request of the client.
+type StreamDisconnectFunc func(ctx context.Context, req *http.Request, disconnect StreamDisconnect)
+
+// WithStreamDisconnectHandler returns a ServeMuxOption notifying fn of the
+// clients disconnecting from server streams before their end, e.g. so that
+// the services can checkpoint the progress of the streams.
+//
+// ForwardResponseStream then stops forwarding a stream as soon as the context
+// of its request is done, even while waiting for its next message, and
+// returns after calling fn. The generated handlers cancel the context of the
+// gRPC call when ForwardResponseStream returns, which cancels the stream,
+// whether the call was made with the context of the request or not.
+func WithStreamDisconnectHandler(fn StreamDisconnectFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.streamDisconnect = fn
+	}
+}
+
+// notifyStreamDisconnect notifies the disconnect handler of the mux, if any,
+// that the client of req disconnected with err after delivered messages.
+func (s *ServeMux) notifyStreamDisconnect(ctx context.Context, req *http.Request, delivered int, err error) {
+	if s.streamDisconnect != nil {
+		s.streamDisconnect(ctx, req, StreamDisconnect{Delivered: delivered, Err: err})
+	}
+}
@@ -0,0 +1,100 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// failingWriter is a ResponseRecorder whose writes fail after its first ones.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+	err    error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.writes == 0 {
+		return 0, w.err
+	}
+	w.writes--
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestForwardResponseStreamDisconnectIdle(t *testing.T) {
+	var got []runtime.StreamDisconnect
+	mux := runtime.NewServeMux(runtime.WithStreamDisconnectHandler(func(_ context.Context, _ *http.Request, disconnect runtime.StreamDisconnect) {
+		got = append(got, disconnect)
+	}))
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil).WithContext(reqCtx)
+	release := make(chan struct{})
+	defer close(release)
+	var sent bool
+	recv := func() (proto.Message, error) {
+		if !sent {
+			sent = true
+			return &pb.SimpleMessage{Id: "One"}, nil
+		}
+		// The client disconnects while the stream waits for its next message.
+		cancel()
+		<-release
+		return nil, io.EOF
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, httptest.NewRecorder(), req, recv)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("runtime.ForwardResponseStream did not return once the client disconnected")
+	}
+
+	want := []runtime.StreamDisconnect{{Delivered: 1, Err: context.Canceled}}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("disconnects = %v; want %v", got, want)
+	}
+}
+
+func TestForwardResponseStreamDisconnectWrite(t *testing.T) {
+	var got []runtime.StreamDisconnect
+	mux := runtime.NewServeMux(runtime.WithStreamDisconnectHandler(func(_ context.Context, _ *http.Request, disconnect runtime.StreamDisconnect) {
+		got = append(got, disconnect)
+	}))
+	msgs := []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}, &pb.SimpleMessage{Id: "Three"}}
+	recv := func() (proto.Message, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+		msg := msgs[0]
+		msgs = msgs[1:]
+		return msg, nil
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	// The first message and its delimiter are written.
+	errBrokenPipe := errors.New("broken pipe")
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder(), writes: 2, err: errBrokenPipe}
+
+	runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, req, recv)
+
+	want := []runtime.StreamDisconnect{{Delivered: 1, Err: errBrokenPipe}}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("disconnects = %v; want %v", got, want)
+	}
+	if len(msgs) != 1 {
+		t.Errorf("%d messages left; want the stream to stop at the failed write", len(msgs))
+	}
+}
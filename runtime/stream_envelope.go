@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"context"
+
+	"google.golang.org/grpc/status"
+)
+
+// StreamEnvelope describes how the chunks of the responses of the server
+// streaming methods wrap their messages and errors. The zero value is the
+// default envelope, e.g. `{"result": {...}}` and `{"error": {...}}`.
+type StreamEnvelope struct {
+	// Bare writes the messages and the errors unwrapped.
+	Bare bool
+	// ResultKey is the key of the messages, "result" if empty.
+	ResultKey string
+	// ErrorKey is the key of the errors, "error" if empty.
+	ErrorKey string
+	// MetadataKey is the key of the metadata of the chunks if set, e.g.
+	// `{"result": {...}, "metadata": {"index": 2}}`, where index is the
+	// position of the chunk in the stream. Bare chunks have no metadata.
+	MetadataKey string
+}
+
+// WithStreamEnvelope returns a ServeMuxOption setting the envelope of the
+// chunks of the server streams, for the consumers which do not expect the
+// default one. The methods with the stream_envelope gateway option use theirs
+// instead.
+//
+// The streams written as JSON arrays by WithStreamJSONArray only wrap their
+// errors.
+func WithStreamEnvelope(envelope StreamEnvelope) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.streamEnvelope = envelope
+	}
+}
+
+type streamEnvelopeKey struct{}
+
+// NewStreamEnvelopeContext returns ctx with envelope, which overrides the
+// envelope of the mux for the stream forwarded with ctx. The generated
+// handlers of the methods with the stream_envelope gateway option call it.
+func NewStreamEnvelopeContext(ctx context.Context, envelope StreamEnvelope) context.Context {
+	return context.WithValue(ctx, streamEnvelopeKey{}, envelope)
+}
+
+// envelopeFor returns the envelope of the stream forwarded with ctx.
+func (s *ServeMux) envelopeFor(ctx context.Context) StreamEnvelope {
+	if envelope, ok := ctx.Value(streamEnvelopeKey{}).(StreamEnvelope); ok {
+		return envelope
+	}
+	return s.streamEnvelope
+}
+
+// resultChunk returns the chunk of body, the index-th of the stream.
+func (e StreamEnvelope) resultChunk(body interface{}, index int) interface{} {
+	key := e.ResultKey
+	if key == "" {
+		key = "result"
+	}
+	return e.chunk(key, body, index)
+}
+
+// errorChunk returns the chunk of st, the index-th of the stream.
+func (e StreamEnvelope) errorChunk(st *status.Status, index int) interface{} {
+	key := e.ErrorKey
+	if key == "" {
+		key = "error"
+	}
+	return e.chunk(key, st.Proto(), index)
+}
+
+func (e StreamEnvelope) chunk(key string, v interface{}, index int) interface{} {
+	if e.Bare {
+		return v
+	}
+	chunk := map[string]interface{}{key: v}
+	if e.MetadataKey != "" {
+		chunk[e.MetadataKey] = map[string]interface{}{"index": index}
+	}
+	return chunk
+}
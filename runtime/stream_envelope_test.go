@@ -0,0 +1,108 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardResponseStreamEnvelope(t *testing.T) {
+	marshaler := &runtime.JSONPb{}
+	errOutOfRange := status.Errorf(codes.OutOfRange, "out of range")
+	buf, err := marshaler.Marshal(status.Convert(errOutOfRange).Proto())
+	if err != nil {
+		t.Fatalf("marshaler.Marshal(status) failed with %v; want success", err)
+	}
+	var st interface{}
+	if err := json.Unmarshal(buf, &st); err != nil {
+		t.Fatalf("json.Unmarshal(%s, &st) failed with %v; want success", buf, err)
+	}
+	one := map[string]interface{}{"id": "One"}
+	two := map[string]interface{}{"id": "Two"}
+
+	for _, spec := range []struct {
+		name     string
+		mux      runtime.StreamEnvelope
+		override *runtime.StreamEnvelope
+		want     []interface{}
+	}{
+		{
+			name: "default",
+			want: []interface{}{
+				map[string]interface{}{"result": one},
+				map[string]interface{}{"result": two},
+				map[string]interface{}{"error": st},
+			},
+		},
+		{
+			name: "bare",
+			mux:  runtime.StreamEnvelope{Bare: true},
+			want: []interface{}{one, two, st},
+		},
+		{
+			name: "keys and metadata",
+			mux:  runtime.StreamEnvelope{ResultKey: "data", ErrorKey: "status", MetadataKey: "meta"},
+			want: []interface{}{
+				map[string]interface{}{"data": one, "meta": map[string]interface{}{"index": float64(0)}},
+				map[string]interface{}{"data": two, "meta": map[string]interface{}{"index": float64(1)}},
+				map[string]interface{}{"status": st, "meta": map[string]interface{}{"index": float64(2)}},
+			},
+		},
+		{
+			name:     "method override",
+			mux:      runtime.StreamEnvelope{Bare: true},
+			override: &runtime.StreamEnvelope{ResultKey: "data"},
+			want: []interface{}{
+				map[string]interface{}{"data": one},
+				map[string]interface{}{"data": two},
+				map[string]interface{}{"error": st},
+			},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			msgs := []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}}
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					return nil, errOutOfRange
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			if spec.override != nil {
+				ctx = runtime.NewStreamEnvelopeContext(ctx, *spec.override)
+			}
+			mux := runtime.NewServeMux(runtime.WithStreamEnvelope(spec.mux))
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv)
+
+			var got []interface{}
+			dec := json.NewDecoder(bytes.NewReader(w.Body.Bytes()))
+			for {
+				var chunk interface{}
+				if err := dec.Decode(&chunk); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("dec.Decode(&chunk) failed with %v in %s; want success", err, w.Body.Bytes())
+				}
+				got = append(got, chunk)
+			}
+			if !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("chunks = %v; want %v", got, spec.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package runtime
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StreamFlushPolicy controls when the chunks of the server streams are
+// flushed to the clients. The zero value flushes every chunk as soon as it
+// is written.
+type StreamFlushPolicy struct {
+	// Bytes flushes the chunks once this many bytes are buffered, if positive.
+	Bytes int
+	// Interval flushes the buffered chunks at most this long after they
+	// are written, if positive, including while the stream waits for its
+	// next message.
+	Interval time.Duration
+}
+
+// WithStreamFlush returns a ServeMuxOption setting when the chunks of the
+// server streams are flushed, so that high throughput streams are not bound
+// by a write to the connection per message. The chunks are flushed as soon as
+// either threshold of policy is reached, and when the stream ends.
+//
+// Interactive streams should keep the default policy, or a short interval.
+func WithStreamFlush(policy StreamFlushPolicy) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.streamFlush = policy
+	}
+}
+
+// bufferedStreamWriter is an http.ResponseWriter flushing the chunks of a
+// stream according to a StreamFlushPolicy. Its methods may be called
+// concurrently with the flushes of its interval.
+type bufferedStreamWriter struct {
+	w      http.ResponseWriter
+	f      http.Flusher
+	policy StreamFlushPolicy
+
+	mu      sync.Mutex
+	pending int
+	timer   *time.Timer
+	closed  bool
+}
+
+func newBufferedStreamWriter(w http.ResponseWriter, f http.Flusher, policy StreamFlushPolicy) *bufferedStreamWriter {
+	return &bufferedStreamWriter{w: w, f: f, policy: policy}
+}
+
+func (b *bufferedStreamWriter) Header() http.Header {
+	return b.w.Header()
+}
+
+func (b *bufferedStreamWriter) WriteHeader(statusCode int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.w.WriteHeader(statusCode)
+}
+
+func (b *bufferedStreamWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, err := b.w.Write(p)
+	b.pending += n
+	return n, err
+}
+
+// chunkWritten flushes the chunks written so far if a threshold is reached,
+// or schedules their flush at the end of the interval.
+func (b *bufferedStreamWriter) chunkWritten() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.pending == 0:
+	case b.policy.Bytes > 0 && b.pending >= b.policy.Bytes:
+		b.flushLocked()
+	case b.policy.Interval > 0 && b.timer == nil:
+		b.timer = time.AfterFunc(b.policy.Interval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if !b.closed {
+				b.flushLocked()
+			}
+		})
+	}
+}
+
+// Flush flushes the pending chunks, e.g. the heartbeats which are not held
+// back by the policy.
+func (b *bufferedStreamWriter) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+}
+
+// close flushes the pending chunks. The writer must not be used afterwards.
+func (b *bufferedStreamWriter) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+	b.closed = true
+}
+
+func (b *bufferedStreamWriter) flushLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if b.pending > 0 {
+		b.f.Flush()
+		b.pending = 0
+	}
+}
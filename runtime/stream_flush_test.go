@@ -0,0 +1,122 @@
+package runtime_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// flushRecorder is a ResponseRecorder counting its flushes.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+
+	mu      sync.Mutex
+	flushes int
+	// flushed is the length of the body at the last flush.
+	flushed int
+}
+
+func (r *flushRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+	r.flushed = r.Body.Len()
+	r.ResponseRecorder.Flush()
+}
+
+func (r *flushRecorder) counts() (flushes, flushed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushes, r.flushed
+}
+
+func TestForwardResponseStreamFlush(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		policy runtime.StreamFlushPolicy
+		want   int
+	}{
+		{
+			name: "per message",
+			want: 3,
+		},
+		{
+			name:   "below the byte threshold",
+			policy: runtime.StreamFlushPolicy{Bytes: 1 << 20},
+			want:   1,
+		},
+		{
+			name:   "above the byte threshold",
+			policy: runtime.StreamFlushPolicy{Bytes: 1},
+			want:   3,
+		},
+		{
+			name:   "long interval",
+			policy: runtime.StreamFlushPolicy{Interval: time.Hour},
+			want:   1,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			msgs := []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}, &pb.SimpleMessage{Id: "Three"}}
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					return nil, io.EOF
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			mux := runtime.NewServeMux(runtime.WithStreamFlush(spec.policy))
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+			runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, req, recv)
+
+			flushes, flushed := w.counts()
+			if flushes != spec.want {
+				t.Errorf("flushes = %d; want %d", flushes, spec.want)
+			}
+			if flushed != w.Body.Len() {
+				t.Errorf("flushed %d bytes; want all the %d bytes of the body", flushed, w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestForwardResponseStreamFlushInterval(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	var sent bool
+	recv := func() (proto.Message, error) {
+		if !sent {
+			sent = true
+			return &pb.SimpleMessage{Id: "One"}, nil
+		}
+		// The first message is flushed while the stream waits for the next one.
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if flushes, _ := w.counts(); flushes > 0 {
+				return nil, io.EOF
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Errorf("the first message was not flushed by the end of the interval")
+		return nil, io.EOF
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	mux := runtime.NewServeMux(runtime.WithStreamFlush(runtime.StreamFlushPolicy{Bytes: 1 << 20, Interval: 10 * time.Millisecond}))
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+
+	runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, req, recv)
+
+	if flushes, _ := w.counts(); flushes != 1 {
+		t.Errorf("flushes = %d; want 1", flushes)
+	}
+}
@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamHeartbeat configures the heartbeats written to the idle server
+// streams, enabled with WithStreamHeartbeat.
+type StreamHeartbeat struct {
+	// Interval is how long a stream may be idle before a heartbeat is
+	// written to it.
+	Interval time.Duration
+	// Frame is the heartbeat written to the streams of delimited chunks,
+	// followed by the delimiter of the marshaler, `{}` if nil. The streams
+	// written as JSON arrays by WithStreamJSONArray get a newline instead.
+	Frame []byte
+	// Routes overrides Interval for the streams of the routes with these
+	// path templates, e.g. "/v1/{name=rooms/*}:watch", as given to
+	// HandlePath or in the http rules. A non-positive interval disables the
+	// heartbeats of a route.
+	Routes map[string]time.Duration
+}
+
+// WithStreamHeartbeat returns a ServeMuxOption writing a heartbeat to the
+// server streams idle for the interval of heartbeat, so that the proxies and
+// browsers between the gateway and its clients do not close them. Only the
+// streams marshaled as JSON get heartbeats.
+//
+// A heartbeat written before the first message of a stream sends the headers
+// of the response, so the errors received afterwards are written as the last
+// chunk of the stream rather than with their HTTP status. The streams of
+// google.api.HttpBody messages, whose chunks are written as is, should not
+// get heartbeats.
+//
+// WithStreamHeartbeat panics if a route is not a valid path template.
+func WithStreamHeartbeat(heartbeat StreamHeartbeat) ServeMuxOption {
+	if heartbeat.Frame == nil {
+		heartbeat.Frame = []byte("{}")
+	}
+	routes := make(map[string]time.Duration, len(heartbeat.Routes))
+	for route, interval := range heartbeat.Routes {
+		pattern, err := normalizeRoute(route)
+		if err != nil {
+			panic(fmt.Sprintf("runtime: invalid stream heartbeat route %q: %v", route, err))
+		}
+		routes[pattern] = interval
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.heartbeat = &streamHeartbeat{StreamHeartbeat: heartbeat, routes: routes}
+	}
+}
+
+type streamHeartbeat struct {
+	StreamHeartbeat
+	routes map[string]time.Duration
+}
+
+// interval returns the heartbeat interval of the stream replying to req, or
+// 0 if it gets no heartbeats.
+func (h *streamHeartbeat) interval(req *http.Request) time.Duration {
+	if h == nil {
+		return 0
+	}
+	if m, ok := req.Context().Value(routeKey{}).(*matchedRoute); ok {
+		if interval, ok := h.routes[m.pattern.String()]; ok {
+			return interval
+		}
+	}
+	return h.Interval
+}
+
+// errHeartbeat is returned by the receive functions of asyncRecv when a
+// heartbeat is due.
+var errHeartbeat = errors.New("heartbeat")
+
+// errClientDisconnected is returned by the receive functions of asyncRecv
+// when the client of the stream disconnected.
+var errClientDisconnected = errors.New("client disconnected")
+
+type recvResult struct {
+	msg proto.Message
+	err error
+}
+
+// asyncRecv returns a function receiving the messages of recv in the
+// background, so that it returns errHeartbeat if none is received for
+// interval, if positive, and errClientDisconnected once done is closed. A
+// message is then still received by the next call.
+func asyncRecv(recv func() (proto.Message, error), interval time.Duration, done <-chan struct{}) func() (proto.Message, error) {
+	// The buffered results let the pending call return once the stream is
+	// no longer forwarded, i.e. once its context is canceled.
+	results := make(chan recvResult, 1)
+	var pending bool
+	return func() (proto.Message, error) {
+		if !pending {
+			pending = true
+			go func() {
+				msg, err := recv()
+				results <- recvResult{msg: msg, err: err}
+			}()
+		}
+		var timeout <-chan time.Time
+		if interval > 0 {
+			timer := time.NewTimer(interval)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case r := <-results:
+			pending = false
+			return r.msg, r.err
+		case <-timeout:
+			return nil, errHeartbeat
+		case <-done:
+			return nil, errClientDisconnected
+		}
+	}
+}
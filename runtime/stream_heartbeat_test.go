@@ -0,0 +1,121 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardResponseStreamHeartbeat(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	var calls int
+	recv := func() (proto.Message, error) {
+		calls++
+		switch calls {
+		case 1:
+			// The stream is idle until two heartbeats are written.
+			deadline := time.Now().Add(5 * time.Second)
+			for time.Now().Before(deadline) {
+				if flushes, _ := w.counts(); flushes >= 2 {
+					return &pb.SimpleMessage{Id: "One"}, nil
+				}
+				time.Sleep(time.Millisecond)
+			}
+			t.Errorf("no heartbeats were written to the idle stream")
+			return &pb.SimpleMessage{Id: "One"}, nil
+		default:
+			return nil, status.Errorf(codes.OutOfRange, "out of range")
+		}
+	}
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+	mux := runtime.NewServeMux(runtime.WithStreamHeartbeat(runtime.StreamHeartbeat{Interval: 5 * time.Millisecond}))
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+
+	runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, req, recv)
+
+	// The error follows the heartbeats which sent the headers.
+	if w.Code != http.StatusOK {
+		t.Errorf("w.Code = %d; want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+		t.Errorf("Content-Type = %q; want %q", got, want)
+	}
+	var heartbeats int
+	var chunks [][]byte
+	for _, chunk := range bytes.Split(bytes.TrimSuffix(w.Body.Bytes(), []byte("\n")), []byte("\n")) {
+		if string(chunk) == "{}" {
+			heartbeats++
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	if heartbeats < 2 {
+		t.Errorf("body = %s; want at least 2 heartbeats", w.Body.Bytes())
+	}
+	if len(chunks) != 2 || !bytes.Contains(chunks[0], []byte(`"result"`)) || !bytes.Contains(chunks[1], []byte(`"error"`)) {
+		t.Errorf("body = %s; want a result and an error besides the heartbeats", w.Body.Bytes())
+	}
+}
+
+func TestForwardResponseStreamHeartbeatRoutes(t *testing.T) {
+	heartbeat := runtime.StreamHeartbeat{
+		Frame: []byte(`{"heartbeat":true}`),
+		Routes: map[string]time.Duration{
+			"/v1/{name=rooms/*}:watch": 5 * time.Millisecond,
+			"/v1/quiet":                0,
+		},
+	}
+	for _, spec := range []struct {
+		name     string
+		interval time.Duration
+		pattern  string
+		path     string
+		want     bool
+	}{
+		{name: "route interval", pattern: "/v1/{name=rooms/*}:watch", path: "/v1/rooms/1:watch", want: true},
+		{name: "no interval", pattern: "/v1/other", path: "/v1/other"},
+		{name: "default interval", interval: 5 * time.Millisecond, pattern: "/v1/other", path: "/v1/other", want: true},
+		{name: "disabled route", interval: 5 * time.Millisecond, pattern: "/v1/quiet", path: "/v1/quiet"},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			heartbeat.Interval = spec.interval
+			mux := runtime.NewServeMux(runtime.WithStreamHeartbeat(heartbeat))
+			if err := mux.HandlePath("GET", spec.pattern, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				recv := func() (proto.Message, error) {
+					time.Sleep(50 * time.Millisecond)
+					return nil, io.EOF
+				}
+				ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+				runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, r, recv)
+			}); err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", spec.pattern, err)
+			}
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, httptest.NewRequest("GET", spec.path, nil))
+
+			if got := bytes.Contains(w.Body.Bytes(), heartbeat.Frame); got != spec.want {
+				t.Errorf("body = %s; want heartbeats: %t", w.Body.Bytes(), spec.want)
+			}
+		})
+	}
+}
+
+func TestWithStreamHeartbeatInvalidRoute(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("runtime.WithStreamHeartbeat(heartbeat) did not panic; want panic")
+		}
+	}()
+	runtime.WithStreamHeartbeat(runtime.StreamHeartbeat{Routes: map[string]time.Duration{"/v1/{name": time.Second}})
+}
@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"mime"
+	"strings"
+)
+
+// WithStreamJSONArray returns a ServeMuxOption writing the responses of the
+// server streaming methods as a single JSON array of the messages, e.g.
+// `[{"id":1},{"id":2}]`, rather than as newline-delimited `{"result": ...}`
+// objects, for the clients which only parse standard JSON. The elements are
+// still flushed as soon as they are received.
+//
+// An error ending the stream after the first message is written as the last
+// element of the array, e.g. `[{"id":1},{"error":{...}}]`, while an error
+// before it is written as the body of the response as usual. The streams
+// marshaled in other formats than JSON, and the streams of
+// google.api.HttpBody messages, are left as is.
+func WithStreamJSONArray() ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.streamJSONArray = true
+	}
+}
+
+// isJSONContentType returns whether contentType is a JSON media type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,106 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardResponseStreamJSONArray(t *testing.T) {
+	marshaler := &runtime.JSONPb{}
+	errOutOfRange := status.Errorf(codes.OutOfRange, "out of range")
+	buf, err := marshaler.Marshal(map[string]proto.Message{"error": status.Convert(errOutOfRange).Proto()})
+	if err != nil {
+		t.Fatalf("marshaler.Marshal(chunk) failed with %v; want success", err)
+	}
+	var errChunk interface{}
+	if err := json.Unmarshal(buf, &errChunk); err != nil {
+		t.Fatalf("json.Unmarshal(%s, &errChunk) failed with %v; want success", buf, err)
+	}
+	for _, spec := range []struct {
+		name   string
+		msgs   []proto.Message
+		err    error
+		status int
+		want   interface{}
+	}{
+		{
+			name: "messages",
+			msgs: []proto.Message{
+				&pb.SimpleMessage{Id: "One"},
+				&pb.SimpleMessage{Id: "Two"},
+			},
+			status: http.StatusOK,
+			want: []interface{}{
+				map[string]interface{}{"id": "One"},
+				map[string]interface{}{"id": "Two"},
+			},
+		},
+		{
+			name:   "empty",
+			status: http.StatusOK,
+			want:   []interface{}{},
+		},
+		{
+			name:   "stream error",
+			msgs:   []proto.Message{&pb.SimpleMessage{Id: "One"}},
+			err:    errOutOfRange,
+			status: http.StatusOK,
+			want: []interface{}{
+				map[string]interface{}{"id": "One"},
+				errChunk,
+			},
+		},
+		{
+			name:   "error",
+			err:    errOutOfRange,
+			status: http.StatusBadRequest,
+			want:   errChunk,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			msgs := spec.msgs
+			recv := func() (proto.Message, error) {
+				if len(msgs) == 0 {
+					if spec.err != nil {
+						return nil, spec.err
+					}
+					return nil, io.EOF
+				}
+				msg := msgs[0]
+				msgs = msgs[1:]
+				return msg, nil
+			}
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			mux := runtime.NewServeMux(runtime.WithStreamJSONArray())
+			req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := httptest.NewRecorder()
+
+			runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv)
+
+			if w.Code != spec.status {
+				t.Errorf("w.Code = %d; want %d", w.Code, spec.status)
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/json"; spec.status == http.StatusOK && got != want {
+				t.Errorf("Content-Type = %q; want %q", got, want)
+			}
+			var got interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%s, &got) failed with %v; want success", w.Body.Bytes(), err)
+			}
+			if !reflect.DeepEqual(got, spec.want) {
+				t.Errorf("response = %v; want %v", got, spec.want)
+			}
+		})
+	}
+}
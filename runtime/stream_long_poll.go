@@ -0,0 +1,218 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamLongPoll configures the routes whose server streams are long-polled,
+// enabled with WithStreamLongPoll.
+type StreamLongPoll struct {
+	// Routes are the path templates of the long-polled routes, e.g.
+	// "/v1/{name=rooms/*}:poll", as given to HandlePath or in the http rules.
+	Routes []string
+	// Timeout is how long a poll collects the messages of its stream, 30
+	// seconds if zero.
+	Timeout time.Duration
+	// MaxMessages is the number of messages a poll returns as soon as they
+	// are received, if positive.
+	MaxMessages int
+	// TokenParam is the query parameter of the continuation tokens of the
+	// polls, "continuation_token" if empty. It is not parsed as a field of
+	// the request message.
+	TokenParam string
+	// MetadataKey is the gRPC metadata key the continuation tokens are
+	// forwarded as, "continuation-token" if empty.
+	MetadataKey string
+}
+
+// WithStreamLongPoll returns a ServeMuxOption exposing the server streams of
+// the routes of longPoll as long-poll endpoints, for the clients whose
+// network does not let streamed responses through.
+//
+// Each request to these routes collects the messages of its stream until
+// the timeout of longPoll, the maximum number of messages or the end of the
+// stream, and replies with them as a JSON object like
+//
+//	{"results": [...], "continuationToken": "3"}
+//
+// The client then polls the route again with the continuation token as the
+// query parameter of longPoll, until a response has no continuation token,
+// i.e. the stream ended. The token is the number of messages the previous
+// polls returned, forwarded to the services as metadata so that they resume
+// their streams after them. The polls failing with an error reply with it
+// rather than with the messages they collected, which the next poll with
+// the same token gets again.
+//
+// Only the streams marshaled as JSON are long-polled.
+//
+// WithStreamLongPoll panics if a route is not a valid path template.
+func WithStreamLongPoll(longPoll StreamLongPoll) ServeMuxOption {
+	if longPoll.Timeout == 0 {
+		longPoll.Timeout = 30 * time.Second
+	}
+	if longPoll.TokenParam == "" {
+		longPoll.TokenParam = "continuation_token"
+	}
+	if longPoll.MetadataKey == "" {
+		longPoll.MetadataKey = "continuation-token"
+	}
+	routes := make(map[string]bool, len(longPoll.Routes))
+	for _, route := range longPoll.Routes {
+		pattern, err := normalizeRoute(route)
+		if err != nil {
+			panic(fmt.Sprintf("runtime: invalid stream long poll route %q: %v", route, err))
+		}
+		routes[pattern] = true
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.longPoll = &streamLongPoll{StreamLongPoll: longPoll, routes: routes}
+	}
+}
+
+type streamLongPoll struct {
+	StreamLongPoll
+	routes map[string]bool
+}
+
+type longPollKey struct{}
+
+// longPollRequest is the poll of a long-polled stream.
+type longPollRequest struct {
+	// offset is the number of messages of the stream the previous polls
+	// returned.
+	offset int
+}
+
+// pairs returns the metadata pairs forwarding the continuation token of the
+// poll of ctx, if any.
+func (l *streamLongPoll) pairs(ctx context.Context) []string {
+	p, ok := ctx.Value(longPollKey{}).(*longPollRequest)
+	if !ok || p.offset == 0 {
+		return nil
+	}
+	return []string{l.MetadataKey, strconv.Itoa(p.offset)}
+}
+
+// checkLongPoll replies with an error and returns false if the continuation
+// token of r is invalid, if pat is a long-polled route. It returns r as a
+// poll, without the query parameter of its token, otherwise.
+func (s *ServeMux) checkLongPoll(w http.ResponseWriter, r *http.Request, pat Pattern) (*http.Request, bool) {
+	if s.longPoll == nil || !s.longPoll.routes[pat.String()] {
+		return r, true
+	}
+	p := &longPollRequest{}
+	query := r.URL.Query()
+	if values, ok := query[s.longPoll.TokenParam]; ok {
+		var err error
+		if len(values) > 1 {
+			err = errors.New("multiple continuation tokens")
+		} else if p.offset, err = strconv.Atoi(values[0]); err == nil && p.offset < 0 {
+			err = errors.New("negative offset")
+		}
+		if err != nil {
+			_, outboundMarshaler := MarshalerForRequest(s, r)
+			HTTPError(r.Context(), s, outboundMarshaler, w, r, FieldViolationError(s.longPoll.TokenParam, fmt.Errorf("invalid continuation token: %v", err)))
+			return r, false
+		}
+		query.Del(s.longPoll.TokenParam)
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		r2 := *r
+		r2.URL = &u
+		r = &r2
+	}
+	return r.WithContext(context.WithValue(r.Context(), longPollKey{}, p)), true
+}
+
+// forwardLongPoll replies to req with the messages of the stream of recv it
+// collects, and returns false if req is not a poll or its response is not
+// marshaled as JSON.
+func forwardLongPoll(ctx context.Context, mux *ServeMux, marshaler Marshaler, w http.ResponseWriter, req *http.Request, recv func() (proto.Message, error), opts []func(context.Context, http.ResponseWriter, proto.Message) error) bool {
+	p, ok := req.Context().Value(longPollKey{}).(*longPollRequest)
+	if !ok || !isJSONContentType(marshaler.ContentType(nil)) {
+		return false
+	}
+
+	// stop is closed once the poll times out or its client disconnects.
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(finished)
+	timer := time.NewTimer(mux.longPoll.Timeout)
+	defer timer.Stop()
+	go func() {
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+		case <-finished:
+			return
+		}
+		close(stop)
+	}()
+	recv = asyncRecv(recv, 0, stop)
+
+	sel := mux.responseFieldSelection(req)
+	var results [][]byte
+	var ended bool
+	for mux.longPoll.MaxMessages <= 0 || len(results) < mux.longPoll.MaxMessages {
+		resp, err := recv()
+		if err == errClientDisconnected {
+			if err := req.Context().Err(); err != nil {
+				mux.notifyStreamDisconnect(ctx, req, 0, err)
+				return true
+			}
+			break
+		}
+		if err == io.EOF {
+			ended = true
+			break
+		}
+		if err == nil {
+			resp, err = mux.rewriteResponse(ctx, w, resp)
+		}
+		if err == nil {
+			err = handleForwardResponseOptions(ctx, w, resp, opts)
+		}
+		var buf []byte
+		if err == nil {
+			var body interface{} = resp
+			if rb, ok := resp.(responseBody); ok {
+				body = rb.XXX_ResponseBody()
+			}
+			if body, err = selectFields(body, sel); err == nil {
+				buf, err = marshaler.Marshal(body)
+			}
+		}
+		if err != nil {
+			HTTPError(ctx, mux, marshaler, w, req, err)
+			return true
+		}
+		results = append(results, buf)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(`{"results":[`)
+	out.Write(bytes.Join(results, []byte(",")))
+	out.WriteString("]")
+	if !ended {
+		token, _ := json.Marshal(strconv.Itoa(p.offset + len(results)))
+		out.WriteString(`,"continuationToken":`)
+		out.Write(token)
+	}
+	out.WriteString("}")
+	w.Header().Set("Content-Type", marshaler.ContentType(nil))
+	if _, err := w.Write(out.Bytes()); err != nil {
+		grpclog.Infof("Failed to write long poll response: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type longPollResponse struct {
+	Results           []map[string]interface{} `json:"results"`
+	ContinuationToken *string                  `json:"continuationToken"`
+}
+
+func TestWithStreamLongPoll(t *testing.T) {
+	msgs := []proto.Message{&pb.SimpleMessage{Id: "One"}, &pb.SimpleMessage{Id: "Two"}, &pb.SimpleMessage{Id: "Three"}}
+	mux := runtime.NewServeMux(runtime.WithStreamLongPoll(runtime.StreamLongPoll{
+		Routes:      []string{"/v1/{name=rooms/*}/messages:poll"},
+		MaxMessages: 2,
+	}))
+	var query string
+	var md metadata.MD
+	if err := mux.HandlePath("GET", "/v1/{name=rooms/*}/messages:poll", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		query = r.URL.RawQuery
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.Example/WatchMessages")
+		if err != nil {
+			t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+		}
+		md, _ = metadata.FromOutgoingContext(ctx)
+		// The service resumes the stream after the messages already polled.
+		stream := msgs
+		if offset := md.Get("continuation-token"); len(offset) == 1 && offset[0] == "2" {
+			stream = msgs[2:]
+		}
+		recv := func() (proto.Message, error) {
+			if len(stream) == 0 {
+				return nil, io.EOF
+			}
+			msg := stream[0]
+			stream = stream[1:]
+			return msg, nil
+		}
+		ctx = runtime.NewServerMetadataContext(ctx, runtime.ServerMetadata{})
+		runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, r, recv)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/{name=rooms/*}/messages:poll", err)
+	}
+
+	for _, spec := range []struct {
+		target    string
+		wantQuery string
+		wantIDs   []string
+		wantToken string
+	}{
+		{
+			target:    "/v1/rooms/general/messages:poll?page_size=10",
+			wantQuery: "page_size=10",
+			wantIDs:   []string{"One", "Two"},
+			wantToken: "2",
+		},
+		{
+			target:    "/v1/rooms/general/messages:poll?continuation_token=2&page_size=10",
+			wantQuery: "page_size=10",
+			wantIDs:   []string{"Three"},
+		},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", spec.target, nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: w.Code = %d; want %d", spec.target, w.Code, http.StatusOK)
+		}
+		if query != spec.wantQuery {
+			t.Errorf("GET %s: r.URL.RawQuery = %q; want %q", spec.target, query, spec.wantQuery)
+		}
+		var resp longPollResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("GET %s: json.Unmarshal(%s) failed with %v; want success", spec.target, w.Body.Bytes(), err)
+		}
+		var ids []string
+		for _, result := range resp.Results {
+			ids = append(ids, result["id"].(string))
+		}
+		if !reflect.DeepEqual(ids, spec.wantIDs) {
+			t.Errorf("GET %s: results = %q; want %q", spec.target, ids, spec.wantIDs)
+		}
+		switch {
+		case spec.wantToken == "" && resp.ContinuationToken != nil:
+			t.Errorf("GET %s: continuationToken = %q; want none once the stream ended", spec.target, *resp.ContinuationToken)
+		case spec.wantToken != "" && (resp.ContinuationToken == nil || *resp.ContinuationToken != spec.wantToken):
+			t.Errorf("GET %s: continuationToken = %v; want %q", spec.target, resp.ContinuationToken, spec.wantToken)
+		}
+	}
+}
+
+func TestWithStreamLongPollTimeout(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithStreamLongPoll(runtime.StreamLongPoll{
+		Routes:  []string{"/v1/messages:poll"},
+		Timeout: 10 * time.Millisecond,
+	}))
+	release := make(chan struct{})
+	defer close(release)
+	if err := mux.HandlePath("GET", "/v1/messages:poll", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		var sent bool
+		recv := func() (proto.Message, error) {
+			if !sent {
+				sent = true
+				return &pb.SimpleMessage{Id: "One"}, nil
+			}
+			// The stream is idle past the timeout of the poll.
+			<-release
+			return nil, io.EOF
+		}
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, r, recv)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/messages:poll", err)
+	}
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/messages:poll?continuation_token=5", nil))
+
+	var resp longPollResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed with %v; want success", w.Body.Bytes(), err)
+	}
+	if len(resp.Results) != 1 {
+		t.Errorf("results = %v; want 1 result", resp.Results)
+	}
+	if resp.ContinuationToken == nil || *resp.ContinuationToken != "6" {
+		t.Errorf("continuationToken = %v; want %q", resp.ContinuationToken, "6")
+	}
+}
+
+func TestWithStreamLongPollInvalidToken(t *testing.T) {
+	mux := runtime.NewServeMux(runtime.WithStreamLongPoll(runtime.StreamLongPoll{
+		Routes: []string{"/v1/messages:poll"},
+	}))
+	if err := mux.HandlePath("GET", "/v1/messages:poll", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		t.Errorf("the poll with an invalid continuation token was handled")
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/messages:poll", err)
+	}
+	for _, token := range []string{"next", "-1"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/messages:poll?continuation_token="+token, nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("continuation_token=%s: w.Code = %d; want %d", token, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestWithStreamLongPollInvalidRoute(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("runtime.WithStreamLongPoll did not panic for an invalid route")
+		}
+	}()
+	runtime.WithStreamLongPoll(runtime.StreamLongPoll{Routes: []string{"v1/{name"}})
+}
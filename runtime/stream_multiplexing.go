@@ -0,0 +1,375 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
+)
+
+// StreamMultiplexing configures the WebSocket endpoint multiplexing the
+// streams of the clients, enabled with WithStreamMultiplexing.
+type StreamMultiplexing struct {
+	// Path is the path of the WebSocket endpoint, e.g. "/v1/streams".
+	Path string
+	// MaxChannels is the number of channels a connection may have open at
+	// once, 100 if zero.
+	MaxChannels int
+	// MaxMessageSize is the size in bytes of the largest WebSocket message
+	// read from the clients, 1 MiB if zero.
+	MaxMessageSize int64
+	// CheckOrigin returns whether the WebSocket handshake r is accepted, if
+	// set. The handshakes are otherwise accepted if they have no Origin
+	// header or an Origin of the host of the request.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// WithStreamMultiplexing returns a ServeMuxOption serving a WebSocket endpoint
+// at the path of multiplexing, over which the clients, e.g. browsers, open
+// several concurrent calls on channels of a single connection.
+//
+// Each WebSocket message is a JSON text frame with the ID of its channel,
+// chosen by the client when opening it:
+//
+//	{"channel": 1, "open": {"method": "POST", "path": "/v1/echo:stream", "header": {...}}}
+//	{"channel": 1, "message": {...}}
+//	{"channel": 1, "close": true}
+//	{"channel": 1, "cancel": true}
+//
+// An open frame sends a request to the mux, with the headers of the
+// handshake and those of the frame. The message frames of the client are the
+// messages of the body of the request, which a close frame ends, as needed by
+// the unary and the client and bidi streaming calls. A cancel frame cancels
+// the call.
+//
+// The server sends the messages of the response of the call as message
+// frames, with a single one for the unary calls, the body of non-JSON
+// responses as a "data" string, and ends the channel with
+//
+//	{"channel": 1, "end": {"status": 200}}
+//
+// the status being the HTTP status of the response, and "error" the reason
+// the channel failed to open, if any.
+func WithStreamMultiplexing(multiplexing StreamMultiplexing) ServeMuxOption {
+	if multiplexing.MaxChannels == 0 {
+		multiplexing.MaxChannels = 100
+	}
+	if multiplexing.MaxMessageSize == 0 {
+		multiplexing.MaxMessageSize = 1 << 20
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.multiplexing = &multiplexing
+	}
+}
+
+// muxFrame is a WebSocket message of a multiplexed connection.
+type muxFrame struct {
+	Channel uint32          `json:"channel"`
+	Open    *muxOpen        `json:"open,omitempty"`
+	Message json.RawMessage `json:"message,omitempty"`
+	Data    string          `json:"data,omitempty"`
+	Close   bool            `json:"close,omitempty"`
+	Cancel  bool            `json:"cancel,omitempty"`
+	End     *muxEnd         `json:"end,omitempty"`
+}
+
+type muxOpen struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+type muxEnd struct {
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// checkOrigin returns whether the Origin of the handshake r is accepted.
+func (m *StreamMultiplexing) checkOrigin(r *http.Request) bool {
+	if m.CheckOrigin != nil {
+		return m.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// serveMultiplexed upgrades r to a multiplexed WebSocket connection and serves
+// its channels until it is closed.
+func (s *ServeMux) serveMultiplexed(w http.ResponseWriter, r *http.Request) {
+	if !s.multiplexing.checkOrigin(r) {
+		_, outboundMarshaler := MarshalerForRequest(s, r)
+		HTTPError(r.Context(), s, outboundMarshaler, w, r, status.Error(codes.PermissionDenied, "origin not allowed"))
+		return
+	}
+	conn, err := upgradeWebSocket(w, r, s.multiplexing.MaxMessageSize)
+	if err != nil {
+		_, outboundMarshaler := MarshalerForRequest(s, r)
+		HTTPError(r.Context(), s, outboundMarshaler, w, r, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	c := &muxConn{mux: s, conn: conn, req: r, channels: make(map[uint32]*muxChannel)}
+	c.serve()
+}
+
+// muxConn is a multiplexed WebSocket connection.
+type muxConn struct {
+	mux  *ServeMux
+	conn *wsConn
+	// req is the WebSocket handshake.
+	req *http.Request
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	channels map[uint32]*muxChannel
+}
+
+// muxChannel is an open channel of a muxConn.
+type muxChannel struct {
+	cancel context.CancelFunc
+	body   *muxBody
+}
+
+func (c *muxConn) serve() {
+	ctx, cancel := context.WithCancel(c.req.Context())
+	defer func() {
+		cancel()
+		c.mu.Lock()
+		for _, ch := range c.channels {
+			ch.body.finish(context.Canceled)
+		}
+		c.mu.Unlock()
+		c.wg.Wait()
+	}()
+	for {
+		op, msg, err := c.conn.readMessage()
+		if err == errWebSocketClosed {
+			return
+		}
+		if cerr, ok := err.(*wsCloseError); ok {
+			c.conn.close(cerr.code, cerr.text)
+			return
+		}
+		if err != nil {
+			grpclog.Infof("Failed to read from websocket: %v", err)
+			c.conn.conn.Close()
+			return
+		}
+		if op != wsText {
+			c.conn.close(wsCloseUnsupportedData, "binary messages are not supported")
+			return
+		}
+		var frame muxFrame
+		if err := json.Unmarshal(msg, &frame); err != nil {
+			c.conn.close(wsCloseInvalidData, "invalid frame")
+			return
+		}
+		c.dispatch(ctx, &frame)
+	}
+}
+
+// dispatch handles the frame of the client.
+func (c *muxConn) dispatch(ctx context.Context, frame *muxFrame) {
+	c.mu.Lock()
+	ch, ok := c.channels[frame.Channel]
+	c.mu.Unlock()
+	switch {
+	case frame.Open != nil:
+		if ok {
+			c.end(frame.Channel, muxEnd{Status: http.StatusConflict, Error: "channel already open"})
+			return
+		}
+		c.open(ctx, frame.Channel, frame.Open)
+	case !ok:
+		// The frames of the channels which ended are dropped.
+	case frame.Cancel:
+		ch.cancel()
+		ch.body.finish(context.Canceled)
+	case frame.Message != nil:
+		ch.body.write(append(frame.Message, '\n'))
+	case frame.Close:
+		ch.body.finish(io.EOF)
+	}
+}
+
+// open opens the channel id, sending the request of open to the mux.
+func (c *muxConn) open(ctx context.Context, id uint32, open *muxOpen) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.channels) >= c.mux.multiplexing.MaxChannels {
+		c.end(id, muxEnd{Status: http.StatusTooManyRequests, Error: "too many channels"})
+		return
+	}
+	u, err := url.ParseRequestURI(open.Path)
+	if err != nil {
+		c.end(id, muxEnd{Status: http.StatusBadRequest, Error: "invalid path"})
+		return
+	}
+	method := open.Method
+	if method == "" {
+		method = "GET"
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	body := newMuxBody()
+	r := c.req.Clone(ctx)
+	r.Method = strings.ToUpper(method)
+	r.URL = u
+	r.RequestURI = u.RequestURI()
+	r.Body = body
+	r.ContentLength = -1
+	for _, h := range []string{"Connection", "Upgrade", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Protocol"} {
+		r.Header.Del(h)
+	}
+	for k, v := range open.Header {
+		r.Header.Set(k, v)
+	}
+	c.channels[id] = &muxChannel{cancel: cancel, body: body}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+		w := &muxResponseWriter{conn: c, channel: id, header: make(http.Header)}
+		c.mux.ServeHTTP(w, r)
+		w.send(true)
+		c.mu.Lock()
+		delete(c.channels, id)
+		c.mu.Unlock()
+		code := w.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		c.end(id, muxEnd{Status: code})
+	}()
+}
+
+// end ends the channel id of the client with end.
+func (c *muxConn) end(id uint32, end muxEnd) {
+	c.write(&muxFrame{Channel: id, End: &end})
+}
+
+func (c *muxConn) write(frame *muxFrame) {
+	buf, err := json.Marshal(frame)
+	if err != nil {
+		grpclog.Infof("Failed to marshal websocket frame: %v", err)
+		return
+	}
+	if err := c.conn.writeFrame(wsText, buf); err != nil {
+		grpclog.Infof("Failed to write to websocket: %v", err)
+	}
+}
+
+// muxBody is the body of the request of a channel, whose writes by the
+// connection never block.
+type muxBody struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	err  error
+}
+
+func newMuxBody() *muxBody {
+	b := &muxBody{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *muxBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.buf.Len() == 0 && b.err == nil {
+		b.cond.Wait()
+	}
+	if b.buf.Len() > 0 {
+		return b.buf.Read(p)
+	}
+	return 0, b.err
+}
+
+func (b *muxBody) Close() error {
+	b.finish(io.ErrClosedPipe)
+	return nil
+}
+
+func (b *muxBody) write(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.buf.Write(p)
+		b.cond.Broadcast()
+	}
+}
+
+// finish ends the body with err once its buffered messages are read.
+func (b *muxBody) finish(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = err
+		b.cond.Broadcast()
+	}
+}
+
+// muxResponseWriter writes the response of a channel as its message frames.
+type muxResponseWriter struct {
+	conn    *muxConn
+	channel uint32
+	header  http.Header
+	status  int
+	buf     bytes.Buffer
+}
+
+func (w *muxResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *muxResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *muxResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.buf.Write(p)
+}
+
+// Flush sends the complete JSON messages written so far.
+func (w *muxResponseWriter) Flush() {
+	w.send(false)
+}
+
+// send sends the JSON messages written so far as message frames, and the rest
+// of the response as a data frame if final or not JSON.
+func (w *muxResponseWriter) send(final bool) {
+	dec := json.NewDecoder(bytes.NewReader(w.buf.Bytes()))
+	var sent int64
+	var err error
+	for {
+		var msg json.RawMessage
+		if err = dec.Decode(&msg); err != nil {
+			break
+		}
+		w.conn.write(&muxFrame{Channel: w.channel, Message: msg})
+		sent = dec.InputOffset()
+	}
+	w.buf.Next(int(sent))
+	if err == io.EOF || (err == io.ErrUnexpectedEOF && !final) {
+		return
+	}
+	if data := w.buf.String(); strings.TrimSpace(data) != "" {
+		w.conn.write(&muxFrame{Channel: w.channel, Data: data})
+	}
+	w.buf.Reset()
+}
@@ -0,0 +1,168 @@
+package runtime_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// wsClient is the client side of a WebSocket connection.
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialWebSocket sends a WebSocket handshake with the extra header lines to
+// path of the server, and returns the response and the connection.
+func dialWebSocket(t *testing.T, server *httptest.Server, path, header string) (*http.Response, *wsClient) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed with %v; want success", server.Listener.Addr(), err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n%s\r\n", path, server.Listener.Addr(), header)
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("http.ReadResponse(r, nil) failed with %v; want success", err)
+	}
+	return resp, &wsClient{conn: conn, r: r}
+}
+
+func (c *wsClient) write(t *testing.T, frame string) {
+	payload := []byte(frame)
+	head := []byte{0x81, 0x80 | byte(len(payload))}
+	if len(payload) > 125 {
+		head = []byte{0x81, 0x80 | 126, byte(len(payload) >> 8), byte(len(payload))}
+	}
+	mask := []byte{1, 2, 3, 4}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	if _, err := c.conn.Write(append(append(head, mask...), payload...)); err != nil {
+		t.Fatalf("conn.Write(%s) failed with %v; want success", frame, err)
+	}
+}
+
+func (c *wsClient) read(t *testing.T) map[string]interface{} {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		t.Fatalf("io.ReadFull(r) failed with %v; want success", err)
+	}
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		t.Fatalf("io.ReadFull(r) failed with %v; want success", err)
+	}
+	if op := head[0] & 0x0f; op != 0x1 {
+		t.Fatalf("opcode = %d, payload = %q; want a text frame", op, payload)
+	}
+	var frame map[string]interface{}
+	if err := json.Unmarshal(payload, &frame); err != nil {
+		t.Fatalf("json.Unmarshal(%s) failed with %v; want success", payload, err)
+	}
+	return frame
+}
+
+func newMultiplexingServer(t *testing.T) *httptest.Server {
+	mux := runtime.NewServeMux(runtime.WithStreamMultiplexing(runtime.StreamMultiplexing{Path: "/v1/streams"}))
+	if err := mux.HandlePath("POST", "/v1/echo:stream", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		dec := (&runtime.JSONPb{}).NewDecoder(r.Body)
+		recv := func() (proto.Message, error) {
+			msg := &pb.SimpleMessage{}
+			if err := dec.Decode(msg); err != nil {
+				return nil, err
+			}
+			return msg, nil
+		}
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		runtime.ForwardResponseStream(ctx, mux, &runtime.JSONPb{}, w, r, recv)
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", "/v1/echo:stream", err)
+	}
+	if err := mux.HandlePath("GET", "/v1/hello", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		runtime.ForwardResponseMessage(ctx, mux, &runtime.JSONPb{}, w, r, &pb.SimpleMessage{Id: r.Header.Get("X-Name")})
+	}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/hello", err)
+	}
+	return httptest.NewServer(mux)
+}
+
+func TestWithStreamMultiplexing(t *testing.T) {
+	server := newMultiplexingServer(t)
+	defer server.Close()
+	resp, client := dialWebSocket(t, server, "/v1/streams", "")
+	defer client.conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("resp.StatusCode = %d; want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("Sec-WebSocket-Accept = %q; want %q", got, want)
+	}
+
+	client.write(t, `{"channel": 1, "open": {"method": "POST", "path": "/v1/echo:stream"}}`)
+	client.write(t, `{"channel": 2, "open": {"path": "/v1/hello", "header": {"X-Name": "world"}}}`)
+	client.write(t, `{"channel": 1, "message": {"id": "One"}}`)
+	client.write(t, `{"channel": 1, "message": {"id": "Two"}}`)
+	client.write(t, `{"channel": 1, "close": true}`)
+
+	messages := make(map[float64][]string)
+	ends := make(map[float64]interface{})
+	for len(ends) < 2 {
+		frame := client.read(t)
+		channel := frame["channel"].(float64)
+		if end, ok := frame["end"]; ok {
+			ends[channel] = end.(map[string]interface{})["status"]
+			continue
+		}
+		msg, ok := frame["message"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("frame = %v; want a message or an end frame", frame)
+		}
+		if result, ok := msg["result"].(map[string]interface{}); ok {
+			msg = result
+		}
+		messages[channel] = append(messages[channel], msg["id"].(string))
+	}
+
+	if got, want := strings.Join(messages[1], ","), "One,Two"; got != want {
+		t.Errorf("messages of channel 1 = %q; want %q", got, want)
+	}
+	if got, want := strings.Join(messages[2], ","), "world"; got != want {
+		t.Errorf("messages of channel 2 = %q; want %q", got, want)
+	}
+	for _, channel := range []float64{1, 2} {
+		if ends[channel] != float64(http.StatusOK) {
+			t.Errorf("status of channel %v = %v; want %d", channel, ends[channel], http.StatusOK)
+		}
+	}
+}
+
+func TestWithStreamMultiplexingOrigin(t *testing.T) {
+	server := newMultiplexingServer(t)
+	defer server.Close()
+	resp, client := dialWebSocket(t, server, "/v1/streams", "Origin: https://attacker.example.com\r\n")
+	defer client.conn.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("resp.StatusCode = %d; want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
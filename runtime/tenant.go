@@ -0,0 +1,155 @@
+package runtime
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TenantExtraction configures the extraction of the tenant of the requests,
+// enabled with WithTenantExtraction. The tenant is read from the path prefix
+// of the requests if it is configured and they have one, and from their host
+// otherwise.
+type TenantExtraction struct {
+	// Domain is the domain the hosts of the tenants are subdomains of, e.g.
+	// "example.com" to read the tenant "acme" of "acme.example.com". Only the
+	// hosts with a single label before it have a tenant.
+	Domain string
+	// PathPrefix is the prefix of the path segment of the tenant if set, e.g.
+	// "/tenants" to read the tenant "acme" of "/tenants/acme/v1/shelves",
+	// which is then matched as "/v1/shelves". "/" reads the tenant of the
+	// first path segment.
+	PathPrefix string
+	// MetadataKey is the gRPC metadata key the tenant is forwarded as,
+	// "x-tenant-id" if empty.
+	MetadataKey string
+	// Required rejects the requests without a tenant as not found.
+	Required bool
+}
+
+type tenantKey struct{}
+
+type requestURIKey struct{}
+
+// WithTenantExtraction returns a ServeMuxOption reading the tenant of the
+// requests from the subdomain of their host or from a prefix of their path,
+// which is stripped before the requests are matched, so that the same routes
+// serve all the tenants. The tenant is forwarded to the backends as metadata,
+// in place of the headers the clients may set it with, and exposed to the
+// handlers, e.g. the request modifiers, with Tenant.
+func WithTenantExtraction(tenant TenantExtraction) ServeMuxOption {
+	if tenant.MetadataKey == "" {
+		tenant.MetadataKey = "x-tenant-id"
+	}
+	return func(serveMux *ServeMux) {
+		serveMux.tenant = &tenant
+	}
+}
+
+// Tenant returns the tenant of the request of ctx, as read with
+// WithTenantExtraction.
+func Tenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// withTenant returns r with its tenant in its context, and its path stripped
+// of the prefix of the tenant, if the mux extracts tenants. It writes an error
+// to w and returns false if r has no tenant but one is required.
+func (s *ServeMux) withTenant(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if s.tenant == nil {
+		return r, true
+	}
+	tenant, u := s.tenant.fromPath(r.URL)
+	if tenant == "" {
+		tenant = s.tenant.fromHost(r.Host)
+	}
+	if tenant == "" {
+		if s.tenant.Required {
+			_, outboundMarshaler := MarshalerForRequest(s, r)
+			s.routingErrorHandler(r.Context(), s, outboundMarshaler, w, r, http.StatusNotFound)
+			return nil, false
+		}
+		return r, true
+	}
+	ctx := context.WithValue(r.Context(), tenantKey{}, tenant)
+	if u != nil {
+		ctx = context.WithValue(ctx, requestURIKey{}, r.URL.RequestURI())
+	}
+	r = r.WithContext(ctx)
+	if u != nil {
+		r.URL = u
+	}
+	return r, true
+}
+
+// requestURI returns the request URI of r as sent by the client, before the
+// prefix of its tenant was stripped, e.g. to verify its signature.
+func requestURI(r *http.Request) string {
+	if uri, ok := r.Context().Value(requestURIKey{}).(string); ok {
+		return uri
+	}
+	return r.URL.RequestURI()
+}
+
+// fromPath returns the tenant of the path of u and a copy of u without it, if
+// u has one.
+func (t *TenantExtraction) fromPath(u *url.URL) (string, *url.URL) {
+	if t.PathPrefix == "" {
+		return "", nil
+	}
+	prefix := strings.TrimSuffix(t.PathPrefix, "/") + "/"
+	path := u.EscapedPath()
+	if !strings.HasPrefix(path, prefix) {
+		return "", nil
+	}
+	rest := path[len(prefix):]
+	segment := rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		segment, rest = rest[:i], rest[i:]
+	} else {
+		rest = "/"
+	}
+	tenant, err := url.PathUnescape(segment)
+	if err != nil || tenant == "" {
+		return "", nil
+	}
+	unescaped, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", nil
+	}
+	stripped := *u
+	stripped.Path, stripped.RawPath = unescaped, ""
+	if rest != unescaped {
+		stripped.RawPath = rest
+	}
+	return tenant, &stripped
+}
+
+// fromHost returns the tenant of the subdomain host, if any.
+func (t *TenantExtraction) fromHost(host string) string {
+	if t.Domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	label := strings.TrimSuffix(host, "."+strings.ToLower(t.Domain))
+	if label == host || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// pairs returns the metadata pairs forwarding the tenant of the request of
+// ctx.
+func (t *TenantExtraction) pairs(ctx context.Context) []string {
+	tenant, ok := Tenant(ctx)
+	if !ok {
+		return nil
+	}
+	return []string{t.MetadataKey, tenant}
+}
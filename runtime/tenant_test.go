@@ -0,0 +1,190 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTenantExtraction(t *testing.T) {
+	for _, spec := range []struct {
+		name   string
+		tenant runtime.TenantExtraction
+		host   string
+		target string
+		header string
+
+		wantTenant string
+		wantPath   string
+		wantCode   int
+	}{
+		{
+			name:       "subdomain",
+			tenant:     runtime.TenantExtraction{Domain: "example.com"},
+			host:       "acme.example.com:8080",
+			target:     "/v1/shelves/a",
+			wantTenant: "acme",
+			wantPath:   "/v1/shelves/a",
+		},
+		{
+			name:     "apex domain",
+			tenant:   runtime.TenantExtraction{Domain: "example.com"},
+			host:     "example.com",
+			target:   "/v1/shelves/a",
+			wantPath: "/v1/shelves/a",
+		},
+		{
+			name:     "nested subdomain",
+			tenant:   runtime.TenantExtraction{Domain: "example.com"},
+			host:     "a.acme.example.com",
+			target:   "/v1/shelves/a",
+			wantPath: "/v1/shelves/a",
+		},
+		{
+			name:       "path prefix",
+			tenant:     runtime.TenantExtraction{PathPrefix: "/tenants"},
+			target:     "/tenants/acme/v1/shelves/a",
+			wantTenant: "acme",
+			wantPath:   "/v1/shelves/a",
+		},
+		{
+			name:       "root path prefix",
+			tenant:     runtime.TenantExtraction{PathPrefix: "/"},
+			target:     "/acme/v1/shelves/a%20b",
+			wantTenant: "acme",
+			wantPath:   "/v1/shelves/a b",
+		},
+		{
+			name:       "path prefix over subdomain",
+			tenant:     runtime.TenantExtraction{Domain: "example.com", PathPrefix: "/tenants/"},
+			host:       "other.example.com",
+			target:     "/tenants/acme/v1/shelves/a",
+			wantTenant: "acme",
+			wantPath:   "/v1/shelves/a",
+		},
+		{
+			name:       "spoofed header",
+			tenant:     runtime.TenantExtraction{Domain: "example.com"},
+			host:       "acme.example.com",
+			target:     "/v1/shelves/a",
+			header:     "other",
+			wantTenant: "acme",
+			wantPath:   "/v1/shelves/a",
+		},
+		{
+			name:     "required",
+			tenant:   runtime.TenantExtraction{PathPrefix: "/tenants", Required: true},
+			target:   "/v1/shelves/a",
+			wantCode: http.StatusNotFound,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			var gotTenant, gotPath string
+			var md metadata.MD
+			mux := runtime.NewServeMux(runtime.WithTenantExtraction(spec.tenant))
+			err := mux.HandlePath("GET", "/v1/shelves/{name}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				gotTenant, _ = runtime.Tenant(r.Context())
+				gotPath = r.URL.Path
+				ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/example.LibraryService/GetShelf")
+				if err != nil {
+					t.Fatalf("runtime.AnnotateContext(ctx, mux, r, method) failed with %v; want success", err)
+				}
+				md, _ = metadata.FromOutgoingContext(ctx)
+			})
+			if err != nil {
+				t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/shelves/{name}", err)
+			}
+
+			r := httptest.NewRequest("GET", spec.target, nil)
+			if spec.host != "" {
+				r.Host = spec.host
+			}
+			if spec.header != "" {
+				r.Header.Set("Grpc-Metadata-X-Tenant-Id", spec.header)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			wantCode := spec.wantCode
+			if wantCode == 0 {
+				wantCode = http.StatusOK
+			}
+			if w.Code != wantCode {
+				t.Fatalf("w.Code = %d; want %d", w.Code, wantCode)
+			}
+			if wantCode != http.StatusOK {
+				return
+			}
+			if gotTenant != spec.wantTenant {
+				t.Errorf("runtime.Tenant(ctx) = %q; want %q", gotTenant, spec.wantTenant)
+			}
+			if gotPath != spec.wantPath {
+				t.Errorf("r.URL.Path = %q; want %q", gotPath, spec.wantPath)
+			}
+			var wantMD []string
+			if spec.wantTenant != "" {
+				wantMD = []string{spec.wantTenant}
+			}
+			if got := md.Get("x-tenant-id"); len(got) != len(wantMD) || len(got) > 0 && got[0] != wantMD[0] {
+				t.Errorf("metadata x-tenant-id = %q; want %q", got, wantMD)
+			}
+		})
+	}
+}
+
+func TestTenantExtractionSignature(t *testing.T) {
+	key := []byte("secret")
+	lookup := func(_ context.Context, keyID string) ([]byte, error) {
+		return key, nil
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithTenantExtraction(runtime.TenantExtraction{PathPrefix: "/tenants"}),
+		runtime.WithSignatureVerification(runtime.SignatureVerification{Key: lookup}),
+	)
+	if err := mux.HandlePath("GET", "/v1/shelves/{name}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {}); err != nil {
+		t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "GET", "/v1/shelves/{name}", err)
+	}
+
+	for _, spec := range []struct {
+		name     string
+		target   string
+		signed   string
+		wantCode int
+	}{
+		{
+			name:     "signed with tenant",
+			target:   "/tenants/acme/v1/shelves/a?view=full",
+			signed:   "/tenants/acme/v1/shelves/a?view=full",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "signed for another tenant",
+			target:   "/tenants/acme/v1/shelves/a",
+			signed:   "/tenants/other/v1/shelves/a",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "signed without tenant",
+			target:   "/v1/shelves/a",
+			signed:   "/v1/shelves/a",
+			wantCode: http.StatusOK,
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			signed := httptest.NewRequest("GET", spec.signed, nil)
+			signRequest(signed, "partner", key, time.Now(), "")
+			r := httptest.NewRequest("GET", spec.target, nil)
+			r.Header = signed.Header
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != spec.wantCode {
+				t.Errorf("w.Code = %d; want %d", w.Code, spec.wantCode)
+			}
+		})
+	}
+}
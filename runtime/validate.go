@@ -0,0 +1,79 @@
+package runtime
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type validatorKey struct{}
+
+// ValidatorFunc validates a decoded request, e.g. with protovalidate.
+type ValidatorFunc func(ctx context.Context, msg proto.Message) error
+
+// WithValidator returns a ServeMuxOption validating the requests of handlers
+// generated with validate=true with the given function, instead of the
+// Validate methods of the request messages.
+func WithValidator(fn ValidatorFunc) ServeMuxOption {
+	return func(serveMux *ServeMux) {
+		serveMux.validator = fn
+	}
+}
+
+// Validate validates a decoded request before it is forwarded. The request is
+// validated with the ValidatorFunc of the ServeMux which annotated the
+// context if there is one, or with the ValidateAll or Validate method
+// generated by protoc-gen-validate otherwise. Requests without either are
+// valid.
+//
+// Status errors are returned as is. Other errors are returned as an
+// InvalidArgument status with a BadRequest detail listing the field
+// violations, read from the Field and Reason methods of protoc-gen-validate
+// errors.
+func Validate(ctx context.Context, msg proto.Message) error {
+	var err error
+	if fn, ok := ctx.Value(validatorKey{}).(ValidatorFunc); ok {
+		err = fn(ctx, msg)
+	} else if v, ok := msg.(interface{ ValidateAll() error }); ok {
+		err = v.ValidateAll()
+	} else if v, ok := msg.(interface{ Validate() error }); ok {
+		err = v.Validate()
+	}
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	errs := []error{err}
+	if multi, ok := err.(interface{ AllErrors() []error }); ok {
+		errs = multi.AllErrors()
+	}
+	br := &errdetails.BadRequest{}
+	for _, e := range errs {
+		v := &errdetails.BadRequest_FieldViolation{Description: e.Error()}
+		if fe, ok := e.(interface {
+			Field() string
+			Reason() string
+		}); ok {
+			v.Field, v.Description = fe.Field(), fe.Reason()
+		}
+		br.FieldViolations = append(br.FieldViolations, v)
+	}
+	s := status.New(codes.InvalidArgument, err.Error())
+	if withDetails, derr := s.WithDetails(br); derr == nil {
+		s = withDetails
+	}
+	return s.Err()
+}
+
+func withValidator(ctx context.Context, fn ValidatorFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, validatorKey{}, fn)
+}
@@ -0,0 +1,899 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The buf.validate rules are read from the serialized options of the
+// descriptors, so neither protovalidate nor the Go code generated for its
+// rules have to be linked into the gateway.
+// https://github.com/bufbuild/protovalidate/blob/main/proto/protovalidate/buf/validate/validate.proto
+const (
+	// validateRulesNumber is the number of the buf.validate extensions of the
+	// field, message and oneof options.
+	validateRulesNumber protowire.Number = 1159
+
+	// Fields of MessageConstraints and OneofConstraints.
+	messageDisabledNumber protowire.Number = 1
+	oneofRequiredNumber   protowire.Number = 1
+
+	// Fields of FieldConstraints.
+	rulesFloatNumber    protowire.Number = 1
+	rulesDoubleNumber   protowire.Number = 2
+	rulesInt32Number    protowire.Number = 3
+	rulesInt64Number    protowire.Number = 4
+	rulesUInt32Number   protowire.Number = 5
+	rulesUInt64Number   protowire.Number = 6
+	rulesSInt32Number   protowire.Number = 7
+	rulesSInt64Number   protowire.Number = 8
+	rulesFixed32Number  protowire.Number = 9
+	rulesFixed64Number  protowire.Number = 10
+	rulesSFixed32Number protowire.Number = 11
+	rulesSFixed64Number protowire.Number = 12
+	rulesBoolNumber     protowire.Number = 13
+	rulesStringNumber   protowire.Number = 14
+	rulesBytesNumber    protowire.Number = 15
+	rulesEnumNumber     protowire.Number = 16
+	rulesRepeatedNumber protowire.Number = 18
+	rulesMapNumber      protowire.Number = 19
+	rulesRequiredNumber protowire.Number = 25
+	rulesIgnoreNumber   protowire.Number = 27
+
+	// Fields of the numeric rules (e.g. Int32Rules) and of BoolRules.
+	numericConstNumber protowire.Number = 1
+	numericLtNumber    protowire.Number = 2
+	numericLteNumber   protowire.Number = 3
+	numericGtNumber    protowire.Number = 4
+	numericGteNumber   protowire.Number = 5
+	numericInNumber    protowire.Number = 6
+	numericNotInNumber protowire.Number = 7
+
+	// Fields of StringRules.
+	stringConstNumber       protowire.Number = 1
+	stringMinLenNumber      protowire.Number = 2
+	stringMaxLenNumber      protowire.Number = 3
+	stringMinBytesNumber    protowire.Number = 4
+	stringMaxBytesNumber    protowire.Number = 5
+	stringPatternNumber     protowire.Number = 6
+	stringPrefixNumber      protowire.Number = 7
+	stringSuffixNumber      protowire.Number = 8
+	stringContainsNumber    protowire.Number = 9
+	stringInNumber          protowire.Number = 10
+	stringNotInNumber       protowire.Number = 11
+	stringEmailNumber       protowire.Number = 12
+	stringHostnameNumber    protowire.Number = 13
+	stringIPNumber          protowire.Number = 14
+	stringIPv4Number        protowire.Number = 15
+	stringIPv6Number        protowire.Number = 16
+	stringURINumber         protowire.Number = 17
+	stringURIRefNumber      protowire.Number = 18
+	stringLenNumber         protowire.Number = 19
+	stringLenBytesNumber    protowire.Number = 20
+	stringAddressNumber     protowire.Number = 21
+	stringUUIDNumber        protowire.Number = 22
+	stringNotContainsNumber protowire.Number = 23
+
+	// Fields of BytesRules.
+	bytesConstNumber    protowire.Number = 1
+	bytesMinLenNumber   protowire.Number = 2
+	bytesMaxLenNumber   protowire.Number = 3
+	bytesPatternNumber  protowire.Number = 4
+	bytesPrefixNumber   protowire.Number = 5
+	bytesSuffixNumber   protowire.Number = 6
+	bytesContainsNumber protowire.Number = 7
+	bytesInNumber       protowire.Number = 8
+	bytesNotInNumber    protowire.Number = 9
+	bytesLenNumber      protowire.Number = 13
+
+	// Fields of EnumRules.
+	enumConstNumber       protowire.Number = 1
+	enumDefinedOnlyNumber protowire.Number = 2
+	enumInNumber          protowire.Number = 3
+	enumNotInNumber       protowire.Number = 4
+
+	// Fields of RepeatedRules.
+	repeatedMinItemsNumber protowire.Number = 1
+	repeatedMaxItemsNumber protowire.Number = 2
+	repeatedUniqueNumber   protowire.Number = 3
+	repeatedItemsNumber    protowire.Number = 4
+
+	// Fields of MapRules.
+	mapMinPairsNumber protowire.Number = 1
+	mapMaxPairsNumber protowire.Number = 2
+	mapKeysNumber     protowire.Number = 4
+	mapValuesNumber   protowire.Number = 5
+)
+
+// Values of the buf.validate.Ignore enum.
+const (
+	ignoreUnspecified = iota
+	ignoreIfUnpopulated
+	ignoreIfDefaultValue
+	ignoreAlways
+)
+
+var (
+	// validateRulesCache maps descriptors to their serialized buf.validate
+	// rules.
+	validateRulesCache sync.Map
+	// validatePatternCache maps the pattern rules to their compiled regexps.
+	validatePatternCache sync.Map
+
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// ValidateRules is a ValidatorFunc evaluating the standard buf.validate
+// (protovalidate) rules of a request and of the messages it contains. The
+// rules are read from the descriptors of the messages, so they apply as well
+// to dynamicpb messages, for which no Validate method is generated.
+//
+// CEL expressions, predefined rules and the rules of the well-known types are
+// not evaluated. Use protovalidate itself with WithValidator for them.
+//
+// The violations are returned as errors with Field and Reason methods, which
+// Validate turns into the field violations of a BadRequest detail:
+//
+//	mux := runtime.NewServeMux(runtime.WithValidator(runtime.ValidateRules))
+func ValidateRules(ctx context.Context, msg proto.Message) error {
+	var violations ruleViolations
+	violations.validateMessage(msg.ProtoReflect(), "")
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// ruleViolation is a buf.validate rule violated by the field at the given
+// path.
+type ruleViolation struct {
+	field, reason string
+}
+
+func (v *ruleViolation) Error() string  { return v.field + ": " + v.reason }
+func (v *ruleViolation) Field() string  { return v.field }
+func (v *ruleViolation) Reason() string { return v.reason }
+
+// ruleViolations are all the rules violated by a request.
+type ruleViolations []error
+
+func (vs ruleViolations) Error() string {
+	msgs := make([]string, 0, len(vs))
+	for _, v := range vs {
+		msgs = append(msgs, v.Error())
+	}
+	return "validation error: " + strings.Join(msgs, "; ")
+}
+
+// AllErrors returns the violations, as the MultiError of protoc-gen-validate.
+func (vs ruleViolations) AllErrors() []error { return vs }
+
+func (vs *ruleViolations) add(field, format string, args ...interface{}) {
+	*vs = append(*vs, &ruleViolation{field: field, reason: fmt.Sprintf(format, args...)})
+}
+
+func (vs *ruleViolations) validateMessage(m protoreflect.Message, prefix string) {
+	md := m.Descriptor()
+	if validateRulesBool(validateRules(md), messageDisabledNumber) {
+		return
+	}
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		od := oneofs.Get(i)
+		if validateRulesBool(validateRules(od), oneofRequiredNumber) && m.WhichOneof(od) == nil {
+			vs.add(prefix+string(od.Name()), "exactly one field is required in oneof")
+		}
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		vs.validateField(m, fields.Get(i), prefix)
+	}
+}
+
+func (vs *ruleViolations) validateField(m protoreflect.Message, fd protoreflect.FieldDescriptor, prefix string) {
+	path := prefix + string(fd.Name())
+	rules := validateRules(fd)
+	ignore := validateRulesVarint(rules, rulesIgnoreNumber)
+	if ignore == ignoreAlways {
+		return
+	}
+	if !m.Has(fd) {
+		if validateRulesBool(rules, rulesRequiredNumber) {
+			vs.add(path, "value is required")
+			return
+		}
+		// Unset fields tracking their presence are not validated, unlike
+		// the zero values of the other fields.
+		if ignore != ignoreUnspecified || hasPresence(fd) {
+			return
+		}
+	}
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		vs.validateList(path, fd, v.List(), rules)
+	case fd.IsMap():
+		vs.validateMap(path, fd, v.Map(), rules)
+	default:
+		vs.validateValue(path, fd, v, rules)
+	}
+}
+
+// validateValue validates a singular value of fd, or an item of a repeated
+// or map field, with the given FieldConstraints.
+func (vs *ruleViolations) validateValue(path string, fd protoreflect.FieldDescriptor, v protoreflect.Value, rules []byte) {
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case rulesFloatNumber, rulesDoubleNumber,
+			rulesInt32Number, rulesInt64Number, rulesUInt32Number, rulesUInt64Number,
+			rulesSInt32Number, rulesSInt64Number, rulesFixed32Number, rulesFixed64Number,
+			rulesSFixed32Number, rulesSFixed64Number:
+			if n := ruleFieldNumber(v); n != nil {
+				vs.validateNumber(path, n, num, b)
+			}
+		case rulesBoolNumber:
+			if fd.Kind() == protoreflect.BoolKind {
+				vs.validateBool(path, v.Bool(), b)
+			}
+		case rulesStringNumber:
+			if fd.Kind() == protoreflect.StringKind {
+				vs.validateString(path, v.String(), b)
+			}
+		case rulesBytesNumber:
+			if fd.Kind() == protoreflect.BytesKind {
+				vs.validateBytes(path, v.Bytes(), b)
+			}
+		case rulesEnumNumber:
+			if fd.Kind() == protoreflect.EnumKind {
+				vs.validateEnum(path, fd.Enum(), v.Enum(), b)
+			}
+		}
+	})
+	if fd.Message() != nil && v.Message().IsValid() {
+		vs.validateMessage(v.Message(), path+".")
+	}
+}
+
+func (vs *ruleViolations) validateNumber(path string, n interface{}, rulesType protowire.Number, rules []byte) {
+	var lt, lte, gt, gte interface{}
+	var in, notIn []interface{}
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		bound, ok := ruleNumber(rulesType, typ, b)
+		if !ok {
+			return
+		}
+		switch num {
+		case numericConstNumber:
+			if compareNumbers(n, bound) != 0 {
+				vs.add(path, "value must equal %v", bound)
+			}
+		case numericLtNumber:
+			lt = bound
+		case numericLteNumber:
+			lte = bound
+		case numericGtNumber:
+			gt = bound
+		case numericGteNumber:
+			gte = bound
+		case numericInNumber:
+			in = append(in, bound)
+		case numericNotInNumber:
+			notIn = append(notIn, bound)
+		}
+	})
+
+	var lower, upper interface{}
+	lowerOK, upperOK := true, true
+	lowerDesc, upperDesc := "", ""
+	switch {
+	case gt != nil:
+		lower, lowerOK, lowerDesc = gt, compareNumbers(n, gt) > 0, fmt.Sprintf("greater than %v", gt)
+	case gte != nil:
+		lower, lowerOK, lowerDesc = gte, compareNumbers(n, gte) >= 0, fmt.Sprintf("greater than or equal to %v", gte)
+	}
+	switch {
+	case lt != nil:
+		upper, upperOK, upperDesc = lt, compareNumbers(n, lt) < 0, fmt.Sprintf("less than %v", lt)
+	case lte != nil:
+		upper, upperOK, upperDesc = lte, compareNumbers(n, lte) <= 0, fmt.Sprintf("less than or equal to %v", lte)
+	}
+	switch {
+	case lower != nil && upper != nil && compareNumbers(lower, upper) > 0:
+		// The bounds exclude the range between them.
+		if !lowerOK && !upperOK {
+			vs.add(path, "value must be %s or %s", lowerDesc, upperDesc)
+		}
+	case lower != nil && upper != nil:
+		if !lowerOK || !upperOK {
+			vs.add(path, "value must be %s and %s", lowerDesc, upperDesc)
+		}
+	case !lowerOK:
+		vs.add(path, "value must be %s", lowerDesc)
+	case !upperOK:
+		vs.add(path, "value must be %s", upperDesc)
+	}
+
+	if len(in) > 0 && !containsNumber(in, n) {
+		vs.add(path, "value must be in list %s", formatRuleList(in))
+	}
+	if containsNumber(notIn, n) {
+		vs.add(path, "value must not be in list %s", formatRuleList(notIn))
+	}
+}
+
+func (vs *ruleViolations) validateBool(path string, v bool, rules []byte) {
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if num == numericConstNumber && typ == protowire.VarintType {
+			if want := ruleVarint(b) != 0; v != want {
+				vs.add(path, "value must equal %t", want)
+			}
+		}
+	})
+}
+
+func (vs *ruleViolations) validateString(path, v string, rules []byte) {
+	var in, notIn []interface{}
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if typ == protowire.BytesType {
+			s := string(b)
+			switch num {
+			case stringConstNumber:
+				if v != s {
+					vs.add(path, "value must equal `%s`", s)
+				}
+			case stringPatternNumber:
+				vs.validatePattern(path, v, s)
+			case stringPrefixNumber:
+				if !strings.HasPrefix(v, s) {
+					vs.add(path, "value does not have prefix `%s`", s)
+				}
+			case stringSuffixNumber:
+				if !strings.HasSuffix(v, s) {
+					vs.add(path, "value does not have suffix `%s`", s)
+				}
+			case stringContainsNumber:
+				if !strings.Contains(v, s) {
+					vs.add(path, "value does not contain substring `%s`", s)
+				}
+			case stringNotContainsNumber:
+				if strings.Contains(v, s) {
+					vs.add(path, "value contains substring `%s`", s)
+				}
+			case stringInNumber:
+				in = append(in, s)
+			case stringNotInNumber:
+				notIn = append(notIn, s)
+			}
+			return
+		}
+		if typ != protowire.VarintType {
+			return
+		}
+		n := ruleVarint(b)
+		runes := uint64(utf8.RuneCountInString(v))
+		switch num {
+		case stringLenNumber:
+			if runes != n {
+				vs.add(path, "value length must be %d characters", n)
+			}
+		case stringMinLenNumber:
+			if runes < n {
+				vs.add(path, "value length must be at least %d characters", n)
+			}
+		case stringMaxLenNumber:
+			if runes > n {
+				vs.add(path, "value length must be at most %d characters", n)
+			}
+		case stringLenBytesNumber:
+			if uint64(len(v)) != n {
+				vs.add(path, "value length must be %d bytes", n)
+			}
+		case stringMinBytesNumber:
+			if uint64(len(v)) < n {
+				vs.add(path, "value length must be at least %d bytes", n)
+			}
+		case stringMaxBytesNumber:
+			if uint64(len(v)) > n {
+				vs.add(path, "value length must be at most %d bytes", n)
+			}
+		default:
+			if n != 0 {
+				vs.validateStringFormat(path, v, num)
+			}
+		}
+	})
+	if len(in) > 0 && !containsString(in, v) {
+		vs.add(path, "value must be in list %s", formatRuleList(in))
+	}
+	if containsString(notIn, v) {
+		vs.add(path, "value must not be in list %s", formatRuleList(notIn))
+	}
+}
+
+// validateStringFormat validates the well-known format of a string set by
+// the rule with the given number.
+func (vs *ruleViolations) validateStringFormat(path, v string, num protowire.Number) {
+	switch num {
+	case stringEmailNumber:
+		if addr, err := mail.ParseAddress(v); err != nil || addr.Address != v {
+			vs.add(path, "value must be a valid email address")
+		}
+	case stringHostnameNumber:
+		if !isHostname(v) {
+			vs.add(path, "value must be a valid hostname")
+		}
+	case stringIPNumber:
+		if net.ParseIP(v) == nil {
+			vs.add(path, "value must be a valid IP address")
+		}
+	case stringIPv4Number:
+		if ip := net.ParseIP(v); ip == nil || ip.To4() == nil {
+			vs.add(path, "value must be a valid IPv4 address")
+		}
+	case stringIPv6Number:
+		if ip := net.ParseIP(v); ip == nil || ip.To4() != nil {
+			vs.add(path, "value must be a valid IPv6 address")
+		}
+	case stringURINumber:
+		if u, err := url.Parse(v); err != nil || !u.IsAbs() {
+			vs.add(path, "value must be a valid URI")
+		}
+	case stringURIRefNumber:
+		if _, err := url.Parse(v); err != nil {
+			vs.add(path, "value must be a valid URI reference")
+		}
+	case stringAddressNumber:
+		if net.ParseIP(v) == nil && !isHostname(v) {
+			vs.add(path, "value must be a valid hostname, or ip address")
+		}
+	case stringUUIDNumber:
+		if !uuidPattern.MatchString(v) {
+			vs.add(path, "value must be a valid UUID")
+		}
+	}
+}
+
+func (vs *ruleViolations) validateBytes(path string, v []byte, rules []byte) {
+	var in, notIn []interface{}
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if typ == protowire.VarintType {
+			n := ruleVarint(b)
+			switch num {
+			case bytesLenNumber:
+				if uint64(len(v)) != n {
+					vs.add(path, "value length must be %d bytes", n)
+				}
+			case bytesMinLenNumber:
+				if uint64(len(v)) < n {
+					vs.add(path, "value length must be at least %d bytes", n)
+				}
+			case bytesMaxLenNumber:
+				if uint64(len(v)) > n {
+					vs.add(path, "value length must be at most %d bytes", n)
+				}
+			}
+			return
+		}
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case bytesConstNumber:
+			if string(v) != string(b) {
+				vs.add(path, "value must be %x", b)
+			}
+		case bytesPatternNumber:
+			if !utf8.Valid(v) {
+				vs.add(path, "value must be valid UTF-8 to apply regexp")
+				return
+			}
+			vs.validatePattern(path, string(v), string(b))
+		case bytesPrefixNumber:
+			if !strings.HasPrefix(string(v), string(b)) {
+				vs.add(path, "value does not have prefix %x", b)
+			}
+		case bytesSuffixNumber:
+			if !strings.HasSuffix(string(v), string(b)) {
+				vs.add(path, "value does not have suffix %x", b)
+			}
+		case bytesContainsNumber:
+			if !strings.Contains(string(v), string(b)) {
+				vs.add(path, "value does not contain %x", b)
+			}
+		case bytesInNumber:
+			in = append(in, string(b))
+		case bytesNotInNumber:
+			notIn = append(notIn, string(b))
+		}
+	})
+	if len(in) > 0 && !containsString(in, string(v)) {
+		vs.add(path, "value must be in list %s", formatRuleList(in))
+	}
+	if containsString(notIn, string(v)) {
+		vs.add(path, "value must not be in list %s", formatRuleList(notIn))
+	}
+}
+
+func (vs *ruleViolations) validateEnum(path string, ed protoreflect.EnumDescriptor, v protoreflect.EnumNumber, rules []byte) {
+	var in, notIn []interface{}
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if typ != protowire.VarintType {
+			return
+		}
+		n := int64(int32(ruleVarint(b)))
+		switch num {
+		case enumConstNumber:
+			if int64(v) != n {
+				vs.add(path, "value must equal %d", n)
+			}
+		case enumDefinedOnlyNumber:
+			if n != 0 && ed.Values().ByNumber(v) == nil {
+				vs.add(path, "value must be one of the defined enum values")
+			}
+		case enumInNumber:
+			in = append(in, n)
+		case enumNotInNumber:
+			notIn = append(notIn, n)
+		}
+	})
+	if len(in) > 0 && !containsNumber(in, int64(v)) {
+		vs.add(path, "value must be in list %s", formatRuleList(in))
+	}
+	if containsNumber(notIn, int64(v)) {
+		vs.add(path, "value must not be in list %s", formatRuleList(notIn))
+	}
+}
+
+func (vs *ruleViolations) validateList(path string, fd protoreflect.FieldDescriptor, list protoreflect.List, rules []byte) {
+	var items []byte
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if num != rulesRepeatedNumber || typ != protowire.BytesType {
+			return
+		}
+		rangeRuleFields(b, func(num protowire.Number, typ protowire.Type, b []byte) {
+			switch {
+			case num == repeatedItemsNumber && typ == protowire.BytesType:
+				items = append(items, b...)
+			case typ != protowire.VarintType:
+			case num == repeatedMinItemsNumber:
+				if n := ruleVarint(b); uint64(list.Len()) < n {
+					vs.add(path, "value must contain at least %d item(s)", n)
+				}
+			case num == repeatedMaxItemsNumber:
+				if n := ruleVarint(b); uint64(list.Len()) > n {
+					vs.add(path, "value must contain no more than %d item(s)", n)
+				}
+			case num == repeatedUniqueNumber:
+				if ruleVarint(b) != 0 && !uniqueItems(list) {
+					vs.add(path, "repeated value must contain unique items")
+				}
+			}
+		})
+	})
+	for i := 0; i < list.Len(); i++ {
+		vs.validateValue(fmt.Sprintf("%s[%d]", path, i), fd, list.Get(i), items)
+	}
+}
+
+func (vs *ruleViolations) validateMap(path string, fd protoreflect.FieldDescriptor, m protoreflect.Map, rules []byte) {
+	var keys, values []byte
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, b []byte) {
+		if num != rulesMapNumber || typ != protowire.BytesType {
+			return
+		}
+		rangeRuleFields(b, func(num protowire.Number, typ protowire.Type, b []byte) {
+			switch {
+			case num == mapKeysNumber && typ == protowire.BytesType:
+				keys = append(keys, b...)
+			case num == mapValuesNumber && typ == protowire.BytesType:
+				values = append(values, b...)
+			case typ != protowire.VarintType:
+			case num == mapMinPairsNumber:
+				if n := ruleVarint(b); uint64(m.Len()) < n {
+					vs.add(path, "map must be at least %d entries", n)
+				}
+			case num == mapMaxPairsNumber:
+				if n := ruleVarint(b); uint64(m.Len()) > n {
+					vs.add(path, "map must be at most %d entries", n)
+				}
+			}
+		})
+	})
+
+	// Sort the entries so that the violations are reported in a stable order.
+	var entries []protoreflect.MapKey
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		entries = append(entries, k)
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return fmt.Sprint(entries[i].Interface()) < fmt.Sprint(entries[j].Interface())
+	})
+	for _, k := range entries {
+		entryPath := fmt.Sprintf("%s[%v]", path, k.Interface())
+		if fd.MapKey().Kind() == protoreflect.StringKind {
+			entryPath = fmt.Sprintf("%s[%q]", path, k.String())
+		}
+		vs.validateValue(entryPath, fd.MapKey(), k.Value(), keys)
+		vs.validateValue(entryPath, fd.MapValue(), m.Get(k), values)
+	}
+}
+
+func (vs *ruleViolations) validatePattern(path, v, pattern string) {
+	var re *regexp.Regexp
+	if cached, ok := validatePatternCache.Load(pattern); ok {
+		re = cached.(*regexp.Regexp)
+	} else {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			vs.add(path, "invalid regex pattern `%s`: %v", pattern, err)
+			return
+		}
+		validatePatternCache.Store(pattern, re)
+	}
+	if !re.MatchString(v) {
+		vs.add(path, "value does not match regex pattern `%s`", pattern)
+	}
+}
+
+// validateRules returns the serialized buf.validate rules of the descriptor
+// d, or nil if it has none.
+func validateRules(d protoreflect.Descriptor) []byte {
+	if rules, ok := validateRulesCache.Load(d); ok {
+		return rules.([]byte)
+	}
+	var rules []byte
+	// The options are serialized again, as the rules are known extensions
+	// if protovalidate is linked into the binary, or unknown fields otherwise.
+	if b, err := proto.Marshal(d.Options()); err == nil {
+		rangeRuleFields(b, func(num protowire.Number, typ protowire.Type, v []byte) {
+			if num == validateRulesNumber && typ == protowire.BytesType {
+				rules = append(rules, v...)
+			}
+		})
+	}
+	validateRulesCache.Store(d, rules)
+	return rules
+}
+
+// validateRulesVarint returns the last value of the varint field with the
+// given number in rules, or 0 if there is none.
+func validateRulesVarint(rules []byte, number protowire.Number) uint64 {
+	var n uint64
+	rangeRuleFields(rules, func(num protowire.Number, typ protowire.Type, v []byte) {
+		if num == number && typ == protowire.VarintType {
+			n = ruleVarint(v)
+		}
+	})
+	return n
+}
+
+func validateRulesBool(rules []byte, number protowire.Number) bool {
+	return validateRulesVarint(rules, number) != 0
+}
+
+// hasPresence determines if the field distinguishes being unset from being
+// set to its zero value.
+func hasPresence(fd protoreflect.FieldDescriptor) bool {
+	if fd.Cardinality() == protoreflect.Repeated {
+		return false
+	}
+	return fd.Message() != nil || fd.ContainingOneof() != nil || fd.Syntax() == protoreflect.Proto2
+}
+
+// rangeRuleFields calls f for every well-formed field of the serialized
+// message b. The value passed to f is the payload of length-delimited fields
+// and the raw encoding of all other fields.
+func rangeRuleFields(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return
+		}
+		v := b[:m]
+		if typ == protowire.BytesType {
+			v, _ = protowire.ConsumeBytes(v)
+		}
+		f(num, typ, v)
+		b = b[m:]
+	}
+}
+
+func ruleVarint(v []byte) uint64 {
+	n, _ := protowire.ConsumeVarint(v)
+	return n
+}
+
+// ruleNumber decodes a value of the numeric rules identified by rulesType as
+// an int64, a uint64 or a float64.
+func ruleNumber(rulesType protowire.Number, typ protowire.Type, v []byte) (interface{}, bool) {
+	switch typ {
+	case protowire.VarintType:
+		n, m := protowire.ConsumeVarint(v)
+		if m < 0 {
+			return nil, false
+		}
+		switch rulesType {
+		case rulesInt32Number:
+			return int64(int32(n)), true
+		case rulesInt64Number:
+			return int64(n), true
+		case rulesSInt32Number, rulesSInt64Number:
+			return protowire.DecodeZigZag(n), true
+		case rulesUInt32Number, rulesUInt64Number:
+			return n, true
+		}
+	case protowire.Fixed32Type:
+		n, m := protowire.ConsumeFixed32(v)
+		if m < 0 {
+			return nil, false
+		}
+		switch rulesType {
+		case rulesFloatNumber:
+			return float64(math.Float32frombits(n)), true
+		case rulesFixed32Number:
+			return uint64(n), true
+		case rulesSFixed32Number:
+			return int64(int32(n)), true
+		}
+	case protowire.Fixed64Type:
+		n, m := protowire.ConsumeFixed64(v)
+		if m < 0 {
+			return nil, false
+		}
+		switch rulesType {
+		case rulesDoubleNumber:
+			return math.Float64frombits(n), true
+		case rulesFixed64Number:
+			return n, true
+		case rulesSFixed64Number:
+			return int64(n), true
+		}
+	}
+	return nil, false
+}
+
+// ruleFieldNumber returns a numeric value as an int64, a uint64 or a float64,
+// or nil if it is not a number.
+func ruleFieldNumber(v protoreflect.Value) interface{} {
+	switch n := v.Interface().(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case uint32:
+		return uint64(n)
+	case uint64:
+		return n
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return nil
+}
+
+// compareNumbers compares two numbers returned by ruleNumber or
+// ruleFieldNumber. Numbers of different types are compared as floats.
+func compareNumbers(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return compareOrdered(a < b, a > b)
+		}
+	case uint64:
+		if b, ok := b.(uint64); ok {
+			return compareOrdered(a < b, a > b)
+		}
+	}
+	x, y := toFloat(a), toFloat(b)
+	return compareOrdered(x < y, x > y)
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(n interface{}) float64 {
+	switch n := n.(type) {
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return math.NaN()
+}
+
+func containsNumber(list []interface{}, n interface{}) bool {
+	for _, item := range list {
+		if compareNumbers(item, n) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []interface{}, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func formatRuleList(list []interface{}) string {
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// uniqueItems determines if the scalar items of list are all different.
+func uniqueItems(list protoreflect.List) bool {
+	seen := make(map[interface{}]bool, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item := list.Get(i).Interface()
+		switch v := item.(type) {
+		case []byte:
+			item = string(v)
+		case protoreflect.Message:
+			// Messages are not comparable, and cannot be unique items.
+			return true
+		}
+		if seen[item] {
+			return false
+		}
+		seen[item] = true
+	}
+	return true
+}
+
+// isHostname determines if s is a valid hostname as defined by RFC 1034.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
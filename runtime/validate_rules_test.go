@@ -0,0 +1,197 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ruleMessage encodes a buf.validate rules message field.
+func ruleMessage(num protowire.Number, fields ...[]byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, bytes.Join(fields, nil))
+}
+
+func ruleVarint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func ruleString(num protowire.Number, s string) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// fieldRules returns field options with the given buf.validate
+// FieldConstraints fields, as unknown fields.
+func fieldRules(fields ...[]byte) *descriptorpb.FieldOptions {
+	opts := &descriptorpb.FieldOptions{}
+	opts.ProtoReflect().SetUnknown(ruleMessage(1159, fields...))
+	return opts
+}
+
+// newValidatedMessage returns a dynamic message whose fields have
+// buf.validate rules.
+func newValidatedMessage(t *testing.T) *dynamicpb.Message {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("validated.proto"),
+		Package: proto.String("example"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("ValidatedMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("name"),
+					JsonName: proto.String("name"),
+					Number:   proto.Int32(1),
+					Label:    optional,
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					// string: {min_len: 3, pattern: "^[a-z]+$"}
+					Options: fieldRules(ruleMessage(14, ruleVarint(2, 3), ruleString(6, "^[a-z]+$"))),
+				},
+				{
+					Name:     proto.String("age"),
+					JsonName: proto.String("age"),
+					Number:   proto.Int32(2),
+					Label:    optional,
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+					// int32: {gte: 0, lt: 150}
+					Options: fieldRules(ruleMessage(3, ruleVarint(5, 0), ruleVarint(2, 150))),
+				},
+				{
+					Name:     proto.String("email"),
+					JsonName: proto.String("email"),
+					Number:   proto.Int32(3),
+					Label:    optional,
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					// required: true, string: {email: true}
+					Options: fieldRules(ruleVarint(25, 1), ruleMessage(14, ruleVarint(12, 1))),
+				},
+				{
+					Name:     proto.String("tags"),
+					JsonName: proto.String("tags"),
+					Number:   proto.Int32(4),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					// repeated: {max_items: 2, unique: true, items: {string: {min_len: 1}}}
+					Options: fieldRules(ruleMessage(18, ruleVarint(2, 2), ruleVarint(3, 1), ruleMessage(4, ruleMessage(14, ruleVarint(2, 1))))),
+				},
+				{
+					Name:           proto.String("nickname"),
+					JsonName:       proto.String("nickname"),
+					Number:         proto.Int32(5),
+					Label:          optional,
+					Type:           descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					OneofIndex:     proto.Int32(0),
+					Proto3Optional: proto.Bool(true),
+					// string: {min_len: 2}
+					Options: fieldRules(ruleMessage(14, ruleVarint(2, 2))),
+				},
+			},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{
+				{Name: proto.String("_nickname")},
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, new(protoregistry.Files))
+	if err != nil {
+		t.Fatalf("protodesc.NewFile(%v) failed with %v; want success", fd, err)
+	}
+	return dynamicpb.NewMessage(file.Messages().Get(0))
+}
+
+func setValidatedFields(msg *dynamicpb.Message, name string, age int32, email string, tags ...string) {
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("name"), protoreflect.ValueOfString(name))
+	msg.Set(fields.ByName("age"), protoreflect.ValueOfInt32(age))
+	if email != "" {
+		msg.Set(fields.ByName("email"), protoreflect.ValueOfString(email))
+	}
+	list := msg.Mutable(fields.ByName("tags")).List()
+	for _, tag := range tags {
+		list.Append(protoreflect.ValueOfString(tag))
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	msg := newValidatedMessage(t)
+	setValidatedFields(msg, "alice", 30, "alice@example.com", "admin")
+	if err := runtime.ValidateRules(context.Background(), msg); err != nil {
+		t.Errorf("runtime.ValidateRules(ctx, %v) = %v; want nil", msg, err)
+	}
+}
+
+func TestValidateRulesViolations(t *testing.T) {
+	request, err := http.NewRequest("POST", "http://www.example.com", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "POST", "http://www.example.com", err)
+	}
+	mux := runtime.NewServeMux(runtime.WithValidator(runtime.ValidateRules))
+	ctx, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.ExampleService/Example")
+	if err != nil {
+		t.Fatalf("runtime.AnnotateContext() failed with %v; want success", err)
+	}
+
+	msg := newValidatedMessage(t)
+	setValidatedFields(msg, "Al", 150, "", "a", "a", "")
+	err = runtime.Validate(ctx, msg)
+	s, _ := status.FromError(err)
+	if s.Code() != codes.InvalidArgument {
+		t.Fatalf("runtime.Validate(ctx, %v) = %v; want code %v", msg, err, codes.InvalidArgument)
+	}
+	want := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "name", Description: "value length must be at least 3 characters"},
+		{Field: "name", Description: "value does not match regex pattern `^[a-z]+$`"},
+		{Field: "age", Description: "value must be greater than or equal to 0 and less than 150"},
+		{Field: "email", Description: "value is required"},
+		{Field: "tags", Description: "value must contain no more than 2 item(s)"},
+		{Field: "tags", Description: "repeated value must contain unique items"},
+		{Field: "tags[2]", Description: "value length must be at least 1 characters"},
+	}}
+	details := s.Details()
+	if len(details) != 1 {
+		t.Fatalf("runtime.Validate(ctx, %v) details = %v; want one BadRequest", msg, details)
+	}
+	if diff := cmp.Diff(details[0], want, protocmp.Transform()); diff != "" {
+		t.Errorf("runtime.Validate(ctx, %v) details = %v; diff: %s", msg, details[0], diff)
+	}
+}
+
+func TestValidateRulesFormats(t *testing.T) {
+	msg := newValidatedMessage(t)
+	setValidatedFields(msg, "alice", 30, "not an email")
+	msg.Set(msg.Descriptor().Fields().ByName("nickname"), protoreflect.ValueOfString("a"))
+
+	err := runtime.ValidateRules(context.Background(), msg)
+	multi, ok := err.(interface{ AllErrors() []error })
+	if !ok {
+		t.Fatalf("runtime.ValidateRules(ctx, %v) = %v; want violations", msg, err)
+	}
+	var got []string
+	for _, e := range multi.AllErrors() {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		"email: value must be a valid email address",
+		"nickname: value length must be at least 2 characters",
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("runtime.ValidateRules(ctx, %v) = %q; want %q", msg, got, want)
+	}
+}
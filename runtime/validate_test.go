@@ -0,0 +1,113 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// fieldError mimics the validation errors generated by protoc-gen-validate.
+type fieldError struct{ field, reason string }
+
+func (e fieldError) Error() string  { return e.field + ": " + e.reason }
+func (e fieldError) Field() string  { return e.field }
+func (e fieldError) Reason() string { return e.reason }
+
+// multiError mimics the MultiError returned by ValidateAll.
+type multiError []error
+
+func (m multiError) Error() string      { return "invalid request" }
+func (m multiError) AllErrors() []error { return m }
+
+func TestValidate(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://www.example.com", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q, nil) failed with %v; want success", "GET", "http://www.example.com", err)
+	}
+	msg := &examplepb.Proto3Message{}
+
+	for _, spec := range []struct {
+		name           string
+		err            error
+		wantCode       codes.Code
+		wantBadRequest *errdetails.BadRequest
+	}{
+		{
+			name:     "valid",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "status error",
+			err:      status.Error(codes.FailedPrecondition, "not now"),
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("bad request"),
+			wantCode: codes.InvalidArgument,
+			wantBadRequest: &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Description: "bad request"},
+			}},
+		},
+		{
+			name: "field errors",
+			err: multiError{
+				fieldError{field: "string_value", reason: "value is required"},
+				fieldError{field: "int32_value", reason: "value must be positive"},
+			},
+			wantCode: codes.InvalidArgument,
+			wantBadRequest: &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "string_value", Description: "value is required"},
+				{Field: "int32_value", Description: "value must be positive"},
+			}},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			var validated proto.Message
+			mux := runtime.NewServeMux(runtime.WithValidator(func(ctx context.Context, m proto.Message) error {
+				validated = m
+				return spec.err
+			}))
+			ctx, err := runtime.AnnotateContext(context.Background(), mux, request, "/example.ExampleService/Example")
+			if err != nil {
+				t.Fatalf("runtime.AnnotateContext() failed with %v; want success", err)
+			}
+
+			err = runtime.Validate(ctx, msg)
+			if validated != msg {
+				t.Errorf("runtime.Validate(ctx, %v) validated %v; want %v", msg, validated, msg)
+			}
+			s, _ := status.FromError(err)
+			if s.Code() != spec.wantCode {
+				t.Fatalf("runtime.Validate(ctx, %v) = %v; want code %v", msg, err, spec.wantCode)
+			}
+			if spec.wantBadRequest == nil {
+				return
+			}
+			details := s.Details()
+			if len(details) != 1 {
+				t.Fatalf("runtime.Validate(ctx, %v) details = %v; want one BadRequest", msg, details)
+			}
+			if diff := cmp.Diff(details[0], spec.wantBadRequest, protocmp.Transform()); diff != "" {
+				t.Errorf("runtime.Validate(ctx, %v) details = %v; diff: %s", msg, details[0], diff)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutValidator(t *testing.T) {
+	msg := &examplepb.Proto3Message{}
+	if err := runtime.Validate(context.Background(), msg); err != nil {
+		t.Errorf("runtime.Validate(ctx, %v) = %v; want nil for a message without Validate", msg, err)
+	}
+}
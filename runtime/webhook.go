@@ -0,0 +1,214 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WebhookTransformFunc sets msg, the request message of the RPC a webhook is
+// forwarded to, from the payload and the headers of the webhook request r.
+// The errors which are not gRPC statuses are replied to as InvalidArgument.
+type WebhookTransformFunc func(r *http.Request, payload []byte, msg proto.Message) error
+
+// WebhookAdapter maps the webhooks a third party, e.g. Stripe or GitHub,
+// sends to a route to the request messages of its RPC, registered with
+// WithWebhookAdapter.
+type WebhookAdapter struct {
+	// Route is the path template of the route the webhooks are sent to, e.g.
+	// "/v1/webhooks/github", as given to HandlePath or in the http rules.
+	Route string
+	// NewMessage returns a new request message of the RPC of the route.
+	NewMessage func() proto.Message
+	// Transform sets the request messages from the webhooks, e.g. the one
+	// returned by MapWebhookFields.
+	Transform WebhookTransformFunc
+}
+
+// WithWebhookAdapter returns a ServeMuxOption terminating the webhooks of
+// adapter onto the typed RPC of its route, whose request message has
+// otherwise to match the payloads of the third party.
+//
+// The bodies of the requests to the route are replaced by the request
+// messages adapter sets from them, marshaled with the inbound marshaler of
+// the requests, before the handler of the route unmarshals them. The
+// signatures of WithSignatureVerification are verified over the original
+// payloads, and the bodies of WithBodyDecoder are decoded before they are
+// transformed. It can be given once per route.
+//
+// WithWebhookAdapter panics if the route of adapter is not a valid path
+// template.
+func WithWebhookAdapter(adapter WebhookAdapter) ServeMuxOption {
+	pattern, err := normalizeRoute(adapter.Route)
+	if err != nil {
+		panic(fmt.Sprintf("runtime: invalid webhook adapter route %q: %v", adapter.Route, err))
+	}
+	return func(serveMux *ServeMux) {
+		if serveMux.webhooks == nil {
+			serveMux.webhooks = make(map[string]*WebhookAdapter)
+		}
+		serveMux.webhooks[pattern] = &adapter
+	}
+}
+
+// MapWebhookFields returns a WebhookTransformFunc setting the fields of the
+// request messages at the field paths keyed by fields, e.g.
+// "repository.name", to the values of the JSON payloads at their sources,
+// for the adapters needing no code. A source is either the field path of a
+// value of the payloads, e.g. "repository.full_name", "." for the whole
+// payload, e.g. for a google.protobuf.Struct field, or "header:" followed by
+// the name of a header of the requests, e.g. "header:X-GitHub-Event".
+//
+// The values are converted to the types of the fields as protojson does,
+// except that the numbers and booleans are converted to the string fields,
+// and the sources missing from a webhook or null leave their fields unset.
+func MapWebhookFields(fields map[string]string) WebhookTransformFunc {
+	targets := make([]string, 0, len(fields))
+	for target := range fields {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return func(r *http.Request, payload []byte, msg proto.Message) error {
+		var doc interface{}
+		if len(bytes.TrimSpace(payload)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(payload))
+			dec.UseNumber()
+			if err := dec.Decode(&doc); err != nil {
+				return fmt.Errorf("invalid JSON payload: %w", err)
+			}
+		}
+		fieldsByName := msg.ProtoReflect().Descriptor().Fields()
+		out := make(map[string]interface{})
+		for _, target := range targets {
+			v, ok := webhookValue(r, doc, fields[target])
+			if !ok {
+				continue
+			}
+			if err := setWebhookField(out, fieldsByName, target, v); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+		return protojson.Unmarshal(b, msg)
+	}
+}
+
+// webhookValue returns the value of the source of MapWebhookFields in the
+// payload doc of r, or false if it is missing or null.
+func webhookValue(r *http.Request, doc interface{}, source string) (interface{}, bool) {
+	if strings.HasPrefix(source, "header:") {
+		values := r.Header.Values(strings.TrimPrefix(source, "header:"))
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	}
+	v := doc
+	if source != "." {
+		for _, name := range strings.Split(source, ".") {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[name]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return v, v != nil
+}
+
+// setWebhookField sets the field path of the JSON object out of the message
+// whose fields are fields to v.
+func setWebhookField(out map[string]interface{}, fields protoreflect.FieldDescriptors, path string, v interface{}) error {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := fields.ByJSONName(name)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(name))
+		}
+		if fd == nil {
+			return fmt.Errorf("no field %q in the request message", path)
+		}
+		key := fd.JSONName()
+		if i == len(names)-1 {
+			if _, ok := out[key]; ok {
+				return fmt.Errorf("field %q is set twice", path)
+			}
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				switch s := v.(type) {
+				case json.Number:
+					v = s.String()
+				case bool:
+					v = strconv.FormatBool(s)
+				}
+			}
+			out[key] = v
+			return nil
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("field %q is not a singular message", strings.Join(names[:i+1], "."))
+		}
+		next, ok := out[key].(map[string]interface{})
+		if !ok {
+			if _, set := out[key]; set {
+				return fmt.Errorf("field %q is set twice", strings.Join(names[:i+1], "."))
+			}
+			next = make(map[string]interface{})
+			out[key] = next
+		}
+		out, fields = next, fd.Message().Fields()
+	}
+	return nil
+}
+
+// adaptWebhook replies with an error and returns false if the webhook r to
+// the route pat cannot be transformed by its adapter. It returns r with the
+// body its handler unmarshals otherwise.
+func (s *ServeMux) adaptWebhook(w http.ResponseWriter, r *http.Request, pat Pattern) (*http.Request, bool) {
+	adapter, ok := s.webhooks[pat.String()]
+	if !ok {
+		return r, true
+	}
+	inboundMarshaler, outboundMarshaler := MarshalerForRequest(s, r)
+	msg := adapter.NewMessage()
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "reading webhook payload: %v", err)
+	} else if err = adapter.Transform(r, payload, msg); err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Errorf(codes.InvalidArgument, "invalid webhook: %v", err)
+		}
+	}
+	var body []byte
+	if err == nil {
+		if body, err = inboundMarshaler.Marshal(msg); err != nil {
+			err = status.Errorf(codes.Internal, "marshaling webhook: %v", err)
+		}
+	}
+	if err != nil {
+		HTTPError(r.Context(), s, outboundMarshaler, w, r, err)
+		return r, false
+	}
+
+	r2 := *r
+	r2.Header = r.Header.Clone()
+	r2.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	r2.ContentLength = int64(len(body))
+	r2.Body = decodedBody{Reader: bytes.NewReader(body), Closer: r.Body}
+	return &r2, true
+}
@@ -0,0 +1,156 @@
+package runtime_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime/internal/examplepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithWebhookAdapter(t *testing.T) {
+	newMessage := func() proto.Message { return &examplepb.ABitOfEverything{} }
+	mux := runtime.NewServeMux(
+		runtime.WithWebhookAdapter(runtime.WebhookAdapter{
+			Route:      "/v1/webhooks/github",
+			NewMessage: newMessage,
+			Transform: runtime.MapWebhookFields(map[string]string{
+				"uuid":                 "header:X-GitHub-Delivery",
+				"string_value":         "action",
+				"singleNested.name":    "repository.full_name",
+				"single_nested.amount": "repository.stargazers_count",
+				"bool_value":           "repository.private",
+				"int64_value":          "repository.id",
+				"float_value":          "missing.field",
+			}),
+		}),
+		runtime.WithWebhookAdapter(runtime.WebhookAdapter{
+			Route:      "/v1/webhooks/{provider}",
+			NewMessage: newMessage,
+			Transform: func(r *http.Request, payload []byte, msg proto.Message) error {
+				if r.Header.Get("X-Event") == "ping" {
+					return status.Error(codes.Unimplemented, "ping")
+				}
+				msg.(*examplepb.ABitOfEverything).StringValue = string(payload)
+				return nil
+			},
+		}),
+		runtime.WithWebhookAdapter(runtime.WebhookAdapter{
+			Route:      "/v1/webhooks/unknown",
+			NewMessage: newMessage,
+			Transform:  runtime.MapWebhookFields(map[string]string{"unknown": "id"}),
+		}),
+	)
+	var got *examplepb.ABitOfEverything
+	// The routes registered last are matched first.
+	for _, route := range []string{"/v1/webhooks/{provider}", "/v1/webhooks/github", "/v1/webhooks/unknown", "/v1/orders"} {
+		if err := mux.HandlePath("POST", route, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			got = &examplepb.ABitOfEverything{}
+			if err := (&runtime.JSONPb{}).NewDecoder(r.Body).Decode(got); err != nil {
+				t.Errorf("Decode(r.Body) failed with %v; want success", err)
+			}
+		}); err != nil {
+			t.Fatalf("mux.HandlePath(%q, %q, h) failed with %v; want success", "POST", route, err)
+		}
+	}
+
+	for _, spec := range []struct {
+		name     string
+		target   string
+		body     string
+		headers  map[string]string
+		wantCode int
+		want     *examplepb.ABitOfEverything
+	}{
+		{
+			name:   "mapped fields",
+			target: "/v1/webhooks/github",
+			body: `{"action": "starred", "repository": {"id": 1296269, "full_name": "octocat/Hello-World",
+				"private": false, "stargazers_count": 80}}`,
+			headers:  map[string]string{"X-GitHub-Delivery": "72d3162e"},
+			wantCode: http.StatusOK,
+			want: &examplepb.ABitOfEverything{
+				Uuid:         "72d3162e",
+				StringValue:  "starred",
+				SingleNested: &examplepb.ABitOfEverything_Nested{Name: "octocat/Hello-World", Amount: 80},
+				Int64Value:   1296269,
+			},
+		},
+		{
+			name:     "number to string",
+			target:   "/v1/webhooks/github",
+			body:     `{"action": 3}`,
+			wantCode: http.StatusOK,
+			want:     &examplepb.ABitOfEverything{StringValue: "3"},
+		},
+		{
+			name:     "transform",
+			target:   "/v1/webhooks/stripe",
+			body:     `{"type": "charge.succeeded"}`,
+			wantCode: http.StatusOK,
+			want:     &examplepb.ABitOfEverything{StringValue: `{"type": "charge.succeeded"}`},
+		},
+		{
+			name:     "transform status",
+			target:   "/v1/webhooks/stripe",
+			headers:  map[string]string{"X-Event": "ping"},
+			wantCode: http.StatusNotImplemented,
+		},
+		{
+			name:     "invalid payload",
+			target:   "/v1/webhooks/github",
+			body:     `{"action":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid value",
+			target:   "/v1/webhooks/github",
+			body:     `{"repository": {"id": "foo"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown field",
+			target:   "/v1/webhooks/unknown",
+			body:     `{"id": "foo"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "other route",
+			target:   "/v1/orders",
+			body:     `{"uuid": "foo"}`,
+			wantCode: http.StatusOK,
+			want:     &examplepb.ABitOfEverything{Uuid: "foo"},
+		},
+	} {
+		t.Run(spec.name, func(t *testing.T) {
+			got = nil
+			r := httptest.NewRequest("POST", spec.target, strings.NewReader(spec.body))
+			r.Header.Set("Content-Type", "application/json")
+			for k, v := range spec.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != spec.wantCode {
+				body, _ := ioutil.ReadAll(w.Body)
+				t.Fatalf("w.Code = %d; want %d; body = %s", w.Code, spec.wantCode, body)
+			}
+			if spec.want == nil {
+				if got != nil {
+					t.Errorf("handler called with %v; want no call", got)
+				}
+				return
+			}
+			if !proto.Equal(got, spec.want) {
+				t.Errorf("got %v; want %v", got, spec.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package runtime
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The opcodes of the WebSocket frames, see RFC 6455, section 5.2.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// The status codes of the WebSocket close frames, see RFC 6455, section 7.4.
+const (
+	wsCloseNormal          = 1000
+	wsCloseProtocolError   = 1002
+	wsCloseUnsupportedData = 1003
+	wsCloseInvalidData     = 1007
+	wsCloseTooBig          = 1009
+)
+
+// wsAcceptGUID is appended to the keys of the WebSocket handshakes.
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// errWebSocketClosed is returned by wsConn.readMessage once the client closed
+// the connection.
+var errWebSocketClosed = errors.New("websocket closed")
+
+// wsCloseError is returned by wsConn.readMessage when a message of the
+// client violates the protocol, and closes the connection with code.
+type wsCloseError struct {
+	code int
+	text string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket: %s", e.text)
+}
+
+// isWebSocketUpgrade returns whether r is a WebSocket opening handshake.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return r.Method == "GET" && headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, v := range h[key] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is the server side of a WebSocket connection, whose messages are
+// read by a single goroutine and written by any.
+type wsConn struct {
+	conn           net.Conn
+	r              *bufio.Reader
+	maxMessageSize int64
+
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+// upgradeWebSocket completes the WebSocket opening handshake r and returns the
+// connection hijacked from w. It returns an error, without replying, if r is
+// not a valid handshake.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, maxMessageSize int64) (*wsConn, error) {
+	if !isWebSocketUpgrade(r) {
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		return nil, errors.New("missing websocket key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("hijacking not supported in %T", w)
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	h.Write([]byte(key + wsAcceptGUID))
+	accept := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: brw.Reader, w: brw.Writer, maxMessageSize: maxMessageSize}, nil
+}
+
+// readMessage returns the opcode and the payload of the next data message of
+// the client, answering its pings. It returns errWebSocketClosed once the
+// client closed the connection.
+func (c *wsConn) readMessage() (int, []byte, error) {
+	var opcode int
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsClose:
+			code := wsCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.close(code, "")
+			return 0, nil, errWebSocketClosed
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsContinuation:
+			if message == nil {
+				return 0, nil, &wsCloseError{code: wsCloseProtocolError, text: "unexpected continuation frame"}
+			}
+		case wsText, wsBinary:
+			if message != nil {
+				return 0, nil, &wsCloseError{code: wsCloseProtocolError, text: "unfinished fragmented message"}
+			}
+			opcode = op
+			message = []byte{}
+		default:
+			return 0, nil, &wsCloseError{code: wsCloseProtocolError, text: fmt.Sprintf("unknown opcode %d", op)}
+		}
+		if int64(len(message)+len(payload)) > c.maxMessageSize {
+			return 0, nil, &wsCloseError{code: wsCloseTooBig, text: "message too big"}
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame reads the next frame of the client and unmasks its payload.
+func (c *wsConn) readFrame() (bool, int, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op := head[0]&0x80 != 0, int(head[0]&0x0f)
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, text: "unexpected reserved bits"}
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, text: "unmasked client frame"}
+	}
+	n := int64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if op >= wsClose && (n > 125 || !fin) {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, text: "invalid control frame"}
+	}
+	if n < 0 || n > c.maxMessageSize {
+		return false, 0, nil, &wsCloseError{code: wsCloseTooBig, text: "message too big"}
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes payload to the client as a single unmasked frame.
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.WriteByte(0x80 | byte(op))
+	switch n := len(payload); {
+	case n <= 125:
+		c.w.WriteByte(byte(n))
+	case n <= 0xffff:
+		c.w.WriteByte(126)
+		var ext [2]byte
+		binary.BigEndian.PutUint16(ext[:], uint16(n))
+		c.w.Write(ext[:])
+	default:
+		c.w.WriteByte(127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		c.w.Write(ext[:])
+	}
+	c.w.Write(payload)
+	return c.w.Flush()
+}
+
+// close writes a close frame with code and text to the client and closes the
+// connection.
+func (c *wsConn) close(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	err := c.writeFrame(wsClose, payload)
+	if cerr := c.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}